@@ -15,6 +15,8 @@ limitations under the License.
 package v1alpha1
 
 import (
+	clusterV1alpha1 "github.com/tmax-cloud/hypercloud-multi-operator/apis/cluster/v1alpha1"
+
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 )
@@ -41,6 +43,12 @@ const (
 	ClusterClaimDeprecatedPhaseClusterDeleted = ClusterClaimPhase("ClusterDeleted")
 )
 
+const (
+	// 승인 과정에서 target namespace 를 생성한 claim 을 namespace 에 label 로 기록한다.
+	LabelKeyProvisionedByClaim          = "clusterclaim.claim.tmax.io/name"
+	LabelKeyProvisionedByClaimNamespace = "clusterclaim.claim.tmax.io/namespace"
+)
+
 // ClusterClaimSpec defines the desired state of ClusterClaim
 type ClusterClaimSpec struct {
 	// +kubebuilder:validation:Required
@@ -50,10 +58,9 @@ type ClusterClaimSpec struct {
 	// +kubebuilder:validation:Pattern:=^v[0-9].[0-9]+.[0-9]+
 	// The version of kubernetes. Example: v1.19.6
 	Version string `json:"version"`
-	// +kubebuilder:validation:Required
 	// +kubebuilder:validation:Enum:=AWS;vSphere
-	// The type of provider.
-	Provider string `json:"provider"`
+	// The type of provider. If empty, the provider and region are selected by PlacementPolicy.
+	Provider string `json:"provider,omitempty"`
 	// +kubebuilder:validation:Required
 	// +kubebuilder:validation:Minimum:=1
 	// The number of master node. Example: 3
@@ -66,6 +73,12 @@ type ClusterClaimSpec struct {
 	ProviderAwsSpec AwsClaimSpec `json:"providerAwsSpec,omitempty"`
 	// Provider vSphere Spec.
 	ProviderVsphereSpec VsphereClaimSpec `json:"providerVsphereSpec,omitempty"`
+	// The namespace to create the cluster in. Defaults to the namespace of the ClusterClaim.
+	// If the namespace does not exist, it is created on approval when namespace provisioning is enabled.
+	TargetNamespace string `json:"targetNamespace,omitempty"`
+	// The pod and service networks of the cluster. Set both IPv4 and IPv6 cidrs for a dual-stack cluster.
+	// If podCidrs is set, it takes precedence over providerVsphereSpec.podCidr.
+	Network *clusterV1alpha1.ClusterNetwork `json:"network,omitempty"`
 }
 
 type AwsClaimSpec struct {
@@ -121,6 +134,38 @@ type VsphereClaimSpec struct {
 	VMPassword string `json:"vmPassword,omitempty"`
 }
 
+// ClusterClaimPlacement defines the placement decision of ClusterClaim
+type ClusterClaimPlacement struct {
+	// The namespace of the placement policy.
+	PolicyNamespace string `json:"policyNamespace"`
+	// The name of the placement policy.
+	Policy string `json:"policy"`
+	// The name of the selected target.
+	Target string `json:"target"`
+	// The provider of the selected target.
+	Provider string `json:"provider"`
+	// The AWS region of the selected target.
+	Region string `json:"region,omitempty"`
+	// The vCenter of the selected target.
+	ProviderVsphereSpec *VspherePlacementSpec `json:"providerVsphereSpec,omitempty"`
+	// The reason why the target is selected.
+	Reason string `json:"reason,omitempty"`
+	// The time when the target is selected.
+	PlacedTime metav1.Time `json:"placedTime,omitempty"`
+}
+
+// ClaimReview defines who approved or rejected the claim
+type ClaimReview struct {
+	// The user who approved or rejected the claim.
+	Reviewer string `json:"reviewer"`
+	// The groups of the reviewer.
+	Groups []string `json:"groups,omitempty"`
+	// The phase decided by the reviewer. Approved or Rejected.
+	Decision string `json:"decision"`
+	// The time when the claim is reviewed.
+	ReviewedTime metav1.Time `json:"reviewedTime,omitempty"`
+}
+
 // ClusterClaimStatus defines the observed state of ClusterClaim
 type ClusterClaimStatus struct {
 	Message string `json:"message,omitempty" protobuf:"bytes,2,opt,name=message"`
@@ -128,6 +173,10 @@ type ClusterClaimStatus struct {
 
 	// +kubebuilder:validation:Enum=Awaiting;Admitted;Approved;Rejected;Error;ClusterDeleted;Cluster Deleted;
 	Phase ClusterClaimPhase `json:"phase,omitempty" protobuf:"bytes,4,opt,name=phase"`
+	// The placement decision. Set only when the provider is not specified.
+	Placement *ClusterClaimPlacement `json:"placement,omitempty"`
+	// The user who approved or rejected the claim. Set by the admission webhook.
+	Review *ClaimReview `json:"review,omitempty"`
 }
 
 // +kubebuilder:object:root=true
@@ -135,6 +184,7 @@ type ClusterClaimStatus struct {
 // +kubebuilder:resource:path=clusterclaims,shortName=cc,scope=Namespaced
 // +kubebuilder:printcolumn:name="Status",type=string,JSONPath=`.status.phase`
 // +kubebuilder:printcolumn:name="Reason",type=string,JSONPath=`.status.reason`
+// +kubebuilder:printcolumn:name="Reviewer",type=string,JSONPath=`.status.review.reviewer`,priority=1
 // +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
 // ClusterClaim is the Schema for the clusterclaims API
 type ClusterClaim struct {
@@ -175,6 +225,49 @@ func (c *ClusterClaim) GetNamespacedName() types.NamespacedName {
 func (c *ClusterClaim) GetClusterManagerNamespacedName() types.NamespacedName {
 	return types.NamespacedName{
 		Name:      c.Spec.ClusterName,
-		Namespace: c.Namespace,
+		Namespace: c.GetTargetNamespace(),
+	}
+}
+
+// cluster manager 를 생성할 namespace, 지정하지 않으면 claim 의 namespace 이다.
+func (c *ClusterClaim) GetTargetNamespace() string {
+	if c.Spec.TargetNamespace != "" {
+		return c.Spec.TargetNamespace
+	}
+	return c.Namespace
+}
+
+// provider 가 지정되지 않았고 아직 배치되지 않은 경우 placement 가 필요하다.
+func (c *ClusterClaim) NeedsPlacement() bool {
+	return c.Spec.Provider == "" && c.Status.Placement == nil
+}
+
+// placement 결과를 spec 에 반영한다. claim 에 지정된 값이 placement 결과보다 우선한다.
+func (c *ClusterClaim) ApplyPlacement() {
+	placement := c.Status.Placement
+	if c.Spec.Provider != "" || placement == nil {
+		return
+	}
+
+	c.Spec.Provider = placement.Provider
+	if c.Spec.ProviderAwsSpec.Region == "" {
+		c.Spec.ProviderAwsSpec.Region = placement.Region
+	}
+	if vsphere := placement.ProviderVsphereSpec; vsphere != nil {
+		spec := &c.Spec.ProviderVsphereSpec
+		setIfEmpty(&spec.VcenterIp, vsphere.VcenterIp)
+		setIfEmpty(&spec.VcenterThumbprint, vsphere.VcenterThumbprint)
+		setIfEmpty(&spec.VcenterNetwork, vsphere.VcenterNetwork)
+		setIfEmpty(&spec.VcenterDataCenter, vsphere.VcenterDataCenter)
+		setIfEmpty(&spec.VcenterDataStore, vsphere.VcenterDataStore)
+		setIfEmpty(&spec.VcenterFolder, vsphere.VcenterFolder)
+		setIfEmpty(&spec.VcenterResourcePool, vsphere.VcenterResourcePool)
+		setIfEmpty(&spec.VcenterTemplate, vsphere.VcenterTemplate)
+	}
+}
+
+func setIfEmpty(target *string, value string) {
+	if *target == "" {
+		*target = value
 	}
 }
@@ -21,8 +21,11 @@ import (
 	"strconv"
 	"strings"
 
+	"github.com/tmax-cloud/hypercloud-multi-operator/controllers/metrics"
+
 	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation"
 	"k8s.io/apimachinery/pkg/util/validation/field"
 	ctrl "sigs.k8s.io/controller-runtime"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
@@ -66,6 +69,10 @@ var _ webhook.Validator = &ClusterClaim{}
 
 // ValidateCreate implements webhook.Validator so a webhook will be registered for the type
 func (r *ClusterClaim) ValidateCreate() error {
+	return metrics.RecordWebhookRejection("ClusterClaim", "create", r.validateCreate())
+}
+
+func (r *ClusterClaim) validateCreate() error {
 	ClusterClaimWebhookLogger.Info("validate create", "name", r.Name)
 
 	// k8s 리소스들의 이름은 기본적으로 DNS-1123의 룰을 따라야 함
@@ -117,11 +124,31 @@ func (r *ClusterClaim) ValidateCreate() error {
 		return errors.New("Cannot be an even number when using managed etcd")
 	}
 
+	if r.Spec.TargetNamespace != "" {
+		if msgs := validation.IsDNS1123Label(r.Spec.TargetNamespace); len(msgs) > 0 {
+			errList := []*field.Error{
+				field.Invalid(field.NewPath("spec", "targetNamespace"), r.Spec.TargetNamespace, strings.Join(msgs, ", ")),
+			}
+			return k8sErrors.NewInvalid(r.GroupVersionKind().GroupKind(), "InvalidSpecTargetNamespace", errList)
+		}
+	}
+
+	if err := r.Spec.Network.Validate(); err != nil {
+		errList := []*field.Error{
+			field.Invalid(field.NewPath("spec", "network"), r.Spec.Network, err.Error()),
+		}
+		return k8sErrors.NewInvalid(r.GroupVersionKind().GroupKind(), "InvalidSpecNetwork", errList)
+	}
+
 	return nil
 }
 
 // ValidateUpdate implements webhook.Validator so a webhook will be registered for the type
 func (r *ClusterClaim) ValidateUpdate(old runtime.Object) error {
+	return metrics.RecordWebhookRejection("ClusterClaim", "update", r.validateUpdate(old))
+}
+
+func (r *ClusterClaim) validateUpdate(old runtime.Object) error {
 	ClusterClaimWebhookLogger.Info("validate update", "name", r.Name)
 	oldClusterClaim := old.(*ClusterClaim).DeepCopy()
 
@@ -139,6 +166,10 @@ func (r *ClusterClaim) ValidateUpdate(old runtime.Object) error {
 
 // ValidateDelete implements webhook.Validator so a webhook will be registered for the type
 func (r *ClusterClaim) ValidateDelete() error {
+	return metrics.RecordWebhookRejection("ClusterClaim", "delete", r.validateDelete())
+}
+
+func (r *ClusterClaim) validateDelete() error {
 	ClusterClaimWebhookLogger.Info("validate delete", "name", r.Name)
 
 	// cluster가 남아있으면 cluster claim을 삭제하지 못하도록 처리
@@ -35,12 +35,14 @@ const (
 type ClusterUpdateClaimReason string
 
 const (
-	ClusterUpdateClaimReasonClusterNotFound   = ClusterUpdateClaimReason("Cluster not found")
-	ClusterUpdateClaimReasonClusterIsDeleting = ClusterUpdateClaimReason("Cluster is deleting")
-	ClusterUpdateClaimReasonAdminApproved     = ClusterUpdateClaimReason("Admin approved")
-	ClusterUpdateClaimReasonAdminAwaiting     = ClusterUpdateClaimReason("Waiting for admin approval")
-	ClusterUpdateClaimReasonConcurruencyError = ClusterUpdateClaimReason("The number of nodes at the time of creation of the clusterupdataclaim differs from the current number of nodes.")
-	ClusterUpdateClaimReasonInvalidCluster    = ClusterUpdateClaimReason("Cluster type is not created type")
+	ClusterUpdateClaimReasonClusterNotFound    = ClusterUpdateClaimReason("Cluster not found")
+	ClusterUpdateClaimReasonClusterIsDeleting  = ClusterUpdateClaimReason("Cluster is deleting")
+	ClusterUpdateClaimReasonAdminApproved      = ClusterUpdateClaimReason("Admin approved")
+	ClusterUpdateClaimReasonAdminAwaiting      = ClusterUpdateClaimReason("Waiting for admin approval")
+	ClusterUpdateClaimReasonConcurruencyError  = ClusterUpdateClaimReason("The number of nodes at the time of creation of the clusterupdataclaim differs from the current number of nodes.")
+	ClusterUpdateClaimReasonInvalidCluster     = ClusterUpdateClaimReason("Cluster type is not created type")
+	ClusterUpdateClaimReasonUnsupportedUpgrade = ClusterUpdateClaimReason("Version upgrade is not supported for the vSphere provider")
+	ClusterUpdateClaimReasonPreflightFailed    = ClusterUpdateClaimReason("Preflight check failed")
 )
 
 type ClusterUpdateType string
@@ -49,6 +51,18 @@ const (
 	ClusterUpdateTypeNodeScale = ClusterUpdateType("NodeScale")
 )
 
+type PreflightResult string
+
+const (
+	PreflightResultPassed = PreflightResult("Passed")
+	// rollout 을 막지 않지만 확인이 필요한 상태
+	PreflightResultWarning = PreflightResult("Warning")
+	// rollout 을 막는 상태
+	PreflightResultFailed = PreflightResult("Failed")
+	// update 의 종류에 해당하지 않아 확인하지 않은 상태
+	PreflightResultSkipped = PreflightResult("Skipped")
+)
+
 // ClusterUpdateClaimSpec defines the desired state of ClusterUpdateClaim
 type ClusterUpdateClaimSpec struct {
 	// +kubebuilder:validation:Required
@@ -60,6 +74,32 @@ type ClusterUpdateClaimSpec struct {
 	// +kubebuilder:validation:Minimum:=1
 	// The number of worker nodes to update.
 	UpdatedWorkerNum int `json:"updatedWorkerNum,omitempty"`
+	// The kubernetes version to upgrade. Example: v1.24.1
+	// Not supported for the vSphere provider since the vcenterTemplate must be updated together.
+	UpdatedVersion string `json:"updatedVersion,omitempty"`
+}
+
+// PreflightCheckResult is the result of a preflight check
+type PreflightCheckResult struct {
+	// The name of the check.
+	Name string `json:"name"`
+	// +kubebuilder:validation:Enum=Passed;Warning;Failed;Skipped
+	// The result of the check.
+	Result PreflightResult `json:"result"`
+	// The details of the result.
+	Message string `json:"message,omitempty"`
+}
+
+// PreflightReport is the report of the preflight checks which gate the update of the cluster
+type PreflightReport struct {
+	// Whether no check failed. The claim is not executed after the approval if any check failed.
+	Passed bool `json:"passed"`
+	// The results of the checks.
+	Checks []PreflightCheckResult `json:"checks,omitempty"`
+	// The generation of the claim which the checks ran for.
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// The time when the checks ran.
+	CheckedTime metav1.Time `json:"checkedTime,omitempty"`
 }
 
 // ClusterUpdateClaimStatus defines the observed state of ClusterUpdateClaim
@@ -75,6 +115,11 @@ type ClusterUpdateClaimStatus struct {
 	CurrentMasterNum int `json:"currentMasterNum,omitempty"`
 	// The number of current worker node.
 	CurrentWorkerNum int `json:"currentWorkerNum,omitempty"`
+	// The user who approved or rejected the claim. Set by the admission webhook.
+	Review *ClaimReview `json:"review,omitempty"`
+	// The report of the preflight checks. The checks run when the claim is created or changed,
+	// and run again when the claim is approved.
+	Preflight *PreflightReport `json:"preflight,omitempty"`
 }
 
 // +kubebuilder:object:root=true
@@ -83,8 +128,11 @@ type ClusterUpdateClaimStatus struct {
 // +kubebuilder:printcolumn:name="Cluster",type=string,JSONPath=`.spec.clusterName`
 // +kubebuilder:printcolumn:name="masternum",type=integer,JSONPath=`.spec.updatedMasterNum`
 // +kubebuilder:printcolumn:name="workernum",type=integer,JSONPath=`.spec.updatedWorkerNum`
+// +kubebuilder:printcolumn:name="version",type=string,JSONPath=`.spec.updatedVersion`
 // +kubebuilder:printcolumn:name="Status",type=string,JSONPath=`.status.phase`
 // +kubebuilder:printcolumn:name="Reason",type=string,JSONPath=`.status.reason`
+// +kubebuilder:printcolumn:name="Preflight",type=boolean,JSONPath=`.status.preflight.passed`,priority=1
+// +kubebuilder:printcolumn:name="Reviewer",type=string,JSONPath=`.status.review.reviewer`,priority=1
 // +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
 // ClusterUpdateClaim is the Schema for the clusterupdateclaims API
 type ClusterUpdateClaim struct {
@@ -17,6 +17,8 @@ package v1alpha1
 import (
 	"fmt"
 
+	"github.com/tmax-cloud/hypercloud-multi-operator/controllers/metrics"
+
 	"k8s.io/apimachinery/pkg/runtime"
 	ctrl "sigs.k8s.io/controller-runtime"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
@@ -38,6 +40,10 @@ var _ webhook.Validator = &ClusterUpdateClaim{}
 
 // ValidateCreate implements webhook.Validator so a webhook will be registered for the type
 func (r *ClusterUpdateClaim) ValidateCreate() error {
+	return metrics.RecordWebhookRejection("ClusterUpdateClaim", "create", r.validateCreate())
+}
+
+func (r *ClusterUpdateClaim) validateCreate() error {
 
 	masterNum := r.Spec.UpdatedMasterNum
 
@@ -52,6 +58,10 @@ func (r *ClusterUpdateClaim) ValidateCreate() error {
 
 // ValidateUpdate implements webhook.Validator so a webhook will be registered for the type
 func (r *ClusterUpdateClaim) ValidateUpdate(old runtime.Object) error {
+	return metrics.RecordWebhookRejection("ClusterUpdateClaim", "update", r.validateUpdate(old))
+}
+
+func (r *ClusterUpdateClaim) validateUpdate(old runtime.Object) error {
 	// oc := old.(*ClusterUpdateClaim).DeepCopy()
 
 	// masterNum을 짝수로 변경하는 경우
@@ -0,0 +1,119 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+type PlacementStrategy string
+
+const (
+	// 배치된 cluster 가 가장 적은 target 을 선택하여 region 간에 분산시킨다.
+	PlacementStrategySpread = PlacementStrategy("Spread")
+	// capacity 가 남은 target 중 목록의 첫번째 target 을 선택한다.
+	PlacementStrategyOrdered = PlacementStrategy("Ordered")
+)
+
+const (
+	// placement 로 생성된 cluster manager 에 다는 label
+	LabelKeyPlacementPolicy          = "placementpolicy.claim.tmax.io/name"
+	LabelKeyPlacementPolicyNamespace = "placementpolicy.claim.tmax.io/namespace"
+	LabelKeyPlacementTarget          = "placementpolicy.claim.tmax.io/target"
+)
+
+// VspherePlacementSpec defines the vCenter of a placement target
+type VspherePlacementSpec struct {
+	// The IP address of vCenter Server Application(VCSA).
+	VcenterIp string `json:"vcenterIp,omitempty"`
+	// The TLS thumbprint of machine certificate.
+	VcenterThumbprint string `json:"vcenterThumbprint,omitempty"`
+	// The name of network.
+	VcenterNetwork string `json:"vcenterNetwork,omitempty"`
+	// The name of datacenter.
+	VcenterDataCenter string `json:"vcenterDataCenter,omitempty"`
+	// The name of datastore.
+	VcenterDataStore string `json:"vcenterDataStore,omitempty"`
+	// The name of folder.
+	VcenterFolder string `json:"vcenterFolder,omitempty"`
+	// The name of resource pool.
+	VcenterResourcePool string `json:"vcenterResourcePool,omitempty"`
+	// The template name to use in vsphere.
+	VcenterTemplate string `json:"vcenterTemplate,omitempty"`
+}
+
+// PlacementTarget defines a provider and region where clusters can be placed
+type PlacementTarget struct {
+	// +kubebuilder:validation:Required
+	// The name of the target.
+	Name string `json:"name"`
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:Enum:=AWS;vSphere
+	// The type of provider.
+	Provider string `json:"provider"`
+	// The AWS region. Required for AWS provider.
+	Region string `json:"region,omitempty"`
+	// The vCenter of the target. Used for vSphere provider.
+	ProviderVsphereSpec *VspherePlacementSpec `json:"providerVsphereSpec,omitempty"`
+	// +kubebuilder:validation:Minimum:=0
+	// The maximum number of clusters placed on the target. 0 means unlimited.
+	Capacity int `json:"capacity,omitempty"`
+}
+
+// PlacementPolicySpec defines the desired state of PlacementPolicy
+type PlacementPolicySpec struct {
+	// +kubebuilder:validation:Enum:=Spread;Ordered
+	// +kubebuilder:default:=Spread
+	// The strategy to select a target.
+	Strategy PlacementStrategy `json:"strategy,omitempty"`
+	// +kubebuilder:validation:Minimum:=0
+	// The maximum number of clusters in a namespace. 0 means unlimited.
+	NamespaceQuota int `json:"namespaceQuota,omitempty"`
+	// +kubebuilder:validation:MinItems=1
+	// The targets where clusters can be placed.
+	Targets []PlacementTarget `json:"targets"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:path=placementpolicies,shortName=pp,scope=Namespaced
+// +kubebuilder:printcolumn:name="Strategy",type=string,JSONPath=`.spec.strategy`
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+// PlacementPolicy is the Schema for the placementpolicies API
+// PlacementPolicy in hypercloud5-system namespace is used when there is no policy in the namespace of the claim.
+type PlacementPolicy struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec PlacementPolicySpec `json:"spec"`
+}
+
+// +kubebuilder:object:root=true
+// PlacementPolicyList contains a list of PlacementPolicy
+type PlacementPolicyList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []PlacementPolicy `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&PlacementPolicy{}, &PlacementPolicyList{})
+}
+
+func (p *PlacementPolicy) GetStrategy() PlacementStrategy {
+	if p.Spec.Strategy == "" {
+		return PlacementStrategySpread
+	}
+	return p.Spec.Strategy
+}
@@ -0,0 +1,129 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/tmax-cloud/hypercloud-multi-operator/controllers/metrics"
+
+	coreV1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+const claimReviewWebhookPath = "/mutate-claim-tmax-io-v1alpha1-review"
+
+// +kubebuilder:webhook:path=/mutate-claim-tmax-io-v1alpha1-review,mutating=true,failurePolicy=fail,groups=claim.tmax.io,resources=clusterclaims/status;clusterupdateclaims/status,verbs=update,versions=v1alpha1,name=review.webhook.claim,admissionReviewVersions=v1beta1;v1,sideEffects=NoneOnDryRun
+
+// ClaimReviewWebhook 은 ClusterClaim, ClusterUpdateClaim 의 phase 가 Approved 또는 Rejected 로 변경될 때
+// 요청한 사용자를 status.review 에 기록하고, event 와 metric 을 남긴다.
+// 승인, 거절은 console 에서 status 를 직접 변경하는 방식이므로 admission 의 userInfo 로만 사용자를 알 수 있다.
+type ClaimReviewWebhook struct {
+	Recorder record.EventRecorder
+	decoder  *admission.Decoder
+}
+
+func SetupClaimReviewWebhookWithManager(mgr ctrl.Manager) error {
+	mgr.GetWebhookServer().Register(claimReviewWebhookPath, &webhook.Admission{
+		Handler: &ClaimReviewWebhook{
+			Recorder: mgr.GetEventRecorderFor("claim-review-webhook"),
+		},
+	})
+	return nil
+}
+
+func (h *ClaimReviewWebhook) InjectDecoder(d *admission.Decoder) error {
+	h.decoder = d
+	return nil
+}
+
+func (h *ClaimReviewWebhook) Handle(ctx context.Context, req admission.Request) admission.Response {
+	var obj runtime.Object
+	var decision string
+
+	switch req.Kind.Kind {
+	case "ClusterClaim":
+		cc, oldCc := &ClusterClaim{}, &ClusterClaim{}
+		if err := h.decode(req, cc, oldCc); err != nil {
+			return admission.Errored(http.StatusBadRequest, err)
+		}
+		decision = reviewDecision(string(oldCc.Status.Phase), string(cc.Status.Phase))
+		if decision == "" {
+			return admission.Allowed("")
+		}
+		cc.Status.Review = newClaimReview(req, decision)
+		obj = cc
+	case "ClusterUpdateClaim":
+		cuc, oldCuc := &ClusterUpdateClaim{}, &ClusterUpdateClaim{}
+		if err := h.decode(req, cuc, oldCuc); err != nil {
+			return admission.Errored(http.StatusBadRequest, err)
+		}
+		decision = reviewDecision(string(oldCuc.Status.Phase), string(cuc.Status.Phase))
+		if decision == "" {
+			return admission.Allowed("")
+		}
+		cuc.Status.Review = newClaimReview(req, decision)
+		obj = cuc
+	default:
+		return admission.Allowed("")
+	}
+
+	marshaled, err := json.Marshal(obj)
+	if err != nil {
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+
+	if req.DryRun == nil || !*req.DryRun {
+		metrics.RecordClaimReview(req.Kind.Kind, decision)
+		h.Recorder.Eventf(obj, coreV1.EventTypeNormal, decision,
+			"%s is %s by %s", req.Kind.Kind, strings.ToLower(decision), req.UserInfo.Username)
+	}
+	return admission.PatchResponseFromRaw(req.Object.Raw, marshaled)
+}
+
+func (h *ClaimReviewWebhook) decode(req admission.Request, obj, old runtime.Object) error {
+	if err := h.decoder.Decode(req, obj); err != nil {
+		return err
+	}
+	return h.decoder.DecodeRaw(req.OldObject, old)
+}
+
+// phase 가 Approved 또는 Rejected 로 변경된 경우에만 해당 phase 를 반환한다.
+func reviewDecision(oldPhase, newPhase string) string {
+	if oldPhase == newPhase {
+		return ""
+	}
+	if newPhase == string(ClusterClaimPhaseApproved) || newPhase == string(ClusterClaimPhaseRejected) {
+		return newPhase
+	}
+	return ""
+}
+
+func newClaimReview(req admission.Request, decision string) *ClaimReview {
+	return &ClaimReview{
+		Reviewer:     req.UserInfo.Username,
+		Groups:       req.UserInfo.Groups,
+		Decision:     decision,
+		ReviewedTime: metav1.Now(),
+	}
+}
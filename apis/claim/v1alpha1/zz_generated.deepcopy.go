@@ -20,6 +20,7 @@ limitations under the License.
 package v1alpha1
 
 import (
+	clusterv1alpha1 "github.com/tmax-cloud/hypercloud-multi-operator/apis/cluster/v1alpha1"
 	"k8s.io/apimachinery/pkg/runtime"
 )
 
@@ -38,13 +39,34 @@ func (in *AwsClaimSpec) DeepCopy() *AwsClaimSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClaimReview) DeepCopyInto(out *ClaimReview) {
+	*out = *in
+	if in.Groups != nil {
+		in, out := &in.Groups, &out.Groups
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	in.ReviewedTime.DeepCopyInto(&out.ReviewedTime)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClaimReview.
+func (in *ClaimReview) DeepCopy() *ClaimReview {
+	if in == nil {
+		return nil
+	}
+	out := new(ClaimReview)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ClusterClaim) DeepCopyInto(out *ClusterClaim) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
-	out.Spec = in.Spec
-	out.Status = in.Status
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterClaim.
@@ -97,11 +119,37 @@ func (in *ClusterClaimList) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterClaimPlacement) DeepCopyInto(out *ClusterClaimPlacement) {
+	*out = *in
+	if in.ProviderVsphereSpec != nil {
+		in, out := &in.ProviderVsphereSpec, &out.ProviderVsphereSpec
+		*out = new(VspherePlacementSpec)
+		**out = **in
+	}
+	in.PlacedTime.DeepCopyInto(&out.PlacedTime)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterClaimPlacement.
+func (in *ClusterClaimPlacement) DeepCopy() *ClusterClaimPlacement {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterClaimPlacement)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ClusterClaimSpec) DeepCopyInto(out *ClusterClaimSpec) {
 	*out = *in
 	out.ProviderAwsSpec = in.ProviderAwsSpec
 	out.ProviderVsphereSpec = in.ProviderVsphereSpec
+	if in.Network != nil {
+		in, out := &in.Network, &out.Network
+		*out = new(clusterv1alpha1.ClusterNetwork)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterClaimSpec.
@@ -117,6 +165,16 @@ func (in *ClusterClaimSpec) DeepCopy() *ClusterClaimSpec {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ClusterClaimStatus) DeepCopyInto(out *ClusterClaimStatus) {
 	*out = *in
+	if in.Placement != nil {
+		in, out := &in.Placement, &out.Placement
+		*out = new(ClusterClaimPlacement)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Review != nil {
+		in, out := &in.Review, &out.Review
+		*out = new(ClaimReview)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterClaimStatus.
@@ -135,7 +193,7 @@ func (in *ClusterUpdateClaim) DeepCopyInto(out *ClusterUpdateClaim) {
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	out.Spec = in.Spec
-	out.Status = in.Status
+	in.Status.DeepCopyInto(&out.Status)
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterUpdateClaim.
@@ -206,6 +264,16 @@ func (in *ClusterUpdateClaimSpec) DeepCopy() *ClusterUpdateClaimSpec {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ClusterUpdateClaimStatus) DeepCopyInto(out *ClusterUpdateClaimStatus) {
 	*out = *in
+	if in.Review != nil {
+		in, out := &in.Review, &out.Review
+		*out = new(ClaimReview)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Preflight != nil {
+		in, out := &in.Preflight, &out.Preflight
+		*out = new(PreflightReport)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterUpdateClaimStatus.
@@ -218,6 +286,142 @@ func (in *ClusterUpdateClaimStatus) DeepCopy() *ClusterUpdateClaimStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PlacementPolicy) DeepCopyInto(out *PlacementPolicy) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PlacementPolicy.
+func (in *PlacementPolicy) DeepCopy() *PlacementPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(PlacementPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *PlacementPolicy) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PlacementPolicyList) DeepCopyInto(out *PlacementPolicyList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]PlacementPolicy, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PlacementPolicyList.
+func (in *PlacementPolicyList) DeepCopy() *PlacementPolicyList {
+	if in == nil {
+		return nil
+	}
+	out := new(PlacementPolicyList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *PlacementPolicyList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PlacementPolicySpec) DeepCopyInto(out *PlacementPolicySpec) {
+	*out = *in
+	if in.Targets != nil {
+		in, out := &in.Targets, &out.Targets
+		*out = make([]PlacementTarget, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PlacementPolicySpec.
+func (in *PlacementPolicySpec) DeepCopy() *PlacementPolicySpec {
+	if in == nil {
+		return nil
+	}
+	out := new(PlacementPolicySpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PlacementTarget) DeepCopyInto(out *PlacementTarget) {
+	*out = *in
+	if in.ProviderVsphereSpec != nil {
+		in, out := &in.ProviderVsphereSpec, &out.ProviderVsphereSpec
+		*out = new(VspherePlacementSpec)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PlacementTarget.
+func (in *PlacementTarget) DeepCopy() *PlacementTarget {
+	if in == nil {
+		return nil
+	}
+	out := new(PlacementTarget)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PreflightCheckResult) DeepCopyInto(out *PreflightCheckResult) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PreflightCheckResult.
+func (in *PreflightCheckResult) DeepCopy() *PreflightCheckResult {
+	if in == nil {
+		return nil
+	}
+	out := new(PreflightCheckResult)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PreflightReport) DeepCopyInto(out *PreflightReport) {
+	*out = *in
+	if in.Checks != nil {
+		in, out := &in.Checks, &out.Checks
+		*out = make([]PreflightCheckResult, len(*in))
+		copy(*out, *in)
+	}
+	in.CheckedTime.DeepCopyInto(&out.CheckedTime)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PreflightReport.
+func (in *PreflightReport) DeepCopy() *PreflightReport {
+	if in == nil {
+		return nil
+	}
+	out := new(PreflightReport)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *VsphereClaimSpec) DeepCopyInto(out *VsphereClaimSpec) {
 	*out = *in
@@ -232,3 +436,18 @@ func (in *VsphereClaimSpec) DeepCopy() *VsphereClaimSpec {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VspherePlacementSpec) DeepCopyInto(out *VspherePlacementSpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VspherePlacementSpec.
+func (in *VspherePlacementSpec) DeepCopy() *VspherePlacementSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(VspherePlacementSpec)
+	in.DeepCopyInto(out)
+	return out
+}
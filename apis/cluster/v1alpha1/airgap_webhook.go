@@ -0,0 +1,157 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	"k8s.io/apimachinery/pkg/util/version"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+const airGapWebhookPath = "/validate-cluster-tmax-io-v1alpha1-airgap"
+
+// AirGapPolicy 는 air-gapped 환경에서 cluster 를 생성하기 위한 설정이다.
+type AirGapPolicy struct {
+	Enabled bool
+	// kubernetes image 를 받을 내부 registry
+	ImageRepository string
+	// node 가 file 을 받을 내부 mirror
+	ArtifactMirror string
+	// addon chart 를 받을 내부 repo
+	ChartRepo string
+	// 내부 registry 에 image 가 준비된 kubernetes minor version 목록, 비어있으면 version 을 검사하지 않는다.
+	SupportedKubernetesVersions []string
+}
+
+// +kubebuilder:webhook:path=/validate-cluster-tmax-io-v1alpha1-airgap,mutating=false,failurePolicy=fail,groups=claim.tmax.io;cluster.tmax.io,resources=clusterclaims;clustermanagers,verbs=create;update,versions=v1alpha1,name=validation.webhook.airgap,admissionReviewVersions=v1beta1;v1,sideEffects=None
+
+// AirGapWebhook 은 air-gapped 환경에서 인터넷 접근이 필요한 ClusterClaim, ClusterManager 를 거부한다.
+// aws provider, 내부 registry 에 없는 kubernetes version, ACME 로 인증서를 발급받는 cert-manager addon 은 인터넷이 필요하다.
+// 등록한 cluster 는 operator 가 생성하지 않으므로 검사하지 않는다.
+type AirGapWebhook struct {
+	// HyperCloudOperatorConfig 로 바뀔 수 있으므로 요청마다 설정을 가져온다.
+	Policy  func() AirGapPolicy
+	decoder *admission.Decoder
+}
+
+func SetupAirGapWebhookWithManager(mgr ctrl.Manager, policy func() AirGapPolicy) error {
+	mgr.GetWebhookServer().Register(airGapWebhookPath, &webhook.Admission{
+		Handler: &AirGapWebhook{
+			Policy: policy,
+		},
+	})
+	return nil
+}
+
+func (h *AirGapWebhook) InjectDecoder(d *admission.Decoder) error {
+	h.decoder = d
+	return nil
+}
+
+// ClusterClaim 은 다른 package 의 type 이므로 검사에 필요한 field 만 decode 한다.
+type airGapClaim struct {
+	Spec struct {
+		Provider string `json:"provider,omitempty"`
+		Version  string `json:"version"`
+	} `json:"spec"`
+}
+
+func (h *AirGapWebhook) Handle(ctx context.Context, req admission.Request) admission.Response {
+	policy := h.Policy()
+	if !policy.Enabled {
+		return admission.Allowed("")
+	}
+
+	var err error
+	switch req.Kind.Kind {
+	case "ClusterClaim":
+		// 승인 등 status 변경은 검사하지 않는다.
+		if req.Operation != admissionv1.Create {
+			return admission.Allowed("")
+		}
+		claim := &airGapClaim{}
+		if err := json.Unmarshal(req.Object.Raw, claim); err != nil {
+			return admission.Errored(http.StatusBadRequest, err)
+		}
+		err = policy.validate(claim.Spec.Provider, claim.Spec.Version)
+	case "ClusterManager":
+		clm := &ClusterManager{}
+		if err := h.decoder.Decode(req, clm); err != nil {
+			return admission.Errored(http.StatusBadRequest, err)
+		}
+		if clm.GetClusterType() != ClusterTypeCreated {
+			return admission.Allowed("")
+		}
+		old := &ClusterManager{}
+		if req.Operation == admissionv1.Update {
+			if err := h.decoder.DecodeRaw(req.OldObject, old); err != nil {
+				return admission.Errored(http.StatusBadRequest, err)
+			}
+			// 이미 생성된 cluster 는 바뀐 version 만 검사한다.
+			if old.Spec.Version == clm.Spec.Version {
+				clm.Spec.Version = ""
+			}
+		}
+		err = policy.validate(clm.Spec.Provider, clm.Spec.Version)
+		if err == nil && clm.GetCertManagerAddon() != nil && old.GetCertManagerAddon() == nil {
+			err = fmt.Errorf("cert-manager addon requires internet access to issue certificates with ACME")
+		}
+	default:
+		return admission.Allowed("")
+	}
+
+	if err != nil {
+		return admission.Denied(fmt.Sprintf("cannot %s %s in air-gapped mode: %s", strings.ToLower(string(req.Operation)), req.Kind.Kind, err.Error()))
+	}
+	return admission.Allowed("")
+}
+
+// provider 와 kubernetes version 으로 인터넷 없이 cluster 를 생성할 수 있는지 검사한다.
+// version 이 비어있으면 검사하지 않는다.
+func (p AirGapPolicy) validate(provider, kubernetesVersion string) error {
+	if p.ImageRepository == "" || p.ArtifactMirror == "" || p.ChartRepo == "" {
+		return fmt.Errorf("internal image repository, artifact mirror and chart repo must be configured")
+	}
+	if strings.EqualFold(provider, ProviderAWS) {
+		return fmt.Errorf("provider %s requires internet access", ProviderAWS)
+	}
+	if kubernetesVersion == "" || len(p.SupportedKubernetesVersions) == 0 {
+		return nil
+	}
+
+	v, err := version.ParseGeneric(kubernetesVersion)
+	if err != nil {
+		return fmt.Errorf("invalid kubernetes version %s", kubernetesVersion)
+	}
+	for _, s := range p.SupportedKubernetesVersions {
+		minor, err := version.ParseGeneric(s)
+		if err != nil {
+			continue
+		}
+		if v.Major() == minor.Major() && v.Minor() == minor.Minor() {
+			return nil
+		}
+	}
+	return fmt.Errorf("images of kubernetes version %s are not available in the internal registry, supported versions are %s",
+		kubernetesVersion, strings.Join(p.SupportedKubernetesVersions, ", "))
+}
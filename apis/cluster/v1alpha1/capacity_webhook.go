@@ -0,0 +1,111 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	coreV1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+const capacityWebhookPath = "/validate-cluster-tmax-io-v1alpha1-capacity"
+
+// +kubebuilder:webhook:path=/validate-cluster-tmax-io-v1alpha1-capacity,mutating=false,failurePolicy=fail,groups=claim.tmax.io,resources=clusterupdateclaims,verbs=create,versions=v1alpha1,name=validation.webhook.capacity,admissionReviewVersions=v1beta1;v1,sideEffects=None
+
+// CapacityWebhook 은 기존 cluster 의 worker node 를 줄이는 ClusterUpdateClaim 을
+// ClusterManager status 에 집계된 capacity 로 검사하여, 남은 worker node 에 현재 request 를 수용할 수 없으면 거부한다.
+// capacity 가 아직 집계되지 않은 cluster 는 검사하지 않는다.
+type CapacityWebhook struct {
+	Client client.Reader
+}
+
+func SetupCapacityWebhookWithManager(mgr ctrl.Manager) error {
+	mgr.GetWebhookServer().Register(capacityWebhookPath, &webhook.Admission{
+		Handler: &CapacityWebhook{
+			Client: mgr.GetClient(),
+		},
+	})
+	return nil
+}
+
+// ClusterUpdateClaim 은 다른 package 의 type 이므로 검사에 필요한 field 만 decode 한다.
+type capacityClaim struct {
+	Spec struct {
+		ClusterName      string `json:"clusterName"`
+		UpdatedWorkerNum int    `json:"updatedWorkerNum,omitempty"`
+	} `json:"spec"`
+}
+
+func (h *CapacityWebhook) Handle(ctx context.Context, req admission.Request) admission.Response {
+	claim := &capacityClaim{}
+	if err := json.Unmarshal(req.Object.Raw, claim); err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+	if claim.Spec.UpdatedWorkerNum == 0 {
+		return admission.Allowed("")
+	}
+
+	clm := &ClusterManager{}
+	key := types.NamespacedName{Name: claim.Spec.ClusterName, Namespace: req.Namespace}
+	if err := h.Client.Get(ctx, key, clm); errors.IsNotFound(err) {
+		// cluster 가 없는 claim 은 controller 가 reject 한다.
+		return admission.Allowed("")
+	} else if err != nil {
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+
+	if err := validateCapacity(clm.Status.Capacity, claim.Spec.UpdatedWorkerNum); err != nil {
+		return admission.Denied(fmt.Sprintf("cannot scale in cluster %s: %s", clm.Name, err.Error()))
+	}
+	return admission.Allowed("")
+}
+
+// worker node 를 workers 개로 줄였을 때 남는 allocatable 로 현재 request 를 수용할 수 있는지 검사한다.
+// node 의 크기가 같다고 가정하여 allocatable 을 node 수에 비례하여 줄인다.
+func validateCapacity(capacity *ClusterCapacity, workers int) error {
+	if capacity == nil || capacity.Workers == 0 || workers >= capacity.Workers {
+		return nil
+	}
+
+	var shortages []string
+	for _, name := range []coreV1.ResourceName{coreV1.ResourceCPU, coreV1.ResourceMemory} {
+		allocatable, ok := capacity.Allocatable[name]
+		if !ok || allocatable.IsZero() {
+			continue
+		}
+		requested := capacity.Requested[name]
+		remaining := allocatable.MilliValue() / int64(capacity.Workers) * int64(workers)
+		if requested.MilliValue() <= remaining {
+			continue
+		}
+		shortages = append(shortages, fmt.Sprintf("%s requested %s of allocatable %s (%d%%)",
+			name, requested.String(), allocatable.String(), requested.MilliValue()*100/allocatable.MilliValue()))
+	}
+	if len(shortages) == 0 {
+		return nil
+	}
+	return fmt.Errorf("%d worker nodes do not have enough headroom for the current workloads on %d worker nodes: %s",
+		workers, capacity.Workers, strings.Join(shortages, ", "))
+}
@@ -0,0 +1,96 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+type ClusterAuditAction string
+
+const (
+	// cluster claim 으로 cluster 를 생성한 경우
+	ClusterAuditActionClusterCreated = ClusterAuditAction("ClusterCreated")
+	// cluster registration 으로 cluster 를 등록한 경우
+	ClusterAuditActionClusterRegistered = ClusterAuditAction("ClusterRegistered")
+	// cluster claim 이 승인된 경우
+	ClusterAuditActionClusterApproved = ClusterAuditAction("ClusterApproved")
+	// cluster 가 삭제된 경우
+	ClusterAuditActionClusterDeleted = ClusterAuditAction("ClusterDeleted")
+	// remote cluster 에 rbac 리소스를 배포한 경우
+	ClusterAuditActionRBACDeployed = ClusterAuditAction("RBACDeployed")
+	// cluster owner 가 변경된 경우
+	ClusterAuditActionOwnerChanged = ClusterAuditAction("OwnerChanged")
+	// capi cluster 의 reconcile 을 중지한 경우
+	ClusterAuditActionClusterPaused = ClusterAuditAction("ClusterPaused")
+	// capi cluster 의 reconcile 을 재개한 경우
+	ClusterAuditActionClusterResumed = ClusterAuditAction("ClusterResumed")
+)
+
+const (
+	LabelKeyClusterAuditAction = "clusteraudit.cluster.tmax.io/action"
+)
+
+// ClusterAuditSpec defines the recorded action of ClusterAudit
+type ClusterAuditSpec struct {
+	// +kubebuilder:validation:Required
+	// The name of the cluster the action is performed on.
+	ClusterName string `json:"clusterName"`
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:Enum:=ClusterCreated;ClusterRegistered;ClusterApproved;ClusterDeleted;RBACDeployed;OwnerChanged;ClusterPaused;ClusterResumed
+	// The action performed by the operator.
+	Action ClusterAuditAction `json:"action"`
+	// The user who triggered the action. Empty if unknown.
+	Actor string `json:"actor,omitempty"`
+	// +kubebuilder:validation:Required
+	// The time when the action is performed.
+	Timestamp metav1.Time `json:"timestamp"`
+	// Additional message for the action.
+	Message string `json:"message,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:path=clusteraudits,shortName=cad,scope=Namespaced
+// +kubebuilder:printcolumn:name="Cluster",type=string,JSONPath=`.spec.clusterName`
+// +kubebuilder:printcolumn:name="Action",type=string,JSONPath=`.spec.action`
+// +kubebuilder:printcolumn:name="Actor",type=string,JSONPath=`.spec.actor`
+// +kubebuilder:printcolumn:name="Time",type="date",JSONPath=`.spec.timestamp`
+// ClusterAudit is the Schema for the clusteraudits API
+type ClusterAudit struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec ClusterAuditSpec `json:"spec"`
+}
+
+// +kubebuilder:object:root=true
+// ClusterAuditList contains a list of ClusterAudit
+type ClusterAuditList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ClusterAudit `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&ClusterAudit{}, &ClusterAuditList{})
+}
+
+func (c *ClusterAudit) GetNamespacedName() types.NamespacedName {
+	return types.NamespacedName{
+		Name:      c.Name,
+		Namespace: c.Namespace,
+	}
+}
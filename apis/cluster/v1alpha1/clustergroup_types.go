@@ -0,0 +1,117 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// ClusterGroupSpec defines the desired state of ClusterGroup
+type ClusterGroupSpec struct {
+	// +kubebuilder:validation:Required
+	// Label selector for cluster managers in the same namespace.
+	// An empty selector selects all cluster managers in the namespace.
+	ClusterSelector metav1.LabelSelector `json:"clusterSelector"`
+}
+
+// ClusterGroupClusterStatus defines the summarized status of a selected cluster
+type ClusterGroupClusterStatus struct {
+	// The name of the cluster manager.
+	Name string `json:"name"`
+	// True if the cluster is ready.
+	Ready bool `json:"ready,omitempty"`
+	// The kubernetes version of the cluster.
+	Version string `json:"version,omitempty"`
+	// The phase of the cluster manager.
+	Phase ClusterManagerPhase `json:"phase,omitempty"`
+}
+
+// ClusterGroupRolloutStatus defines the summarized status of a rollout targeting the group
+type ClusterGroupRolloutStatus struct {
+	// The name of the cluster rollout.
+	Name string `json:"name"`
+	// The phase of the rollout.
+	Phase ClusterRolloutPhase `json:"phase,omitempty"`
+	// The current stage of the rollout.
+	Stage ClusterRolloutStage `json:"stage,omitempty"`
+	// The number of updated clusters.
+	Updated int `json:"updated,omitempty"`
+	// The number of failed clusters.
+	Failed int `json:"failed,omitempty"`
+	// The number of clusters in the rollout.
+	Total int `json:"total,omitempty"`
+}
+
+// ClusterGroupStatus defines the observed state of ClusterGroup
+type ClusterGroupStatus struct {
+	// True if all selected clusters are ready.
+	Ready bool `json:"ready,omitempty"`
+	// The number of selected clusters.
+	ClusterCount int `json:"clusterCount,omitempty"`
+	// The number of ready clusters.
+	ReadyCount int `json:"readyCount,omitempty"`
+	// The kubernetes versions of selected clusters.
+	Versions []string `json:"versions,omitempty"`
+	// The total number of running master nodes of selected clusters.
+	MasterRun int `json:"masterRun,omitempty"`
+	// The total number of running worker nodes of selected clusters.
+	WorkerRun int `json:"workerRun,omitempty"`
+	// The status of selected clusters.
+	Clusters []ClusterGroupClusterStatus `json:"clusters,omitempty"`
+	// The stage status of the cluster rollouts targeting the group.
+	Rollouts []ClusterGroupRolloutStatus `json:"rollouts,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:path=clustergroups,shortName=clg,scope=Namespaced
+// +kubebuilder:printcolumn:name="Clusters",type=integer,JSONPath=`.status.clusterCount`
+// +kubebuilder:printcolumn:name="ReadyClusters",type=integer,JSONPath=`.status.readyCount`
+// +kubebuilder:printcolumn:name="Ready",type=boolean,JSONPath=`.status.ready`
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+// ClusterGroup is the Schema for the clustergroups API
+type ClusterGroup struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   ClusterGroupSpec   `json:"spec"`
+	Status ClusterGroupStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// ClusterGroupList contains a list of ClusterGroup
+type ClusterGroupList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ClusterGroup `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&ClusterGroup{}, &ClusterGroupList{})
+}
+
+func (c *ClusterGroup) GetNamespacedName() types.NamespacedName {
+	return types.NamespacedName{
+		Name:      c.Name,
+		Namespace: c.Namespace,
+	}
+}
+
+// cluster group 의 cluster selector
+func (c *ClusterGroup) GetClusterSelector() (labels.Selector, error) {
+	return metav1.LabelSelectorAsSelector(&c.Spec.ClusterSelector)
+}
@@ -0,0 +1,178 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+type ClusterImportSessionPhase string
+
+const (
+	// kubeconfig 로 cluster 를 검사하고 있는 상태
+	ClusterImportSessionPhaseValidating = ClusterImportSessionPhase("Validating")
+	// 모든 검사를 통과하여 사용자의 확인을 기다리는 상태
+	ClusterImportSessionPhaseValidated = ClusterImportSessionPhase("Validated")
+	// 실패한 검사가 있어 import 할 수 없는 상태
+	ClusterImportSessionPhaseFailed = ClusterImportSessionPhase("Failed")
+	// ClusterRegistration 을 생성한 상태
+	ClusterImportSessionPhaseImported = ClusterImportSessionPhase("Imported")
+)
+
+type ClusterImportCheckName string
+
+const (
+	// kubeconfig 로 api server 에 접근할 수 있는지 검사한다.
+	ClusterImportCheckConnectivity = ClusterImportCheckName("Connectivity")
+	// kubeconfig 의 사용자가 operator 가 사용하는 권한을 가지고 있는지 검사한다.
+	ClusterImportCheckPermissions = ClusterImportCheckName("Permissions")
+	// cluster 의 kubernetes version 이 지원되는지 검사한다.
+	ClusterImportCheckVersion = ClusterImportCheckName("Version")
+	// 같은 이름이나 같은 api server 의 cluster 가 이미 등록되었는지 검사한다.
+	ClusterImportCheckConflicts = ClusterImportCheckName("Conflicts")
+)
+
+type ClusterImportCheckResult string
+
+const (
+	ClusterImportCheckResultPassed = ClusterImportCheckResult("Passed")
+	// import 는 가능하지만 사용자가 확인해야 하는 결과
+	ClusterImportCheckResultWarning = ClusterImportCheckResult("Warning")
+	ClusterImportCheckResultFailed  = ClusterImportCheckResult("Failed")
+	// 앞선 검사가 실패하여 수행하지 않은 결과
+	ClusterImportCheckResultSkipped = ClusterImportCheckResult("Skipped")
+)
+
+// ClusterImportSessionSpec defines the desired state of ClusterImportSession
+type ClusterImportSessionSpec struct {
+	// +kubebuilder:validation:Required
+	// The name of the cluster to be imported
+	ClusterName string `json:"clusterName"`
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Format:="data-url"
+	// The kubeconfig file of the cluster to be imported. Exactly one of kubeConfig, kubeConfigSecret and encryptedKubeConfig must be set
+	KubeConfig string `json:"kubeConfig,omitempty"`
+	// +kubebuilder:validation:Optional
+	// The name of the secret in the same namespace which has the kubeconfig file in value
+	KubeConfigSecret string `json:"kubeConfigSecret,omitempty"`
+	// +kubebuilder:validation:Optional
+	// The encrypted kubeconfig file of the cluster to be imported. See encryptedKubeConfig of ClusterRegistration
+	EncryptedKubeConfig string `json:"encryptedKubeConfig,omitempty"`
+	// Set true to import the cluster after reviewing the validation results.
+	// The cluster is validated again and the ClusterRegistration is created only if no check failed
+	Confirmed bool `json:"confirmed,omitempty"`
+}
+
+// ClusterImportCheck defines the result of a validation stage
+type ClusterImportCheck struct {
+	// +kubebuilder:validation:Enum=Connectivity;Permissions;Version;Conflicts;
+	// The name of the check.
+	Name ClusterImportCheckName `json:"name"`
+	// +kubebuilder:validation:Enum=Passed;Warning;Failed;Skipped;
+	// The result of the check.
+	Result ClusterImportCheckResult `json:"result"`
+	// The machine readable reason of the result.
+	Reason string `json:"reason,omitempty"`
+	// The human readable message of the result.
+	Message string `json:"message,omitempty"`
+	// The details of the result such as the missing permissions or the conflicting clusters.
+	Details []string `json:"details,omitempty"`
+}
+
+// ClusterImportSessionStatus defines the observed state of ClusterImportSession
+type ClusterImportSessionStatus struct {
+	// +kubebuilder:validation:Enum=Validating;Validated;Failed;Imported;
+	// Phase of the import session.
+	Phase ClusterImportSessionPhase `json:"phase,omitempty"`
+	// The generation of the spec validated by the checks.
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// The time the checks were run.
+	LastValidationTime *metav1.Time `json:"lastValidationTime,omitempty"`
+	// The results of the checks in order.
+	Checks []ClusterImportCheck `json:"checks,omitempty"`
+	// The api server of the cluster.
+	APIServer string `json:"apiServer,omitempty"`
+	// The kubernetes version of the cluster.
+	Version string `json:"version,omitempty"`
+	// The number of nodes of the cluster.
+	NodeCount int `json:"nodeCount,omitempty"`
+	// The reason the ClusterRegistration could not be created.
+	Reason string `json:"reason,omitempty"`
+	// The name of the created ClusterRegistration.
+	ClusterRegistration string `json:"clusterRegistration,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:path=clusterimportsessions,shortName=clis,scope=Namespaced
+// +kubebuilder:printcolumn:name="ClusterName",type=string,JSONPath=`.spec.clusterName`
+// +kubebuilder:printcolumn:name="Version",type=string,JSONPath=`.status.version`
+// +kubebuilder:printcolumn:name="Confirmed",type=boolean,JSONPath=`.spec.confirmed`
+// +kubebuilder:printcolumn:name="Status",type=string,JSONPath=`.status.phase`
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+// ClusterImportSession is the Schema for the clusterimportsessions API
+type ClusterImportSession struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   ClusterImportSessionSpec   `json:"spec"`
+	Status ClusterImportSessionStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// ClusterImportSessionList contains a list of ClusterImportSession
+type ClusterImportSessionList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ClusterImportSession `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&ClusterImportSession{}, &ClusterImportSessionList{})
+}
+
+func (c *ClusterImportSessionStatus) SetTypedPhase(p ClusterImportSessionPhase) {
+	c.Phase = p
+}
+
+func (c *ClusterImportSession) GetNamespacedName() types.NamespacedName {
+	return types.NamespacedName{
+		Name:      c.Name,
+		Namespace: c.Namespace,
+	}
+}
+
+func (c *ClusterImportSessionStatus) GetCheck(name ClusterImportCheckName) *ClusterImportCheck {
+	for i := range c.Checks {
+		if c.Checks[i].Name == name {
+			return &c.Checks[i]
+		}
+	}
+	return nil
+}
+
+// 실패한 검사가 없으면 import 할 수 있다.
+func (c *ClusterImportSessionStatus) Passed() bool {
+	if len(c.Checks) == 0 {
+		return false
+	}
+	for _, check := range c.Checks {
+		if check.Result == ClusterImportCheckResultFailed || check.Result == ClusterImportCheckResultSkipped {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,123 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+type ClusterKubeconfigPhase string
+
+const (
+	// kubeconfig secret 생성을 기다리는 상태
+	ClusterKubeconfigPhasePending = ClusterKubeconfigPhase("Pending")
+	// kubeconfig secret 이 생성되어 사용자가 조회할 수 있는 상태
+	ClusterKubeconfigPhaseReady = ClusterKubeconfigPhase("Ready")
+	// ttl 이 지나 kubeconfig secret 이 삭제된 상태
+	ClusterKubeconfigPhaseExpired = ClusterKubeconfigPhase("Expired")
+	// 사용자가 cluster 의 멤버가 아니거나 kubeconfig 생성에 실패한 상태
+	ClusterKubeconfigPhaseError = ClusterKubeconfigPhase("Error")
+)
+
+const (
+	// ttl 을 지정하지 않은 경우의 기본값
+	ClusterKubeconfigTTLDefault = 24 * time.Hour
+	// 지정할 수 있는 ttl 의 상한
+	ClusterKubeconfigTTLMax = 7 * 24 * time.Hour
+)
+
+// ClusterKubeconfigSpec defines the desired state of ClusterKubeconfig
+type ClusterKubeconfigSpec struct {
+	// +kubebuilder:validation:Required
+	// The name of the cluster to access.
+	ClusterName string `json:"clusterName"`
+	// The lifetime of the kubeconfig secret. Defaults to 24h and must not exceed 168h.
+	TTL *metav1.Duration `json:"ttl,omitempty"`
+	// The user who requested the kubeconfig. Set by the webhook from the request.
+	User string `json:"user,omitempty"`
+	// The groups of the user. Set by the webhook from the request.
+	Groups []string `json:"groups,omitempty"`
+}
+
+// ClusterKubeconfigStatus defines the observed state of ClusterKubeconfig
+type ClusterKubeconfigStatus struct {
+	// +kubebuilder:validation:Enum=Pending;Ready;Expired;Error;
+	// Phase of the clusterkubeconfig.
+	Phase ClusterKubeconfigPhase `json:"phase,omitempty"`
+	// Reason of the phase.
+	Reason string `json:"reason,omitempty"`
+	// The name of the secret which has the kubeconfig in the value key.
+	SecretName string `json:"secretName,omitempty"`
+	// The time when the kubeconfig secret is deleted.
+	ExpirationTime *metav1.Time `json:"expirationTime,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:path=clusterkubeconfigs,shortName=ckc,scope=Namespaced
+// +kubebuilder:printcolumn:name="Cluster",type=string,JSONPath=`.spec.clusterName`
+// +kubebuilder:printcolumn:name="User",type=string,JSONPath=`.spec.user`
+// +kubebuilder:printcolumn:name="Secret",type=string,JSONPath=`.status.secretName`
+// +kubebuilder:printcolumn:name="Status",type=string,JSONPath=`.status.phase`
+// +kubebuilder:printcolumn:name="Expiration",type=string,JSONPath=`.status.expirationTime`
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+// ClusterKubeconfig is the Schema for the clusterkubeconfigs API
+type ClusterKubeconfig struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   ClusterKubeconfigSpec   `json:"spec"`
+	Status ClusterKubeconfigStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// ClusterKubeconfigList contains a list of ClusterKubeconfig
+type ClusterKubeconfigList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ClusterKubeconfig `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&ClusterKubeconfig{}, &ClusterKubeconfigList{})
+}
+
+func (c *ClusterKubeconfigStatus) SetTypedPhase(p ClusterKubeconfigPhase) {
+	c.Phase = p
+}
+
+func (c *ClusterKubeconfig) GetNamespacedName() types.NamespacedName {
+	return types.NamespacedName{
+		Name:      c.Name,
+		Namespace: c.Namespace,
+	}
+}
+
+// kubeconfig secret 과 secret 을 조회하는 Role, RoleBinding 의 이름
+func (c *ClusterKubeconfig) GetSecretName() string {
+	return c.Name + "-oidc-kubeconfig"
+}
+
+// 생성 시각으로부터 ttl 이 지난 시각
+func (c *ClusterKubeconfig) GetExpirationTime() metav1.Time {
+	ttl := ClusterKubeconfigTTLDefault
+	if c.Spec.TTL != nil {
+		ttl = c.Spec.TTL.Duration
+	}
+	return metav1.NewTime(c.CreationTimestamp.Add(ttl))
+}
@@ -0,0 +1,85 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+const clusterKubeconfigWebhookPath = "/mutate-cluster-tmax-io-v1alpha1-clusterkubeconfig"
+
+// +kubebuilder:webhook:path=/mutate-cluster-tmax-io-v1alpha1-clusterkubeconfig,mutating=true,failurePolicy=fail,groups=cluster.tmax.io,resources=clusterkubeconfigs,verbs=create;update,versions=v1alpha1,name=mutation.webhook.clusterkubeconfig,admissionReviewVersions=v1beta1;v1,sideEffects=None
+
+// ClusterKubeconfigWebhook 은 ClusterKubeconfig 를 생성한 사용자를 spec 에 기록하고 ttl 의 기본값을 설정한다.
+// controller 는 spec 의 사용자로 멤버 여부를 확인하므로, 생성 후에는 spec 을 변경할 수 없다.
+type ClusterKubeconfigWebhook struct {
+	decoder *admission.Decoder
+}
+
+func SetupClusterKubeconfigWebhookWithManager(mgr ctrl.Manager) error {
+	mgr.GetWebhookServer().Register(clusterKubeconfigWebhookPath, &webhook.Admission{
+		Handler: &ClusterKubeconfigWebhook{},
+	})
+	return nil
+}
+
+func (h *ClusterKubeconfigWebhook) InjectDecoder(d *admission.Decoder) error {
+	h.decoder = d
+	return nil
+}
+
+func (h *ClusterKubeconfigWebhook) Handle(ctx context.Context, req admission.Request) admission.Response {
+	ckc := &ClusterKubeconfig{}
+	if err := h.decoder.Decode(req, ckc); err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+
+	if req.Operation == admissionv1.Update {
+		old := &ClusterKubeconfig{}
+		if err := h.decoder.DecodeRaw(req.OldObject, old); err != nil {
+			return admission.Errored(http.StatusBadRequest, err)
+		}
+		if !reflect.DeepEqual(old.Spec, ckc.Spec) {
+			return admission.Denied("spec of ClusterKubeconfig is immutable")
+		}
+		return admission.Allowed("")
+	}
+
+	if ckc.Spec.TTL == nil {
+		ckc.Spec.TTL = &metav1.Duration{Duration: ClusterKubeconfigTTLDefault}
+	}
+	if ckc.Spec.TTL.Duration <= 0 || ckc.Spec.TTL.Duration > ClusterKubeconfigTTLMax {
+		return admission.Denied(fmt.Sprintf("ttl must be greater than 0 and not exceed %s", ClusterKubeconfigTTLMax))
+	}
+	// 다른 사용자의 kubeconfig 를 요청할 수 없도록 요청한 사용자로 덮어쓴다.
+	ckc.Spec.User = req.UserInfo.Username
+	ckc.Spec.Groups = req.UserInfo.Groups
+
+	marshaled, err := json.Marshal(ckc)
+	if err != nil {
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+	return admission.PatchResponseFromRaw(req.Object.Raw, marshaled)
+}
@@ -15,11 +15,16 @@ limitations under the License.
 package v1alpha1
 
 import (
+	"fmt"
+	"strconv"
 	"strings"
+	"time"
 
 	coreV1 "k8s.io/api/core/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
 )
 
 // type NodeInfo struct {
@@ -49,12 +54,189 @@ type ClusterManagerSpec struct {
 	// +kubebuilder:validation:Required
 	// The number of worker node
 	WorkerNum int `json:"workerNum"`
+	// Set true to pause the reconciliation of the cluster api resources (cluster, machines) of the created cluster.
+	// Scaling and upgrade are not allowed while paused.
+	Paused bool `json:"paused,omitempty"`
+	// The additional worker node pools of the created cluster.
+	NodePools []NodePoolSpec `json:"nodePools,omitempty"`
+	// The labels and taints which are enforced on the nodes of the cluster.
+	NodeConfigs []NodeConfig `json:"nodeConfigs,omitempty"`
+	// The addons installed on the cluster by the argocd application of the cluster.
+	Addons *ClusterAddons `json:"addons,omitempty"`
+	// Set to create the cluster from a ClusterClass instead of the provider template.
+	// Cannot be added or removed after the cluster is created.
+	Topology *ClusterTopology `json:"topology,omitempty"`
+	// Set to cordon all nodes of the cluster and evict their pods before the maintenance or deletion.
+	// The pods are evicted with the eviction api, so the PodDisruptionBudgets are respected.
+	// Removing it uncordons the nodes cordoned by the drain.
+	Drain *ClusterDrain `json:"drain,omitempty"`
+	// Set to run the disruptive operations such as upgrade, addon update and certificate renewal only inside the window.
+	// The operations started inside the window are not stopped at the end of the window.
+	MaintenanceWindow *MaintenanceWindow `json:"maintenanceWindow,omitempty"`
+	// The pod and service networks of the created cluster. Set both IPv4 and IPv6 cidrs for a dual-stack cluster.
+	// Cannot be updated after the cluster is created.
+	Network *ClusterNetwork `json:"network,omitempty"`
 	// The version of kubernetes
 	// KubernetesVersion string `json:"kubernetesVersion"`
 	// The owner of cluster
 	// Owner string `json:"owner"`
 }
 
+// NodePoolSpec defines an additional worker node pool of the created cluster
+type NodePoolSpec struct {
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:Pattern:=^[a-z0-9]([-a-z0-9]*[a-z0-9])?$
+	// The name of the node pool.
+	Name string `json:"name"`
+	// +kubebuilder:validation:Minimum:=0
+	// The number of nodes in the pool.
+	Replicas int `json:"replicas"`
+	// +kubebuilder:validation:Enum:=linux;windows
+	// The operating system of the nodes. Windows is only supported by the vSphere provider. Defaults to linux.
+	OsType string `json:"osType,omitempty"`
+	// +kubebuilder:validation:Enum:=amd64;arm64
+	// The cpu architecture of the nodes. The arm64 nodes are only supported by the vSphere provider with an arm64 vcenterTemplate. Defaults to amd64.
+	Architecture string `json:"architecture,omitempty"`
+	// The VM template of the nodes for the vSphere provider. Required for windows. Defaults to the template of the worker nodes.
+	VcenterTemplate string `json:"vcenterTemplate,omitempty"`
+	// Set true to create the nodes as spot instances. Only supported by the AWS provider.
+	// The spot nodes are labeled with node.kubernetes.io/lifecycle=spot.
+	Spot bool `json:"spot,omitempty"`
+	// The maximum hourly price of the spot instances. Defaults to the on-demand price.
+	SpotMaxPrice string `json:"spotMaxPrice,omitempty"`
+	// The rolling update strategy of the nodes used for the upgrade. Defaults to the cluster api defaults.
+	Strategy *NodePoolStrategy `json:"strategy,omitempty"`
+}
+
+// NodePoolStrategy defines how the nodes of the node pool are replaced during the rolling update
+type NodePoolStrategy struct {
+	// The maximum number of nodes that can be created over the desired number of nodes. Value can be an absolute number or a percentage. Defaults to 1.
+	MaxSurge *intstr.IntOrString `json:"maxSurge,omitempty"`
+	// The maximum number of nodes that can be unavailable during the update. Value can be an absolute number or a percentage. Defaults to 0.
+	MaxUnavailable *intstr.IntOrString `json:"maxUnavailable,omitempty"`
+	// The total amount of time to drain a node before deleting it. Waits forever if not set.
+	// Changing it replaces the nodes of the pool.
+	NodeDrainTimeout *metav1.Duration `json:"nodeDrainTimeout,omitempty"`
+}
+
+// ClusterDrain defines how the nodes of the cluster are drained
+type ClusterDrain struct {
+	// The maximum time to wait for the pods to be evicted. The drain is stopped as timed out after it, and the nodes remain cordoned.
+	// Waits forever if not set.
+	Timeout *metav1.Duration `json:"timeout,omitempty"`
+}
+
+// ClusterNetwork defines the pod and service networks of the created cluster
+type ClusterNetwork struct {
+	// +kubebuilder:validation:MaxItems=2
+	// The cidr blocks for pods. The first one is the primary ip family of the cluster.
+	// Set an IPv4 and an IPv6 cidr for a dual-stack cluster. Example: [10.0.0.0/16, fd00:10::/56]
+	PodCIDRs []string `json:"podCidrs,omitempty"`
+	// +kubebuilder:validation:MaxItems=2
+	// The cidr blocks for services. Must have the same ip families in the same order as podCidrs.
+	// Example: [10.96.0.0/12, fd00:20::/108]
+	ServiceCIDRs []string `json:"serviceCidrs,omitempty"`
+}
+
+// MaintenanceWindow defines the recurring window in which the disruptive operations are allowed
+type MaintenanceWindow struct {
+	// +kubebuilder:validation:Required
+	// The start of the window in cron format (minute hour day-of-month month day-of-week), evaluated in UTC.
+	Schedule string `json:"schedule"`
+	// +kubebuilder:validation:Required
+	// The length of the window.
+	Duration metav1.Duration `json:"duration"`
+}
+
+// ClusterTopology defines the ClusterClass and the variables used to create the cluster
+type ClusterTopology struct {
+	// +kubebuilder:validation:Required
+	// The name of the ClusterClass in the namespace of the ClusterManager.
+	Class string `json:"class"`
+	// The name of the machine deployment class of the worker nodes. Defaults to the first machine deployment class of the ClusterClass.
+	WorkerClass string `json:"workerClass,omitempty"`
+	// The values of the variables defined in the ClusterClass.
+	Variables []TopologyVariable `json:"variables,omitempty"`
+}
+
+// TopologyVariable defines the value of a variable of the ClusterClass
+type TopologyVariable struct {
+	// +kubebuilder:validation:Required
+	// The name of the variable.
+	Name string `json:"name"`
+	// +kubebuilder:validation:Required
+	// The value of the variable. Validated against the schema of the variable in the ClusterClass.
+	Value apiextensionsv1.JSON `json:"value"`
+}
+
+// NodeConfig defines the labels and taints of the nodes selected by the selector
+type NodeConfig struct {
+	// The node labels to select the nodes. Selects all nodes if empty.
+	Selector map[string]string `json:"selector,omitempty"`
+	// The labels to set on the selected nodes.
+	Labels map[string]string `json:"labels,omitempty"`
+	// The taints to set on the selected nodes.
+	Taints []coreV1.Taint `json:"taints,omitempty"`
+}
+
+// ClusterAddons defines the addons installed on the cluster
+type ClusterAddons struct {
+	// The cert-manager addon. A ClusterIssuer for HC_DOMAIN is created with the DNS01 solver.
+	CertManager *CertManagerAddon `json:"certManager,omitempty"`
+	// The audit policy and log shipping of the kube-apiserver. Only for the clusters created by the operator.
+	// Applied by patching the kubeadm control plane, which rolls out the control plane machines.
+	AuditPolicy *AuditPolicyAddon `json:"auditPolicy,omitempty"`
+}
+
+// AuditPolicyAddon defines the audit policy and the audit log backends of the kube-apiserver
+type AuditPolicyAddon struct {
+	// The name of the configmap in the same namespace which has the audit policy in the "policy.yaml" key.
+	// Defaults to the standard audit policy of the operator.
+	PolicyConfigMapName string `json:"policyConfigMapName,omitempty"`
+	// +kubebuilder:validation:Minimum=1
+	// The days to retain the audit log files on the control plane nodes. Defaults to 30.
+	MaxAge int `json:"maxAge,omitempty"`
+	// +kubebuilder:validation:Minimum=1
+	// The number of the audit log files to retain. Defaults to 10.
+	MaxBackup int `json:"maxBackup,omitempty"`
+	// +kubebuilder:validation:Minimum=1
+	// The megabytes of an audit log file before it is rotated. Defaults to 100.
+	MaxSize int `json:"maxSize,omitempty"`
+	// The webhook backend which ships the audit events to the log collector.
+	Webhook *AuditWebhookBackend `json:"webhook,omitempty"`
+}
+
+// AuditWebhookBackend defines the log collector receiving the audit events
+type AuditWebhookBackend struct {
+	// +kubebuilder:validation:Required
+	// The name of the secret in the same namespace which has the url of the log collector in the "url" key,
+	// and optionally the PEM encoded CA certificate of the collector in the "ca.crt" key.
+	SecretName string `json:"secretName"`
+}
+
+// CertManagerAddon defines the cert-manager addon and its ClusterIssuer
+type CertManagerAddon struct {
+	// +kubebuilder:validation:Required
+	// The email address registered to the ACME server.
+	Email string `json:"email"`
+	// +kubebuilder:validation:Required
+	// The DNS01 solver of the ClusterIssuer.
+	DNS01 CertManagerDNS01 `json:"dns01"`
+}
+
+// CertManagerDNS01 defines the DNS provider and its credentials for the DNS01 challenge
+type CertManagerDNS01 struct {
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:Enum:=route53;cloudflare
+	// The DNS provider which hosts the zone of HC_DOMAIN.
+	Provider string `json:"provider"`
+	// +kubebuilder:validation:Required
+	// The name of the secret in the same namespace which has the credentials of the DNS provider.
+	// route53 requires access-key-id, secret-access-key and region keys, cloudflare requires api-token key.
+	// Only the required keys are copied to the cert-manager namespace of the cluster.
+	CredentialsSecretName string `json:"credentialsSecretName"`
+}
+
 // ProviderAwsSpec defines
 type ProviderAwsSpec struct {
 	// The region where VM is working
@@ -127,12 +309,220 @@ type ClusterManagerStatus struct {
 	AuthClientReady       bool                    `json:"authClientReady,omitempty"`
 	OpenSearchReady       bool                    `json:"openSearchReady,omitempty"`
 	ApplicationLink       string                  `json:"applicationLink,omitempty"`
+	// The last time the api server of the cluster responded to the health check.
+	LastHeartbeatTime *metav1.Time `json:"lastHeartbeatTime,omitempty"`
+	// Conditions of the cluster.
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+	// The kubernetes distribution of the cluster. One of kubeadm, rke2, k3s, eks, gke, aks
+	Distribution string `json:"distribution,omitempty"`
+	// The platform which manages the registered cluster. (e.g. rancher)
+	ManagedBy string `json:"managedBy,omitempty"`
+	// The node pools of the cluster. For the registered cluster, they are imported from the node labels and the machine annotations.
+	// For the created cluster, they are the node pools of spec.nodePools.
+	NodePools []NodePool `json:"nodePools,omitempty"`
+	// The cost of the cluster in the current month. Set only when the pricing configmap is configured.
+	Cost *ClusterCost `json:"cost,omitempty"`
+	// The aggregated allocatable resources and requests of the worker nodes of the cluster.
+	Capacity *ClusterCapacity `json:"capacity,omitempty"`
+	// The usage of the apis which are removed in the next minor version of kubernetes.
+	DeprecatedAPIs *DeprecatedAPIScan `json:"deprecatedAPIs,omitempty"`
+	// The identity of the cloud provider detected from the nodes of the cluster.
+	ProviderIdentity *ProviderIdentity `json:"providerIdentity,omitempty"`
+	// The progress of spec.drain.
+	Drain *ClusterDrainStatus `json:"drain,omitempty"`
+	// The current and next window of spec.maintenanceWindow.
+	MaintenanceWindow *MaintenanceWindowStatus `json:"maintenanceWindow,omitempty"`
+	// The endpoints of the cluster. Replaces the deprecated apiserver, gateway and domain annotations,
+	// which are kept mirrored from these fields until all clients migrate.
+	Endpoints *ClusterEndpoints `json:"endpoints,omitempty"`
+	// The results of the lifecycle hooks run for the cluster.
+	LifecycleHooks []LifecycleHookStatus `json:"lifecycleHooks,omitempty"`
+	// The expiry of the TLS certificates of the cluster.
+	Certificates *CertificateInventory `json:"certificates,omitempty"`
 
 	// will be deprecated
 	PrometheusReady bool `json:"prometheusReady,omitempty"`
 	// HyperregistryOidcReady bool                    `json:"hyperregistryOidcReady,omitempty"`
 }
 
+// NodePool is a group of nodes which are created from the same machine deployment or node group
+type NodePool struct {
+	Name string `json:"name"`
+	// The role of nodes in the pool. One of master, worker
+	Role         string `json:"role,omitempty"`
+	InstanceType string `json:"instanceType,omitempty"`
+	Replicas     int    `json:"replicas"`
+	Ready        int    `json:"ready"`
+	// Whether the nodes are spot instances.
+	Spot bool `json:"spot,omitempty"`
+	// The number of spot nodes which are evicted and replaced.
+	Evictions int `json:"evictions,omitempty"`
+	// The last time a spot node was evicted.
+	LastEvictionTime *metav1.Time `json:"lastEvictionTime,omitempty"`
+}
+
+// ClusterDrainStatus is the progress of the drain of the nodes of the cluster
+type ClusterDrainStatus struct {
+	// One of Draining, Drained, TimedOut
+	Phase ClusterDrainPhase `json:"phase"`
+	// The time the drain was started.
+	StartTime *metav1.Time `json:"startTime,omitempty"`
+	// The time all pods were evicted or the drain timed out.
+	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
+	// The drain progress of each node.
+	Nodes []NodeDrainStatus `json:"nodes,omitempty"`
+}
+
+// MaintenanceWindowStatus is the current and next maintenance window
+type MaintenanceWindowStatus struct {
+	// Whether the disruptive operations are allowed now.
+	Active bool `json:"active"`
+	// The end of the current window if active.
+	CurrentWindowEnd *metav1.Time `json:"currentWindowEnd,omitempty"`
+	// The start of the next window.
+	NextWindowStart *metav1.Time `json:"nextWindowStart,omitempty"`
+}
+
+// ClusterEndpoints defines the endpoints used to reach the cluster
+type ClusterEndpoints struct {
+	// The host of the api server of the cluster.
+	APIServer string `json:"apiServer,omitempty"`
+	// The hostname or ip of the load balancer of the api gateway in the cluster.
+	Gateway string `json:"gateway,omitempty"`
+	// The domain of hypercloud. The cluster is served at multicluster.<domain>.
+	Domain string `json:"domain,omitempty"`
+}
+
+// NodeDrainStatus is the drain progress of a node
+type NodeDrainStatus struct {
+	Name string `json:"name"`
+	// One of Draining, Drained
+	Phase NodeDrainPhase `json:"phase"`
+	// The number of pods which are not evicted yet. DaemonSet pods and static pods are not counted.
+	PodsRemaining int `json:"podsRemaining,omitempty"`
+	// The reason why the pods are not evicted yet. (e.g. blocked by the PodDisruptionBudget)
+	Message string `json:"message,omitempty"`
+}
+
+type ClusterDrainPhase string
+
+const (
+	ClusterDrainPhaseDraining = ClusterDrainPhase("Draining")
+	ClusterDrainPhaseDrained  = ClusterDrainPhase("Drained")
+	ClusterDrainPhaseTimedOut = ClusterDrainPhase("TimedOut")
+)
+
+type NodeDrainPhase string
+
+const (
+	NodeDrainPhaseDraining = NodeDrainPhase("Draining")
+	NodeDrainPhaseDrained  = NodeDrainPhase("Drained")
+)
+
+// ProviderIdentity is the cloud account and region of the nodes detected from their providerID and labels
+type ProviderIdentity struct {
+	// The scheme of the providerID of the nodes. (e.g. aws, gce, azure, vsphere)
+	ProviderIDPrefix string `json:"providerIDPrefix,omitempty"`
+	// The aws account id, azure subscription id or gcp project of the nodes.
+	Account string `json:"account,omitempty"`
+	// The region of the nodes.
+	Region string `json:"region,omitempty"`
+}
+
+// ClusterCost is the cost of the cluster accumulated from the hourly price of the running nodes
+type ClusterCost struct {
+	// The month of the cost. Format: 2006-01
+	Month string `json:"month"`
+	// The accumulated cost from the beginning of the month. Decimal string.
+	MonthToDate string `json:"monthToDate"`
+	// The sum of the hourly price of the running nodes. Decimal string.
+	HourlyRate string `json:"hourlyRate,omitempty"`
+	// The currency of the price in the pricing configmap.
+	Currency string `json:"currency,omitempty"`
+	// The number of running nodes at the last update.
+	RunningNodes int `json:"runningNodes,omitempty"`
+	// The hours in the month while any node of the cluster was running. Decimal string.
+	UptimeHours string `json:"uptimeHours,omitempty"`
+	// The hours in the month while no node of the cluster was running. (e.g. hibernated) Decimal string.
+	HibernatedHours string `json:"hibernatedHours,omitempty"`
+	// The last time the cost was accumulated.
+	LastUpdateTime *metav1.Time `json:"lastUpdateTime,omitempty"`
+}
+
+// ClusterCapacity is the sum of the resources of the ready and schedulable worker nodes of the cluster
+type ClusterCapacity struct {
+	// The number of ready and schedulable worker nodes.
+	Workers int `json:"workers"`
+	// The sum of the allocatable cpu and memory of the workers.
+	Allocatable coreV1.ResourceList `json:"allocatable,omitempty"`
+	// The sum of the cpu and memory requests of the pods running on the workers.
+	Requested coreV1.ResourceList `json:"requested,omitempty"`
+	// The last time the capacity was aggregated.
+	LastUpdateTime *metav1.Time `json:"lastUpdateTime,omitempty"`
+}
+
+// DeprecatedAPIScan is the result of scanning the cluster for the apis removed in the target version
+type DeprecatedAPIScan struct {
+	// The kubernetes version which the scan checked the removed apis for. Example: 1.25
+	TargetVersion string `json:"targetVersion"`
+	// The removed apis which are still used in the cluster.
+	Findings []DeprecatedAPIFinding `json:"findings,omitempty"`
+	// The last time the cluster was scanned.
+	LastScanTime *metav1.Time `json:"lastScanTime,omitempty"`
+}
+
+// DeprecatedAPIFinding is an api which is removed in the target version and still used in the cluster
+type DeprecatedAPIFinding struct {
+	Group    string `json:"group,omitempty"`
+	Version  string `json:"version"`
+	Resource string `json:"resource"`
+	// The kubernetes version which the api is removed in.
+	RemovedRelease string `json:"removedRelease"`
+	// How the usage was found. Request means the api server served requests for the api since it started,
+	// Manifest means the objects were last applied with the api.
+	Source string `json:"source"`
+	// The objects last applied with the api. Only for the Manifest source. At most 10 objects are listed.
+	Objects []string `json:"objects,omitempty"`
+}
+
+type CertificateType string
+
+const (
+	// api server 의 serving 인증서
+	CertificateTypeAPIServer = CertificateType("APIServer")
+	// control plane node 의 etcd serving 인증서
+	CertificateTypeEtcd = CertificateType("Etcd")
+	// capi 가 생성한 cluster, etcd, front proxy 의 CA 인증서
+	CertificateTypeCA = CertificateType("CA")
+	// ingress 의 tls secret 인증서
+	CertificateTypeIngress = CertificateType("Ingress")
+)
+
+// CertificateInventory is the result of the certificate scan of the cluster
+type CertificateInventory struct {
+	// The certificates found in the cluster, sorted by the expiry.
+	Certificates []ClusterCertificate `json:"certificates,omitempty"`
+	// The earliest expiry of the certificates.
+	EarliestExpiry *metav1.Time `json:"earliestExpiry,omitempty"`
+	// The reasons of the certificates which could not be read, such as an unreachable etcd endpoint.
+	Errors []string `json:"errors,omitempty"`
+	// The last time the cluster was scanned.
+	LastScanTime *metav1.Time `json:"lastScanTime,omitempty"`
+}
+
+// ClusterCertificate is a TLS certificate of the cluster and its expiry
+type ClusterCertificate struct {
+	// +kubebuilder:validation:Enum=APIServer;Etcd;CA;Ingress;
+	// The type of the certificate.
+	Type CertificateType `json:"type"`
+	// Where the certificate was read. host:port for the serving certificates and namespace/name of the secret for the others.
+	Name string `json:"name"`
+	// The subject common name of the certificate.
+	Subject string `json:"subject,omitempty"`
+	// The expiry of the certificate.
+	NotAfter metav1.Time `json:"notAfter"`
+}
+
 type ClusterManagerPhase string
 
 const (
@@ -151,6 +541,41 @@ const (
 	ClusterManagerPhaseScaling = ClusterManagerPhase("Scaling")
 )
 
+// ClusterManager 의 condition type
+const (
+	// heartbeat 가 threshold 이상 갱신되지 않아 api server 에 접근할 수 없다고 판단된 상태
+	ClusterManagerConditionUnreachable = "Unreachable"
+	// spec.paused 에 따라 capi cluster 의 reconcile 이 중지된 상태
+	ClusterManagerConditionPaused = "Paused"
+	// spec.nodeConfigs 의 label, taint 가 remote cluster 의 node 에 적용된 상태
+	ClusterManagerConditionNodeConfigSynced = "NodeConfigSynced"
+	// spec.addons.certManager 의 ClusterIssuer 와 DNS01 credential 이 remote cluster 에 배포된 상태
+	ClusterManagerConditionCertManagerReady = "CertManagerReady"
+	// spec.addons.auditPolicy 가 kubeadm control plane 에 반영되고 control plane 의 rollout 이 끝난 상태
+	ClusterManagerConditionAuditPolicyApplied = "AuditPolicyApplied"
+	// kubeadm control plane 의 모든 replica 가 spec 의 version 으로 갱신되고 ready 인 상태
+	ClusterManagerConditionControlPlaneRolledOut = "ControlPlaneRolledOut"
+	// cluster 의 모든 machine deployment 의 replica 가 갱신되고 ready 인 상태
+	ClusterManagerConditionWorkersRolledOut = "WorkersRolledOut"
+	// 등록된 cluster 의 control plane 또는 kubelet version 이 지원하는 version 목록에 없는 상태
+	ClusterManagerConditionOutdatedVersion = "OutdatedVersion"
+	// spec.drain 에 따라 모든 node 가 cordon 되고 pod 가 evict 된 상태
+	ClusterManagerConditionDrained = "Drained"
+	// 각 lifecycle point 의 hook 이 모두 성공한 상태
+	ClusterManagerConditionPreCreateHooksSucceeded  = "PreCreateHooksSucceeded"
+	ClusterManagerConditionPostCreateHooksSucceeded = "PostCreateHooksSucceeded"
+	ClusterManagerConditionPreDeleteHooksSucceeded  = "PreDeleteHooksSucceeded"
+	// cluster 의 인증서 중 만료되었거나 CERT_EXPIRY_WARNING_THRESHOLD 안에 만료되는 인증서가 있는 상태
+	ClusterManagerConditionCertificatesExpiring = "CertificatesExpiring"
+	// remote cluster 에 설치된 addon 의 workload 가 준비되어 있고 필요한 CRD 가 등록된 상태
+	ClusterManagerConditionArgoAddonHealthy       = "ArgoAddonHealthy"
+	ClusterManagerConditionIngressAddonHealthy    = "IngressAddonHealthy"
+	ClusterManagerConditionCNIAddonHealthy        = "CNIAddonHealthy"
+	ClusterManagerConditionMonitoringAddonHealthy = "MonitoringAddonHealthy"
+	// membership db 에 쓰지 못한 요청이 PendingDBWrite 로 남아있는 상태
+	ClusterManagerConditionDBDegraded = "DBDegraded"
+)
+
 // deprecated phases
 const (
 	ClusterManagerDeprecatedPhasePending      = ClusterManagerPhase("Pending")
@@ -194,23 +619,50 @@ const (
 	// ClusterManagerPhaseUnknown = ClusterManagerPhase("Unknown")
 
 	ClusterManagerFinalizer = "clustermanager.cluster.tmax.io/finalizer"
+	// karmada / ocm hub 에서 cluster 를 분리하기 위한 finalizer
+	ClusterManagerHubAttachmentFinalizer = "hubattachment.cluster.tmax.io/finalizer"
 
 	// ClusterTypeCreated    = ClusterType("created")
 	// ClusterTypeRegistered = ClusterType("registered")
 	ClusterTypeCreated    = "created"
 	ClusterTypeRegistered = "registered"
 
-	AnnotationKeyClmApiserver      = "clustermanager.cluster.tmax.io/apiserver"
-	AnnotationKeyClmGateway        = "clustermanager.cluster.tmax.io/gateway"
-	AnnotationKeyClmSuffix         = "clustermanager.cluster.tmax.io/suffix"
-	AnnotationKeyClmDomain         = "clustermanager.cluster.tmax.io/domain"
+	AnnotationKeyClmSuffix = "clustermanager.cluster.tmax.io/suffix"
+	// Deprecated: status.endpoints 를 사용한다. 기존 client 를 위해 status.endpoints 의 값이 계속 기록된다.
+	AnnotationKeyClmApiserver   = "clustermanager.cluster.tmax.io/apiserver"
+	AnnotationKeyClmGateway     = "clustermanager.cluster.tmax.io/gateway"
+	AnnotationKeyClmDomain      = "clustermanager.cluster.tmax.io/domain"
+	AnnotationKeyClmHubAttached = "clustermanager.cluster.tmax.io/hub-attached"
 
 	LabelKeyClmName               = "clustermanager.cluster.tmax.io/clm-name"
 	LabelKeyClmNamespace          = "clustermanager.cluster.tmax.io/clm-namespace"
 	LabelKeyClcName               = "clustermanager.cluster.tmax.io/clc-name"
+	LabelKeyClcNamespace          = "clustermanager.cluster.tmax.io/clc-namespace"
 	LabelKeyClrName               = "clustermanager.cluster.tmax.io/clr-name"
 	LabelKeyClmClusterType        = "clustermanager.cluster.tmax.io/cluster-type"
 	LabelKeyClmClusterTypeDefunct = "type"
+	// node pool 의 machine deployment, machine, node 에 다는 label
+	LabelKeyClmNodePool = "clustermanager.cluster.tmax.io/node-pool"
+	// eviction 수에 이미 반영한 spot machine 에 다는 annotation
+	AnnotationKeyClmEvictionCounted = "clustermanager.cluster.tmax.io/eviction-counted"
+	// spec.nodeConfigs 로 remote cluster 의 node 에 적용한 label, taint 를 기록하는 annotation
+	AnnotationKeyClmNodeConfig = "clustermanager.cluster.tmax.io/node-config"
+	// spec.drain 으로 cordon 한 remote cluster 의 node 에 다는 annotation
+	// spec.drain 이 제거되면 이 annotation 이 있는 node 만 uncordon 한다.
+	AnnotationKeyClmDrainCordoned = "clustermanager.cluster.tmax.io/drain-cordoned"
+	// cluster 별로 health check 와 resync 주기를 변경하는 annotation (예: 30s, 10m)
+	// ClusterRegistration 에 달면 생성되는 ClusterManager 에 복사된다.
+	AnnotationKeyClmHealthCheckInterval = "clustermanager.cluster.tmax.io/health-check-interval"
+	AnnotationKeyClmResyncPeriod        = "clustermanager.cluster.tmax.io/resync-period"
+	// 삭제가 시작된 후 실패한 finalizer 단계를 건너뛰기까지 기다리는 시간 (예: 30m)
+	// 설정하지 않으면 FINALIZER_TIMEOUT 을 따른다.
+	AnnotationKeyClmDeletionTimeout = "clustermanager.cluster.tmax.io/deletion-timeout"
+	// "true" 로 설정하면 삭제 중 실패한 finalizer 단계를 기다리지 않고 건너뛴다.
+	// member cluster 나 db 에 더 이상 접근할 수 없는 cluster 를 삭제할 때 사용한다.
+	AnnotationKeyClmForceDelete = "clustermanager.cluster.tmax.io/force-delete"
+	// fleet 전체에 적용하는 변경을 cluster 에 적용하는 순서를 정하는 annotation (정수, 클수록 먼저 적용한다. 기본값 0)
+	// 개발, canary cluster 에 높은 값을 주어 운영 cluster 보다 먼저 변경을 받도록 할 수 있다.
+	AnnotationKeyClmFanOutPriority = "clustermanager.cluster.tmax.io/fanout-priority"
 
 	// LabelKeyClmClusterTypeDefunct = "type"
 	// LabelKeyClcNameDefunct = "parent"
@@ -222,6 +674,21 @@ const (
 	ProviderVSphere = "vSphere"
 )
 
+const (
+	OsTypeLinux   = "linux"
+	OsTypeWindows = "windows"
+)
+
+const (
+	ArchitectureAmd64 = "amd64"
+	ArchitectureArm64 = "arm64"
+)
+
+const (
+	CertManagerDNS01ProviderRoute53    = "route53"
+	CertManagerDNS01ProviderCloudflare = "cloudflare"
+)
+
 // +kubebuilder:object:root=true
 // +kubebuilder:subresource:status
 // +kubebuilder:resource:path=clustermanagers,scope=Namespaced,shortName=clm
@@ -256,6 +723,22 @@ func init() {
 	SchemeBuilder.Register(&ClusterManager{}, &ClusterManagerList{})
 }
 
+// osType 을 지정하지 않으면 linux 로 본다.
+func (p *NodePoolSpec) GetOsType() string {
+	if p.OsType == "" {
+		return OsTypeLinux
+	}
+	return p.OsType
+}
+
+// architecture 를 지정하지 않으면 amd64 로 본다.
+func (p *NodePoolSpec) GetArchitecture() string {
+	if p.Architecture == "" {
+		return ArchitectureAmd64
+	}
+	return p.Architecture
+}
+
 func (c *ClusterManager) GetNamespacedName() types.NamespacedName {
 	return types.NamespacedName{
 		Name:      c.Name,
@@ -274,11 +757,142 @@ func (c *ClusterManager) GetClusterType() string {
 	return ""
 }
 
+// hyperauth client 의 secret 을 저장하는 secret name
+func (c *ClusterManager) GetHyperAuthClientSecretName() string {
+	return c.Name + "-hyperauth-client"
+}
+
+// member cluster 의 webhook token authentication 설정을 저장하는 secret name
+func (c *ClusterManager) GetAuthnWebhookSecretName() string {
+	return c.Name + "-authn-webhook"
+}
+
+// karmada / ocm hub 에 등록할 cluster name
+func (c *ClusterManager) GetHubClusterName() string {
+	return c.GetNamespacedPrefix()
+}
+
+// argocd AppProject name
+func (c *ClusterManager) GetAppProjectName() string {
+	return c.GetNamespacedPrefix()
+}
+
 // single app of apps application name
 func (c *ClusterManager) GetApplicationName() string {
 	return c.GetNamespacedPrefix() + "-applications"
 }
 
+// status.endpoints 를 우선 사용하고, 아직 이전되지 않은 cluster 는 annotation 을 사용한다.
+func (c *ClusterManager) GetAPIServerEndpoint() string {
+	if c.Status.Endpoints != nil && c.Status.Endpoints.APIServer != "" {
+		return c.Status.Endpoints.APIServer
+	}
+	return c.Annotations[AnnotationKeyClmApiserver]
+}
+
+func (c *ClusterManager) GetGatewayEndpoint() string {
+	if c.Status.Endpoints != nil && c.Status.Endpoints.Gateway != "" {
+		return c.Status.Endpoints.Gateway
+	}
+	return c.Annotations[AnnotationKeyClmGateway]
+}
+
+func (c *ClusterManager) GetDomain() string {
+	if c.Status.Endpoints != nil && c.Status.Endpoints.Domain != "" {
+		return c.Status.Endpoints.Domain
+	}
+	return c.Annotations[AnnotationKeyClmDomain]
+}
+
+func (c *ClusterManagerStatus) GetEndpoints() *ClusterEndpoints {
+	if c.Endpoints == nil {
+		c.Endpoints = &ClusterEndpoints{}
+	}
+	return c.Endpoints
+}
+
+func (c *ClusterManager) GetCertManagerAddon() *CertManagerAddon {
+	if c.Spec.Addons == nil {
+		return nil
+	}
+	return c.Spec.Addons.CertManager
+}
+
+func (c *ClusterManager) GetAuditPolicyAddon() *AuditPolicyAddon {
+	if c.Spec.Addons == nil {
+		return nil
+	}
+	return c.Spec.Addons.AuditPolicy
+}
+
+// annotation 으로 지정할 수 있는 주기의 하한
+const MinIntervalOverride = 10 * time.Second
+
+var IntervalOverrideAnnotations = []string{
+	AnnotationKeyClmHealthCheckInterval,
+	AnnotationKeyClmResyncPeriod,
+	AnnotationKeyClmDeletionTimeout,
+}
+
+// 주기를 변경하는 annotation 의 값이 하한 이상의 duration 인지 확인한다.
+func ValidateIntervalOverrides(annotations map[string]string) error {
+	for _, key := range IntervalOverrideAnnotations {
+		value, ok := annotations[key]
+		if !ok {
+			continue
+		}
+		interval, err := time.ParseDuration(value)
+		if err != nil {
+			return fmt.Errorf("invalid annotation %s: %w", key, err)
+		}
+		if interval < MinIntervalOverride {
+			return fmt.Errorf("invalid annotation %s: must be at least %s", key, MinIntervalOverride)
+		}
+	}
+	return nil
+}
+
+// annotation 으로 지정한 주기를 반환한다. 설정되지 않았거나 잘못된 경우 defaultInterval 을 반환한다.
+func (c *ClusterManager) GetIntervalOverride(key string, defaultInterval time.Duration) time.Duration {
+	value, ok := c.Annotations[key]
+	if !ok {
+		return defaultInterval
+	}
+	interval, err := time.ParseDuration(value)
+	if err != nil || interval < MinIntervalOverride {
+		return defaultInterval
+	}
+	return interval
+}
+
+// fanout-priority annotation 의 값을 반환한다. 설정되지 않았거나 잘못된 경우 0 을 반환한다.
+func (c *ClusterManager) GetFanOutPriority() int {
+	priority, err := strconv.Atoi(c.Annotations[AnnotationKeyClmFanOutPriority])
+	if err != nil {
+		return 0
+	}
+	return priority
+}
+
+// force-delete annotation 이 있거나 삭제 deadline 이 지난 경우, 삭제 중 실패한 finalizer 단계를 건너뛸 수 있다.
+// 건너뛸 수 있으면 그 이유를 함께 반환한다. defaultTimeout 이 0 이면 deadline 을 두지 않는다.
+func (c *ClusterManager) FinalizerSkipReason(defaultTimeout time.Duration, now time.Time) (string, bool) {
+	if c.DeletionTimestamp.IsZero() {
+		return "", false
+	}
+	if strings.EqualFold(c.Annotations[AnnotationKeyClmForceDelete], "true") {
+		return "force-delete annotation is set", true
+	}
+	timeout := c.GetIntervalOverride(AnnotationKeyClmDeletionTimeout, defaultTimeout)
+	if timeout <= 0 {
+		return "", false
+	}
+	if deadline := c.DeletionTimestamp.Add(timeout); now.After(deadline) {
+		return "deletion deadline " + deadline.Format(time.RFC3339) + " is exceeded", true
+	}
+	return "", false
+}
+
 func (c *ClusterManagerStatus) SetTypedPhase(p ClusterManagerPhase) {
 	c.Phase = p
 }
@@ -16,8 +16,19 @@ package v1alpha1
 
 import (
 	"errors"
+	"fmt"
+	"net"
+	"reflect"
+	"strings"
 
+	"github.com/robfig/cron"
+	"github.com/tmax-cloud/hypercloud-multi-operator/controllers/metrics"
+
+	coreV1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/util/validation"
+	netutils "k8s.io/utils/net"
 	ctrl "sigs.k8s.io/controller-runtime"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/webhook"
@@ -60,6 +71,10 @@ func (r *ClusterManager) ValidateCreate() error {
 
 // ValidateUpdate implements webhook.Validator so a webhook will be registered for the type
 func (r *ClusterManager) ValidateUpdate(old runtime.Object) error {
+	return metrics.RecordWebhookRejection("ClusterManager", "update", r.validateUpdate(old))
+}
+
+func (r *ClusterManager) validateUpdate(old runtime.Object) error {
 
 	ClusterManagerWebhookLogger.Info("validate update", "name", r.Name)
 	oldClusterManager := old.(*ClusterManager).DeepCopy()
@@ -77,11 +92,67 @@ func (r *ClusterManager) ValidateUpdate(old runtime.Object) error {
 	// 	}
 	// }
 
+	if r.Spec.Paused && !oldClusterManager.Spec.Paused && oldClusterManager.GetClusterType() != ClusterTypeCreated {
+		return errors.New("Only created cluster can be paused")
+	}
+
+	if err := r.validateNodeConfigs(); err != nil {
+		return err
+	}
+
+	if err := r.validateMaintenanceWindow(); err != nil {
+		return err
+	}
+
+	// pod, service network 는 cluster 를 생성할 때 kubeadm 에 설정되므로 변경할 수 없다.
+	if !reflect.DeepEqual(r.Spec.Network, oldClusterManager.Spec.Network) {
+		return errors.New("Cannot update network after the cluster is created")
+	}
+
+	// 이전에 설정된 잘못된 값 때문에 다른 변경이 막히지 않도록 변경된 경우에만 검증한다.
+	for _, key := range IntervalOverrideAnnotations {
+		if r.Annotations[key] != oldClusterManager.Annotations[key] {
+			if err := ValidateIntervalOverrides(r.Annotations); err != nil {
+				return err
+			}
+			break
+		}
+	}
+
+	if len(r.Spec.NodePools) != 0 && oldClusterManager.GetClusterType() != ClusterTypeCreated {
+		return errors.New("Only created cluster can have node pools")
+	}
+
+	if (r.Spec.Topology == nil) != (oldClusterManager.Spec.Topology == nil) {
+		return errors.New("Cannot add or remove topology after the cluster is created")
+	}
+	// topology 로 생성한 cluster 는 topology controller 가 machine deployment 를 관리한다.
+	if r.Spec.Topology != nil && len(r.Spec.NodePools) != 0 {
+		return errors.New("Node pools are not supported for the cluster created from ClusterClass")
+	}
+
 	if oldClusterManager.GetClusterType() == ClusterTypeCreated {
+		// paused 인 경우 capi 가 reconcile 하지 않으므로 scaling, upgrade 를 할 수 없다.
+		if r.Spec.Paused {
+			specChanged := r.Spec.MasterNum != oldClusterManager.Spec.MasterNum ||
+				r.Spec.WorkerNum != oldClusterManager.Spec.WorkerNum ||
+				r.GetK8SVersion() != oldClusterManager.GetK8SVersion() ||
+				!reflect.DeepEqual(r.Spec.NodePools, oldClusterManager.Spec.NodePools) ||
+				!reflect.DeepEqual(r.Spec.Topology, oldClusterManager.Spec.Topology)
+			if specChanged {
+				return errors.New("Cannot update MasterNum, WorkerNum, version, node pools or topology while the cluster is paused")
+			}
+		}
+
+		if err := r.validateNodePools(oldClusterManager); err != nil {
+			return err
+		}
+
 		// version upgrade의 경우
 		if r.GetK8SVersion() != oldClusterManager.GetK8SVersion() {
 			// vsphere의 경우, version과 template을 함께 업데이트해야 함
-			if r.Spec.Provider == ProviderVSphere {
+			// topology 로 생성한 cluster 는 ClusterClass 의 variable 로 template 을 지정한다.
+			if r.Spec.Provider == ProviderVSphere && r.Spec.Topology == nil {
 				if r.VsphereSpec.VcenterTemplate == oldClusterManager.VsphereSpec.VcenterTemplate {
 					return errors.New("For vsphere provider, must update spec.version and vsphereSpec.vcetnerTemplate")
 				}
@@ -121,6 +192,197 @@ func (r *ClusterManager) ValidateUpdate(old runtime.Object) error {
 	return nil
 }
 
+// 유지보수 시간의 schedule 은 cron 형식이어야 하며, 길이가 있어야 한다.
+func (r *ClusterManager) validateMaintenanceWindow() error {
+	window := r.Spec.MaintenanceWindow
+	if window == nil {
+		return nil
+	}
+	if _, err := cron.ParseStandard(window.Schedule); err != nil {
+		return fmt.Errorf("Invalid maintenance window schedule %s: %s", window.Schedule, err.Error())
+	}
+	if window.Duration.Duration <= 0 {
+		return errors.New("Maintenance window duration must be positive")
+	}
+	return nil
+}
+
+// service cidr 은 kube-apiserver 의 제한에 따라 host bit 가 20 bit 를 넘을 수 없다.
+const maxServiceCIDRHostBits = 20
+
+// pod, service cidr 은 하나의 ip family 이거나 IPv4, IPv6 를 하나씩 가진 dual-stack 이어야 한다.
+// kubeadm 은 pod, service cidr 의 ip family 순서가 같아야 하므로 함께 지정된 경우 순서를 비교한다.
+func (n *ClusterNetwork) Validate() error {
+	if n == nil {
+		return nil
+	}
+	podCIDRs, err := parseCIDRs("podCidrs", n.PodCIDRs)
+	if err != nil {
+		return err
+	}
+	serviceCIDRs, err := parseCIDRs("serviceCidrs", n.ServiceCIDRs)
+	if err != nil {
+		return err
+	}
+	for _, cidr := range serviceCIDRs {
+		if ones, bits := cidr.Mask.Size(); bits-ones > maxServiceCIDRHostBits {
+			return fmt.Errorf("Service cidr %s is too large, the prefix length must be at least /%d", cidr, bits-maxServiceCIDRHostBits)
+		}
+	}
+	if len(podCIDRs) != 0 && len(serviceCIDRs) != 0 {
+		if len(podCIDRs) != len(serviceCIDRs) {
+			return errors.New("podCidrs and serviceCidrs must have the same ip families")
+		}
+		for i := range podCIDRs {
+			if netutils.IsIPv6CIDR(podCIDRs[i]) != netutils.IsIPv6CIDR(serviceCIDRs[i]) {
+				return errors.New("podCidrs and serviceCidrs must have the same ip families in the same order")
+			}
+		}
+	}
+	return nil
+}
+
+func parseCIDRs(field string, values []string) ([]*net.IPNet, error) {
+	cidrs := []*net.IPNet{}
+	for _, value := range values {
+		_, cidr, err := net.ParseCIDR(value)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid %s %s: %s", field, value, err.Error())
+		}
+		cidrs = append(cidrs, cidr)
+	}
+	if len(cidrs) > 2 {
+		return nil, fmt.Errorf("%s can have at most 2 cidrs", field)
+	}
+	if len(cidrs) == 2 {
+		if dualStack, _ := netutils.IsDualStackCIDRStrings(values); !dualStack {
+			return nil, fmt.Errorf("%s must have one IPv4 and one IPv6 cidr for a dual-stack cluster", field)
+		}
+	}
+	return cidrs, nil
+}
+
+// node pool 의 machine deployment 이름은 <cluster>-<pool> 이므로 label value 길이 제한을 넘지 않아야 한다.
+// windows, arm64 node 는 vsphere provider 의 windows, arm64 template 으로만 생성할 수 있다.
+func (r *ClusterManager) validateNodePools(old *ClusterManager) error {
+	oldPools := map[string]NodePoolSpec{}
+	for _, pool := range old.Spec.NodePools {
+		oldPools[pool.Name] = pool
+	}
+
+	names := map[string]bool{}
+	for _, pool := range r.Spec.NodePools {
+		if names[pool.Name] {
+			return fmt.Errorf("Duplicated node pool name %s", pool.Name)
+		}
+		names[pool.Name] = true
+
+		if len(r.Name)+1+len(pool.Name) > 63 {
+			return fmt.Errorf("Node pool name %s is too long for the cluster %s", pool.Name, r.Name)
+		}
+
+		if pool.OsType == OsTypeWindows {
+			if !strings.EqualFold(r.Spec.Provider, ProviderVSphere) {
+				return fmt.Errorf("Windows node pool %s is only supported by the vSphere provider", pool.Name)
+			}
+			if pool.VcenterTemplate == "" {
+				return fmt.Errorf("Windows node pool %s requires vcenterTemplate", pool.Name)
+			}
+		}
+
+		// worker 의 template 은 amd64 이미지이므로 arm64 이미지의 template 을 지정해야 한다.
+		if pool.GetArchitecture() == ArchitectureArm64 {
+			if !strings.EqualFold(r.Spec.Provider, ProviderVSphere) {
+				return fmt.Errorf("Arm64 node pool %s is only supported by the vSphere provider", pool.Name)
+			}
+			if pool.VcenterTemplate == "" {
+				return fmt.Errorf("Arm64 node pool %s requires vcenterTemplate", pool.Name)
+			}
+			if pool.GetOsType() == OsTypeWindows {
+				return fmt.Errorf("Windows node pool %s cannot be arm64", pool.Name)
+			}
+		}
+
+		if pool.Spot && !strings.EqualFold(r.Spec.Provider, ProviderAWS) {
+			return fmt.Errorf("Spot node pool %s is only supported by the AWS provider", pool.Name)
+		}
+		if !pool.Spot && pool.SpotMaxPrice != "" {
+			return fmt.Errorf("spotMaxPrice of the node pool %s requires spot", pool.Name)
+		}
+
+		if err := pool.Strategy.validate(); err != nil {
+			return fmt.Errorf("Invalid strategy of the node pool %s: %s", pool.Name, err.Error())
+		}
+
+		// 이미 생성된 node 의 os, architecture, template, spot 설정은 변경할 수 없다.
+		if oldPool, ok := oldPools[pool.Name]; ok {
+			if pool.GetOsType() != oldPool.GetOsType() ||
+				pool.GetArchitecture() != oldPool.GetArchitecture() ||
+				pool.VcenterTemplate != oldPool.VcenterTemplate ||
+				pool.Spot != oldPool.Spot ||
+				pool.SpotMaxPrice != oldPool.SpotMaxPrice {
+				return fmt.Errorf("Cannot update osType, architecture, vcenterTemplate or spot of the node pool %s", pool.Name)
+			}
+		}
+	}
+	return nil
+}
+
+// maxSurge, maxUnavailable 이 모두 0 이면 node 를 교체할 수 없으므로 capi 의 검증과 같이 거부한다.
+func (s *NodePoolStrategy) validate() error {
+	if s == nil {
+		return nil
+	}
+	// 지정하지 않으면 capi 의 기본값인 maxSurge 1, maxUnavailable 0 이 적용된다.
+	maxSurge, maxUnavailable := 1, 0
+	var err error
+	if s.MaxSurge != nil {
+		if maxSurge, err = intstr.GetScaledValueFromIntOrPercent(s.MaxSurge, 100, true); err != nil {
+			return fmt.Errorf("maxSurge: %s", err.Error())
+		}
+	}
+	if s.MaxUnavailable != nil {
+		if maxUnavailable, err = intstr.GetScaledValueFromIntOrPercent(s.MaxUnavailable, 100, false); err != nil {
+			return fmt.Errorf("maxUnavailable: %s", err.Error())
+		}
+	}
+	if maxSurge < 0 || maxUnavailable < 0 {
+		return errors.New("maxSurge and maxUnavailable cannot be negative")
+	}
+	if maxSurge == 0 && maxUnavailable == 0 {
+		return errors.New("maxSurge and maxUnavailable cannot be both 0")
+	}
+	if s.NodeDrainTimeout != nil && s.NodeDrainTimeout.Duration < 0 {
+		return errors.New("nodeDrainTimeout cannot be negative")
+	}
+	return nil
+}
+
+// node 에 적용할 수 없는 label, taint 는 remote cluster 에서 거부되므로 미리 검사한다.
+func (r *ClusterManager) validateNodeConfigs() error {
+	for _, nodeConfig := range r.Spec.NodeConfigs {
+		for key, value := range nodeConfig.Labels {
+			if errs := validation.IsQualifiedName(key); len(errs) != 0 {
+				return fmt.Errorf("Invalid node label key %s: %s", key, strings.Join(errs, ", "))
+			}
+			if errs := validation.IsValidLabelValue(value); len(errs) != 0 {
+				return fmt.Errorf("Invalid node label value %s: %s", value, strings.Join(errs, ", "))
+			}
+		}
+		for _, taint := range nodeConfig.Taints {
+			if errs := validation.IsQualifiedName(taint.Key); len(errs) != 0 {
+				return fmt.Errorf("Invalid taint key %s: %s", taint.Key, strings.Join(errs, ", "))
+			}
+			switch taint.Effect {
+			case coreV1.TaintEffectNoSchedule, coreV1.TaintEffectPreferNoSchedule, coreV1.TaintEffectNoExecute:
+			default:
+				return fmt.Errorf("Invalid taint effect %s", taint.Effect)
+			}
+		}
+	}
+	return nil
+}
+
 // ValidateDelete implements webhook.Validator so a webhook will be registered for the type
 func (r *ClusterManager) ValidateDelete() error {
 
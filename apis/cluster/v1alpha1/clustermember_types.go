@@ -0,0 +1,146 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+type ClusterMemberPhase string
+
+const (
+	// 멤버가 초대되고, 수락을 기다리는 상태
+	ClusterMemberPhaseInvited = ClusterMemberPhase("Invited")
+	// 초대를 수락하여 remote cluster에 rolebinding이 생성된 상태
+	ClusterMemberPhaseAccepted = ClusterMemberPhase("Accepted")
+	// 멤버 삭제가 진행중인 상태
+	ClusterMemberPhaseRemoving = ClusterMemberPhase("Removing")
+	// 멤버 처리 과정에서 에러가 발생한 상태
+	ClusterMemberPhaseError = ClusterMemberPhase("Error")
+)
+
+const (
+	ClusterMemberAttributeUser  = "user"
+	ClusterMemberAttributeGroup = "group"
+)
+
+const (
+	ClusterMemberRoleAdmin     = "admin"
+	ClusterMemberRoleDeveloper = "developer"
+	ClusterMemberRoleGuest     = "guest"
+)
+
+const (
+	ClusterMemberFinalizer = "clustermember.cluster.tmax.io/finalizer"
+)
+
+// ClusterMemberSpec defines the desired state of ClusterMember
+type ClusterMemberSpec struct {
+	// +kubebuilder:validation:Required
+	// The name of the cluster to invite the member.
+	ClusterName string `json:"clusterName"`
+	// +kubebuilder:validation:Required
+	// The id of the member. user id(email) for user, group name for group.
+	MemberId string `json:"memberId"`
+	// The display name of the member.
+	MemberName string `json:"memberName,omitempty"`
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:Enum:=user;group
+	// The attribute of the member.
+	Attribute string `json:"attribute"`
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:Enum:=admin;developer;guest
+	// The role of the member in the cluster.
+	Role string `json:"role"`
+	// Set true when the member accepts the invitation.
+	Accepted bool `json:"accepted,omitempty"`
+}
+
+// ClusterMemberStatus defines the observed state of ClusterMember
+type ClusterMemberStatus struct {
+	// +kubebuilder:validation:Enum=Invited;Accepted;Removing;Error;
+	// Phase of the clustermember.
+	Phase ClusterMemberPhase `json:"phase,omitempty"`
+	// Reason of the phase.
+	Reason string `json:"reason,omitempty"`
+	// The name of rolebinding created to the remote cluster.
+	RoleBindingName string `json:"roleBindingName,omitempty"`
+	// The role currently bound to the remote cluster.
+	BoundRole string `json:"boundRole,omitempty"`
+	// True if the member is written to the db.
+	MemberRegistered bool `json:"memberRegistered,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:path=clustermembers,shortName=cmb,scope=Namespaced
+// +kubebuilder:printcolumn:name="Cluster",type=string,JSONPath=`.spec.clusterName`
+// +kubebuilder:printcolumn:name="Member",type=string,JSONPath=`.spec.memberId`
+// +kubebuilder:printcolumn:name="Role",type=string,JSONPath=`.spec.role`
+// +kubebuilder:printcolumn:name="Status",type=string,JSONPath=`.status.phase`
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+// ClusterMember is the Schema for the clustermembers API
+type ClusterMember struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   ClusterMemberSpec   `json:"spec"`
+	Status ClusterMemberStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// ClusterMemberList contains a list of ClusterMember
+type ClusterMemberList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ClusterMember `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&ClusterMember{}, &ClusterMemberList{})
+}
+
+func (c *ClusterMemberStatus) SetTypedPhase(p ClusterMemberPhase) {
+	c.Phase = p
+}
+
+func (c *ClusterMember) GetNamespacedName() types.NamespacedName {
+	return types.NamespacedName{
+		Name:      c.Name,
+		Namespace: c.Namespace,
+	}
+}
+
+func (c *ClusterMember) GetClusterManagerNamespacedName() types.NamespacedName {
+	return types.NamespacedName{
+		Name:      c.Spec.ClusterName,
+		Namespace: c.Namespace,
+	}
+}
+
+// remote cluster에 생성되는 rolebinding 이름
+// 기존 db 기반 멤버의 crb 이름 규칙(<memberId>-user-rolebinding, <memberId>-group-rolebinding)을 따른다.
+func (c *ClusterMember) GetRoleBindingName() string {
+	return c.Spec.MemberId + "-" + c.Spec.Attribute + "-rolebinding"
+}
+
+// member role에 대응되는 remote cluster의 cluster role
+func (c *ClusterMember) GetClusterRoleName() string {
+	if c.Spec.Role == ClusterMemberRoleAdmin {
+		return "cluster-admin"
+	}
+	return c.Spec.Role
+}
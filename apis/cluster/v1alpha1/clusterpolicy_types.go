@@ -0,0 +1,175 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	networkingV1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+type ClusterPolicyPhase string
+
+const (
+	// 선택된 모든 cluster 가 policy 를 준수하는 상태
+	ClusterPolicyPhaseCompliant = ClusterPolicyPhase("Compliant")
+	// 일부 cluster 가 policy 를 준수하지 않거나 적용에 실패한 상태
+	ClusterPolicyPhaseNonCompliant = ClusterPolicyPhase("NonCompliant")
+	// 삭제가 진행중인 상태
+	ClusterPolicyPhaseDeleting = ClusterPolicyPhase("Deleting")
+)
+
+const (
+	ClusterPolicyFinalizer = "clusterpolicy.cluster.tmax.io/finalizer"
+	// remote cluster 에 적용된 리소스에 다는 label
+	LabelKeyClusterPolicy          = "clusterpolicy.cluster.tmax.io/name"
+	LabelKeyClusterPolicyNamespace = "clusterpolicy.cluster.tmax.io/namespace"
+)
+
+// Pod security admission level
+type PodSecurityLevel string
+
+const (
+	PodSecurityLevelPrivileged = PodSecurityLevel("privileged")
+	PodSecurityLevelBaseline   = PodSecurityLevel("baseline")
+	PodSecurityLevelRestricted = PodSecurityLevel("restricted")
+)
+
+// PodSecurity defines the pod security admission level for namespaces
+type PodSecurity struct {
+	// +kubebuilder:validation:Enum=privileged;baseline;restricted;
+	// The pod security admission level to be enforced.
+	Enforce PodSecurityLevel `json:"enforce"`
+	// The namespaces of the member clusters to be labeled.
+	// Selects all namespaces except the excluded namespaces and kube-system, kube-public, kube-node-lease if empty.
+	// The level of the listed namespaces takes precedence over the level for all namespaces.
+	Namespaces []string `json:"namespaces,omitempty"`
+	// The namespaces not to be labeled when namespaces is empty.
+	ExcludeNamespaces []string `json:"excludeNamespaces,omitempty"`
+}
+
+// ClusterPolicyNetworkPolicy defines the network policy to be deployed
+type ClusterPolicyNetworkPolicy struct {
+	// The name of the network policy.
+	Name string `json:"name"`
+	// The namespace of the network policy.
+	Namespace string `json:"namespace"`
+	// The spec of the network policy.
+	Spec networkingV1.NetworkPolicySpec `json:"spec"`
+}
+
+// ClusterPolicySpec defines the desired state of ClusterPolicy
+type ClusterPolicySpec struct {
+	// Label selector for cluster managers in the same namespace.
+	// An empty selector selects all cluster managers in the namespace.
+	ClusterSelector metav1.LabelSelector `json:"clusterSelector,omitempty"`
+	// The network policies to be deployed.
+	NetworkPolicies []ClusterPolicyNetworkPolicy `json:"networkPolicies,omitempty"`
+	// The pod security admission levels to be enforced.
+	PodSecurity []PodSecurity `json:"podSecurity,omitempty"`
+	// The OPA Gatekeeper constraints to be deployed.
+	// The constraint templates must be installed in the member clusters.
+	Constraints []Manifest `json:"constraints,omitempty"`
+}
+
+// ClusterPolicyClusterStatus defines the compliance status of a cluster
+type ClusterPolicyClusterStatus struct {
+	// The name of the cluster manager.
+	Name string `json:"name"`
+	// True if the cluster complies with the policy.
+	Compliant bool `json:"compliant,omitempty"`
+	// The reason why the cluster does not comply with the policy.
+	Reason string `json:"reason,omitempty"`
+	// The total number of violations reported by the constraints.
+	Violations int64 `json:"violations,omitempty"`
+	// The resources deployed to the cluster.
+	Resources []ManifestStatus `json:"resources,omitempty"`
+	// The namespaces labeled with pod security admission level.
+	Namespaces []string `json:"namespaces,omitempty"`
+	// The last time the compliance is checked.
+	LastCheckedTime metav1.Time `json:"lastCheckedTime,omitempty"`
+	// The generation of the clusterpolicy last enforced to the cluster.
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+}
+
+// ClusterPolicyStatus defines the observed state of ClusterPolicy
+type ClusterPolicyStatus struct {
+	// +kubebuilder:validation:Enum=Compliant;NonCompliant;Deleting;
+	// Phase of the clusterpolicy.
+	Phase ClusterPolicyPhase `json:"phase,omitempty"`
+	// The generation observed by the controller.
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// The number of selected clusters.
+	ClusterCount int `json:"clusterCount,omitempty"`
+	// The number of compliant clusters.
+	CompliantCount int `json:"compliantCount,omitempty"`
+	// The compliance status of each selected cluster.
+	Clusters []ClusterPolicyClusterStatus `json:"clusters,omitempty"`
+	// The progress of enforcing the current generation to the selected clusters.
+	Progress *FanOutProgress `json:"progress,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:path=clusterpolicies,shortName=clp,scope=Namespaced
+// +kubebuilder:printcolumn:name="Status",type=string,JSONPath=`.status.phase`
+// +kubebuilder:printcolumn:name="Clusters",type=integer,JSONPath=`.status.clusterCount`
+// +kubebuilder:printcolumn:name="CompliantClusters",type=integer,JSONPath=`.status.compliantCount`
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+// ClusterPolicy is the Schema for the clusterpolicies API
+type ClusterPolicy struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   ClusterPolicySpec   `json:"spec"`
+	Status ClusterPolicyStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// ClusterPolicyList contains a list of ClusterPolicy
+type ClusterPolicyList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ClusterPolicy `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&ClusterPolicy{}, &ClusterPolicyList{})
+}
+
+func (c *ClusterPolicyStatus) SetTypedPhase(p ClusterPolicyPhase) {
+	c.Phase = p
+}
+
+func (c *ClusterPolicy) GetNamespacedName() types.NamespacedName {
+	return types.NamespacedName{
+		Name:      c.Name,
+		Namespace: c.Namespace,
+	}
+}
+
+func (c *ClusterPolicy) GetClusterSelector() (labels.Selector, error) {
+	return metav1.LabelSelectorAsSelector(&c.Spec.ClusterSelector)
+}
+
+func (c *ClusterPolicyStatus) GetClusterStatus(name string) *ClusterPolicyClusterStatus {
+	for i := range c.Clusters {
+		if c.Clusters[i].Name == name {
+			return &c.Clusters[i]
+		}
+	}
+	return nil
+}
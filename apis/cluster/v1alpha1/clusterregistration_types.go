@@ -25,13 +25,87 @@ type ClusterRegistrationSpec struct {
 	// +kubebuilder:validation:Required
 	// The name of the cluster to be registered
 	ClusterName string `json:"clusterName"`
-	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:Optional
 	// +kubebuilder:validation:Format:="data-url"
-	// The kubeconfig file of the cluster to be registered
-	KubeConfig string `json:"kubeConfig"`
+	// The kubeconfig file of the cluster to be registered. Exactly one of kubeConfig, kubeConfigSecret, encryptedKubeConfig, eks, gke and aks must be set
+	KubeConfig string `json:"kubeConfig,omitempty"`
+	// +kubebuilder:validation:Optional
+	// The name of the secret in the same namespace which has the kubeconfig file in value.
+	// The secret can be unsealed from a SealedSecret of the same name
+	KubeConfigSecret string `json:"kubeConfigSecret,omitempty"`
+	// +kubebuilder:validation:Optional
+	// The kubeconfig file of the cluster to be registered, encrypted with the public key in the
+	// hypercloud-multi-operator-kubeconfig-public-key configmap of hypercloud5-system.
+	// The value is base64 of the RSA-OAEP-SHA256 encrypted AES-256 key, a 12 byte nonce and the AES-GCM encrypted kubeconfig.
+	// If the namespace has the cluster.tmax.io/kms-key-arn or cluster.tmax.io/vault-transit-key annotation,
+	// the kubeconfig must be encrypted with a data key of the tenant key instead. The value is then base64 of
+	// the 2 byte big endian length of the encrypted data key, the encrypted data key, a 12 byte nonce and
+	// the AES-GCM encrypted kubeconfig with the namespace as the additional data.
+	// The KMS data key must be generated with the encryption context namespace=<namespace>.
+	// The tenant key protects the kubeconfig only while it is submitted and in backups,
+	// the kubeconfig secret of the registered cluster is stored like any other secret.
+	// A kubeconfig encrypted with the operator public key before the annotation was set is still accepted
+	EncryptedKubeConfig string `json:"encryptedKubeConfig,omitempty"`
+	// +kubebuilder:validation:Optional
+	// The EKS cluster to be registered with IAM authentication. The kubeconfig is generated by the operator
+	EKS *EKSRegistration `json:"eks,omitempty"`
+	// +kubebuilder:validation:Optional
+	// The GKE cluster to be registered with a google service account. The kubeconfig is generated by the operator
+	GKE *GKERegistration `json:"gke,omitempty"`
+	// +kubebuilder:validation:Optional
+	// The AKS cluster to be registered with an azure service principal. The kubeconfig is generated by the operator
+	AKS *AKSRegistration `json:"aks,omitempty"`
 	// WithPrometheus string `json:"withPrometheus,omitempty"`
 }
 
+// EKSRegistration defines the EKS cluster to be registered with IAM authentication
+type EKSRegistration struct {
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:Pattern:=`^arn:aws[a-z-]*:eks:[a-z0-9-]+:[0-9]{12}:cluster/[0-9A-Za-z][A-Za-z0-9_-]*$`
+	// The ARN of the EKS cluster
+	ClusterARN string `json:"clusterArn"`
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:Pattern:=`^arn:aws[a-z-]*:iam::[0-9]{12}:role/.+$`
+	// The ARN of the IAM role which the operator assumes to access the cluster.
+	// The role must be one of EKS_ALLOWED_ROLE_ARNS of the operator and be mapped to a cluster admin
+	// in the aws-auth configmap of the cluster
+	RoleARN string `json:"roleArn"`
+}
+
+// GKERegistration defines the GKE cluster to be registered with a google service account
+type GKERegistration struct {
+	// +kubebuilder:validation:Required
+	// The project ID of the GKE cluster
+	Project string `json:"project"`
+	// +kubebuilder:validation:Required
+	// The region or zone of the GKE cluster
+	Location string `json:"location"`
+	// +kubebuilder:validation:Required
+	// The name of the GKE cluster
+	Cluster string `json:"cluster"`
+	// +kubebuilder:validation:Required
+	// The name of the secret in the same namespace which has the service account key in credentials.json.
+	// The service account must have the Kubernetes Engine Admin role
+	CredentialsSecret string `json:"credentialsSecret"`
+}
+
+// AKSRegistration defines the AKS cluster to be registered with an azure service principal
+type AKSRegistration struct {
+	// +kubebuilder:validation:Required
+	// The subscription ID of the AKS cluster
+	SubscriptionID string `json:"subscriptionId"`
+	// +kubebuilder:validation:Required
+	// The resource group of the AKS cluster
+	ResourceGroup string `json:"resourceGroup"`
+	// +kubebuilder:validation:Required
+	// The name of the AKS cluster
+	Cluster string `json:"cluster"`
+	// +kubebuilder:validation:Required
+	// The name of the secret in the same namespace which has tenantId, clientId and clientSecret of the service principal.
+	// The cluster must use the AKS-managed Azure AD integration and the service principal must be a cluster admin
+	CredentialsSecret string `json:"credentialsSecret"`
+}
+
 // ClusterRegistrationStatus defines the observed state of ClusterRegistration
 type ClusterRegistrationStatus struct {
 	Provider         string                    `json:"provider,omitempty"`
@@ -46,6 +120,8 @@ type ClusterRegistrationStatus struct {
 	Reason           ClusterRegistrationReason `json:"reason,omitempty"`
 	ClusterValidated bool                      `json:"clusterValidated,omitempty"`
 	SecretReady      bool                      `json:"secretReady,omitempty"`
+	// Conditions of the registration phases
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
 }
 
 type ClusterRegistrationPhase string
@@ -102,6 +178,18 @@ const (
 
 	// ClusterRegistrationReasonClusterNameDuplicated is returned if the cluster name is duplicated
 	ClusterRegistrationReasonClusterNameDuplicated = ClusterRegistrationReason("ClusterNameDuplicated")
+
+	// ClusterRegistrationReasonPermissionDenied is returned if the Input Kubeconfig has no permission for the cluster
+	ClusterRegistrationReasonPermissionDenied = ClusterRegistrationReason("PermissionDenied")
+)
+
+const (
+	// kubeconfig 와 cluster 의 검사 결과
+	ClusterRegistrationConditionValidated = "Validated"
+	// cluster manager 생성 결과
+	ClusterRegistrationConditionClusterManagerCreated = "ClusterManagerCreated"
+	// kubeconfig secret 생성 결과
+	ClusterRegistrationConditionKubeconfigSecretCreated = "KubeconfigSecretCreated"
 )
 
 func (c *ClusterRegistrationStatus) SetTypedPhase(p ClusterRegistrationPhase) {
@@ -146,6 +234,15 @@ func (c *ClusterRegistration) GetNamespacedName() types.NamespacedName {
 	}
 }
 
+// pipeline.Conditioned 를 구현하여 phase 의 결과를 condition 으로 기록한다.
+func (c *ClusterRegistration) GetConditions() []metav1.Condition {
+	return c.Status.Conditions
+}
+
+func (c *ClusterRegistration) SetConditions(conditions []metav1.Condition) {
+	c.Status.Conditions = conditions
+}
+
 func (c *ClusterRegistration) GetCluterManagerNamespacedName() types.NamespacedName {
 	return types.NamespacedName{
 		Name:      c.Spec.ClusterName,
@@ -21,6 +21,8 @@ import (
 	"strconv"
 	"strings"
 
+	"github.com/tmax-cloud/hypercloud-multi-operator/controllers/metrics"
+
 	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/util/validation/field"
@@ -47,6 +49,10 @@ var _ webhook.Validator = &ClusterRegistration{}
 
 // ValidateCreate implements webhook.Validator so a webhook will be registered for the type
 func (r *ClusterRegistration) ValidateCreate() error {
+	return metrics.RecordWebhookRejection("ClusterRegistration", "create", r.validateCreate())
+}
+
+func (r *ClusterRegistration) validateCreate() error {
 	ClusterRegistrationWebhookLogger.Info("validate create", "name", r.Name)
 
 	// clusterclaim_webhook.go의 주석내용 참조
@@ -83,11 +89,41 @@ func (r *ClusterRegistration) ValidateCreate() error {
 		return k8sErrors.NewInvalid(r.GroupVersionKind().GroupKind(), "InvalidSpecClusterName", errList)
 	}
 
+	// kubeconfig 를 직접 입력하거나, managed cluster 의 경우 operator 가 생성하도록 하나만 설정해야 한다.
+	sources := 0
+	for _, set := range []bool{
+		r.Spec.KubeConfig != "", r.Spec.KubeConfigSecret != "", r.Spec.EncryptedKubeConfig != "",
+		r.Spec.EKS != nil, r.Spec.GKE != nil, r.Spec.AKS != nil,
+	} {
+		if set {
+			sources++
+		}
+	}
+	if sources != 1 {
+		errList := []*field.Error{
+			{
+				Type:     field.ErrorTypeInvalid,
+				Field:    "spec.kubeConfig",
+				BadValue: "",
+				Detail:   "exactly one of spec.kubeConfig, spec.kubeConfigSecret, spec.encryptedKubeConfig, spec.eks, spec.gke and spec.aks must be set",
+			},
+		}
+		return k8sErrors.NewInvalid(r.GroupVersionKind().GroupKind(), "InvalidSpecKubeConfig", errList)
+	}
+
+	if err := ValidateIntervalOverrides(r.Annotations); err != nil {
+		return err
+	}
+
 	return nil
 }
 
 // ValidateUpdate implements webhook.Validator so a webhook will be registered for the type
 func (r *ClusterRegistration) ValidateUpdate(old runtime.Object) error {
+	return metrics.RecordWebhookRejection("ClusterRegistration", "update", r.validateUpdate(old))
+}
+
+func (r *ClusterRegistration) validateUpdate(old runtime.Object) error {
 	ClusterRegistrationWebhookLogger.Info("validate update", "name", r.Name)
 	oldClusterRegistration := old.(*ClusterRegistration).DeepCopy()
 
@@ -101,11 +137,19 @@ func (r *ClusterRegistration) ValidateUpdate(old runtime.Object) error {
 			return errors.New("cannot modify ClusterRegistration after approval")
 		}
 	}
+
+	if err := ValidateIntervalOverrides(r.Annotations); err != nil {
+		return err
+	}
 	return nil
 }
 
 // ValidateDelete implements webhook.Validator so a webhook will be registered for the type
 func (r *ClusterRegistration) ValidateDelete() error {
+	return metrics.RecordWebhookRejection("ClusterRegistration", "delete", r.validateDelete())
+}
+
+func (r *ClusterRegistration) validateDelete() error {
 	ClusterRegistrationWebhookLogger.Info("validate delete", "name", r.Name)
 
 	// cluster가 남아있으면 cluster claim을 삭제하지 못하도록 처리
@@ -0,0 +1,65 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// remote cluster 에 생성된 cluster role 에 다는 label
+	LabelKeyClusterRoleTemplate = "clusterroletemplate.cluster.tmax.io/name"
+)
+
+// ClusterRoleTemplateSpec defines the desired state of ClusterRoleTemplate
+type ClusterRoleTemplateSpec struct {
+	// The name of the cluster role created in each cluster. Defaults to the name of the template.
+	ClusterRoleName string `json:"clusterRoleName,omitempty"`
+	// +kubebuilder:validation:MinItems=1
+	// The rules of the cluster role.
+	Rules []rbacv1.PolicyRule `json:"rules"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:path=clusterroletemplates,shortName=crt,scope=Cluster
+// +kubebuilder:printcolumn:name="ClusterRole",type=string,JSONPath=`.spec.clusterRoleName`
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+// ClusterRoleTemplate is the Schema for the clusterroletemplates API
+type ClusterRoleTemplate struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec ClusterRoleTemplateSpec `json:"spec"`
+}
+
+// +kubebuilder:object:root=true
+// ClusterRoleTemplateList contains a list of ClusterRoleTemplate
+type ClusterRoleTemplateList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ClusterRoleTemplate `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&ClusterRoleTemplate{}, &ClusterRoleTemplateList{})
+}
+
+func (t *ClusterRoleTemplate) GetClusterRoleName() string {
+	if t.Spec.ClusterRoleName != "" {
+		return t.Spec.ClusterRoleName
+	}
+	return t.Name
+}
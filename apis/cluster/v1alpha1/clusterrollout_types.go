@@ -0,0 +1,246 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+type ClusterRolloutPhase string
+
+const (
+	// stage 별로 cluster 에 변경을 적용하고 있는 상태
+	ClusterRolloutPhaseProgressing = ClusterRolloutPhase("Progressing")
+	// 사용자가 중지했거나 실패한 cluster 가 threshold 를 넘어 중지된 상태
+	ClusterRolloutPhasePaused = ClusterRolloutPhase("Paused")
+	// group 의 모든 cluster 에 변경이 적용된 상태
+	ClusterRolloutPhaseCompleted = ClusterRolloutPhase("Completed")
+)
+
+type ClusterRolloutStage string
+
+const (
+	// canary cluster 에만 적용하는 단계
+	ClusterRolloutStageCanary = ClusterRolloutStage("Canary")
+	// batch size 만큼의 cluster 에 추가로 적용하는 단계
+	ClusterRolloutStageBatch = ClusterRolloutStage("Batch")
+	// 나머지 모든 cluster 에 적용하는 단계
+	ClusterRolloutStageFull = ClusterRolloutStage("Full")
+)
+
+type ClusterRolloutClusterState string
+
+const (
+	// 아직 변경을 적용하지 않은 상태
+	ClusterRolloutClusterStatePending = ClusterRolloutClusterState("Pending")
+	// 변경을 적용하고 cluster 가 준비되기를 기다리는 상태
+	ClusterRolloutClusterStateProgressing = ClusterRolloutClusterState("Progressing")
+	// 변경이 적용되고 cluster 가 준비된 상태
+	ClusterRolloutClusterStateUpdated = ClusterRolloutClusterState("Updated")
+	// 변경이 거부되었거나 progress deadline 안에 준비되지 않은 상태
+	ClusterRolloutClusterStateFailed = ClusterRolloutClusterState("Failed")
+)
+
+const (
+	// strategy 가 설정되지 않은 경우의 기본값
+	ClusterRolloutCanaryCountDefault      = 1
+	ClusterRolloutBatchSizeDefault        = 5
+	ClusterRolloutProgressDeadlineDefault = 30 * time.Minute
+)
+
+// ClusterRolloutTarget defines the change rolled out to the clusters of the group
+type ClusterRolloutTarget struct {
+	// The kubernetes version to upgrade the clusters to. Only for the clusters created by the operator.
+	Version string `json:"version,omitempty"`
+	// The addons of the clusters. Replaces spec.addons of each cluster manager.
+	Addons *ClusterAddons `json:"addons,omitempty"`
+	// The labels to set on each cluster manager.
+	// Select the labels in a ClusterPolicy, WorkloadDistribution or FederatedRoleBinding to roll out it stage by stage.
+	Labels map[string]string `json:"labels,omitempty"`
+}
+
+// ClusterRolloutStrategy defines the stages of the rollout and when the rollout is paused
+type ClusterRolloutStrategy struct {
+	// +kubebuilder:validation:Minimum=1
+	// The number of clusters in the canary stage. Defaults to 1.
+	CanaryCount int `json:"canaryCount,omitempty"`
+	// +kubebuilder:validation:Minimum=1
+	// The number of clusters in the batch stage. Defaults to 5.
+	// The remaining clusters are rolled out in the full stage.
+	BatchSize int `json:"batchSize,omitempty"`
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=100
+	// The rollout is paused when the percentage of failed clusters among the clusters rolled out so far exceeds it.
+	// Defaults to 0, which pauses the rollout on any failure.
+	MaxFailurePercent int `json:"maxFailurePercent,omitempty"`
+	// The duration to wait after all clusters of a stage are rolled out before starting the next stage.
+	StageInterval *metav1.Duration `json:"stageInterval,omitempty"`
+	// The duration after which a cluster not ready with the change is regarded as failed. Defaults to 30m.
+	ProgressDeadline *metav1.Duration `json:"progressDeadline,omitempty"`
+}
+
+// ClusterRolloutSpec defines the desired state of ClusterRollout
+type ClusterRolloutSpec struct {
+	// +kubebuilder:validation:Required
+	// The name of the cluster group in the same namespace.
+	ClusterGroup string `json:"clusterGroup"`
+	// +kubebuilder:validation:Required
+	// The change rolled out to the clusters. At least one of the fields must be set.
+	// Changing the target restarts the rollout from the canary stage.
+	Target ClusterRolloutTarget `json:"target"`
+	// The stages of the rollout.
+	Strategy ClusterRolloutStrategy `json:"strategy,omitempty"`
+	// Set true to pause the rollout. The controller sets it when the failures exceed maxFailurePercent.
+	// Set false to resume the rollout, which retries the failed clusters.
+	Paused bool `json:"paused,omitempty"`
+}
+
+// ClusterRolloutClusterStatus defines the rollout status for a cluster
+type ClusterRolloutClusterStatus struct {
+	// The name of the cluster manager.
+	Name string `json:"name"`
+	// +kubebuilder:validation:Enum=Canary;Batch;Full;
+	// The stage in which the cluster is rolled out.
+	Stage ClusterRolloutStage `json:"stage"`
+	// +kubebuilder:validation:Enum=Pending;Progressing;Updated;Failed;
+	// The rollout state of the cluster.
+	State ClusterRolloutClusterState `json:"state"`
+	// The reason of the failure.
+	Reason string `json:"reason,omitempty"`
+	// The generation of the cluster manager after the change is applied.
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// The time the change is applied to the cluster.
+	StartTime *metav1.Time `json:"startTime,omitempty"`
+}
+
+// ClusterRolloutStatus defines the observed state of ClusterRollout
+type ClusterRolloutStatus struct {
+	// +kubebuilder:validation:Enum=Progressing;Paused;Completed;
+	// Phase of the rollout.
+	Phase ClusterRolloutPhase `json:"phase,omitempty"`
+	// +kubebuilder:validation:Enum=Canary;Batch;Full;
+	// The current stage of the rollout.
+	Stage ClusterRolloutStage `json:"stage,omitempty"`
+	// The reason of the pause.
+	Reason string `json:"reason,omitempty"`
+	// The hash of the target being rolled out.
+	TargetHash string `json:"targetHash,omitempty"`
+	// The number of clusters in the group.
+	Total int `json:"total,omitempty"`
+	// The number of updated clusters.
+	Updated int `json:"updated,omitempty"`
+	// The number of failed clusters.
+	Failed int `json:"failed,omitempty"`
+	// The time the current stage started.
+	StageStartTime *metav1.Time `json:"stageStartTime,omitempty"`
+	// The time all clusters of the current stage are rolled out.
+	StageCompletionTime *metav1.Time `json:"stageCompletionTime,omitempty"`
+	// The rollout status of each cluster in the group.
+	Clusters []ClusterRolloutClusterStatus `json:"clusters,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:path=clusterrollouts,shortName=clro,scope=Namespaced
+// +kubebuilder:printcolumn:name="ClusterGroup",type=string,JSONPath=`.spec.clusterGroup`
+// +kubebuilder:printcolumn:name="Stage",type=string,JSONPath=`.status.stage`
+// +kubebuilder:printcolumn:name="Updated",type=integer,JSONPath=`.status.updated`
+// +kubebuilder:printcolumn:name="Failed",type=integer,JSONPath=`.status.failed`
+// +kubebuilder:printcolumn:name="Total",type=integer,JSONPath=`.status.total`
+// +kubebuilder:printcolumn:name="Status",type=string,JSONPath=`.status.phase`
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+// ClusterRollout is the Schema for the clusterrollouts API
+type ClusterRollout struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   ClusterRolloutSpec   `json:"spec"`
+	Status ClusterRolloutStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// ClusterRolloutList contains a list of ClusterRollout
+type ClusterRolloutList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ClusterRollout `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&ClusterRollout{}, &ClusterRolloutList{})
+}
+
+func (c *ClusterRolloutStatus) SetTypedPhase(p ClusterRolloutPhase) {
+	c.Phase = p
+}
+
+func (c *ClusterRollout) GetNamespacedName() types.NamespacedName {
+	return types.NamespacedName{
+		Name:      c.Name,
+		Namespace: c.Namespace,
+	}
+}
+
+func (c *ClusterRolloutStatus) GetClusterStatus(name string) *ClusterRolloutClusterStatus {
+	for i := range c.Clusters {
+		if c.Clusters[i].Name == name {
+			return &c.Clusters[i]
+		}
+	}
+	return nil
+}
+
+// canary, batch, full 순서의 index
+func (s ClusterRolloutStage) Index() int {
+	switch s {
+	case ClusterRolloutStageCanary:
+		return 0
+	case ClusterRolloutStageBatch:
+		return 1
+	default:
+		return 2
+	}
+}
+
+func (c *ClusterRollout) GetCanaryCount() int {
+	if c.Spec.Strategy.CanaryCount > 0 {
+		return c.Spec.Strategy.CanaryCount
+	}
+	return ClusterRolloutCanaryCountDefault
+}
+
+func (c *ClusterRollout) GetBatchSize() int {
+	if c.Spec.Strategy.BatchSize > 0 {
+		return c.Spec.Strategy.BatchSize
+	}
+	return ClusterRolloutBatchSizeDefault
+}
+
+func (c *ClusterRollout) GetStageInterval() time.Duration {
+	if c.Spec.Strategy.StageInterval != nil {
+		return c.Spec.Strategy.StageInterval.Duration
+	}
+	return 0
+}
+
+func (c *ClusterRollout) GetProgressDeadline() time.Duration {
+	if c.Spec.Strategy.ProgressDeadline != nil && c.Spec.Strategy.ProgressDeadline.Duration > 0 {
+		return c.Spec.Strategy.ProgressDeadline.Duration
+	}
+	return ClusterRolloutProgressDeadlineDefault
+}
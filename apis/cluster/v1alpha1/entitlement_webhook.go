@@ -0,0 +1,86 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+const entitlementWebhookPath = "/validate-cluster-tmax-io-v1alpha1-entitlement"
+
+// +kubebuilder:webhook:path=/validate-cluster-tmax-io-v1alpha1-entitlement,mutating=false,failurePolicy=fail,groups=claim.tmax.io;cluster.tmax.io,resources=clusterclaims;clusterregistrations,verbs=create,versions=v1alpha1,name=validation.webhook.entitlement,admissionReviewVersions=v1beta1;v1,sideEffects=None
+
+// EntitlementWebhook 은 관리중인 cluster 수가 License 의 최대 cluster 수에 도달하면
+// 새로운 ClusterClaim, ClusterRegistration 의 생성을 막는다.
+// License 가 없으면 제한하지 않는다.
+type EntitlementWebhook struct {
+	Client client.Reader
+}
+
+func SetupEntitlementWebhookWithManager(mgr ctrl.Manager) error {
+	mgr.GetWebhookServer().Register(entitlementWebhookPath, &webhook.Admission{
+		Handler: &EntitlementWebhook{
+			Client: mgr.GetClient(),
+		},
+	})
+	return nil
+}
+
+func (h *EntitlementWebhook) Handle(ctx context.Context, req admission.Request) admission.Response {
+	licenseList := &LicenseList{}
+	if err := h.Client.List(ctx, licenseList); err != nil {
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+	if len(licenseList.Items) == 0 {
+		return admission.Allowed("")
+	}
+
+	maxClusters := licenseList.GetLicensedClusters(metav1.Now())
+	if maxClusters == 0 {
+		return admission.Denied(fmt.Sprintf("cannot create %s: there is no valid license. check the Valid condition of the License", req.Kind.Kind))
+	}
+
+	usedClusters, err := CountManagedClusters(ctx, h.Client)
+	if err != nil {
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+	if usedClusters >= maxClusters {
+		return admission.Denied(fmt.Sprintf("cannot create %s: cluster entitlement exceeded, %d of %d clusters licensed are in use", req.Kind.Kind, usedClusters, maxClusters))
+	}
+	return admission.Allowed("")
+}
+
+// 삭제중이 아닌 모든 namespace 의 ClusterManager 수를 반환한다.
+func CountManagedClusters(ctx context.Context, c client.Reader) (int, error) {
+	clmList := &ClusterManagerList{}
+	if err := c.List(ctx, clmList); err != nil {
+		return 0, err
+	}
+	count := 0
+	for _, clm := range clmList.Items {
+		if clm.DeletionTimestamp.IsZero() {
+			count++
+		}
+	}
+	return count, nil
+}
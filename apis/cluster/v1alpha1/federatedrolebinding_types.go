@@ -0,0 +1,143 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+type FederatedRoleBindingPhase string
+
+const (
+	// 선택된 모든 cluster 에 role binding 이 생성된 상태
+	FederatedRoleBindingPhaseSynced = FederatedRoleBindingPhase("Synced")
+	// 일부 cluster 에 role binding 생성이 진행중이거나 실패한 상태
+	FederatedRoleBindingPhaseProgressing = FederatedRoleBindingPhase("Progressing")
+	// 삭제가 진행중인 상태
+	FederatedRoleBindingPhaseDeleting = FederatedRoleBindingPhase("Deleting")
+)
+
+const (
+	FederatedRoleBindingFinalizer = "federatedrolebinding.cluster.tmax.io/finalizer"
+	// remote cluster 에 생성된 role binding 에 다는 label
+	LabelKeyFederatedRoleBinding          = "federatedrolebinding.cluster.tmax.io/name"
+	LabelKeyFederatedRoleBindingNamespace = "federatedrolebinding.cluster.tmax.io/namespace"
+)
+
+// FederatedRoleBindingSpec defines the desired state of FederatedRoleBinding
+type FederatedRoleBindingSpec struct {
+	// Label selector for cluster managers in the same namespace.
+	// An empty selector selects all cluster managers in the namespace.
+	ClusterSelector metav1.LabelSelector `json:"clusterSelector,omitempty"`
+	// +kubebuilder:validation:MinItems=1
+	// The users or groups to be bound to the role.
+	Subjects []rbacv1.Subject `json:"subjects"`
+	// +kubebuilder:validation:Required
+	// The role to be bound in the member clusters.
+	// Role can be used only when namespaces is set.
+	RoleRef rbacv1.RoleRef `json:"roleRef"`
+	// The namespaces of the member clusters where the role bindings are created.
+	// If empty, a cluster role binding is created instead.
+	Namespaces []string `json:"namespaces,omitempty"`
+}
+
+// FederatedRoleBindingClusterStatus defines the sync status for a cluster
+type FederatedRoleBindingClusterStatus struct {
+	// The name of the cluster manager.
+	Name string `json:"name"`
+	// True if the role bindings are synced to the cluster.
+	Synced bool `json:"synced,omitempty"`
+	// The reason of the failure.
+	Reason string `json:"reason,omitempty"`
+	// The role bindings created in the cluster.
+	Resources []ManifestStatus `json:"resources,omitempty"`
+	// The last time the role bindings are synced.
+	LastSyncTime metav1.Time `json:"lastSyncTime,omitempty"`
+	// The generation of the federatedrolebinding last synced to the cluster.
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+}
+
+// FederatedRoleBindingStatus defines the observed state of FederatedRoleBinding
+type FederatedRoleBindingStatus struct {
+	// +kubebuilder:validation:Enum=Synced;Progressing;Deleting;
+	// Phase of the federatedrolebinding.
+	Phase FederatedRoleBindingPhase `json:"phase,omitempty"`
+	// The reason why the spec is invalid.
+	Reason string `json:"reason,omitempty"`
+	// The sync status of each selected cluster.
+	Clusters []FederatedRoleBindingClusterStatus `json:"clusters,omitempty"`
+	// The progress of syncing the current generation to the selected clusters.
+	Progress *FanOutProgress `json:"progress,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:path=federatedrolebindings,shortName=frb,scope=Namespaced
+// +kubebuilder:printcolumn:name="Role",type=string,JSONPath=`.spec.roleRef.name`
+// +kubebuilder:printcolumn:name="Status",type=string,JSONPath=`.status.phase`
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+// FederatedRoleBinding is the Schema for the federatedrolebindings API
+type FederatedRoleBinding struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   FederatedRoleBindingSpec   `json:"spec"`
+	Status FederatedRoleBindingStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// FederatedRoleBindingList contains a list of FederatedRoleBinding
+type FederatedRoleBindingList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []FederatedRoleBinding `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&FederatedRoleBinding{}, &FederatedRoleBindingList{})
+}
+
+func (f *FederatedRoleBindingStatus) SetTypedPhase(p FederatedRoleBindingPhase) {
+	f.Phase = p
+}
+
+func (f *FederatedRoleBinding) GetNamespacedName() types.NamespacedName {
+	return types.NamespacedName{
+		Name:      f.Name,
+		Namespace: f.Namespace,
+	}
+}
+
+func (f *FederatedRoleBinding) GetClusterSelector() (labels.Selector, error) {
+	return metav1.LabelSelectorAsSelector(&f.Spec.ClusterSelector)
+}
+
+// member cluster 에 생성할 role binding 의 이름
+// roleRef 는 수정할 수 없으므로 role 이름을 포함해, role 이 변경되면 새로운 binding 을 생성하고 이전 binding 은 삭제한다.
+func (f *FederatedRoleBinding) GetRoleBindingName() string {
+	return f.Namespace + "-" + f.Name + "-" + f.Spec.RoleRef.Name
+}
+
+func (f *FederatedRoleBindingStatus) GetClusterStatus(name string) *FederatedRoleBindingClusterStatus {
+	for i := range f.Clusters {
+		if f.Clusters[i].Name == name {
+			return &f.Clusters[i]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,180 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+type FleetStatusPhase string
+
+const (
+	// 선택된 모든 cluster 가 Ready 인 상태
+	FleetStatusPhaseHealthy = FleetStatusPhase("Healthy")
+	// Degraded 또는 Unreachable 인 cluster 가 있는 상태
+	FleetStatusPhaseDegraded = FleetStatusPhase("Degraded")
+)
+
+type ClusterHealth string
+
+const (
+	// cluster 가 준비되어 모든 node 가 동작중인 상태
+	ClusterHealthReady = ClusterHealth("Ready")
+	// cluster 에 접근할 수 있지만 준비되지 않았거나 동작하지 않는 node 가 있는 상태
+	ClusterHealthDegraded = ClusterHealth("Degraded")
+	// cluster 의 api server 에 접근할 수 없는 상태
+	ClusterHealthUnreachable = ClusterHealth("Unreachable")
+	// cluster 가 생성, 업그레이드, 스케일링 중인 상태
+	ClusterHealthProgressing = ClusterHealth("Progressing")
+)
+
+// FleetStatusSpec defines the desired state of FleetStatus
+type FleetStatusSpec struct {
+	// Label selector for cluster managers in the same namespace.
+	// An empty selector selects all cluster managers in the namespace.
+	ClusterSelector metav1.LabelSelector `json:"clusterSelector,omitempty"`
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:default=10
+	// The maximum number of clusters listed in worstConditions.
+	MaxWorstConditions int `json:"maxWorstConditions,omitempty"`
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:default=10
+	// The maximum number of certificates listed in expiringCertificates.
+	MaxExpiringCertificates int `json:"maxExpiringCertificates,omitempty"`
+}
+
+// FleetClusterStatus defines the health of a cluster
+type FleetClusterStatus struct {
+	// The name of the cluster manager.
+	Name string `json:"name"`
+	// +kubebuilder:validation:Enum=Ready;Degraded;Unreachable;Progressing;
+	// Health of the cluster.
+	Health ClusterHealth `json:"health"`
+	// The reason of the health.
+	Reason string `json:"reason,omitempty"`
+	// Human readable message of the health.
+	Message string `json:"message,omitempty"`
+	// The last time the health is changed.
+	LastTransitionTime metav1.Time `json:"lastTransitionTime,omitempty"`
+}
+
+// FleetCertificateStatus defines a certificate of a cluster which expires soon
+type FleetCertificateStatus struct {
+	// The name of the cluster manager.
+	Cluster string `json:"cluster"`
+	// The type of the certificate.
+	Type CertificateType `json:"type"`
+	// Where the certificate was read.
+	Name string `json:"name"`
+	// The expiry of the certificate.
+	NotAfter metav1.Time `json:"notAfter"`
+}
+
+// FleetStatusStatus defines the observed state of FleetStatus
+type FleetStatusStatus struct {
+	// +kubebuilder:validation:Enum=Healthy;Degraded;
+	// Phase of the fleetstatus.
+	Phase FleetStatusPhase `json:"phase,omitempty"`
+	// The number of selected clusters.
+	Total int `json:"total,omitempty"`
+	// The number of Ready clusters.
+	ReadyCount int `json:"readyCount,omitempty"`
+	// The number of Degraded clusters.
+	DegradedCount int `json:"degradedCount,omitempty"`
+	// The number of Unreachable clusters.
+	UnreachableCount int `json:"unreachableCount,omitempty"`
+	// The number of Progressing clusters.
+	ProgressingCount int `json:"progressingCount,omitempty"`
+	// The health of each selected cluster.
+	Clusters []FleetClusterStatus `json:"clusters,omitempty"`
+	// The clusters in the worst health, Unreachable first and then Degraded.
+	WorstConditions []FleetClusterStatus `json:"worstConditions,omitempty"`
+	// The number of certificates of the selected clusters which are expired or expire within CERT_EXPIRY_WARNING_THRESHOLD.
+	ExpiringCertificateCount int `json:"expiringCertificateCount,omitempty"`
+	// The expired or expiring certificates of the selected clusters, the earliest expiry first.
+	ExpiringCertificates []FleetCertificateStatus `json:"expiringCertificates,omitempty"`
+	// The last time the status is updated.
+	LastUpdateTime metav1.Time `json:"lastUpdateTime,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:path=fleetstatuses,shortName=fleet,scope=Namespaced
+// +kubebuilder:printcolumn:name="Status",type=string,JSONPath=`.status.phase`
+// +kubebuilder:printcolumn:name="Total",type=integer,JSONPath=`.status.total`
+// +kubebuilder:printcolumn:name="Ready",type=integer,JSONPath=`.status.readyCount`
+// +kubebuilder:printcolumn:name="Degraded",type=integer,JSONPath=`.status.degradedCount`
+// +kubebuilder:printcolumn:name="Unreachable",type=integer,JSONPath=`.status.unreachableCount`
+// +kubebuilder:printcolumn:name="ExpiringCerts",type=integer,JSONPath=`.status.expiringCertificateCount`,priority=1
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+// FleetStatus is the Schema for the fleetstatuses API
+type FleetStatus struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   FleetStatusSpec   `json:"spec,omitempty"`
+	Status FleetStatusStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// FleetStatusList contains a list of FleetStatus
+type FleetStatusList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []FleetStatus `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&FleetStatus{}, &FleetStatusList{})
+}
+
+func (f *FleetStatusStatus) SetTypedPhase(p FleetStatusPhase) {
+	f.Phase = p
+}
+
+func (f *FleetStatus) GetNamespacedName() types.NamespacedName {
+	return types.NamespacedName{
+		Name:      f.Name,
+		Namespace: f.Namespace,
+	}
+}
+
+func (f *FleetStatus) GetClusterSelector() (labels.Selector, error) {
+	return metav1.LabelSelectorAsSelector(&f.Spec.ClusterSelector)
+}
+
+func (f *FleetStatusStatus) GetClusterStatus(name string) *FleetClusterStatus {
+	for i := range f.Clusters {
+		if f.Clusters[i].Name == name {
+			return &f.Clusters[i]
+		}
+	}
+	return nil
+}
+
+// health 의 심각한 정도, 값이 클수록 심각하다.
+func (h ClusterHealth) Severity() int {
+	switch h {
+	case ClusterHealthUnreachable:
+		return 3
+	case ClusterHealthDegraded:
+		return 2
+	case ClusterHealthProgressing:
+		return 1
+	}
+	return 0
+}
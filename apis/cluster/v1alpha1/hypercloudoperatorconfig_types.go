@@ -0,0 +1,127 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// operator 는 이 이름의 설정만 사용한다.
+	HyperCloudOperatorConfigName = "hypercloud-multi-operator"
+
+	// spec 의 검증 결과
+	HyperCloudOperatorConfigConditionValid = "Valid"
+)
+
+const (
+	// 값이 operator 의 환경 변수에서 온 경우
+	OperatorConfigSourceEnv = "Env"
+	// 값이 HyperCloudOperatorConfig 의 spec 에서 온 경우
+	OperatorConfigSourceConfig = "Config"
+)
+
+// HyperCloudOperatorConfigSpec defines the desired state of HyperCloudOperatorConfig
+// Every field overrides the environment variable of the operator and is applied without restart.
+// Unset fields fall back to the environment variable.
+type HyperCloudOperatorConfigSpec struct {
+	// +kubebuilder:validation:Pattern:=^[a-z0-9]([-a-z0-9]*[a-z0-9])?(\.[a-z0-9]([-a-z0-9]*[a-z0-9])?)*$
+	// The domain of hypercloud. Overrides HC_DOMAIN.
+	HCDomain string `json:"hcDomain,omitempty"`
+	// +kubebuilder:validation:Pattern:=^[a-z0-9]([-a-z0-9]*[a-z0-9])?$
+	// The subdomain of hyperauth. Overrides AUTH_SUBDOMAIN.
+	AuthSubdomain string `json:"authSubdomain,omitempty"`
+	// Delete the argocd applications of the cluster when the cluster is deleted. Overrides ARGO_APP_DELETE.
+	ArgoAppDelete *bool `json:"argoAppDelete,omitempty"`
+	// Push cluster lifecycle events to the hypercloud api server. Overrides HC_EVENT_PUSH.
+	HCEventPush *bool `json:"hcEventPush,omitempty"`
+	// The client id used by the member kubeconfig to get a token from hyperauth. Overrides KUBECONFIG_OIDC_CLIENT_ID.
+	KubeconfigOIDCClientID string `json:"kubeconfigOIDCClientID,omitempty"`
+	// +kubebuilder:validation:Pattern:=^https://
+	// The ACME server of the ClusterIssuer of the cert-manager addon. Overrides CERT_MANAGER_ACME_SERVER.
+	CertManagerACMEServer string `json:"certManagerACMEServer,omitempty"`
+	// The image pull secrets in hypercloud5-system deployed to the member clusters. Overrides IMAGE_PULL_SECRETS.
+	ImagePullSecrets []string `json:"imagePullSecrets,omitempty"`
+	// The namespaces of the member clusters the image pull secrets are deployed to. Overrides IMAGE_PULL_SECRET_NAMESPACES.
+	ImagePullSecretNamespaces []string `json:"imagePullSecretNamespaces,omitempty"`
+	// The namespaces of the member clusters the default network policies are deployed to. Overrides DEFAULT_NETWORK_POLICY_NAMESPACES.
+	DefaultNetworkPolicyNamespaces []string `json:"defaultNetworkPolicyNamespaces,omitempty"`
+	// The platform namespaces allowed by the default network policies. Overrides DEFAULT_NETWORK_POLICY_PLATFORM_NAMESPACES.
+	DefaultNetworkPolicyPlatformNamespaces []string `json:"defaultNetworkPolicyPlatformNamespaces,omitempty"`
+	// The interval of the periodic reconciliation of the controllers. Must be at least 10s. Overrides RESYNC_PERIOD.
+	ResyncPeriod *metav1.Duration `json:"resyncPeriod,omitempty"`
+	// The supported minor versions of kubernetes. Example: 1.26
+	// Registered clusters running other versions are reported as outdated. Overrides SUPPORTED_KUBERNETES_VERSIONS.
+	SupportedKubernetesVersions []string `json:"supportedKubernetesVersions,omitempty"`
+	// The secret in hypercloud5-system which has the registry mirrors, credentials and CA certificates
+	// applied to the containerd of the nodes of the created clusters. Overrides REGISTRY_CONFIG_SECRET.
+	RegistryConfigSecret string `json:"registryConfigSecret,omitempty"`
+	// Set true to provision the clusters without internet access. Overrides AIR_GAPPED.
+	AirGapped *bool `json:"airGapped,omitempty"`
+	// The internal registry which has the kubernetes images for the air-gapped mode. Overrides AIR_GAPPED_IMAGE_REPOSITORY.
+	AirGappedImageRepository string `json:"airGappedImageRepository,omitempty"`
+	// The internal mirror which serves the files such as the CNI manifest with the same path as the upstream
+	// for the air-gapped mode. Overrides AIR_GAPPED_ARTIFACT_MIRROR.
+	AirGappedArtifactMirror string `json:"airGappedArtifactMirror,omitempty"`
+	// The internal git repo of the addon charts for the air-gapped mode. Overrides AIR_GAPPED_CHART_REPO.
+	AirGappedChartRepo string `json:"airGappedChartRepo,omitempty"`
+}
+
+// OperatorConfigValue defines the effective value of a setting
+type OperatorConfigValue struct {
+	// The name of the environment variable of the setting.
+	Name string `json:"name"`
+	// The effective value.
+	Value string `json:"value,omitempty"`
+	// +kubebuilder:validation:Enum=Env;Config;
+	// Where the value comes from.
+	Source string `json:"source"`
+}
+
+// HyperCloudOperatorConfigStatus defines the observed state of HyperCloudOperatorConfig
+type HyperCloudOperatorConfigStatus struct {
+	// The generation applied by the operator.
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// The effective settings of the operator.
+	Effective []OperatorConfigValue `json:"effective,omitempty"`
+	// Conditions of the config.
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:path=hypercloudoperatorconfigs,shortName=hoc,scope=Cluster
+// +kubebuilder:printcolumn:name="Valid",type=string,JSONPath=`.status.conditions[?(@.type=="Valid")].status`
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+// HyperCloudOperatorConfig is the Schema for the hypercloudoperatorconfigs API
+type HyperCloudOperatorConfig struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   HyperCloudOperatorConfigSpec   `json:"spec,omitempty"`
+	Status HyperCloudOperatorConfigStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// HyperCloudOperatorConfigList contains a list of HyperCloudOperatorConfig
+type HyperCloudOperatorConfigList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []HyperCloudOperatorConfig `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&HyperCloudOperatorConfig{}, &HyperCloudOperatorConfigList{})
+}
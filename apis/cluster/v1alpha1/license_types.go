@@ -0,0 +1,115 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// token 의 서명과 만료일을 검증한 결과
+	LicenseConditionValid = "Valid"
+	// 관리중인 cluster 수가 license 의 최대 cluster 수에 도달했는지 여부
+	LicenseConditionEntitlementExceeded = "EntitlementExceeded"
+)
+
+const (
+	LicenseReasonVerified               = "Verified"
+	LicenseReasonPublicKeyNotConfigured = "PublicKeyNotConfigured"
+	LicenseReasonInvalidToken           = "InvalidToken"
+	LicenseReasonExpired                = "Expired"
+	LicenseReasonWithinLimit            = "WithinLimit"
+	LicenseReasonLimitReached           = "LimitReached"
+	LicenseReasonLimitExceeded          = "LimitExceeded"
+)
+
+// LicenseSpec defines the desired state of License
+type LicenseSpec struct {
+	// +kubebuilder:validation:Required
+	// The signed license token issued by TmaxCloud.
+	// The token is "<payload>.<signature>" where the payload is base64url encoded json and the signature is ed25519.
+	Token string `json:"token"`
+}
+
+// LicenseStatus defines the observed state of License
+type LicenseStatus struct {
+	// The customer of the license.
+	Customer string `json:"customer,omitempty"`
+	// The maximum number of clusters managed by the operator.
+	MaxClusters int `json:"maxClusters,omitempty"`
+	// The number of clusters managed by the operator.
+	UsedClusters int `json:"usedClusters,omitempty"`
+	// The time the license expires. The license does not expire if empty.
+	ExpirationTime *metav1.Time `json:"expirationTime,omitempty"`
+	// The generation observed by the controller.
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// Conditions of the license.
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:path=licenses,shortName=lic,scope=Cluster
+// +kubebuilder:printcolumn:name="Customer",type=string,JSONPath=`.status.customer`
+// +kubebuilder:printcolumn:name="Used",type=integer,JSONPath=`.status.usedClusters`
+// +kubebuilder:printcolumn:name="Max",type=integer,JSONPath=`.status.maxClusters`
+// +kubebuilder:printcolumn:name="Valid",type=string,JSONPath=`.status.conditions[?(@.type=="Valid")].status`
+// +kubebuilder:printcolumn:name="Expiration",type=string,JSONPath=`.status.expirationTime`
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+// License is the Schema for the licenses API
+type License struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   LicenseSpec   `json:"spec"`
+	Status LicenseStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// LicenseList contains a list of License
+type LicenseList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []License `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&License{}, &LicenseList{})
+}
+
+// controller 가 현재 token 을 검증하였고, 검증에 성공한 경우 true 를 반환한다.
+func (l *License) IsVerified() bool {
+	return l.Status.ObservedGeneration == l.Generation &&
+		meta.IsStatusConditionTrue(l.Status.Conditions, LicenseConditionValid)
+}
+
+// 만료일이 지났는지 확인한다.
+func (l *License) IsExpired(now metav1.Time) bool {
+	return l.Status.ExpirationTime != nil && !now.Before(l.Status.ExpirationTime)
+}
+
+// controller 가 검증한 유효한 License 들의 최대 cluster 수를 합산한다.
+// 서명을 검증하지 않은 token 은 신뢰하지 않으므로 status 만 사용한다.
+func (l *LicenseList) GetLicensedClusters(now metav1.Time) int {
+	maxClusters := 0
+	for _, license := range l.Items {
+		if !license.IsVerified() || license.IsExpired(now) {
+			continue
+		}
+		maxClusters += license.Status.MaxClusters
+	}
+	return maxClusters
+}
@@ -0,0 +1,154 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	batchV1 "k8s.io/api/batch/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+type LifecycleHookPoint string
+
+const (
+	// cluster 를 생성하거나 join 하기 전
+	LifecycleHookPointPreCreate = LifecycleHookPoint("PreCreate")
+	// cluster 의 생성 또는 등록이 완료된 후. 완료될 때까지 cluster manager 는 Ready 가 되지 않는다.
+	LifecycleHookPointPostCreate = LifecycleHookPoint("PostCreate")
+	// cluster 를 삭제하기 전
+	LifecycleHookPointPreDelete = LifecycleHookPoint("PreDelete")
+)
+
+type LifecycleHookFailurePolicy string
+
+const (
+	// hook 이 성공할 때까지 다음 단계로 진행하지 않는다.
+	LifecycleHookFailurePolicyFail = LifecycleHookFailurePolicy("Fail")
+	// hook 이 실패해도 다음 단계로 진행한다.
+	LifecycleHookFailurePolicyIgnore = LifecycleHookFailurePolicy("Ignore")
+)
+
+type LifecycleHookPhase string
+
+const (
+	LifecycleHookPhaseRunning   = LifecycleHookPhase("Running")
+	LifecycleHookPhaseSucceeded = LifecycleHookPhase("Succeeded")
+	LifecycleHookPhaseFailed    = LifecycleHookPhase("Failed")
+)
+
+const (
+	// webhook secret 의 key
+	LifecycleHookSecretKeyURL = "url"
+	// job 의 timeoutSeconds 기본값
+	DefaultLifecycleHookTimeoutSeconds = 600
+)
+
+// LifecycleHookWebhook defines the endpoint called at the lifecycle point
+type LifecycleHookWebhook struct {
+	// +kubebuilder:validation:Required
+	// The name of the secret in the same namespace which has the url in the "url" key.
+	// The point, namespace and name of the cluster are posted in json and a 2xx response means success.
+	SecretName string `json:"secretName"`
+}
+
+// LifecycleHookSpec defines the desired state of LifecycleHook
+type LifecycleHookSpec struct {
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:Enum=PreCreate;PostCreate;PreDelete;
+	// The lifecycle point of the cluster manager to run the hook.
+	// PreCreate and PostCreate hooks only run for the clusters created after the hook.
+	Point LifecycleHookPoint `json:"point"`
+	// Label selector for cluster managers in the same namespace.
+	// A hook in hypercloud5-system namespace selects cluster managers of all namespaces.
+	// An empty selector selects all cluster managers.
+	ClusterSelector metav1.LabelSelector `json:"clusterSelector,omitempty"`
+	// +kubebuilder:pruning:PreserveUnknownFields
+	// +kubebuilder:validation:Schemaless
+	// The job run in the namespace of the hook. Exactly one of job and webhook must be set.
+	// CLUSTER_NAME, CLUSTER_NAMESPACE and HOOK_POINT env are added to the containers.
+	Job *batchV1.JobTemplateSpec `json:"job,omitempty"`
+	// The webhook called at the lifecycle point. Exactly one of job and webhook must be set.
+	Webhook *LifecycleHookWebhook `json:"webhook,omitempty"`
+	// +kubebuilder:validation:Enum=Fail;Ignore;
+	// Fail blocks the next phase of the cluster until the hook succeeds, Ignore proceeds even if the hook fails.
+	// Defaults to Fail. A failed job is retried when it is deleted.
+	FailurePolicy LifecycleHookFailurePolicy `json:"failurePolicy,omitempty"`
+	// +kubebuilder:validation:Minimum=1
+	// The seconds the job may run before it is considered failed. Defaults to 600.
+	TimeoutSeconds *int64 `json:"timeoutSeconds,omitempty"`
+}
+
+// LifecycleHookStatus defines the result of a lifecycle hook run for the cluster manager
+type LifecycleHookStatus struct {
+	// The namespace/name of the lifecycle hook.
+	Name string `json:"name"`
+	// The lifecycle point the hook is run.
+	Point LifecycleHookPoint `json:"point"`
+	// +kubebuilder:validation:Enum=Running;Succeeded;Failed;
+	// Phase of the hook.
+	Phase LifecycleHookPhase `json:"phase"`
+	// The name of the job created for the hook.
+	Job string `json:"job,omitempty"`
+	// The reason of the failure.
+	Message string `json:"message,omitempty"`
+	// The time the hook started.
+	StartTime *metav1.Time `json:"startTime,omitempty"`
+	// The time the hook succeeded or failed.
+	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:path=lifecyclehooks,shortName=lch,scope=Namespaced
+// +kubebuilder:printcolumn:name="Point",type=string,JSONPath=`.spec.point`
+// +kubebuilder:printcolumn:name="FailurePolicy",type=string,JSONPath=`.spec.failurePolicy`
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+// LifecycleHook is the Schema for the lifecyclehooks API
+// The results of the hooks are reported in the status.lifecycleHooks of the cluster manager.
+type LifecycleHook struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec LifecycleHookSpec `json:"spec"`
+}
+
+// +kubebuilder:object:root=true
+// LifecycleHookList contains a list of LifecycleHook
+type LifecycleHookList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []LifecycleHook `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&LifecycleHook{}, &LifecycleHookList{})
+}
+
+func (h *LifecycleHook) GetClusterSelector() (labels.Selector, error) {
+	return metav1.LabelSelectorAsSelector(&h.Spec.ClusterSelector)
+}
+
+func (h *LifecycleHook) GetFailurePolicy() LifecycleHookFailurePolicy {
+	if h.Spec.FailurePolicy == "" {
+		return LifecycleHookFailurePolicyFail
+	}
+	return h.Spec.FailurePolicy
+}
+
+func (h *LifecycleHook) GetTimeoutSeconds() int64 {
+	if h.Spec.TimeoutSeconds == nil {
+		return DefaultLifecycleHookTimeoutSeconds
+	}
+	return *h.Spec.TimeoutSeconds
+}
@@ -0,0 +1,113 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+type ManagementPeerPhase string
+
+const (
+	// peer management cluster 의 cluster 목록을 가져온 상태
+	ManagementPeerPhaseConnected = ManagementPeerPhase("Connected")
+	// peer management cluster 에 접근할 수 없는 상태, 마지막으로 가져온 목록을 유지한다.
+	ManagementPeerPhaseDisconnected = ManagementPeerPhase("Disconnected")
+)
+
+// ManagementPeerSpec defines the desired state of ManagementPeer
+type ManagementPeerSpec struct {
+	// +kubebuilder:validation:Required
+	// The name of the secret in the same namespace which has the kubeconfig of the peer management cluster in the "value" key.
+	// The kubeconfig only needs permission to list clustermanagers.
+	KubeconfigSecret string `json:"kubeconfigSecret"`
+	// The namespaces of the peer management cluster to import clusters from. Imports from all namespaces if empty.
+	Namespaces []string `json:"namespaces,omitempty"`
+}
+
+// PeerClusterStatus defines the summary of a cluster registered on the peer management cluster
+type PeerClusterStatus struct {
+	// The name of the cluster manager on the peer.
+	Name string `json:"name"`
+	// The namespace of the cluster manager on the peer.
+	Namespace string `json:"namespace"`
+	// The type of the cluster. created or registered.
+	Type string `json:"type,omitempty"`
+	// The provider of the cluster.
+	Provider string `json:"provider,omitempty"`
+	// The kubernetes version of the cluster.
+	Version string `json:"version,omitempty"`
+	// Phase of the cluster manager on the peer.
+	Phase ClusterManagerPhase `json:"phase,omitempty"`
+	// True if the cluster is ready on the peer.
+	Ready bool `json:"ready,omitempty"`
+	// The endpoint of the control plane.
+	ControlPlaneEndpoint string `json:"controlPlaneEndpoint,omitempty"`
+}
+
+// ManagementPeerStatus defines the observed state of ManagementPeer
+type ManagementPeerStatus struct {
+	// +kubebuilder:validation:Enum=Connected;Disconnected;
+	// Phase of the managementpeer.
+	Phase ManagementPeerPhase `json:"phase,omitempty"`
+	// The reason why the peer is disconnected.
+	Reason string `json:"reason,omitempty"`
+	// The number of clusters imported from the peer.
+	ClusterCount int `json:"clusterCount,omitempty"`
+	// The clusters registered on the peer management cluster. They are read-only on this management cluster.
+	Clusters []PeerClusterStatus `json:"clusters,omitempty"`
+	// The last time the clusters are imported from the peer.
+	LastSyncTime *metav1.Time `json:"lastSyncTime,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:path=managementpeers,shortName=mpeer,scope=Namespaced
+// +kubebuilder:printcolumn:name="Status",type=string,JSONPath=`.status.phase`
+// +kubebuilder:printcolumn:name="Clusters",type=integer,JSONPath=`.status.clusterCount`
+// +kubebuilder:printcolumn:name="LastSync",type="date",JSONPath=`.status.lastSyncTime`
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+// ManagementPeer is the Schema for the managementpeers API
+type ManagementPeer struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   ManagementPeerSpec   `json:"spec"`
+	Status ManagementPeerStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// ManagementPeerList contains a list of ManagementPeer
+type ManagementPeerList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ManagementPeer `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&ManagementPeer{}, &ManagementPeerList{})
+}
+
+func (m *ManagementPeerStatus) SetTypedPhase(p ManagementPeerPhase) {
+	m.Phase = p
+}
+
+func (m *ManagementPeer) GetNamespacedName() types.NamespacedName {
+	return types.NamespacedName{
+		Name:      m.Name,
+		Namespace: m.Namespace,
+	}
+}
@@ -0,0 +1,126 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+type MemberClaimPhase string
+
+const (
+	// cluster owner 의 승인을 기다리는 상태
+	MemberClaimPhaseAwaiting = MemberClaimPhase("Awaiting")
+	// cluster owner 가 승인하여 ClusterMember 를 생성하는 상태
+	MemberClaimPhaseApproved = MemberClaimPhase("Approved")
+	// cluster owner 가 거절한 상태
+	MemberClaimPhaseRejected = MemberClaimPhase("Rejected")
+	// ClusterMember 가 생성되어 remote cluster 에 권한이 부여된 상태
+	MemberClaimPhaseGranted = MemberClaimPhase("Granted")
+	// cluster 가 없거나 ClusterMember 생성에 실패한 상태
+	MemberClaimPhaseError = MemberClaimPhase("Error")
+)
+
+const (
+	// MemberClaim 으로 생성한 ClusterMember 에 다는 label
+	LabelKeyMemberClaimName = "cluster.tmax.io/memberclaim"
+)
+
+// MemberClaimSpec defines the desired state of MemberClaim
+type MemberClaimSpec struct {
+	// +kubebuilder:validation:Required
+	// The name of the cluster to request access.
+	ClusterName string `json:"clusterName"`
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:Enum:=admin;developer;guest
+	// The desired role in the cluster.
+	Role string `json:"role"`
+	// The reason for the request shown to the cluster owner.
+	Message string `json:"message,omitempty"`
+	// The user who requested the access. Set by the webhook from the request.
+	Requester string `json:"requester,omitempty"`
+}
+
+// MemberClaimReview defines who approved or rejected the claim
+type MemberClaimReview struct {
+	// The user who approved or rejected the claim.
+	Reviewer string `json:"reviewer"`
+	// The phase decided by the reviewer. Approved or Rejected.
+	Decision string `json:"decision"`
+	// The time when the claim is reviewed.
+	ReviewedTime metav1.Time `json:"reviewedTime,omitempty"`
+}
+
+// MemberClaimStatus defines the observed state of MemberClaim
+type MemberClaimStatus struct {
+	// +kubebuilder:validation:Enum=Awaiting;Approved;Rejected;Granted;Error;
+	// Phase of the memberclaim. The cluster owner approves or rejects the claim by setting Approved or Rejected.
+	Phase MemberClaimPhase `json:"phase,omitempty"`
+	// Reason of the phase.
+	Reason string `json:"reason,omitempty"`
+	// The user who approved or rejected the claim. Set by the admission webhook.
+	Review *MemberClaimReview `json:"review,omitempty"`
+	// The name of the ClusterMember created for the claim.
+	ClusterMemberName string `json:"clusterMemberName,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:path=memberclaims,shortName=mcl,scope=Namespaced
+// +kubebuilder:printcolumn:name="Cluster",type=string,JSONPath=`.spec.clusterName`
+// +kubebuilder:printcolumn:name="Requester",type=string,JSONPath=`.spec.requester`
+// +kubebuilder:printcolumn:name="Role",type=string,JSONPath=`.spec.role`
+// +kubebuilder:printcolumn:name="Status",type=string,JSONPath=`.status.phase`
+// +kubebuilder:printcolumn:name="Reviewer",type=string,JSONPath=`.status.review.reviewer`,priority=1
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+// MemberClaim is the Schema for the memberclaims API
+type MemberClaim struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   MemberClaimSpec   `json:"spec"`
+	Status MemberClaimStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// MemberClaimList contains a list of MemberClaim
+type MemberClaimList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []MemberClaim `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&MemberClaim{}, &MemberClaimList{})
+}
+
+func (c *MemberClaimStatus) SetTypedPhase(p MemberClaimPhase) {
+	c.Phase = p
+}
+
+func (c *MemberClaim) GetNamespacedName() types.NamespacedName {
+	return types.NamespacedName{
+		Name:      c.Name,
+		Namespace: c.Namespace,
+	}
+}
+
+func (c *MemberClaim) GetClusterManagerNamespacedName() types.NamespacedName {
+	return types.NamespacedName{
+		Name:      c.Spec.ClusterName,
+		Namespace: c.Namespace,
+	}
+}
@@ -0,0 +1,115 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+const memberClaimWebhookPath = "/mutate-cluster-tmax-io-v1alpha1-memberclaim"
+
+// +kubebuilder:webhook:path=/mutate-cluster-tmax-io-v1alpha1-memberclaim,mutating=true,failurePolicy=fail,groups=cluster.tmax.io,resources=memberclaims;memberclaims/status,verbs=create;update,versions=v1alpha1,name=mutation.webhook.memberclaim,admissionReviewVersions=v1beta1;v1,sideEffects=None
+
+// MemberClaimWebhook 은 MemberClaim 을 생성한 사용자를 spec 에 기록하고,
+// status.phase 를 Approved 또는 Rejected 로 변경하는 사용자가 cluster 의 owner 인지 확인하여 status.review 에 기록한다.
+// 승인, 거절은 console 에서 status 를 직접 변경하는 방식이므로 admission 의 userInfo 로만 사용자를 알 수 있다.
+type MemberClaimWebhook struct {
+	Client  client.Reader
+	decoder *admission.Decoder
+}
+
+func SetupMemberClaimWebhookWithManager(mgr ctrl.Manager) error {
+	mgr.GetWebhookServer().Register(memberClaimWebhookPath, &webhook.Admission{
+		Handler: &MemberClaimWebhook{
+			Client: mgr.GetClient(),
+		},
+	})
+	return nil
+}
+
+func (h *MemberClaimWebhook) InjectDecoder(d *admission.Decoder) error {
+	h.decoder = d
+	return nil
+}
+
+func (h *MemberClaimWebhook) Handle(ctx context.Context, req admission.Request) admission.Response {
+	mcl := &MemberClaim{}
+	if err := h.decoder.Decode(req, mcl); err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+
+	if req.Operation == admissionv1.Create {
+		// 다른 사용자의 권한을 요청할 수 없도록 요청한 사용자로 덮어쓴다.
+		mcl.Spec.Requester = req.UserInfo.Username
+		return patchMemberClaim(req, mcl)
+	}
+
+	old := &MemberClaim{}
+	if err := h.decoder.DecodeRaw(req.OldObject, old); err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+	if req.SubResource != "status" {
+		if !reflect.DeepEqual(old.Spec, mcl.Spec) {
+			return admission.Denied("spec of MemberClaim is immutable")
+		}
+		return admission.Allowed("")
+	}
+
+	decision := mcl.Status.Phase
+	if old.Status.Phase == decision ||
+		(decision != MemberClaimPhaseApproved && decision != MemberClaimPhaseRejected) {
+		return admission.Allowed("")
+	}
+	if old.Status.Phase != MemberClaimPhaseAwaiting {
+		return admission.Denied(fmt.Sprintf("MemberClaim in %s phase cannot be %s", old.Status.Phase, decision))
+	}
+
+	clm := &ClusterManager{}
+	if err := h.Client.Get(ctx, mcl.GetClusterManagerNamespacedName(), clm); errors.IsNotFound(err) {
+		return admission.Denied(fmt.Sprintf("cluster %s not found", mcl.Spec.ClusterName))
+	} else if err != nil {
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+	if owner := clm.Annotations["owner"]; owner == "" || owner != req.UserInfo.Username {
+		return admission.Denied(fmt.Sprintf("only the owner of cluster %s can review the MemberClaim", clm.Name))
+	}
+
+	mcl.Status.Review = &MemberClaimReview{
+		Reviewer:     req.UserInfo.Username,
+		Decision:     string(decision),
+		ReviewedTime: metav1.Now(),
+	}
+	return patchMemberClaim(req, mcl)
+}
+
+func patchMemberClaim(req admission.Request, mcl *MemberClaim) admission.Response {
+	marshaled, err := json.Marshal(mcl)
+	if err != nil {
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+	return admission.PatchResponseFromRaw(req.Object.Raw, marshaled)
+}
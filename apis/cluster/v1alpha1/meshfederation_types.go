@@ -0,0 +1,143 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+type MeshFederationPhase string
+
+const (
+	// cluster group 의 모든 cluster 가 mesh 에 연결된 상태
+	MeshFederationPhaseFederated = MeshFederationPhase("Federated")
+	// 일부 cluster 의 연결이 진행중이거나 실패한 상태
+	MeshFederationPhaseProgressing = MeshFederationPhase("Progressing")
+	// 삭제가 진행중인 상태
+	MeshFederationPhaseDeleting = MeshFederationPhase("Deleting")
+)
+
+const (
+	MeshFederationFinalizer = "meshfederation.cluster.tmax.io/finalizer"
+	// remote cluster 에 생성된 리소스에 다는 label
+	LabelKeyMeshFederation          = "meshfederation.cluster.tmax.io/name"
+	LabelKeyMeshFederationNamespace = "meshfederation.cluster.tmax.io/namespace"
+)
+
+const (
+	MeshTypeIstio = "istio"
+)
+
+// MeshFederationSpec defines the desired state of MeshFederation
+type MeshFederationSpec struct {
+	// +kubebuilder:validation:Required
+	// The name of the cluster group in the same namespace.
+	ClusterGroup string `json:"clusterGroup"`
+	// +kubebuilder:validation:Enum:=istio
+	// The type of the service mesh. Only istio multi-primary on multiple networks is supported. Defaults to istio.
+	Type string `json:"type,omitempty"`
+	// The mesh id shared by the clusters. Defaults to the namespace and name of the MeshFederation.
+	MeshID string `json:"meshID,omitempty"`
+}
+
+// MeshFederationClusterStatus defines the federation status for a cluster
+type MeshFederationClusterStatus struct {
+	// The name of the cluster manager.
+	Name string `json:"name"`
+	// True if the cluster is federated to the mesh.
+	Federated bool `json:"federated,omitempty"`
+	// The reason of the failure.
+	Reason string `json:"reason,omitempty"`
+	// The clusters whose endpoints are discovered by this cluster.
+	Peers []string `json:"peers,omitempty"`
+	// The east-west gateway and remote secrets created in the cluster.
+	Resources []ManifestStatus `json:"resources,omitempty"`
+	// The last time the cluster is federated.
+	LastFederatedTime metav1.Time `json:"lastFederatedTime,omitempty"`
+}
+
+// MeshFederationStatus defines the observed state of MeshFederation
+type MeshFederationStatus struct {
+	// +kubebuilder:validation:Enum=Federated;Progressing;Deleting;
+	// Phase of the meshfederation.
+	Phase MeshFederationPhase `json:"phase,omitempty"`
+	// The generation observed by the controller.
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// The name of the secret in the same namespace which has the root certificate of the mesh.
+	RootCASecret string `json:"rootCASecret,omitempty"`
+	// The federation status of each cluster in the group.
+	Clusters []MeshFederationClusterStatus `json:"clusters,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:path=meshfederations,shortName=mfd,scope=Namespaced
+// +kubebuilder:printcolumn:name="ClusterGroup",type=string,JSONPath=`.spec.clusterGroup`
+// +kubebuilder:printcolumn:name="Status",type=string,JSONPath=`.status.phase`
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+// MeshFederation is the Schema for the meshfederations API
+type MeshFederation struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   MeshFederationSpec   `json:"spec"`
+	Status MeshFederationStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// MeshFederationList contains a list of MeshFederation
+type MeshFederationList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []MeshFederation `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&MeshFederation{}, &MeshFederationList{})
+}
+
+func (m *MeshFederationStatus) SetTypedPhase(p MeshFederationPhase) {
+	m.Phase = p
+}
+
+func (m *MeshFederation) GetNamespacedName() types.NamespacedName {
+	return types.NamespacedName{
+		Name:      m.Name,
+		Namespace: m.Namespace,
+	}
+}
+
+// meshID 를 지정하지 않으면 namespace 와 이름으로 만든다.
+func (m *MeshFederation) GetMeshID() string {
+	if m.Spec.MeshID != "" {
+		return m.Spec.MeshID
+	}
+	return m.Namespace + "-" + m.Name
+}
+
+// mesh 의 root CA 를 저장하는 secret 의 이름
+func (m *MeshFederation) GetRootCASecretName() string {
+	return m.Name + "-mesh-root-ca"
+}
+
+func (m *MeshFederationStatus) GetClusterStatus(name string) *MeshFederationClusterStatus {
+	for i := range m.Clusters {
+		if m.Clusters[i].Name == name {
+			return &m.Clusters[i]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,147 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	coreV1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+type NamespaceTemplatePhase string
+
+const (
+	// cluster group 의 모든 cluster 에 namespace 가 생성된 상태
+	NamespaceTemplatePhaseApplied = NamespaceTemplatePhase("Applied")
+	// 일부 cluster 에 namespace 생성이 진행중이거나 실패한 상태
+	NamespaceTemplatePhaseProgressing = NamespaceTemplatePhase("Progressing")
+	// 삭제가 진행중인 상태
+	NamespaceTemplatePhaseDeleting = NamespaceTemplatePhase("Deleting")
+)
+
+const (
+	NamespaceTemplateFinalizer = "namespacetemplate.cluster.tmax.io/finalizer"
+	// remote cluster 에 생성된 리소스에 다는 label
+	LabelKeyNamespaceTemplate          = "namespacetemplate.cluster.tmax.io/name"
+	LabelKeyNamespaceTemplateNamespace = "namespacetemplate.cluster.tmax.io/namespace"
+)
+
+// NamespaceTemplateRoleBinding defines the role binding created in each namespace
+type NamespaceTemplateRoleBinding struct {
+	// +kubebuilder:validation:Required
+	// The name of the role binding.
+	Name string `json:"name"`
+	// +kubebuilder:validation:Required
+	// The role to be bound.
+	RoleRef rbacv1.RoleRef `json:"roleRef"`
+	// The subjects to be bound.
+	Subjects []rbacv1.Subject `json:"subjects,omitempty"`
+}
+
+// NamespaceTemplateSpec defines the desired state of NamespaceTemplate
+type NamespaceTemplateSpec struct {
+	// +kubebuilder:validation:Required
+	// The name of the cluster group in the same namespace.
+	ClusterGroup string `json:"clusterGroup"`
+	// +kubebuilder:validation:MinItems=1
+	// The namespaces to be created in each cluster.
+	Namespaces []string `json:"namespaces"`
+	// The labels of the namespaces.
+	Labels map[string]string `json:"labels,omitempty"`
+	// The annotations of the namespaces.
+	Annotations map[string]string `json:"annotations,omitempty"`
+	// The resource quota created in each namespace.
+	ResourceQuota *coreV1.ResourceQuotaSpec `json:"resourceQuota,omitempty"`
+	// The limit range created in each namespace.
+	LimitRange *coreV1.LimitRangeSpec `json:"limitRange,omitempty"`
+	// The role bindings created in each namespace.
+	RoleBindings []NamespaceTemplateRoleBinding `json:"roleBindings,omitempty"`
+	// If true, default-deny ingress and platform-allow network policies are created in each namespace.
+	DefaultNetworkPolicies bool `json:"defaultNetworkPolicies,omitempty"`
+}
+
+// NamespaceTemplateClusterStatus defines the apply status for a cluster
+type NamespaceTemplateClusterStatus struct {
+	// The name of the cluster manager.
+	Name string `json:"name"`
+	// True if all namespaces are applied to the cluster.
+	Applied bool `json:"applied,omitempty"`
+	// The reason of the failure.
+	Reason string `json:"reason,omitempty"`
+	// The resources created in the namespaces of the cluster.
+	// Namespaces are not listed since they are not deleted with the template.
+	Resources []ManifestStatus `json:"resources,omitempty"`
+	// The last time the namespaces are applied.
+	LastAppliedTime metav1.Time `json:"lastAppliedTime,omitempty"`
+}
+
+// NamespaceTemplateStatus defines the observed state of NamespaceTemplate
+type NamespaceTemplateStatus struct {
+	// +kubebuilder:validation:Enum=Applied;Progressing;Deleting;
+	// Phase of the namespacetemplate.
+	Phase NamespaceTemplatePhase `json:"phase,omitempty"`
+	// The generation observed by the controller.
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// The apply status of each cluster in the group.
+	Clusters []NamespaceTemplateClusterStatus `json:"clusters,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:path=namespacetemplates,shortName=nst,scope=Namespaced
+// +kubebuilder:printcolumn:name="ClusterGroup",type=string,JSONPath=`.spec.clusterGroup`
+// +kubebuilder:printcolumn:name="Status",type=string,JSONPath=`.status.phase`
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+// NamespaceTemplate is the Schema for the namespacetemplates API
+type NamespaceTemplate struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   NamespaceTemplateSpec   `json:"spec"`
+	Status NamespaceTemplateStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// NamespaceTemplateList contains a list of NamespaceTemplate
+type NamespaceTemplateList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []NamespaceTemplate `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&NamespaceTemplate{}, &NamespaceTemplateList{})
+}
+
+func (n *NamespaceTemplateStatus) SetTypedPhase(p NamespaceTemplatePhase) {
+	n.Phase = p
+}
+
+func (n *NamespaceTemplate) GetNamespacedName() types.NamespacedName {
+	return types.NamespacedName{
+		Name:      n.Name,
+		Namespace: n.Namespace,
+	}
+}
+
+func (n *NamespaceTemplateStatus) GetClusterStatus(name string) *NamespaceTemplateClusterStatus {
+	for i := range n.Clusters {
+		if n.Clusters[i].Name == name {
+			return &n.Clusters[i]
+		}
+	}
+	return nil
+}
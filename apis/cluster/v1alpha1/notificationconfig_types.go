@@ -0,0 +1,137 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+type NotificationEvent string
+
+const (
+	// cluster 가 생성 또는 등록된 경우
+	NotificationEventClusterCreated = NotificationEvent("ClusterCreated")
+	// cluster 가 Ready 상태가 된 경우
+	NotificationEventClusterReady = NotificationEvent("ClusterReady")
+	// cluster 가 Degraded 또는 Unreachable 상태가 된 경우
+	NotificationEventClusterUnhealthy = NotificationEvent("ClusterUnhealthy")
+	// cluster 업그레이드가 완료된 경우
+	NotificationEventUpgradeFinished = NotificationEvent("UpgradeFinished")
+	// cluster 가 삭제되었거나 분리된 경우
+	NotificationEventClusterDeleted = NotificationEvent("ClusterDeleted")
+	// cluster claim 이 관리자 승인을 기다리는 경우
+	NotificationEventClaimPending = NotificationEvent("ClaimPending")
+	// 등록된 cluster 의 version 이 지원하는 version 목록을 벗어난 경우
+	NotificationEventOutdatedVersion = NotificationEvent("OutdatedVersion")
+	// cluster 의 인증서가 만료되었거나 곧 만료되는 경우
+	NotificationEventCertificateExpiring = NotificationEvent("CertificateExpiring")
+)
+
+type NotificationSinkType string
+
+const (
+	NotificationSinkTypeSlack   = NotificationSinkType("Slack")
+	NotificationSinkTypeWebhook = NotificationSinkType("Webhook")
+	NotificationSinkTypeSMTP    = NotificationSinkType("SMTP")
+)
+
+const (
+	// sink secret 의 key
+	NotificationSecretKeyURL      = "url"
+	NotificationSecretKeyUsername = "username"
+	NotificationSecretKeyPassword = "password"
+	// webhook sink 의 request 를 서명하는 key (optional)
+	NotificationSecretKeyHMACKey = "hmacKey"
+)
+
+// NotificationSMTP defines the mail server and recipients of SMTP sink
+type NotificationSMTP struct {
+	// +kubebuilder:validation:Required
+	// The address of the mail server in host:port form.
+	Address string `json:"address"`
+	// +kubebuilder:validation:Required
+	// The sender address.
+	From string `json:"from"`
+	// +kubebuilder:validation:MinItems=1
+	// The recipient addresses.
+	To []string `json:"to"`
+}
+
+// NotificationSink defines where notifications are sent
+type NotificationSink struct {
+	// +kubebuilder:validation:Required
+	// The name of the sink.
+	Name string `json:"name"`
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:Enum=Slack;Webhook;SMTP;
+	// The type of the sink.
+	Type NotificationSinkType `json:"type"`
+	// The name of the secret in the same namespace.
+	// Slack and Webhook sinks read the url key, SMTP sink reads the username and password keys.
+	// Webhook sink signs the request with the optional hmacKey key in the X-Hypercloud-Signature header.
+	SecretName string `json:"secretName,omitempty"`
+	// The mail server and recipients. Required for SMTP sink.
+	SMTP *NotificationSMTP `json:"smtp,omitempty"`
+}
+
+// NotificationConfigSpec defines the desired state of NotificationConfig
+type NotificationConfigSpec struct {
+	// The events to be notified. An empty list means all events.
+	Events []NotificationEvent `json:"events,omitempty"`
+	// +kubebuilder:validation:MinItems=1
+	// The sinks notifications are sent to.
+	Sinks []NotificationSink `json:"sinks"`
+	// Go text/template for the message.
+	// .Event, .Namespace, .ClusterName, .Message and .Timestamp are available.
+	// Defaults to "[{{.Event}}] {{.Namespace}}/{{.ClusterName}}: {{.Message}}".
+	Template string `json:"template,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:path=notificationconfigs,shortName=nc,scope=Namespaced
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+// NotificationConfig is the Schema for the notificationconfigs API
+// NotificationConfig in hypercloud5-system namespace receives notifications of all namespaces.
+type NotificationConfig struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec NotificationConfigSpec `json:"spec"`
+}
+
+// +kubebuilder:object:root=true
+// NotificationConfigList contains a list of NotificationConfig
+type NotificationConfigList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []NotificationConfig `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&NotificationConfig{}, &NotificationConfigList{})
+}
+
+// events 가 비어있으면 모든 event 를 전송한다.
+func (n *NotificationConfig) Accepts(event NotificationEvent) bool {
+	if len(n.Spec.Events) == 0 {
+		return true
+	}
+	for _, e := range n.Spec.Events {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
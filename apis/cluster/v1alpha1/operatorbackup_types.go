@@ -0,0 +1,121 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+type OperatorBackupPhase string
+
+const (
+	// 마지막 bundle 을 object store 에 업로드한 상태
+	OperatorBackupPhaseCompleted = OperatorBackupPhase("Completed")
+	// 마지막 export 가 실패한 상태
+	OperatorBackupPhaseFailed = OperatorBackupPhase("Failed")
+)
+
+// BackupStorage defines the S3 compatible object store which keeps the bundles
+type BackupStorage struct {
+	// +kubebuilder:validation:Required
+	// The endpoint of the S3 compatible object store. Example: https://s3.ap-northeast-2.amazonaws.com
+	Endpoint string `json:"endpoint"`
+	// +kubebuilder:validation:Required
+	// The name of the bucket.
+	Bucket string `json:"bucket"`
+	// The region used to sign the requests. Defaults to us-east-1.
+	Region string `json:"region,omitempty"`
+	// The prefix of the object keys of the bundles.
+	Prefix string `json:"prefix,omitempty"`
+	// +kubebuilder:validation:Required
+	// The name of the secret in the same namespace which has the "accessKey" and "secretKey" of the object store.
+	CredentialsSecret string `json:"credentialsSecret"`
+}
+
+// OperatorBackupSpec defines the desired state of OperatorBackup
+type OperatorBackupSpec struct {
+	// +kubebuilder:validation:Required
+	// The object store to upload the bundles.
+	Storage BackupStorage `json:"storage"`
+	// +kubebuilder:validation:Required
+	// The name of the secret in the same namespace which has the "key" to re-encrypt the kubeconfig secrets in the bundle.
+	// The same key is required to restore the bundle.
+	// The kubeconfig secrets in the namespaces with a tenant key are encrypted with the tenant key instead.
+	EncryptionKeySecret string `json:"encryptionKeySecret"`
+	// The cron schedule in UTC to export the bundle periodically. Exports only once if empty.
+	// Example: 0 3 * * *
+	Schedule string `json:"schedule,omitempty"`
+}
+
+// OperatorBackupStatus defines the observed state of OperatorBackup
+type OperatorBackupStatus struct {
+	// +kubebuilder:validation:Enum=Completed;Failed;
+	// Phase of the operatorbackup.
+	Phase OperatorBackupPhase `json:"phase,omitempty"`
+	// The reason of the failure.
+	Reason string `json:"reason,omitempty"`
+	// The object key of the last bundle.
+	LastBundle string `json:"lastBundle,omitempty"`
+	// The last time the bundle is uploaded.
+	LastBackupTime *metav1.Time `json:"lastBackupTime,omitempty"`
+	// The next time the bundle will be exported.
+	NextBackupTime *metav1.Time `json:"nextBackupTime,omitempty"`
+	// The number of custom resources in the last bundle.
+	Resources int `json:"resources,omitempty"`
+	// The number of kubeconfig secrets in the last bundle.
+	KubeconfigSecrets int `json:"kubeconfigSecrets,omitempty"`
+	// The number of cluster members of the db in the last bundle.
+	Members int `json:"members,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:path=operatorbackups,shortName=obk,scope=Namespaced
+// +kubebuilder:printcolumn:name="Status",type=string,JSONPath=`.status.phase`
+// +kubebuilder:printcolumn:name="Bundle",type=string,JSONPath=`.status.lastBundle`
+// +kubebuilder:printcolumn:name="LastBackup",type="date",JSONPath=`.status.lastBackupTime`
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+// OperatorBackup is the Schema for the operatorbackups API
+type OperatorBackup struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   OperatorBackupSpec   `json:"spec"`
+	Status OperatorBackupStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// OperatorBackupList contains a list of OperatorBackup
+type OperatorBackupList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []OperatorBackup `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&OperatorBackup{}, &OperatorBackupList{})
+}
+
+func (o *OperatorBackupStatus) SetTypedPhase(p OperatorBackupPhase) {
+	o.Phase = p
+}
+
+func (o *OperatorBackup) GetNamespacedName() types.NamespacedName {
+	return types.NamespacedName{
+		Name:      o.Name,
+		Namespace: o.Namespace,
+	}
+}
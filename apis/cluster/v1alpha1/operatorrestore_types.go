@@ -0,0 +1,124 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+type OperatorRestorePhase string
+
+const (
+	// bundle 을 복원하고 있는 상태
+	OperatorRestorePhaseRestoring = OperatorRestorePhase("Restoring")
+	// 복원한 cluster 에 접근할 수 있는지 확인하고 있는 상태
+	OperatorRestorePhaseValidating = OperatorRestorePhase("Validating")
+	// 복원한 모든 cluster 에 접근할 수 있는 상태
+	OperatorRestorePhaseCompleted = OperatorRestorePhase("Completed")
+	// bundle 을 복원하지 못한 상태
+	OperatorRestorePhaseFailed = OperatorRestorePhase("Failed")
+)
+
+const (
+	// 복원한 cluster manager 에 bundle 의 object key 를 기록하는 annotation
+	AnnotationKeyClmRestoredFrom = "clustermanager.cluster.tmax.io/restored-from"
+)
+
+// OperatorRestoreSpec defines the desired state of OperatorRestore
+type OperatorRestoreSpec struct {
+	// +kubebuilder:validation:Required
+	// The object store which has the bundle.
+	Storage BackupStorage `json:"storage"`
+	// +kubebuilder:validation:Required
+	// The object key of the bundle to restore.
+	Bundle string `json:"bundle"`
+	// +kubebuilder:validation:Required
+	// The name of the secret in the same namespace which has the "key" used when the bundle is exported.
+	EncryptionKeySecret string `json:"encryptionKeySecret"`
+}
+
+// RestoredClusterStatus defines the validation result of a restored cluster
+type RestoredClusterStatus struct {
+	// The name of the cluster manager.
+	Name string `json:"name"`
+	// The namespace of the cluster manager.
+	Namespace string `json:"namespace"`
+	// True if the cluster is reachable with the restored kubeconfig.
+	Validated bool `json:"validated,omitempty"`
+	// The kubernetes version of the cluster reported by the api server.
+	Version string `json:"version,omitempty"`
+	// The reason why the cluster is not validated.
+	Reason string `json:"reason,omitempty"`
+}
+
+// OperatorRestoreStatus defines the observed state of OperatorRestore
+type OperatorRestoreStatus struct {
+	// +kubebuilder:validation:Enum=Restoring;Validating;Completed;Failed;
+	// Phase of the operatorrestore.
+	Phase OperatorRestorePhase `json:"phase,omitempty"`
+	// The reason of the failure.
+	Reason string `json:"reason,omitempty"`
+	// The time the bundle is restored.
+	RestoredTime *metav1.Time `json:"restoredTime,omitempty"`
+	// The number of restored custom resources. Resources which already exist are not counted.
+	Resources int `json:"resources,omitempty"`
+	// The number of restored kubeconfig secrets.
+	KubeconfigSecrets int `json:"kubeconfigSecrets,omitempty"`
+	// The number of cluster members written to the db.
+	Members int `json:"members,omitempty"`
+	// The validation result of each restored cluster.
+	Clusters []RestoredClusterStatus `json:"clusters,omitempty"`
+	// The last time the clusters are validated.
+	LastValidationTime *metav1.Time `json:"lastValidationTime,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:path=operatorrestores,shortName=ors,scope=Namespaced
+// +kubebuilder:printcolumn:name="Bundle",type=string,JSONPath=`.spec.bundle`
+// +kubebuilder:printcolumn:name="Status",type=string,JSONPath=`.status.phase`
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+// OperatorRestore is the Schema for the operatorrestores API
+type OperatorRestore struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   OperatorRestoreSpec   `json:"spec"`
+	Status OperatorRestoreStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// OperatorRestoreList contains a list of OperatorRestore
+type OperatorRestoreList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []OperatorRestore `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&OperatorRestore{}, &OperatorRestoreList{})
+}
+
+func (o *OperatorRestoreStatus) SetTypedPhase(p OperatorRestorePhase) {
+	o.Phase = p
+}
+
+func (o *OperatorRestore) GetNamespacedName() types.NamespacedName {
+	return types.NamespacedName{
+		Name:      o.Name,
+		Namespace: o.Namespace,
+	}
+}
@@ -0,0 +1,100 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+type PendingDBWriteOperation string
+
+const (
+	// cluster 정보를 db 에 쓴다. 기록된 시점의 cluster manager 를 다시 조회하여 쓴다.
+	PendingDBWriteOperationInsertCluster = PendingDBWriteOperation("InsertCluster")
+	// cluster 와 cluster 의 member 정보를 db 에서 삭제한다.
+	PendingDBWriteOperationDeleteCluster = PendingDBWriteOperation("DeleteCluster")
+	// member 정보를 db 에 쓴다.
+	PendingDBWriteOperationInsertMember = PendingDBWriteOperation("InsertMember")
+	// role 이 변경된 member 정보를 db 에 다시 쓴다.
+	PendingDBWriteOperationUpdateMember = PendingDBWriteOperation("UpdateMember")
+	// member 정보를 db 에서 삭제한다.
+	PendingDBWriteOperationDeleteMember = PendingDBWriteOperation("DeleteMember")
+)
+
+// PendingDBWriteSpec defines the membership write to be replayed when the db is available
+type PendingDBWriteSpec struct {
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:Enum=InsertCluster;DeleteCluster;InsertMember;UpdateMember;DeleteMember;
+	// The operation to be replayed. A later write of the same cluster or member replaces it.
+	Operation PendingDBWriteOperation `json:"operation"`
+	// +kubebuilder:validation:Required
+	// The name of the cluster manager in the same namespace.
+	ClusterName string `json:"clusterName"`
+	// The member to be inserted, updated or deleted. Required for InsertMember, UpdateMember and DeleteMember.
+	Member *ClusterMemberSpec `json:"member,omitempty"`
+}
+
+// PendingDBWriteStatus defines the observed state of PendingDBWrite
+type PendingDBWriteStatus struct {
+	// The number of failed replays.
+	Attempts int `json:"attempts,omitempty"`
+	// The time of the last replay.
+	LastAttemptTime *metav1.Time `json:"lastAttemptTime,omitempty"`
+	// The error of the last replay.
+	LastError string `json:"lastError,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:path=pendingdbwrites,shortName=pdw,scope=Namespaced
+// +kubebuilder:printcolumn:name="Operation",type=string,JSONPath=`.spec.operation`
+// +kubebuilder:printcolumn:name="ClusterName",type=string,JSONPath=`.spec.clusterName`
+// +kubebuilder:printcolumn:name="Attempts",type=integer,JSONPath=`.status.attempts`
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+// PendingDBWrite is the Schema for the pendingdbwrites API
+// It is created by the operator when the membership db is unavailable and deleted when the write is replayed.
+type PendingDBWrite struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   PendingDBWriteSpec   `json:"spec"`
+	Status PendingDBWriteStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// PendingDBWriteList contains a list of PendingDBWrite
+type PendingDBWriteList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []PendingDBWrite `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&PendingDBWrite{}, &PendingDBWriteList{})
+}
+
+func (c *PendingDBWrite) GetNamespacedName() types.NamespacedName {
+	return types.NamespacedName{
+		Name:      c.Name,
+		Namespace: c.Namespace,
+	}
+}
+
+func (c *PendingDBWrite) IsMemberWrite() bool {
+	return c.Spec.Operation == PendingDBWriteOperationInsertMember ||
+		c.Spec.Operation == PendingDBWriteOperationUpdateMember ||
+		c.Spec.Operation == PendingDBWriteOperationDeleteMember
+}
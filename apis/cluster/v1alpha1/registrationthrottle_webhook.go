@@ -0,0 +1,136 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"time"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+const (
+	registrationThrottleWebhookPath = "/validate-cluster-tmax-io-v1alpha1-registrationthrottle"
+
+	// REGISTRATION_FAILURE_LIMIT, REGISTRATION_FAILURE_WINDOW 가 설정되지 않은 경우의 기본값
+	RegistrationFailureLimitDefault  = 5
+	RegistrationFailureWindowDefault = 10 * time.Minute
+)
+
+// +kubebuilder:webhook:path=/validate-cluster-tmax-io-v1alpha1-registrationthrottle,mutating=false,failurePolicy=fail,groups=cluster.tmax.io,resources=clusterregistrations,verbs=create,versions=v1alpha1,name=validation.webhook.registrationthrottle,admissionReviewVersions=v1beta1;v1,sideEffects=None
+
+// RegistrationThrottleWebhook 은 같은 사용자가 생성한 ClusterRegistration 이 window 동안 Limit 번 이상 검증에 실패하면
+// 새로운 ClusterRegistration 의 생성을 retry-after 와 함께 거부한다.
+// 잘못된 자동화가 반복해서 등록을 요청하여 remote endpoint 와 operator 에 부하를 주는 것을 막는다.
+// 실패한 ClusterRegistration 으로 집계하므로 operator 의 replica 와 관계없이 동작한다.
+type RegistrationThrottleWebhook struct {
+	Client  client.Reader
+	Limit   int
+	Window  time.Duration
+	decoder *admission.Decoder
+}
+
+func SetupRegistrationThrottleWebhookWithManager(mgr ctrl.Manager, limit int, window time.Duration) error {
+	if limit == 0 {
+		limit = RegistrationFailureLimitDefault
+	}
+	if window == 0 {
+		window = RegistrationFailureWindowDefault
+	}
+	mgr.GetWebhookServer().Register(registrationThrottleWebhookPath, &webhook.Admission{
+		Handler: &RegistrationThrottleWebhook{
+			Client: mgr.GetClient(),
+			Limit:  limit,
+			Window: window,
+		},
+	})
+	return nil
+}
+
+func (h *RegistrationThrottleWebhook) InjectDecoder(d *admission.Decoder) error {
+	h.decoder = d
+	return nil
+}
+
+func (h *RegistrationThrottleWebhook) Handle(ctx context.Context, req admission.Request) admission.Response {
+	// 음수이면 제한하지 않는다.
+	if h.Limit < 0 {
+		return admission.Allowed("")
+	}
+
+	clr := &ClusterRegistration{}
+	if err := h.decoder.Decode(req, clr); err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+	creator := clr.Annotations["creator"]
+	if creator == "" {
+		creator = req.UserInfo.Username
+	}
+
+	clrList := &ClusterRegistrationList{}
+	if err := h.Client.List(ctx, clrList); err != nil {
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+
+	now := time.Now()
+	failures := 0
+	var oldest time.Time
+	for _, item := range clrList.Items {
+		if item.Annotations["creator"] != creator || item.Status.Phase != ClusterRegistrationPhaseError {
+			continue
+		}
+		created := item.CreationTimestamp.Time
+		if now.Sub(created) > h.Window {
+			continue
+		}
+		failures++
+		if oldest.IsZero() || created.Before(oldest) {
+			oldest = created
+		}
+	}
+	if failures < h.Limit {
+		return admission.Allowed("")
+	}
+
+	// 가장 오래된 실패가 window 를 벗어나면 다시 생성할 수 있다.
+	retryAfter := int32(math.Ceil(oldest.Add(h.Window).Sub(now).Seconds()))
+	if retryAfter < 1 {
+		retryAfter = 1
+	}
+	return admission.Response{
+		AdmissionResponse: admissionv1.AdmissionResponse{
+			UID:     req.UID,
+			Allowed: false,
+			Result: &metav1.Status{
+				Status: metav1.StatusFailure,
+				Code:   http.StatusTooManyRequests,
+				Reason: metav1.StatusReasonTooManyRequests,
+				Message: fmt.Sprintf("cannot create ClusterRegistration: %d registrations of %s failed validation in the last %s, retry after %d seconds",
+					failures, creator, h.Window.String(), retryAfter),
+				Details: &metav1.StatusDetails{
+					RetryAfterSeconds: retryAfter,
+				},
+			},
+		},
+	}
+}
@@ -0,0 +1,147 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+type SecretSyncPhase string
+
+const (
+	// 선택된 모든 cluster 에 secret 이 동기화된 상태
+	SecretSyncPhaseSynced = SecretSyncPhase("Synced")
+	// 일부 cluster 에 secret 동기화가 진행중이거나 실패한 상태
+	SecretSyncPhaseProgressing = SecretSyncPhase("Progressing")
+	// 삭제가 진행중인 상태
+	SecretSyncPhaseDeleting = SecretSyncPhase("Deleting")
+)
+
+const (
+	SecretSyncFinalizer = "secretsync.cluster.tmax.io/finalizer"
+	// remote cluster 에 생성된 secret 에 다는 label
+	LabelKeySecretSync          = "secretsync.cluster.tmax.io/name"
+	LabelKeySecretSyncNamespace = "secretsync.cluster.tmax.io/namespace"
+	// remote cluster 에 생성된 secret 의 data hash
+	AnnotationKeySecretSyncHash = "secretsync.cluster.tmax.io/hash"
+)
+
+// SecretSyncSource defines the secret to be replicated
+type SecretSyncSource struct {
+	// +kubebuilder:validation:Required
+	// The name of the secret in the same namespace.
+	Name string `json:"name"`
+	// +kubebuilder:validation:Required
+	// The namespace of the member clusters where the secret is replicated.
+	TargetNamespace string `json:"targetNamespace"`
+	// The name of the replicated secret. Defaults to the name of the source secret.
+	TargetName string `json:"targetName,omitempty"`
+}
+
+// SecretSyncSpec defines the desired state of SecretSync
+type SecretSyncSpec struct {
+	// Label selector for cluster managers in the same namespace.
+	// An empty selector selects all cluster managers in the namespace.
+	ClusterSelector metav1.LabelSelector `json:"clusterSelector,omitempty"`
+	// +kubebuilder:validation:MinItems=1
+	// The secrets to be replicated.
+	Secrets []SecretSyncSource `json:"secrets"`
+}
+
+// SecretSyncClusterStatus defines the sync status for a cluster
+type SecretSyncClusterStatus struct {
+	// The name of the cluster manager.
+	Name string `json:"name"`
+	// True if all secrets are synced to the cluster.
+	Synced bool `json:"synced,omitempty"`
+	// The reason of the failure.
+	Reason string `json:"reason,omitempty"`
+	// The number of secrets overwritten because they were changed in the cluster.
+	DriftCount int `json:"driftCount,omitempty"`
+	// The secrets replicated to the cluster.
+	Resources []ManifestStatus `json:"resources,omitempty"`
+	// The last time the secrets are synced.
+	LastSyncTime metav1.Time `json:"lastSyncTime,omitempty"`
+}
+
+// SecretSyncStatus defines the observed state of SecretSync
+type SecretSyncStatus struct {
+	// +kubebuilder:validation:Enum=Synced;Progressing;Deleting;
+	// Phase of the secretsync.
+	Phase SecretSyncPhase `json:"phase,omitempty"`
+	// The reason why the source secrets cannot be read.
+	Reason string `json:"reason,omitempty"`
+	// The sync status of each selected cluster.
+	Clusters []SecretSyncClusterStatus `json:"clusters,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:path=secretsyncs,shortName=ssync,scope=Namespaced
+// +kubebuilder:printcolumn:name="Status",type=string,JSONPath=`.status.phase`
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+// SecretSync is the Schema for the secretsyncs API
+type SecretSync struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   SecretSyncSpec   `json:"spec"`
+	Status SecretSyncStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// SecretSyncList contains a list of SecretSync
+type SecretSyncList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []SecretSync `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&SecretSync{}, &SecretSyncList{})
+}
+
+func (s *SecretSyncStatus) SetTypedPhase(p SecretSyncPhase) {
+	s.Phase = p
+}
+
+func (s *SecretSync) GetNamespacedName() types.NamespacedName {
+	return types.NamespacedName{
+		Name:      s.Name,
+		Namespace: s.Namespace,
+	}
+}
+
+func (s *SecretSync) GetClusterSelector() (labels.Selector, error) {
+	return metav1.LabelSelectorAsSelector(&s.Spec.ClusterSelector)
+}
+
+func (s *SecretSyncStatus) GetClusterStatus(name string) *SecretSyncClusterStatus {
+	for i := range s.Clusters {
+		if s.Clusters[i].Name == name {
+			return &s.Clusters[i]
+		}
+	}
+	return nil
+}
+
+func (s SecretSyncSource) GetTargetName() string {
+	if s.TargetName != "" {
+		return s.TargetName
+	}
+	return s.Name
+}
@@ -0,0 +1,96 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	coreV1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// ServiceExportSpec defines the desired state of ServiceExport
+type ServiceExportSpec struct {
+	// +kubebuilder:validation:Required
+	// The name of the cluster manager which has the service.
+	ClusterName string `json:"clusterName"`
+	// +kubebuilder:validation:Required
+	// The namespace of the service in the member cluster.
+	ServiceNamespace string `json:"serviceNamespace"`
+	// +kubebuilder:validation:Required
+	// The name of the service in the member cluster.
+	ServiceName string `json:"serviceName"`
+}
+
+// ServiceExportPort defines the port of the exported service
+type ServiceExportPort struct {
+	// The name of the service port.
+	Name string `json:"name,omitempty"`
+	// The protocol of the service port.
+	Protocol coreV1.Protocol `json:"protocol,omitempty"`
+	// The port of the service.
+	Port int32 `json:"port"`
+	// The port of the endpoints.
+	TargetPort int32 `json:"targetPort,omitempty"`
+}
+
+// ServiceExportStatus defines the observed state of ServiceExport
+type ServiceExportStatus struct {
+	// True if the service and its endpoints are synced.
+	Ready bool `json:"ready,omitempty"`
+	// The reason of the failure.
+	Reason string `json:"reason,omitempty"`
+	// The ports of the service.
+	Ports []ServiceExportPort `json:"ports,omitempty"`
+	// The ready endpoint addresses of the service.
+	// The addresses must be routable from the importing clusters.
+	Addresses []string `json:"addresses,omitempty"`
+	// The last time the endpoints are synced.
+	LastSyncTime metav1.Time `json:"lastSyncTime,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:path=serviceexports,shortName=svcex,scope=Namespaced
+// +kubebuilder:printcolumn:name="Cluster",type=string,JSONPath=`.spec.clusterName`
+// +kubebuilder:printcolumn:name="Service",type=string,JSONPath=`.spec.serviceName`
+// +kubebuilder:printcolumn:name="Ready",type=boolean,JSONPath=`.status.ready`
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+// ServiceExport is the Schema for the serviceexports API
+type ServiceExport struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   ServiceExportSpec   `json:"spec"`
+	Status ServiceExportStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// ServiceExportList contains a list of ServiceExport
+type ServiceExportList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ServiceExport `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&ServiceExport{}, &ServiceExportList{})
+}
+
+func (s *ServiceExport) GetNamespacedName() types.NamespacedName {
+	return types.NamespacedName{
+		Name:      s.Name,
+		Namespace: s.Namespace,
+	}
+}
@@ -0,0 +1,130 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+type ServiceImportPhase string
+
+const (
+	// 선택된 모든 cluster 에 service 가 생성된 상태
+	ServiceImportPhaseSynced = ServiceImportPhase("Synced")
+	// 일부 cluster 에 service 생성이 진행중이거나 실패한 상태
+	ServiceImportPhaseProgressing = ServiceImportPhase("Progressing")
+	// 삭제가 진행중인 상태
+	ServiceImportPhaseDeleting = ServiceImportPhase("Deleting")
+)
+
+const (
+	ServiceImportFinalizer = "serviceimport.cluster.tmax.io/finalizer"
+	// remote cluster 에 생성된 리소스에 다는 label
+	LabelKeyServiceImport          = "serviceimport.cluster.tmax.io/name"
+	LabelKeyServiceImportNamespace = "serviceimport.cluster.tmax.io/namespace"
+)
+
+// ServiceImportSpec defines the desired state of ServiceImport
+type ServiceImportSpec struct {
+	// +kubebuilder:validation:Required
+	// The name of the service export in the same namespace.
+	ServiceExport string `json:"serviceExport"`
+	// Label selector for cluster managers in the same namespace.
+	// An empty selector selects all cluster managers in the namespace.
+	// The cluster of the service export is always excluded.
+	ClusterSelector metav1.LabelSelector `json:"clusterSelector,omitempty"`
+	// The namespace of the service to be created in the member clusters.
+	// Defaults to the namespace of the exported service.
+	ServiceNamespace string `json:"serviceNamespace,omitempty"`
+	// The name of the service to be created in the member clusters.
+	// Defaults to the name of the exported service.
+	ServiceName string `json:"serviceName,omitempty"`
+}
+
+// ServiceImportClusterStatus defines the sync status for a cluster
+type ServiceImportClusterStatus struct {
+	// The name of the cluster manager.
+	Name string `json:"name"`
+	// True if the service and endpoints are synced to the cluster.
+	Synced bool `json:"synced,omitempty"`
+	// The reason of the failure.
+	Reason string `json:"reason,omitempty"`
+	// The resources created in the cluster.
+	Resources []ManifestStatus `json:"resources,omitempty"`
+	// The last time the service is synced.
+	LastSyncTime metav1.Time `json:"lastSyncTime,omitempty"`
+}
+
+// ServiceImportStatus defines the observed state of ServiceImport
+type ServiceImportStatus struct {
+	// +kubebuilder:validation:Enum=Synced;Progressing;Deleting;
+	// Phase of the serviceimport.
+	Phase ServiceImportPhase `json:"phase,omitempty"`
+	// The sync status of each selected cluster.
+	Clusters []ServiceImportClusterStatus `json:"clusters,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:path=serviceimports,shortName=svcim,scope=Namespaced
+// +kubebuilder:printcolumn:name="ServiceExport",type=string,JSONPath=`.spec.serviceExport`
+// +kubebuilder:printcolumn:name="Status",type=string,JSONPath=`.status.phase`
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+// ServiceImport is the Schema for the serviceimports API
+type ServiceImport struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   ServiceImportSpec   `json:"spec"`
+	Status ServiceImportStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// ServiceImportList contains a list of ServiceImport
+type ServiceImportList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ServiceImport `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&ServiceImport{}, &ServiceImportList{})
+}
+
+func (s *ServiceImportStatus) SetTypedPhase(p ServiceImportPhase) {
+	s.Phase = p
+}
+
+func (s *ServiceImport) GetNamespacedName() types.NamespacedName {
+	return types.NamespacedName{
+		Name:      s.Name,
+		Namespace: s.Namespace,
+	}
+}
+
+func (s *ServiceImport) GetClusterSelector() (labels.Selector, error) {
+	return metav1.LabelSelectorAsSelector(&s.Spec.ClusterSelector)
+}
+
+func (s *ServiceImportStatus) GetClusterStatus(name string) *ServiceImportClusterStatus {
+	for i := range s.Clusters {
+		if s.Clusters[i].Name == name {
+			return &s.Clusters[i]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,168 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+type WorkloadDistributionPhase string
+
+const (
+	// 선택된 모든 cluster 에 manifest 가 적용된 상태
+	WorkloadDistributionPhaseApplied = WorkloadDistributionPhase("Applied")
+	// 일부 cluster 에 manifest 적용이 진행중이거나 실패한 상태
+	WorkloadDistributionPhaseProgressing = WorkloadDistributionPhase("Progressing")
+	// 삭제가 진행중인 상태
+	WorkloadDistributionPhaseDeleting = WorkloadDistributionPhase("Deleting")
+)
+
+const (
+	WorkloadDistributionFinalizer = "workloaddistribution.cluster.tmax.io/finalizer"
+	// remote cluster 에 적용된 리소스에 다는 label
+	LabelKeyWorkloadDistribution          = "workloaddistribution.cluster.tmax.io/name"
+	LabelKeyWorkloadDistributionNamespace = "workloaddistribution.cluster.tmax.io/namespace"
+)
+
+// Manifest represents a resource to be applied to the member cluster.
+// +kubebuilder:validation:EmbeddedResource
+// +kubebuilder:pruning:PreserveUnknownFields
+type Manifest struct {
+	runtime.RawExtension `json:",inline"`
+}
+
+// WorkloadDistributionSpec defines the desired state of WorkloadDistribution
+type WorkloadDistributionSpec struct {
+	// Label selector for cluster managers in the same namespace.
+	// An empty selector selects all cluster managers unless clusterGroup is set.
+	ClusterSelector metav1.LabelSelector `json:"clusterSelector,omitempty"`
+	// The name of the cluster group in the same namespace.
+	// If set, clusters of the group are also selected.
+	ClusterGroup string `json:"clusterGroup,omitempty"`
+	// +kubebuilder:validation:Required
+	// The manifests to be applied to the selected clusters.
+	Manifests []Manifest `json:"manifests"`
+}
+
+// ManifestStatus defines the applied status of a manifest
+type ManifestStatus struct {
+	APIVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"`
+	Namespace  string `json:"namespace,omitempty"`
+	Name       string `json:"name"`
+}
+
+func (m ManifestStatus) Key() string {
+	return m.APIVersion + "/" + m.Kind + "/" + m.Namespace + "/" + m.Name
+}
+
+// FanOutProgress defines the progress of rolling out the current generation to the selected clusters.
+// The clusters are processed in batches through the rate-limited work pool shared by the fleet-wide controllers.
+type FanOutProgress struct {
+	// The generation being rolled out.
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// The number of selected clusters.
+	Total int `json:"total"`
+	// The number of clusters to which the generation is applied successfully.
+	Updated int `json:"updated"`
+	// The number of clusters which failed to apply the generation. They are retried in the next batches.
+	Failed int `json:"failed"`
+	// The number of clusters waiting for their turn or for the control plane to be ready.
+	Pending int `json:"pending"`
+	// The last time a batch of clusters is processed.
+	LastBatchTime *metav1.Time `json:"lastBatchTime,omitempty"`
+}
+
+// WorkloadDistributionClusterStatus defines the apply status for a cluster
+type WorkloadDistributionClusterStatus struct {
+	// The name of the cluster manager.
+	Name string `json:"name"`
+	// True if all manifests are applied to the cluster.
+	Applied bool `json:"applied,omitempty"`
+	// The reason of the failure.
+	Reason string `json:"reason,omitempty"`
+	// The manifests applied to the cluster.
+	Resources []ManifestStatus `json:"resources,omitempty"`
+	// The last time the manifests are applied.
+	LastAppliedTime metav1.Time `json:"lastAppliedTime,omitempty"`
+	// The generation of the workloaddistribution last applied to the cluster.
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+}
+
+// WorkloadDistributionStatus defines the observed state of WorkloadDistribution
+type WorkloadDistributionStatus struct {
+	// +kubebuilder:validation:Enum=Applied;Progressing;Deleting;
+	// Phase of the workloaddistribution.
+	Phase WorkloadDistributionPhase `json:"phase,omitempty"`
+	// The generation observed by the controller.
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// The apply status of each selected cluster.
+	Clusters []WorkloadDistributionClusterStatus `json:"clusters,omitempty"`
+	// The progress of applying the current generation to the selected clusters.
+	Progress *FanOutProgress `json:"progress,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:path=workloaddistributions,shortName=wld,scope=Namespaced
+// +kubebuilder:printcolumn:name="Status",type=string,JSONPath=`.status.phase`
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+// WorkloadDistribution is the Schema for the workloaddistributions API
+type WorkloadDistribution struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   WorkloadDistributionSpec   `json:"spec"`
+	Status WorkloadDistributionStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// WorkloadDistributionList contains a list of WorkloadDistribution
+type WorkloadDistributionList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []WorkloadDistribution `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&WorkloadDistribution{}, &WorkloadDistributionList{})
+}
+
+func (w *WorkloadDistributionStatus) SetTypedPhase(p WorkloadDistributionPhase) {
+	w.Phase = p
+}
+
+func (w *WorkloadDistribution) GetNamespacedName() types.NamespacedName {
+	return types.NamespacedName{
+		Name:      w.Name,
+		Namespace: w.Namespace,
+	}
+}
+
+func (w *WorkloadDistribution) GetClusterSelector() (labels.Selector, error) {
+	return metav1.LabelSelectorAsSelector(&w.Spec.ClusterSelector)
+}
+
+func (w *WorkloadDistributionStatus) GetClusterStatus(name string) *WorkloadDistributionClusterStatus {
+	for i := range w.Clusters {
+		if w.Clusters[i].Name == name {
+			return &w.Clusters[i]
+		}
+	}
+	return nil
+}
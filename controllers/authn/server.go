@@ -0,0 +1,188 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package authn
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	clusterV1alpha1 "github.com/tmax-cloud/hypercloud-multi-operator/apis/cluster/v1alpha1"
+	hyperauthCaller "github.com/tmax-cloud/hypercloud-multi-operator/controllers/hyperAuth"
+	util "github.com/tmax-cloud/hypercloud-multi-operator/controllers/util"
+
+	authenticationV1 "k8s.io/api/authentication/v1"
+	coreV1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// member cluster 의 api server 는 /authenticate/<namespace>/<name> 으로 TokenReview 를 요청한다.
+	AuthenticatePath = "/authenticate/"
+
+	// TokenReview 요청 body 의 최대 크기
+	maxRequestBytes = 1 << 20
+)
+
+var (
+	errUnauthorized = errors.New("unauthorized")
+	errInvalidPath  = errors.New("path must be " + AuthenticatePath + "<namespace>/<name>")
+)
+
+// Server 는 oidc 를 설정할 수 없는 등록된 cluster 의 api server 가 webhook token authentication 으로
+// hyperauth 가 발급한 token 을 인증할 수 있도록 TokenReview 를 대신 처리하는 http server
+// member cluster 는 cluster 별로 생성한 webhook 설정 secret 의 token 으로 broker 에 인증한다.
+// manager 에 runnable 로 등록되어 manager 와 함께 시작되고 종료된다.
+type Server struct {
+	Client      client.Client
+	Log         logr.Logger
+	BindAddress string
+	// token 을 받으므로 https 로만 요청을 받는다. tls.crt, tls.key 가 있는 directory
+	CertDir string
+}
+
+// leader 가 아닌 replica 에서도 요청을 처리할 수 있도록 leader election 과 무관하게 동작한다.
+func (s *Server) NeedLeaderElection() bool {
+	return false
+}
+
+func (s *Server) Start(ctx context.Context) error {
+	mux := http.NewServeMux()
+	mux.HandleFunc(AuthenticatePath, s.handleAuthenticate)
+
+	server := &http.Server{
+		Addr:              s.BindAddress,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	if err := util.ConfigureServingCert(ctx, server, s.CertDir, s.Log); err != nil {
+		return err
+	}
+
+	errCh := make(chan error, 1)
+	go func() {
+		s.Log.Info("Starting authn broker", "address", s.BindAddress, "certDir", s.CertDir)
+		if err := server.ListenAndServeTLS("", ""); err != nil && err != http.ErrServerClosed {
+			errCh <- err
+		}
+		close(errCh)
+	}()
+
+	select {
+	case <-ctx.Done():
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		return server.Shutdown(shutdownCtx)
+	case err := <-errCh:
+		return err
+	}
+}
+
+func (s *Server) handleAuthenticate(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	key, err := parseAuthenticatePath(req.URL.Path)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	log := s.Log.WithValues("cluster", key.String())
+
+	if code, err := s.authorizeCluster(req, key); err != nil {
+		log.Info("Unauthorized authn request", "reason", err.Error())
+		http.Error(w, err.Error(), code)
+		return
+	}
+
+	tokenReview := &authenticationV1.TokenReview{}
+	if err := json.NewDecoder(http.MaxBytesReader(w, req.Body, maxRequestBytes)).Decode(tokenReview); err != nil {
+		http.Error(w, "invalid TokenReview", http.StatusBadRequest)
+		return
+	}
+
+	// api server 는 응답의 status 만 사용한다.
+	tokenReview.Status = authenticationV1.TokenReviewStatus{}
+	userInfo, err := hyperauthCaller.GetUserInfo(req.Context(), tokenReview.Spec.Token)
+	if err != nil {
+		log.Error(err, "Failed to get userinfo from hyperauth")
+		tokenReview.Status.Error = "failed to review the token"
+	} else if userInfo == nil || userInfo.PreferredUsername == "" {
+		tokenReview.Status.Error = "invalid token"
+	} else {
+		// api server 의 oidc 설정 (--oidc-username-prefix=-) 과 동일하게 prefix 를 붙이지 않는다.
+		tokenReview.Status.Authenticated = true
+		tokenReview.Status.User = authenticationV1.UserInfo{
+			Username: userInfo.PreferredUsername,
+			UID:      userInfo.Sub,
+			Groups:   userInfo.Group,
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(tokenReview); err != nil {
+		log.Error(err, "Failed to write TokenReview response")
+	}
+}
+
+func parseAuthenticatePath(path string) (types.NamespacedName, error) {
+	parts := strings.Split(strings.TrimPrefix(path, AuthenticatePath), "/")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return types.NamespacedName{}, errInvalidPath
+	}
+	return types.NamespacedName{Namespace: parts[0], Name: parts[1]}, nil
+}
+
+// 요청한 member cluster 의 bearer token 이 cluster 의 webhook 설정 secret 의 token 과 같은지 확인한다.
+// 아무나 broker 를 통해 token 의 유효성을 확인할 수 없도록 cluster 별 token 을 사용한다.
+func (s *Server) authorizeCluster(req *http.Request, key types.NamespacedName) (int, error) {
+	token := strings.TrimPrefix(req.Header.Get("Authorization"), "Bearer ")
+	if token == "" || token == req.Header.Get("Authorization") {
+		return http.StatusUnauthorized, errUnauthorized
+	}
+
+	clm := &clusterV1alpha1.ClusterManager{}
+	if err := s.Client.Get(req.Context(), key, clm); apierrors.IsNotFound(err) {
+		return http.StatusUnauthorized, errUnauthorized
+	} else if err != nil {
+		s.Log.Error(err, "Failed to get ClusterManager", "cluster", key.String())
+		return http.StatusInternalServerError, err
+	}
+
+	secret := &coreV1.Secret{}
+	secretKey := types.NamespacedName{Name: clm.GetAuthnWebhookSecretName(), Namespace: clm.Namespace}
+	if err := s.Client.Get(req.Context(), secretKey, secret); apierrors.IsNotFound(err) {
+		return http.StatusUnauthorized, errUnauthorized
+	} else if err != nil {
+		s.Log.Error(err, "Failed to get authn webhook secret", "cluster", key.String())
+		return http.StatusInternalServerError, err
+	}
+
+	expected := secret.Data[util.AuthnWebhookTokenKey]
+	if len(expected) == 0 || subtle.ConstantTimeCompare(expected, []byte(token)) != 1 {
+		return http.StatusUnauthorized, errUnauthorized
+	}
+	return http.StatusOK, nil
+}
@@ -16,15 +16,15 @@ package controllers
 
 import (
 	"context"
-	"time"
 
 	"github.com/go-logr/logr"
 	claimV1alpha1 "github.com/tmax-cloud/hypercloud-multi-operator/apis/claim/v1alpha1"
 	clusterV1alpha1 "github.com/tmax-cloud/hypercloud-multi-operator/apis/cluster/v1alpha1"
+	"github.com/tmax-cloud/hypercloud-multi-operator/controllers/pipeline"
+	"github.com/tmax-cloud/hypercloud-multi-operator/controllers/util"
 
 	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/runtime"
-	"k8s.io/apimachinery/pkg/types"
 
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -36,13 +36,6 @@ import (
 
 var AutoAdmit bool
 
-const (
-	requeueAfter10Second = 10 * time.Second
-	requeueAfter20Second = 20 * time.Second
-	requeueAfter30Second = 30 * time.Second
-	requeueAfter1Minute  = 1 * time.Minute
-)
-
 // ClusterClaimReconciler reconciles a ClusterClaim object
 type ClusterClaimReconciler struct {
 	client.Client
@@ -55,9 +48,13 @@ type ClusterClaimReconciler struct {
 // +kubebuilder:rbac:groups=rbac.authorization.k8s.io,resources=roles,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=rbac.authorization.k8s.io,resources=rolebindings,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=rbac.authorization.k8s.io,resources=clusterroles,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=rbac.authorization.k8s.io,resources=clusterroles,verbs=bind,resourceNames=admin
+// +kubebuilder:rbac:groups="",resources=namespaces,verbs=get;list;watch;create
+// +kubebuilder:rbac:groups="",resources=resourcequotas,verbs=get;list;watch;create
 // +kubebuilder:rbac:groups=rbac.authorization.k8s.io,resources=clusterrolebindings,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=cluster.tmax.io,resources=clustermanagers,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=cluster.tmax.io,resources=clustermanagers/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=claim.tmax.io,resources=placementpolicies,verbs=get;list;watch
 
 // cluster claim 이 생성되면, reconcile 함수는 해당 cluster claim 의 status 를 awaiting 으로 변경해준다.
 // 해당 claim 으로 생성한 cluster 에 대한 cluster manager 의 생성은 hypercloud-api-server 에서 진행된다.
@@ -68,39 +65,33 @@ func (r *ClusterClaimReconciler) Reconcile(ctx context.Context, req ctrl.Request
 	clusterClaim := &claimV1alpha1.ClusterClaim{}
 	if err := r.Client.Get(context.TODO(), req.NamespacedName, clusterClaim); errors.IsNotFound(err) {
 		log.Info("ClusterClaim resource not found. Ignoring since object must be deleted")
+		pipeline.Forget(clusterClaim, req.NamespacedName)
 		return ctrl.Result{}, nil
 	} else if err != nil {
 		log.Error(err, "Failed to get ClusterClaim")
 		return ctrl.Result{}, err
 	}
 
-	if !AutoAdmit {
-		Awaiting := clusterClaim.Status.Phase == claimV1alpha1.ClusterClaimPhaseAwaiting
-		if clusterClaim.Status.Phase == "" {
-			clusterClaim.Status.SetTypedPhase(claimV1alpha1.ClusterClaimPhaseAwaiting)
-			clusterClaim.Status.SetReason("Waiting for admin approval")
-			err := r.Status().Update(context.TODO(), clusterClaim)
-			if err != nil {
-				log.Error(err, "Failed to update ClusterClaim status")
-				return ctrl.Result{}, err
-			}
-			return ctrl.Result{}, nil
-		} else if Awaiting {
-			return ctrl.Result{}, nil
-		}
-	}
-
-	// console로부터 approved로 변경시 clustermanager 생성
-	Approved := clusterClaim.Status.Phase == claimV1alpha1.ClusterClaimPhaseApproved
-	if Approved {
-		if err := r.CreateClusterManager(context.TODO(), clusterClaim); err != nil {
-			log.Error(err, "Failed to Create ClusterManager")
-			return ctrl.Result{RequeueAfter: requeueAfter10Second}, nil
-		}
-		return ctrl.Result{}, nil
+	phases := []pipeline.Phase[*claimV1alpha1.ClusterClaim]{
+		// provider 가 지정되지 않은 경우, 승인 전에 배치할 target 을 먼저 선택한다.
+		{Name: "PlaceClusterClaim", Run: r.PlaceClusterClaim, When: needsPlacement},
+		// 새로 생성된 claim 의 status 를 awaiting 으로 변경하고 관리자의 승인을 기다린다.
+		{
+			Name:      "AwaitApproval",
+			Run:       r.AwaitApproval,
+			When:      needsApproval,
+			DependsOn: []string{"PlaceClusterClaim"},
+		},
+		// console로부터 approved로 변경시 clustermanager 생성
+		{
+			Name:      "CreateClusterManager",
+			Run:       r.reconcileApproved,
+			When:      isApproved,
+			DependsOn: []string{"PlaceClusterClaim", "AwaitApproval"},
+		},
 	}
 
-	return ctrl.Result{}, nil
+	return pipeline.NewRunner[*claimV1alpha1.ClusterClaim](r.Log, nil).Run(ctx, clusterClaim, phases)
 }
 
 func (r *ClusterClaimReconciler) RequeueClusterClaimsForClusterManager(o client.Object) []ctrl.Request {
@@ -108,32 +99,37 @@ func (r *ClusterClaimReconciler) RequeueClusterClaimsForClusterManager(o client.
 	log := r.Log.WithValues("objectMapper", "clusterManagerToClusterClaim", "clusterManager", clm.Name)
 	log.Info("Start to clusterManagerToClusterClaim mapping...")
 
-	//get clusterManager
-	cc := &claimV1alpha1.ClusterClaim{}
-	key := types.NamespacedName{
-		Name:      clm.Labels[clusterV1alpha1.LabelKeyClcName],
-		Namespace: clm.Namespace,
+	// cluster manager 의 label 이 아닌 clusterClaim 의 spec.clusterName 으로 조회한다.
+	// target namespace 에 생성된 cluster manager 는 label 에 기록된 claim 의 namespace 에서 조회한다.
+	namespace := clm.Namespace
+	if clcNamespace := clm.Labels[clusterV1alpha1.LabelKeyClcNamespace]; clcNamespace != "" {
+		namespace = clcNamespace
 	}
-	if err := r.Client.Get(context.TODO(), key, cc); errors.IsNotFound(err) {
-		log.Info("ClusterClaim resource not found. Ignoring since object must be deleted")
-		return nil
-	} else if err != nil {
-		log.Error(err, "Failed to get ClusterClaim")
+	ccs := &claimV1alpha1.ClusterClaimList{}
+	if err := r.Client.List(context.TODO(), ccs,
+		client.InNamespace(namespace),
+		client.MatchingFields{util.IndexKeyClusterName: clm.Name},
+	); err != nil {
+		log.Error(err, "Failed to list ClusterClaims")
 		return nil
 	}
 
-	NotApproved := cc.Status.Phase != claimV1alpha1.ClusterClaimPhaseApproved
-	if NotApproved {
-		log.Info("ClusterClaims for ClusterManager [" + cc.Spec.ClusterName + "] is already delete... Do not update cc status to delete ")
-		return nil
-	}
+	for i := range ccs.Items {
+		cc := &ccs.Items[i]
+		if cc.GetTargetNamespace() != clm.Namespace {
+			continue
+		}
+		NotApproved := cc.Status.Phase != claimV1alpha1.ClusterClaimPhaseApproved
+		if NotApproved {
+			log.Info("ClusterClaims for ClusterManager is already delete... Do not update cc status to delete", "clusterManager", cc.Spec.ClusterName)
+			continue
+		}
 
-	cc.Status.SetTypedPhase(claimV1alpha1.ClusterClaimPhaseClusterDeleted)
-	cc.Status.SetReason("cluster is deleted")
-	err := r.Status().Update(context.TODO(), cc)
-	if err != nil {
-		log.Error(err, "Failed to update ClusterClaim status")
-		return nil //??
+		cc.Status.SetTypedPhase(claimV1alpha1.ClusterClaimPhaseClusterDeleted)
+		cc.Status.SetReason("cluster is deleted")
+		if err := r.Status().Update(context.TODO(), cc); err != nil {
+			log.Error(err, "Failed to update ClusterClaim status", "clusterClaim", cc.Name)
+		}
 	}
 	return nil
 }
@@ -141,13 +137,17 @@ func (r *ClusterClaimReconciler) RequeueClusterClaimsForClusterManager(o client.
 func (r *ClusterClaimReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	controller, err := ctrl.NewControllerManagedBy(mgr).
 		For(&claimV1alpha1.ClusterClaim{}).
+		WithOptions(util.DefaultControllerOptions()).
 		WithEventFilter(
 			predicate.Funcs{
 				CreateFunc: func(e event.CreateEvent) bool {
 					return true
 				},
 				UpdateFunc: func(e event.UpdateEvent) bool {
-					return true
+					oldCc := e.ObjectOld.(*claimV1alpha1.ClusterClaim)
+					newCc := e.ObjectNew.(*claimV1alpha1.ClusterClaim)
+					// 승인, 거절은 status.phase 를 변경하는 방식이므로 phase 변경은 통과시킨다.
+					return util.IsSpecChanged(oldCc, newCc) || oldCc.Status.Phase != newCc.Status.Phase
 				},
 				DeleteFunc: func(e event.DeleteEvent) bool {
 					return false
@@ -157,7 +157,7 @@ func (r *ClusterClaimReconciler) SetupWithManager(mgr ctrl.Manager) error {
 				},
 			},
 		).
-		Build(r)
+		Build(util.ShardReconciler(mgr.GetClient(), r))
 
 	if err != nil {
 		return err
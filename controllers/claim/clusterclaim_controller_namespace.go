@@ -0,0 +1,139 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	claimV1alpha1 "github.com/tmax-cloud/hypercloud-multi-operator/apis/claim/v1alpha1"
+	"github.com/tmax-cloud/hypercloud-multi-operator/controllers/util"
+	coreV1 "k8s.io/api/core/v1"
+	rbacV1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+const (
+	// 생성한 namespace 에서 claim 의 creator 와 team 에게 부여하는 ClusterRole
+	claimNamespaceClusterRole = "admin"
+	claimNamespaceRoleBinding = "clusterclaim-admin"
+)
+
+// ProvisionTargetNamespace 는 claim 의 target namespace 가 없으면 CLAIM_NAMESPACE_PROVISIONING 이 설정된 경우에 생성한다.
+// 이미 있는 namespace 는 변경하지 않고, 새로 생성한 namespace 에만 quota 와 rolebinding 을 생성한다.
+func (r *ClusterClaimReconciler) ProvisionTargetNamespace(ctx context.Context, cc *claimV1alpha1.ClusterClaim) error {
+	name := cc.GetTargetNamespace()
+	namespace := &coreV1.Namespace{}
+	if err := r.Client.Get(context.TODO(), types.NamespacedName{Name: name}, namespace); err == nil {
+		// 생성 도중 실패한 경우 quota 와 rolebinding 을 다시 생성한다.
+		if namespace.Labels[claimV1alpha1.LabelKeyProvisionedByClaim] != cc.Name ||
+			namespace.Labels[claimV1alpha1.LabelKeyProvisionedByClaimNamespace] != cc.Namespace {
+			return nil
+		}
+	} else if !errors.IsNotFound(err) {
+		return err
+	} else if !util.IsTrue(os.Getenv(util.CLAIM_NAMESPACE_PROVISIONING)) {
+		return fmt.Errorf("target namespace %s not found", name)
+	} else {
+		namespace = &coreV1.Namespace{
+			ObjectMeta: metaV1.ObjectMeta{
+				Name: name,
+				Labels: map[string]string{
+					util.LabelKeyManagedBy:                            util.ManagedByOperator,
+					claimV1alpha1.LabelKeyProvisionedByClaim:          cc.Name,
+					claimV1alpha1.LabelKeyProvisionedByClaimNamespace: cc.Namespace,
+				},
+				Annotations: map[string]string{
+					util.AnnotationKeyOwner:   cc.Annotations[util.AnnotationKeyCreator],
+					util.AnnotationKeyCreator: cc.Annotations[util.AnnotationKeyCreator],
+				},
+			},
+		}
+		if team := cc.Annotations[util.AnnotationKeyTeam]; team != "" {
+			namespace.Annotations[util.AnnotationKeyTeam] = team
+		}
+		if err := r.Create(context.TODO(), namespace); err != nil && !errors.IsAlreadyExists(err) {
+			return err
+		}
+		r.Log.Info("Created target namespace for ClusterClaim", "namespace", name, "clusterClaim", cc.Name)
+	}
+
+	if err := r.createNamespaceResourceQuota(cc, name); err != nil {
+		return err
+	}
+	return r.createNamespaceRoleBinding(cc, name)
+}
+
+// hypercloud5-system 의 CLAIM_NAMESPACE_RESOURCE_QUOTA 의 spec 을 복사한다.
+func (r *ClusterClaimReconciler) createNamespaceResourceQuota(cc *claimV1alpha1.ClusterClaim, namespace string) error {
+	quotaName := os.Getenv(util.CLAIM_NAMESPACE_RESOURCE_QUOTA)
+	if quotaName == "" {
+		return nil
+	}
+
+	source := &coreV1.ResourceQuota{}
+	key := types.NamespacedName{Name: quotaName, Namespace: util.HypercloudNamespace}
+	if err := r.Client.Get(context.TODO(), key, source); err != nil {
+		return err
+	}
+
+	quota := &coreV1.ResourceQuota{
+		ObjectMeta: metaV1.ObjectMeta{
+			Name:      quotaName,
+			Namespace: namespace,
+			Labels: map[string]string{
+				util.LabelKeyManagedBy:                   util.ManagedByOperator,
+				claimV1alpha1.LabelKeyProvisionedByClaim: cc.Name,
+			},
+		},
+		Spec: *source.Spec.DeepCopy(),
+	}
+	if err := r.Create(context.TODO(), quota); err != nil && !errors.IsAlreadyExists(err) {
+		return err
+	}
+	return nil
+}
+
+// claim 의 creator 와 team group 에게 namespace 의 admin 권한을 부여한다.
+func (r *ClusterClaimReconciler) createNamespaceRoleBinding(cc *claimV1alpha1.ClusterClaim, namespace string) error {
+	subjects := []rbacV1.Subject{}
+	if creator := cc.Annotations[util.AnnotationKeyCreator]; creator != "" {
+		subjects = append(subjects, rbacV1.Subject{
+			APIGroup: rbacV1.GroupName,
+			Kind:     rbacV1.UserKind,
+			Name:     creator,
+		})
+	}
+	if team := cc.Annotations[util.AnnotationKeyTeam]; team != "" {
+		subjects = append(subjects, rbacV1.Subject{
+			APIGroup: rbacV1.GroupName,
+			Kind:     rbacV1.GroupKind,
+			Name:     team,
+		})
+	}
+	if len(subjects) == 0 {
+		return nil
+	}
+
+	roleBinding := &rbacV1.RoleBinding{
+		ObjectMeta: metaV1.ObjectMeta{
+			Name:      claimNamespaceRoleBinding,
+			Namespace: namespace,
+			Labels: map[string]string{
+				util.LabelKeyManagedBy:                   util.ManagedByOperator,
+				claimV1alpha1.LabelKeyProvisionedByClaim: cc.Name,
+			},
+		},
+		RoleRef: rbacV1.RoleRef{
+			APIGroup: rbacV1.GroupName,
+			Kind:     "ClusterRole",
+			Name:     claimNamespaceClusterRole,
+		},
+		Subjects: subjects,
+	}
+	if err := r.Create(context.TODO(), roleBinding); err != nil && !errors.IsAlreadyExists(err) {
+		return err
+	}
+	return nil
+}
@@ -13,14 +13,48 @@ import (
 	"k8s.io/apimachinery/pkg/api/errors"
 	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
 )
 
+// AUTO_ADMIT 이 아닌 경우, 새로 생성된 claim 은 관리자의 승인을 기다려야 한다.
+func needsApproval(cc *claimV1alpha1.ClusterClaim) bool {
+	return !AutoAdmit && cc.Status.Phase == ""
+}
+
+func isApproved(cc *claimV1alpha1.ClusterClaim) bool {
+	return cc.Status.Phase == claimV1alpha1.ClusterClaimPhaseApproved
+}
+
+func (r *ClusterClaimReconciler) AwaitApproval(ctx context.Context, cc *claimV1alpha1.ClusterClaim) (ctrl.Result, error) {
+	cc.Status.SetTypedPhase(claimV1alpha1.ClusterClaimPhaseAwaiting)
+	cc.Status.SetReason("Waiting for admin approval")
+	if err := r.Status().Update(context.TODO(), cc); err != nil {
+		r.Log.Error(err, "Failed to update ClusterClaim status", "clusterClaim", cc.GetNamespacedName())
+		return ctrl.Result{}, err
+	}
+	r.notify(cc, clusterV1alpha1.NotificationEventClaimPending, "ClusterClaim ["+cc.Name+"] is waiting for admin approval")
+	return ctrl.Result{}, nil
+}
+
+// CreateClusterManager 가 실패하면 error 를 반환하지 않고 다시 시도한다.
+func (r *ClusterClaimReconciler) reconcileApproved(ctx context.Context, cc *claimV1alpha1.ClusterClaim) (ctrl.Result, error) {
+	if err := r.CreateClusterManager(ctx, cc); err != nil {
+		r.Log.Error(err, "Failed to Create ClusterManager", "clusterClaim", cc.GetNamespacedName())
+		return ctrl.Result{Requeue: true}, nil
+	}
+	return ctrl.Result{}, nil
+}
+
 func (r *ClusterClaimReconciler) CreateClusterManager(ctx context.Context, cc *claimV1alpha1.ClusterClaim) error {
 
 	clmKey := cc.GetClusterManagerNamespacedName()
 	clm := &clusterV1alpha1.ClusterManager{}
 
 	if err := r.Client.Get(context.TODO(), clmKey, clm); errors.IsNotFound(err) {
+		if err := r.ProvisionTargetNamespace(ctx, cc); err != nil {
+			return err
+		}
+
 		clm, err := r.ConstructClusterManagerByClaim(cc)
 		if err != nil {
 			return err
@@ -29,6 +63,24 @@ func (r *ClusterClaimReconciler) CreateClusterManager(ctx context.Context, cc *c
 			return err
 		}
 
+		approver := ""
+		if cc.Status.Review != nil {
+			approver = cc.Status.Review.Reviewer
+		}
+		r.recordAudit(&clm, clusterV1alpha1.ClusterAuditActionClusterApproved, approver, "ClusterClaim ["+cc.Name+"] is approved")
+		r.recordAudit(&clm, clusterV1alpha1.ClusterAuditActionClusterCreated, cc.Annotations[util.AnnotationKeyCreator], "Created by ClusterClaim ["+cc.Name+"]")
+
+		err = util.PushClusterEvent(util.ClusterEvent{
+			Type:        util.ClusterEventTypeClusterCreated,
+			Namespace:   clm.Namespace,
+			ClusterName: clm.Name,
+			Owner:       clm.Annotations[util.AnnotationKeyOwner],
+		})
+		if err != nil {
+			r.Log.Error(err, "Failed to push cluster event for ClusterManager", "clusterManager", clm.Name)
+		}
+		r.notify(cc, clusterV1alpha1.NotificationEventClusterCreated, "Created by ClusterClaim ["+cc.Name+"]")
+
 	} else if err != nil {
 		return err
 	}
@@ -37,20 +89,26 @@ func (r *ClusterClaimReconciler) CreateClusterManager(ctx context.Context, cc *c
 }
 
 func (r *ClusterClaimReconciler) ConstructClusterManagerByClaim(cc *claimV1alpha1.ClusterClaim) (clusterV1alpha1.ClusterManager, error) {
+	// placement 된 claim 은 선택된 target 의 provider 와 region 으로 cluster 를 생성한다.
+	cc = cc.DeepCopy()
+	cc.ApplyPlacement()
+
 	clmSpec := clusterV1alpha1.ClusterManagerSpec{
 		Provider:  cc.Spec.Provider,
 		Version:   cc.Spec.Version,
 		MasterNum: cc.Spec.MasterNum,
 		WorkerNum: cc.Spec.WorkerNum,
+		Network:   cc.Spec.Network.DeepCopy(),
 	}
 
 	clm := clusterV1alpha1.ClusterManager{
 		ObjectMeta: metaV1.ObjectMeta{
 			Name:      cc.Spec.ClusterName,
-			Namespace: cc.Namespace,
+			Namespace: cc.GetTargetNamespace(),
 			Labels: map[string]string{
 				clusterV1alpha1.LabelKeyClmClusterType: clusterV1alpha1.ClusterTypeCreated,
 				clusterV1alpha1.LabelKeyClcName:        cc.Name,
+				clusterV1alpha1.LabelKeyClcNamespace:   cc.Namespace,
 			},
 			Annotations: map[string]string{
 				"owner":                                cc.Annotations[util.AnnotationKeyCreator],
@@ -61,6 +119,12 @@ func (r *ClusterClaimReconciler) ConstructClusterManagerByClaim(cc *claimV1alpha
 		Spec: clmSpec,
 	}
 
+	if placement := cc.Status.Placement; placement != nil {
+		clm.Labels[claimV1alpha1.LabelKeyPlacementPolicy] = placement.Policy
+		clm.Labels[claimV1alpha1.LabelKeyPlacementPolicyNamespace] = placement.PolicyNamespace
+		clm.Labels[claimV1alpha1.LabelKeyPlacementTarget] = placement.Target
+	}
+
 	if util.IsAWSProvider(cc.Spec.Provider) {
 		awsSpec, err := NewAwsSpec(cc)
 		if err != nil {
@@ -86,6 +150,10 @@ func (r *ClusterClaimReconciler) ConstructClusterManagerByClaim(cc *claimV1alpha
 func NewVsphereSpec(cc *claimV1alpha1.ClusterClaim) (clusterV1alpha1.ProviderVsphereSpec, error) {
 
 	podCidr := cc.Spec.ProviderVsphereSpec.PodCidr
+	// template 에는 primary ip family 의 cidr 을 넣고, dual-stack 인 경우 나머지는 capi cluster 에 추가한다.
+	if network := cc.Spec.Network; network != nil && len(network.PodCIDRs) != 0 {
+		podCidr = network.PodCIDRs[0]
+	}
 	if podCidr == "" {
 		podCidr = "10.0.0.0/16"
 	}
@@ -213,3 +281,21 @@ func (r *ClusterClaimReconciler) LoadVsphereCredentials(clm *clusterV1alpha1.Clu
 	clm.VsphereSpec.VcenterPassword = password
 	return nil
 }
+
+func (r *ClusterClaimReconciler) notify(cc *claimV1alpha1.ClusterClaim, event clusterV1alpha1.NotificationEvent, message string) {
+	err := util.Notify(r.Client, util.Notification{
+		Event:       event,
+		Namespace:   cc.Namespace,
+		ClusterName: cc.Spec.ClusterName,
+		Message:     message,
+	})
+	if err != nil {
+		r.Log.Error(err, "Failed to send notification for ClusterClaim", "event", string(event), "clusterClaim", cc.Name)
+	}
+}
+
+func (r *ClusterClaimReconciler) recordAudit(clm *clusterV1alpha1.ClusterManager, action clusterV1alpha1.ClusterAuditAction, actor, message string) {
+	if err := util.RecordAudit(r.Client, clm.Namespace, clm.Name, action, actor, message); err != nil {
+		r.Log.Error(err, "Failed to record audit for ClusterManager", "action", string(action), "clusterManager", clm.Name)
+	}
+}
@@ -0,0 +1,194 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	claimV1alpha1 "github.com/tmax-cloud/hypercloud-multi-operator/apis/claim/v1alpha1"
+	clusterV1alpha1 "github.com/tmax-cloud/hypercloud-multi-operator/apis/cluster/v1alpha1"
+	"github.com/tmax-cloud/hypercloud-multi-operator/controllers/util"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// 거절되지 않은 claim 중 provider 가 지정되지 않은 claim 만 배치한다.
+func needsPlacement(cc *claimV1alpha1.ClusterClaim) bool {
+	return cc.NeedsPlacement() && cc.Status.Phase != claimV1alpha1.ClusterClaimPhaseRejected
+}
+
+// PlaceClusterClaim 은 provider 가 지정되지 않은 claim 에 대해 PlacementPolicy 에 따라 target 을 선택하고,
+// 선택 결과를 claim 의 status 에 기록한다. 선택할 수 있는 target 이 없으면 주기적으로 다시 시도한다.
+func (r *ClusterClaimReconciler) PlaceClusterClaim(ctx context.Context, cc *claimV1alpha1.ClusterClaim) (ctrl.Result, error) {
+	log := r.Log.WithValues("ClusterClaim", cc.GetNamespacedName())
+	log.Info("Start to place ClusterClaim")
+
+	policy, err := r.getPlacementPolicy(cc.Namespace)
+	if err != nil {
+		log.Error(err, "Failed to get PlacementPolicy")
+		return ctrl.Result{}, err
+	}
+	if policy == nil {
+		return r.setPlacementFailed(cc, "No PlacementPolicy is found")
+	}
+
+	if policy.Spec.NamespaceQuota > 0 {
+		count, err := r.countNamespaceClusters(cc)
+		if err != nil {
+			log.Error(err, "Failed to count clusters in namespace")
+			return ctrl.Result{}, err
+		}
+		if count >= policy.Spec.NamespaceQuota {
+			return r.setPlacementFailed(cc, fmt.Sprintf("Namespace quota exceeded (%d/%d)", count, policy.Spec.NamespaceQuota))
+		}
+	}
+
+	counts, err := r.countPlacedClusters(policy, cc)
+	if err != nil {
+		log.Error(err, "Failed to count placed clusters")
+		return ctrl.Result{}, err
+	}
+	target, reason := selectPlacementTarget(policy, counts)
+	if target == nil {
+		return r.setPlacementFailed(cc, "All targets of PlacementPolicy ["+policy.Name+"] are full")
+	}
+
+	cc.Status.Placement = &claimV1alpha1.ClusterClaimPlacement{
+		PolicyNamespace:     policy.Namespace,
+		Policy:              policy.Name,
+		Target:              target.Name,
+		Provider:            target.Provider,
+		Region:              target.Region,
+		ProviderVsphereSpec: target.ProviderVsphereSpec,
+		Reason:              reason,
+		PlacedTime:          metaV1.Now(),
+	}
+	if err := r.Status().Update(context.TODO(), cc); err != nil {
+		log.Error(err, "Failed to update ClusterClaim status")
+		return ctrl.Result{}, err
+	}
+
+	log.Info("ClusterClaim is placed on target of PlacementPolicy", "target", target.Name, "placementPolicy", policy.Name)
+	return ctrl.Result{}, nil
+}
+
+func (r *ClusterClaimReconciler) setPlacementFailed(cc *claimV1alpha1.ClusterClaim, message string) (ctrl.Result, error) {
+	r.Log.Info("Failed to place ClusterClaim", "reason", message, "clusterClaim", cc.Name)
+	if cc.Status.Reason != "PlacementFailed" || cc.Status.Message != message {
+		cc.Status.SetReason("PlacementFailed")
+		cc.Status.Message = message
+		if err := r.Status().Update(context.TODO(), cc); err != nil {
+			r.Log.Error(err, "Failed to update ClusterClaim status")
+			return ctrl.Result{}, err
+		}
+	}
+	return ctrl.Result{Requeue: true}, nil
+}
+
+// claim 의 namespace 에 PlacementPolicy 가 없으면 hypercloud5-system namespace 의 policy 를 사용한다.
+// 여러 policy 가 있는 경우 이름 순으로 첫번째 policy 를 사용한다.
+func (r *ClusterClaimReconciler) getPlacementPolicy(namespace string) (*claimV1alpha1.PlacementPolicy, error) {
+	for _, ns := range []string{namespace, util.HypercloudNamespace} {
+		policyList := &claimV1alpha1.PlacementPolicyList{}
+		if err := r.Client.List(context.TODO(), policyList, client.InNamespace(ns)); err != nil {
+			return nil, err
+		}
+		if len(policyList.Items) == 0 {
+			continue
+		}
+		sort.Slice(policyList.Items, func(i, j int) bool {
+			return policyList.Items[i].Name < policyList.Items[j].Name
+		})
+		return &policyList.Items[0], nil
+	}
+	return nil, nil
+}
+
+// namespace 의 cluster 와 승인을 기다리는 placement 된 claim 의 개수
+func (r *ClusterClaimReconciler) countNamespaceClusters(cc *claimV1alpha1.ClusterClaim) (int, error) {
+	clmList := &clusterV1alpha1.ClusterManagerList{}
+	if err := r.Client.List(context.TODO(), clmList, client.InNamespace(cc.Namespace)); err != nil {
+		return 0, err
+	}
+	ccList := &claimV1alpha1.ClusterClaimList{}
+	if err := r.Client.List(context.TODO(), ccList, client.InNamespace(cc.Namespace)); err != nil {
+		return 0, err
+	}
+
+	count := len(clmList.Items)
+	for _, pending := range ccList.Items {
+		if isReservedClaim(&pending, cc) {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// target 별로 배치된 cluster 와 승인을 기다리는 placement 된 claim 의 개수
+func (r *ClusterClaimReconciler) countPlacedClusters(policy *claimV1alpha1.PlacementPolicy, cc *claimV1alpha1.ClusterClaim) (map[string]int, error) {
+	clmList := &clusterV1alpha1.ClusterManagerList{}
+	opts := []client.ListOption{
+		client.MatchingLabels{
+			claimV1alpha1.LabelKeyPlacementPolicy:          policy.Name,
+			claimV1alpha1.LabelKeyPlacementPolicyNamespace: policy.Namespace,
+		},
+	}
+	if err := r.Client.List(context.TODO(), clmList, opts...); err != nil {
+		return nil, err
+	}
+	ccList := &claimV1alpha1.ClusterClaimList{}
+	if err := r.Client.List(context.TODO(), ccList); err != nil {
+		return nil, err
+	}
+
+	counts := map[string]int{}
+	for _, clm := range clmList.Items {
+		counts[clm.Labels[claimV1alpha1.LabelKeyPlacementTarget]]++
+	}
+	for _, pending := range ccList.Items {
+		if !isReservedClaim(&pending, cc) {
+			continue
+		}
+		if pending.Status.Placement.Policy == policy.Name && pending.Status.Placement.PolicyNamespace == policy.Namespace {
+			counts[pending.Status.Placement.Target]++
+		}
+	}
+	return counts, nil
+}
+
+// 아직 cluster manager 가 생성되지 않았지만 target 이 선택된 claim 은 capacity 를 차지한다.
+func isReservedClaim(pending, cc *claimV1alpha1.ClusterClaim) bool {
+	if pending.Namespace == cc.Namespace && pending.Name == cc.Name {
+		return false
+	}
+	return pending.Status.Placement != nil &&
+		(pending.Status.Phase == "" || pending.Status.Phase == claimV1alpha1.ClusterClaimPhaseAwaiting)
+}
+
+// capacity 가 남은 target 중 strategy 에 따라 target 을 선택하고 선택한 이유를 반환한다.
+func selectPlacementTarget(policy *claimV1alpha1.PlacementPolicy, counts map[string]int) (*claimV1alpha1.PlacementTarget, string) {
+	var selected *claimV1alpha1.PlacementTarget
+	for i := range policy.Spec.Targets {
+		target := &policy.Spec.Targets[i]
+		if target.Capacity > 0 && counts[target.Name] >= target.Capacity {
+			continue
+		}
+		if policy.GetStrategy() == claimV1alpha1.PlacementStrategyOrdered {
+			selected = target
+			break
+		}
+		if selected == nil || counts[target.Name] < counts[selected.Name] {
+			selected = target
+		}
+	}
+	if selected == nil {
+		return nil, ""
+	}
+
+	reason := fmt.Sprintf("%s strategy selected target with %d clusters", policy.GetStrategy(), counts[selected.Name])
+	if selected.Capacity > 0 {
+		reason = fmt.Sprintf("%s (capacity %d)", reason, selected.Capacity)
+	}
+	return selected, reason
+}
@@ -21,6 +21,7 @@ import (
 	"github.com/go-logr/logr"
 	claimV1alpha1 "github.com/tmax-cloud/hypercloud-multi-operator/apis/claim/v1alpha1"
 	clusterV1alpha1 "github.com/tmax-cloud/hypercloud-multi-operator/apis/cluster/v1alpha1"
+	"github.com/tmax-cloud/hypercloud-multi-operator/controllers/util"
 	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/runtime"
 	"sigs.k8s.io/cluster-api/util/patch"
@@ -37,6 +38,8 @@ type ClusterUpdateClaimReconciler struct {
 	client.Client
 	Log    logr.Logger
 	Scheme *runtime.Scheme
+	// claim 을 실행하기 전에 확인하는 항목. 설정하지 않으면 DefaultPreflightChecks 를 사용한다.
+	PreflightChecks []PreflightCheck
 }
 
 const (
@@ -102,7 +105,7 @@ func (r *ClusterUpdateClaimReconciler) reconcile(ctx context.Context, cuc *claim
 		log.Info(fmt.Sprintf("Deleting clustermanager [%s]. cannot use cluster update claim.", cuc.Spec.ClusterName))
 		cuc.Status.SetTypedPhase(claimV1alpha1.ClusterUpdateClaimPhaseError)
 		cuc.Status.SetTypedReason(claimV1alpha1.ClusterUpdateClaimReasonClusterIsDeleting)
-		return ctrl.Result{RequeueAfter: requeueAfter10Second}, nil
+		return ctrl.Result{Requeue: true}, nil
 	}
 
 	if clm.GetClusterType() != clusterV1alpha1.ClusterTypeCreated {
@@ -112,11 +115,27 @@ func (r *ClusterUpdateClaimReconciler) reconcile(ctx context.Context, cuc *claim
 		return ctrl.Result{}, nil
 	}
 
+	// vsphere 는 version 과 vcenterTemplate 을 함께 변경해야 하므로 claim 으로 upgrade 할 수 없다.
+	if cuc.Spec.UpdatedVersion != "" && clm.Spec.Provider == clusterV1alpha1.ProviderVSphere {
+		log.Info(fmt.Sprintf("Clustermanager[%s] cannot be upgraded by claim.", cuc.Spec.ClusterName))
+		cuc.Status.SetTypedPhase(claimV1alpha1.ClusterUpdateClaimPhaseError)
+		cuc.Status.SetTypedReason(claimV1alpha1.ClusterUpdateClaimReasonUnsupportedUpgrade)
+		return ctrl.Result{}, nil
+	}
+
 	log.Info(fmt.Sprintf("Found clustermanager [%s]. Start clusterupdateclaim reconcile phase", cuc.Spec.ClusterName))
 
 	r.SetupClaim(cuc, clm)
 
-	if cuc.IsPhaseError() || cuc.IsPhaseAwaiting() {
+	if cuc.IsPhaseError() {
+		return ctrl.Result{}, nil
+	}
+
+	// 관리자가 승인 전에 확인할 수 있도록 claim 이 생성되거나 변경될 때 preflight check 결과를 기록한다.
+	if cuc.IsPhaseAwaiting() {
+		if cuc.Status.Preflight == nil || cuc.Status.Preflight.ObservedGeneration != cuc.Generation {
+			cuc.Status.Preflight = r.RunPreflightChecks(ctx, clm, cuc)
+		}
 		return ctrl.Result{}, nil
 	}
 
@@ -128,7 +147,16 @@ func (r *ClusterUpdateClaimReconciler) reconcile(ctx context.Context, cuc *claim
 			return ctrl.Result{}, nil
 		}
 
-		if err := r.UpdateNodeNum(clm, cuc); err != nil {
+		// 승인을 기다리는 동안 cluster 의 상태가 바뀌었을 수 있으므로 다시 확인하고, 실패하면 update 하지 않는다.
+		cuc.Status.Preflight = r.RunPreflightChecks(ctx, clm, cuc)
+		if !cuc.Status.Preflight.Passed {
+			log.Info("Preflight check failed. Cluster is not updated")
+			cuc.Status.SetTypedPhase(claimV1alpha1.ClusterUpdateClaimPhaseError)
+			cuc.Status.SetTypedReason(claimV1alpha1.ClusterUpdateClaimReasonPreflightFailed)
+			return ctrl.Result{}, nil
+		}
+
+		if err := r.UpdateClusterManager(clm, cuc); err != nil {
 			log.Error(err, "Failed to approve")
 			cuc.Status.SetTypedPhase(claimV1alpha1.ClusterUpdateClaimPhaseError)
 			cuc.Status.SetTypedReason(claimV1alpha1.ClusterUpdateClaimReason(err.Error()))
@@ -148,8 +176,13 @@ func (r *ClusterUpdateClaimReconciler) reconcile(ctx context.Context, cuc *claim
 }
 
 func (r *ClusterUpdateClaimReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	if r.PreflightChecks == nil {
+		r.PreflightChecks = DefaultPreflightChecks()
+	}
+
 	controller, err := ctrl.NewControllerManagedBy(mgr).
 		For(&claimV1alpha1.ClusterUpdateClaim{}).
+		WithOptions(util.DefaultControllerOptions()).
 		WithEventFilter(
 			predicate.Funcs{
 				CreateFunc: func(e event.CreateEvent) bool {
@@ -167,7 +200,8 @@ func (r *ClusterUpdateClaimReconciler) SetupWithManager(mgr ctrl.Manager) error
 						return false
 					}
 
-					return true
+					// 승인은 status.phase 를 변경하는 방식이므로 phase 변경은 통과시킨다.
+					return util.IsSpecChanged(oc, nc) || oc.Status.Phase != nc.Status.Phase
 				},
 				DeleteFunc: func(e event.DeleteEvent) bool {
 					return false
@@ -177,7 +211,7 @@ func (r *ClusterUpdateClaimReconciler) SetupWithManager(mgr ctrl.Manager) error
 				},
 			},
 		).
-		Build(r)
+		Build(util.ShardReconciler(mgr.GetClient(), r))
 
 	if err != nil {
 		return err
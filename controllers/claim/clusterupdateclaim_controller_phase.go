@@ -6,6 +6,7 @@ import (
 
 	claimV1alpha1 "github.com/tmax-cloud/hypercloud-multi-operator/apis/claim/v1alpha1"
 	clusterV1alpha1 "github.com/tmax-cloud/hypercloud-multi-operator/apis/cluster/v1alpha1"
+	"github.com/tmax-cloud/hypercloud-multi-operator/controllers/util"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	// "k8s.io/apimachinery/pkg/api/errors"
@@ -28,8 +29,8 @@ func (r *ClusterUpdateClaimReconciler) CheckValidClaim(clm *clusterV1alpha1.Clus
 	return nil
 }
 
-// 노드를 스케일링할 때 사용하는 메소드
-func (r *ClusterUpdateClaimReconciler) UpdateNodeNum(clm *clusterV1alpha1.ClusterManager, cuc *claimV1alpha1.ClusterUpdateClaim) error {
+// 노드를 스케일링하거나 version 을 upgrade 할 때 사용하는 메소드
+func (r *ClusterUpdateClaimReconciler) UpdateClusterManager(clm *clusterV1alpha1.ClusterManager, cuc *claimV1alpha1.ClusterUpdateClaim) error {
 
 	realMasterNum := clm.Spec.MasterNum
 	realWorkerNum := clm.Spec.WorkerNum
@@ -42,6 +43,10 @@ func (r *ClusterUpdateClaimReconciler) UpdateNodeNum(clm *clusterV1alpha1.Cluste
 		clm.Spec.WorkerNum = cuc.Spec.UpdatedWorkerNum
 	}
 
+	if cuc.Spec.UpdatedVersion != "" && clm.GetK8SVersion() != cuc.Spec.UpdatedVersion {
+		clm.SetK8SVersion(cuc.Spec.UpdatedVersion)
+	}
+
 	if err := r.Update(context.TODO(), clm); err != nil {
 		return err
 	}
@@ -53,7 +58,7 @@ func (r *ClusterUpdateClaimReconciler) RequeueClusterUpdateClaimsForClusterManag
 	clm := o.DeepCopyObject().(*clusterV1alpha1.ClusterManager)
 	cucs := &claimV1alpha1.ClusterUpdateClaimList{}
 	opts := []client.ListOption{client.InNamespace(clm.Namespace),
-		client.MatchingLabels{LabelKeyClmName: clm.Name},
+		client.MatchingFields{util.IndexKeyClusterName: clm.Name},
 	}
 	reqs := []ctrl.Request{}
 
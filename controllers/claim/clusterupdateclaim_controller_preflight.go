@@ -0,0 +1,373 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	claimV1alpha1 "github.com/tmax-cloud/hypercloud-multi-operator/apis/claim/v1alpha1"
+	clusterV1alpha1 "github.com/tmax-cloud/hypercloud-multi-operator/apis/cluster/v1alpha1"
+	"github.com/tmax-cloud/hypercloud-multi-operator/controllers/util"
+
+	appsV1 "k8s.io/api/apps/v1"
+	coreV1 "k8s.io/api/core/v1"
+	policyV1 "k8s.io/api/policy/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/version"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// 결과 message 에 나열하는 object 의 최대 수
+	preflightMessageMaxItems = 5
+)
+
+// cert-manager 의 minor version 별로 지원하는 kubernetes 의 최대 minor version
+// 목록에 없는 version 은 호환 여부를 알 수 없으므로 Warning 으로 처리한다.
+var certManagerMaxKubernetesVersions = map[string]string{
+	"1.8":  "1.24",
+	"1.9":  "1.24",
+	"1.10": "1.26",
+	"1.11": "1.27",
+}
+
+// PreflightTarget 은 preflight check 가 확인하는 claim 과 cluster 이다.
+type PreflightTarget struct {
+	Claim          *claimV1alpha1.ClusterUpdateClaim
+	ClusterManager *clusterV1alpha1.ClusterManager
+	// member cluster 의 kubeconfig secret
+	KubeconfigSecret *coreV1.Secret
+}
+
+func (t *PreflightTarget) isUpgrade() bool {
+	return t.Claim.Spec.UpdatedVersion != "" && t.Claim.Spec.UpdatedVersion != t.ClusterManager.GetK8SVersion()
+}
+
+// upgrade 하거나 node 를 줄이면 node 를 drain 하게 된다.
+func (t *PreflightTarget) drainsNodes() bool {
+	return t.isUpgrade() ||
+		t.Claim.Spec.UpdatedMasterNum < t.ClusterManager.Spec.MasterNum ||
+		t.Claim.Spec.UpdatedWorkerNum < t.ClusterManager.Spec.WorkerNum
+}
+
+// PreflightCheck 는 claim 을 실행하기 전에 확인하는 항목이다.
+// Run 이 error 를 반환하면 확인할 수 없으므로 Failed 로 처리한다.
+type PreflightCheck struct {
+	Name string
+	Run  func(ctx context.Context, target *PreflightTarget) (claimV1alpha1.PreflightResult, string, error)
+}
+
+// ClusterUpdateClaimReconciler 의 PreflightChecks 가 설정되지 않은 경우 사용하는 check 목록
+func DefaultPreflightChecks() []PreflightCheck {
+	return []PreflightCheck{
+		{Name: "VersionSkew", Run: checkVersionSkew},
+		{Name: "DeprecatedAPIs", Run: checkDeprecatedAPIs},
+		{Name: "PodDisruptionBudgets", Run: checkPodDisruptionBudgets},
+		{Name: "FreeCapacity", Run: checkFreeCapacity},
+		{Name: "AddonCompatibility", Run: checkAddonCompatibility},
+	}
+}
+
+// 모든 check 를 실행하여 report 를 만든다. 하나라도 Failed 이면 report 는 통과하지 못한다.
+func (r *ClusterUpdateClaimReconciler) RunPreflightChecks(ctx context.Context, clm *clusterV1alpha1.ClusterManager, cuc *claimV1alpha1.ClusterUpdateClaim) *claimV1alpha1.PreflightReport {
+	log := r.Log.WithValues("ClusterUpdateClaim", cuc.GetNamespacedName())
+
+	target := &PreflightTarget{
+		Claim:          cuc,
+		ClusterManager: clm,
+	}
+	secret, err := util.GetKubeconfigSecret(ctx, r.Client, clm.Namespace, clm.Name)
+	if err != nil {
+		log.Error(err, "Failed to get kubeconfig secret")
+	}
+	target.KubeconfigSecret = secret
+
+	report := &claimV1alpha1.PreflightReport{
+		Passed:             true,
+		ObservedGeneration: cuc.Generation,
+		CheckedTime:        metav1.Now(),
+	}
+	for _, check := range r.PreflightChecks {
+		result, message, err := check.Run(ctx, target)
+		if err != nil {
+			log.Error(err, "Failed to run preflight check", "check", check.Name)
+			result = claimV1alpha1.PreflightResultFailed
+			message = err.Error()
+		}
+		if result == claimV1alpha1.PreflightResultFailed {
+			report.Passed = false
+		}
+		report.Checks = append(report.Checks, claimV1alpha1.PreflightCheckResult{
+			Name:    check.Name,
+			Result:  result,
+			Message: message,
+		})
+	}
+	log.Info("Run preflight checks", "passed", report.Passed)
+	return report
+}
+
+func getRemoteClient(target *PreflightTarget) (client.Client, error) {
+	if target.KubeconfigSecret == nil {
+		return nil, fmt.Errorf("kubeconfig secret of cluster %s not found", target.ClusterManager.Name)
+	}
+	return util.GetRemoteK8sRuntimeClient(target.KubeconfigSecret)
+}
+
+// kubeadm 은 minor version 을 하나씩만 올릴 수 있고 downgrade 를 지원하지 않는다.
+func checkVersionSkew(ctx context.Context, target *PreflightTarget) (claimV1alpha1.PreflightResult, string, error) {
+	if !target.isUpgrade() {
+		return claimV1alpha1.PreflightResultSkipped, "", nil
+	}
+	current, err := version.ParseGeneric(target.ClusterManager.GetK8SVersion())
+	if err != nil {
+		return "", "", err
+	}
+	updated, err := version.ParseGeneric(target.Claim.Spec.UpdatedVersion)
+	if err != nil {
+		return claimV1alpha1.PreflightResultFailed, fmt.Sprintf("invalid version %s", target.Claim.Spec.UpdatedVersion), nil
+	}
+
+	if !current.LessThan(updated) {
+		return claimV1alpha1.PreflightResultFailed, fmt.Sprintf("cannot downgrade from %s to %s", current, updated), nil
+	}
+	if updated.Major() != current.Major() || updated.Minor() > current.Minor()+1 {
+		return claimV1alpha1.PreflightResultFailed, fmt.Sprintf("cannot skip minor versions from %s to %s", current, updated), nil
+	}
+	return claimV1alpha1.PreflightResultPassed, "", nil
+}
+
+// upgrade 할 version 에서 제거되는 api 를 사용하고 있는지 확인한다.
+// api server 의 요청 기록은 직접 확인하고, manifest 는 주기적으로 scan 한 cluster 의 status 를 사용한다.
+func checkDeprecatedAPIs(ctx context.Context, target *PreflightTarget) (claimV1alpha1.PreflightResult, string, error) {
+	if !target.isUpgrade() {
+		return claimV1alpha1.PreflightResultSkipped, "", nil
+	}
+	updated, err := version.ParseGeneric(target.Claim.Spec.UpdatedVersion)
+	if err != nil {
+		return claimV1alpha1.PreflightResultSkipped, "invalid version", nil
+	}
+	if target.KubeconfigSecret == nil {
+		return "", "", fmt.Errorf("kubeconfig secret of cluster %s not found", target.ClusterManager.Name)
+	}
+	remoteClientset, err := util.GetRemoteK8sClient(target.KubeconfigSecret)
+	if err != nil {
+		return "", "", err
+	}
+
+	findings, err := util.GetRequestedDeprecatedAPIs(ctx, remoteClientset, updated)
+	if err != nil {
+		return "", "", err
+	}
+	if scan := target.ClusterManager.Status.DeprecatedAPIs; scan != nil {
+		for _, finding := range scan.Findings {
+			release, err := version.ParseGeneric(finding.RemovedRelease)
+			if err != nil || !updated.AtLeast(release) || finding.Source != util.DeprecatedAPISourceManifest {
+				continue
+			}
+			findings = append(findings, finding)
+		}
+	}
+
+	removed := []string{}
+	for _, finding := range findings {
+		gvr := strings.Trim(strings.Join([]string{finding.Resource, finding.Version, finding.Group}, "."), ".")
+		removed = append(removed, fmt.Sprintf("%s (removed in %s, %s)", gvr, finding.RemovedRelease, finding.Source))
+	}
+	if len(removed) != 0 {
+		return claimV1alpha1.PreflightResultFailed, "removed apis are used: " + joinItems(removed), nil
+	}
+	return claimV1alpha1.PreflightResultPassed, "", nil
+}
+
+// 허용된 disruption 이 없는 pdb 가 있으면 node 의 drain 이 끝나지 않는다.
+func checkPodDisruptionBudgets(ctx context.Context, target *PreflightTarget) (claimV1alpha1.PreflightResult, string, error) {
+	if !target.drainsNodes() {
+		return claimV1alpha1.PreflightResultSkipped, "", nil
+	}
+	remoteClient, err := getRemoteClient(target)
+	if err != nil {
+		return "", "", err
+	}
+
+	blocking := []string{}
+	pdbs := &policyV1.PodDisruptionBudgetList{}
+	err = util.ListPages(ctx, remoteClient, pdbs, func() (bool, error) {
+		for _, pdb := range pdbs.Items {
+			if pdb.Status.ExpectedPods > 0 && pdb.Status.DisruptionsAllowed == 0 {
+				blocking = append(blocking, pdb.Namespace+"/"+pdb.Name)
+			}
+		}
+		return true, nil
+	})
+	if err != nil {
+		return "", "", err
+	}
+
+	if len(blocking) != 0 {
+		return claimV1alpha1.PreflightResultFailed, "pod disruption budgets allow no disruption: " + joinItems(blocking), nil
+	}
+	return claimV1alpha1.PreflightResultPassed, "", nil
+}
+
+type nodeCapacity struct {
+	name        string
+	allocatable coreV1.ResourceList
+	// daemonset pod 는 node 와 함께 사라지므로 다른 node 로 옮겨지지 않는다.
+	daemonSetRequests coreV1.ResourceList
+}
+
+// drain 하는 worker 의 pod 가 남은 worker 에 scheduling 될 수 있는지 확인한다.
+// upgrade 는 한 번에 하나의 worker 를 drain 하고, scale in 은 줄어드는 수만큼 drain 한다.
+// 어떤 worker 가 삭제될지 알 수 없으므로 allocatable 이 큰 worker 부터 삭제된다고 가정한다.
+func checkFreeCapacity(ctx context.Context, target *PreflightTarget) (claimV1alpha1.PreflightResult, string, error) {
+	drained := target.ClusterManager.Spec.WorkerNum - target.Claim.Spec.UpdatedWorkerNum
+	if target.isUpgrade() && drained < 1 {
+		drained = 1
+	}
+	if drained < 1 {
+		return claimV1alpha1.PreflightResultSkipped, "", nil
+	}
+	remoteClient, err := getRemoteClient(target)
+	if err != nil {
+		return "", "", err
+	}
+
+	workers := map[string]*nodeCapacity{}
+	nodes := &coreV1.NodeList{}
+	err = util.ListPages(ctx, remoteClient, nodes, func() (bool, error) {
+		for i := range nodes.Items {
+			node := &nodes.Items[i]
+			if util.IsControlPlaneNode(node) || !util.IsNodeReady(node) || node.Spec.Unschedulable {
+				continue
+			}
+			workers[node.Name] = &nodeCapacity{
+				name:              node.Name,
+				allocatable:       node.Status.Allocatable,
+				daemonSetRequests: coreV1.ResourceList{},
+			}
+		}
+		return true, nil
+	})
+	if err != nil {
+		return "", "", err
+	}
+
+	required := coreV1.ResourceList{}
+	pods := &coreV1.PodList{}
+	selector := fields.ParseSelectorOrDie("status.phase!=" + string(coreV1.PodSucceeded) + ",status.phase!=" + string(coreV1.PodFailed))
+	err = util.ListPages(ctx, remoteClient, pods, func() (bool, error) {
+		for i := range pods.Items {
+			pod := &pods.Items[i]
+			worker, ok := workers[pod.Spec.NodeName]
+			if !ok {
+				continue
+			}
+			if util.IsDaemonSetPod(pod) {
+				util.AddResourceList(worker.daemonSetRequests, util.PodRequests(pod))
+			} else {
+				util.AddResourceList(required, util.PodRequests(pod))
+			}
+		}
+		return true, nil
+	}, client.MatchingFieldsSelector{Selector: selector})
+	if err != nil {
+		return "", "", err
+	}
+
+	sorted := make([]*nodeCapacity, 0, len(workers))
+	for _, worker := range workers {
+		sorted = append(sorted, worker)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].allocatable.Memory().Cmp(*sorted[j].allocatable.Memory()) > 0
+	})
+	if drained >= len(sorted) {
+		return claimV1alpha1.PreflightResultFailed, fmt.Sprintf("no ready worker remains after draining %d of %d workers", drained, len(sorted)), nil
+	}
+
+	available := coreV1.ResourceList{}
+	for _, worker := range sorted[drained:] {
+		util.AddResourceList(available, worker.allocatable)
+		util.SubtractResourceList(available, worker.daemonSetRequests)
+	}
+	for _, name := range []coreV1.ResourceName{coreV1.ResourceCPU, coreV1.ResourceMemory} {
+		need := required[name]
+		free := available[name]
+		if need.Cmp(free) > 0 {
+			return claimV1alpha1.PreflightResultFailed,
+				fmt.Sprintf("%s requests %s exceed allocatable %s of the remaining %d workers", name, need.String(), free.String(), len(sorted)-drained), nil
+		}
+	}
+	return claimV1alpha1.PreflightResultPassed, "", nil
+}
+
+// 설치된 addon 이 upgrade 할 version 을 지원하는지 확인한다.
+func checkAddonCompatibility(ctx context.Context, target *PreflightTarget) (claimV1alpha1.PreflightResult, string, error) {
+	if !target.isUpgrade() || target.ClusterManager.GetCertManagerAddon() == nil {
+		return claimV1alpha1.PreflightResultSkipped, "", nil
+	}
+	updated, err := version.ParseGeneric(target.Claim.Spec.UpdatedVersion)
+	if err != nil {
+		return claimV1alpha1.PreflightResultSkipped, "invalid version", nil
+	}
+	remoteClient, err := getRemoteClient(target)
+	if err != nil {
+		return "", "", err
+	}
+
+	deployment := &appsV1.Deployment{}
+	key := types.NamespacedName{Name: "cert-manager", Namespace: util.CertManagerNamespace}
+	if err := remoteClient.Get(ctx, key, deployment); errors.IsNotFound(err) {
+		return claimV1alpha1.PreflightResultPassed, "cert-manager is not installed yet", nil
+	} else if err != nil {
+		return "", "", err
+	}
+
+	installed := ""
+	for _, container := range deployment.Spec.Template.Spec.Containers {
+		if i := strings.LastIndex(container.Image, ":"); i != -1 {
+			installed = container.Image[i+1:]
+			break
+		}
+	}
+	installedVersion, err := version.ParseGeneric(installed)
+	if err != nil {
+		return claimV1alpha1.PreflightResultWarning, fmt.Sprintf("cannot find the version of cert-manager from the image tag %q", installed), nil
+	}
+	maxSupported, ok := certManagerMaxKubernetesVersions[fmt.Sprintf("%d.%d", installedVersion.Major(), installedVersion.Minor())]
+	if !ok {
+		return claimV1alpha1.PreflightResultWarning, fmt.Sprintf("unknown compatibility of cert-manager %s", installed), nil
+	}
+	maxVersion := version.MustParseGeneric(maxSupported)
+	if updated.Major() > maxVersion.Major() || (updated.Major() == maxVersion.Major() && updated.Minor() > maxVersion.Minor()) {
+		return claimV1alpha1.PreflightResultFailed, fmt.Sprintf("cert-manager %s supports kubernetes up to %s", installed, maxSupported), nil
+	}
+	return claimV1alpha1.PreflightResultPassed, "", nil
+}
+
+func joinItems(items []string) string {
+	if len(items) > preflightMessageMaxItems {
+		return strings.Join(items[:preflightMessageMaxItems], ", ") + fmt.Sprintf(" and %d more", len(items)-preflightMessageMaxItems)
+	}
+	return strings.Join(items, ", ")
+}
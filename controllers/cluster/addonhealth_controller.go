@@ -0,0 +1,312 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-logr/logr"
+	clusterV1alpha1 "github.com/tmax-cloud/hypercloud-multi-operator/apis/cluster/v1alpha1"
+	"github.com/tmax-cloud/hypercloud-multi-operator/controllers/metrics"
+	util "github.com/tmax-cloud/hypercloud-multi-operator/controllers/util"
+
+	coreV1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/record"
+
+	"sigs.k8s.io/cluster-api/util/patch"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+)
+
+const (
+	addonHealthCheckInterval = 5 * time.Minute
+
+	reasonAddonUnhealthy = "AddonUnhealthy"
+	reasonAddonHealthy   = "AddonHealthy"
+)
+
+// addon 의 health 를 확인하는 방법
+type addonProbe struct {
+	name          string
+	conditionType string
+	// addon 이 remote cluster 에 설치되어 있어야 하는지 여부
+	expected func(clm *clusterV1alpha1.ClusterManager) bool
+	// 정상이면 condition 의 message 를 반환한다.
+	check func(ctx context.Context, c kubernetes.Interface) (string, error)
+}
+
+// argocd application 의 gateway bootstrap 으로 설치되는 addon 은 ArgoReady 이후에 확인한다.
+var addonProbes = []addonProbe{
+	{
+		name:          "argo",
+		conditionType: clusterV1alpha1.ClusterManagerConditionArgoAddonHealthy,
+		expected:      func(clm *clusterV1alpha1.ClusterManager) bool { return clm.Status.ArgoReady },
+		check:         checkArgoAddon,
+	},
+	{
+		name:          "ingress",
+		conditionType: clusterV1alpha1.ClusterManagerConditionIngressAddonHealthy,
+		expected:      func(clm *clusterV1alpha1.ClusterManager) bool { return clm.Status.ArgoReady },
+		check:         checkIngressAddon,
+	},
+	{
+		name:          "cni",
+		conditionType: clusterV1alpha1.ClusterManagerConditionCNIAddonHealthy,
+		expected:      func(clm *clusterV1alpha1.ClusterManager) bool { return true },
+		check:         checkCNIAddon,
+	},
+	{
+		name:          "monitoring",
+		conditionType: clusterV1alpha1.ClusterManagerConditionMonitoringAddonHealthy,
+		expected:      func(clm *clusterV1alpha1.ClusterManager) bool { return clm.Status.ArgoReady },
+		check:         checkMonitoringAddon,
+	},
+}
+
+// AddonHealthReconciler periodically probes the health of the addons installed in the remote cluster of each ClusterManager
+// (workloads ready, CRDs registered) and exposes the results as the AddonHealthy conditions,
+// so that an addon broken after the installation is surfaced on the ClusterManager.
+type AddonHealthReconciler struct {
+	client.Client
+	Log      logr.Logger
+	Scheme   *runtime.Scheme
+	Recorder record.EventRecorder
+}
+
+// +kubebuilder:rbac:groups=cluster.tmax.io,resources=clustermanagers/status,verbs=get;patch;update
+
+func (r *AddonHealthReconciler) Reconcile(ctx context.Context, req ctrl.Request) (_ ctrl.Result, reterr error) {
+	_ = context.Background()
+	log := r.Log.WithValues("clustermanager", req.NamespacedName)
+
+	clm := &clusterV1alpha1.ClusterManager{}
+	if err := r.Client.Get(context.TODO(), req.NamespacedName, clm); errors.IsNotFound(err) {
+		deleteAddonHealthMetrics(req.Namespace, req.Name)
+		return ctrl.Result{}, nil
+	} else if err != nil {
+		log.Error(err, "Failed to get ClusterManager")
+		return ctrl.Result{}, err
+	}
+
+	if !clm.DeletionTimestamp.IsZero() {
+		deleteAddonHealthMetrics(clm.Namespace, clm.Name)
+		return ctrl.Result{}, nil
+	}
+	if !clm.Status.ControlPlaneReady {
+		return ctrl.Result{}, nil
+	}
+
+	patchHelper, err := patch.NewHelper(clm, r.Client)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	defer func() {
+		if err := patchHelper.Patch(context.TODO(), clm); err != nil {
+			reterr = err
+		}
+	}()
+
+	kubeconfigSecret, err := util.GetKubeconfigSecret(ctx, r.Client, clm.Namespace, clm.Name)
+	if err != nil {
+		log.Info("Failed to get kubeconfig secret", "reason", err.Error())
+		return util.RequeueAfterWithJitter(addonHealthCheckInterval), nil
+	}
+	remoteClientset, err := util.GetRemoteK8sClient(kubeconfigSecret)
+	if err != nil {
+		log.Info("Failed to get remoteK8sClient", "reason", err.Error())
+		return util.RequeueAfterWithJitter(addonHealthCheckInterval), nil
+	}
+	// api server 에 접근할 수 없는 경우는 Unreachable condition 으로 표시되므로 이전 결과를 유지한다.
+	if _, err := remoteClientset.Discovery().ServerVersion(); err != nil {
+		log.Info("Failed to probe addons", "reason", util.ClassifyRemoteError(err).Error())
+		return util.RequeueAfterWithJitter(addonHealthCheckInterval), nil
+	}
+
+	for _, probe := range addonProbes {
+		if !probe.expected(clm) {
+			meta.RemoveStatusCondition(&clm.Status.Conditions, probe.conditionType)
+			metrics.ClusterAddonHealthy.DeleteLabelValues(clm.Namespace, clm.Name, probe.name)
+			continue
+		}
+
+		previous := meta.FindStatusCondition(clm.Status.Conditions, probe.conditionType)
+		wasHealthy := previous != nil && previous.Status == metav1.ConditionTrue
+		condition := getAddonHealthCondition(ctx, probe, remoteClientset)
+		condition.ObservedGeneration = clm.Generation
+		meta.SetStatusCondition(&clm.Status.Conditions, condition)
+
+		healthy := condition.Status == metav1.ConditionTrue
+		if healthy {
+			metrics.ClusterAddonHealthy.WithLabelValues(clm.Namespace, clm.Name, probe.name).Set(1)
+		} else {
+			metrics.ClusterAddonHealthy.WithLabelValues(clm.Namespace, clm.Name, probe.name).Set(0)
+		}
+
+		// 처음 확인한 경우가 아니라 상태가 바뀐 경우에만 알린다.
+		if r.Recorder == nil || previous == nil || healthy == wasHealthy {
+			continue
+		}
+		if healthy {
+			r.Recorder.Event(clm, coreV1.EventTypeNormal, reasonAddonHealthy,
+				fmt.Sprintf("%s addon is healthy: %s", probe.name, condition.Message))
+		} else {
+			r.Recorder.Event(clm, coreV1.EventTypeWarning, reasonAddonUnhealthy,
+				fmt.Sprintf("%s addon is unhealthy: %s", probe.name, condition.Message))
+		}
+	}
+
+	return util.RequeueAfterWithJitter(addonHealthCheckInterval), nil
+}
+
+func getAddonHealthCondition(ctx context.Context, probe addonProbe, c kubernetes.Interface) metav1.Condition {
+	condition := metav1.Condition{
+		Type:   probe.conditionType,
+		Status: metav1.ConditionFalse,
+	}
+	message, err := probe.check(ctx, c)
+	switch {
+	case err == nil:
+		condition.Status = metav1.ConditionTrue
+		condition.Reason = "Healthy"
+		condition.Message = message
+	case errors.IsNotFound(err):
+		condition.Reason = "NotInstalled"
+		condition.Message = err.Error()
+	case util.IsAddonNotReady(err):
+		condition.Reason = "NotReady"
+		condition.Message = err.Error()
+	default:
+		condition.Reason = "ProbeFailed"
+		condition.Message = util.ClassifyRemoteError(err).Error()
+	}
+	return condition
+}
+
+// argocd 가 cluster 에 접근할 때 사용하는 service account 와 token 이 있는지 확인한다.
+func checkArgoAddon(ctx context.Context, c kubernetes.Interface) (string, error) {
+	if _, err := c.CoreV1().ServiceAccounts(util.KubeNamespace).Get(ctx, util.ArgoServiceAccount, metav1.GetOptions{}); err != nil {
+		return "", err
+	}
+	secret, err := c.CoreV1().Secrets(util.KubeNamespace).Get(ctx, util.ArgoServiceAccountTokenSecret, metav1.GetOptions{})
+	if err != nil {
+		return "", err
+	}
+	if len(secret.Data["token"]) == 0 {
+		return "", &util.AddonNotReadyError{Message: "token of service account " + util.ArgoServiceAccount + " is not issued"}
+	}
+	return "service account " + util.ArgoServiceAccount + " has a token", nil
+}
+
+// ingress-nginx controller 가 준비되어 있고 IngressClass 가 등록되어 있는지 확인한다.
+func checkIngressAddon(ctx context.Context, c kubernetes.Interface) (string, error) {
+	if err := util.CheckRemoteDeploymentReady(ctx, c, util.IngressNginxNamespace, util.IngressNginxName); err != nil {
+		return "", err
+	}
+	ingressClassList, err := c.NetworkingV1().IngressClasses().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return "", err
+	}
+	for _, ingressClass := range ingressClassList.Items {
+		if ingressClass.Spec.Controller == util.IngressNginxController {
+			return "ingress class " + ingressClass.Name + " is served by " + util.IngressNginxName, nil
+		}
+	}
+	return "", errors.NewNotFound(schema.GroupResource{Group: "networking.k8s.io", Resource: "ingressclasses"}, util.IngressNginxController)
+}
+
+// 지원하는 CNI 중 설치된 CNI 의 daemonset 과 CRD 를 확인한다.
+func checkCNIAddon(ctx context.Context, c kubernetes.Interface) (string, error) {
+	cnis := []struct {
+		name         string
+		groupVersion string
+		resources    []string
+	}{
+		{util.CalicoNodeName, util.CalicoGroupVersion, []string{"ippools"}},
+		{util.CiliumName, util.CiliumGroupVersion, []string{"ciliumnetworkpolicies"}},
+	}
+	for _, cni := range cnis {
+		err := util.CheckRemoteDaemonSetReady(ctx, c, util.KubeNamespace, cni.name)
+		if errors.IsNotFound(err) {
+			continue
+		} else if err != nil {
+			return "", err
+		}
+		if err := util.CheckRemoteAPIResources(c, cni.groupVersion, cni.resources...); err != nil {
+			return "", err
+		}
+		return cni.name + " is ready on all nodes", nil
+	}
+	return "", errors.NewNotFound(schema.GroupResource{Group: "apps", Resource: "daemonsets"}, util.CalicoNodeName+" or "+util.CiliumName)
+}
+
+// prometheus operator 와 prometheus 가 준비되어 있고 monitoring CRD 가 등록되어 있는지 확인한다.
+func checkMonitoringAddon(ctx context.Context, c kubernetes.Interface) (string, error) {
+	if err := util.CheckRemoteAPIResources(c, util.MonitoringGroupVersion, "prometheuses", "servicemonitors"); err != nil {
+		return "", err
+	}
+	if err := util.CheckRemoteDeploymentReady(ctx, c, util.MonitoringNamespace, util.PrometheusOperatorName); err != nil {
+		return "", err
+	}
+	if err := util.CheckRemoteStatefulSetReady(ctx, c, util.MonitoringNamespace, util.PrometheusName); err != nil {
+		return "", err
+	}
+	return util.PrometheusName + " is ready", nil
+}
+
+func deleteAddonHealthMetrics(namespace, name string) {
+	for _, probe := range addonProbes {
+		metrics.ClusterAddonHealthy.DeleteLabelValues(namespace, name, probe.name)
+	}
+}
+
+func (r *AddonHealthReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		Named("addonhealth").
+		For(&clusterV1alpha1.ClusterManager{}).
+		WithOptions(util.DefaultControllerOptions()).
+		WithEventFilter(
+			predicate.Funcs{
+				CreateFunc: func(e event.CreateEvent) bool {
+					return true
+				},
+				UpdateFunc: func(e event.UpdateEvent) bool {
+					oldClm := e.ObjectOld.(*clusterV1alpha1.ClusterManager)
+					newClm := e.ObjectNew.(*clusterV1alpha1.ClusterManager)
+					// 확인할 addon 이 바뀌는 경우에만 바로 확인하고, 이후에는 주기적으로 requeue 된다.
+					isDeleted := oldClm.DeletionTimestamp.IsZero() && !newClm.DeletionTimestamp.IsZero()
+					isReady := !oldClm.Status.ControlPlaneReady && newClm.Status.ControlPlaneReady
+					isArgoReady := oldClm.Status.ArgoReady != newClm.Status.ArgoReady
+					return isDeleted || isReady || isArgoReady
+				},
+				DeleteFunc: func(e event.DeleteEvent) bool {
+					return true
+				},
+				GenericFunc: func(e event.GenericEvent) bool {
+					return false
+				},
+			},
+		).
+		Complete(util.ShardReconciler(mgr.GetClient(), r))
+}
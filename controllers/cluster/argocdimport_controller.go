@@ -0,0 +1,302 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	b64 "encoding/base64"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"net/url"
+	"regexp"
+	"strings"
+
+	argocdV1alpha1 "github.com/argoproj/argo-cd/v2/pkg/apis/application/v1alpha1"
+	"github.com/go-logr/logr"
+	clusterV1alpha1 "github.com/tmax-cloud/hypercloud-multi-operator/apis/cluster/v1alpha1"
+	util "github.com/tmax-cloud/hypercloud-multi-operator/controllers/util"
+
+	coreV1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+	"k8s.io/client-go/tools/record"
+
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+)
+
+const (
+	// argocd 가 자기 자신의 cluster 를 가리킬 때 사용하는 주소
+	argocdInClusterServer = "https://kubernetes.default.svc"
+	// ClusterRegistration 의 webhook 이 허용하는 cluster 이름의 최대 길이
+	importedClusterNameMaxLength = 63 - len("-gateway-service")
+
+	reasonImported      = "Imported"
+	reasonImportSkipped = "ImportSkipped"
+	reasonImportFailed  = "ImportFailed"
+)
+
+var invalidClusterNameChars = regexp.MustCompile(`[^a-z0-9-]+`)
+
+// ArgocdImportReconciler creates a ClusterRegistration for each argocd cluster secret
+// which is registered to argocd directly, so that every cluster in argocd is managed as a ClusterManager.
+type ArgocdImportReconciler struct {
+	client.Client
+	Log      logr.Logger
+	Scheme   *runtime.Scheme
+	Recorder record.EventRecorder
+	// ClusterRegistration 을 생성할 namespace
+	Namespace string
+	// secret 에 owner annotation 이 없을 때 cluster 의 owner 로 기록할 사용자
+	Owner string
+}
+
+// +kubebuilder:rbac:groups="",resources=secrets,verbs=get;list;watch;update;patch
+// +kubebuilder:rbac:groups=cluster.tmax.io,resources=clusterregistrations,verbs=get;list;watch;create
+
+func (r *ArgocdImportReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	_ = context.Background()
+	log := r.Log.WithValues("secret", req.NamespacedName)
+
+	secret := &coreV1.Secret{}
+	if err := r.Client.Get(context.TODO(), req.NamespacedName, secret); errors.IsNotFound(err) {
+		return ctrl.Result{}, nil
+	} else if err != nil {
+		log.Error(err, "Failed to get argocd cluster secret")
+		return ctrl.Result{}, err
+	}
+	if !isUnmanagedArgocdClusterSecret(secret) || !secret.DeletionTimestamp.IsZero() {
+		return ctrl.Result{}, nil
+	}
+
+	server := string(secret.Data["server"])
+	if server == argocdInClusterServer {
+		return ctrl.Result{}, nil
+	}
+	endpoint, err := url.Parse(server)
+	if err != nil || endpoint.Hostname() == "" {
+		r.Recorder.Eventf(secret, coreV1.EventTypeWarning, reasonImportFailed, "Invalid server address %q", server)
+		return ctrl.Result{}, nil
+	}
+
+	clr, err := r.getImportedClusterRegistration(secret)
+	if err != nil {
+		log.Error(err, "Failed to list ClusterRegistrations")
+		return ctrl.Result{}, err
+	}
+	if clr == nil {
+		// 이미 등록된 cluster 는 다시 등록하지 않는다.
+		if clm, err := r.findClusterManagerByEndpoint(endpoint.Hostname()); err != nil {
+			log.Error(err, "Failed to list ClusterManagers")
+			return ctrl.Result{}, err
+		} else if clm != nil {
+			r.Recorder.Eventf(secret, coreV1.EventTypeNormal, reasonImportSkipped,
+				"Cluster is already registered as ClusterManager %s/%s", clm.Namespace, clm.Name)
+			return ctrl.Result{}, nil
+		}
+
+		config := &argocdV1alpha1.ClusterConfig{}
+		if err := json.Unmarshal(secret.Data["config"], config); err != nil {
+			r.Recorder.Eventf(secret, coreV1.EventTypeWarning, reasonImportFailed, "Invalid cluster config: %s", err.Error())
+			return ctrl.Result{}, nil
+		}
+		clusterName, err := r.newImportedClusterName(secret)
+		if err != nil {
+			log.Error(err, "Failed to check cluster name")
+			return ctrl.Result{}, err
+		}
+		kubeconfig, err := newKubeconfigFromArgocdCluster(clusterName, server, config)
+		if err != nil {
+			r.Recorder.Event(secret, coreV1.EventTypeWarning, reasonImportFailed, err.Error())
+			return ctrl.Result{}, nil
+		}
+
+		owner := secret.Annotations[util.AnnotationKeyOwner]
+		if owner == "" {
+			owner = r.Owner
+		}
+		clr = &clusterV1alpha1.ClusterRegistration{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      clusterName,
+				Namespace: r.Namespace,
+				Annotations: map[string]string{
+					util.AnnotationKeyCreator: owner,
+					// kubeconfig secret 이 새로 만들지 않고 기존 argocd cluster secret 을 사용하도록 한다.
+					util.AnnotationKeyArgoClusterSecret: secret.Name,
+				},
+			},
+			Spec: clusterV1alpha1.ClusterRegistrationSpec{
+				ClusterName: clusterName,
+				KubeConfig:  b64.StdEncoding.EncodeToString(kubeconfig),
+			},
+		}
+		if err := r.Client.Create(context.TODO(), clr); err != nil {
+			log.Error(err, "Failed to create ClusterRegistration")
+			return ctrl.Result{}, err
+		}
+		log.Info("Create ClusterRegistration for argocd cluster successfully", "clusterRegistration", clr.Name)
+		r.Recorder.Eventf(secret, coreV1.EventTypeNormal, reasonImported,
+			"ClusterRegistration %s/%s is created", clr.Namespace, clr.Name)
+	}
+
+	// operator 가 생성한 argocd cluster secret 과 같이 cluster manager 가 삭제될 때 함께 삭제되도록 한다.
+	// secret controller 가 kubeconfig 가 없는 secret 을 reconcile 하지 않도록 finalizer 는 달지 않는다.
+	if secret.Labels == nil {
+		secret.Labels = map[string]string{}
+	}
+	secret.Labels[util.LabelKeyClmSecretType] = util.ClmSecretTypeArgo
+	secret.Labels[clusterV1alpha1.LabelKeyClrName] = clr.Name
+	secret.Labels[clusterV1alpha1.LabelKeyClmName] = clr.Spec.ClusterName
+	secret.Labels[clusterV1alpha1.LabelKeyClmNamespace] = clr.Namespace
+	if err := r.Client.Update(context.TODO(), secret); err != nil {
+		log.Error(err, "Failed to update argocd cluster secret")
+		return ctrl.Result{}, err
+	}
+
+	return ctrl.Result{}, nil
+}
+
+// operator 가 생성하지 않은 argocd cluster secret
+func isUnmanagedArgocdClusterSecret(o client.Object) bool {
+	if o.GetNamespace() != util.ArgoNamespace || o.GetLabels()[util.LabelKeyArgoSecretType] != util.ArgoSecretTypeCluster {
+		return false
+	}
+	_, managed := o.GetLabels()[util.LabelKeyClmSecretType]
+	return !managed
+}
+
+// 이전 reconcile 에서 secret 으로 생성한 ClusterRegistration 을 찾는다.
+func (r *ArgocdImportReconciler) getImportedClusterRegistration(secret *coreV1.Secret) (*clusterV1alpha1.ClusterRegistration, error) {
+	clrList := &clusterV1alpha1.ClusterRegistrationList{}
+	if err := r.Client.List(context.TODO(), clrList, client.InNamespace(r.Namespace)); err != nil {
+		return nil, err
+	}
+	for i := range clrList.Items {
+		if clrList.Items[i].Annotations[util.AnnotationKeyArgoClusterSecret] == secret.Name {
+			return &clrList.Items[i], nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *ArgocdImportReconciler) findClusterManagerByEndpoint(host string) (*clusterV1alpha1.ClusterManager, error) {
+	clmList := &clusterV1alpha1.ClusterManagerList{}
+	if err := r.Client.List(context.TODO(), clmList); err != nil {
+		return nil, err
+	}
+	// IPv6 address 는 표기가 여러가지이므로 정규화하여 비교한다.
+	for i := range clmList.Items {
+		if util.EndpointHost(clmList.Items[i].GetAPIServerEndpoint()) == util.EndpointHost(host) {
+			return &clmList.Items[i], nil
+		}
+	}
+	return nil, nil
+}
+
+// argocd 에 등록된 이름을 ClusterRegistration 의 cluster 이름 규칙에 맞게 변환한다.
+// 같은 이름의 cluster 가 이미 있으면 secret 이름의 hash 를 붙인다.
+func (r *ArgocdImportReconciler) newImportedClusterName(secret *coreV1.Secret) (string, error) {
+	name := invalidClusterNameChars.ReplaceAllString(strings.ToLower(string(secret.Data["name"])), "-")
+	name = strings.Trim(name, "-")
+	if name == "" || name[0] < 'a' || name[0] > 'z' {
+		name = strings.TrimSuffix("cluster-"+name, "-")
+	}
+	if len(name) > importedClusterNameMaxLength {
+		name = strings.TrimRight(name[:importedClusterNameMaxLength], "-")
+	}
+
+	key := types.NamespacedName{Name: name, Namespace: r.Namespace}
+	err := r.Client.Get(context.TODO(), key, &clusterV1alpha1.ClusterManager{})
+	if errors.IsNotFound(err) {
+		err = r.Client.Get(context.TODO(), key, &clusterV1alpha1.ClusterRegistration{})
+	}
+	if errors.IsNotFound(err) {
+		return name, nil
+	} else if err != nil {
+		return "", err
+	}
+
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(secret.Name))
+	hash := fmt.Sprintf("%08x", h.Sum32())
+	if len(name) > importedClusterNameMaxLength-len(hash)-1 {
+		name = strings.TrimRight(name[:importedClusterNameMaxLength-len(hash)-1], "-")
+	}
+	return name + "-" + hash, nil
+}
+
+// argocd cluster secret 의 config 로 kubeconfig 를 생성한다.
+// operator 에서 실행할 수 없는 exec plugin 과 aws iam 인증은 지원하지 않는다.
+func newKubeconfigFromArgocdCluster(name, server string, config *argocdV1alpha1.ClusterConfig) ([]byte, error) {
+	if config.ExecProviderConfig != nil || config.AWSAuthConfig != nil {
+		return nil, fmt.Errorf("exec provider and aws auth are not supported, register the cluster with ClusterRegistration instead")
+	}
+	authInfo := &clientcmdapi.AuthInfo{
+		Token:                 config.BearerToken,
+		Username:              config.Username,
+		Password:              config.Password,
+		ClientCertificateData: config.TLSClientConfig.CertData,
+		ClientKeyData:         config.TLSClientConfig.KeyData,
+	}
+	if authInfo.Token == "" && authInfo.Username == "" && len(authInfo.ClientCertificateData) == 0 {
+		return nil, fmt.Errorf("cluster config has no credentials")
+	}
+
+	kubeconfig := clientcmdapi.NewConfig()
+	kubeconfig.Clusters[name] = &clientcmdapi.Cluster{
+		Server:                   server,
+		TLSServerName:            config.TLSClientConfig.ServerName,
+		InsecureSkipTLSVerify:    config.TLSClientConfig.Insecure,
+		CertificateAuthorityData: config.TLSClientConfig.CAData,
+	}
+	kubeconfig.AuthInfos[name] = authInfo
+	kubeconfig.Contexts[name] = &clientcmdapi.Context{
+		Cluster:  name,
+		AuthInfo: name,
+	}
+	kubeconfig.CurrentContext = name
+	return clientcmd.Write(*kubeconfig)
+}
+
+func (r *ArgocdImportReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		Named("argocdimport").
+		For(&coreV1.Secret{}).
+		WithOptions(util.DefaultControllerOptions()).
+		WithEventFilter(
+			predicate.Funcs{
+				CreateFunc: func(e event.CreateEvent) bool {
+					return isUnmanagedArgocdClusterSecret(e.Object)
+				},
+				UpdateFunc: func(e event.UpdateEvent) bool {
+					return isUnmanagedArgocdClusterSecret(e.ObjectNew)
+				},
+				DeleteFunc: func(e event.DeleteEvent) bool {
+					return false
+				},
+				GenericFunc: func(e event.GenericEvent) bool {
+					return false
+				},
+			},
+		).
+		Complete(util.ShardReconciler(mgr.GetClient(), r))
+}
@@ -0,0 +1,342 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"net/url"
+	"sort"
+	"time"
+
+	"github.com/go-logr/logr"
+	clusterV1alpha1 "github.com/tmax-cloud/hypercloud-multi-operator/apis/cluster/v1alpha1"
+	"github.com/tmax-cloud/hypercloud-multi-operator/controllers/metrics"
+	util "github.com/tmax-cloud/hypercloud-multi-operator/controllers/util"
+
+	coreV1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+
+	"sigs.k8s.io/cluster-api/util/patch"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+)
+
+const (
+	// 인증서는 자주 바뀌지 않으므로 긴 주기로 scan 한다.
+	certificateScanInterval = 6 * time.Hour
+
+	reasonCertificatesExpiring = "CertificatesExpiring"
+)
+
+// capi 가 cluster 의 namespace 에 생성하는 CA secret 의 suffix
+var capiCASecretSuffixes = []string{"-ca", "-etcd", "-proxy"}
+
+// CertificateInventoryReconciler periodically collects the expiry of the api server, etcd, CA and ingress certificates
+// of each ClusterManager, and warns about the certificates which expire within CERT_EXPIRY_WARNING_THRESHOLD.
+// FleetStatus aggregates the results into the fleet-wide expiry report.
+type CertificateInventoryReconciler struct {
+	client.Client
+	Log      logr.Logger
+	Scheme   *runtime.Scheme
+	Recorder record.EventRecorder
+}
+
+// +kubebuilder:rbac:groups=cluster.tmax.io,resources=clustermanagers/status,verbs=get;patch;update
+
+func (r *CertificateInventoryReconciler) Reconcile(ctx context.Context, req ctrl.Request) (_ ctrl.Result, reterr error) {
+	_ = context.Background()
+	log := r.Log.WithValues("clustermanager", req.NamespacedName)
+
+	clm := &clusterV1alpha1.ClusterManager{}
+	if err := r.Client.Get(context.TODO(), req.NamespacedName, clm); errors.IsNotFound(err) {
+		metrics.ClusterCertificateExpiry.Delete(req.Namespace, req.Name)
+		return ctrl.Result{}, nil
+	} else if err != nil {
+		log.Error(err, "Failed to get ClusterManager")
+		return ctrl.Result{}, err
+	}
+
+	if !clm.DeletionTimestamp.IsZero() {
+		metrics.ClusterCertificateExpiry.Delete(clm.Namespace, clm.Name)
+		return ctrl.Result{}, nil
+	}
+	if !clm.Status.ControlPlaneReady {
+		return ctrl.Result{}, nil
+	}
+
+	patchHelper, err := patch.NewHelper(clm, r.Client)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	defer func() {
+		if err := patchHelper.Patch(context.TODO(), clm); err != nil {
+			reterr = err
+		}
+	}()
+
+	inventory, err := r.scan(ctx, clm)
+	if err != nil {
+		// 일시적으로 접근할 수 없는 경우 이전 결과를 유지하고 다음 주기에 다시 scan 한다.
+		log.Info("Failed to scan certificates", "reason", err.Error())
+		return util.RequeueAfterWithJitter(certificateScanInterval), nil
+	}
+	clm.Status.Certificates = inventory
+
+	expiries := []metrics.CertificateExpiry{}
+	for _, cert := range inventory.Certificates {
+		expiries = append(expiries, metrics.CertificateExpiry{
+			Type:        string(cert.Type),
+			Certificate: cert.Name,
+			NotAfter:    cert.NotAfter.Time,
+		})
+	}
+	metrics.ClusterCertificateExpiry.Set(clm.Namespace, clm.Name, expiries)
+
+	wasExpiring := meta.IsStatusConditionTrue(clm.Status.Conditions, clusterV1alpha1.ClusterManagerConditionCertificatesExpiring)
+	condition := getCertificatesExpiringCondition(inventory, util.GetCertExpiryWarningThreshold())
+	condition.ObservedGeneration = clm.Generation
+	meta.SetStatusCondition(&clm.Status.Conditions, condition)
+	// 만료 예정 상태가 된 경우에만 알린다.
+	if condition.Status == metav1.ConditionTrue && !wasExpiring {
+		if r.Recorder != nil {
+			r.Recorder.Event(clm, coreV1.EventTypeWarning, reasonCertificatesExpiring, condition.Message)
+		}
+		r.notify(clm, clusterV1alpha1.NotificationEventCertificateExpiring, condition.Message)
+	}
+
+	return util.RequeueAfterWithJitter(certificateScanInterval), nil
+}
+
+// api server 에 접근할 수 없으면 실패하고, 나머지 인증서는 읽지 못한 이유를 errors 에 기록한다.
+func (r *CertificateInventoryReconciler) scan(ctx context.Context, clm *clusterV1alpha1.ClusterManager) (*clusterV1alpha1.CertificateInventory, error) {
+	inventory := &clusterV1alpha1.CertificateInventory{}
+	add := func(certType clusterV1alpha1.CertificateType, name string, cert *x509.Certificate) {
+		inventory.Certificates = append(inventory.Certificates, clusterV1alpha1.ClusterCertificate{
+			Type:     certType,
+			Name:     name,
+			Subject:  cert.Subject.CommonName,
+			NotAfter: metav1.NewTime(cert.NotAfter),
+		})
+	}
+	fail := func(name string, err error) {
+		inventory.Errors = append(inventory.Errors, name+": "+err.Error())
+	}
+
+	kubeconfigSecret, err := util.GetKubeconfigSecret(ctx, r.Client, clm.Namespace, clm.Name)
+	if err != nil {
+		return nil, err
+	}
+	host, _, err := util.GetRemoteK8sTransport(kubeconfigSecret)
+	if err != nil {
+		return nil, err
+	}
+	address, err := getServerAddress(host)
+	if err != nil {
+		return nil, err
+	}
+	cert, err := util.GetServingCertificate(address)
+	if err != nil {
+		return nil, err
+	}
+	add(clusterV1alpha1.CertificateTypeAPIServer, address, cert)
+
+	// capi 로 생성한 cluster 는 management cluster 에 CA 인증서가 있다.
+	if clm.GetClusterType() == clusterV1alpha1.ClusterTypeCreated {
+		for _, suffix := range capiCASecretSuffixes {
+			key := types.NamespacedName{Name: clm.Name + suffix, Namespace: clm.Namespace}
+			secret := &coreV1.Secret{}
+			if err := r.Client.Get(ctx, key, secret); errors.IsNotFound(err) {
+				continue
+			} else if err != nil {
+				fail(key.String(), err)
+				continue
+			}
+			if cert, err := util.ParseCertificatePEM(secret.Data[coreV1.TLSCertKey]); err != nil {
+				fail(key.String(), err)
+			} else {
+				add(clusterV1alpha1.CertificateTypeCA, key.String(), cert)
+			}
+		}
+	}
+
+	remoteClientset, err := util.GetRemoteK8sClient(kubeconfigSecret)
+	if err != nil {
+		return nil, err
+	}
+
+	// managed kubernetes 처럼 control plane node 가 보이지 않는 cluster 는 etcd 인증서를 확인하지 않는다.
+	nodeList, err := remoteClientset.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, util.ClassifyRemoteError(err)
+	}
+	for i := range nodeList.Items {
+		node := &nodeList.Items[i]
+		if !util.IsControlPlaneNode(node) {
+			continue
+		}
+		for _, nodeAddress := range node.Status.Addresses {
+			if nodeAddress.Type != coreV1.NodeInternalIP {
+				continue
+			}
+			etcdAddress := net.JoinHostPort(nodeAddress.Address, util.EtcdClientPort)
+			if cert, err := util.GetServingCertificate(etcdAddress); err != nil {
+				fail(etcdAddress, err)
+			} else {
+				add(clusterV1alpha1.CertificateTypeEtcd, etcdAddress, cert)
+			}
+			break
+		}
+	}
+
+	ingressList, err := remoteClientset.NetworkingV1().Ingresses("").List(ctx, metav1.ListOptions{})
+	if err != nil {
+		fail("ingresses", util.ClassifyRemoteError(err))
+	} else {
+		// 여러 ingress 가 같은 secret 을 사용할 수 있다.
+		seen := map[string]bool{}
+		for _, ingress := range ingressList.Items {
+			for _, tls := range ingress.Spec.TLS {
+				key := types.NamespacedName{Name: tls.SecretName, Namespace: ingress.Namespace}
+				if tls.SecretName == "" || seen[key.String()] {
+					continue
+				}
+				seen[key.String()] = true
+
+				secret, err := remoteClientset.CoreV1().Secrets(key.Namespace).Get(ctx, key.Name, metav1.GetOptions{})
+				if errors.IsNotFound(err) {
+					// cert-manager 등이 아직 인증서를 발급하지 않은 경우
+					continue
+				} else if err != nil {
+					fail(key.String(), util.ClassifyRemoteError(err))
+					continue
+				}
+				if cert, err := util.ParseCertificatePEM(secret.Data[coreV1.TLSCertKey]); err != nil {
+					fail(key.String(), err)
+				} else {
+					add(clusterV1alpha1.CertificateTypeIngress, key.String(), cert)
+				}
+			}
+		}
+	}
+
+	sort.SliceStable(inventory.Certificates, func(i, j int) bool {
+		return inventory.Certificates[i].NotAfter.Before(&inventory.Certificates[j].NotAfter)
+	})
+	if len(inventory.Certificates) > 0 {
+		earliest := inventory.Certificates[0].NotAfter
+		inventory.EarliestExpiry = &earliest
+	}
+	inventory.LastScanTime = &metav1.Time{Time: time.Now()}
+	return inventory, nil
+}
+
+// api server 의 url 에서 tls 연결을 맺을 host:port 를 구한다.
+func getServerAddress(server string) (string, error) {
+	u, err := url.Parse(server)
+	if err != nil {
+		return "", err
+	}
+	if u.Port() != "" {
+		return u.Host, nil
+	}
+	return net.JoinHostPort(u.Hostname(), "443"), nil
+}
+
+// 만료되었거나 threshold 안에 만료되는 인증서가 있으면 True 이다.
+func getCertificatesExpiringCondition(inventory *clusterV1alpha1.CertificateInventory, threshold time.Duration) metav1.Condition {
+	now := time.Now()
+	expired, expiring := 0, 0
+	var earliest *clusterV1alpha1.ClusterCertificate
+	for i := range inventory.Certificates {
+		cert := &inventory.Certificates[i]
+		if cert.NotAfter.Time.Before(now) {
+			expired++
+		} else if cert.NotAfter.Time.Before(now.Add(threshold)) {
+			expiring++
+		} else {
+			continue
+		}
+		if earliest == nil {
+			earliest = cert
+		}
+	}
+
+	condition := metav1.Condition{
+		Type:   clusterV1alpha1.ClusterManagerConditionCertificatesExpiring,
+		Status: metav1.ConditionFalse,
+		Reason: "NotExpiring",
+		Message: fmt.Sprintf("%d certificates do not expire within %s",
+			len(inventory.Certificates), threshold),
+	}
+	if earliest == nil {
+		return condition
+	}
+	condition.Status = metav1.ConditionTrue
+	condition.Reason = "Expiring"
+	if expired > 0 {
+		condition.Reason = "Expired"
+	}
+	condition.Message = fmt.Sprintf("%d certificates expired and %d certificates expire within %s, the earliest is %s %s at %s",
+		expired, expiring, threshold, earliest.Type, earliest.Name, earliest.NotAfter.Format(time.RFC3339))
+	return condition
+}
+
+func (r *CertificateInventoryReconciler) notify(clm *clusterV1alpha1.ClusterManager, event clusterV1alpha1.NotificationEvent, message string) {
+	err := util.Notify(r.Client, util.NewClusterNotification(clm, event, message))
+	if err != nil {
+		r.Log.Error(err, "Failed to send notification for ClusterManager", "event", string(event), "clusterManager", clm.Name)
+	}
+}
+
+func (r *CertificateInventoryReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		Named("certificateinventory").
+		For(&clusterV1alpha1.ClusterManager{}).
+		WithOptions(util.DefaultControllerOptions()).
+		WithEventFilter(
+			predicate.Funcs{
+				CreateFunc: func(e event.CreateEvent) bool {
+					return true
+				},
+				UpdateFunc: func(e event.UpdateEvent) bool {
+					oldClm := e.ObjectOld.(*clusterV1alpha1.ClusterManager)
+					newClm := e.ObjectNew.(*clusterV1alpha1.ClusterManager)
+					// control plane 이 준비되면 바로 scan 하고, 이후에는 주기적으로 requeue 된다.
+					// upgrade 로 control plane 이 교체되면 인증서가 새로 발급되므로 다시 scan 한다.
+					isDeleted := oldClm.DeletionTimestamp.IsZero() && !newClm.DeletionTimestamp.IsZero()
+					isReady := !oldClm.Status.ControlPlaneReady && newClm.Status.ControlPlaneReady
+					isUpgraded := oldClm.Status.GetK8SVersion() != newClm.Status.GetK8SVersion()
+					return isDeleted || isReady || isUpgraded
+				},
+				DeleteFunc: func(e event.DeleteEvent) bool {
+					return true
+				},
+				GenericFunc: func(e event.GenericEvent) bool {
+					return false
+				},
+			},
+		).
+		Complete(util.ShardReconciler(mgr.GetClient(), r))
+}
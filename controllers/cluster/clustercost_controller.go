@@ -0,0 +1,255 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strconv"
+	"time"
+
+	"github.com/go-logr/logr"
+	clusterV1alpha1 "github.com/tmax-cloud/hypercloud-multi-operator/apis/cluster/v1alpha1"
+	"github.com/tmax-cloud/hypercloud-multi-operator/controllers/metrics"
+	util "github.com/tmax-cloud/hypercloud-multi-operator/controllers/util"
+
+	coreV1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+
+	"sigs.k8s.io/cluster-api/util/patch"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+)
+
+const (
+	// 비용을 누적하는 주기
+	costUpdateInterval = 10 * time.Minute
+
+	// pricing configmap 에 없는 instance type 에 사용하는 가격의 key
+	PricingKeyDefault = "default"
+	// pricing configmap 의 가격의 통화를 나타내는 key
+	PricingKeyCurrency = "currency"
+	// spot instance 의 가격은 <instance type>.spot key 로 설정하고, 없으면 on-demand 가격을 사용한다.
+	pricingSpotSuffix = ".spot"
+
+	costMonthFormat = "2006-01"
+)
+
+// ClusterCostReconciler periodically accumulates the cost of each ClusterManager
+// from the hourly price of the running nodes and exports it as metrics.
+type ClusterCostReconciler struct {
+	client.Client
+	// configmap 은 cache 하지 않으므로 api server 에서 직접 조회한다.
+	Reader client.Reader
+	Log    logr.Logger
+	Scheme *runtime.Scheme
+	// instance type 별 시간당 가격을 가지고 있는 configmap 이름
+	PricingConfigMap string
+}
+
+// +kubebuilder:rbac:groups="",resources=configmaps,verbs=get
+// +kubebuilder:rbac:groups=cluster.tmax.io,resources=clustermanagers/status,verbs=get;patch;update
+
+func (r *ClusterCostReconciler) Reconcile(ctx context.Context, req ctrl.Request) (_ ctrl.Result, reterr error) {
+	_ = context.Background()
+	log := r.Log.WithValues("clustermanager", req.NamespacedName)
+
+	clm := &clusterV1alpha1.ClusterManager{}
+	if err := r.Client.Get(context.TODO(), req.NamespacedName, clm); errors.IsNotFound(err) {
+		metrics.ClusterCost.Delete(req.Namespace, req.Name)
+		return ctrl.Result{}, nil
+	} else if err != nil {
+		log.Error(err, "Failed to get ClusterManager")
+		return ctrl.Result{}, err
+	}
+
+	if !clm.DeletionTimestamp.IsZero() {
+		metrics.ClusterCost.Delete(clm.Namespace, clm.Name)
+		return ctrl.Result{}, nil
+	}
+
+	pricing, err := r.getPricing()
+	if err != nil {
+		log.Error(err, "Failed to get pricing configmap")
+		return ctrl.Result{}, err
+	}
+
+	patchHelper, err := patch.NewHelper(clm, r.Client)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	defer func() {
+		if err := patchHelper.Patch(context.TODO(), clm); err != nil {
+			reterr = err
+		}
+	}()
+
+	accumulateCost(clm, pricing, time.Now())
+
+	monthToDate, _ := strconv.ParseFloat(clm.Status.Cost.MonthToDate, 64)
+	hourlyRate, _ := strconv.ParseFloat(clm.Status.Cost.HourlyRate, 64)
+	metrics.ClusterCost.Set(clm.Namespace, clm.Name, clm.Annotations[util.AnnotationKeyTeam], monthToDate, hourlyRate)
+
+	return util.RequeueAfterWithJitter(costUpdateInterval), nil
+}
+
+// pricing configmap 의 data 는 instance type 을 key 로, 시간당 가격을 value 로 가진다.
+// configmap 이 없으면 모든 가격을 0 으로 본다.
+func (r *ClusterCostReconciler) getPricing() (map[string]string, error) {
+	key := types.NamespacedName{Name: r.PricingConfigMap, Namespace: util.HypercloudNamespace}
+	cm := &coreV1.ConfigMap{}
+	if err := r.Reader.Get(context.TODO(), key, cm); errors.IsNotFound(err) {
+		return map[string]string{}, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	for k, v := range cm.Data {
+		if k == PricingKeyCurrency {
+			continue
+		}
+		if _, err := strconv.ParseFloat(v, 64); err != nil {
+			return nil, fmt.Errorf("invalid price of %s in configmap %s: %s", k, key.String(), v)
+		}
+	}
+	return cm.Data, nil
+}
+
+func getHourlyPrice(pricing map[string]string, instanceType string, spot bool) float64 {
+	if spot {
+		if price, ok := pricing[instanceType+pricingSpotSuffix]; ok {
+			v, _ := strconv.ParseFloat(price, 64)
+			return v
+		}
+	}
+	price, ok := pricing[instanceType]
+	if !ok || instanceType == "" {
+		price = pricing[PricingKeyDefault]
+	}
+	v, _ := strconv.ParseFloat(price, 64)
+	return v
+}
+
+// 실행 중인 node 의 수와 시간당 가격의 합을 계산한다.
+// 등록된 cluster 는 status.nodePools 가 모든 node 를 포함하고,
+// 생성된 cluster 는 status.nodePools 가 master, worker 이외의 node pool 만 포함한다.
+func getRunningNodesAndHourlyRate(clm *clusterV1alpha1.ClusterManager, pricing map[string]string) (int, float64) {
+	nodes := 0
+	rate := 0.0
+	if clm.GetClusterType() != clusterV1alpha1.ClusterTypeRegistered {
+		nodes += clm.Status.MasterRun + clm.Status.WorkerRun
+		rate += float64(clm.Status.MasterRun) * getHourlyPrice(pricing, clm.AwsSpec.MasterType, false)
+		rate += float64(clm.Status.WorkerRun) * getHourlyPrice(pricing, clm.AwsSpec.WorkerType, false)
+	}
+	for _, pool := range clm.Status.NodePools {
+		instanceType := pool.InstanceType
+		// 생성된 cluster 의 node pool 은 worker 의 template 으로 생성된다.
+		if instanceType == "" && clm.GetClusterType() != clusterV1alpha1.ClusterTypeRegistered {
+			instanceType = clm.AwsSpec.WorkerType
+		}
+		nodes += pool.Ready
+		rate += float64(pool.Ready) * getHourlyPrice(pricing, instanceType, pool.Spot)
+	}
+	return nodes, rate
+}
+
+// 마지막 갱신 이후 지난 시간 동안 이전의 시간당 가격으로 비용이 발생한 것으로 보고 누적한다.
+// 실행 중인 node 가 없던 시간은 hibernation 으로 보고 비용을 누적하지 않는다.
+// 달이 바뀌면 이번 달 1일부터 다시 누적한다.
+func accumulateCost(clm *clusterV1alpha1.ClusterManager, pricing map[string]string, now time.Time) {
+	month := now.Format(costMonthFormat)
+	monthStart := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+
+	cost := clm.Status.Cost
+	if cost == nil {
+		cost = &clusterV1alpha1.ClusterCost{}
+	}
+	monthToDate, _ := strconv.ParseFloat(cost.MonthToDate, 64)
+	uptime, _ := strconv.ParseFloat(cost.UptimeHours, 64)
+	hibernated, _ := strconv.ParseFloat(cost.HibernatedHours, 64)
+	if cost.Month != month {
+		monthToDate, uptime, hibernated = 0, 0, 0
+	}
+
+	if cost.LastUpdateTime != nil {
+		from := cost.LastUpdateTime.Time
+		if from.Before(monthStart) {
+			from = monthStart
+		}
+		if elapsed := now.Sub(from).Hours(); elapsed > 0 {
+			if cost.RunningNodes == 0 {
+				hibernated += elapsed
+			} else {
+				previousRate, _ := strconv.ParseFloat(cost.HourlyRate, 64)
+				uptime += elapsed
+				monthToDate += elapsed * previousRate
+			}
+		}
+	}
+
+	nodes, rate := getRunningNodesAndHourlyRate(clm, pricing)
+	clm.Status.Cost = &clusterV1alpha1.ClusterCost{
+		Month:           month,
+		MonthToDate:     formatDecimal(monthToDate),
+		HourlyRate:      formatDecimal(rate),
+		Currency:        pricing[PricingKeyCurrency],
+		RunningNodes:    nodes,
+		UptimeHours:     formatDecimal(uptime),
+		HibernatedHours: formatDecimal(hibernated),
+		LastUpdateTime:  &metav1.Time{Time: now},
+	}
+}
+
+func formatDecimal(v float64) string {
+	return strconv.FormatFloat(v, 'f', 4, 64)
+}
+
+func (r *ClusterCostReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		Named("clustercost").
+		For(&clusterV1alpha1.ClusterManager{}).
+		WithOptions(util.DefaultControllerOptions()).
+		WithEventFilter(
+			predicate.Funcs{
+				CreateFunc: func(e event.CreateEvent) bool {
+					return true
+				},
+				UpdateFunc: func(e event.UpdateEvent) bool {
+					oldClm := e.ObjectOld.(*clusterV1alpha1.ClusterManager)
+					newClm := e.ObjectNew.(*clusterV1alpha1.ClusterManager)
+					// 실행 중인 node 가 바뀌면 바뀌기 전까지의 비용을 이전 가격으로 누적하고, 이후에는 주기적으로 requeue 된다.
+					isDeleted := oldClm.DeletionTimestamp.IsZero() && !newClm.DeletionTimestamp.IsZero()
+					isNodeChanged := oldClm.Status.MasterRun != newClm.Status.MasterRun ||
+						oldClm.Status.WorkerRun != newClm.Status.WorkerRun ||
+						!reflect.DeepEqual(oldClm.Status.NodePools, newClm.Status.NodePools)
+					isTeamChanged := oldClm.Annotations[util.AnnotationKeyTeam] != newClm.Annotations[util.AnnotationKeyTeam]
+					return isDeleted || isNodeChanged || isTeamChanged
+				},
+				DeleteFunc: func(e event.DeleteEvent) bool {
+					return true
+				},
+				GenericFunc: func(e event.GenericEvent) bool {
+					return false
+				},
+			},
+		).
+		Complete(util.ShardReconciler(mgr.GetClient(), r))
+}
@@ -0,0 +1,290 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"sort"
+
+	"github.com/go-logr/logr"
+	clusterV1alpha1 "github.com/tmax-cloud/hypercloud-multi-operator/apis/cluster/v1alpha1"
+	"github.com/tmax-cloud/hypercloud-multi-operator/controllers/pipeline"
+	"github.com/tmax-cloud/hypercloud-multi-operator/controllers/util"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+
+	"sigs.k8s.io/cluster-api/util/patch"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+// ClusterGroupReconciler reconciles a ClusterGroup object
+type ClusterGroupReconciler struct {
+	client.Client
+	Log      logr.Logger
+	Scheme   *runtime.Scheme
+	Recorder record.EventRecorder
+}
+
+// +kubebuilder:rbac:groups=cluster.tmax.io,resources=clustergroups,verbs=create;delete;get;list;patch;update;watch
+// +kubebuilder:rbac:groups=cluster.tmax.io,resources=clustergroups/status,verbs=get;patch;update
+// +kubebuilder:rbac:groups=cluster.tmax.io,resources=clusterrollouts,verbs=get;list;watch
+
+func (r *ClusterGroupReconciler) Reconcile(ctx context.Context, req ctrl.Request) (_ ctrl.Result, reterr error) {
+	_ = context.Background()
+	log := r.Log.WithValues("clustergroup", req.NamespacedName)
+
+	// get ClusterGroup
+	clusterGroup := &clusterV1alpha1.ClusterGroup{}
+	if err := r.Client.Get(context.TODO(), req.NamespacedName, clusterGroup); errors.IsNotFound(err) {
+		log.Info("ClusterGroup not found. Ignoring since object must be deleted")
+		pipeline.Forget(clusterGroup, req.NamespacedName)
+		return ctrl.Result{}, nil
+	} else if err != nil {
+		log.Error(err, "Failed to get ClusterGroup")
+		return ctrl.Result{}, err
+	}
+
+	patchHelper, err := patch.NewHelper(clusterGroup, r.Client)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	defer func() {
+		if err := patchHelper.Patch(context.TODO(), clusterGroup); err != nil {
+			reterr = err
+		}
+	}()
+
+	// Handle normal reconciliation loop.
+	return r.reconcile(context.TODO(), clusterGroup)
+}
+
+// reconcile handles cluster group reconciliation.
+func (r *ClusterGroupReconciler) reconcile(ctx context.Context, clusterGroup *clusterV1alpha1.ClusterGroup) (ctrl.Result, error) {
+	phases := []pipeline.Phase[*clusterV1alpha1.ClusterGroup]{
+		// selector 에 해당하는 cluster manager 들의 상태를 모아 cluster group 의 status 에 반영한다.
+		{Name: "UpdateClusterGroupStatus", Run: r.UpdateClusterGroupStatus},
+	}
+
+	return pipeline.NewRunner[*clusterV1alpha1.ClusterGroup](r.Log, r.Recorder).Run(ctx, clusterGroup, phases)
+}
+
+func (r *ClusterGroupReconciler) UpdateClusterGroupStatus(ctx context.Context, clusterGroup *clusterV1alpha1.ClusterGroup) (ctrl.Result, error) {
+	log := r.Log.WithValues("clustergroup", clusterGroup.GetNamespacedName())
+	log.Info("Start to reconcile phase for UpdateClusterGroupStatus")
+
+	clmList, err := GetClusterGroupMembers(r.Client, clusterGroup)
+	if err != nil {
+		log.Error(err, "Failed to list ClusterManagers for ClusterGroup")
+		return ctrl.Result{}, err
+	}
+
+	status := clusterV1alpha1.ClusterGroupStatus{}
+	versions := map[string]bool{}
+	for _, clm := range clmList {
+		status.Clusters = append(status.Clusters, clusterV1alpha1.ClusterGroupClusterStatus{
+			Name:    clm.Name,
+			Ready:   clm.Status.Ready,
+			Version: clm.Status.Version,
+			Phase:   clm.Status.Phase,
+		})
+		if clm.Status.Ready {
+			status.ReadyCount++
+		}
+		if clm.Status.Version != "" && !versions[clm.Status.Version] {
+			versions[clm.Status.Version] = true
+			status.Versions = append(status.Versions, clm.Status.Version)
+		}
+		status.MasterRun += clm.Status.MasterRun
+		status.WorkerRun += clm.Status.WorkerRun
+	}
+	sort.Strings(status.Versions)
+	status.ClusterCount = len(clmList)
+	status.Ready = status.ClusterCount > 0 && status.ReadyCount == status.ClusterCount
+
+	// group 을 대상으로 하는 rollout 의 stage 를 함께 보여준다.
+	rolloutList := &clusterV1alpha1.ClusterRolloutList{}
+	if err := r.Client.List(context.TODO(), rolloutList, client.InNamespace(clusterGroup.Namespace)); err != nil {
+		log.Error(err, "Failed to list ClusterRollouts for ClusterGroup")
+		return ctrl.Result{}, err
+	}
+	for _, rollout := range rolloutList.Items {
+		if rollout.Spec.ClusterGroup != clusterGroup.Name {
+			continue
+		}
+		status.Rollouts = append(status.Rollouts, clusterV1alpha1.ClusterGroupRolloutStatus{
+			Name:    rollout.Name,
+			Phase:   rollout.Status.Phase,
+			Stage:   rollout.Status.Stage,
+			Updated: rollout.Status.Updated,
+			Failed:  rollout.Status.Failed,
+			Total:   rollout.Status.Total,
+		})
+	}
+	sort.Slice(status.Rollouts, func(i, j int) bool {
+		return status.Rollouts[i].Name < status.Rollouts[j].Name
+	})
+
+	clusterGroup.Status = status
+	return ctrl.Result{}, nil
+}
+
+// cluster group 의 selector 에 해당하는 cluster manager 목록을 이름 순으로 반환한다.
+// cluster group 을 대상으로 하는 다른 기능에서도 사용한다.
+func GetClusterGroupMembers(c client.Client, clusterGroup *clusterV1alpha1.ClusterGroup) ([]clusterV1alpha1.ClusterManager, error) {
+	selector, err := clusterGroup.GetClusterSelector()
+	if err != nil {
+		return nil, err
+	}
+
+	clmList := &clusterV1alpha1.ClusterManagerList{}
+	opts := []client.ListOption{
+		client.InNamespace(clusterGroup.Namespace),
+		client.MatchingLabelsSelector{Selector: selector},
+	}
+	if err := c.List(context.TODO(), clmList, opts...); err != nil {
+		return nil, err
+	}
+
+	sort.Slice(clmList.Items, func(i, j int) bool {
+		return clmList.Items[i].Name < clmList.Items[j].Name
+	})
+	return clmList.Items, nil
+}
+
+func (r *ClusterGroupReconciler) requeueClusterGroupsForClusterManager(o client.Object) []ctrl.Request {
+	clm := o.DeepCopyObject().(*clusterV1alpha1.ClusterManager)
+	log := r.Log.WithValues("ClusterGroup-ObjectMapper", "clusterManagerToClusterGroups", "ClusterManager", clm.GetNamespacedName())
+
+	clusterGroupList := &clusterV1alpha1.ClusterGroupList{}
+	if err := r.Client.List(context.TODO(), clusterGroupList, client.InNamespace(clm.Namespace)); err != nil {
+		log.Error(err, "Failed to list ClusterGroup")
+		return nil
+	}
+
+	// label 이 변경되어 group 에서 빠지는 경우도 반영해야 하므로,
+	// 현재 selector 에 해당하거나 status 에 포함된 group 을 모두 requeue 한다.
+	reqs := []ctrl.Request{}
+	for _, clusterGroup := range clusterGroupList.Items {
+		selector, err := clusterGroup.GetClusterSelector()
+		if err != nil {
+			continue
+		}
+		if selector.Matches(labels.Set(clm.Labels)) || clusterGroupContains(&clusterGroup, clm.Name) {
+			reqs = append(reqs, ctrl.Request{NamespacedName: clusterGroup.GetNamespacedName()})
+		}
+	}
+	return reqs
+}
+
+func (r *ClusterGroupReconciler) requeueClusterGroupForClusterRollout(o client.Object) []ctrl.Request {
+	rollout := o.(*clusterV1alpha1.ClusterRollout)
+	return []ctrl.Request{
+		{NamespacedName: types.NamespacedName{Name: rollout.Spec.ClusterGroup, Namespace: rollout.Namespace}},
+	}
+}
+
+func clusterGroupContains(clusterGroup *clusterV1alpha1.ClusterGroup, clusterName string) bool {
+	for _, cluster := range clusterGroup.Status.Clusters {
+		if cluster.Name == clusterName {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *ClusterGroupReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	controller, err := ctrl.NewControllerManagedBy(mgr).
+		For(&clusterV1alpha1.ClusterGroup{}).
+		WithOptions(util.DefaultControllerOptions()).
+		WithEventFilter(util.SpecChangedPredicate()).
+		Build(util.ShardReconciler(mgr.GetClient(), r))
+
+	if err != nil {
+		return err
+	}
+
+	// rollout 의 stage 나 진행 상황이 변경되면 대상 group 의 status 를 갱신한다.
+	err = controller.Watch(
+		&source.Kind{Type: &clusterV1alpha1.ClusterRollout{}},
+		handler.EnqueueRequestsFromMapFunc(r.requeueClusterGroupForClusterRollout),
+		predicate.Funcs{
+			CreateFunc: func(e event.CreateEvent) bool {
+				return true
+			},
+			UpdateFunc: func(e event.UpdateEvent) bool {
+				oldRollout := e.ObjectOld.(*clusterV1alpha1.ClusterRollout)
+				newRollout := e.ObjectNew.(*clusterV1alpha1.ClusterRollout)
+				if oldRollout.Spec.ClusterGroup != newRollout.Spec.ClusterGroup ||
+					oldRollout.Status.Phase != newRollout.Status.Phase ||
+					oldRollout.Status.Stage != newRollout.Status.Stage ||
+					oldRollout.Status.Updated != newRollout.Status.Updated ||
+					oldRollout.Status.Failed != newRollout.Status.Failed ||
+					oldRollout.Status.Total != newRollout.Status.Total {
+					return true
+				}
+				return false
+			},
+			DeleteFunc: func(e event.DeleteEvent) bool {
+				return true
+			},
+			GenericFunc: func(e event.GenericEvent) bool {
+				return false
+			},
+		},
+	)
+	if err != nil {
+		return err
+	}
+
+	// cluster manager 의 label 이나 상태가 변경되면 해당 cluster 를 포함하는 group 의 status 를 갱신한다.
+	return controller.Watch(
+		&source.Kind{Type: &clusterV1alpha1.ClusterManager{}},
+		handler.EnqueueRequestsFromMapFunc(r.requeueClusterGroupsForClusterManager),
+		predicate.Funcs{
+			CreateFunc: func(e event.CreateEvent) bool {
+				return true
+			},
+			UpdateFunc: func(e event.UpdateEvent) bool {
+				oldClm := e.ObjectOld.(*clusterV1alpha1.ClusterManager)
+				newClm := e.ObjectNew.(*clusterV1alpha1.ClusterManager)
+				if !labels.Equals(oldClm.Labels, newClm.Labels) ||
+					oldClm.Status.Ready != newClm.Status.Ready ||
+					oldClm.Status.Version != newClm.Status.Version ||
+					oldClm.Status.Phase != newClm.Status.Phase ||
+					oldClm.Status.MasterRun != newClm.Status.MasterRun ||
+					oldClm.Status.WorkerRun != newClm.Status.WorkerRun {
+					return true
+				}
+				return false
+			},
+			DeleteFunc: func(e event.DeleteEvent) bool {
+				return true
+			},
+			GenericFunc: func(e event.GenericEvent) bool {
+				return false
+			},
+		},
+	)
+}
@@ -0,0 +1,112 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+
+	"github.com/go-logr/logr"
+	clusterV1alpha1 "github.com/tmax-cloud/hypercloud-multi-operator/apis/cluster/v1alpha1"
+	"github.com/tmax-cloud/hypercloud-multi-operator/controllers/pipeline"
+	"github.com/tmax-cloud/hypercloud-multi-operator/controllers/util"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+
+	"sigs.k8s.io/cluster-api/util/patch"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// ClusterImportSessionReconciler reconciles a ClusterImportSession object
+type ClusterImportSessionReconciler struct {
+	client.Client
+	Log      logr.Logger
+	Scheme   *runtime.Scheme
+	Recorder record.EventRecorder
+	// member cluster 의 clientset 을 생성한다. nil 이면 util.DefaultRemoteClientFactory 를 사용한다.
+	RemoteClients util.RemoteClientFactory
+}
+
+func (r *ClusterImportSessionReconciler) remoteClients() util.RemoteClientFactory {
+	return util.RemoteClientFactoryOrDefault(r.RemoteClients)
+}
+
+// +kubebuilder:rbac:groups=cluster.tmax.io,resources=clusterimportsessions,verbs=get;list;patch;update;watch
+// +kubebuilder:rbac:groups=cluster.tmax.io,resources=clusterimportsessions/status,verbs=get;patch;update
+
+func (r *ClusterImportSessionReconciler) Reconcile(ctx context.Context, req ctrl.Request) (_ ctrl.Result, reterr error) {
+	_ = context.Background()
+	log := r.Log.WithValues("clusterimportsession", req.NamespacedName)
+
+	// get ClusterImportSession
+	session := &clusterV1alpha1.ClusterImportSession{}
+	if err := r.Client.Get(context.TODO(), req.NamespacedName, session); errors.IsNotFound(err) {
+		log.Info("ClusterImportSession not found. Ignoring since object must be deleted")
+		pipeline.Forget(session, req.NamespacedName)
+		return ctrl.Result{}, nil
+	} else if err != nil {
+		log.Error(err, "Failed to get ClusterImportSession")
+		return ctrl.Result{}, err
+	}
+
+	// 생성된 ClusterRegistration 은 session 과 관계없이 유지되므로 삭제 시 정리할 리소스가 없다.
+	if !session.DeletionTimestamp.IsZero() {
+		return ctrl.Result{}, nil
+	}
+
+	// import 가 끝난 session 은 더 이상 처리하지 않는다.
+	if session.Status.Phase == clusterV1alpha1.ClusterImportSessionPhaseImported {
+		return ctrl.Result{}, nil
+	}
+
+	patchHelper, err := patch.NewHelper(session, r.Client)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	defer func() {
+		if err := patchHelper.Patch(context.TODO(), session); err != nil {
+			reterr = err
+		}
+	}()
+
+	// Handle normal reconciliation loop.
+	return r.reconcile(context.TODO(), session)
+}
+
+// reconcile handles cluster import session reconciliation.
+func (r *ClusterImportSessionReconciler) reconcile(ctx context.Context, session *clusterV1alpha1.ClusterImportSession) (ctrl.Result, error) {
+	phases := []pipeline.Phase[*clusterV1alpha1.ClusterImportSession]{
+		// kubeconfig 로 connectivity, permissions, version, conflicts 를 차례로 검사하여 결과를 status 에 기록한다.
+		// spec 이 변경되면 다시 검사하므로, 사용자가 확인하는 시점의 결과로 import 여부를 결정한다.
+		{Name: "ValidateImport", Run: r.ValidateImport, When: needsImportValidation},
+		// 사용자가 확인하고 모든 검사를 통과한 경우 ClusterRegistration 을 생성한다.
+		{Name: "ImportCluster", Run: r.ImportCluster, When: isImportConfirmed, DependsOn: []string{"ValidateImport"}},
+	}
+
+	return pipeline.NewRunner[*clusterV1alpha1.ClusterImportSession](r.Log, r.Recorder).Run(ctx, session, phases)
+}
+
+func (r *ClusterImportSessionReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	_, err := ctrl.NewControllerManagedBy(mgr).
+		For(&clusterV1alpha1.ClusterImportSession{}).
+		WithOptions(util.DefaultControllerOptions()).
+		WithEventFilter(util.SpecChangedPredicate()).
+		Build(util.ShardReconciler(mgr.GetClient(), r))
+
+	return err
+}
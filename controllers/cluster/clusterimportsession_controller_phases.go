@@ -0,0 +1,462 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	goerrors "errors"
+	"fmt"
+	"strings"
+	"time"
+
+	clusterV1alpha1 "github.com/tmax-cloud/hypercloud-multi-operator/apis/cluster/v1alpha1"
+	"github.com/tmax-cloud/hypercloud-multi-operator/controllers/util"
+
+	authorizationV1 "k8s.io/api/authorization/v1"
+	coreV1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// 일시적인 이유로 connectivity 검사가 실패한 경우 다시 검사하는 간격
+	clusterImportRetryInterval = 30 * time.Second
+
+	reasonClusterImported = "ClusterImported"
+
+	// ClusterImportCheck 의 reason
+	importReasonInvalidSpec          = "InvalidSpec"
+	importReasonSecretNotFound       = "KubeconfigSecretNotFound"
+	importReasonInvalidKubeconfig    = "InvalidKubeconfig"
+	importReasonUnauthorized         = "Unauthorized"
+	importReasonUnreachable          = "Unreachable"
+	importReasonConnected            = "Connected"
+	importReasonInsufficientAccess   = "InsufficientPermissions"
+	importReasonNotClusterAdmin      = "NotClusterAdmin"
+	importReasonAccessReviewFailed   = "AccessReviewFailed"
+	importReasonAuthorized           = "Authorized"
+	importReasonUnsupportedVersion   = "UnsupportedVersion"
+	importReasonUnknownVersion       = "UnknownVersion"
+	importReasonSupportedVersion     = "SupportedVersion"
+	importReasonConflicted           = "Conflicted"
+	importReasonNoConflict           = "NoConflict"
+	importReasonPreviousCheckFailed  = "PreviousCheckFailed"
+	importReasonRegistrationConflict = "ClusterRegistrationConflicted"
+)
+
+// member cluster 에 대해 operator 가 사용하는 권한
+var clusterImportRequiredAccess = []authorizationV1.ResourceAttributes{
+	{Verb: "list", Resource: "nodes"},
+	{Verb: "update", Resource: "nodes"},
+	{Verb: "list", Resource: "pods"},
+	{Verb: "create", Resource: "namespaces"},
+	{Verb: "create", Resource: "secrets"},
+	{Verb: "create", Resource: "serviceaccounts", Namespace: util.KubeNamespace},
+	{Verb: "create", Group: "rbac.authorization.k8s.io", Resource: "clusterroles"},
+	{Verb: "update", Group: "rbac.authorization.k8s.io", Resource: "clusterroles"},
+	{Verb: "create", Group: "rbac.authorization.k8s.io", Resource: "clusterrolebindings"},
+}
+
+// spec 이 변경되었거나, 일시적인 이유로 실패하여 다시 검사해야 하는 경우에만 검사한다.
+func needsImportValidation(session *clusterV1alpha1.ClusterImportSession) bool {
+	if session.Status.ObservedGeneration != session.Generation {
+		return true
+	}
+	if session.Status.Phase != clusterV1alpha1.ClusterImportSessionPhaseFailed {
+		return false
+	}
+	check := session.Status.GetCheck(clusterV1alpha1.ClusterImportCheckConnectivity)
+	return check != nil && isRetryableImportReason(check.Reason)
+}
+
+func isRetryableImportReason(reason string) bool {
+	return reason == importReasonUnreachable || reason == importReasonSecretNotFound
+}
+
+// 사용자가 확인했고 현재 spec 으로 모든 검사를 통과한 경우에만 import 한다.
+func isImportConfirmed(session *clusterV1alpha1.ClusterImportSession) bool {
+	return session.Spec.Confirmed &&
+		session.Status.Phase == clusterV1alpha1.ClusterImportSessionPhaseValidated &&
+		session.Status.ObservedGeneration == session.Generation
+}
+
+func (r *ClusterImportSessionReconciler) ValidateImport(ctx context.Context, session *clusterV1alpha1.ClusterImportSession) (ctrl.Result, error) {
+	log := r.Log.WithValues("ClusterImportSession", session.GetNamespacedName())
+	log.Info("Start to reconcile phase for ValidateImport")
+
+	status := &session.Status
+	status.SetTypedPhase(clusterV1alpha1.ClusterImportSessionPhaseValidating)
+	status.ObservedGeneration = session.Generation
+	status.Checks = nil
+	status.APIServer = ""
+	status.Version = ""
+	status.NodeCount = 0
+	status.Reason = ""
+	now := metav1.Now()
+	status.LastValidationTime = &now
+
+	connectivity, kubeconfig, clientset, err := r.checkImportConnectivity(ctx, session)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	status.Checks = append(status.Checks, connectivity)
+
+	if connectivity.Result == clusterV1alpha1.ClusterImportCheckResultFailed {
+		// 접근할 수 없는 cluster 는 나머지 항목을 검사할 수 없다.
+		for _, name := range []clusterV1alpha1.ClusterImportCheckName{
+			clusterV1alpha1.ClusterImportCheckPermissions,
+			clusterV1alpha1.ClusterImportCheckVersion,
+			clusterV1alpha1.ClusterImportCheckConflicts,
+		} {
+			status.Checks = append(status.Checks, clusterV1alpha1.ClusterImportCheck{
+				Name:    name,
+				Result:  clusterV1alpha1.ClusterImportCheckResultSkipped,
+				Reason:  importReasonPreviousCheckFailed,
+				Message: "the cluster is not connected",
+			})
+		}
+		status.SetTypedPhase(clusterV1alpha1.ClusterImportSessionPhaseFailed)
+		if isRetryableImportReason(connectivity.Reason) {
+			log.Info("Cluster is not connected, retry later", "reason", connectivity.Message)
+			return util.RequeueAfterWithJitter(clusterImportRetryInterval), nil
+		}
+		return ctrl.Result{}, nil
+	}
+
+	status.Checks = append(status.Checks, checkImportPermissions(ctx, clientset))
+	status.Checks = append(status.Checks, checkImportVersion(status.Version))
+	conflicts, err := r.checkImportConflicts(ctx, session, kubeconfig)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	status.Checks = append(status.Checks, conflicts)
+
+	if status.Passed() {
+		status.SetTypedPhase(clusterV1alpha1.ClusterImportSessionPhaseValidated)
+	} else {
+		status.SetTypedPhase(clusterV1alpha1.ClusterImportSessionPhaseFailed)
+	}
+	return ctrl.Result{}, nil
+}
+
+// kubeconfig 를 가져와 api server 에 접근할 수 있는지 확인하고, version 과 node 수를 status 에 기록한다.
+// kms, vault 에 접근할 수 없는 경우와 같이 사용자가 해결할 수 없는 error 만 반환한다.
+func (r *ClusterImportSessionReconciler) checkImportConnectivity(ctx context.Context, session *clusterV1alpha1.ClusterImportSession) (clusterV1alpha1.ClusterImportCheck, *util.Kubeconfig, kubernetes.Interface, error) {
+	check := clusterV1alpha1.ClusterImportCheck{
+		Name:   clusterV1alpha1.ClusterImportCheckConnectivity,
+		Result: clusterV1alpha1.ClusterImportCheckResultFailed,
+	}
+	failed := func(reason, message string) (clusterV1alpha1.ClusterImportCheck, *util.Kubeconfig, kubernetes.Interface, error) {
+		check.Reason = reason
+		check.Message = message
+		return check, nil, nil, nil
+	}
+
+	spec := session.Spec
+	sources := 0
+	for _, set := range []bool{spec.KubeConfig != "", spec.KubeConfigSecret != "", spec.EncryptedKubeConfig != ""} {
+		if set {
+			sources++
+		}
+	}
+	if sources != 1 {
+		return failed(importReasonInvalidSpec, "exactly one of spec.kubeConfig, spec.kubeConfigSecret and spec.encryptedKubeConfig must be set")
+	}
+
+	var kubeconfig *util.Kubeconfig
+	var err error
+	switch {
+	case spec.KubeConfigSecret != "":
+		var raw []byte
+		raw, err = r.getImportKubeconfigFromSecret(ctx, session)
+		if errors.IsNotFound(err) {
+			return failed(importReasonSecretNotFound, fmt.Sprintf("secret %s is not found", spec.KubeConfigSecret))
+		} else if err == nil {
+			kubeconfig, err = util.ParseKubeconfig(raw)
+		}
+	case spec.EncryptedKubeConfig != "":
+		var raw []byte
+		raw, err = util.DecryptKubeconfig(ctx, r.Client, session.Namespace, spec.EncryptedKubeConfig)
+		if goerrors.Is(err, util.ErrRemoteUnreachable) {
+			// tenant key 의 kms, vault 에 접근할 수 없는 경우 다시 시도한다.
+			return check, nil, nil, err
+		} else if err == nil {
+			kubeconfig, err = util.ParseKubeconfig(raw)
+		}
+	default:
+		kubeconfig, err = util.ParseEncodedKubeconfig(spec.KubeConfig)
+	}
+	if err != nil {
+		return failed(importReasonInvalidKubeconfig, err.Error())
+	}
+	session.Status.APIServer = kubeconfig.Server
+
+	clientset, err := r.remoteClients().ClientsetForKubeconfig(kubeconfig)
+	if err != nil {
+		return failed(importReasonInvalidKubeconfig, err.Error())
+	}
+
+	info, err := clientset.Discovery().ServerVersion()
+	if err := util.ClassifyRemoteError(err); goerrors.Is(err, util.ErrPermissionDenied) {
+		return failed(importReasonUnauthorized, "the credentials of the kubeconfig are rejected: "+err.Error())
+	} else if err != nil {
+		return failed(importReasonUnreachable, err.Error())
+	}
+	session.Status.Version = info.GitVersion
+
+	// node 조회 권한은 permissions 항목에서 확인하므로 여기서는 실패를 무시한다.
+	if nodes, err := clientset.CoreV1().Nodes().List(ctx, metav1.ListOptions{}); err == nil {
+		session.Status.NodeCount = len(nodes.Items)
+	}
+
+	check.Result = clusterV1alpha1.ClusterImportCheckResultPassed
+	check.Reason = importReasonConnected
+	check.Message = fmt.Sprintf("connected to %s (%s)", kubeconfig.Server, info.GitVersion)
+	return check, kubeconfig, clientset, nil
+}
+
+func (r *ClusterImportSessionReconciler) getImportKubeconfigFromSecret(ctx context.Context, session *clusterV1alpha1.ClusterImportSession) ([]byte, error) {
+	key := types.NamespacedName{
+		Name:      session.Spec.KubeConfigSecret,
+		Namespace: session.Namespace,
+	}
+	secret := &coreV1.Secret{}
+	if err := r.Client.Get(ctx, key, secret); err != nil {
+		return nil, err
+	}
+	raw, ok := secret.Data["value"]
+	if !ok || len(raw) == 0 {
+		return nil, fmt.Errorf("kubeconfig is not found in value of secret %s", secret.Name)
+	}
+	return raw, nil
+}
+
+// kubeconfig 의 사용자가 operator 가 member cluster 에 사용하는 권한을 모두 가지고 있는지 확인한다.
+// addon 은 argocd 로 배포하므로 cluster admin 이 아니면 warning 으로 알린다.
+func checkImportPermissions(ctx context.Context, clientset kubernetes.Interface) clusterV1alpha1.ClusterImportCheck {
+	check := clusterV1alpha1.ClusterImportCheck{
+		Name: clusterV1alpha1.ClusterImportCheckPermissions,
+	}
+
+	allowed := func(attributes authorizationV1.ResourceAttributes) (bool, error) {
+		review := &authorizationV1.SelfSubjectAccessReview{
+			Spec: authorizationV1.SelfSubjectAccessReviewSpec{
+				ResourceAttributes: &attributes,
+			},
+		}
+		result, err := clientset.AuthorizationV1().SelfSubjectAccessReviews().Create(ctx, review, metav1.CreateOptions{})
+		if err != nil {
+			return false, err
+		}
+		return result.Status.Allowed, nil
+	}
+
+	for _, attributes := range clusterImportRequiredAccess {
+		ok, err := allowed(attributes)
+		if err != nil {
+			check.Result = clusterV1alpha1.ClusterImportCheckResultFailed
+			check.Reason = importReasonAccessReviewFailed
+			check.Message = err.Error()
+			check.Details = nil
+			return check
+		}
+		if !ok {
+			check.Details = append(check.Details, describeResourceAttributes(attributes))
+		}
+	}
+	if len(check.Details) > 0 {
+		check.Result = clusterV1alpha1.ClusterImportCheckResultFailed
+		check.Reason = importReasonInsufficientAccess
+		check.Message = fmt.Sprintf("%d permissions required by the operator are missing", len(check.Details))
+		return check
+	}
+
+	if ok, err := allowed(authorizationV1.ResourceAttributes{Verb: "*", Group: "*", Resource: "*"}); err == nil && !ok {
+		check.Result = clusterV1alpha1.ClusterImportCheckResultWarning
+		check.Reason = importReasonNotClusterAdmin
+		check.Message = "the user is not a cluster admin, addons deployed by argocd may fail to sync"
+		return check
+	}
+
+	check.Result = clusterV1alpha1.ClusterImportCheckResultPassed
+	check.Reason = importReasonAuthorized
+	check.Message = "the user has all permissions required by the operator"
+	return check
+}
+
+// ex) create clusterrolebindings.rbac.authorization.k8s.io, create serviceaccounts in kube-system
+func describeResourceAttributes(attributes authorizationV1.ResourceAttributes) string {
+	resource := attributes.Resource
+	if attributes.Group != "" {
+		resource += "." + attributes.Group
+	}
+	description := attributes.Verb + " " + resource
+	if attributes.Namespace != "" {
+		description += " in " + attributes.Namespace
+	}
+	return description
+}
+
+// 지원하지 않는 version 의 cluster 도 import 할 수는 있으므로 warning 으로 알린다.
+func checkImportVersion(version string) clusterV1alpha1.ClusterImportCheck {
+	check := clusterV1alpha1.ClusterImportCheck{
+		Name:   clusterV1alpha1.ClusterImportCheckVersion,
+		Result: clusterV1alpha1.ClusterImportCheckResultPassed,
+		Reason: importReasonSupportedVersion,
+	}
+
+	supported := util.GetSupportedKubernetesVersions()
+	if len(supported) == 0 {
+		check.Message = "supported kubernetes versions are not restricted"
+		return check
+	}
+
+	ok, err := util.IsSupportedKubernetesVersion(version, supported)
+	if err != nil {
+		check.Result = clusterV1alpha1.ClusterImportCheckResultWarning
+		check.Reason = importReasonUnknownVersion
+		check.Message = fmt.Sprintf("failed to parse version %s: %s", version, err.Error())
+		return check
+	}
+	if !ok {
+		check.Result = clusterV1alpha1.ClusterImportCheckResultWarning
+		check.Reason = importReasonUnsupportedVersion
+		check.Message = fmt.Sprintf("version %s is not supported, supported versions are %s", version, strings.Join(supported, ", "))
+		check.Details = supported
+		return check
+	}
+	check.Message = fmt.Sprintf("version %s is supported", version)
+	return check
+}
+
+// 같은 이름의 cluster 나 ClusterRegistration, 같은 api server 로 등록된 cluster 가 있는지 확인한다.
+func (r *ClusterImportSessionReconciler) checkImportConflicts(ctx context.Context, session *clusterV1alpha1.ClusterImportSession, kubeconfig *util.Kubeconfig) (clusterV1alpha1.ClusterImportCheck, error) {
+	check := clusterV1alpha1.ClusterImportCheck{
+		Name: clusterV1alpha1.ClusterImportCheckConflicts,
+	}
+	clusterName := session.Spec.ClusterName
+
+	key := types.NamespacedName{Name: clusterName, Namespace: session.Namespace}
+	if err := r.Client.Get(ctx, key, &clusterV1alpha1.ClusterManager{}); err != nil && !errors.IsNotFound(err) {
+		return check, err
+	} else if err == nil {
+		check.Details = append(check.Details, fmt.Sprintf("ClusterManager %s already exists", key))
+	}
+
+	// 등록에 실패했거나 cluster 가 삭제된 ClusterRegistration 은 제외한다.
+	clrs := &clusterV1alpha1.ClusterRegistrationList{}
+	if err := r.Client.List(ctx, clrs,
+		client.InNamespace(session.Namespace),
+		client.MatchingFields{util.IndexKeyClusterName: clusterName},
+	); err != nil {
+		return check, err
+	}
+	for _, clr := range clrs.Items {
+		if clr.Status.Phase == clusterV1alpha1.ClusterRegistrationPhaseError ||
+			clr.Status.Phase == clusterV1alpha1.ClusterRegistrationPhaseClusterDeleted {
+			continue
+		}
+		check.Details = append(check.Details, fmt.Sprintf("ClusterRegistration %s/%s is registering cluster %s", clr.Namespace, clr.Name, clusterName))
+	}
+
+	// import 시 session 과 같은 이름으로 ClusterRegistration 을 생성한다.
+	clr := &clusterV1alpha1.ClusterRegistration{}
+	if err := r.Client.Get(ctx, session.GetNamespacedName(), clr); err != nil && !errors.IsNotFound(err) {
+		return check, err
+	} else if err == nil && clr.Spec.ClusterName != clusterName {
+		check.Details = append(check.Details, fmt.Sprintf("ClusterRegistration %s/%s already exists", clr.Namespace, clr.Name))
+	}
+
+	// 다른 namespace 에 같은 cluster 가 등록되어 있으면 member cluster 의 operator 리소스가 충돌한다.
+	host, err := kubeconfig.Host()
+	if err != nil {
+		return check, err
+	}
+	clms := &clusterV1alpha1.ClusterManagerList{}
+	if err := r.Client.List(ctx, clms); err != nil {
+		return check, err
+	}
+	for _, clm := range clms.Items {
+		if util.EndpointHost(clm.GetAPIServerEndpoint()) == util.EndpointHost(host) {
+			check.Details = append(check.Details, fmt.Sprintf("api server %s is already registered as ClusterManager %s/%s", host, clm.Namespace, clm.Name))
+		}
+	}
+
+	if len(check.Details) > 0 {
+		check.Result = clusterV1alpha1.ClusterImportCheckResultFailed
+		check.Reason = importReasonConflicted
+		check.Message = "the cluster conflicts with registered clusters"
+		return check, nil
+	}
+	check.Result = clusterV1alpha1.ClusterImportCheckResultPassed
+	check.Reason = importReasonNoConflict
+	check.Message = "no registered cluster conflicts with the cluster"
+	return check, nil
+}
+
+func (r *ClusterImportSessionReconciler) ImportCluster(ctx context.Context, session *clusterV1alpha1.ClusterImportSession) (ctrl.Result, error) {
+	log := r.Log.WithValues("ClusterImportSession", session.GetNamespacedName())
+	log.Info("Start to reconcile phase for ImportCluster")
+
+	// session 을 삭제해도 등록된 cluster 가 유지되도록 owner reference 를 설정하지 않는다.
+	clr := &clusterV1alpha1.ClusterRegistration{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      session.Name,
+			Namespace: session.Namespace,
+			Annotations: map[string]string{
+				util.AnnotationKeyCreator:       session.Annotations[util.AnnotationKeyCreator],
+				util.AnnotationKeyImportSession: session.Name,
+			},
+		},
+		Spec: clusterV1alpha1.ClusterRegistrationSpec{
+			ClusterName:         session.Spec.ClusterName,
+			KubeConfig:          session.Spec.KubeConfig,
+			KubeConfigSecret:    session.Spec.KubeConfigSecret,
+			EncryptedKubeConfig: session.Spec.EncryptedKubeConfig,
+		},
+	}
+	if err := r.Client.Create(ctx, clr); errors.IsAlreadyExists(err) {
+		// 이전 reconcile 에서 생성했지만 status 를 기록하지 못한 경우
+		existing := &clusterV1alpha1.ClusterRegistration{}
+		if err := r.Client.Get(ctx, clr.GetNamespacedName(), existing); err != nil {
+			return ctrl.Result{}, err
+		}
+		if existing.Annotations[util.AnnotationKeyImportSession] != session.Name {
+			session.Status.SetTypedPhase(clusterV1alpha1.ClusterImportSessionPhaseFailed)
+			session.Status.Reason = importReasonRegistrationConflict
+			return ctrl.Result{}, nil
+		}
+	} else if errors.IsInvalid(err) || errors.IsForbidden(err) {
+		// webhook 에서 거부된 경우 spec 을 수정해야 한다.
+		log.Info("ClusterRegistration is rejected", "reason", err.Error())
+		session.Status.SetTypedPhase(clusterV1alpha1.ClusterImportSessionPhaseFailed)
+		session.Status.Reason = err.Error()
+		return ctrl.Result{}, nil
+	} else if err != nil {
+		log.Error(err, "Failed to create ClusterRegistration")
+		return ctrl.Result{}, err
+	}
+
+	session.Status.ClusterRegistration = clr.Name
+	session.Status.SetTypedPhase(clusterV1alpha1.ClusterImportSessionPhaseImported)
+	r.Recorder.Event(session, coreV1.EventTypeNormal, reasonClusterImported,
+		fmt.Sprintf("ClusterRegistration %s is created for cluster %s", clr.Name, session.Spec.ClusterName))
+	return ctrl.Result{}, nil
+}
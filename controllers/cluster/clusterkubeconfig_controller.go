@@ -0,0 +1,198 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-logr/logr"
+	clusterV1alpha1 "github.com/tmax-cloud/hypercloud-multi-operator/apis/cluster/v1alpha1"
+	"github.com/tmax-cloud/hypercloud-multi-operator/controllers/pipeline"
+	"github.com/tmax-cloud/hypercloud-multi-operator/controllers/util"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+
+	"sigs.k8s.io/cluster-api/util/patch"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+// ClusterKubeconfigReconciler reconciles a ClusterKubeconfig object
+type ClusterKubeconfigReconciler struct {
+	client.Client
+	Log      logr.Logger
+	Scheme   *runtime.Scheme
+	Recorder record.EventRecorder
+}
+
+// +kubebuilder:rbac:groups=cluster.tmax.io,resources=clusterkubeconfigs,verbs=create;delete;get;list;patch;update;watch
+// +kubebuilder:rbac:groups=cluster.tmax.io,resources=clusterkubeconfigs/status,verbs=get;patch;update
+
+func (r *ClusterKubeconfigReconciler) Reconcile(ctx context.Context, req ctrl.Request) (_ ctrl.Result, reterr error) {
+	_ = context.Background()
+	log := r.Log.WithValues("clusterkubeconfig", req.NamespacedName)
+
+	// get ClusterKubeconfig
+	ckc := &clusterV1alpha1.ClusterKubeconfig{}
+	if err := r.Client.Get(context.TODO(), req.NamespacedName, ckc); errors.IsNotFound(err) {
+		log.Info("ClusterKubeconfig not found. Ignoring since object must be deleted")
+		pipeline.Forget(ckc, req.NamespacedName)
+		return ctrl.Result{}, nil
+	} else if err != nil {
+		log.Error(err, "Failed to get ClusterKubeconfig")
+		return ctrl.Result{}, err
+	}
+
+	patchHelper, err := patch.NewHelper(ckc, r.Client)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	defer func() {
+		r.reconcilePhase(context.TODO(), ckc)
+
+		if err := patchHelper.Patch(context.TODO(), ckc); err != nil {
+			reterr = err
+		}
+	}()
+
+	// secret, Role, RoleBinding 은 owner reference 로 함께 삭제되므로 finalizer 를 사용하지 않는다.
+	if !ckc.ObjectMeta.DeletionTimestamp.IsZero() {
+		return ctrl.Result{}, nil
+	}
+
+	return r.reconcile(context.TODO(), ckc)
+}
+
+func (r *ClusterKubeconfigReconciler) reconcile(ctx context.Context, ckc *clusterV1alpha1.ClusterKubeconfig) (ctrl.Result, error) {
+	log := r.Log.WithValues("clusterkubeconfig", ckc.GetNamespacedName())
+
+	expirationTime := ckc.GetExpirationTime()
+	ckc.Status.ExpirationTime = &expirationTime
+	ckc.Status.Reason = ""
+
+	// ttl 이 지나면 사용자가 더 이상 kubeconfig 를 조회할 수 없도록 secret 을 삭제한다.
+	if !time.Now().Before(expirationTime.Time) {
+		if err := r.revokeKubeconfig(ctx, ckc); err != nil {
+			log.Error(err, "Failed to delete expired kubeconfig")
+			return ctrl.Result{}, err
+		}
+		return ctrl.Result{}, nil
+	}
+
+	// 멤버에서 제외된 경우에도 secret 을 삭제한다. 멤버가 다시 추가되면 watch 로 requeue 된다.
+	isMember, err := r.isClusterMember(ctx, ckc)
+	if err != nil {
+		log.Error(err, "Failed to check membership of the user")
+		return ctrl.Result{}, err
+	}
+	if !isMember {
+		log.Info("User is not a member of the cluster", "user", ckc.Spec.User, "cluster", ckc.Spec.ClusterName)
+		ckc.Status.Reason = "user " + ckc.Spec.User + " is not a member of the cluster " + ckc.Spec.ClusterName
+		if err := r.revokeKubeconfig(ctx, ckc); err != nil {
+			log.Error(err, "Failed to delete kubeconfig")
+			return ctrl.Result{}, err
+		}
+		return ctrl.Result{}, nil
+	}
+
+	phases := []pipeline.Phase[*clusterV1alpha1.ClusterKubeconfig]{
+		// cluster 의 api server 주소와 CA, hyperauth 의 oidc 설정으로 kubeconfig secret 을 생성한다.
+		{Name: "CreateKubeconfigSecret", Run: r.CreateKubeconfigSecret},
+		// 요청한 사용자만 secret 을 조회할 수 있도록 Role, RoleBinding 을 생성한다.
+		{Name: "CreateSecretRoleBinding", Run: r.CreateSecretRoleBinding},
+	}
+
+	res, err := pipeline.NewRunner[*clusterV1alpha1.ClusterKubeconfig](r.Log, r.Recorder).Run(ctx, ckc, phases)
+	if err != nil {
+		return res, err
+	}
+	// 만료 시각에 다시 reconcile 하여 secret 을 삭제한다.
+	return util.LowestNonZeroResult(res, ctrl.Result{RequeueAfter: time.Until(expirationTime.Time)}), nil
+}
+
+func (r *ClusterKubeconfigReconciler) reconcilePhase(_ context.Context, ckc *clusterV1alpha1.ClusterKubeconfig) {
+	switch {
+	case ckc.Status.ExpirationTime != nil && !time.Now().Before(ckc.Status.ExpirationTime.Time):
+		ckc.Status.SetTypedPhase(clusterV1alpha1.ClusterKubeconfigPhaseExpired)
+	case ckc.Status.Reason != "":
+		ckc.Status.SetTypedPhase(clusterV1alpha1.ClusterKubeconfigPhaseError)
+	case ckc.Status.SecretName != "":
+		ckc.Status.SetTypedPhase(clusterV1alpha1.ClusterKubeconfigPhaseReady)
+	default:
+		ckc.Status.SetTypedPhase(clusterV1alpha1.ClusterKubeconfigPhasePending)
+	}
+}
+
+func (r *ClusterKubeconfigReconciler) requeueClusterKubeconfigsForClusterMember(o client.Object) []ctrl.Request {
+	clusterMember := o.(*clusterV1alpha1.ClusterMember)
+	log := r.Log.WithValues("ClusterKubeconfig-ObjectMapper", "clusterMemberToClusterKubeconfigs", "ClusterMember", clusterMember.GetNamespacedName())
+
+	ckcList := &clusterV1alpha1.ClusterKubeconfigList{}
+	if err := r.Client.List(context.TODO(), ckcList, client.InNamespace(clusterMember.Namespace)); err != nil {
+		log.Error(err, "Failed to list ClusterKubeconfig")
+		return nil
+	}
+
+	reqs := []ctrl.Request{}
+	for _, ckc := range ckcList.Items {
+		if ckc.Spec.ClusterName == clusterMember.Spec.ClusterName {
+			reqs = append(reqs, ctrl.Request{NamespacedName: ckc.GetNamespacedName()})
+		}
+	}
+	return reqs
+}
+
+func (r *ClusterKubeconfigReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	controller, err := ctrl.NewControllerManagedBy(mgr).
+		For(&clusterV1alpha1.ClusterKubeconfig{}).
+		WithOptions(util.DefaultControllerOptions()).
+		WithEventFilter(util.SpecChangedPredicate()).
+		Build(util.ShardReconciler(mgr.GetClient(), r))
+
+	if err != nil {
+		return err
+	}
+
+	// 멤버가 초대를 수락하거나 삭제되면 kubeconfig 를 다시 생성하거나 삭제한다.
+	return controller.Watch(
+		&source.Kind{Type: &clusterV1alpha1.ClusterMember{}},
+		handler.EnqueueRequestsFromMapFunc(r.requeueClusterKubeconfigsForClusterMember),
+		predicate.Funcs{
+			CreateFunc: func(e event.CreateEvent) bool {
+				return false
+			},
+			UpdateFunc: func(e event.UpdateEvent) bool {
+				oldMember := e.ObjectOld.(*clusterV1alpha1.ClusterMember)
+				newMember := e.ObjectNew.(*clusterV1alpha1.ClusterMember)
+				return oldMember.Spec.Accepted != newMember.Spec.Accepted ||
+					oldMember.DeletionTimestamp.IsZero() != newMember.DeletionTimestamp.IsZero()
+			},
+			DeleteFunc: func(e event.DeleteEvent) bool {
+				return true
+			},
+			GenericFunc: func(e event.GenericEvent) bool {
+				return false
+			},
+		},
+	)
+}
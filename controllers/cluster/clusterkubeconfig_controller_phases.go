@@ -0,0 +1,217 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+
+	clusterV1alpha1 "github.com/tmax-cloud/hypercloud-multi-operator/apis/cluster/v1alpha1"
+	util "github.com/tmax-cloud/hypercloud-multi-operator/controllers/util"
+
+	coreV1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+func (r *ClusterKubeconfigReconciler) CreateKubeconfigSecret(ctx context.Context, ckc *clusterV1alpha1.ClusterKubeconfig) (ctrl.Result, error) {
+	log := r.Log.WithValues("clusterkubeconfig", ckc.GetNamespacedName())
+	log.Info("Start to reconcile phase for CreateKubeconfigSecret")
+
+	clusterSecret, err := util.GetKubeconfigSecret(ctx, r.Client, ckc.Namespace, ckc.Spec.ClusterName)
+	if err != nil {
+		log.Error(err, "Failed to get kubeconfig secret of the cluster")
+		ckc.Status.Reason = "kubeconfig of the cluster is not ready"
+		return ctrl.Result{}, err
+	}
+	kubeconfig, err := util.ParseKubeconfig(clusterSecret.Data["value"])
+	if err != nil {
+		log.Error(err, "Failed to parse kubeconfig of the cluster")
+		ckc.Status.Reason = "Failed to parse kubeconfig of the cluster: " + err.Error()
+		return ctrl.Result{}, err
+	}
+	value, err := util.NewOIDCKubeconfig(ckc.Spec.ClusterName, kubeconfig)
+	if err != nil {
+		log.Error(err, "Failed to create kubeconfig")
+		ckc.Status.Reason = "Failed to create kubeconfig: " + err.Error()
+		return ctrl.Result{}, err
+	}
+
+	secret := &coreV1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      ckc.GetSecretName(),
+			Namespace: ckc.Namespace,
+		},
+	}
+	if err := r.createOrUpdateOwned(ctx, ckc, secret, func() {
+		secret.Type = coreV1.SecretTypeOpaque
+		secret.Data = map[string][]byte{
+			"value": value,
+		}
+	}); err != nil {
+		log.Error(err, "Failed to create kubeconfig secret")
+		ckc.Status.Reason = err.Error()
+		return ctrl.Result{}, err
+	}
+
+	return ctrl.Result{}, nil
+}
+
+func (r *ClusterKubeconfigReconciler) CreateSecretRoleBinding(ctx context.Context, ckc *clusterV1alpha1.ClusterKubeconfig) (ctrl.Result, error) {
+	log := r.Log.WithValues("clusterkubeconfig", ckc.GetNamespacedName())
+	log.Info("Start to reconcile phase for CreateSecretRoleBinding")
+
+	name := ckc.GetSecretName()
+	role := &rbacv1.Role{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: ckc.Namespace,
+		},
+	}
+	if err := r.createOrUpdateOwned(ctx, ckc, role, func() {
+		role.Rules = []rbacv1.PolicyRule{
+			{
+				APIGroups:     []string{""},
+				Resources:     []string{"secrets"},
+				ResourceNames: []string{name},
+				Verbs:         []string{"get"},
+			},
+		}
+	}); err != nil {
+		log.Error(err, "Failed to create role for kubeconfig secret")
+		ckc.Status.Reason = err.Error()
+		return ctrl.Result{}, err
+	}
+
+	roleBinding := &rbacv1.RoleBinding{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: ckc.Namespace,
+		},
+	}
+	if err := r.createOrUpdateOwned(ctx, ckc, roleBinding, func() {
+		roleBinding.RoleRef = rbacv1.RoleRef{
+			APIGroup: rbacv1.GroupName,
+			Kind:     "Role",
+			Name:     name,
+		}
+		roleBinding.Subjects = []rbacv1.Subject{
+			{
+				APIGroup: rbacv1.GroupName,
+				Kind:     rbacv1.UserKind,
+				Name:     ckc.Spec.User,
+			},
+		}
+	}); err != nil {
+		log.Error(err, "Failed to create rolebinding for kubeconfig secret")
+		ckc.Status.Reason = err.Error()
+		return ctrl.Result{}, err
+	}
+
+	ckc.Status.SecretName = name
+	log.Info("Kubeconfig secret is ready", "user", ckc.Spec.User)
+	return ctrl.Result{}, nil
+}
+
+// 같은 이름의 리소스가 이미 있고 ClusterKubeconfig 가 소유한 것이 아니면 덮어쓰지 않는다.
+// cluster 의 kubeconfig secret 등 다른 리소스를 사용자에게 노출하지 않기 위함이다.
+func (r *ClusterKubeconfigReconciler) createOrUpdateOwned(ctx context.Context, ckc *clusterV1alpha1.ClusterKubeconfig, obj client.Object, mutate func()) error {
+	_, err := controllerutil.CreateOrUpdate(ctx, r.Client, obj, func() error {
+		if obj.GetResourceVersion() != "" && !metav1.IsControlledBy(obj, ckc) {
+			return fmt.Errorf("%s already exists and is not owned by the ClusterKubeconfig", obj.GetName())
+		}
+		mutate()
+		return controllerutil.SetControllerReference(ckc, obj, r.Scheme)
+	})
+	return err
+}
+
+// 요청한 사용자가 cluster 의 owner 이거나 초대를 수락한 멤버인지 확인한다.
+// group 멤버는 사용자의 group 중 하나가 일치하면 멤버로 본다.
+func (r *ClusterKubeconfigReconciler) isClusterMember(ctx context.Context, ckc *clusterV1alpha1.ClusterKubeconfig) (bool, error) {
+	if ckc.Spec.User == "" {
+		return false, nil
+	}
+
+	clm := &clusterV1alpha1.ClusterManager{}
+	key := types.NamespacedName{Name: ckc.Spec.ClusterName, Namespace: ckc.Namespace}
+	if err := r.Client.Get(ctx, key, clm); errors.IsNotFound(err) {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+	if clm.Annotations[util.AnnotationKeyOwner] == ckc.Spec.User {
+		return true, nil
+	}
+
+	clusterMemberList := &clusterV1alpha1.ClusterMemberList{}
+	opts := []client.ListOption{
+		client.InNamespace(ckc.Namespace),
+		client.MatchingLabels{clusterV1alpha1.LabelKeyClmName: ckc.Spec.ClusterName},
+	}
+	if err := r.Client.List(ctx, clusterMemberList, opts...); err != nil {
+		return false, err
+	}
+	for _, member := range clusterMemberList.Items {
+		if !member.Spec.Accepted || !member.DeletionTimestamp.IsZero() {
+			continue
+		}
+		switch member.Spec.Attribute {
+		case clusterV1alpha1.ClusterMemberAttributeUser:
+			if member.Spec.MemberId == ckc.Spec.User {
+				return true, nil
+			}
+		case clusterV1alpha1.ClusterMemberAttributeGroup:
+			for _, group := range ckc.Spec.Groups {
+				if member.Spec.MemberId == group {
+					return true, nil
+				}
+			}
+		}
+	}
+	return false, nil
+}
+
+// 만료되거나 멤버에서 제외된 사용자의 kubeconfig secret 과 Role, RoleBinding 을 삭제한다.
+func (r *ClusterKubeconfigReconciler) revokeKubeconfig(ctx context.Context, ckc *clusterV1alpha1.ClusterKubeconfig) error {
+	meta := metav1.ObjectMeta{Name: ckc.GetSecretName(), Namespace: ckc.Namespace}
+	objs := []client.Object{
+		&rbacv1.RoleBinding{ObjectMeta: meta},
+		&rbacv1.Role{ObjectMeta: meta},
+		&coreV1.Secret{ObjectMeta: meta},
+	}
+	for _, obj := range objs {
+		if err := r.Client.Get(ctx, client.ObjectKeyFromObject(obj), obj); errors.IsNotFound(err) {
+			continue
+		} else if err != nil {
+			return err
+		}
+		// 소유하지 않은 같은 이름의 리소스는 삭제하지 않는다.
+		if !metav1.IsControlledBy(obj, ckc) {
+			continue
+		}
+		if err := r.Client.Delete(ctx, obj); err != nil && !errors.IsNotFound(err) {
+			return err
+		}
+	}
+	ckc.Status.SecretName = ""
+	return nil
+}
@@ -18,23 +18,28 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"reflect"
 	"time"
 
 	"github.com/go-logr/logr"
 	certmanagerV1 "github.com/jetstack/cert-manager/pkg/apis/certmanager/v1"
 	clusterV1alpha1 "github.com/tmax-cloud/hypercloud-multi-operator/apis/cluster/v1alpha1"
-	util "github.com/tmax-cloud/hypercloud-multi-operator/controllers/util"
+	"github.com/tmax-cloud/hypercloud-multi-operator/controllers/metrics"
+	"github.com/tmax-cloud/hypercloud-multi-operator/controllers/pipeline"
+	"github.com/tmax-cloud/hypercloud-multi-operator/controllers/util"
 	tmaxv1 "github.com/tmax-cloud/template-operator/api/v1"
 	traefikV1alpha1 "github.com/traefik/traefik/v2/pkg/provider/kubernetes/crd/traefik/v1alpha1"
 
 	coreV1 "k8s.io/api/core/v1"
 	networkingv1 "k8s.io/api/networking/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
-	kerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/client-go/tools/record"
 
-	capiV1alpha3 "sigs.k8s.io/cluster-api/api/v1alpha3"
+	capiV1beta1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/util/conditions"
 	"sigs.k8s.io/cluster-api/util/patch"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -45,23 +50,43 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 	"sigs.k8s.io/controller-runtime/pkg/source"
 
-	controlplanev1 "sigs.k8s.io/cluster-api/controlplane/kubeadm/api/v1alpha3"
+	controlplanev1 "sigs.k8s.io/cluster-api/controlplane/kubeadm/api/v1beta1"
 )
 
 // ClusterManagerReconciler reconciles a ClusterManager object
 type ClusterManagerReconciler struct {
 	client.Client
-	Log    logr.Logger
-	Scheme *runtime.Scheme
+	Log      logr.Logger
+	Scheme   *runtime.Scheme
+	Recorder record.EventRecorder
+	// member cluster 의 clientset 을 생성한다. nil 이면 util.DefaultRemoteClientFactory 를 사용한다.
+	RemoteClients util.RemoteClientFactory
 }
 
+func (r *ClusterManagerReconciler) remoteClients() util.RemoteClientFactory {
+	return util.RemoteClientFactoryOrDefault(r.RemoteClients)
+}
+
+// 실패하거나 기다려야 하는 경우에는 Result.Requeue 를 반환하여 controller 의 rate limiter 로 backoff 하고,
+// watch 할 수 없는 member cluster 의 상태를 확인하는 경우에만 주기적으로 requeue 한다.
 const (
-	requeueAfter10Second = 10 * time.Second
-	requeueAfter20Second = 20 * time.Second
-	requeueAfter30Second = 30 * time.Second
-	requeueAfter1Minute  = 1 * time.Minute
+	resyncPeriod30Second = 30 * time.Second
+	resyncPeriod1Minute  = 1 * time.Minute
 )
 
+// RESYNC_PERIOD 가 설정되지 않았거나 잘못된 경우 1분마다 다시 확인한다.
+func getResyncPeriod() time.Duration {
+	if period, err := util.GetDurationEnv(util.RESYNC_PERIOD); err == nil && period > 0 {
+		return period
+	}
+	return resyncPeriod1Minute
+}
+
+// cluster 에 resync-period annotation 이 있으면 RESYNC_PERIOD 대신 사용한다.
+func getClusterResyncPeriod(clusterManager *clusterV1alpha1.ClusterManager) time.Duration {
+	return clusterManager.GetIntervalOverride(clusterV1alpha1.AnnotationKeyClmResyncPeriod, getResyncPeriod())
+}
+
 const (
 	// upgrade template
 	CAPI_VSPHERE_UPGRADE_TEMPLATE = "capi-vsphere-upgrade-template"
@@ -72,9 +97,12 @@ const (
 	CAPI_WORKER_LABEL_KEY       = "cluster.x-k8s.io/deployment-name"
 )
 
+// +kubebuilder:rbac:groups=cluster.tmax.io,resources=clusteraudits,verbs=create;get;list
 // +kubebuilder:rbac:groups=cluster.tmax.io,resources=clustermanagers,verbs=create;delete;get;list;patch;update;watch
+// +kubebuilder:rbac:groups=cluster.tmax.io,resources=clustermembers,verbs=get;list;watch
 // +kubebuilder:rbac:groups=cluster.tmax.io,resources=clustermanagers/status,verbs=get;list;patch;update;watch
-// +kubebuilder:rbac:groups=cluster.x-k8s.io,resources=clusters,verbs=get;list;watch
+// +kubebuilder:rbac:groups=cluster.x-k8s.io,resources=clusterclasses,verbs=get;list;watch
+// +kubebuilder:rbac:groups=cluster.x-k8s.io,resources=clusters,verbs=create;delete;get;list;patch;update;watch
 // +kubebuilder:rbac:groups=cluster.x-k8s.io,resources=machinedeployments,verbs=create;delete;get;list;patch;update;watch
 // +kubebuilder:rbac:groups=cluster.x-k8s.io,resources=machinedeployments/status,verbs=get;list;patch;update;watch
 // +kubebuilder:rbac:groups=cluster.x-k8s.io,resources=machines,verbs=get;list;patch;update;watch
@@ -82,14 +110,21 @@ const (
 // +kubebuilder:rbac:groups=rbac.authorization.k8s.io,resources=clusterroles;clusterrolebindings;roles;rolebindings,verbs=create;delete;get;list;patch;update;watch
 // +kubebuilder:rbac:groups=controlplane.cluster.x-k8s.io,resources=kubeadmcontrolplanes,verbs=create;delete;get;list;patch;update;watch
 // +kubebuilder:rbac:groups=controlplane.cluster.x-k8s.io,resources=kubeadmcontrolplanes/status,verbs=get;list;patch;update;watch
+// +kubebuilder:rbac:groups=bootstrap.cluster.x-k8s.io,resources=kubeadmconfigtemplates,verbs=create;delete;get;list;watch
+// +kubebuilder:rbac:groups=infrastructure.cluster.x-k8s.io,resources=awsmachinetemplates;vspheremachinetemplates,verbs=create;delete;get;list;watch
 // +kubebuilder:rbac:groups=tmax.io,resources=templateinstances,verbs=create;delete;get;list;patch;update;watch
 // +kubebuilder:rbac:groups=cert-manager.io,resources=certificates,verbs=create;delete;get;list;patch;update;watch
 // +kubebuilder:rbac:groups=networking.k8s.io,resources=ingresses,verbs=create;delete;get;list;patch;update;watch
 // +kubebuilder:rbac:groups="",resources=services;endpoints,verbs=create;delete;get;list;patch;update;watch
 // +kubebuilder:rbac:groups=traefik.containo.us,resources=middlewares,verbs=create;delete;get;list;patch;update;watch
 // +kubebuilder:rbac:groups=coordination.k8s.io,resources=leases,verbs=create;delete;get;list;patch;update;watch
+// +kubebuilder:rbac:groups=argoproj.io,resources=appprojects,verbs=create;delete;get;list;patch;update;watch
 // +kubebuilder:rbac:groups=argoproj.io,resources=applications,verbs=create;delete;get;list;patch;update;watch
 // +kubebuilder:rbac:groups="",resources=nodes,verbs=get;list;patch;update;watch
+// +kubebuilder:rbac:groups="",resources=events,verbs=create;patch
+// +kubebuilder:rbac:groups=cluster.tmax.io,resources=notificationconfigs,verbs=get;list;watch
+// +kubebuilder:rbac:groups=cluster.tmax.io,resources=lifecyclehooks,verbs=get;list;watch
+// +kubebuilder:rbac:groups=batch,resources=jobs,verbs=create;delete;get;list;watch
 
 func (r *ClusterManagerReconciler) Reconcile(ctx context.Context, req ctrl.Request) (_ ctrl.Result, reterr error) {
 	_ = context.Background()
@@ -99,6 +134,7 @@ func (r *ClusterManagerReconciler) Reconcile(ctx context.Context, req ctrl.Reque
 	clusterManager := &clusterV1alpha1.ClusterManager{}
 	if err := r.Client.Get(context.TODO(), req.NamespacedName, clusterManager); errors.IsNotFound(err) {
 		log.Info("ClusterManager resource not found. Ignoring since object must be deleted")
+		pipeline.Forget(clusterManager, req.NamespacedName)
 		return ctrl.Result{}, nil
 	} else if err != nil {
 		log.Error(err, "Failed to get ClusterManager")
@@ -111,9 +147,16 @@ func (r *ClusterManagerReconciler) Reconcile(ctx context.Context, req ctrl.Reque
 		return ctrl.Result{}, err
 	}
 
+	oldPhase := string(clusterManager.Status.Phase)
 	defer func() {
 		// Always reconcile the Status.Phase field.
 		r.reconcilePhase(context.TODO(), clusterManager)
+		metrics.RecordPhaseTransition("ClusterManager", oldPhase, string(clusterManager.Status.Phase))
+		// Ready 상태가 된 경우에만 알리고, Ready 를 유지하는 동안에는 다시 알리지 않는다.
+		if oldPhase != string(clusterV1alpha1.ClusterManagerPhaseReady) &&
+			clusterManager.Status.Phase == clusterV1alpha1.ClusterManagerPhaseReady {
+			r.notify(clusterManager, clusterV1alpha1.NotificationEventClusterReady, "Cluster is ready")
+		}
 
 		if err := patchHelper.Patch(context.TODO(), clusterManager); err != nil {
 			// if err := patchClusterManager(context.TODO(), patchHelper, clusterManager, patchOpts...); err != nil {
@@ -141,21 +184,45 @@ func (r *ClusterManagerReconciler) Reconcile(ctx context.Context, req ctrl.Reque
 // reconcile handles cluster reconciliation.
 func (r *ClusterManagerReconciler) reconcile(ctx context.Context, clusterManager *clusterV1alpha1.ClusterManager) (ctrl.Result, error) {
 
-	type phaseFunc func(context.Context, *clusterV1alpha1.ClusterManager) (ctrl.Result, error)
-	phases := []phaseFunc{}
-	phases = append(phases, r.ReadyReconcilePhase)
+	type phase = pipeline.Phase[*clusterV1alpha1.ClusterManager]
+	runner := pipeline.NewRunner[*clusterV1alpha1.ClusterManager](r.Log, r.Recorder)
+
+	// pre-create hook 이 모두 끝나기 전에는 cluster 를 생성하거나 join 하지 않는다.
+	if res, err := runner.Run(ctx, clusterManager, []phase{{Name: "RunPreCreateHooks", Run: r.RunPreCreateHooks}}); err != nil || !res.IsZero() {
+		return res, err
+	}
+
+	phases := []phase{}
+	phases = append(phases, phase{Name: "ReadyReconcilePhase", Run: r.ReadyReconcilePhase})
 
 	if clusterManager.GetClusterType() == clusterV1alpha1.ClusterTypeCreated {
 		// cluster claim 으로 cluster 를 생성한 경우에만 수행
+		// node 의 bootstrap 에서 참조하는 registry 설정 secret 을 cluster 보다 먼저 생성한다.
+		phases = append(phases, phase{Name: "CreateRegistryConfigSecret", Run: r.CreateRegistryConfigSecret})
+		if clusterManager.Spec.Topology != nil {
+			// spec.topology 의 ClusterClass 와 variable 로 topology 가 설정된 cluster 를 생성한다.
+			phases = append(phases, phase{Name: "CreateTopologyCluster", Run: r.CreateTopologyCluster})
+		} else {
+			// cluster manager 의  metadata 와 provider 정보를 template instance 의 parameter 값에 넣어 template instance 를 생성한다.
+			phases = append(phases, phase{Name: "CreateTemplateInstance", Run: r.CreateTemplateInstance})
+			// template 에 넣을 수 없는 dual-stack, service cidr 을 control plane 이 초기화되기 전에 capi cluster 에 반영한다.
+			phases = append(phases, phase{Name: "ApplyClusterNetwork", Run: r.ApplyClusterNetwork})
+		}
 		phases = append(
 			phases,
-			// cluster manager 의  metadata 와 provider 정보를 template instance 의 parameter 값에 넣어 template instance 를 생성한다.
-			r.CreateTemplateInstance,
-			// cluster manager 가 바라봐야 할 cluster 의 endpoint 를 annotation 으로 달아준다.
-			r.SetEndpoint,
+			// cluster manager 가 바라봐야 할 cluster 의 endpoint 를 status.endpoints 에 기록한다.
+			phase{Name: "SetEndpoint", Run: r.SetEndpoint},
+			// spec.paused 에 따라 capi cluster 의 reconcile 을 중지하거나 재개한다.
+			phase{Name: "PauseCluster", Run: r.PauseCluster},
 			// scaling을 roll back하는 경우, kcp와 md의 replicas를 원래대로 돌려놓는다.
-			r.KubeadmControlPlaneUpdate,
-			r.MachineDeploymentUpdate,
+			phase{Name: "KubeadmControlPlaneUpdate", Run: r.KubeadmControlPlaneUpdate},
+			phase{Name: "MachineDeploymentUpdate", Run: r.MachineDeploymentUpdate},
+			// spec.nodePools 에 따라 linux, windows node pool 의 machine deployment 를 생성하거나 삭제한다.
+			phase{Name: "ReconcileNodePools", Run: r.ReconcileNodePools},
+			// control plane 인증서가 만료되기 전에 유지보수 시간 안에서 control plane 을 rollout 한다.
+			phase{Name: "RenewControlPlaneCertificates", Run: r.RenewControlPlaneCertificates},
+			// spec.addons.auditPolicy 의 audit policy 와 log 전송 설정을 kcp 에 반영하여 control plane 을 rollout 한다.
+			phase{Name: "ApplyAuditPolicy", Run: r.ApplyAuditPolicy},
 		)
 	} else {
 		// cluster 를 등록한 경우에만 수행
@@ -164,99 +231,152 @@ func (r *ClusterManagerReconciler) reconcile(ctx context.Context, clusterManager
 		// cluster manager 에 k8s version을 업데이트 해주고,
 		// single cluster 의 nodes 를 가져와 ready 상태의 worker node 와 master node의 개수를 업데이트해준다.
 		// 또한, 해당 cluster 의 provider 이름 (Aws/Vsphere) 을 업데이트 해주는 과정을 진행한다.
-		phases = append(phases, r.UpdateClusterManagerStatus)
+		phases = append(phases, phase{Name: "UpdateClusterManagerStatus", Run: r.UpdateClusterManagerStatus})
+		// oidc 를 설정할 수 없는 cluster 를 위해 hyperauth token 을 authn broker 로 인증하는 webhook 설정을 생성한다.
+		phases = append(phases, phase{Name: "CreateAuthnWebhookConfig", Run: r.CreateAuthnWebhookConfig})
+		// operator 가 upgrade 할 수 없으므로 control plane, kubelet version 이 지원하는 version 을 벗어나면 알린다.
+		phases = append(phases, phase{Name: "CheckVersionDrift", Run: r.CheckVersionDrift})
 	}
 
 	// 공통적으로 수행
 	phases = append(
 		phases,
 		// Argocd 연동을 위해 필요한 정보를 kube-config 로 부터 가져와 secret을 생성한다.
-		r.CreateArgocdResources,
+		phase{Name: "CreateArgocdResources", Run: r.CreateArgocdResources},
+		// ApplicationSet 의 cluster generator 가 cluster 를 선택할 수 있도록 argocd cluster secret 에 label 을 동기화하고,
+		// cluster 에만 배포할 수 있는 AppProject 를 생성하여 owner, 멤버, team 을 role 에 매핑한다.
+		phase{Name: "SyncArgocdClusterSecretLabels", Run: r.SyncArgocdClusterSecretLabels},
+		phase{Name: "CreateArgocdAppProject", Run: r.CreateArgocdAppProject},
+		// node 의 providerID, label 로부터 cloud account, region 을 status 에 반영한다.
+		phase{Name: "UpdateProviderIdentity", Run: r.UpdateProviderIdentity},
+		// worker node 의 allocatable 과 pod 의 request 를 집계하여 claim 의 admission 에 사용한다.
+		phase{Name: "UpdateClusterCapacity", Run: r.UpdateClusterCapacity},
+		// spec.nodeConfigs 의 label, taint 를 remote cluster 의 node 에 적용한다.
+		phase{Name: "SyncNodeConfig", Run: r.SyncNodeConfig},
+		// spec.drain 에 따라 node 를 cordon 하고 pod 를 evict 하거나, 제거된 경우 uncordon 한다.
+		phase{Name: "DrainCluster", Run: r.DrainCluster},
+		// spec.addons.certManager 에 따라 cert-manager module 을 활성화하고 DNS01 credential 과 ClusterIssuer 를 배포한다.
+		phase{Name: "DeployCertManagerAddon", Run: r.DeployCertManagerAddon},
 		// single cluster 의 api gateway service 의 주소로 gateway service 생성
-		r.CreateGatewayResources,
+		phase{Name: "CreateGatewayResources", Run: r.CreateGatewayResources},
 		// Kibana, Grafana, Kiali 등 모듈과 HyperAuth oidc 연동을 위한 resource 생성 작업 (HyperAuth 계정정보로 여러 모듈에 로그인 가능)
 		// HyperAuth caller 를 통해 admin token 을 가져와 각 모듈 마다 HyperAuth client 를 생성후, 모듈에 따른 resource들을 추가한다.
 		// HyperRegistry를 위한 admin group 또한 생성해준다.
-		r.CreateHyperAuthResources,
+		phase{Name: "CreateHyperAuthResources", Run: r.CreateHyperAuthResources},
 		// // hyperregistry domain 을 single cluster 의 ingress 로 부터 가져와 oidc 연동설정
 		// r.SetHyperregistryOidcConfig,
 		// Traefik 을 통하기 위한 리소스인 certificate, ingress, middleware를 생성한다.
 		// 콘솔에서 ingress를 조회하여 LNB에 cluster를 listing 해주므로 cluster가 완전히 join되고 나서
 		// LNB에 리스팅 될 수 있게 해당 프로세스를 가장 마지막에 수행한다.
-		r.CreateTraefikResources,
+		phase{Name: "CreateTraefikResources", Run: r.CreateTraefikResources},
+		// cluster 가 준비된 후 cmdb 등록 등 post-create hook 을 수행한다.
+		phase{
+			Name:      "RunPostCreateHooks",
+			Run:       r.RunPostCreateHooks,
+			When:      func(clm *clusterV1alpha1.ClusterManager) bool { return clm.Status.TraefikReady },
+			DependsOn: []string{"CreateTraefikResources"},
+		},
 	)
 
 	// special case- capi upgrade/master scaling/worker scaling
-	if clusterManager.GetClusterType() == clusterV1alpha1.ClusterTypeCreated {
+	// paused 인 경우 capi 가 kcp, machine deployment 를 reconcile 하지 않으므로, 재개된 후에 수행한다.
+	if clusterManager.GetClusterType() == clusterV1alpha1.ClusterTypeCreated && !clusterManager.Spec.Paused {
 		if clusterManager.Status.GetK8SVersion() != "" && clusterManager.GetK8SVersion() != clusterManager.Status.GetK8SVersion() {
-			phases = []phaseFunc{}
-			if clusterManager.Spec.Provider == clusterV1alpha1.ProviderVSphere {
-				phases = append(phases, r.CreateUpgradeTemplateInstance)
+			phases = []phase{}
+			// topology 로 생성한 cluster 는 ClusterClass 의 template 으로 machine 을 교체하므로 upgrade template instance 가 필요 없다.
+			if clusterManager.Spec.Provider == clusterV1alpha1.ProviderVSphere && clusterManager.Spec.Topology == nil {
+				phases = append(phases, phase{Name: "CreateUpgradeTemplateInstance", Run: r.CreateUpgradeTemplateInstance})
 			}
-			phases = append(phases, r.UpgradeCluster)
+			phases = append(phases, phase{Name: "UpgradeCluster", Run: r.UpgradeCluster})
 		} else if clusterManager.Status.MasterNum != 0 && clusterManager.Spec.MasterNum != clusterManager.Status.MasterNum {
-			phases = []phaseFunc{r.ScaleControlplane}
+			phases = []phase{{Name: "ScaleControlplane", Run: r.ScaleControlplane}}
 		} else if clusterManager.Status.WorkerNum != 0 && clusterManager.Spec.WorkerNum != clusterManager.Status.WorkerNum {
-			phases = []phaseFunc{r.ScaleWorker}
+			phases = []phase{{Name: "ScaleWorker", Run: r.ScaleWorker}}
 		}
 	}
 
-	res := ctrl.Result{}
-	errs := []error{}
-	// phases 를 돌면서, append 한 함수들을 순차적으로 수행하고,
-	// error가 있는지 체크하여 error가 있으면 무조건 requeue
-	// 이때는 가장 최초로 error가 발생한 phase의 requeue after time을 따라감
-	// 모든 error를 최종적으로 aggregate하여 반환할 수 있도록 리스트로 반환
-	// error는 없지만 다시 requeue 가 되어야 하는 phase들이 존재하는 경우
-	// LowestNonZeroResult 함수를 통해 requeueAfter time 이 가장 짧은 함수를 찾는다.
-	for _, phase := range phases {
-		// Call the inner reconciliation methods.
-		phaseResult, err := phase(ctx, clusterManager)
-		if err != nil {
-			errs = append(errs, err)
-		}
-		if len(errs) > 0 {
-			continue
-		}
+	// upgrade, addon update, 인증서 갱신 전에 spec.maintenanceWindow 의 유지보수 시간 안인지 확인한다.
+	phases = append([]phase{{Name: "UpdateMaintenanceWindow", Run: r.UpdateMaintenanceWindow}}, phases...)
 
-		// Aggregate phases which requeued without err
-		res = util.LowestNonZeroResult(res, phaseResult)
+	// upgrade, scaling 중에도 kcp 와 machine deployment 의 rollout 상태를 condition 으로 반영한다.
+	if clusterManager.GetClusterType() == clusterV1alpha1.ClusterTypeCreated {
+		phases = append(phases, phase{Name: "UpdateRolloutStatus", Run: r.UpdateRolloutStatus})
 	}
 
-	return res, kerrors.NewAggregate(errs)
+	// phases 를 돌면서, append 한 함수들을 순차적으로 수행하고,
+	// error가 있는지 체크하여 error가 있으면 무조건 requeue
+	// 모든 error를 최종적으로 aggregate하여 반환
+	// error는 없지만 다시 requeue 가 되어야 하는 phase들이 존재하는 경우
+	// requeueAfter time 이 가장 짧은 결과를 따라간다.
+	return runner.Run(ctx, clusterManager, phases)
 }
 
 func (r *ClusterManagerReconciler) reconcileDelete(ctx context.Context, clusterManager *clusterV1alpha1.ClusterManager) (reconcile.Result, error) {
 	log := r.Log.WithValues("clustermanager", clusterManager.GetNamespacedName())
 	log.Info("Start reconcile phase for delete")
 
+	// data migration 등 pre-delete hook 이 끝난 후에 cluster 를 삭제한다.
+	hookRes, hookErr := r.RunPreDeleteHooks(ctx, clusterManager)
+	if hookErr == nil && !hookRes.IsZero() {
+		hookErr = errLifecycleHooksRunning
+	}
+	if err := util.IgnoreStuckFinalizerError(log, clusterManager, "RunPreDeleteHooks", hookErr); err == errLifecycleHooksRunning {
+		log.Info("Wait for pre-delete hooks before deletion")
+		return hookRes, nil
+	} else if err != nil {
+		return ctrl.Result{}, err
+	}
+
 	ARGO_APP_DELETE := os.Getenv(util.ARGO_APP_DELETE)
 	if util.IsTrue(ARGO_APP_DELETE) {
-		if err := r.DeleteApplicationRemains(clusterManager); err != nil {
-			return ctrl.Result{RequeueAfter: requeueAfter10Second}, nil
+		if err := util.IgnoreStuckFinalizerError(log, clusterManager, "DeleteApplicationRemains", r.DeleteApplicationRemains(clusterManager)); err != nil {
+			return ctrl.Result{Requeue: true}, nil
 		}
 	} else {
-		if err := r.CheckApplicationRemains(clusterManager); err != nil {
-			return ctrl.Result{RequeueAfter: requeueAfter10Second}, nil
+		if err := util.IgnoreStuckFinalizerError(log, clusterManager, "CheckApplicationRemains", r.CheckApplicationRemains(clusterManager)); err != nil {
+			return ctrl.Result{Requeue: true}, nil
 		}
 	}
 
 	// ClusterAPI-provider-aws의 경우, lb type의 svc가 남아있으면 infra nlb deletion이 stuck걸리면서 클러스터가 지워지지 않는 버그가 있음
 	// 이를 해결하기 위해 클러스터를 삭제하기 전에 lb type의 svc를 전체 삭제한 후 클러스터를 삭제
-	if err := r.DeleteLoadBalancerServices(clusterManager); err != nil {
+	if err := util.IgnoreStuckFinalizerError(log, clusterManager, "DeleteLoadBalancerServices", r.DeleteLoadBalancerServices(clusterManager)); err != nil {
 		return ctrl.Result{}, err
 	}
 
-	if err := r.DeleteIngressRoute(clusterManager); err != nil {
+	if err := util.IgnoreStuckFinalizerError(log, clusterManager, "DeleteIngressRoute", r.DeleteIngressRoute(clusterManager)); err != nil {
 		return ctrl.Result{}, err
 	}
 
-	if err := r.DeleteHyperAuthResources(clusterManager); err != nil {
+	if err := util.IgnoreStuckFinalizerError(log, clusterManager, "DeleteHyperAuthResources", r.DeleteHyperAuthResources(clusterManager)); err != nil {
 		return ctrl.Result{}, err
 	}
 
+	if err := util.IgnoreStuckFinalizerError(log, clusterManager, "DeleteAppProject", r.DeleteAppProject(clusterManager)); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	// spec.drain 이 설정된 경우 pod 를 evict 한 후에 cluster 를 삭제한다.
+	if clusterManager.Spec.Drain != nil {
+		res, err := r.DrainCluster(ctx, clusterManager)
+		if err == nil && !res.IsZero() {
+			err = errClusterDraining
+		}
+		if err := util.IgnoreStuckFinalizerError(log, clusterManager, "DrainCluster", err); err == errClusterDraining {
+			log.Info("Wait for nodes to be drained before deletion")
+			return res, nil
+		} else if err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
 	// cluster type label을 지우면 생성 타입 클러스터를 지우지 않고 분리할 수 있음
-	if clusterManager.GetClusterType() == clusterV1alpha1.ClusterTypeCreated {
+	if clusterManager.GetClusterType() == clusterV1alpha1.ClusterTypeCreated && clusterManager.Spec.Topology != nil {
+		if err := r.deleteTopologyCluster(clusterManager); err != nil {
+			log.Error(err, "Failed to delete topology cluster")
+			return ctrl.Result{}, err
+		}
+	} else if clusterManager.GetClusterType() == clusterV1alpha1.ClusterTypeCreated {
 		// delete templateinstance
 		key := types.NamespacedName{
 			Name:      clusterManager.Name + "-" + clusterManager.Annotations[clusterV1alpha1.AnnotationKeyClmSuffix],
@@ -280,12 +400,8 @@ func (r *ClusterManagerReconciler) reconcileDelete(ctx context.Context, clusterM
 	// capi가 생성한 kubeconfig는 template instance를 지우면서 삭제되었으므로, registration으로 생성한 경우 또한 kubeconfig를 이 시점에서 삭제한다.
 	// kubeconfig가 없으면 skip 한다.
 	if clusterManager.GetClusterType() == clusterV1alpha1.ClusterTypeRegistered {
-		key := types.NamespacedName{
-			Name:      clusterManager.Name + util.KubeconfigSuffix,
-			Namespace: clusterManager.Namespace,
-		}
-		regKubeconfigSecret := &coreV1.Secret{}
-		if err := r.Client.Get(context.TODO(), key, regKubeconfigSecret); errors.IsNotFound(err) {
+		regKubeconfigSecret, err := util.GetKubeconfigSecret(context.TODO(), r.Client, clusterManager.Namespace, clusterManager.Name)
+		if errors.IsNotFound(err) {
 			log.Info("Kubeconfig secret for cluster registration was deleted successfully")
 		} else if err != nil {
 			log.Error(err, "Failed to get kubeconfig secret for cluster registration")
@@ -301,19 +417,19 @@ func (r *ClusterManagerReconciler) reconcileDelete(ctx context.Context, clusterM
 
 	//delete handling
 	key := clusterManager.GetNamespacedName()
-	err := r.Client.Get(context.TODO(), key, &capiV1alpha3.Cluster{})
+	err := r.Client.Get(context.TODO(), key, &capiV1beta1.Cluster{})
 	if errors.IsNotFound(err) {
-		if err := util.Delete(clusterManager.Namespace, clusterManager.Name); err != nil {
+		if err := util.IgnoreStuckFinalizerError(log, clusterManager, "DeleteClusterMember", util.DeleteOrQueue(ctx, r.Client, clusterManager.Namespace, clusterManager.Name)); err != nil {
 			log.Error(err, "Failed to delete cluster info from cluster_member table")
 			return ctrl.Result{}, err
 		}
 		// kubeconfig secret이 없다면(모든 시크릿이 삭제되었다면) clm을 삭제한다.
-		key = types.NamespacedName{
-			Name:      clusterManager.Name + util.KubeconfigSuffix,
-			Namespace: clusterManager.Namespace,
-		}
-		if err := r.Client.Get(context.TODO(), key, &coreV1.Secret{}); errors.IsNotFound(err) {
+		if _, err := util.GetKubeconfigSecret(context.TODO(), r.Client, clusterManager.Namespace, clusterManager.Name); errors.IsNotFound(err) {
 			controllerutil.RemoveFinalizer(clusterManager, clusterV1alpha1.ClusterManagerFinalizer)
+			util.RemoveRemoteCluster(clusterManager.Namespace, clusterManager.Name)
+			r.recordAudit(clusterManager, clusterV1alpha1.ClusterAuditActionClusterDeleted, "", "Cluster manager was deleted")
+			r.pushClusterEvent(clusterManager, util.ClusterEventTypeClusterDeleted)
+			r.notify(clusterManager, clusterV1alpha1.NotificationEventClusterDeleted, "Cluster manager was deleted")
 			log.Info("Cluster manager was deleted successfully")
 			// 끝
 			return ctrl.Result{}, nil
@@ -331,17 +447,20 @@ func (r *ClusterManagerReconciler) reconcileDelete(ctx context.Context, clusterM
 	if !(clusterManager.GetClusterType() == clusterV1alpha1.ClusterTypeCreated ||
 		clusterManager.GetClusterType() == clusterV1alpha1.ClusterTypeRegistered) {
 		log.Info("This cluster type is not created or registered")
-		if err := util.Delete(clusterManager.Namespace, clusterManager.Name); err != nil {
+		if err := util.IgnoreStuckFinalizerError(log, clusterManager, "DeleteClusterMember", util.DeleteOrQueue(ctx, r.Client, clusterManager.Namespace, clusterManager.Name)); err != nil {
 			log.Error(err, "Failed to delete cluster info from cluster_member table")
 			return ctrl.Result{}, err
 		}
 		controllerutil.RemoveFinalizer(clusterManager, clusterV1alpha1.ClusterManagerFinalizer)
+		r.recordAudit(clusterManager, clusterV1alpha1.ClusterAuditActionClusterDeleted, "", "Cluster manager was detached")
+		r.pushClusterEvent(clusterManager, util.ClusterEventTypeClusterDeleted)
+		r.notify(clusterManager, clusterV1alpha1.NotificationEventClusterDeleted, "Cluster manager was detached")
 		log.Info("Cluster manager was deleted successfully")
 		return ctrl.Result{}, nil
 	}
 
-	log.Info("Cluster is deleting. Requeue after 1min")
-	return ctrl.Result{RequeueAfter: requeueAfter1Minute}, nil
+	log.Info("Cluster is deleting")
+	return ctrl.Result{Requeue: true}, nil
 }
 
 func (r *ClusterManagerReconciler) reconcilePhase(_ context.Context, clusterManager *clusterV1alpha1.ClusterManager) {
@@ -363,8 +482,11 @@ func (r *ClusterManagerReconciler) reconcilePhase(_ context.Context, clusterMana
 		clusterManager.Status.SetTypedPhase(clusterV1alpha1.ClusterManagerPhaseProcessing)
 	}
 
+	// post-create hook 이 끝날 때까지 Ready 로 변경하지 않는다.
 	if clusterManager.Status.TraefikReady {
-		clusterManager.Status.SetTypedPhase(clusterV1alpha1.ClusterManagerPhaseReady)
+		if !meta.IsStatusConditionFalse(clusterManager.Status.Conditions, clusterV1alpha1.ClusterManagerConditionPostCreateHooksSucceeded) {
+			clusterManager.Status.SetTypedPhase(clusterV1alpha1.ClusterManagerPhaseReady)
+		}
 	}
 
 	// cluster scaling
@@ -384,6 +506,7 @@ func (r *ClusterManagerReconciler) reconcilePhase(_ context.Context, clusterMana
 func (r *ClusterManagerReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	controller, err := ctrl.NewControllerManagedBy(mgr).
 		For(&clusterV1alpha1.ClusterManager{}).
+		WithOptions(util.DefaultControllerOptions()).
 		WithEventFilter(
 			predicate.Funcs{
 				CreateFunc: func(e event.CreateEvent) bool {
@@ -405,11 +528,21 @@ func (r *ClusterManagerReconciler) SetupWithManager(mgr ctrl.Manager) error {
 					isUpgrade := oldclm.GetK8SVersion() != "" && oldclm.GetK8SVersion() != newclm.GetK8SVersion()
 					isScaling := oldclm.Spec.MasterNum != newclm.Spec.MasterNum ||
 						oldclm.Spec.WorkerNum != newclm.Spec.WorkerNum
-					if isDelete || isControlPlaneEndpointUpdate || isFinalized || isUpgrade || isScaling {
+					// argocd cluster secret 의 label 과 AppProject 를 갱신해야 한다.
+					isArgoUpdate := !reflect.DeepEqual(oldclm.Labels, newclm.Labels) ||
+						oldclm.Annotations[util.AnnotationKeyTeam] != newclm.Annotations[util.AnnotationKeyTeam] ||
+						oldclm.Annotations[util.AnnotationKeyOwner] != newclm.Annotations[util.AnnotationKeyOwner]
+					// 등록한 cluster 도 node 의 label, taint 를 관리한다.
+					isNodeConfigUpdate := !reflect.DeepEqual(oldclm.Spec.NodeConfigs, newclm.Spec.NodeConfigs)
+					isAddonUpdate := !reflect.DeepEqual(oldclm.Spec.Addons, newclm.Spec.Addons)
+					if isDelete || isControlPlaneEndpointUpdate || isFinalized || isUpgrade || isScaling || isArgoUpdate || isNodeConfigUpdate || isAddonUpdate {
 						return true
 					} else {
+						// heartbeat 등 status 만 변경된 경우는 무시한다.
+						// control plane 의 준비 상태는 capi cluster 를 watch 하여 status 에만 반영되므로 따로 확인한다.
 						if newclm.GetClusterType() == clusterV1alpha1.ClusterTypeCreated {
-							return true
+							isControlPlaneReadyUpdate := oldclm.Status.ControlPlaneReady != newclm.Status.ControlPlaneReady
+							return util.IsSpecChanged(oldclm, newclm) || isControlPlaneReadyUpdate || isSubResourceNotReady
 						}
 						if newclm.GetClusterType() == clusterV1alpha1.ClusterTypeRegistered {
 							return isSubResourceNotReady
@@ -425,21 +558,22 @@ func (r *ClusterManagerReconciler) SetupWithManager(mgr ctrl.Manager) error {
 				},
 			},
 		).
-		Build(r)
+		Build(util.ShardReconciler(mgr.GetClient(), r))
 
 	if err != nil {
 		return err
 	}
 
 	controller.Watch(
-		&source.Kind{Type: &capiV1alpha3.Cluster{}},
+		&source.Kind{Type: &capiV1beta1.Cluster{}},
 		handler.EnqueueRequestsFromMapFunc(r.requeueClusterManagersForCluster),
 		predicate.Funcs{
 			UpdateFunc: func(e event.UpdateEvent) bool {
-				oldc := e.ObjectOld.(*capiV1alpha3.Cluster)
-				newc := e.ObjectNew.(*capiV1alpha3.Cluster)
+				oldc := e.ObjectOld.(*capiV1beta1.Cluster)
+				newc := e.ObjectNew.(*capiV1beta1.Cluster)
 
-				return !oldc.Status.ControlPlaneInitialized && newc.Status.ControlPlaneInitialized
+				return !conditions.IsTrue(oldc, capiV1beta1.ControlPlaneInitializedCondition) &&
+					conditions.IsTrue(newc, capiV1beta1.ControlPlaneInitializedCondition)
 			},
 			CreateFunc: func(e event.CreateEvent) bool {
 				return false
@@ -462,13 +596,17 @@ func (r *ClusterManagerReconciler) SetupWithManager(mgr ctrl.Manager) error {
 				newKcp := e.ObjectNew.(*controlplanev1.KubeadmControlPlane)
 				// kcp status가 변경된 경우, cluster manager status에 반영
 				replicaStatusChanged := oldKcp.Status.ReadyReplicas != newKcp.Status.ReadyReplicas
+				// rollout 상태가 변경된 경우, cluster manager condition에 반영
+				rolloutChanged := oldKcp.Status.UpdatedReplicas != newKcp.Status.UpdatedReplicas ||
+					oldKcp.Status.UnavailableReplicas != newKcp.Status.UnavailableReplicas ||
+					!reflect.DeepEqual(oldKcp.Status.Version, newKcp.Status.Version)
 				// kcp replica가 임의로 변경된 경우, cluster manager spec을 조회하여 원래 상태로 복구
 				replicaChanged := false
 				if oldKcp.Spec.Replicas != nil && newKcp.Spec.Replicas != nil {
 					replicaChanged = *oldKcp.Spec.Replicas != *newKcp.Spec.Replicas
 				}
 
-				return replicaStatusChanged || replicaChanged
+				return replicaStatusChanged || replicaChanged || rolloutChanged
 			},
 			CreateFunc: func(e event.CreateEvent) bool {
 				return true
@@ -483,21 +621,24 @@ func (r *ClusterManagerReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	)
 
 	controller.Watch(
-		&source.Kind{Type: &capiV1alpha3.MachineDeployment{}},
+		&source.Kind{Type: &capiV1beta1.MachineDeployment{}},
 		handler.EnqueueRequestsFromMapFunc(r.requeueClusterManagersForMachineDeployment),
 		predicate.Funcs{
 			UpdateFunc: func(e event.UpdateEvent) bool {
-				oldMd := e.ObjectOld.(*capiV1alpha3.MachineDeployment)
-				newMd := e.ObjectNew.(*capiV1alpha3.MachineDeployment)
+				oldMd := e.ObjectOld.(*capiV1beta1.MachineDeployment)
+				newMd := e.ObjectNew.(*capiV1beta1.MachineDeployment)
 				// md status가 변경된 경우, cluster manager status에 반영
 				replicaStatusChanged := oldMd.Status.ReadyReplicas != newMd.Status.ReadyReplicas
+				// rollout 상태가 변경된 경우, cluster manager condition에 반영
+				rolloutChanged := oldMd.Status.UpdatedReplicas != newMd.Status.UpdatedReplicas ||
+					oldMd.Status.UnavailableReplicas != newMd.Status.UnavailableReplicas
 				// md replica가 임의로 변경된 경우, cluster manager spec을 조회하여 원래 상태로 복구
 				replicaChanged := false
 				if oldMd.Spec.Replicas != nil && newMd.Spec.Replicas != nil {
 					replicaChanged = *oldMd.Spec.Replicas != *newMd.Spec.Replicas
 				}
 
-				return replicaStatusChanged || replicaChanged
+				return replicaStatusChanged || replicaChanged || rolloutChanged
 			},
 			CreateFunc: func(e event.CreateEvent) bool {
 				return true
@@ -511,6 +652,31 @@ func (r *ClusterManagerReconciler) SetupWithManager(mgr ctrl.Manager) error {
 		},
 	)
 
+	// 멤버의 초대 수락, role 변경, 삭제를 AppProject 의 role 에 반영한다.
+	controller.Watch(
+		&source.Kind{Type: &clusterV1alpha1.ClusterMember{}},
+		handler.EnqueueRequestsFromMapFunc(r.requeueClusterManagersForClusterMember),
+		predicate.Funcs{
+			UpdateFunc: func(e event.UpdateEvent) bool {
+				oldMember := e.ObjectOld.(*clusterV1alpha1.ClusterMember)
+				newMember := e.ObjectNew.(*clusterV1alpha1.ClusterMember)
+				return oldMember.Spec.Accepted != newMember.Spec.Accepted ||
+					oldMember.Spec.Role != newMember.Spec.Role ||
+					oldMember.Labels[clusterV1alpha1.LabelKeyClmName] != newMember.Labels[clusterV1alpha1.LabelKeyClmName] ||
+					oldMember.DeletionTimestamp.IsZero() != newMember.DeletionTimestamp.IsZero()
+			},
+			CreateFunc: func(e event.CreateEvent) bool {
+				return false
+			},
+			DeleteFunc: func(e event.DeleteEvent) bool {
+				return true
+			},
+			GenericFunc: func(e event.GenericEvent) bool {
+				return false
+			},
+		},
+	)
+
 	subResources := []client.Object{
 		&certmanagerV1.Certificate{},
 		&networkingv1.Ingress{},
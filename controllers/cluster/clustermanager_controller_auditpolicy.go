@@ -0,0 +1,214 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	clusterV1alpha1 "github.com/tmax-cloud/hypercloud-multi-operator/apis/cluster/v1alpha1"
+	util "github.com/tmax-cloud/hypercloud-multi-operator/controllers/util"
+
+	coreV1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+// ApplyAuditPolicy 는 spec.addons.auditPolicy 의 audit policy 와 webhook backend 설정을 secret 으로 생성하고,
+// kubeadm control plane 의 kubeadm config 에 kube-apiserver 의 audit 설정을 추가하여 control plane 을 rollout 한다.
+// control plane machine 이 교체되므로 유지보수 시간에만 반영하며, addon 이 제거되면 audit 설정을 되돌린다.
+// topology 로 생성한 cluster 의 kubeadm control plane 은 topology controller 가 관리하므로 ClusterClass 의 patch 로 설정해야 한다.
+func (r *ClusterManagerReconciler) ApplyAuditPolicy(ctx context.Context, clusterManager *clusterV1alpha1.ClusterManager) (ctrl.Result, error) {
+	auditPolicy := clusterManager.GetAuditPolicyAddon()
+	// 한 번도 적용한 적이 없으면 되돌릴 설정도 없다.
+	applied := meta.FindStatusCondition(clusterManager.Status.Conditions, clusterV1alpha1.ClusterManagerConditionAuditPolicyApplied)
+	if auditPolicy == nil && applied == nil {
+		return ctrl.Result{}, nil
+	}
+	if !clusterManager.Status.ControlPlaneReady || clusterManager.Spec.Paused {
+		return ctrl.Result{}, nil
+	}
+
+	log := r.Log.WithValues("clustermanager", clusterManager.GetNamespacedName())
+	log.Info("Start to reconcile phase for ApplyAuditPolicy")
+
+	setCondition := func(status metav1.ConditionStatus, reason, message string) {
+		meta.SetStatusCondition(&clusterManager.Status.Conditions, metav1.Condition{
+			Type:               clusterV1alpha1.ClusterManagerConditionAuditPolicyApplied,
+			Status:             status,
+			Reason:             reason,
+			Message:            message,
+			ObservedGeneration: clusterManager.Generation,
+		})
+	}
+
+	cluster, err := r.GetCapiCluster(clusterManager)
+	if errors.IsNotFound(err) {
+		return ctrl.Result{}, nil
+	} else if err != nil {
+		log.Error(err, "Failed to get cluster")
+		return ctrl.Result{}, err
+	}
+	if isTopologyManaged(cluster) {
+		setCondition(metav1.ConditionFalse, "TopologyManaged", "audit policy of the topology cluster must be set by the patches of the ClusterClass")
+		return ctrl.Result{}, nil
+	}
+	kcp, err := r.GetKubeadmControlPlane(cluster)
+	if errors.IsNotFound(err) {
+		return ctrl.Result{}, nil
+	} else if err != nil {
+		log.Error(err, "Failed to get kubeadmcontrolplane")
+		return ctrl.Result{}, err
+	}
+
+	desired := kcp.DeepCopy()
+	hash := ""
+	if auditPolicy != nil {
+		data, err := r.getAuditPolicySecretData(ctx, clusterManager, auditPolicy)
+		if err != nil {
+			log.Error(err, "Failed to render audit policy")
+			setCondition(metav1.ConditionFalse, "InvalidConfig", err.Error())
+			return ctrl.Result{}, err
+		}
+		if err := r.createAuditPolicySecret(ctx, clusterManager, data); err != nil {
+			log.Error(err, "Failed to create audit policy secret")
+			setCondition(metav1.ConditionFalse, "SecretFailed", err.Error())
+			return ctrl.Result{}, err
+		}
+		hash = util.AuditPolicyHash(data)
+		util.SetAuditPolicy(&desired.Spec.KubeadmConfigSpec, clusterManager.Name+util.AuditPolicySecretSuffix, auditPolicy)
+	} else {
+		util.RemoveAuditPolicy(&desired.Spec.KubeadmConfigSpec)
+	}
+
+	specChanged := !reflect.DeepEqual(kcp.Spec.KubeadmConfigSpec, desired.Spec.KubeadmConfigSpec)
+	hashChanged := kcp.Annotations[util.AnnotationKeyAuditPolicyHash] != hash
+	if specChanged || hashChanged {
+		if !inMaintenanceWindow(clusterManager) {
+			setCondition(metav1.ConditionFalse, "WaitingForMaintenanceWindow", "the control plane is rolled out in the maintenance window")
+			return r.waitForMaintenanceWindow(clusterManager, "audit policy rollout"), nil
+		}
+
+		if hash == "" {
+			delete(desired.Annotations, util.AnnotationKeyAuditPolicyHash)
+		} else {
+			if desired.Annotations == nil {
+				desired.Annotations = map[string]string{}
+			}
+			desired.Annotations[util.AnnotationKeyAuditPolicyHash] = hash
+		}
+		// policy 의 내용만 바뀐 경우 kubeadm config 가 그대로이므로 직접 rollout 을 요청한다.
+		if !specChanged && auditPolicy != nil {
+			now := metav1.Now()
+			desired.Spec.RolloutAfter = &now
+		}
+		if err := r.Update(context.TODO(), desired); err != nil {
+			log.Error(err, "Failed to update audit policy of kubeadmcontrolplane")
+			setCondition(metav1.ConditionFalse, "UpdateFailed", err.Error())
+			return ctrl.Result{}, err
+		}
+		log.Info("Requested rollout of controlplane to apply audit policy", "hash", hash)
+		r.Recorder.Event(clusterManager, coreV1.EventTypeNormal, "AuditPolicyRollout", "Rolling out the control plane to apply the audit policy")
+		setCondition(metav1.ConditionFalse, rolloutReasonRollingOut, "rolling out the control plane")
+		return util.RequeueAfterWithJitter(getClusterResyncPeriod(clusterManager)), nil
+	}
+
+	// 모든 control plane machine 이 교체될 때까지 기다린다.
+	rollout := getControlPlaneReplicas(kcp).condition(clusterV1alpha1.ClusterManagerConditionAuditPolicyApplied)
+	if kcp.Status.ObservedGeneration != kcp.Generation || rollout.Status != metav1.ConditionTrue {
+		setCondition(metav1.ConditionFalse, rolloutReasonRollingOut, rollout.Message)
+		return util.RequeueAfterWithJitter(getClusterResyncPeriod(clusterManager)), nil
+	}
+
+	if auditPolicy == nil {
+		secret := &coreV1.Secret{}
+		secret.Name = clusterManager.Name + util.AuditPolicySecretSuffix
+		secret.Namespace = clusterManager.Namespace
+		if err := r.Client.Delete(context.TODO(), secret); err != nil && !errors.IsNotFound(err) {
+			log.Error(err, "Failed to delete audit policy secret")
+			return ctrl.Result{}, err
+		}
+		meta.RemoveStatusCondition(&clusterManager.Status.Conditions, clusterV1alpha1.ClusterManagerConditionAuditPolicyApplied)
+		log.Info("Removed audit policy from controlplane successfully")
+		return ctrl.Result{}, nil
+	}
+
+	setCondition(metav1.ConditionTrue, "Applied", "audit policy "+hash+" is applied to all control plane machines")
+	return ctrl.Result{}, nil
+}
+
+// policy configmap 과 webhook secret 으로 control plane node 에 내려받을 audit 설정 file 들을 만든다.
+func (r *ClusterManagerReconciler) getAuditPolicySecretData(ctx context.Context, clusterManager *clusterV1alpha1.ClusterManager, auditPolicy *clusterV1alpha1.AuditPolicyAddon) (map[string][]byte, error) {
+	data := map[string][]byte{
+		util.AuditPolicyKey: []byte(util.StandardAuditPolicy),
+	}
+
+	if auditPolicy.PolicyConfigMapName != "" {
+		cm := &coreV1.ConfigMap{}
+		key := types.NamespacedName{Name: auditPolicy.PolicyConfigMapName, Namespace: clusterManager.Namespace}
+		if err := r.Client.Get(ctx, key, cm); err != nil {
+			return nil, err
+		}
+		policy, ok := cm.Data[util.AuditPolicyKey]
+		if !ok {
+			return nil, fmt.Errorf("configmap %s does not have %s", key.Name, util.AuditPolicyKey)
+		}
+		data[util.AuditPolicyKey] = []byte(policy)
+	}
+
+	if auditPolicy.Webhook != nil {
+		secret := &coreV1.Secret{}
+		key := types.NamespacedName{Name: auditPolicy.Webhook.SecretName, Namespace: clusterManager.Namespace}
+		if err := r.Client.Get(ctx, key, secret); err != nil {
+			return nil, err
+		}
+		url := string(secret.Data[util.AuditWebhookURLKey])
+		if url == "" {
+			return nil, fmt.Errorf("secret %s does not have %s", key.Name, util.AuditWebhookURLKey)
+		}
+		config, err := util.NewAuditWebhookConfig(url, secret.Data[util.AuditWebhookCAKey])
+		if err != nil {
+			return nil, err
+		}
+		data[util.AuditWebhookConfigKey] = config
+	}
+	return data, nil
+}
+
+func (r *ClusterManagerReconciler) createAuditPolicySecret(ctx context.Context, clusterManager *clusterV1alpha1.ClusterManager, data map[string][]byte) error {
+	secret := &coreV1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      clusterManager.Name + util.AuditPolicySecretSuffix,
+			Namespace: clusterManager.Namespace,
+		},
+	}
+	_, err := controllerutil.CreateOrUpdate(ctx, r.Client, secret, func() error {
+		if secret.Labels == nil {
+			secret.Labels = map[string]string{}
+		}
+		secret.Labels[util.LabelKeyClmSecretType] = util.ClmSecretTypeAuditPolicy
+		secret.Labels[clusterV1alpha1.LabelKeyClmName] = clusterManager.Name
+		secret.Labels[clusterV1alpha1.LabelKeyClmNamespace] = clusterManager.Namespace
+		secret.Data = data
+		return ctrl.SetControllerReference(clusterManager, secret, r.Scheme)
+	})
+	return err
+}
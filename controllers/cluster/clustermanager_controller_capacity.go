@@ -0,0 +1,64 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"time"
+
+	clusterV1alpha1 "github.com/tmax-cloud/hypercloud-multi-operator/apis/cluster/v1alpha1"
+	"github.com/tmax-cloud/hypercloud-multi-operator/controllers/util"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+// 모든 pod 를 조회하므로 resync 주기와 관계없이 이 간격보다 자주 집계하지 않는다.
+const clusterCapacityRefreshInterval = 5 * time.Minute
+
+// UpdateClusterCapacity 는 remote cluster 의 worker node 의 allocatable 과 pod 의 request 를 합산하여 status 에 반영한다.
+// claim webhook 이 기존 cluster 를 대상으로 하는 claim 을 승인하기 전에 여유 resource 를 확인하는 데 사용한다.
+func (r *ClusterManagerReconciler) UpdateClusterCapacity(ctx context.Context, clusterManager *clusterV1alpha1.ClusterManager) (ctrl.Result, error) {
+	if !clusterManager.Status.ControlPlaneReady {
+		return ctrl.Result{}, nil
+	}
+	if capacity := clusterManager.Status.Capacity; capacity != nil && capacity.LastUpdateTime != nil &&
+		time.Since(capacity.LastUpdateTime.Time) < clusterCapacityRefreshInterval {
+		return ctrl.Result{}, nil
+	}
+
+	log := r.Log.WithValues("clustermanager", clusterManager.GetNamespacedName())
+	log.Info("Start to reconcile phase for UpdateClusterCapacity")
+
+	// limited kubeconfig 는 pod 를 조회할 수 없으므로 admin kubeconfig 를 사용한다.
+	remoteClientset, err := r.remoteClients().ClientsetForCluster(ctx, r.Client, clusterManager.Namespace, clusterManager.Name, util.CredentialAdmin)
+	if errors.IsNotFound(err) {
+		log.Info("kubeconfig secret is not found")
+		return ctrl.Result{Requeue: true}, nil
+	} else if err != nil {
+		log.Error(err, "Failed to get remoteK8sClient")
+		return ctrl.Result{}, err
+	}
+
+	capacity, err := util.AggregateClusterCapacity(ctx, remoteClientset)
+	if err != nil {
+		log.Error(err, "Failed to aggregate capacity of remote cluster")
+		return ctrl.Result{}, err
+	}
+	clusterManager.Status.Capacity = capacity
+
+	return util.RequeueAfterWithJitter(clusterCapacityRefreshInterval), nil
+}
@@ -0,0 +1,214 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+
+	clusterV1alpha1 "github.com/tmax-cloud/hypercloud-multi-operator/apis/cluster/v1alpha1"
+	util "github.com/tmax-cloud/hypercloud-multi-operator/controllers/util"
+
+	coreV1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// dns provider 별로 member cluster 에 복제하는 credential key
+// secret 의 나머지 key 는 복제하지 않는다.
+var dns01CredentialKeys = map[string][]string{
+	clusterV1alpha1.CertManagerDNS01ProviderRoute53:    {"access-key-id", "secret-access-key", "region"},
+	clusterV1alpha1.CertManagerDNS01ProviderCloudflare: {"api-token"},
+}
+
+func getCertManagerACMEServer() string {
+	if server := os.Getenv(util.CERT_MANAGER_ACME_SERVER); server != "" {
+		return server
+	}
+	return util.CertManagerACMEServerDefault
+}
+
+// argocd application 의 cert-manager module 활성화 parameter 를 spec 에 맞춘다.
+func (r *ClusterManagerReconciler) syncCertManagerApplication(clusterManager *clusterV1alpha1.ClusterManager) error {
+	return setApplicationHelmParameters(r.Client, clusterManager, map[string]string{
+		util.ArgoParameterCertManagerEnable: strconv.FormatBool(clusterManager.GetCertManagerAddon() != nil),
+	})
+}
+
+// dns provider 의 credential 중 solver 가 사용하는 key 만 member cluster 의 cert-manager namespace 에 복제하고,
+// 복제한 credential 을 반환한다.
+func (r *ClusterManagerReconciler) propagateDNS01Credentials(clusterManager *clusterV1alpha1.ClusterManager, remoteClientset *kubernetes.Clientset) (map[string][]byte, error) {
+	dns01 := clusterManager.GetCertManagerAddon().DNS01
+
+	source := &coreV1.Secret{}
+	key := types.NamespacedName{
+		Name:      dns01.CredentialsSecretName,
+		Namespace: clusterManager.Namespace,
+	}
+	if err := r.Client.Get(context.TODO(), key, source); err != nil {
+		return nil, err
+	}
+	data := map[string][]byte{}
+	for _, credentialKey := range dns01CredentialKeys[dns01.Provider] {
+		value, ok := source.Data[credentialKey]
+		if !ok {
+			return nil, fmt.Errorf("secret %s does not have %s key", dns01.CredentialsSecretName, credentialKey)
+		}
+		data[credentialKey] = value
+	}
+
+	if err := util.EnsureRemoteNamespace(remoteClientset, util.CertManagerNamespace); err != nil {
+		return nil, err
+	}
+	secrets := remoteClientset.CoreV1().Secrets(util.CertManagerNamespace)
+	existSecret, err := secrets.Get(context.TODO(), util.CertManagerCredentialsSecret, metav1.GetOptions{})
+	if errors.IsNotFound(err) {
+		secret := &coreV1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      util.CertManagerCredentialsSecret,
+				Namespace: util.CertManagerNamespace,
+			},
+			Type: coreV1.SecretTypeOpaque,
+			Data: data,
+		}
+		util.SetRemoteOwnerLabels(secret, certManagerRemoteOwner(clusterManager))
+		_, err := secrets.Create(context.TODO(), secret, metav1.CreateOptions{})
+		return data, err
+	} else if err != nil {
+		return nil, err
+	}
+
+	// credential 이 rotate 되었거나 owner label 이 없는 경우에만 갱신한다.
+	if _, ok := util.GetRemoteOwner(existSecret); ok && reflect.DeepEqual(existSecret.Data, data) {
+		return data, nil
+	}
+	existSecret.Data = data
+	util.SetRemoteOwnerLabels(existSecret, certManagerRemoteOwner(clusterManager))
+	_, err = secrets.Update(context.TODO(), existSecret, metav1.UpdateOptions{})
+	return data, err
+}
+
+// HC_DOMAIN 과 그 subdomain 의 인증서를 DNS01 challenge 로 발급하는 ClusterIssuer
+// route53 의 region 은 secret ref 로 지정할 수 없으므로 credential 에서 읽어 설정한다.
+func newCertManagerClusterIssuer(clusterManager *clusterV1alpha1.ClusterManager, credentials map[string][]byte) *unstructured.Unstructured {
+	certManager := clusterManager.GetCertManagerAddon()
+	secretRef := func(key string) map[string]interface{} {
+		return map[string]interface{}{
+			"name": util.CertManagerCredentialsSecret,
+			"key":  key,
+		}
+	}
+
+	var solver map[string]interface{}
+	switch certManager.DNS01.Provider {
+	case clusterV1alpha1.CertManagerDNS01ProviderRoute53:
+		solver = map[string]interface{}{
+			"route53": map[string]interface{}{
+				"region":                   string(credentials["region"]),
+				"accessKeyIDSecretRef":     secretRef("access-key-id"),
+				"secretAccessKeySecretRef": secretRef("secret-access-key"),
+			},
+		}
+	case clusterV1alpha1.CertManagerDNS01ProviderCloudflare:
+		solver = map[string]interface{}{
+			"cloudflare": map[string]interface{}{
+				"apiTokenSecretRef": secretRef("api-token"),
+			},
+		}
+	}
+
+	issuer := &unstructured.Unstructured{}
+	issuer.SetAPIVersion("cert-manager.io/v1")
+	issuer.SetKind("ClusterIssuer")
+	issuer.SetName(util.CertManagerClusterIssuer)
+	util.SetRemoteOwnerLabels(issuer, certManagerRemoteOwner(clusterManager))
+	_ = unstructured.SetNestedMap(issuer.Object, map[string]interface{}{
+		"server": getCertManagerACMEServer(),
+		"email":  certManager.Email,
+		"privateKeySecretRef": map[string]interface{}{
+			"name": util.CertManagerAccountKeySecret,
+		},
+		"solvers": []interface{}{
+			map[string]interface{}{
+				"selector": map[string]interface{}{
+					"dnsZones": []interface{}{os.Getenv(util.HC_DOMAIN)},
+				},
+				"dns01": solver,
+			},
+		},
+	}, "spec", "acme")
+	return issuer
+}
+
+// ClusterIssuer 를 생성하거나 spec 과 owner label 을 갱신한다.
+// cert-manager 가 아직 설치되지 않아 crd 가 없으면 false 를 반환한다.
+func applyCertManagerClusterIssuer(remoteClient client.Client, issuer *unstructured.Unstructured) (bool, error) {
+	exist := &unstructured.Unstructured{}
+	exist.SetGroupVersionKind(issuer.GroupVersionKind())
+	err := remoteClient.Get(context.TODO(), client.ObjectKeyFromObject(issuer), exist)
+	if meta.IsNoMatchError(err) {
+		return false, nil
+	} else if errors.IsNotFound(err) {
+		return true, remoteClient.Create(context.TODO(), issuer)
+	} else if err != nil {
+		return false, err
+	}
+
+	if _, ok := util.GetRemoteOwner(exist); ok && reflect.DeepEqual(exist.Object["spec"], issuer.Object["spec"]) {
+		return true, nil
+	}
+	exist.Object["spec"] = issuer.Object["spec"]
+	owner, _ := util.GetRemoteOwner(issuer)
+	util.SetRemoteOwnerLabels(exist, owner)
+	return true, remoteClient.Update(context.TODO(), exist)
+}
+
+func certManagerRemoteOwner(clusterManager *clusterV1alpha1.ClusterManager) util.RemoteOwner {
+	return util.RemoteOwner{
+		Kind:      util.RemoteOwnerKindCertManagerAddon,
+		Namespace: clusterManager.Namespace,
+		Name:      clusterManager.Name,
+	}
+}
+
+// cert-manager addon 이 제거되면 member cluster 의 ClusterIssuer 와 credential secret 을 삭제한다.
+// cert-manager 자체는 argocd application 에서 module 이 비활성화되면서 삭제된다.
+func deleteCertManagerResources(remoteClient client.Client, remoteClientset *kubernetes.Clientset) error {
+	issuer := &unstructured.Unstructured{}
+	issuer.SetAPIVersion("cert-manager.io/v1")
+	issuer.SetKind("ClusterIssuer")
+	issuer.SetName(util.CertManagerClusterIssuer)
+	if err := remoteClient.Delete(context.TODO(), issuer); err != nil && !errors.IsNotFound(err) && !meta.IsNoMatchError(err) {
+		return err
+	}
+
+	err := remoteClientset.
+		CoreV1().
+		Secrets(util.CertManagerNamespace).
+		Delete(context.TODO(), util.CertManagerCredentialsSecret, metav1.DeleteOptions{})
+	if err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,199 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	clusterV1alpha1 "github.com/tmax-cloud/hypercloud-multi-operator/apis/cluster/v1alpha1"
+	"github.com/tmax-cloud/hypercloud-multi-operator/controllers/util"
+
+	coreV1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+// pod 가 evict 되었는지 다시 확인하는 간격
+const drainPollInterval = 10 * time.Second
+
+// 삭제 전에 drain 이 끝나기를 기다리는 중임을 나타낸다.
+var errClusterDraining = fmt.Errorf("cluster is draining")
+
+// DrainCluster 는 spec.drain 에 따라 remote cluster 의 모든 node 를 cordon 하고 pod 를 evict 한다.
+// eviction api 를 사용하므로 PodDisruptionBudget 에 의해 거부된 pod 는 timeout 까지 다시 시도한다.
+// drain 이 끝나거나 timeout 된 후에는 spec.drain 을 제거하기 전까지 다시 수행하지 않으며,
+// spec.drain 이 제거되면 drain 으로 cordon 한 node 를 uncordon 한다.
+func (r *ClusterManagerReconciler) DrainCluster(ctx context.Context, clusterManager *clusterV1alpha1.ClusterManager) (ctrl.Result, error) {
+	drain := clusterManager.Spec.Drain
+	status := clusterManager.Status.Drain
+	if drain == nil && status == nil {
+		return ctrl.Result{}, nil
+	}
+	if drain != nil && status != nil && status.Phase != clusterV1alpha1.ClusterDrainPhaseDraining {
+		return ctrl.Result{}, nil
+	}
+	if !clusterManager.Status.ControlPlaneReady {
+		return ctrl.Result{}, nil
+	}
+
+	log := r.Log.WithValues("clustermanager", clusterManager.GetNamespacedName())
+	log.Info("Start to reconcile phase for DrainCluster")
+
+	// node 를 변경하므로 admin kubeconfig 를 사용한다.
+	remoteClientset, err := r.remoteClients().ClientsetForCluster(ctx, r.Client, clusterManager.Namespace, clusterManager.Name, util.CredentialAdmin)
+	if errors.IsNotFound(err) {
+		log.Info("kubeconfig secret is not found")
+		return ctrl.Result{Requeue: true}, nil
+	} else if err != nil {
+		log.Error(err, "Failed to get remoteK8sClient")
+		return ctrl.Result{}, err
+	}
+
+	if drain == nil {
+		return r.uncordonNodes(ctx, clusterManager, remoteClientset)
+	}
+
+	now := metav1.Now()
+	if status == nil {
+		status = &clusterV1alpha1.ClusterDrainStatus{
+			Phase:     clusterV1alpha1.ClusterDrainPhaseDraining,
+			StartTime: &now,
+		}
+		clusterManager.Status.Drain = status
+		r.Recorder.Event(clusterManager, coreV1.EventTypeNormal, "DrainStarted", "Started to drain the nodes of the cluster")
+	}
+
+	nodeList, err := remoteClientset.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		log.Error(err, "Failed to list nodes of remote cluster")
+		return ctrl.Result{}, err
+	}
+	nodes := []clusterV1alpha1.NodeDrainStatus{}
+	remaining := 0
+	for i := range nodeList.Items {
+		node := &nodeList.Items[i]
+		if util.CordonNode(node) {
+			if _, err := remoteClientset.CoreV1().Nodes().Update(ctx, node, metav1.UpdateOptions{}); err != nil {
+				log.Error(err, "Failed to cordon node of remote cluster", "node", node.Name)
+				return ctrl.Result{}, err
+			}
+			log.Info("Cordoned node", "node", node.Name)
+		}
+
+		nodeStatus, err := drainNode(ctx, remoteClientset, node.Name)
+		if err != nil {
+			log.Error(err, "Failed to evict pods of node", "node", node.Name)
+			return ctrl.Result{}, err
+		}
+		nodes = append(nodes, nodeStatus)
+		remaining += nodeStatus.PodsRemaining
+	}
+	status.Nodes = nodes
+
+	condition := metav1.Condition{
+		Type:               clusterV1alpha1.ClusterManagerConditionDrained,
+		Status:             metav1.ConditionFalse,
+		Reason:             "Draining",
+		Message:            fmt.Sprintf("%d pods are not evicted yet", remaining),
+		ObservedGeneration: clusterManager.Generation,
+	}
+	switch {
+	case remaining == 0:
+		status.Phase = clusterV1alpha1.ClusterDrainPhaseDrained
+		status.CompletionTime = &now
+		condition.Status = metav1.ConditionTrue
+		condition.Reason = "Drained"
+		condition.Message = fmt.Sprintf("All pods are evicted from %d nodes", len(nodes))
+		r.Recorder.Event(clusterManager, coreV1.EventTypeNormal, "Drained", condition.Message)
+	case drain.Timeout != nil && drain.Timeout.Duration > 0 && now.Sub(status.StartTime.Time) > drain.Timeout.Duration:
+		status.Phase = clusterV1alpha1.ClusterDrainPhaseTimedOut
+		status.CompletionTime = &now
+		condition.Reason = "TimedOut"
+		condition.Message = fmt.Sprintf("%d pods are not evicted in %s", remaining, drain.Timeout.Duration)
+		r.Recorder.Event(clusterManager, coreV1.EventTypeWarning, "DrainTimedOut", condition.Message)
+	}
+	meta.SetStatusCondition(&clusterManager.Status.Conditions, condition)
+	if status.Phase == clusterV1alpha1.ClusterDrainPhaseDraining {
+		return util.RequeueAfterWithJitter(drainPollInterval), nil
+	}
+	log.Info("Finished to drain cluster", "phase", status.Phase)
+
+	return ctrl.Result{}, nil
+}
+
+// node 의 pod 를 evict 하고 drain 진행 상태를 반환한다.
+func drainNode(ctx context.Context, clientset kubernetes.Interface, nodeName string) (clusterV1alpha1.NodeDrainStatus, error) {
+	status := clusterV1alpha1.NodeDrainStatus{
+		Name:  nodeName,
+		Phase: clusterV1alpha1.NodeDrainPhaseDrained,
+	}
+	pods, err := util.ListEvictablePods(ctx, clientset, nodeName)
+	if err != nil {
+		return status, err
+	}
+	if len(pods) == 0 {
+		return status, nil
+	}
+
+	status.Phase = clusterV1alpha1.NodeDrainPhaseDraining
+	status.PodsRemaining = len(pods)
+	for i := range pods {
+		pod := &pods[i]
+		// 이미 evict 된 pod 는 종료되기를 기다린다.
+		if pod.DeletionTimestamp != nil {
+			continue
+		}
+		if err := util.EvictPod(ctx, clientset, pod); errors.IsTooManyRequests(err) {
+			status.Message = fmt.Sprintf("Eviction of pod %s/%s is blocked by the PodDisruptionBudget", pod.Namespace, pod.Name)
+		} else if err != nil {
+			return status, err
+		}
+	}
+	return status, nil
+}
+
+// drain 으로 cordon 한 node 를 uncordon 하고 drain 상태를 제거한다.
+func (r *ClusterManagerReconciler) uncordonNodes(ctx context.Context, clusterManager *clusterV1alpha1.ClusterManager, clientset kubernetes.Interface) (ctrl.Result, error) {
+	log := r.Log.WithValues("clustermanager", clusterManager.GetNamespacedName())
+
+	nodeList, err := clientset.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		log.Error(err, "Failed to list nodes of remote cluster")
+		return ctrl.Result{}, err
+	}
+	uncordoned := 0
+	for i := range nodeList.Items {
+		node := &nodeList.Items[i]
+		if !util.UncordonNode(node) {
+			continue
+		}
+		if _, err := clientset.CoreV1().Nodes().Update(ctx, node, metav1.UpdateOptions{}); err != nil {
+			log.Error(err, "Failed to uncordon node of remote cluster", "node", node.Name)
+			return ctrl.Result{}, err
+		}
+		uncordoned++
+	}
+	log.Info("Uncordoned nodes", "uncordoned", uncordoned)
+
+	clusterManager.Status.Drain = nil
+	meta.RemoveStatusCondition(&clusterManager.Status.Conditions, clusterV1alpha1.ClusterManagerConditionDrained)
+	return ctrl.Result{}, nil
+}
@@ -0,0 +1,321 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"strings"
+	"time"
+
+	clusterV1alpha1 "github.com/tmax-cloud/hypercloud-multi-operator/apis/cluster/v1alpha1"
+	"github.com/tmax-cloud/hypercloud-multi-operator/controllers/util"
+
+	batchV1 "k8s.io/api/batch/v1"
+	coreV1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	kerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/validation"
+
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// hook 의 job 이 끝났는지 다시 확인하는 간격
+const lifecycleHookPollInterval = 10 * time.Second
+
+// 삭제 전에 pre-delete hook 이 끝나기를 기다리는 중임을 나타낸다.
+var errLifecycleHooksRunning = fmt.Errorf("lifecycle hooks are running")
+
+// cluster 를 생성하거나 join 하기 전에 pre-create hook 을 수행한다.
+// 모든 hook 이 끝나기 전에는 requeue 하여 다른 phase 를 수행하지 않는다.
+func (r *ClusterManagerReconciler) RunPreCreateHooks(ctx context.Context, clusterManager *clusterV1alpha1.ClusterManager) (ctrl.Result, error) {
+	return r.runLifecycleHooks(ctx, clusterManager, clusterV1alpha1.LifecycleHookPointPreCreate)
+}
+
+// cluster 가 준비된 후에 post-create hook 을 수행한다.
+// 모든 hook 이 끝나기 전에는 cluster manager 를 Ready 로 변경하지 않는다.
+func (r *ClusterManagerReconciler) RunPostCreateHooks(ctx context.Context, clusterManager *clusterV1alpha1.ClusterManager) (ctrl.Result, error) {
+	return r.runLifecycleHooks(ctx, clusterManager, clusterV1alpha1.LifecycleHookPointPostCreate)
+}
+
+// cluster 를 삭제하기 전에 pre-delete hook 을 수행한다.
+func (r *ClusterManagerReconciler) RunPreDeleteHooks(ctx context.Context, clusterManager *clusterV1alpha1.ClusterManager) (ctrl.Result, error) {
+	return r.runLifecycleHooks(ctx, clusterManager, clusterV1alpha1.LifecycleHookPointPreDelete)
+}
+
+// point 의 hook 들을 동시에 수행하고 결과를 status.lifecycleHooks 와 condition 에 기록한다.
+// 수행 중인 hook 이 있으면 requeue 하고, failurePolicy 가 Fail 인 hook 이 실패하면 error 를 반환한다.
+func (r *ClusterManagerReconciler) runLifecycleHooks(ctx context.Context, clusterManager *clusterV1alpha1.ClusterManager, point clusterV1alpha1.LifecycleHookPoint) (ctrl.Result, error) {
+	conditionType := map[clusterV1alpha1.LifecycleHookPoint]string{
+		clusterV1alpha1.LifecycleHookPointPreCreate:  clusterV1alpha1.ClusterManagerConditionPreCreateHooksSucceeded,
+		clusterV1alpha1.LifecycleHookPointPostCreate: clusterV1alpha1.ClusterManagerConditionPostCreateHooksSucceeded,
+		clusterV1alpha1.LifecycleHookPointPreDelete:  clusterV1alpha1.ClusterManagerConditionPreDeleteHooksSucceeded,
+	}[point]
+	hooks, err := r.listLifecycleHooks(ctx, clusterManager, point)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	if len(hooks) == 0 {
+		meta.RemoveStatusCondition(&clusterManager.Status.Conditions, conditionType)
+		return ctrl.Result{}, nil
+	}
+
+	log := r.Log.WithValues("clustermanager", clusterManager.GetNamespacedName())
+	running := []string{}
+	errs := []error{}
+	for i := range hooks {
+		hook := &hooks[i]
+		status := getLifecycleHookStatus(clusterManager, hook, point)
+		if status.Phase == clusterV1alpha1.LifecycleHookPhaseSucceeded {
+			continue
+		}
+		if status.Phase == clusterV1alpha1.LifecycleHookPhaseFailed && hook.GetFailurePolicy() == clusterV1alpha1.LifecycleHookFailurePolicyIgnore {
+			continue
+		}
+
+		prev := status.Phase
+		if err := r.runLifecycleHook(ctx, clusterManager, hook, point, status); err != nil {
+			return ctrl.Result{}, err
+		}
+		if status.Phase != prev {
+			log.Info("Lifecycle hook phase changed", "hook", status.Name, "point", point, "phase", status.Phase)
+			switch status.Phase {
+			case clusterV1alpha1.LifecycleHookPhaseSucceeded:
+				r.Recorder.Event(clusterManager, coreV1.EventTypeNormal, "LifecycleHookSucceeded",
+					fmt.Sprintf("%s hook %s succeeded", point, status.Name))
+			case clusterV1alpha1.LifecycleHookPhaseFailed:
+				r.Recorder.Event(clusterManager, coreV1.EventTypeWarning, "LifecycleHookFailed",
+					fmt.Sprintf("%s hook %s failed: %s", point, status.Name, status.Message))
+			}
+		}
+		setLifecycleHookStatus(clusterManager, *status)
+
+		switch {
+		case status.Phase == clusterV1alpha1.LifecycleHookPhaseRunning:
+			running = append(running, status.Name)
+		case status.Phase == clusterV1alpha1.LifecycleHookPhaseFailed && hook.GetFailurePolicy() == clusterV1alpha1.LifecycleHookFailurePolicyFail:
+			errs = append(errs, fmt.Errorf("%s hook %s failed: %s", point, status.Name, status.Message))
+		}
+	}
+
+	if err := kerrors.NewAggregate(errs); err != nil {
+		meta.SetStatusCondition(&clusterManager.Status.Conditions, metav1.Condition{
+			Type:    conditionType,
+			Status:  metav1.ConditionFalse,
+			Reason:  "HookFailed",
+			Message: err.Error(),
+		})
+		return ctrl.Result{}, err
+	}
+	if len(running) != 0 {
+		meta.SetStatusCondition(&clusterManager.Status.Conditions, metav1.Condition{
+			Type:    conditionType,
+			Status:  metav1.ConditionFalse,
+			Reason:  "HookRunning",
+			Message: "Waiting for hooks: " + strings.Join(running, ", "),
+		})
+		return ctrl.Result{RequeueAfter: lifecycleHookPollInterval}, nil
+	}
+	meta.SetStatusCondition(&clusterManager.Status.Conditions, metav1.Condition{
+		Type:   conditionType,
+		Status: metav1.ConditionTrue,
+		Reason: "HooksSucceeded",
+	})
+	return ctrl.Result{}, nil
+}
+
+// cluster manager 의 namespace 와 hypercloud5-system namespace 의 hook 중 point 와 selector 가 일치하는 hook 을 반환한다.
+// 생성 전후의 hook 은 cluster 보다 먼저 생성된 hook 만 수행하여, 이미 생성된 cluster 에 다시 수행하지 않는다.
+func (r *ClusterManagerReconciler) listLifecycleHooks(ctx context.Context, clusterManager *clusterV1alpha1.ClusterManager, point clusterV1alpha1.LifecycleHookPoint) ([]clusterV1alpha1.LifecycleHook, error) {
+	namespaces := []string{clusterManager.Namespace}
+	if clusterManager.Namespace != util.HypercloudNamespace {
+		namespaces = append(namespaces, util.HypercloudNamespace)
+	}
+
+	hooks := []clusterV1alpha1.LifecycleHook{}
+	for _, namespace := range namespaces {
+		hookList := &clusterV1alpha1.LifecycleHookList{}
+		if err := r.Client.List(ctx, hookList, client.InNamespace(namespace)); err != nil {
+			return nil, err
+		}
+		for _, hook := range hookList.Items {
+			if hook.Spec.Point != point || !hook.DeletionTimestamp.IsZero() {
+				continue
+			}
+			if point != clusterV1alpha1.LifecycleHookPointPreDelete && !hook.CreationTimestamp.Before(&clusterManager.CreationTimestamp) {
+				continue
+			}
+			selector, err := hook.GetClusterSelector()
+			if err != nil {
+				return nil, fmt.Errorf("invalid cluster selector of lifecycle hook %s/%s: %w", hook.Namespace, hook.Name, err)
+			}
+			if selector.Matches(labels.Set(clusterManager.Labels)) {
+				hooks = append(hooks, hook)
+			}
+		}
+	}
+	return hooks, nil
+}
+
+// hook 을 수행하고 status 를 갱신한다. hook 의 실패는 status 에 기록하고, hook 을 수행할 수 없는 경우에만 error 를 반환한다.
+func (r *ClusterManagerReconciler) runLifecycleHook(ctx context.Context, clusterManager *clusterV1alpha1.ClusterManager, hook *clusterV1alpha1.LifecycleHook, point clusterV1alpha1.LifecycleHookPoint, status *clusterV1alpha1.LifecycleHookStatus) error {
+	now := metav1.Now()
+	fail := func(message string) {
+		status.Phase = clusterV1alpha1.LifecycleHookPhaseFailed
+		status.Message = message
+		status.CompletionTime = &now
+	}
+	if (hook.Spec.Job == nil) == (hook.Spec.Webhook == nil) {
+		fail("exactly one of job and webhook must be set")
+		return nil
+	}
+
+	if hook.Spec.Webhook != nil {
+		// webhook 은 성공할 때까지 reconcile 마다 다시 호출한다.
+		status.StartTime = &now
+		request := util.LifecycleHookRequest{
+			Point:       point,
+			Hook:        status.Name,
+			Namespace:   clusterManager.Namespace,
+			ClusterName: clusterManager.Name,
+			Timestamp:   now.Time,
+		}
+		if err := util.CallLifecycleHookWebhook(ctx, r.Client, hook, request); err != nil {
+			fail(err.Error())
+			return nil
+		}
+		status.Phase = clusterV1alpha1.LifecycleHookPhaseSucceeded
+		status.Message = ""
+		status.CompletionTime = &now
+		return nil
+	}
+
+	job := &batchV1.Job{}
+	key := client.ObjectKey{Name: lifecycleHookJobName(clusterManager, hook, point), Namespace: hook.Namespace}
+	if err := r.Client.Get(ctx, key, job); errors.IsNotFound(err) {
+		// 실패한 job 을 삭제하면 다시 수행한다.
+		job = constructLifecycleHookJob(clusterManager, hook, point, key.Name)
+		if err := r.Client.Create(ctx, job); err != nil && !errors.IsAlreadyExists(err) {
+			return err
+		}
+		status.Phase = clusterV1alpha1.LifecycleHookPhaseRunning
+		status.Job = job.Name
+		status.Message = ""
+		status.StartTime = &now
+		status.CompletionTime = nil
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	status.Job = job.Name
+	for _, condition := range job.Status.Conditions {
+		if condition.Status != coreV1.ConditionTrue {
+			continue
+		}
+		switch condition.Type {
+		case batchV1.JobComplete:
+			status.Phase = clusterV1alpha1.LifecycleHookPhaseSucceeded
+			status.Message = ""
+			status.CompletionTime = &now
+			// 성공한 job 은 정리하고, 실패한 job 은 원인을 확인할 수 있도록 남겨둔다.
+			if err := r.Client.Delete(ctx, job, client.PropagationPolicy(metav1.DeletePropagationBackground)); err != nil && !errors.IsNotFound(err) {
+				return err
+			}
+			return nil
+		case batchV1.JobFailed:
+			if status.Phase != clusterV1alpha1.LifecycleHookPhaseFailed {
+				fail(fmt.Sprintf("job %s/%s failed: %s", job.Namespace, job.Name, condition.Message))
+			}
+			return nil
+		}
+	}
+	status.Phase = clusterV1alpha1.LifecycleHookPhaseRunning
+	return nil
+}
+
+func constructLifecycleHookJob(clusterManager *clusterV1alpha1.ClusterManager, hook *clusterV1alpha1.LifecycleHook, point clusterV1alpha1.LifecycleHookPoint, name string) *batchV1.Job {
+	template := hook.Spec.Job.DeepCopy()
+	job := &batchV1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        name,
+			Namespace:   hook.Namespace,
+			Labels:      template.Labels,
+			Annotations: template.Annotations,
+		},
+		Spec: template.Spec,
+	}
+	if job.Labels == nil {
+		job.Labels = map[string]string{}
+	}
+	job.Labels[clusterV1alpha1.LabelKeyClmName] = clusterManager.Name
+	job.Labels[clusterV1alpha1.LabelKeyClmNamespace] = clusterManager.Namespace
+
+	if job.Spec.ActiveDeadlineSeconds == nil {
+		timeout := hook.GetTimeoutSeconds()
+		job.Spec.ActiveDeadlineSeconds = &timeout
+	}
+	if job.Spec.Template.Spec.RestartPolicy == "" {
+		job.Spec.Template.Spec.RestartPolicy = coreV1.RestartPolicyNever
+	}
+	env := []coreV1.EnvVar{
+		{Name: util.LifecycleHookEnvClusterName, Value: clusterManager.Name},
+		{Name: util.LifecycleHookEnvClusterNamespace, Value: clusterManager.Namespace},
+		{Name: util.LifecycleHookEnvPoint, Value: string(point)},
+	}
+	for i := range job.Spec.Template.Spec.Containers {
+		container := &job.Spec.Template.Spec.Containers[i]
+		container.Env = append(container.Env, env...)
+	}
+	return job
+}
+
+// 같은 hook 이 여러 cluster 에 수행되므로 cluster 와 point 의 hash 를 붙인다.
+func lifecycleHookJobName(clusterManager *clusterV1alpha1.ClusterManager, hook *clusterV1alpha1.LifecycleHook, point clusterV1alpha1.LifecycleHookPoint) string {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(clusterManager.Namespace + "/" + clusterManager.Name + "/" + string(point)))
+	hash := fmt.Sprintf("%08x", h.Sum32())
+
+	prefix := hook.Name
+	if maxLength := validation.DNS1123LabelMaxLength - len(hash) - 1; len(prefix) > maxLength {
+		prefix = strings.TrimRight(prefix[:maxLength], "-.")
+	}
+	return prefix + "-" + hash
+}
+
+func getLifecycleHookStatus(clusterManager *clusterV1alpha1.ClusterManager, hook *clusterV1alpha1.LifecycleHook, point clusterV1alpha1.LifecycleHookPoint) *clusterV1alpha1.LifecycleHookStatus {
+	name := hook.Namespace + "/" + hook.Name
+	for _, status := range clusterManager.Status.LifecycleHooks {
+		if status.Name == name && status.Point == point {
+			return status.DeepCopy()
+		}
+	}
+	return &clusterV1alpha1.LifecycleHookStatus{Name: name, Point: point}
+}
+
+func setLifecycleHookStatus(clusterManager *clusterV1alpha1.ClusterManager, status clusterV1alpha1.LifecycleHookStatus) {
+	for i := range clusterManager.Status.LifecycleHooks {
+		if clusterManager.Status.LifecycleHooks[i].Name == status.Name && clusterManager.Status.LifecycleHooks[i].Point == status.Point {
+			clusterManager.Status.LifecycleHooks[i] = status
+			return
+		}
+	}
+	clusterManager.Status.LifecycleHooks = append(clusterManager.Status.LifecycleHooks, status)
+}
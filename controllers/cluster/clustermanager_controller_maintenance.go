@@ -0,0 +1,152 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"time"
+
+	clusterV1alpha1 "github.com/tmax-cloud/hypercloud-multi-operator/apis/cluster/v1alpha1"
+	"github.com/tmax-cloud/hypercloud-multi-operator/controllers/util"
+
+	coreV1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+const (
+	// kubeadm 이 발급하는 control plane 인증서의 유효 기간
+	controlPlaneCertificateValidity = 365 * 24 * time.Hour
+	// 인증서가 만료되기 이 기간 전부터 control plane 을 rollout 하여 인증서를 갱신한다.
+	controlPlaneCertificateRenewBefore = 30 * 24 * time.Hour
+)
+
+// UpdateMaintenanceWindow 는 spec.maintenanceWindow 의 현재 유지보수 시간과 다음 유지보수 시간을 status 에 기록하고,
+// 유지보수 시간이 시작되거나 끝나는 시점에 다시 reconcile 한다.
+func (r *ClusterManagerReconciler) UpdateMaintenanceWindow(ctx context.Context, clusterManager *clusterV1alpha1.ClusterManager) (ctrl.Result, error) {
+	window := clusterManager.Spec.MaintenanceWindow
+	if window == nil {
+		clusterManager.Status.MaintenanceWindow = nil
+		return ctrl.Result{}, nil
+	}
+
+	status, err := util.GetMaintenanceWindowStatus(window, time.Now())
+	if err != nil {
+		// 유지보수 시간을 알 수 없으므로 spec 이 수정될 때까지 disruptive 작업을 수행하지 않는다.
+		clusterManager.Status.MaintenanceWindow = nil
+		r.Log.WithValues("clustermanager", clusterManager.GetNamespacedName()).Error(err, "Invalid maintenance window schedule")
+		return ctrl.Result{}, nil
+	}
+	clusterManager.Status.MaintenanceWindow = status
+
+	next := status.NextWindowStart
+	if status.Active {
+		next = status.CurrentWindowEnd
+	}
+	if next == nil {
+		return ctrl.Result{}, nil
+	}
+	// jitter 를 더하면 유지보수 시간을 놓칠 수 있으므로 정확한 시점에 requeue 한다.
+	return ctrl.Result{RequeueAfter: time.Until(next.Time) + time.Second}, nil
+}
+
+// spec.maintenanceWindow 가 없거나 현재 유지보수 시간 안이면 disruptive 작업을 수행할 수 있다.
+func inMaintenanceWindow(clusterManager *clusterV1alpha1.ClusterManager) bool {
+	if clusterManager.Spec.MaintenanceWindow == nil {
+		return true
+	}
+	status := clusterManager.Status.MaintenanceWindow
+	return status != nil && status.Active
+}
+
+// disruptive 작업을 다음 유지보수 시간까지 미룬다.
+func (r *ClusterManagerReconciler) waitForMaintenanceWindow(clusterManager *clusterV1alpha1.ClusterManager, operation string) ctrl.Result {
+	log := r.Log.WithValues("clustermanager", clusterManager.GetNamespacedName())
+
+	status := clusterManager.Status.MaintenanceWindow
+	if status == nil || status.NextWindowStart == nil {
+		log.Info("Wait for the maintenance window", "operation", operation)
+		return ctrl.Result{}
+	}
+	log.Info("Wait for the maintenance window", "operation", operation, "nextWindowStart", status.NextWindowStart.Time)
+	return ctrl.Result{RequeueAfter: time.Until(status.NextWindowStart.Time) + time.Second}
+}
+
+// RenewControlPlaneCertificates 는 kubeadm 이 발급한 control plane 인증서가 만료되기 전에
+// 유지보수 시간 안에서 kubeadm control plane 을 rollout 하여 인증서를 새로 발급받는다.
+// topology 로 생성한 cluster 의 kubeadm control plane 은 topology controller 가 관리하므로 제외한다.
+func (r *ClusterManagerReconciler) RenewControlPlaneCertificates(ctx context.Context, clusterManager *clusterV1alpha1.ClusterManager) (ctrl.Result, error) {
+	if !clusterManager.Status.ControlPlaneReady || clusterManager.Spec.Paused {
+		return ctrl.Result{}, nil
+	}
+
+	log := r.Log.WithValues("clustermanager", clusterManager.GetNamespacedName())
+	log.Info("Start to reconcile phase for RenewControlPlaneCertificates")
+
+	cluster, err := r.GetCapiCluster(clusterManager)
+	if errors.IsNotFound(err) {
+		return ctrl.Result{}, nil
+	} else if err != nil {
+		log.Error(err, "Failed to get cluster")
+		return ctrl.Result{}, err
+	}
+	if isTopologyManaged(cluster) {
+		return ctrl.Result{}, nil
+	}
+
+	machines, err := r.GetControlplaneMachineList(clusterManager)
+	if err != nil {
+		log.Error(err, "Failed to list controlplane machines")
+		return ctrl.Result{}, err
+	}
+	// 가장 오래된 control plane machine 의 인증서가 가장 먼저 만료된다.
+	var oldest *metav1.Time
+	for i := range machines {
+		created := machines[i].CreationTimestamp
+		if oldest == nil || created.Before(oldest) {
+			oldest = &created
+		}
+	}
+	if oldest == nil || time.Since(oldest.Time) < controlPlaneCertificateValidity-controlPlaneCertificateRenewBefore {
+		return ctrl.Result{}, nil
+	}
+
+	kcp, err := r.GetKubeadmControlPlane(cluster)
+	if errors.IsNotFound(err) {
+		return ctrl.Result{}, nil
+	} else if err != nil {
+		log.Error(err, "Failed to get kubeadmcontrolplane")
+		return ctrl.Result{}, err
+	}
+	// 이미 rollout 을 요청한 경우 machine 이 교체되기를 기다린다.
+	if kcp.Spec.RolloutAfter != nil && kcp.Spec.RolloutAfter.After(oldest.Time) {
+		return ctrl.Result{}, nil
+	}
+	if !inMaintenanceWindow(clusterManager) {
+		return r.waitForMaintenanceWindow(clusterManager, "certificate renewal"), nil
+	}
+
+	now := metav1.Now()
+	kcp.Spec.RolloutAfter = &now
+	if err := r.Update(context.TODO(), kcp); err != nil {
+		log.Error(err, "Failed to update rolloutAfter of kubeadmcontrolplane")
+		return ctrl.Result{}, err
+	}
+	log.Info("Requested rollout of controlplane to renew certificates", "oldestMachineCreated", oldest.Time)
+	r.Recorder.Event(clusterManager, coreV1.EventTypeNormal, "CertificateRenewal", "Rolling out the control plane to renew the certificates before expiry")
+	return ctrl.Result{}, nil
+}
@@ -0,0 +1,89 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"reflect"
+
+	clusterV1alpha1 "github.com/tmax-cloud/hypercloud-multi-operator/apis/cluster/v1alpha1"
+
+	coreV1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+
+	capiV1beta1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+const reasonClusterNetworkNotApplied = "ClusterNetworkNotApplied"
+
+// ApplyClusterNetwork 는 spec.network 의 pod, service cidr 을 template 으로 생성한 capi cluster 에 반영한다.
+// template parameter 는 하나의 값만 넣을 수 있으므로 dual-stack cidr 과 service cidr 은 cluster 생성 후에 설정한다.
+// kubeadm 은 control plane 을 초기화할 때만 cluster network 를 사용하므로, 초기화된 이후에는 변경하지 않는다.
+func (r *ClusterManagerReconciler) ApplyClusterNetwork(ctx context.Context, clusterManager *clusterV1alpha1.ClusterManager) (ctrl.Result, error) {
+	if clusterManager.Spec.Network == nil {
+		return ctrl.Result{}, nil
+	}
+	log := r.Log.WithValues("clustermanager", clusterManager.GetNamespacedName())
+	log.Info("Start to reconcile phase for ApplyClusterNetwork")
+
+	cluster, err := r.GetCapiCluster(clusterManager)
+	if errors.IsNotFound(err) {
+		log.Info("Cluster is not found")
+		return ctrl.Result{Requeue: true}, nil
+	} else if err != nil {
+		log.Error(err, "Failed to get cluster")
+		return ctrl.Result{}, err
+	}
+
+	network := cluster.Spec.ClusterNetwork.DeepCopy()
+	if network == nil {
+		network = &capiV1beta1.ClusterNetwork{}
+	}
+	setClusterNetwork(network, clusterManager.Spec.Network)
+	if reflect.DeepEqual(network, cluster.Spec.ClusterNetwork) {
+		return ctrl.Result{}, nil
+	}
+
+	if conditions.IsTrue(cluster, capiV1beta1.ControlPlaneInitializedCondition) {
+		message := "Cluster network cannot be applied after the control plane is initialized"
+		log.Info(message)
+		r.Recorder.Event(clusterManager, coreV1.EventTypeWarning, reasonClusterNetworkNotApplied, message)
+		return ctrl.Result{}, nil
+	}
+
+	cluster.Spec.ClusterNetwork = network
+	if err := r.Update(context.TODO(), cluster); err != nil {
+		log.Error(err, "Failed to update network of cluster")
+		return ctrl.Result{}, err
+	}
+	log.Info("Updated network of cluster successfully", "pods", clusterManager.Spec.Network.PodCIDRs, "services", clusterManager.Spec.Network.ServiceCIDRs)
+	return ctrl.Result{}, nil
+}
+
+// 지정된 cidr 만 덮어쓰고, 지정하지 않은 network 는 template 이나 ClusterClass 의 값을 그대로 둔다.
+func setClusterNetwork(network *capiV1beta1.ClusterNetwork, spec *clusterV1alpha1.ClusterNetwork) {
+	if len(spec.PodCIDRs) != 0 {
+		network.Pods = &capiV1beta1.NetworkRanges{
+			CIDRBlocks: append([]string{}, spec.PodCIDRs...),
+		}
+	}
+	if len(spec.ServiceCIDRs) != 0 {
+		network.Services = &capiV1beta1.NetworkRanges{
+			CIDRBlocks: append([]string{}, spec.ServiceCIDRs...),
+		}
+	}
+}
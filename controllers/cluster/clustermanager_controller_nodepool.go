@@ -0,0 +1,377 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+
+	clusterV1alpha1 "github.com/tmax-cloud/hypercloud-multi-operator/apis/cluster/v1alpha1"
+
+	coreV1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+
+	capiV1beta1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	bootstrapv1 "sigs.k8s.io/cluster-api/bootstrap/kubeadm/api/v1beta1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// windows node 의 containerd named pipe
+	windowsCRISocket = "npipe:////./pipe/containerd-containerd"
+	// capv 의 VSphereMachineTemplate 에 설정하는 windows os 이름
+	vsphereOsWindows = "Windows"
+	// spot node 에 다는 label
+	nodeLabelLifecycleSpot = "node.kubernetes.io/lifecycle=spot"
+)
+
+// node pool 의 machine deployment, kubeadm config template, infrastructure machine template 의 이름
+func nodePoolResourceName(clusterManager *clusterV1alpha1.ClusterManager, pool *clusterV1alpha1.NodePoolSpec) string {
+	return clusterManager.Name + "-" + pool.Name
+}
+
+func nodePoolLabels(clusterManager *clusterV1alpha1.ClusterManager, pool *clusterV1alpha1.NodePoolSpec) map[string]string {
+	return map[string]string{
+		CAPI_CLUSTER_LABEL_KEY:              clusterManager.Name,
+		clusterV1alpha1.LabelKeyClmNodePool: pool.Name,
+	}
+}
+
+// worker 의 infrastructure machine template 을 복사하여 node pool 의 template 을 생성한다.
+// provider 별 template 의 spec 이 다르므로 unstructured 로 다루고, vsphere 인 경우에만 vm template 과 os 를 바꾼다.
+func (r *ClusterManagerReconciler) createNodePoolInfrastructureTemplate(clusterManager *clusterV1alpha1.ClusterManager, pool *clusterV1alpha1.NodePoolSpec, workerRef *coreV1.ObjectReference) error {
+	name := nodePoolResourceName(clusterManager, pool)
+	existing := &unstructured.Unstructured{}
+	existing.SetAPIVersion(workerRef.APIVersion)
+	existing.SetKind(workerRef.Kind)
+	if err := r.Client.Get(context.TODO(), types.NamespacedName{Name: name, Namespace: clusterManager.Namespace}, existing); err == nil {
+		return nil
+	} else if !errors.IsNotFound(err) {
+		return err
+	}
+
+	worker := &unstructured.Unstructured{}
+	worker.SetAPIVersion(workerRef.APIVersion)
+	worker.SetKind(workerRef.Kind)
+	if err := r.Client.Get(context.TODO(), types.NamespacedName{Name: workerRef.Name, Namespace: clusterManager.Namespace}, worker); err != nil {
+		return err
+	}
+
+	template := &unstructured.Unstructured{}
+	template.SetAPIVersion(workerRef.APIVersion)
+	template.SetKind(workerRef.Kind)
+	template.SetName(name)
+	template.SetNamespace(clusterManager.Namespace)
+	template.SetLabels(nodePoolLabels(clusterManager, pool))
+	spec, _, err := unstructured.NestedMap(worker.Object, "spec")
+	if err != nil {
+		return err
+	}
+	if err := unstructured.SetNestedMap(template.Object, spec, "spec"); err != nil {
+		return err
+	}
+	if pool.VcenterTemplate != "" {
+		if err := unstructured.SetNestedField(template.Object, pool.VcenterTemplate, "spec", "template", "spec", "template"); err != nil {
+			return err
+		}
+	}
+	if pool.GetOsType() == clusterV1alpha1.OsTypeWindows {
+		if err := unstructured.SetNestedField(template.Object, vsphereOsWindows, "spec", "template", "spec", "os"); err != nil {
+			return err
+		}
+	}
+	// capa 의 AWSMachineTemplate 은 spotMarketOptions 가 있으면 spot instance 로 생성한다.
+	if pool.Spot {
+		spotMarketOptions := map[string]interface{}{}
+		if pool.SpotMaxPrice != "" {
+			spotMarketOptions["maxPrice"] = pool.SpotMaxPrice
+		}
+		if err := unstructured.SetNestedMap(template.Object, spotMarketOptions, "spec", "template", "spec", "spotMarketOptions"); err != nil {
+			return err
+		}
+	}
+
+	return r.Client.Create(context.TODO(), template)
+}
+
+// node pool 의 kubeadm config template 을 생성한다.
+// linux 는 worker 의 설정에 node pool label 만 추가하고,
+// windows 는 cloudbase-init 이 처리할 수 있는 설정으로 새로 만들고 linux workload 가 스케줄링되지 않도록 taint 를 단다.
+func (r *ClusterManagerReconciler) createNodePoolKubeadmConfigTemplate(clusterManager *clusterV1alpha1.ClusterManager, pool *clusterV1alpha1.NodePoolSpec, workerRef *coreV1.ObjectReference) error {
+	key := types.NamespacedName{Name: nodePoolResourceName(clusterManager, pool), Namespace: clusterManager.Namespace}
+	if err := r.Client.Get(context.TODO(), key, &bootstrapv1.KubeadmConfigTemplate{}); err == nil {
+		return nil
+	} else if !errors.IsNotFound(err) {
+		return err
+	}
+
+	spec := bootstrapv1.KubeadmConfigSpec{}
+	if pool.GetOsType() == clusterV1alpha1.OsTypeWindows {
+		spec = windowsKubeadmConfigSpec()
+	} else if workerRef == nil {
+		return fmt.Errorf("bootstrap config of the worker machine deployment is not found")
+	} else {
+		worker := &bootstrapv1.KubeadmConfigTemplate{}
+		if err := r.Client.Get(context.TODO(), types.NamespacedName{Name: workerRef.Name, Namespace: clusterManager.Namespace}, worker); err != nil {
+			return err
+		}
+		worker.Spec.Template.Spec.DeepCopyInto(&spec)
+	}
+	if spec.JoinConfiguration == nil {
+		spec.JoinConfiguration = &bootstrapv1.JoinConfiguration{}
+	}
+	nodeRegistration := &spec.JoinConfiguration.NodeRegistration
+	if nodeRegistration.KubeletExtraArgs == nil {
+		nodeRegistration.KubeletExtraArgs = map[string]string{}
+	}
+	nodeLabels := []string{clusterV1alpha1.LabelKeyClmNodePool + "=" + pool.Name}
+	if pool.Spot {
+		nodeLabels = append(nodeLabels, nodeLabelLifecycleSpot)
+	}
+	if labels := nodeRegistration.KubeletExtraArgs["node-labels"]; labels != "" {
+		nodeLabels = append(strings.Split(labels, ","), nodeLabels...)
+	}
+	nodeRegistration.KubeletExtraArgs["node-labels"] = strings.Join(nodeLabels, ",")
+	// amd64 이미지만 제공하는 addon 이 arm64 node 에 스케줄링되지 않도록 taint 를 단다.
+	if pool.GetArchitecture() == clusterV1alpha1.ArchitectureArm64 {
+		nodeRegistration.Taints = append(nodeRegistration.Taints, coreV1.Taint{
+			Key:    coreV1.LabelArchStable,
+			Value:  clusterV1alpha1.ArchitectureArm64,
+			Effect: coreV1.TaintEffectNoSchedule,
+		})
+	}
+
+	template := &bootstrapv1.KubeadmConfigTemplate{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      key.Name,
+			Namespace: key.Namespace,
+			Labels:    nodePoolLabels(clusterManager, pool),
+		},
+		Spec: bootstrapv1.KubeadmConfigTemplateSpec{
+			Template: bootstrapv1.KubeadmConfigTemplateResource{
+				Spec: spec,
+			},
+		},
+	}
+	return r.Client.Create(context.TODO(), template)
+}
+
+// capv 의 windows flavor 와 같이 cloudbase-init 으로 kubeadm join 을 수행하는 설정
+func windowsKubeadmConfigSpec() bootstrapv1.KubeadmConfigSpec {
+	return bootstrapv1.KubeadmConfigSpec{
+		Format: bootstrapv1.CloudConfig,
+		JoinConfiguration: &bootstrapv1.JoinConfiguration{
+			NodeRegistration: bootstrapv1.NodeRegistrationOptions{
+				Name:      `{{ ds.meta_data["local_hostname"] }}`,
+				CRISocket: windowsCRISocket,
+				KubeletExtraArgs: map[string]string{
+					"cloud-provider": "external",
+				},
+				Taints: []coreV1.Taint{
+					{
+						Key:    coreV1.LabelOSStable,
+						Value:  clusterV1alpha1.OsTypeWindows,
+						Effect: coreV1.TaintEffectNoSchedule,
+					},
+				},
+			},
+		},
+		PostKubeadmCommands: []string{
+			// kubeadm join 후 재부팅되어도 kubelet 이 실행되도록 한다.
+			"nssm set kubelet start SERVICE_AUTO_START",
+		},
+	}
+}
+
+// node pool 의 machine deployment 를 생성하거나 replicas 를 spec 에 맞춘다.
+// worker machine deployment 와 같은 version 으로 생성하며, 생성 후에는 version 을 바꾸지 않는다.
+func (r *ClusterManagerReconciler) reconcileNodePoolMachineDeployment(clusterManager *clusterV1alpha1.ClusterManager, pool *clusterV1alpha1.NodePoolSpec, worker *capiV1beta1.MachineDeployment) (*capiV1beta1.MachineDeployment, error) {
+	replicas := int32(pool.Replicas)
+	key := types.NamespacedName{Name: nodePoolResourceName(clusterManager, pool), Namespace: clusterManager.Namespace}
+	md := &capiV1beta1.MachineDeployment{}
+	if err := r.Client.Get(context.TODO(), key, md); err == nil {
+		strategyChanged := setNodePoolStrategy(pool, md)
+		if md.Spec.Replicas == nil || *md.Spec.Replicas != replicas || strategyChanged {
+			md.Spec.Replicas = &replicas
+			return md, r.Client.Update(context.TODO(), md)
+		}
+		return md, nil
+	} else if !errors.IsNotFound(err) {
+		return nil, err
+	}
+
+	labels := nodePoolLabels(clusterManager, pool)
+	infrastructureRef := worker.Spec.Template.Spec.InfrastructureRef
+	infrastructureRef.Name = key.Name
+	md = &capiV1beta1.MachineDeployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      key.Name,
+			Namespace: key.Namespace,
+			Labels:    labels,
+		},
+		Spec: capiV1beta1.MachineDeploymentSpec{
+			ClusterName: clusterManager.Name,
+			Replicas:    &replicas,
+			Selector: metav1.LabelSelector{
+				MatchLabels: labels,
+			},
+			Template: capiV1beta1.MachineTemplateSpec{
+				ObjectMeta: capiV1beta1.ObjectMeta{
+					Labels: labels,
+				},
+				Spec: capiV1beta1.MachineSpec{
+					ClusterName: clusterManager.Name,
+					Version:     worker.Spec.Template.Spec.Version,
+					Bootstrap: capiV1beta1.Bootstrap{
+						ConfigRef: &coreV1.ObjectReference{
+							APIVersion: bootstrapv1.GroupVersion.String(),
+							Kind:       "KubeadmConfigTemplate",
+							Name:       key.Name,
+						},
+					},
+					InfrastructureRef: infrastructureRef,
+				},
+			},
+		},
+	}
+	setNodePoolStrategy(pool, md)
+	return md, r.Client.Create(context.TODO(), md)
+}
+
+// node pool 의 rolling update 설정을 machine deployment 에 반영하고, 변경 여부를 반환한다.
+// 지정하지 않은 값은 capi 의 기본값을 따르도록 비워둔다.
+func setNodePoolStrategy(pool *clusterV1alpha1.NodePoolSpec, md *capiV1beta1.MachineDeployment) bool {
+	var strategy *capiV1beta1.MachineDeploymentStrategy
+	var nodeDrainTimeout *metav1.Duration
+	if pool.Strategy != nil {
+		nodeDrainTimeout = pool.Strategy.NodeDrainTimeout
+		if pool.Strategy.MaxSurge != nil || pool.Strategy.MaxUnavailable != nil {
+			strategy = &capiV1beta1.MachineDeploymentStrategy{
+				Type: capiV1beta1.RollingUpdateMachineDeploymentStrategyType,
+				RollingUpdate: &capiV1beta1.MachineRollingUpdateDeployment{
+					MaxSurge:       pool.Strategy.MaxSurge,
+					MaxUnavailable: pool.Strategy.MaxUnavailable,
+				},
+			}
+		}
+	}
+
+	changed := false
+	// capi 의 webhook 이 기본값을 채우므로 지정한 값만 비교한다.
+	if strategy != nil {
+		current := md.Spec.Strategy
+		if current == nil || current.RollingUpdate == nil ||
+			(strategy.RollingUpdate.MaxSurge != nil && !reflect.DeepEqual(current.RollingUpdate.MaxSurge, strategy.RollingUpdate.MaxSurge)) ||
+			(strategy.RollingUpdate.MaxUnavailable != nil && !reflect.DeepEqual(current.RollingUpdate.MaxUnavailable, strategy.RollingUpdate.MaxUnavailable)) {
+			md.Spec.Strategy = strategy
+			changed = true
+		}
+	}
+	if !reflect.DeepEqual(md.Spec.Template.Spec.NodeDrainTimeout, nodeDrainTimeout) {
+		md.Spec.Template.Spec.NodeDrainTimeout = nodeDrainTimeout
+		changed = true
+	}
+	return changed
+}
+
+// spot node pool 에서 실패한 machine 을 eviction 으로 집계한다.
+// spot instance 가 회수되면 machine 이 Failed 가 되고 machine health check 나 machine set 이 새 machine 으로 교체하므로,
+// 같은 machine 을 두 번 세지 않도록 집계한 machine 에 annotation 을 단다.
+func (r *ClusterManagerReconciler) countNodePoolEvictions(clusterManager *clusterV1alpha1.ClusterManager, pool *clusterV1alpha1.NodePoolSpec, status *clusterV1alpha1.NodePool) error {
+	machineList := &capiV1beta1.MachineList{}
+	opts := []client.ListOption{
+		client.InNamespace(clusterManager.Namespace),
+		client.MatchingLabels(nodePoolLabels(clusterManager, pool)),
+	}
+	if err := r.Client.List(context.TODO(), machineList, opts...); err != nil {
+		return err
+	}
+
+	for i := range machineList.Items {
+		machine := &machineList.Items[i]
+		failed := machine.Status.FailureReason != nil ||
+			machine.Status.GetTypedPhase() == capiV1beta1.MachinePhaseFailed
+		if !failed {
+			continue
+		}
+		if _, ok := machine.Annotations[clusterV1alpha1.AnnotationKeyClmEvictionCounted]; ok {
+			continue
+		}
+
+		helper := client.MergeFrom(machine.DeepCopy())
+		if machine.Annotations == nil {
+			machine.Annotations = map[string]string{}
+		}
+		machine.Annotations[clusterV1alpha1.AnnotationKeyClmEvictionCounted] = "true"
+		if err := r.Client.Patch(context.TODO(), machine, helper); errors.IsNotFound(err) {
+			continue
+		} else if err != nil {
+			return err
+		}
+		now := metav1.Now()
+		status.Evictions++
+		status.LastEvictionTime = &now
+	}
+	return nil
+}
+
+// spec 에서 제거된 node pool 의 machine deployment 와 template 을 삭제한다.
+func (r *ClusterManagerReconciler) deleteRemovedNodePools(clusterManager *clusterV1alpha1.ClusterManager) error {
+	pools := map[string]bool{}
+	for _, pool := range clusterManager.Spec.NodePools {
+		pools[pool.Name] = true
+	}
+
+	mdList := &capiV1beta1.MachineDeploymentList{}
+	opts := []client.ListOption{
+		client.InNamespace(clusterManager.Namespace),
+		client.MatchingLabels{CAPI_CLUSTER_LABEL_KEY: clusterManager.Name},
+		client.HasLabels{clusterV1alpha1.LabelKeyClmNodePool},
+	}
+	if err := r.Client.List(context.TODO(), mdList, opts...); err != nil {
+		return err
+	}
+
+	for i := range mdList.Items {
+		md := &mdList.Items[i]
+		if pools[md.Labels[clusterV1alpha1.LabelKeyClmNodePool]] {
+			continue
+		}
+
+		infrastructureTemplate := &unstructured.Unstructured{}
+		infrastructureTemplate.SetAPIVersion(md.Spec.Template.Spec.InfrastructureRef.APIVersion)
+		infrastructureTemplate.SetKind(md.Spec.Template.Spec.InfrastructureRef.Kind)
+		infrastructureTemplate.SetName(md.Spec.Template.Spec.InfrastructureRef.Name)
+		infrastructureTemplate.SetNamespace(md.Namespace)
+		objs := []client.Object{
+			md,
+			infrastructureTemplate,
+			&bootstrapv1.KubeadmConfigTemplate{
+				ObjectMeta: metav1.ObjectMeta{Name: md.Name, Namespace: md.Namespace},
+			},
+		}
+		for _, obj := range objs {
+			if err := r.Client.Delete(context.TODO(), obj); err != nil && !errors.IsNotFound(err) {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -19,7 +19,8 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
-	"regexp"
+	"reflect"
+	"sort"
 	"strings"
 
 	argocdV1alpha1 "github.com/argoproj/argo-cd/v2/pkg/apis/application/v1alpha1"
@@ -29,6 +30,7 @@ import (
 	traefikV1alpha1 "github.com/traefik/traefik/v2/pkg/provider/kubernetes/crd/traefik/v1alpha1"
 	coreV1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/intstr"
@@ -36,11 +38,9 @@ import (
 
 	// cpavV1alpha3 "sigs.k8s.io/cluster-api-provider-vsphere/apis/v1alpha3"
 	tmaxv1 "github.com/tmax-cloud/template-operator/api/v1"
-	capiV1alpha3 "sigs.k8s.io/cluster-api/api/v1alpha3"
-	controlplanev1 "sigs.k8s.io/cluster-api/controlplane/kubeadm/api/v1alpha3"
+	capiV1beta1 "sigs.k8s.io/cluster-api/api/v1beta1"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
-	"sigs.k8s.io/yaml"
 )
 
 // ReadyReconcilePhase는 reconcile시작 전에 수행되는 phase
@@ -59,7 +59,7 @@ func (r *ClusterManagerReconciler) ReadyReconcilePhase(ctx context.Context, clus
 	_, err := r.fetchArgocdIngressDomain(clusterManager)
 	if err != nil {
 		log.Error(err, "Failed to get argocd ingress domain")
-		return ctrl.Result{RequeueAfter: requeueAfter10Second}, nil
+		return ctrl.Result{Requeue: true}, nil
 	}
 
 	if clusterManager.Status.GetK8SVersion() == "" {
@@ -75,7 +75,7 @@ func (r *ClusterManagerReconciler) ReadyReconcilePhase(ctx context.Context, clus
 	}
 
 	if !clusterManager.Status.TraefikReady {
-		return ctrl.Result{RequeueAfter: requeueAfter1Minute}, nil
+		return ctrl.Result{Requeue: true}, nil
 	}
 	clusterManager.Status.Ready = true
 	log.Info("ClusterManager is ready successfully")
@@ -93,7 +93,7 @@ func (r *ClusterManagerReconciler) UpdateClusterManagerStatus(ctx context.Contex
 	kubeconfigSecret, err := r.GetKubeconfigSecret(clusterManager)
 	if err != nil {
 		log.Error(err, "Failed to get kubeconfig secret")
-		return ctrl.Result{RequeueAfter: requeueAfter10Second}, nil
+		return ctrl.Result{Requeue: true}, nil
 	}
 
 	remoteClientset, err := util.GetRemoteK8sClient(kubeconfigSecret)
@@ -102,78 +102,27 @@ func (r *ClusterManagerReconciler) UpdateClusterManagerStatus(ctx context.Contex
 		return ctrl.Result{}, err
 	}
 
-	// cluster registration의 경우에는 k8s version을 parameter로 받지 않기 때문에,
-	// k8s version을 single cluster의 kube-system 네임스페이스의 kubeadm-config ConfigMap으로 부터 조회
-	kubeadmConfig, err := remoteClientset.CoreV1().
-		ConfigMaps(util.KubeNamespace).
-		Get(context.TODO(), "kubeadm-config", metav1.GetOptions{})
-	if err != nil {
-		log.Error(err, "Failed to get kubeadm-config ConfigMap from remote cluster")
-		return ctrl.Result{}, err
-	}
-
-	jsonData, _ := yaml.YAMLToJSON([]byte(kubeadmConfig.Data["ClusterConfiguration"]))
-	data := make(map[string]interface{})
-	if err := json.Unmarshal(jsonData, &data); err != nil {
-		return ctrl.Result{}, err
-	}
-
-	clusterManager.SetK8SVersion(fmt.Sprintf("%v", data["kubernetesVersion"]))
-
-	nodeList, err := remoteClientset.
-		CoreV1().
-		Nodes().
-		List(context.TODO(), metav1.ListOptions{})
+	// cluster registration의 경우에는 k8s version, provider, node 수를 parameter로 받지 않기 때문에,
+	// single cluster 의 kubeadm-config, api server version, node 로부터 조회한다.
+	// kubeadm 이 아닌 rancher, managed cluster 등도 같은 정보를 채운다.
+	metadata, err := util.ImportClusterMetadata(context.TODO(), remoteClientset)
 	if err != nil {
-		log.Error(err, "Failed to list remote K8s nodeList")
+		log.Error(err, "Failed to import cluster metadata from remote cluster")
 		return ctrl.Result{}, err
 	}
+	log.Info("Import cluster metadata", "distribution", metadata.Distribution, "version", metadata.Version, "managedBy", metadata.ManagedBy)
 
-	clusterManager.Spec.MasterNum = 0
-	clusterManager.Status.MasterRun = 0
-	clusterManager.Spec.WorkerNum = 0
-	clusterManager.Status.WorkerRun = 0
-	clusterManager.Spec.Provider = util.ProviderUnknown
-	clusterManager.Status.Provider = util.ProviderUnknown
-	for _, node := range nodeList.Items {
-		if _, ok := node.Labels["node-role.kubernetes.io/master"]; ok {
-			clusterManager.Spec.MasterNum++
-			if node.Status.Conditions[len(node.Status.Conditions)-1].Type == "Ready" {
-				clusterManager.Status.MasterRun++
-			}
-		} else {
-			clusterManager.Spec.WorkerNum++
-			if node.Status.Conditions[len(node.Status.Conditions)-1].Type == "Ready" {
-				clusterManager.Status.WorkerRun++
-			}
-		}
-
-		if clusterManager.Spec.Provider == util.ProviderUnknown && node.Spec.ProviderID != "" {
-			providerID, err := util.GetProviderName(
-				strings.Split(node.Spec.ProviderID, "://")[0],
-			)
-			if err != nil {
-				log.Error(err, "Cannot find given provider name.")
-			}
-			clusterManager.Status.Provider = providerID
-			clusterManager.Spec.Provider = providerID
-		}
-	}
-
-	if clusterManager.Spec.Provider == util.ProviderUnknown {
-		reg, _ := regexp.Compile(`cloud-provider: [a-zA-Z-_ ]+`)
-		matchString := reg.FindString(kubeadmConfig.Data["ClusterConfiguration"])
-		if matchString != "" {
-			cloudProvider, err := util.GetProviderName(
-				matchString[len("cloud-provider: "):],
-			)
-			if err != nil {
-				log.Error(err, "Cannot find given provider name.")
-			}
-			clusterManager.Status.Provider = cloudProvider
-			clusterManager.Spec.Provider = cloudProvider
-		}
-	}
+	clusterManager.SetK8SVersion(metadata.Version)
+	clusterManager.Status.SetK8SVersion(metadata.Version)
+	clusterManager.Spec.Provider = metadata.Provider
+	clusterManager.Status.Provider = metadata.Provider
+	clusterManager.Spec.MasterNum = metadata.MasterNum
+	clusterManager.Status.MasterRun = metadata.MasterRun
+	clusterManager.Spec.WorkerNum = metadata.WorkerNum
+	clusterManager.Status.WorkerRun = metadata.WorkerRun
+	clusterManager.Status.Distribution = metadata.Distribution
+	clusterManager.Status.ManagedBy = metadata.ManagedBy
+	clusterManager.Status.NodePools = metadata.NodePools
 
 	// health check
 	resp, err := remoteClientset.
@@ -190,7 +139,7 @@ func (r *ClusterManagerReconciler) UpdateClusterManagerStatus(ctx context.Contex
 		clusterManager.Status.Ready = true
 	} else {
 		log.Info("Remote cluster is not ready... wait...")
-		return ctrl.Result{RequeueAfter: requeueAfter30Second}, nil
+		return ctrl.Result{Requeue: true}, nil
 	}
 
 	log.Info("Update status of ClusterManager successfully")
@@ -303,27 +252,74 @@ func (r *ClusterManagerReconciler) CreateUpgradeTemplateInstance(ctx context.Con
 }
 
 func (r *ClusterManagerReconciler) SetEndpoint(ctx context.Context, clusterManager *clusterV1alpha1.ClusterManager) (ctrl.Result, error) {
-	if clusterManager.Annotations[clusterV1alpha1.AnnotationKeyClmApiserver] != "" {
+	if clusterManager.GetAPIServerEndpoint() != "" {
 		return ctrl.Result{}, nil
 	}
 	log := r.Log.WithValues("clustermanager", clusterManager.GetNamespacedName())
 	log.Info("Start to reconcile phase for SetEndpoint")
 
 	key := clusterManager.GetNamespacedName()
-	cluster := &capiV1alpha3.Cluster{}
+	cluster := &capiV1beta1.Cluster{}
 	if err := r.Client.Get(context.TODO(), key, cluster); errors.IsNotFound(err) {
-		log.Info("Cluster is not found. Requeue after 20sec")
-		return ctrl.Result{RequeueAfter: requeueAfter20Second}, err
+		log.Info("Cluster is not found")
+		return ctrl.Result{}, err
 	} else if err != nil {
 		log.Error(err, "Failed to get cluster")
 		return ctrl.Result{}, err
 	}
 
 	if cluster.Spec.ControlPlaneEndpoint.Host == "" {
-		log.Info("ControlPlane endpoint is not ready yet. requeue after 20sec")
-		return ctrl.Result{RequeueAfter: requeueAfter20Second}, nil
+		log.Info("ControlPlane endpoint is not ready yet")
+		return ctrl.Result{Requeue: true}, nil
 	}
-	clusterManager.Annotations[clusterV1alpha1.AnnotationKeyClmApiserver] = cluster.Spec.ControlPlaneEndpoint.Host
+	clusterManager.Status.GetEndpoints().APIServer = cluster.Spec.ControlPlaneEndpoint.Host
+
+	return ctrl.Result{}, nil
+}
+
+// spec.paused 를 capi cluster 에 반영한다.
+// capi 의 controller 들은 cluster 가 paused 이면 cluster 에 속한 kcp, machine deployment, machine 의 reconcile 을 중지하므로,
+// 인프라 점검 중에 capi 가 machine 을 다시 생성하거나 remediation 하지 않는다.
+func (r *ClusterManagerReconciler) PauseCluster(ctx context.Context, clusterManager *clusterV1alpha1.ClusterManager) (ctrl.Result, error) {
+	log := r.Log.WithValues("clustermanager", clusterManager.GetNamespacedName())
+	log.Info("Start to reconcile phase for PauseCluster")
+
+	cluster, err := r.GetCapiCluster(clusterManager)
+	if errors.IsNotFound(err) {
+		log.Info("Cluster is not found")
+		return ctrl.Result{}, nil
+	} else if err != nil {
+		log.Error(err, "Failed to get cluster")
+		return ctrl.Result{}, err
+	}
+
+	if cluster.Spec.Paused != clusterManager.Spec.Paused {
+		cluster.Spec.Paused = clusterManager.Spec.Paused
+		if err := r.Update(context.TODO(), cluster); err != nil {
+			log.Error(err, "Failed to update paused of cluster")
+			return ctrl.Result{}, err
+		}
+
+		action, message := clusterV1alpha1.ClusterAuditActionClusterResumed, "Reconciliation of cluster api resources is resumed"
+		if cluster.Spec.Paused {
+			action, message = clusterV1alpha1.ClusterAuditActionClusterPaused, "Reconciliation of cluster api resources is paused"
+		}
+		log.Info(message)
+		r.Recorder.Event(clusterManager, coreV1.EventTypeNormal, string(action), message)
+		r.recordAudit(clusterManager, action, "", message)
+	}
+
+	condition := metav1.Condition{
+		Type:               clusterV1alpha1.ClusterManagerConditionPaused,
+		Status:             metav1.ConditionFalse,
+		Reason:             string(clusterV1alpha1.ClusterAuditActionClusterResumed),
+		ObservedGeneration: clusterManager.Generation,
+	}
+	if cluster.Spec.Paused {
+		condition.Status = metav1.ConditionTrue
+		condition.Reason = string(clusterV1alpha1.ClusterAuditActionClusterPaused)
+	}
+	meta.SetStatusCondition(&clusterManager.Status.Conditions, condition)
 
 	return ctrl.Result{}, nil
 }
@@ -333,13 +329,27 @@ func (r *ClusterManagerReconciler) ScaleControlplane(ctx context.Context, cluste
 	log := r.Log.WithValues("clustermanager", clusterManager.GetNamespacedName())
 	log.Info("Start to reconcile phase for ControlplaneScaling")
 
-	key := types.NamespacedName{
-		Name:      clusterManager.Name + "-control-plane",
-		Namespace: clusterManager.Namespace,
+	cluster, err := r.GetCapiCluster(clusterManager)
+	if err != nil {
+		log.Error(err, "Failed to get cluster")
+		return ctrl.Result{}, err
+	}
+
+	expectedNum := int32(clusterManager.Spec.MasterNum)
+	if isTopologyManaged(cluster) {
+		replicas := cluster.Spec.Topology.ControlPlane.Replicas
+		if replicas == nil || *replicas != expectedNum {
+			cluster.Spec.Topology.ControlPlane.Replicas = &expectedNum
+			if err := r.Update(context.TODO(), cluster); err != nil {
+				log.Error(err, "Failed to update controlplane replicas of cluster topology")
+				return ctrl.Result{}, err
+			}
+			return ctrl.Result{Requeue: true}, nil
+		}
 	}
 
-	kcp := &controlplanev1.KubeadmControlPlane{}
-	if err := r.Client.Get(context.TODO(), key, kcp); errors.IsNotFound(err) {
+	kcp, err := r.GetKubeadmControlPlane(cluster)
+	if errors.IsNotFound(err) {
 		log.Error(err, "Cannot find kubeadmcontrolplane")
 		return ctrl.Result{}, err
 	} else if err != nil {
@@ -347,14 +357,18 @@ func (r *ClusterManagerReconciler) ScaleControlplane(ctx context.Context, cluste
 		return ctrl.Result{}, err
 	}
 
-	expectedNum := int32(clusterManager.Spec.MasterNum)
-	if *kcp.Spec.Replicas != expectedNum {
-		*kcp.Spec.Replicas = expectedNum
+	// topology 인 경우 topology controller 가 kcp 의 replicas 를 변경한다.
+	if kcp.Spec.Replicas == nil || *kcp.Spec.Replicas != expectedNum {
+		if isTopologyManaged(cluster) {
+			log.Info("Waiting for topology controller to update kubeadmcontrolplane")
+			return ctrl.Result{Requeue: true}, nil
+		}
+		kcp.Spec.Replicas = &expectedNum
 		if err := r.Update(context.TODO(), kcp); err != nil {
 			log.Info("Failed to update kubadmcontrolplane")
 			return ctrl.Result{}, err
 		}
-		return ctrl.Result{RequeueAfter: requeueAfter20Second}, nil
+		return ctrl.Result{Requeue: true}, nil
 	}
 
 	if kcp.Status.ReadyReplicas == *kcp.Spec.Replicas {
@@ -363,11 +377,11 @@ func (r *ClusterManagerReconciler) ScaleControlplane(ctx context.Context, cluste
 		return ctrl.Result{}, nil
 	}
 	if clusterManager.Spec.MasterNum > clusterManager.Status.MasterNum {
-		log.Info("Waiting for Controlplane nodes to be scaled out. Requeue after 1 min.")
+		log.Info("Waiting for Controlplane nodes to be scaled out")
 	} else {
-		log.Info("Waiting for Controlplane nodes to be scaled in. Requeue after 1 min.")
+		log.Info("Waiting for Controlplane nodes to be scaled in")
 	}
-	return ctrl.Result{RequeueAfter: requeueAfter1Minute}, nil
+	return ctrl.Result{Requeue: true}, nil
 }
 
 // worker를 scaling한다.
@@ -375,27 +389,48 @@ func (r *ClusterManagerReconciler) ScaleWorker(ctx context.Context, clusterManag
 	log := r.Log.WithValues("clustermanager", clusterManager.GetNamespacedName())
 	log.Info("Start to reconcile phase for WorkerScaling")
 
-	key := types.NamespacedName{
-		Name:      clusterManager.Name + "-md-0",
-		Namespace: clusterManager.Namespace,
+	cluster, err := r.GetCapiCluster(clusterManager)
+	if err != nil {
+		log.Error(err, "Failed to get cluster")
+		return ctrl.Result{}, err
+	}
+
+	expectedNum := int32(clusterManager.Spec.WorkerNum)
+	if isTopologyManaged(cluster) {
+		topology := workerMachineDeploymentTopology(cluster)
+		if topology == nil {
+			return ctrl.Result{}, nil
+		}
+		if topology.Replicas == nil || *topology.Replicas != expectedNum {
+			topology.Replicas = &expectedNum
+			if err := r.Update(context.TODO(), cluster); err != nil {
+				log.Error(err, "Failed to update worker replicas of cluster topology")
+				return ctrl.Result{}, err
+			}
+			return ctrl.Result{Requeue: true}, nil
+		}
 	}
 
-	md := &capiV1alpha3.MachineDeployment{}
-	if err := r.Client.Get(context.TODO(), key, md); errors.IsNotFound(err) {
+	md, err := r.GetWorkerMachineDeployment(cluster)
+	if errors.IsNotFound(err) {
 		return ctrl.Result{}, nil
 	} else if err != nil {
 		log.Error(err, "Failed to get machineDeployment")
 		return ctrl.Result{}, err
 	}
 
-	expectedNum := int32(clusterManager.Spec.WorkerNum)
-	if *md.Spec.Replicas != expectedNum {
-		*md.Spec.Replicas = expectedNum
+	// topology 인 경우 topology controller 가 machine deployment 의 replicas 를 변경한다.
+	if md.Spec.Replicas == nil || *md.Spec.Replicas != expectedNum {
+		if isTopologyManaged(cluster) {
+			log.Info("Waiting for topology controller to update machineDeployment")
+			return ctrl.Result{Requeue: true}, nil
+		}
+		md.Spec.Replicas = &expectedNum
 		if err := r.Update(context.TODO(), md); err != nil {
 			log.Info("Failed to update machineDeployment")
 			return ctrl.Result{}, err
 		}
-		return ctrl.Result{RequeueAfter: requeueAfter20Second}, nil
+		return ctrl.Result{Requeue: true}, nil
 	}
 
 	if md.Status.ReadyReplicas == *md.Spec.Replicas {
@@ -404,20 +439,40 @@ func (r *ClusterManagerReconciler) ScaleWorker(ctx context.Context, clusterManag
 		return ctrl.Result{}, nil
 	}
 	if clusterManager.Spec.WorkerNum > clusterManager.Status.WorkerNum {
-		log.Info("Waiting for Worker nodes to be scaled out. Requeue after 20sec.")
+		log.Info("Waiting for Worker nodes to be scaled out")
 	} else {
-		log.Info("Waiting for Worker nodes to be scaled in. Requeue after 20sec.")
+		log.Info("Waiting for Worker nodes to be scaled in")
 	}
-	return ctrl.Result{RequeueAfter: requeueAfter20Second}, nil
+	return ctrl.Result{Requeue: true}, nil
 }
 
 // UpgradeCluster는 controlplane, worker순으로 진행된다.
 // controlplane upgrade가 완료되면, worker upgrade를 진행한다.
+// topology 로 생성된 cluster 는 topology 의 version 만 변경하면 topology controller 가 같은 순서로 upgrade 한다.
 func (r *ClusterManagerReconciler) UpgradeCluster(ctx context.Context, clusterManager *clusterV1alpha1.ClusterManager) (ctrl.Result, error) {
 	log := r.Log.WithValues("clustermanager", clusterManager.GetNamespacedName())
 	log.Info("Start to reconcile phase for ClusterUpgrade")
 
-	if clusterManager.Spec.Provider == clusterV1alpha1.ProviderVSphere {
+	cluster, err := r.GetCapiCluster(clusterManager)
+	if err != nil {
+		log.Error(err, "Failed to get cluster")
+		return ctrl.Result{}, err
+	}
+	topologyManaged := isTopologyManaged(cluster)
+
+	if topologyManaged {
+		if cluster.Spec.Topology.Version != clusterManager.GetK8SVersion() {
+			if !inMaintenanceWindow(clusterManager) {
+				return r.waitForMaintenanceWindow(clusterManager, "upgrade"), nil
+			}
+			cluster.Spec.Topology.Version = clusterManager.GetK8SVersion()
+			if err := r.Update(context.TODO(), cluster); err != nil {
+				log.Error(err, "Failed to update version of cluster topology")
+				return ctrl.Result{}, err
+			}
+			return ctrl.Result{Requeue: true}, nil
+		}
+	} else if clusterManager.Spec.Provider == clusterV1alpha1.ProviderVSphere {
 		// template instance 체크 for controlplane
 		templateInstanceName := fmt.Sprintf("%s-controlplane-%s", clusterManager.Name, clusterManager.GetK8SVersion())
 		key := types.NamespacedName{
@@ -428,7 +483,7 @@ func (r *ClusterManagerReconciler) UpgradeCluster(ctx context.Context, clusterMa
 		templateinstance := &tmaxv1.TemplateInstance{}
 		if err := r.Client.Get(context.TODO(), key, templateinstance); errors.IsNotFound(err) {
 			log.Info("Waiting for vsphere upgrade templateinstance(controlplane) to be created")
-			return ctrl.Result{RequeueAfter: requeueAfter10Second}, nil
+			return ctrl.Result{Requeue: true}, nil
 		} else if err != nil {
 			log.Error(err, "Failed to get templateinstance")
 			return ctrl.Result{}, err
@@ -436,7 +491,7 @@ func (r *ClusterManagerReconciler) UpgradeCluster(ctx context.Context, clusterMa
 
 		if !checkTemplateInstanceDeployed(templateinstance) {
 			log.Info("Waiting for vsphere upgrade templateinstance(controlplane) to be provisioned")
-			return ctrl.Result{RequeueAfter: requeueAfter10Second}, nil
+			return ctrl.Result{Requeue: true}, nil
 		}
 
 		// template instance 체크 for worker
@@ -449,7 +504,7 @@ func (r *ClusterManagerReconciler) UpgradeCluster(ctx context.Context, clusterMa
 		templateinstance = &tmaxv1.TemplateInstance{}
 		if err := r.Client.Get(context.TODO(), key, templateinstance); errors.IsNotFound(err) {
 			log.Info("Waiting for vsphere upgrade templateinstance(worker) to be created")
-			return ctrl.Result{RequeueAfter: requeueAfter10Second}, nil
+			return ctrl.Result{Requeue: true}, nil
 		} else if err != nil {
 			log.Error(err, "Failed to get templateinstance")
 			return ctrl.Result{}, err
@@ -457,101 +512,104 @@ func (r *ClusterManagerReconciler) UpgradeCluster(ctx context.Context, clusterMa
 
 		if !checkTemplateInstanceDeployed(templateinstance) {
 			log.Info("Waiting for vsphere upgrade templateinstance(worker) to be provisioned")
-			return ctrl.Result{RequeueAfter: requeueAfter10Second}, nil
+			return ctrl.Result{Requeue: true}, nil
 		}
 	}
 
 	// 1. kcp 업데이트
-	key := types.NamespacedName{
-		Name:      clusterManager.Name + "-control-plane",
-		Namespace: clusterManager.Namespace,
-	}
-
-	kcp := &controlplanev1.KubeadmControlPlane{}
-	if err := r.Client.Get(context.TODO(), key, kcp); errors.IsNotFound(err) {
-		log.Error(err, "Cannot find kubeadmcontrolplane")
-		return ctrl.Result{}, err
-	} else if err != nil {
-		log.Error(err, "Failed to get kubeadmcontrolplane")
-		return ctrl.Result{}, err
-	}
-
-	// 단일 트랜잭션으로 업데이트 필요
-	if kcp.Spec.Version != clusterManager.GetK8SVersion() {
-		kcp.Spec.Version = clusterManager.GetK8SVersion()
-		if clusterManager.Spec.Provider == clusterV1alpha1.ProviderVSphere {
-			kcp.Spec.InfrastructureTemplate.Name = fmt.Sprintf("%s-controlplane-%s", clusterManager.Name, clusterManager.GetK8SVersion())
-		}
-		if err := r.Update(context.TODO(), kcp); err != nil {
-			log.Error(err, "Failed to update kubeadmcontrolplane")
+	if !topologyManaged {
+		kcp, err := r.GetKubeadmControlPlane(cluster)
+		if errors.IsNotFound(err) {
+			log.Error(err, "Cannot find kubeadmcontrolplane")
+			return ctrl.Result{}, err
+		} else if err != nil {
+			log.Error(err, "Failed to get kubeadmcontrolplane")
 			return ctrl.Result{}, err
 		}
-		return ctrl.Result{RequeueAfter: requeueAfter10Second}, nil
+
+		// 단일 트랜잭션으로 업데이트 필요
+		if kcp.Spec.Version != clusterManager.GetK8SVersion() {
+			if !inMaintenanceWindow(clusterManager) {
+				return r.waitForMaintenanceWindow(clusterManager, "controlplane upgrade"), nil
+			}
+			kcp.Spec.Version = clusterManager.GetK8SVersion()
+			if clusterManager.Spec.Provider == clusterV1alpha1.ProviderVSphere {
+				kcp.Spec.MachineTemplate.InfrastructureRef.Name = fmt.Sprintf("%s-controlplane-%s", clusterManager.Name, clusterManager.GetK8SVersion())
+			}
+			if err := r.Update(context.TODO(), kcp); err != nil {
+				log.Error(err, "Failed to update kubeadmcontrolplane")
+				return ctrl.Result{}, err
+			}
+			return ctrl.Result{Requeue: true}, nil
+		}
 	}
 
 	// upgrade 완료한 machine 찾기
 	machines, err := r.GetUpgradeControlplaneMachines(clusterManager)
 	if err != nil {
 		log.Error(err, "Failed to list machines")
-		return ctrl.Result{RequeueAfter: requeueAfter10Second}, nil
+		return ctrl.Result{Requeue: true}, nil
 	}
 
 	if len(machines.NewMachineRunningList) == clusterManager.Spec.MasterNum {
 		log.Info(fmt.Sprintf("Controlplane nodes upgraded successfully (%d/%d)", len(machines.NewMachineRunningList), clusterManager.Spec.MasterNum))
 	} else {
 		log.Info(fmt.Sprintf("Controlplane nodes are upgrading (%d/%d)", len(machines.NewMachineRunningList), clusterManager.Spec.MasterNum))
-		log.Info(fmt.Sprintf("Need to upgrade machine: [%s]. Requeue After 1 min", strings.Join(machines.OldMachineList, ", ")))
-		return ctrl.Result{RequeueAfter: requeueAfter1Minute}, nil
+		log.Info(fmt.Sprintf("Need to upgrade machine: [%s]", strings.Join(machines.OldMachineList, ", ")))
+		return ctrl.Result{Requeue: true}, nil
 	}
 
 	// 2. machineDeployment 업데이트
-	key = types.NamespacedName{
-		Name:      clusterManager.Name + "-md-0",
-		Namespace: clusterManager.Namespace,
-	}
-
-	md := &capiV1alpha3.MachineDeployment{}
-	if err := r.Client.Get(context.TODO(), key, md); errors.IsNotFound(err) {
-		return ctrl.Result{}, nil
-	} else if err != nil {
-		log.Error(err, "Failed to get machineDeployment")
-		return ctrl.Result{}, err
-	}
-
-	if *md.Spec.Template.Spec.Version != clusterManager.GetK8SVersion() {
-		*md.Spec.Template.Spec.Version = clusterManager.GetK8SVersion()
-		if clusterManager.Spec.Provider == clusterV1alpha1.ProviderVSphere {
-			md.Spec.Template.Spec.InfrastructureRef.Name = fmt.Sprintf("%s-worker-%s", clusterManager.Name, clusterManager.GetK8SVersion())
-		}
-		if err := r.Update(context.TODO(), md); err != nil {
-			log.Error(err, "Failed to update machinedeployment")
+	if !topologyManaged {
+		md, err := r.GetWorkerMachineDeployment(cluster)
+		if errors.IsNotFound(err) {
+			return ctrl.Result{}, nil
+		} else if err != nil {
+			log.Error(err, "Failed to get machineDeployment")
 			return ctrl.Result{}, err
 		}
-		return ctrl.Result{RequeueAfter: requeueAfter10Second}, nil
+
+		if *md.Spec.Template.Spec.Version != clusterManager.GetK8SVersion() {
+			// control plane 의 upgrade 가 유지보수 시간 안에 끝나지 않은 경우 worker 는 다음 유지보수 시간에 upgrade 한다.
+			if !inMaintenanceWindow(clusterManager) {
+				return r.waitForMaintenanceWindow(clusterManager, "worker upgrade"), nil
+			}
+			*md.Spec.Template.Spec.Version = clusterManager.GetK8SVersion()
+			if clusterManager.Spec.Provider == clusterV1alpha1.ProviderVSphere {
+				md.Spec.Template.Spec.InfrastructureRef.Name = fmt.Sprintf("%s-worker-%s", clusterManager.Name, clusterManager.GetK8SVersion())
+			}
+			if err := r.Update(context.TODO(), md); err != nil {
+				log.Error(err, "Failed to update machinedeployment")
+				return ctrl.Result{}, err
+			}
+			return ctrl.Result{Requeue: true}, nil
+		}
 	}
 
 	// upgrade 완료한 machine 찾기
 	machines, err = r.GetUpgradeWorkerMachines(clusterManager)
 	if err != nil {
 		log.Error(err, "Failed to list machines")
-		return ctrl.Result{RequeueAfter: requeueAfter10Second}, nil
+		return ctrl.Result{Requeue: true}, nil
 	}
 
 	if len(machines.NewMachineRunningList) == clusterManager.Spec.WorkerNum {
 		log.Info(fmt.Sprintf("worker nodes upgraded successfully (%d/%d)", len(machines.NewMachineRunningList), clusterManager.Spec.WorkerNum))
 	} else {
 		log.Info(fmt.Sprintf("worker nodes are upgrading (%d/%d)", len(machines.NewMachineRunningList), clusterManager.Spec.WorkerNum))
-		log.Info(fmt.Sprintf("Need to upgrade machine: [%s]. Requeue After 1 min", strings.Join(machines.OldMachineList, ", ")))
-		return ctrl.Result{RequeueAfter: requeueAfter1Minute}, nil
+		log.Info(fmt.Sprintf("Need to upgrade machine: [%s]", strings.Join(machines.OldMachineList, ", ")))
+		return ctrl.Result{Requeue: true}, nil
 	}
 
 	clusterManager.Status.SetK8SVersion(clusterManager.Spec.Version)
 	log.Info("Cluster upgradeded successfully")
+	r.notify(clusterManager, clusterV1alpha1.NotificationEventUpgradeFinished, "Cluster is upgraded to "+clusterManager.Spec.Version)
 	return ctrl.Result{}, nil
 }
 
 // KubeadmControlPlaneUpdate는 cluster manager의 master num와 kubeadmcontrolplane의 replicas를 비교하여
 // kubeadmcontrolplane의 replicas를 cluster manager의 master num으로 업데이트한다.
+// topology 로 생성된 cluster 는 topology 의 controlplane replicas 를 업데이트한다.
 func (r *ClusterManagerReconciler) KubeadmControlPlaneUpdate(ctx context.Context, clusterManager *clusterV1alpha1.ClusterManager) (ctrl.Result, error) {
 	// scaling 하는 경우에 대해서는 실행하지 않음
 	if clusterManager.Spec.MasterNum != clusterManager.Status.MasterNum {
@@ -561,20 +619,38 @@ func (r *ClusterManagerReconciler) KubeadmControlPlaneUpdate(ctx context.Context
 	log := r.Log.WithValues("clustermanager", clusterManager.GetNamespacedName())
 	log.Info("Start to reconcile phase for kubeadmControlPlaneUpdate")
 
-	key := types.NamespacedName{
-		Name:      clusterManager.Name + "-control-plane",
-		Namespace: clusterManager.Namespace,
+	cluster, err := r.GetCapiCluster(clusterManager)
+	if errors.IsNotFound(err) {
+		return ctrl.Result{}, nil
+	} else if err != nil {
+		log.Error(err, "Failed to get cluster")
+		return ctrl.Result{}, err
 	}
-	kcp := &controlplanev1.KubeadmControlPlane{}
-	if err := r.Client.Get(context.TODO(), key, kcp); errors.IsNotFound(err) {
+
+	masterNum := int32(clusterManager.Spec.MasterNum)
+	if isTopologyManaged(cluster) {
+		replicas := cluster.Spec.Topology.ControlPlane.Replicas
+		if replicas == nil || *replicas != masterNum {
+			cluster.Spec.Topology.ControlPlane.Replicas = &masterNum
+			if err := r.Client.Update(context.TODO(), cluster); err != nil {
+				log.Error(err, "Failed to update controlplane replicas of cluster topology")
+				return ctrl.Result{}, err
+			}
+			log.Info("Updated controlplane replicas of cluster topology", "replicas", masterNum)
+		}
+		return ctrl.Result{}, nil
+	}
+
+	kcp, err := r.GetKubeadmControlPlane(cluster)
+	if errors.IsNotFound(err) {
 		return ctrl.Result{}, nil
 	} else if err != nil {
 		log.Error(err, "Failed to get kubeadmControlPlane")
 		return ctrl.Result{}, err
 	}
 
-	if *kcp.Spec.Replicas != int32(clusterManager.Spec.MasterNum) {
-		*kcp.Spec.Replicas = int32(clusterManager.Spec.MasterNum)
+	if kcp.Spec.Replicas == nil || *kcp.Spec.Replicas != masterNum {
+		kcp.Spec.Replicas = &masterNum
 		if err := r.Client.Update(context.TODO(), kcp); err != nil {
 			log.Error(err, "Failed to update kubeadmcontrolplane")
 			return ctrl.Result{}, err
@@ -586,6 +662,7 @@ func (r *ClusterManagerReconciler) KubeadmControlPlaneUpdate(ctx context.Context
 
 // MachineDeploymentUpdate는 cluster manager의 worker num와 machinedeployment의 replicas를 비교하여
 // machinedeployment의 replicas를 cluster manager의 worker num으로 업데이트한다.
+// topology 로 생성된 cluster 는 topology 의 machine deployment replicas 를 업데이트한다.
 func (r *ClusterManagerReconciler) MachineDeploymentUpdate(ctx context.Context, clusterManager *clusterV1alpha1.ClusterManager) (ctrl.Result, error) {
 	// scaling 하는 경우에 대해서는 실행하지 않음
 	if clusterManager.Spec.WorkerNum != clusterManager.Status.WorkerNum {
@@ -595,20 +672,38 @@ func (r *ClusterManagerReconciler) MachineDeploymentUpdate(ctx context.Context,
 	log := r.Log.WithValues("clustermanager", clusterManager.GetNamespacedName())
 	log.Info("Start to reconcile phase for machineDeploymentUpdate")
 
-	key := types.NamespacedName{
-		Name:      clusterManager.Name + "-md-0",
-		Namespace: clusterManager.Namespace,
+	cluster, err := r.GetCapiCluster(clusterManager)
+	if errors.IsNotFound(err) {
+		return ctrl.Result{}, nil
+	} else if err != nil {
+		log.Error(err, "Failed to get cluster")
+		return ctrl.Result{}, err
 	}
-	md := &capiV1alpha3.MachineDeployment{}
-	if err := r.Client.Get(context.TODO(), key, md); errors.IsNotFound(err) {
+
+	workerNum := int32(clusterManager.Spec.WorkerNum)
+	if isTopologyManaged(cluster) {
+		topology := workerMachineDeploymentTopology(cluster)
+		if topology != nil && (topology.Replicas == nil || *topology.Replicas != workerNum) {
+			topology.Replicas = &workerNum
+			if err := r.Client.Update(context.TODO(), cluster); err != nil {
+				log.Error(err, "Failed to update worker replicas of cluster topology")
+				return ctrl.Result{}, err
+			}
+			log.Info("Updated worker replicas of cluster topology", "replicas", workerNum)
+		}
+		return ctrl.Result{}, nil
+	}
+
+	md, err := r.GetWorkerMachineDeployment(cluster)
+	if errors.IsNotFound(err) {
 		return ctrl.Result{}, nil
 	} else if err != nil {
 		log.Error(err, "Failed to get machineDeployment")
 		return ctrl.Result{}, err
 	}
 
-	if *md.Spec.Replicas != int32(clusterManager.Spec.WorkerNum) {
-		*md.Spec.Replicas = int32(clusterManager.Spec.WorkerNum)
+	if md.Spec.Replicas == nil || *md.Spec.Replicas != workerNum {
+		md.Spec.Replicas = &workerNum
 		if err := r.Client.Update(context.Background(), md); err != nil {
 			log.Error(err, "Failed to update machinedeployment")
 			return ctrl.Result{}, err
@@ -619,6 +714,295 @@ func (r *ClusterManagerReconciler) MachineDeploymentUpdate(ctx context.Context,
 	return ctrl.Result{}, nil
 }
 
+// ReconcileNodePools 는 spec.nodePools 의 node pool 마다 worker 와 별도의 machine deployment 를 생성하고,
+// spec 에서 제거된 node pool 의 machine deployment 를 삭제한다.
+// topology 로 생성된 cluster 는 topology controller 가 machine deployment 를 관리하므로 지원하지 않는다.
+func (r *ClusterManagerReconciler) ReconcileNodePools(ctx context.Context, clusterManager *clusterV1alpha1.ClusterManager) (ctrl.Result, error) {
+	log := r.Log.WithValues("clustermanager", clusterManager.GetNamespacedName())
+	log.Info("Start to reconcile phase for ReconcileNodePools")
+
+	cluster, err := r.GetCapiCluster(clusterManager)
+	if errors.IsNotFound(err) {
+		return ctrl.Result{}, nil
+	} else if err != nil {
+		log.Error(err, "Failed to get cluster")
+		return ctrl.Result{}, err
+	}
+	if isTopologyManaged(cluster) {
+		if len(clusterManager.Spec.NodePools) != 0 {
+			log.Info("Node pools are not supported for the cluster created from cluster class")
+		}
+		return ctrl.Result{}, nil
+	}
+
+	if err := r.deleteRemovedNodePools(clusterManager); err != nil {
+		log.Error(err, "Failed to delete removed node pools")
+		return ctrl.Result{}, err
+	}
+	if len(clusterManager.Spec.NodePools) == 0 {
+		clusterManager.Status.NodePools = nil
+		return ctrl.Result{}, nil
+	}
+
+	// node pool 의 template 은 worker 의 template 을 기반으로 생성한다.
+	worker, err := r.GetWorkerMachineDeployment(cluster)
+	if errors.IsNotFound(err) {
+		log.Info("Wait for worker machine deployment to be created")
+		return ctrl.Result{Requeue: true}, nil
+	} else if err != nil {
+		log.Error(err, "Failed to get machineDeployment")
+		return ctrl.Result{}, err
+	}
+
+	// eviction 수는 이전 status 에서 이어서 집계한다.
+	oldStatuses := map[string]clusterV1alpha1.NodePool{}
+	for _, status := range clusterManager.Status.NodePools {
+		oldStatuses[status.Name] = status
+	}
+	statuses := []clusterV1alpha1.NodePool{}
+	for i := range clusterManager.Spec.NodePools {
+		pool := &clusterManager.Spec.NodePools[i]
+		if err := r.createNodePoolInfrastructureTemplate(clusterManager, pool, &worker.Spec.Template.Spec.InfrastructureRef); err != nil {
+			log.Error(err, "Failed to create infrastructure machine template for node pool", "nodePool", pool.Name)
+			return ctrl.Result{}, err
+		}
+		if err := r.createNodePoolKubeadmConfigTemplate(clusterManager, pool, worker.Spec.Template.Spec.Bootstrap.ConfigRef); err != nil {
+			log.Error(err, "Failed to create kubeadm config template for node pool", "nodePool", pool.Name)
+			return ctrl.Result{}, err
+		}
+		md, err := r.reconcileNodePoolMachineDeployment(clusterManager, pool, worker)
+		if err != nil {
+			log.Error(err, "Failed to reconcile machine deployment for node pool", "nodePool", pool.Name)
+			return ctrl.Result{}, err
+		}
+
+		status := oldStatuses[pool.Name]
+		status.Name = pool.Name
+		status.Role = util.NodeRoleWorker
+		status.Replicas = pool.Replicas
+		status.Ready = int(md.Status.ReadyReplicas)
+		status.Spot = pool.Spot
+		if pool.Spot {
+			if err := r.countNodePoolEvictions(clusterManager, pool, &status); err != nil {
+				log.Error(err, "Failed to count evictions of node pool", "nodePool", pool.Name)
+				return ctrl.Result{}, err
+			}
+		}
+		statuses = append(statuses, status)
+	}
+	clusterManager.Status.NodePools = statuses
+
+	return ctrl.Result{}, nil
+}
+
+// SyncNodeConfig 는 spec.nodeConfigs 의 label, taint 를 remote cluster 의 node 에 적용한다.
+// 사용자가 member cluster 에서 직접 변경하거나 새 node 가 join 하는 경우를 watch 할 수 없으므로 주기적으로 다시 적용한다.
+func (r *ClusterManagerReconciler) SyncNodeConfig(ctx context.Context, clusterManager *clusterV1alpha1.ClusterManager) (ctrl.Result, error) {
+	// 한 번도 적용한 적이 없으면 정리할 label, taint 도 없다.
+	synced := meta.FindStatusCondition(clusterManager.Status.Conditions, clusterV1alpha1.ClusterManagerConditionNodeConfigSynced)
+	if len(clusterManager.Spec.NodeConfigs) == 0 && synced == nil {
+		return ctrl.Result{}, nil
+	}
+	if !clusterManager.Status.ControlPlaneReady {
+		return ctrl.Result{}, nil
+	}
+
+	log := r.Log.WithValues("clustermanager", clusterManager.GetNamespacedName())
+	log.Info("Start to reconcile phase for SyncNodeConfig")
+
+	condition := metav1.Condition{
+		Type:               clusterV1alpha1.ClusterManagerConditionNodeConfigSynced,
+		Status:             metav1.ConditionFalse,
+		ObservedGeneration: clusterManager.Generation,
+	}
+	setFailed := func(reason string, err error) {
+		condition.Reason = reason
+		condition.Message = err.Error()
+		meta.SetStatusCondition(&clusterManager.Status.Conditions, condition)
+	}
+
+	kubeconfigSecret, err := r.GetKubeconfigSecret(clusterManager)
+	if err != nil {
+		log.Error(err, "Failed to get kubeconfig secret")
+		return ctrl.Result{Requeue: true}, nil
+	}
+	remoteClientset, err := util.GetRemoteK8sClient(kubeconfigSecret)
+	if err != nil {
+		log.Error(err, "Failed to get remoteK8sClient")
+		setFailed("RemoteClientFailed", err)
+		return ctrl.Result{}, err
+	}
+
+	nodeList, err := remoteClientset.CoreV1().Nodes().List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		log.Error(err, "Failed to list nodes of remote cluster")
+		setFailed("ListNodesFailed", err)
+		return ctrl.Result{}, err
+	}
+	updated := 0
+	for i := range nodeList.Items {
+		node := &nodeList.Items[i]
+		if !util.ApplyNodeConfigs(node, clusterManager.Spec.NodeConfigs) {
+			continue
+		}
+		if _, err := remoteClientset.CoreV1().Nodes().Update(context.TODO(), node, metav1.UpdateOptions{}); err != nil {
+			log.Error(err, "Failed to update node of remote cluster", "node", node.Name)
+			setFailed("UpdateNodeFailed", err)
+			return ctrl.Result{}, err
+		}
+		updated++
+	}
+	if updated != 0 {
+		log.Info("Applied node config to nodes", "updated", updated)
+	}
+
+	if len(clusterManager.Spec.NodeConfigs) == 0 {
+		meta.RemoveStatusCondition(&clusterManager.Status.Conditions, clusterV1alpha1.ClusterManagerConditionNodeConfigSynced)
+		return ctrl.Result{}, nil
+	}
+	condition.Status = metav1.ConditionTrue
+	condition.Reason = "Synced"
+	condition.Message = fmt.Sprintf("Node config is applied to %d nodes", len(nodeList.Items))
+	meta.SetStatusCondition(&clusterManager.Status.Conditions, condition)
+
+	return util.RequeueAfterWithJitter(getClusterResyncPeriod(clusterManager)), nil
+}
+
+// UpdateProviderIdentity 는 remote cluster 의 node 로부터 cloud account, region 을 조회하여 status 에 반영한다.
+// cloud api 를 호출하지 않고 account, region 별로 cluster 를 조회할 수 있도록 한다.
+// node 의 providerID 는 cloud controller manager 가 설정하므로, provider 를 확인할 때까지 주기적으로 다시 조회한다.
+func (r *ClusterManagerReconciler) UpdateProviderIdentity(ctx context.Context, clusterManager *clusterV1alpha1.ClusterManager) (ctrl.Result, error) {
+	identity := clusterManager.Status.ProviderIdentity
+	if !clusterManager.Status.ControlPlaneReady || (identity != nil && identity.ProviderIDPrefix != "") {
+		return ctrl.Result{}, nil
+	}
+
+	log := r.Log.WithValues("clustermanager", clusterManager.GetNamespacedName())
+	log.Info("Start to reconcile phase for UpdateProviderIdentity")
+
+	kubeconfigSecret, err := r.GetKubeconfigSecret(clusterManager)
+	if err != nil {
+		log.Error(err, "Failed to get kubeconfig secret")
+		return ctrl.Result{Requeue: true}, nil
+	}
+	remoteClientset, err := util.GetRemoteK8sClient(kubeconfigSecret)
+	if err != nil {
+		log.Error(err, "Failed to get remoteK8sClient")
+		return ctrl.Result{}, err
+	}
+
+	identity, err = util.DetectProviderIdentity(context.TODO(), remoteClientset)
+	if err != nil {
+		log.Error(err, "Failed to detect provider identity from remote cluster")
+		return ctrl.Result{}, err
+	}
+	clusterManager.Status.ProviderIdentity = identity
+	if identity.ProviderIDPrefix == "" {
+		log.Info("ProviderID of nodes is not set yet")
+		return util.RequeueAfterWithJitter(getClusterResyncPeriod(clusterManager)), nil
+	}
+	log.Info("Detected provider identity", "providerIDPrefix", identity.ProviderIDPrefix, "account", identity.Account, "region", identity.Region)
+
+	return ctrl.Result{}, nil
+}
+
+// spec.addons.certManager 에 따라 argocd application 의 cert-manager module 을 활성화하고,
+// DNS01 credential 과 ClusterIssuer 를 member cluster 에 배포한다.
+func (r *ClusterManagerReconciler) DeployCertManagerAddon(ctx context.Context, clusterManager *clusterV1alpha1.ClusterManager) (ctrl.Result, error) {
+	certManager := clusterManager.GetCertManagerAddon()
+	// 한 번도 배포한 적이 없으면 정리할 리소스도 없다.
+	ready := meta.FindStatusCondition(clusterManager.Status.Conditions, clusterV1alpha1.ClusterManagerConditionCertManagerReady)
+	if certManager == nil && ready == nil {
+		return ctrl.Result{}, nil
+	}
+	// argocd application 은 CreateArgocdResources 에서 생성된다.
+	if !clusterManager.Status.ArgoReady {
+		return ctrl.Result{}, nil
+	}
+
+	log := r.Log.WithValues("clustermanager", clusterManager.GetNamespacedName())
+	log.Info("Start to reconcile phase for DeployCertManagerAddon")
+
+	// 처음 배포하는 경우가 아니면 addon 변경은 유지보수 시간에만 반영한다.
+	if ready != nil && !inMaintenanceWindow(clusterManager) {
+		return r.waitForMaintenanceWindow(clusterManager, "addon update"), nil
+	}
+
+	condition := metav1.Condition{
+		Type:               clusterV1alpha1.ClusterManagerConditionCertManagerReady,
+		Status:             metav1.ConditionFalse,
+		ObservedGeneration: clusterManager.Generation,
+	}
+	setFailed := func(reason string, err error) {
+		condition.Reason = reason
+		condition.Message = err.Error()
+		meta.SetStatusCondition(&clusterManager.Status.Conditions, condition)
+	}
+
+	if err := r.syncCertManagerApplication(clusterManager); err != nil {
+		log.Error(err, "Failed to update cert-manager module of argocd application")
+		setFailed("ApplicationUpdateFailed", err)
+		return ctrl.Result{}, err
+	}
+
+	kubeconfigSecret, err := r.GetKubeconfigSecret(clusterManager)
+	if err != nil {
+		log.Error(err, "Failed to get kubeconfig secret")
+		return ctrl.Result{Requeue: true}, nil
+	}
+	remoteClientset, err := util.GetRemoteK8sClient(kubeconfigSecret)
+	if err != nil {
+		log.Error(err, "Failed to get remoteK8sClient")
+		setFailed("RemoteClientFailed", err)
+		return ctrl.Result{}, err
+	}
+	remoteClient, err := util.GetRemoteK8sRuntimeClient(kubeconfigSecret)
+	if err != nil {
+		log.Error(err, "Failed to get remote runtime client")
+		setFailed("RemoteClientFailed", err)
+		return ctrl.Result{}, err
+	}
+
+	if certManager == nil {
+		if err := deleteCertManagerResources(remoteClient, remoteClientset); err != nil {
+			log.Error(err, "Failed to delete cert-manager resources from remote cluster")
+			setFailed("DeleteFailed", err)
+			return ctrl.Result{}, err
+		}
+		meta.RemoveStatusCondition(&clusterManager.Status.Conditions, clusterV1alpha1.ClusterManagerConditionCertManagerReady)
+		log.Info("Delete cert-manager resources from remote cluster successfully")
+		return ctrl.Result{}, nil
+	}
+
+	credentials, err := r.propagateDNS01Credentials(clusterManager, remoteClientset)
+	if err != nil {
+		log.Error(err, "Failed to propagate DNS01 credentials to remote cluster")
+		setFailed("CredentialsFailed", err)
+		return ctrl.Result{}, err
+	}
+
+	installed, err := applyCertManagerClusterIssuer(remoteClient, newCertManagerClusterIssuer(clusterManager, credentials))
+	if err != nil {
+		log.Error(err, "Failed to apply ClusterIssuer to remote cluster")
+		setFailed("ClusterIssuerFailed", err)
+		return ctrl.Result{}, err
+	}
+	if !installed {
+		// argocd 가 cert-manager 를 설치할 때까지 기다린다.
+		log.Info("Wait for cert-manager to be installed")
+		setFailed("Installing", fmt.Errorf("cert-manager is not installed yet"))
+		return util.RequeueAfterWithJitter(getClusterResyncPeriod(clusterManager)), nil
+	}
+
+	condition.Status = metav1.ConditionTrue
+	condition.Reason = "Ready"
+	condition.Message = "ClusterIssuer " + util.CertManagerClusterIssuer + " is ready"
+	meta.SetStatusCondition(&clusterManager.Status.Conditions, condition)
+
+	// credential 이 rotate 되면 다시 복제한다.
+	return util.RequeueAfterWithJitter(getClusterResyncPeriod(clusterManager)), nil
+}
+
 func (r *ClusterManagerReconciler) CreateArgocdResources(ctx context.Context, clusterManager *clusterV1alpha1.ClusterManager) (ctrl.Result, error) {
 
 	if !clusterManager.Status.ControlPlaneReady || clusterManager.Status.ArgoReady {
@@ -631,7 +1015,7 @@ func (r *ClusterManagerReconciler) CreateArgocdResources(ctx context.Context, cl
 	kubeconfigSecret, err := r.GetKubeconfigSecret(clusterManager)
 	if err != nil {
 		log.Error(err, "Failed to get kubeconfig secret")
-		return ctrl.Result{RequeueAfter: requeueAfter10Second}, nil
+		return ctrl.Result{Requeue: true}, nil
 	}
 
 	kubeConfig, err := clientcmd.Load(kubeconfigSecret.Data["value"])
@@ -654,7 +1038,7 @@ func (r *ClusterManagerReconciler) CreateArgocdResources(ctx context.Context, cl
 		Get(context.TODO(), util.ArgoServiceAccountTokenSecret, metav1.GetOptions{})
 	if errors.IsNotFound(err) {
 		log.Info("Service account secret not found. Wait for creating")
-		return ctrl.Result{RequeueAfter: requeueAfter10Second}, nil
+		return ctrl.Result{Requeue: true}, nil
 	} else if err != nil {
 		log.Error(err, "Failed to get service account secret")
 		return ctrl.Result{}, err
@@ -683,7 +1067,7 @@ func (r *ClusterManagerReconciler) CreateArgocdResources(ctx context.Context, cl
 
 	// master cluster에 secret 생성
 	// ArgoCD에서 single cluster를 연동하기 위한 secret
-	clusterName := strings.Split(kubeconfigSecret.Name, util.KubeconfigSuffix)[0]
+	clusterName := util.KubeconfigClusterName(kubeconfigSecret)
 	key := types.NamespacedName{
 		Name:      kubeconfigSecret.Annotations[util.AnnotationKeyArgoClusterSecret],
 		Namespace: util.ArgoNamespace,
@@ -743,6 +1127,163 @@ func (r *ClusterManagerReconciler) CreateArgocdResources(ctx context.Context, cl
 	return ctrl.Result{}, nil
 }
 
+// ApplicationSet 의 cluster generator 가 cluster manager 의 label 로 cluster 를 선택할 수 있도록
+// argocd cluster secret 에 cluster manager 의 label 을 동기화한다.
+func (r *ClusterManagerReconciler) SyncArgocdClusterSecretLabels(ctx context.Context, clusterManager *clusterV1alpha1.ClusterManager) (ctrl.Result, error) {
+	if !clusterManager.Status.ArgoReady {
+		return ctrl.Result{}, nil
+	}
+	log := r.Log.WithValues("ClusterManager", clusterManager.GetNamespacedName())
+
+	argocdClusterSecret, err := r.GetArgocdClusterSecret(clusterManager)
+	if errors.IsNotFound(err) {
+		log.Info("Argocd cluster secret not found. Wait for creating")
+		return ctrl.Result{Requeue: true}, nil
+	} else if err != nil {
+		log.Error(err, "Failed to get argocd cluster secret")
+		return ctrl.Result{}, err
+	}
+
+	labels, annotations := argocdClusterSecret.GetLabels(), argocdClusterSecret.GetAnnotations()
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	changed := false
+	// cluster manager 에서 삭제된 label 은 secret 에서도 삭제한다.
+	for _, k := range strings.Split(annotations[util.AnnotationKeyArgoSyncedLabels], ",") {
+		if _, ok := clusterManager.Labels[k]; !ok && k != "" && !isReservedArgoClusterSecretLabel(k) {
+			delete(labels, k)
+			changed = true
+		}
+	}
+	keys := []string{}
+	for k, v := range clusterManager.Labels {
+		if isReservedArgoClusterSecretLabel(k) {
+			continue
+		}
+		keys = append(keys, k)
+		if labels[k] != v {
+			labels[k] = v
+			changed = true
+		}
+	}
+	sort.Strings(keys)
+	if annotations[util.AnnotationKeyArgoSyncedLabels] != strings.Join(keys, ",") {
+		annotations[util.AnnotationKeyArgoSyncedLabels] = strings.Join(keys, ",")
+		changed = true
+	}
+	if !changed {
+		return ctrl.Result{}, nil
+	}
+
+	argocdClusterSecret.SetLabels(labels)
+	argocdClusterSecret.SetAnnotations(annotations)
+	if err := r.Update(context.TODO(), argocdClusterSecret); err != nil {
+		log.Error(err, "Failed to update labels of argocd cluster secret")
+		return ctrl.Result{}, err
+	}
+
+	log.Info("Sync labels of argocd cluster secret successfully")
+	return ctrl.Result{}, nil
+}
+
+// cluster 에만 배포할 수 있는 AppProject 를 생성하고, cluster 의 owner, 멤버, team 을 AppProject 의 role 에 매핑한다.
+// 멤버의 초대 수락, role 변경, 삭제는 ClusterMember 를 watch 하여 반영한다.
+func (r *ClusterManagerReconciler) CreateArgocdAppProject(ctx context.Context, clusterManager *clusterV1alpha1.ClusterManager) (ctrl.Result, error) {
+	if !clusterManager.Status.ArgoReady {
+		return ctrl.Result{}, nil
+	}
+	log := r.Log.WithValues("ClusterManager", clusterManager.GetNamespacedName())
+
+	argocdClusterSecret, err := r.GetArgocdClusterSecret(clusterManager)
+	if errors.IsNotFound(err) {
+		log.Info("Argocd cluster secret not found. Wait for creating")
+		return ctrl.Result{Requeue: true}, nil
+	} else if err != nil {
+		log.Error(err, "Failed to get argocd cluster secret")
+		return ctrl.Result{}, err
+	}
+
+	members, err := r.listAcceptedClusterMembers(clusterManager)
+	if err != nil {
+		log.Error(err, "Failed to list ClusterMember")
+		return ctrl.Result{}, err
+	}
+
+	team := clusterManager.Annotations[util.AnnotationKeyTeam]
+	projectName := clusterManager.GetAppProjectName()
+	spec := argocdV1alpha1.AppProjectSpec{
+		Description: "AppProject for cluster [" + clusterManager.GetNamespacedPrefix() + "]",
+		SourceRepos: []string{"*"},
+		Destinations: []argocdV1alpha1.ApplicationDestination{
+			{
+				Server:    string(argocdClusterSecret.Data["server"]),
+				Namespace: "*",
+			},
+		},
+		ClusterResourceWhitelist: []metav1.GroupKind{
+			{
+				Group: "*",
+				Kind:  "*",
+			},
+		},
+		Roles: appProjectRoles(projectName, clusterManager.Annotations[util.AnnotationKeyOwner], team, members),
+	}
+
+	key := types.NamespacedName{
+		Name:      projectName,
+		Namespace: util.ArgoNamespace,
+	}
+	appProject := &argocdV1alpha1.AppProject{}
+	if err := r.Client.Get(context.TODO(), key, appProject); errors.IsNotFound(err) {
+		appProject = &argocdV1alpha1.AppProject{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      key.Name,
+				Namespace: key.Namespace,
+				Labels: map[string]string{
+					clusterV1alpha1.LabelKeyClmName:      clusterManager.Name,
+					clusterV1alpha1.LabelKeyClmNamespace: clusterManager.Namespace,
+				},
+				Annotations: map[string]string{
+					util.AnnotationKeyTeam: team,
+				},
+			},
+			Spec: spec,
+		}
+		if err := r.Create(context.TODO(), appProject); err != nil {
+			log.Error(err, "Failed to create AppProject", "appProject", key.Name)
+			return ctrl.Result{}, err
+		}
+		log.Info("Create AppProject successfully", "appProject", key.Name)
+		return ctrl.Result{}, nil
+	} else if err != nil {
+		log.Error(err, "Failed to get AppProject", "appProject", key.Name)
+		return ctrl.Result{}, err
+	}
+
+	// operator 가 관리하는 destination 과 role 만 갱신하고, 사용자가 추가한 설정은 유지한다.
+	roles := mergeAppProjectRoles(appProject.Spec.Roles, spec.Roles)
+	if appProject.Annotations[util.AnnotationKeyTeam] == team &&
+		reflect.DeepEqual(appProject.Spec.Destinations, spec.Destinations) &&
+		reflect.DeepEqual(appProject.Spec.Roles, roles) {
+		return ctrl.Result{}, nil
+	}
+	if appProject.Annotations == nil {
+		appProject.Annotations = map[string]string{}
+	}
+	appProject.Annotations[util.AnnotationKeyTeam] = team
+	appProject.Spec.Description = spec.Description
+	appProject.Spec.Destinations = spec.Destinations
+	appProject.Spec.Roles = roles
+	if err := r.Update(context.TODO(), appProject); err != nil {
+		log.Error(err, "Failed to update AppProject", "appProject", key.Name)
+		return ctrl.Result{}, err
+	}
+
+	log.Info("Update AppProject successfully", "appProject", key.Name)
+	return ctrl.Result{}, nil
+}
+
 // master cluster에서 single cluster로 가기위한 ExternalNameService 생성
 func (r *ClusterManagerReconciler) CreateGatewayResources(ctx context.Context, clusterManager *clusterV1alpha1.ClusterManager) (reconcile.Result, error) {
 	if !clusterManager.Status.ArgoReady || clusterManager.Status.GatewayReady {
@@ -754,7 +1295,7 @@ func (r *ClusterManagerReconciler) CreateGatewayResources(ctx context.Context, c
 	kubeconfigSecret, err := r.GetKubeconfigSecret(clusterManager)
 	if err != nil {
 		log.Error(err, "Failed to get kubeconfig secret")
-		return ctrl.Result{RequeueAfter: requeueAfter10Second}, nil
+		return ctrl.Result{Requeue: true}, nil
 	}
 
 	remoteClient, err := util.GetRemoteK8sClient(kubeconfigSecret)
@@ -770,8 +1311,8 @@ func (r *ClusterManagerReconciler) CreateGatewayResources(ctx context.Context, c
 		Services(util.ApiGatewayNamespace).
 		Get(context.TODO(), "gateway", metav1.GetOptions{})
 	if errors.IsNotFound(err) {
-		log.Info("Cannot find Service for gateway. Wait for installing api-gateway. Requeue after 1 min")
-		return ctrl.Result{RequeueAfter: requeueAfter1Minute}, nil
+		log.Info("Cannot find Service for gateway. Wait for installing api-gateway")
+		return ctrl.Result{Requeue: true}, nil
 	} else if err != nil {
 		log.Error(err, "Failed to get Service for gateway")
 		return ctrl.Result{}, err
@@ -781,33 +1322,33 @@ func (r *ClusterManagerReconciler) CreateGatewayResources(ctx context.Context, c
 	// k8s api-server의 endpoint도 NodePort로 되어있을 것이므로
 	// k8s api-server의 domain host를 gateway service의 endpoint로 사용
 	// single cluster의 k8s api-server domain과 gateway service의 domain중
-	// 어떤 것을 이용해야 할지 앞의 로직에서 annotation key를 통해 전달
-	annotationKey := clusterV1alpha1.AnnotationKeyClmApiserver
+	// 어떤 것을 이용해야 할지 앞의 로직에서 external name을 통해 전달
+	externalName := clusterManager.GetAPIServerEndpoint()
 	if gatewayService.Spec.Type != coreV1.ServiceTypeNodePort {
 		if gatewayService.Status.LoadBalancer.Ingress == nil {
 			err := fmt.Errorf("service for gateway's type is not LoadBalancer or not ready")
-			log.Error(err, "Service for api-gateway is not Ready. Requeue after 1 min")
-			return ctrl.Result{RequeueAfter: requeueAfter1Minute}, nil
+			log.Error(err, "Service for api-gateway is not Ready")
+			return ctrl.Result{Requeue: true}, nil
 		}
 
 		ingress := gatewayService.Status.LoadBalancer.Ingress[0]
 		hostnameOrIp := ingress.Hostname + ingress.IP
 		if hostnameOrIp == "" {
 			err := fmt.Errorf("service for gateway doesn't have both hostname and ip address")
-			log.Error(err, "Service for api-gateway is not Ready. Requeue after 1 min")
-			return ctrl.Result{RequeueAfter: requeueAfter1Minute}, nil
+			log.Error(err, "Service for api-gateway is not Ready")
+			return ctrl.Result{Requeue: true}, nil
 		}
 
-		clusterManager.Annotations[clusterV1alpha1.AnnotationKeyClmGateway] = hostnameOrIp
-		annotationKey = clusterV1alpha1.AnnotationKeyClmGateway
+		clusterManager.Status.GetEndpoints().Gateway = hostnameOrIp
+		externalName = hostnameOrIp
 	}
 
 	// single cluster의 gateway service로 연결시켜줄 external name type의 service
-	// 앞에서 받은 annotation key를 이용하여 service의 endpoint가 설정 됨
+	// 앞에서 받은 external name으로 service의 endpoint가 설정 됨
 	// ip address의 경우 k8s 기본 정책상으로는 endpoint resource로 생성하여 연결을 하는게 일반적인데
 	// ip address도 external name type service의 external name의 value로 넣을 수 있기 때문에
 	// 리소스 관리를 최소화 하기 위해 external name type으로 동일하게 생성
-	if err := r.CreateExternalNameService(clusterManager, annotationKey); err != nil {
+	if err := r.CreateExternalNameService(clusterManager, externalName); err != nil {
 		return ctrl.Result{}, err
 	}
 
@@ -961,12 +1502,19 @@ func (r *ClusterManagerReconciler) CreateHyperAuthResources(ctx context.Context,
 	// Hyperauth와 연동해야 하는 module 리스트는 정해져 있으므로, preset.go에서 관리
 	// cluster마다 client 이름이 달라야 해서 {namespace}-{cluster name} 를 prefix로
 	// 붙여주기로 했기 때문에, preset을 기본토대로 prefix를 추가하여 리턴하도록 구성
-	// client 생성 (kibana, grafana, kiali, jaeger, hyperregistry, opensearch)
+	// client 생성 (console, kibana, grafana, kiali, jaeger, hyperregistry, opensearch)
 	clientConfigs := hyperauthCaller.GetClientConfigPreset(clusterManager.GetNamespacedPrefix())
+	// client secret 은 cluster 마다 생성하여 {cluster name}-hyperauth-client secret 에 저장하고,
+	// 각 모듈은 저장된 secret 을 참조하여 sso 를 설정한다.
+	clientSecrets, err := r.GetOrCreateHyperAuthClientSecret(clusterManager, clientConfigs)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
 	for _, config := range clientConfigs {
+		config.Secret = clientSecrets[config.ClientId]
 		if err := hyperauthCaller.CreateClient(config, secret); err != nil {
-			log.Error(err, "Failed to create hyperauth client ["+config.ClientId+"] for single cluster")
-			return ctrl.Result{RequeueAfter: requeueAfter10Second}, err
+			log.Error(err, "Failed to create hyperauth client for single cluster", "client", config.ClientId)
+			return ctrl.Result{}, err
 		}
 	}
 
@@ -974,8 +1522,8 @@ func (r *ClusterManagerReconciler) CreateHyperAuthResources(ctx context.Context,
 	protocolMapperMappingConfigs := hyperauthCaller.GetMappingProtocolMapperToClientConfigPreset(clusterManager.GetNamespacedPrefix())
 	for _, config := range protocolMapperMappingConfigs {
 		if err := hyperauthCaller.CreateClientLevelProtocolMapper(config, secret); err != nil {
-			log.Error(err, "Failed to create hyperauth protocol mapper ["+config.ClientId+"] for single cluster")
-			return ctrl.Result{RequeueAfter: requeueAfter10Second}, err
+			log.Error(err, "Failed to create hyperauth protocol mapper for single cluster", "client", config.ClientId)
+			return ctrl.Result{}, err
 		}
 	}
 
@@ -983,14 +1531,14 @@ func (r *ClusterManagerReconciler) CreateHyperAuthResources(ctx context.Context,
 	clientLevelRoleConfigs := hyperauthCaller.GetClientLevelRoleConfigPreset(clusterManager.GetNamespacedPrefix())
 	for _, config := range clientLevelRoleConfigs {
 		if err := hyperauthCaller.CreateClientLevelRole(config, secret); err != nil {
-			log.Error(err, "Failed to create hyperauth client-level role ["+config.ClientId+"] for single cluster")
-			return ctrl.Result{RequeueAfter: requeueAfter10Second}, err
+			log.Error(err, "Failed to create hyperauth client-level role for single cluster", "client", config.ClientId)
+			return ctrl.Result{}, err
 		}
 
 		userEmail := clusterManager.Annotations[util.AnnotationKeyOwner]
 		if err := hyperauthCaller.AddClientLevelRolesToUserRoleMapping(config, userEmail, secret); err != nil {
-			log.Error(err, "Failed to add client-level role to user role mapping ["+config.ClientId+"] for single cluster")
-			return ctrl.Result{RequeueAfter: requeueAfter10Second}, err
+			log.Error(err, "Failed to add client-level role to user role mapping for single cluster", "client", config.ClientId)
+			return ctrl.Result{}, err
 		}
 	}
 
@@ -999,8 +1547,8 @@ func (r *ClusterManagerReconciler) CreateHyperAuthResources(ctx context.Context,
 	for _, config := range clientScopeMappingConfig {
 		err := hyperauthCaller.AddClientScopeToClient(config, secret)
 		if err != nil {
-			log.Error(err, "Failed to add client scope to client ["+config.ClientId+"] for single cluster")
-			return ctrl.Result{RequeueAfter: requeueAfter10Second}, err
+			log.Error(err, "Failed to add client scope to client for single cluster", "client", config.ClientId)
+			return ctrl.Result{}, err
 		}
 	}
 
@@ -1009,14 +1557,14 @@ func (r *ClusterManagerReconciler) CreateHyperAuthResources(ctx context.Context,
 	for _, config := range groupConfig {
 		err := hyperauthCaller.CreateGroup(config, secret)
 		if err != nil {
-			log.Error(err, "Failed to create group ["+config.Name+"] for single cluster")
-			return ctrl.Result{RequeueAfter: requeueAfter10Second}, err
+			log.Error(err, "Failed to create group for single cluster", "group", config.Name)
+			return ctrl.Result{}, err
 		}
 
 		err = hyperauthCaller.AddGroupToUser(clusterManager.Annotations[util.AnnotationKeyOwner], config, secret)
 		if err != nil {
-			log.Error(err, "Failed to add group to user ["+config.Name+"] for single cluster")
-			return ctrl.Result{RequeueAfter: requeueAfter10Second}, err
+			log.Error(err, "Failed to add group to user for single cluster", "group", config.Name)
+			return ctrl.Result{}, err
 		}
 	}
 
@@ -1025,6 +1573,95 @@ func (r *ClusterManagerReconciler) CreateHyperAuthResources(ctx context.Context,
 	return ctrl.Result{}, nil
 }
 
+// oidc 를 설정할 수 없는 등록된 cluster 가 hyperauth 의 token 을 인증할 수 있도록
+// authn broker 를 가리키는 webhook token authentication 설정을 secret 으로 생성한다.
+// 관리자는 secret 의 config 를 member cluster 의 api server 의 --authentication-token-webhook-config-file 로 지정한다.
+func (r *ClusterManagerReconciler) CreateAuthnWebhookConfig(ctx context.Context, clusterManager *clusterV1alpha1.ClusterManager) (ctrl.Result, error) {
+	if os.Getenv(util.AUTHN_WEBHOOK_URL) == "" {
+		return ctrl.Result{}, nil
+	}
+	log := r.Log.WithValues("ClusterManager", clusterManager.GetNamespacedName())
+	log.Info("Start to reconcile phase for CreateAuthnWebhookConfig")
+
+	key := types.NamespacedName{
+		Name:      clusterManager.GetAuthnWebhookSecretName(),
+		Namespace: clusterManager.Namespace,
+	}
+	webhookSecret := &coreV1.Secret{}
+	err := r.Client.Get(context.TODO(), key, webhookSecret)
+	if err != nil && !errors.IsNotFound(err) {
+		log.Error(err, "Failed to get authn webhook secret")
+		return ctrl.Result{}, err
+	}
+	isNew := errors.IsNotFound(err)
+	if isNew {
+		webhookSecret = &coreV1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      key.Name,
+				Namespace: key.Namespace,
+				Labels: map[string]string{
+					util.LabelKeyClmSecretType:           util.ClmSecretTypeAuthnWebhook,
+					clusterV1alpha1.LabelKeyClmName:      clusterManager.Name,
+					clusterV1alpha1.LabelKeyClmNamespace: clusterManager.Namespace,
+				},
+				Annotations: map[string]string{
+					util.AnnotationKeyOwner: clusterManager.Annotations[util.AnnotationKeyOwner],
+				},
+				Finalizers: []string{
+					clusterV1alpha1.ClusterManagerFinalizer,
+				},
+			},
+			Data: map[string][]byte{},
+		}
+		ctrl.SetControllerReference(clusterManager, webhookSecret, r.Scheme)
+	}
+	if webhookSecret.Data == nil {
+		webhookSecret.Data = map[string][]byte{}
+	}
+
+	// token 은 처음 생성할 때만 만들고, broker 의 주소나 CA 가 바뀐 경우 설정만 다시 생성한다.
+	token := string(webhookSecret.Data[util.AuthnWebhookTokenKey])
+	if token == "" {
+		if token, err = util.CreateClientSecretString(); err != nil {
+			log.Error(err, "Failed to create authn webhook token")
+			return ctrl.Result{}, err
+		}
+	}
+	// cert-manager 가 broker 의 cert 를 갱신하면 CA 가 바뀔 수 있으므로 매번 다시 읽는다.
+	ca, err := util.GetAuthnServingCA(context.TODO(), r.Client)
+	if err != nil {
+		log.Error(err, "Failed to get CA of authn broker")
+		return ctrl.Result{}, err
+	}
+	config, err := util.NewAuthnWebhookConfig(clusterManager.Namespace, clusterManager.Name, token, ca)
+	if err != nil {
+		log.Error(err, "Failed to create authn webhook config")
+		return ctrl.Result{}, err
+	}
+	if !isNew &&
+		string(webhookSecret.Data[util.AuthnWebhookTokenKey]) == token &&
+		string(webhookSecret.Data[util.AuthnWebhookConfigKey]) == string(config) {
+		return ctrl.Result{}, nil
+	}
+	webhookSecret.Data[util.AuthnWebhookTokenKey] = []byte(token)
+	webhookSecret.Data[util.AuthnWebhookConfigKey] = config
+
+	if isNew {
+		if err := r.Client.Create(context.TODO(), webhookSecret); err != nil {
+			log.Error(err, "Failed to create authn webhook secret")
+			return ctrl.Result{}, err
+		}
+		r.Recorder.Event(clusterManager, coreV1.EventTypeNormal, "AuthnWebhookConfigCreated",
+			fmt.Sprintf("set %s of secret %s as --authentication-token-webhook-config-file of the api server", util.AuthnWebhookConfigKey, key.Name))
+	} else if err := r.Client.Update(context.TODO(), webhookSecret); err != nil {
+		log.Error(err, "Failed to update authn webhook secret")
+		return ctrl.Result{}, err
+	}
+
+	log.Info("Reconcile authn webhook config successfully")
+	return ctrl.Result{}, nil
+}
+
 // func (r *ClusterManagerReconciler) SetHyperregistryOidcConfig(ctx context.Context, clusterManager *clusterV1alpha1.ClusterManager) (reconcile.Result, error) {
 // 	if !clusterManager.Status.AuthClientReady || clusterManager.Status.HyperregistryOidcReady {
 // 		return ctrl.Result{}, nil
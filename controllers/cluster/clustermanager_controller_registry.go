@@ -0,0 +1,72 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+
+	clusterV1alpha1 "github.com/tmax-cloud/hypercloud-multi-operator/apis/cluster/v1alpha1"
+	util "github.com/tmax-cloud/hypercloud-multi-operator/controllers/util"
+
+	coreV1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+// CreateRegistryConfigSecret 은 REGISTRY_CONFIG_SECRET 의 registry 설정을 containerd 설정으로 변환하여
+// cluster 의 namespace 에 <cluster>-registry-config secret 으로 생성한다.
+// capi template 의 kubeadm config 가 이 secret 을 참조하므로, 설정이 없어도 빈 secret 을 template instance 보다 먼저 생성한다.
+// 설정이 바뀌면 secret 을 갱신하며, 이후에 생성되는 node 부터 반영된다.
+func (r *ClusterManagerReconciler) CreateRegistryConfigSecret(ctx context.Context, clusterManager *clusterV1alpha1.ClusterManager) (ctrl.Result, error) {
+	log := r.Log.WithValues("clustermanager", clusterManager.GetNamespacedName())
+
+	config, err := util.GetRegistryConfig(ctx, r.Client)
+	if err != nil {
+		log.Error(err, "Failed to get registry config")
+		return ctrl.Result{}, err
+	}
+	toml, ca := config.Render()
+
+	secret := &coreV1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      clusterManager.Name + util.RegistryConfigSecretSuffix,
+			Namespace: clusterManager.Namespace,
+		},
+	}
+	result, err := controllerutil.CreateOrUpdate(ctx, r.Client, secret, func() error {
+		if secret.Labels == nil {
+			secret.Labels = map[string]string{}
+		}
+		secret.Labels[util.LabelKeyClmSecretType] = util.ClmSecretTypeRegistryConfig
+		secret.Labels[clusterV1alpha1.LabelKeyClmName] = clusterManager.Name
+		secret.Labels[clusterV1alpha1.LabelKeyClmNamespace] = clusterManager.Namespace
+		secret.Data = map[string][]byte{
+			util.RegistryConfigTomlKey: []byte(toml),
+			util.RegistryConfigCAKey:   []byte(ca),
+		}
+		return ctrl.SetControllerReference(clusterManager, secret, r.Scheme)
+	})
+	if err != nil {
+		log.Error(err, "Failed to create registry config secret")
+		return ctrl.Result{}, err
+	}
+	if result != controllerutil.OperationResultNone {
+		log.Info("Registry config secret is "+string(result), "registries", len(config.Registries))
+	}
+
+	return ctrl.Result{}, nil
+}
@@ -0,0 +1,151 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	clusterV1alpha1 "github.com/tmax-cloud/hypercloud-multi-operator/apis/cluster/v1alpha1"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	capiV1beta1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	controlplanev1 "sigs.k8s.io/cluster-api/controlplane/kubeadm/api/v1beta1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	rolloutReasonRolledOut   = "RolledOut"
+	rolloutReasonRollingOut  = "RollingOut"
+	rolloutReasonUnavailable = "Unavailable"
+)
+
+// kcp, machine deployment 의 replica 상태
+type rolloutReplicas struct {
+	desired     int32
+	updated     int32
+	ready       int32
+	unavailable int32
+	// 현재 version, 갱신 중이면 목표 version 을 함께 표시한다.
+	version string
+}
+
+func (r *ClusterManagerReconciler) UpdateRolloutStatus(ctx context.Context, clusterManager *clusterV1alpha1.ClusterManager) (ctrl.Result, error) {
+	log := r.Log.WithValues("clustermanager", clusterManager.GetNamespacedName())
+	log.Info("Start to reconcile phase for UpdateRolloutStatus")
+
+	cluster, err := r.GetCapiCluster(clusterManager)
+	if errors.IsNotFound(err) {
+		return ctrl.Result{}, nil
+	} else if err != nil {
+		log.Error(err, "Failed to get cluster")
+		return ctrl.Result{}, err
+	}
+
+	kcp, err := r.GetKubeadmControlPlane(cluster)
+	if errors.IsNotFound(err) {
+		meta.RemoveStatusCondition(&clusterManager.Status.Conditions, clusterV1alpha1.ClusterManagerConditionControlPlaneRolledOut)
+	} else if err != nil {
+		log.Error(err, "Failed to get kubeadmControlPlane")
+		return ctrl.Result{}, err
+	} else {
+		condition := getControlPlaneReplicas(kcp).condition(clusterV1alpha1.ClusterManagerConditionControlPlaneRolledOut)
+		condition.ObservedGeneration = clusterManager.Generation
+		meta.SetStatusCondition(&clusterManager.Status.Conditions, condition)
+	}
+
+	// worker 와 node pool 의 machine deployment 를 모두 포함한다.
+	mdList := &capiV1beta1.MachineDeploymentList{}
+	opts := []client.ListOption{
+		client.InNamespace(cluster.Namespace),
+		client.MatchingLabels{LabelKeyCAPIClusterName: cluster.Name},
+	}
+	if err := r.Client.List(context.TODO(), mdList, opts...); err != nil {
+		log.Error(err, "Failed to list machineDeployments")
+		return ctrl.Result{}, err
+	}
+	if len(mdList.Items) == 0 {
+		meta.RemoveStatusCondition(&clusterManager.Status.Conditions, clusterV1alpha1.ClusterManagerConditionWorkersRolledOut)
+	} else {
+		condition := getWorkerReplicas(mdList.Items).condition(clusterV1alpha1.ClusterManagerConditionWorkersRolledOut)
+		condition.ObservedGeneration = clusterManager.Generation
+		meta.SetStatusCondition(&clusterManager.Status.Conditions, condition)
+	}
+
+	return ctrl.Result{}, nil
+}
+
+func getControlPlaneReplicas(kcp *controlplanev1.KubeadmControlPlane) rolloutReplicas {
+	replicas := rolloutReplicas{
+		desired:     1,
+		updated:     kcp.Status.UpdatedReplicas,
+		ready:       kcp.Status.ReadyReplicas,
+		unavailable: kcp.Status.UnavailableReplicas,
+		version:     kcp.Spec.Version,
+	}
+	if kcp.Spec.Replicas != nil {
+		replicas.desired = *kcp.Spec.Replicas
+	}
+	// status.version 은 모든 machine 중 가장 낮은 version 이다.
+	if kcp.Status.Version != nil && *kcp.Status.Version != kcp.Spec.Version {
+		replicas.version = fmt.Sprintf("%s (target %s)", *kcp.Status.Version, kcp.Spec.Version)
+	}
+	return replicas
+}
+
+// machine deployment 의 replica 를 합산한다. version 이 다른 machine deployment 가 있으면 모두 표시한다.
+func getWorkerReplicas(mds []capiV1beta1.MachineDeployment) rolloutReplicas {
+	replicas := rolloutReplicas{}
+	versions := sets.NewString()
+	for _, md := range mds {
+		if md.Spec.Replicas != nil {
+			replicas.desired += *md.Spec.Replicas
+		}
+		replicas.updated += md.Status.UpdatedReplicas
+		replicas.ready += md.Status.ReadyReplicas
+		replicas.unavailable += md.Status.UnavailableReplicas
+		if md.Spec.Template.Spec.Version != nil {
+			versions.Insert(*md.Spec.Template.Spec.Version)
+		}
+	}
+	replicas.version = strings.Join(versions.List(), ", ")
+	return replicas
+}
+
+// 모든 replica 가 갱신되고 ready 이면 True 이다.
+func (r rolloutReplicas) condition(conditionType string) metav1.Condition {
+	condition := metav1.Condition{
+		Type:   conditionType,
+		Status: metav1.ConditionTrue,
+		Reason: rolloutReasonRolledOut,
+		Message: fmt.Sprintf("version %s, updated %d/%d, ready %d/%d, unavailable %d",
+			r.version, r.updated, r.desired, r.ready, r.desired, r.unavailable),
+	}
+	switch {
+	case r.updated < r.desired:
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = rolloutReasonRollingOut
+	case r.ready < r.desired || r.unavailable > 0:
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = rolloutReasonUnavailable
+	}
+	return condition
+}
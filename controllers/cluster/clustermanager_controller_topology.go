@@ -0,0 +1,176 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+
+	clusterV1alpha1 "github.com/tmax-cloud/hypercloud-multi-operator/apis/cluster/v1alpha1"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	capiV1beta1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+// topology 로 생성하는 cluster 의 worker machine deployment topology 이름
+// template 으로 생성한 worker machine deployment 와 같은 이름을 쓴다.
+const topologyWorkerName = "md-0"
+
+// CreateTopologyCluster 는 spec.topology 의 ClusterClass 로 topology 가 설정된 capi cluster 를 생성한다.
+// 생성된 후에는 class 와 variables 만 동기화하고, version 과 replicas 는 upgrade, scaling phase 에서 반영한다.
+func (r *ClusterManagerReconciler) CreateTopologyCluster(ctx context.Context, clusterManager *clusterV1alpha1.ClusterManager) (ctrl.Result, error) {
+	log := r.Log.WithValues("clustermanager", clusterManager.GetNamespacedName())
+	log.Info("Start to reconcile phase for CreateTopologyCluster")
+
+	cluster, err := r.GetCapiCluster(clusterManager)
+	if errors.IsNotFound(err) {
+		cluster, err = r.constructTopologyCluster(clusterManager)
+		if err != nil {
+			log.Error(err, "Failed to construct topology cluster")
+			return ctrl.Result{}, err
+		}
+		if err := r.Create(context.TODO(), cluster); err != nil {
+			log.Error(err, "Failed to create topology cluster")
+			return ctrl.Result{}, err
+		}
+		log.Info("Created topology cluster successfully", "class", cluster.Spec.Topology.Class)
+		return ctrl.Result{}, nil
+	} else if err != nil {
+		log.Error(err, "Failed to get cluster")
+		return ctrl.Result{}, err
+	}
+
+	if !isTopologyManaged(cluster) {
+		log.Info("Cluster is not created from ClusterClass")
+		return ctrl.Result{}, nil
+	}
+
+	changed := false
+	if cluster.Spec.Topology.Class != clusterManager.Spec.Topology.Class {
+		cluster.Spec.Topology.Class = clusterManager.Spec.Topology.Class
+		changed = true
+	}
+	if setTopologyVariables(cluster.Spec.Topology, clusterManager.Spec.Topology.Variables) {
+		changed = true
+	}
+	if changed {
+		if err := r.Update(context.TODO(), cluster); err != nil {
+			log.Error(err, "Failed to update topology of cluster")
+			return ctrl.Result{}, err
+		}
+		log.Info("Updated topology of cluster successfully")
+	}
+
+	return ctrl.Result{}, nil
+}
+
+func (r *ClusterManagerReconciler) constructTopologyCluster(clusterManager *clusterV1alpha1.ClusterManager) (*capiV1beta1.Cluster, error) {
+	workerClass := clusterManager.Spec.Topology.WorkerClass
+	if workerClass == "" {
+		key := types.NamespacedName{Name: clusterManager.Spec.Topology.Class, Namespace: clusterManager.Namespace}
+		clusterClass := &capiV1beta1.ClusterClass{}
+		if err := r.Client.Get(context.TODO(), key, clusterClass); err != nil {
+			return nil, err
+		}
+		if len(clusterClass.Spec.Workers.MachineDeployments) == 0 {
+			return nil, fmt.Errorf("ClusterClass %s has no machine deployment class", clusterClass.Name)
+		}
+		workerClass = clusterClass.Spec.Workers.MachineDeployments[0].Class
+	}
+
+	masterNum := int32(clusterManager.Spec.MasterNum)
+	workerNum := int32(clusterManager.Spec.WorkerNum)
+	cluster := &capiV1beta1.Cluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      clusterManager.Name,
+			Namespace: clusterManager.Namespace,
+		},
+		Spec: capiV1beta1.ClusterSpec{
+			Paused: clusterManager.Spec.Paused,
+			Topology: &capiV1beta1.Topology{
+				Class:   clusterManager.Spec.Topology.Class,
+				Version: clusterManager.GetK8SVersion(),
+				ControlPlane: capiV1beta1.ControlPlaneTopology{
+					Replicas: &masterNum,
+				},
+				Workers: &capiV1beta1.WorkersTopology{
+					MachineDeployments: []capiV1beta1.MachineDeploymentTopology{
+						{
+							Class:    workerClass,
+							Name:     topologyWorkerName,
+							Replicas: &workerNum,
+						},
+					},
+				},
+			},
+		},
+	}
+	setTopologyVariables(cluster.Spec.Topology, clusterManager.Spec.Topology.Variables)
+	if clusterManager.Spec.Network != nil {
+		cluster.Spec.ClusterNetwork = &capiV1beta1.ClusterNetwork{}
+		setClusterNetwork(cluster.Spec.ClusterNetwork, clusterManager.Spec.Network)
+	}
+	return cluster, nil
+}
+
+// spec 의 variable 값을 topology 에 반영하고, 변경 여부를 반환한다.
+// capi 의 webhook 이 ClusterClass 의 기본값으로 채운 variable 은 그대로 둔다.
+func setTopologyVariables(topology *capiV1beta1.Topology, variables []clusterV1alpha1.TopologyVariable) bool {
+	changed := false
+	for _, variable := range variables {
+		found := false
+		for i := range topology.Variables {
+			if topology.Variables[i].Name != variable.Name {
+				continue
+			}
+			found = true
+			if !bytes.Equal(topology.Variables[i].Value.Raw, variable.Value.Raw) {
+				topology.Variables[i].Value = *variable.Value.DeepCopy()
+				changed = true
+			}
+			break
+		}
+		if !found {
+			topology.Variables = append(topology.Variables, capiV1beta1.ClusterVariable{
+				Name:  variable.Name,
+				Value: *variable.Value.DeepCopy(),
+			})
+			changed = true
+		}
+	}
+	return changed
+}
+
+// topology 로 생성한 cluster 는 template instance 가 없으므로 cluster 를 직접 삭제한다.
+func (r *ClusterManagerReconciler) deleteTopologyCluster(clusterManager *clusterV1alpha1.ClusterManager) error {
+	cluster, err := r.GetCapiCluster(clusterManager)
+	if errors.IsNotFound(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	if !cluster.DeletionTimestamp.IsZero() {
+		return nil
+	}
+	if err := r.Delete(context.TODO(), cluster); err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+	return nil
+}
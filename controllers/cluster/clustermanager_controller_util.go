@@ -19,6 +19,8 @@ import (
 	"fmt"
 	"os"
 	"regexp"
+	"sort"
+	"strconv"
 	"strings"
 
 	argocdV1alpha1 "github.com/argoproj/argo-cd/v2/pkg/apis/application/v1alpha1"
@@ -29,7 +31,8 @@ import (
 	util "github.com/tmax-cloud/hypercloud-multi-operator/controllers/util"
 	dynamicv2 "github.com/traefik/traefik/v2/pkg/config/dynamic"
 	traefikV1alpha1 "github.com/traefik/traefik/v2/pkg/provider/kubernetes/crd/traefik/v1alpha1"
-	capiV1alpha3 "sigs.k8s.io/cluster-api/api/v1alpha3"
+	capiV1beta1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	controlplanev1 "sigs.k8s.io/cluster-api/controlplane/kubeadm/api/v1beta1"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
@@ -40,8 +43,10 @@ import (
 	networkingv1 "k8s.io/api/networking/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/tools/pager"
 )
 
 // checkTemplateInstanceDeployed는 TemplateInstance가 배포되었는지 확인
@@ -111,8 +116,87 @@ func mergeParams(paramsList ...[]tmaxv1.ParamSpec) []tmaxv1.ParamSpec {
 	return result
 }
 
+// cluster manager 와 같은 이름의 capi cluster 를 반환한다.
+func (r *ClusterManagerReconciler) GetCapiCluster(clusterManager *clusterV1alpha1.ClusterManager) (*capiV1beta1.Cluster, error) {
+	cluster := &capiV1beta1.Cluster{}
+	if err := r.Client.Get(context.TODO(), clusterManager.GetNamespacedName(), cluster); err != nil {
+		return nil, err
+	}
+	return cluster, nil
+}
+
+// ClusterClass 로 생성한 cluster 는 topology controller 가 kcp 와 machine deployment 를 관리하므로,
+// replicas 와 version 을 kcp, machine deployment 대신 cluster 의 topology 에 설정해야 한다.
+func isTopologyManaged(cluster *capiV1beta1.Cluster) bool {
+	return cluster.Spec.Topology != nil
+}
+
+// cluster 의 controlPlaneRef 가 가리키는 kcp 를 반환한다.
+// topology 로 생성된 kcp 는 이름이 <cluster>-control-plane 이 아니다.
+func (r *ClusterManagerReconciler) GetKubeadmControlPlane(cluster *capiV1beta1.Cluster) (*controlplanev1.KubeadmControlPlane, error) {
+	key := types.NamespacedName{
+		Name:      cluster.Name + "-control-plane",
+		Namespace: cluster.Namespace,
+	}
+	if cluster.Spec.ControlPlaneRef != nil {
+		key.Name = cluster.Spec.ControlPlaneRef.Name
+	}
+
+	kcp := &controlplanev1.KubeadmControlPlane{}
+	if err := r.Client.Get(context.TODO(), key, kcp); err != nil {
+		return nil, err
+	}
+	return kcp, nil
+}
+
+// worker 의 machine deployment 를 반환한다.
+// topology 로 생성된 cluster 는 첫 번째 machine deployment topology 로 생성된 machine deployment 를 반환한다.
+func (r *ClusterManagerReconciler) GetWorkerMachineDeployment(cluster *capiV1beta1.Cluster) (*capiV1beta1.MachineDeployment, error) {
+	if !isTopologyManaged(cluster) {
+		key := types.NamespacedName{
+			Name:      cluster.Name + "-md-0",
+			Namespace: cluster.Namespace,
+		}
+		md := &capiV1beta1.MachineDeployment{}
+		if err := r.Client.Get(context.TODO(), key, md); err != nil {
+			return nil, err
+		}
+		return md, nil
+	}
+
+	notFound := errors.NewNotFound(capiV1beta1.GroupVersion.WithResource("machinedeployments").GroupResource(), cluster.Name)
+	topology := workerMachineDeploymentTopology(cluster)
+	if topology == nil {
+		return nil, notFound
+	}
+	mdList := &capiV1beta1.MachineDeploymentList{}
+	opts := []client.ListOption{
+		client.InNamespace(cluster.Namespace),
+		client.MatchingLabels{
+			CAPI_CLUSTER_LABEL_KEY:                                cluster.Name,
+			capiV1beta1.ClusterTopologyMachineDeploymentLabelName: topology.Name,
+		},
+	}
+	if err := r.List(context.TODO(), mdList, opts...); err != nil {
+		return nil, err
+	}
+	if len(mdList.Items) == 0 {
+		return nil, notFound
+	}
+	return &mdList.Items[0], nil
+}
+
+// cluster manager 의 worker 수를 반영할 machine deployment topology
+func workerMachineDeploymentTopology(cluster *capiV1beta1.Cluster) *capiV1beta1.MachineDeploymentTopology {
+	workers := cluster.Spec.Topology.Workers
+	if workers == nil || len(workers.MachineDeployments) == 0 {
+		return nil
+	}
+	return &workers.MachineDeployments[0]
+}
+
 // controlplane, worker에 따른 machine list를 반환한다.
-func (r *ClusterManagerReconciler) GetMachineList(clusterManager *clusterV1alpha1.ClusterManager, controlplane bool) ([]capiV1alpha3.Machine, error) {
+func (r *ClusterManagerReconciler) GetMachineList(clusterManager *clusterV1alpha1.ClusterManager, controlplane bool) ([]capiV1beta1.Machine, error) {
 
 	opts := []client.ListOption{client.InNamespace(clusterManager.Namespace),
 		client.MatchingLabels{CAPI_CLUSTER_LABEL_KEY: clusterManager.Name}}
@@ -120,22 +204,34 @@ func (r *ClusterManagerReconciler) GetMachineList(clusterManager *clusterV1alpha
 	if controlplane {
 		opts = append(opts, client.MatchingLabels{CAPI_CONTROLPLANE_LABEL_KEY: ""})
 	} else {
-		opts = append(opts, client.MatchingLabels{CAPI_WORKER_LABEL_KEY: clusterManager.Name + "-md-0"})
+		// topology 로 생성된 machine deployment 는 이름이 <cluster>-md-0 이 아니므로, machine deployment 가 소유한 machine 을 모두 조회한다.
+		opts = append(opts, client.HasLabels{CAPI_WORKER_LABEL_KEY})
 	}
-	machines := &capiV1alpha3.MachineList{}
+	machines := &capiV1beta1.MachineList{}
 	if err := r.List(context.TODO(), machines, opts...); err != nil {
-		return []capiV1alpha3.Machine{}, err
+		return []capiV1beta1.Machine{}, err
+	}
+	if controlplane {
+		return machines.Items, nil
+	}
+
+	// node pool 의 machine 은 worker 수에 포함하지 않는다.
+	workers := []capiV1beta1.Machine{}
+	for _, machine := range machines.Items {
+		if _, ok := machine.Labels[clusterV1alpha1.LabelKeyClmNodePool]; !ok {
+			workers = append(workers, machine)
+		}
 	}
-	return machines.Items, nil
+	return workers, nil
 }
 
 // controlplane machine list를 반환
-func (r *ClusterManagerReconciler) GetControlplaneMachineList(clusterManager *clusterV1alpha1.ClusterManager) ([]capiV1alpha3.Machine, error) {
+func (r *ClusterManagerReconciler) GetControlplaneMachineList(clusterManager *clusterV1alpha1.ClusterManager) ([]capiV1beta1.Machine, error) {
 	return r.GetMachineList(clusterManager, true)
 }
 
 // worker machine list를 반환
-func (r *ClusterManagerReconciler) GetWorkerMachineList(clusterManager *clusterV1alpha1.ClusterManager) ([]capiV1alpha3.Machine, error) {
+func (r *ClusterManagerReconciler) GetWorkerMachineList(clusterManager *clusterV1alpha1.ClusterManager) ([]capiV1beta1.Machine, error) {
 	return r.GetMachineList(clusterManager, false)
 }
 
@@ -172,7 +268,7 @@ func (r *ClusterManagerReconciler) GetUpgradeWorkerMachines(clusterManager *clus
 	return r.GetUpgradeMachinesInfo(clusterManager, machines)
 }
 
-func (r *ClusterManagerReconciler) GetUpgradeMachinesInfo(clusterManager *clusterV1alpha1.ClusterManager, machines []capiV1alpha3.Machine) (MachineUpgradeList, error) {
+func (r *ClusterManagerReconciler) GetUpgradeMachinesInfo(clusterManager *clusterV1alpha1.ClusterManager, machines []capiV1beta1.Machine) (MachineUpgradeList, error) {
 	machineUpgrade := MachineUpgradeList{}
 	newMachineList := []string{}
 	oldMachineList := []string{}
@@ -181,7 +277,7 @@ func (r *ClusterManagerReconciler) GetUpgradeMachinesInfo(clusterManager *cluste
 	for _, machine := range machines {
 		if *machine.Spec.Version == clusterManager.Spec.Version {
 			newMachineList = append(newMachineList, machine.Name)
-			if machine.Status.Phase == string(capiV1alpha3.MachinePhaseRunning) {
+			if machine.Status.Phase == string(capiV1beta1.MachinePhaseRunning) {
 				newMachineRunning = append(newMachineRunning, machine.Name)
 			}
 		} else if *machine.Spec.Version != clusterManager.Spec.Version {
@@ -213,12 +309,8 @@ func SetArgocdApplicationLink(manager *clusterV1alpha1.ClusterManager, subdomain
 func (r *ClusterManagerReconciler) GetKubeconfigSecret(clusterManager *clusterV1alpha1.ClusterManager) (*coreV1.Secret, error) {
 	log := r.Log.WithValues("clustermanager", clusterManager.GetNamespacedName())
 
-	key := types.NamespacedName{
-		Name:      clusterManager.Name + util.KubeconfigSuffix,
-		Namespace: clusterManager.Namespace,
-	}
-	kubeconfigSecret := &coreV1.Secret{}
-	if err := r.Client.Get(context.TODO(), key, kubeconfigSecret); errors.IsNotFound(err) {
+	kubeconfigSecret, err := util.GetKubeconfigSecret(context.TODO(), r.Client, clusterManager.Namespace, clusterManager.Name)
+	if errors.IsNotFound(err) {
 		log.Info("kubeconfig secret is not found")
 		return nil, err
 	} else if err != nil {
@@ -260,7 +352,7 @@ func (r *ClusterManagerReconciler) CreateCertificate(clusterManager *clusterV1al
 					certmanagerV1.UsageClientAuth,
 				},
 				DNSNames: []string{
-					"multicluster." + clusterManager.Annotations[clusterV1alpha1.AnnotationKeyClmDomain],
+					"multicluster." + clusterManager.GetDomain(),
 				},
 				IssuerRef: certmanagerMetaV1.ObjectReference{
 					Name:  "tmaxcloud-issuer",
@@ -294,7 +386,7 @@ func (r *ClusterManagerReconciler) CreateIngress(clusterManager *clusterV1alpha1
 		provider := "tmax-cloud"
 		pathType := networkingv1.PathTypePrefix
 		prefixMiddleware := clusterManager.GetNamespacedPrefix() + "-prefix@kubernetescrd"
-		multiclusterDNS := "multicluster." + clusterManager.Annotations[clusterV1alpha1.AnnotationKeyClmDomain]
+		multiclusterDNS := "multicluster." + clusterManager.GetDomain()
 		urlPath := "/api/" + clusterManager.Namespace + "/" + clusterManager.Name
 		middlwareAnnotations := "api-gateway-system-oauth2-proxy-forwardauth@kubernetescrd,api-gateway-system-jwt-decode-auth@kubernetescrd," + prefixMiddleware
 		ingress := &networkingv1.Ingress{
@@ -371,13 +463,15 @@ func (r *ClusterManagerReconciler) CreateIngress(clusterManager *clusterV1alpha1
 	return err
 }
 
-func (r *ClusterManagerReconciler) CreateExternalNameService(clusterManager *clusterV1alpha1.ClusterManager, annotationKey string) error {
+func (r *ClusterManagerReconciler) CreateExternalNameService(clusterManager *clusterV1alpha1.ClusterManager, externalName string) error {
 	log := r.Log.WithValues("clustermanager", clusterManager.GetNamespacedName())
 
 	key := types.NamespacedName{
 		Name:      clusterManager.Name + "-gateway-service",
 		Namespace: clusterManager.Namespace,
 	}
+	// IPv6 address 는 external name 으로 지정할 수 없으므로 selector 가 없는 service 와 endpoints 로 연결한다.
+	ipv6 := util.IsIPv6Host(externalName)
 	err := r.Client.Get(context.TODO(), key, &coreV1.Service{})
 	if errors.IsNotFound(err) {
 		service := &coreV1.Service{
@@ -395,7 +489,7 @@ func (r *ClusterManagerReconciler) CreateExternalNameService(clusterManager *clu
 				},
 			},
 			Spec: coreV1.ServiceSpec{
-				ExternalName: clusterManager.Annotations[annotationKey],
+				ExternalName: externalName,
 				Ports: []coreV1.ServicePort{
 					{
 						Port:       443,
@@ -406,16 +500,63 @@ func (r *ClusterManagerReconciler) CreateExternalNameService(clusterManager *clu
 				Type: coreV1.ServiceTypeExternalName,
 			},
 		}
+		if ipv6 {
+			service.Spec.ExternalName = ""
+			service.Spec.Type = coreV1.ServiceTypeClusterIP
+		}
 		ctrl.SetControllerReference(clusterManager, service, r.Scheme)
 		if err := r.Create(context.TODO(), service); err != nil {
 			log.Error(err, "Failed to Create Service for gateway")
 			return err
 		}
 		log.Info("Create Service for gateway successfully")
-		return nil
+	} else if err != nil {
+		return err
 	}
 
-	return err
+	if ipv6 {
+		// service 를 생성한 뒤 endpoints 생성이 실패한 경우에도 다시 생성하도록 매번 확인한다.
+		if err := r.createGatewayEndpoints(clusterManager, key, externalName); err != nil {
+			log.Error(err, "Failed to Create Endpoints for gateway")
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *ClusterManagerReconciler) createGatewayEndpoints(clusterManager *clusterV1alpha1.ClusterManager, key types.NamespacedName, ip string) error {
+	if err := r.Client.Get(context.TODO(), key, &coreV1.Endpoints{}); err == nil || !errors.IsNotFound(err) {
+		return err
+	}
+	endpoints := &coreV1.Endpoints{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      key.Name,
+			Namespace: key.Namespace,
+			Labels: map[string]string{
+				clusterV1alpha1.LabelKeyClmName: clusterManager.Name,
+			},
+		},
+		Subsets: []coreV1.EndpointSubset{
+			{
+				Addresses: []coreV1.EndpointAddress{
+					{IP: ip},
+				},
+				Ports: []coreV1.EndpointPort{
+					{
+						Port:     443,
+						Protocol: coreV1.ProtocolTCP,
+					},
+				},
+			},
+		},
+	}
+	ctrl.SetControllerReference(clusterManager, endpoints, r.Scheme)
+	if err := r.Create(context.TODO(), endpoints); err != nil {
+		return err
+	}
+	log := r.Log.WithValues("clustermanager", clusterManager.GetNamespacedName())
+	log.Info("Create Endpoints for gateway successfully")
+	return nil
 }
 
 // func (r *ClusterManagerReconciler) CreateGatewayEndpoint(clusterManager *clusterV1alpha1.ClusterManager) error {
@@ -534,15 +675,15 @@ func (r *ClusterManagerReconciler) CreateServiceAccountSecret(clusterManager *cl
 		Secrets(util.KubeNamespace).
 		Get(context.TODO(), adminServiceAccountName+"-token", metav1.GetOptions{})
 	if errors.IsNotFound(err) {
-		log.Info("Waiting for create service account token secret [" + adminServiceAccountName + "]")
+		log.Info("Waiting for create service account token secret", "secret", adminServiceAccountName)
 		return err
 	} else if err != nil {
-		log.Error(err, "Failed to get service account token secret ["+adminServiceAccountName+"-token]")
+		log.Error(err, "Failed to get service account token secret", "secret", adminServiceAccountName+"-token")
 		return err
 	}
 
 	if string(tokenSecret.Data["token"]) == "" {
-		log.Info("Waiting for create service account token secret [" + adminServiceAccountName + "]")
+		log.Info("Waiting for create service account token secret", "secret", adminServiceAccountName)
 		return fmt.Errorf("service account token secret is not found")
 	}
 
@@ -591,6 +732,223 @@ func (r *ClusterManagerReconciler) CreateServiceAccountSecret(clusterManager *cl
 	return err
 }
 
+// cluster 마다 hyperauth client 의 secret 을 생성하여 secret 에 저장하고, client id 별 secret 을 리턴한다.
+// 이미 저장된 secret 이 있는 경우에는 다시 생성하지 않고 저장된 값을 사용한다.
+func (r *ClusterManagerReconciler) GetOrCreateHyperAuthClientSecret(clusterManager *clusterV1alpha1.ClusterManager,
+	clientConfigs []hyperauthCaller.ClientConfig) (map[string]string, error) {
+	log := r.Log.WithValues("clustermanager", clusterManager.GetNamespacedName())
+
+	key := types.NamespacedName{
+		Name:      clusterManager.GetHyperAuthClientSecretName(),
+		Namespace: clusterManager.Namespace,
+	}
+	clientSecret := &coreV1.Secret{}
+	err := r.Client.Get(context.TODO(), key, clientSecret)
+	if err != nil && !errors.IsNotFound(err) {
+		log.Error(err, "Failed to get HyperAuth client secret")
+		return nil, err
+	}
+	isNew := errors.IsNotFound(err)
+	if isNew {
+		clientSecret = &coreV1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      key.Name,
+				Namespace: key.Namespace,
+				Labels: map[string]string{
+					util.LabelKeyClmSecretType:           util.ClmSecretTypeHyperAuth,
+					clusterV1alpha1.LabelKeyClmName:      clusterManager.Name,
+					clusterV1alpha1.LabelKeyClmNamespace: clusterManager.Namespace,
+				},
+				Annotations: map[string]string{
+					util.AnnotationKeyOwner: clusterManager.Annotations[util.AnnotationKeyOwner],
+				},
+				Finalizers: []string{
+					clusterV1alpha1.ClusterManagerFinalizer,
+				},
+			},
+		}
+		ctrl.SetControllerReference(clusterManager, clientSecret, r.Scheme)
+	}
+	if clientSecret.Data == nil {
+		clientSecret.Data = map[string][]byte{}
+	}
+
+	// 새로 추가된 client 에 대해서만 secret 을 생성한다.
+	changed := false
+	secrets := map[string]string{}
+	for _, config := range clientConfigs {
+		if v, ok := clientSecret.Data[config.ClientId]; ok {
+			secrets[config.ClientId] = string(v)
+			continue
+		}
+		v, err := util.CreateClientSecretString()
+		if err != nil {
+			log.Error(err, "Failed to generate HyperAuth client secret", "client", config.ClientId)
+			return nil, err
+		}
+		clientSecret.Data[config.ClientId] = []byte(v)
+		secrets[config.ClientId] = v
+		changed = true
+	}
+
+	if isNew {
+		if err := r.Create(context.TODO(), clientSecret); err != nil {
+			log.Error(err, "Failed to create HyperAuth client secret")
+			return nil, err
+		}
+		log.Info("Create HyperAuth client secret successfully")
+	} else if changed {
+		if err := r.Update(context.TODO(), clientSecret); err != nil {
+			log.Error(err, "Failed to update HyperAuth client secret")
+			return nil, err
+		}
+		log.Info("Update HyperAuth client secret successfully")
+	}
+
+	return secrets, nil
+}
+
+func (r *ClusterManagerReconciler) GetArgocdClusterSecret(clusterManager *clusterV1alpha1.ClusterManager) (*coreV1.Secret, error) {
+	secretList := &coreV1.SecretList{}
+	opts := []client.ListOption{
+		client.InNamespace(util.ArgoNamespace),
+		client.MatchingLabels{
+			util.LabelKeyClmSecretType:           util.ClmSecretTypeArgo,
+			clusterV1alpha1.LabelKeyClmName:      clusterManager.Name,
+			clusterV1alpha1.LabelKeyClmNamespace: clusterManager.Namespace,
+		},
+	}
+	if err := r.Client.List(context.TODO(), secretList, opts...); err != nil {
+		return nil, err
+	}
+	if len(secretList.Items) == 0 {
+		return nil, errors.NewNotFound(coreV1.Resource("secrets"), clusterManager.Name)
+	}
+	return &secretList.Items[0], nil
+}
+
+// argocd cluster secret 의 동작에 필요한 label 은 cluster manager 의 label 로 덮어쓰지 않는다.
+func isReservedArgoClusterSecretLabel(key string) bool {
+	switch key {
+	case util.LabelKeyClmSecretType, util.LabelKeyArgoSecretType,
+		clusterV1alpha1.LabelKeyClmName, clusterV1alpha1.LabelKeyClmNamespace:
+		return true
+	}
+	return false
+}
+
+// 초대를 수락한 cluster 의 멤버 목록
+func (r *ClusterManagerReconciler) listAcceptedClusterMembers(clusterManager *clusterV1alpha1.ClusterManager) ([]clusterV1alpha1.ClusterMember, error) {
+	clusterMemberList := &clusterV1alpha1.ClusterMemberList{}
+	opts := []client.ListOption{
+		client.InNamespace(clusterManager.Namespace),
+		client.MatchingLabels{clusterV1alpha1.LabelKeyClmName: clusterManager.Name},
+	}
+	if err := r.Client.List(context.TODO(), clusterMemberList, opts...); err != nil {
+		return nil, err
+	}
+
+	members := []clusterV1alpha1.ClusterMember{}
+	for _, member := range clusterMemberList.Items {
+		if member.Spec.Accepted && member.DeletionTimestamp.IsZero() {
+			members = append(members, member)
+		}
+	}
+	return members, nil
+}
+
+// cluster member 의 role 별로 AppProject 에서 허용하는 동작
+var appProjectRolePolicies = map[string][]string{
+	clusterV1alpha1.ClusterMemberRoleAdmin: {
+		"applications, *",
+		"logs, get",
+		"exec, create",
+	},
+	clusterV1alpha1.ClusterMemberRoleDeveloper: {
+		"applications, get",
+		"applications, create",
+		"applications, update",
+		"applications, sync",
+		"applications, action/*",
+		"logs, get",
+	},
+	clusterV1alpha1.ClusterMemberRoleGuest: {
+		"applications, get",
+	},
+}
+
+// operator 가 관리하는 AppProject 의 role 이름
+const appProjectRoleTeam = "team"
+
+var appProjectRoleNames = []string{
+	clusterV1alpha1.ClusterMemberRoleAdmin,
+	clusterV1alpha1.ClusterMemberRoleDeveloper,
+	clusterV1alpha1.ClusterMemberRoleGuest,
+}
+
+// cluster 의 owner 는 admin, 멤버는 cluster member 의 role, team 은 team role 에 매핑한다.
+// argocd 는 role 의 group 을 oidc token 의 claim 과 비교하므로,
+// user 멤버가 매칭되려면 argocd-rbac-cm 의 scopes 에 email 이 포함되어야 한다.
+func appProjectRoles(projectName, owner, team string, members []clusterV1alpha1.ClusterMember) []argocdV1alpha1.ProjectRole {
+	groups := map[string][]string{}
+	if owner != "" {
+		groups[clusterV1alpha1.ClusterMemberRoleAdmin] = append(groups[clusterV1alpha1.ClusterMemberRoleAdmin], owner)
+	}
+	for _, member := range members {
+		if _, ok := appProjectRolePolicies[member.Spec.Role]; !ok {
+			continue
+		}
+		groups[member.Spec.Role] = append(groups[member.Spec.Role], member.Spec.MemberId)
+	}
+
+	var roles []argocdV1alpha1.ProjectRole
+	for _, name := range appProjectRoleNames {
+		if len(groups[name]) == 0 {
+			continue
+		}
+		sort.Strings(groups[name])
+		policies := []string{}
+		for _, policy := range appProjectRolePolicies[name] {
+			policies = append(policies, "p, proj:"+projectName+":"+name+", "+policy+", "+projectName+"/*, allow")
+		}
+		roles = append(roles, argocdV1alpha1.ProjectRole{
+			Name:        name,
+			Description: "Cluster " + name + " members",
+			Policies:    policies,
+			Groups:      groups[name],
+		})
+	}
+
+	if team != "" {
+		roles = append(roles, argocdV1alpha1.ProjectRole{
+			Name:        appProjectRoleTeam,
+			Description: "Members of team [" + team + "]",
+			Policies: []string{
+				"p, proj:" + projectName + ":" + appProjectRoleTeam + ", applications, *, " + projectName + "/*, allow",
+			},
+			Groups: []string{team},
+		})
+	}
+	return roles
+}
+
+// operator 가 관리하는 role 은 새로 생성한 role 로 교체하고, 사용자가 추가한 role 은 유지한다.
+func mergeAppProjectRoles(current, managed []argocdV1alpha1.ProjectRole) []argocdV1alpha1.ProjectRole {
+	isManaged := map[string]bool{appProjectRoleTeam: true}
+	for _, name := range appProjectRoleNames {
+		isManaged[name] = true
+	}
+
+	// 비교를 위해 role 이 없는 경우 nil 을 반환한다.
+	var roles []argocdV1alpha1.ProjectRole
+	for _, role := range current {
+		if !isManaged[role.Name] {
+			roles = append(roles, role)
+		}
+	}
+	return append(roles, managed...)
+}
+
 func (r *ClusterManagerReconciler) CreateApplication(clusterManager *clusterV1alpha1.ClusterManager) error {
 	log := r.Log.WithValues("clustermanager", clusterManager.GetNamespacedName())
 
@@ -600,6 +958,11 @@ func (r *ClusterManagerReconciler) CreateApplication(clusterManager *clusterV1al
 	}
 	err := r.Client.Get(context.TODO(), key, &argocdV1alpha1.Application{})
 	if errors.IsNotFound(err) {
+		// air-gapped 환경에서는 내부 repo 의 chart 와 내부 registry 의 image 를 사용한다.
+		repoURL, privateRegistry := util.ArgoDescriptionGitRepo, util.ArgoDescriptionPrivateRegistry
+		if util.IsAirGapped() {
+			repoURL, privateRegistry = os.Getenv(util.AIR_GAPPED_CHART_REPO), os.Getenv(util.AIR_GAPPED_IMAGE_REPOSITORY)
+		}
 		application := &argocdV1alpha1.Application{
 			ObjectMeta: metav1.ObjectMeta{
 				Name:       key.Name,
@@ -633,7 +996,7 @@ func (r *ClusterManagerReconciler) CreateApplication(clusterManager *clusterV1al
 							},
 							{
 								Name:  "global.privateRegistry",
-								Value: util.ArgoDescriptionPrivateRegistry,
+								Value: privateRegistry,
 							},
 							{
 								Name:  "global.adminUser",
@@ -695,10 +1058,14 @@ func (r *ClusterManagerReconciler) CreateApplication(clusterManager *clusterV1al
 								Name:  "modules.hyperregistry.storageClassDatabase",
 								Value: util.ArgoDescriptionHyperregistryDBStorageClass,
 							},
+							{
+								Name:  util.ArgoParameterCertManagerEnable,
+								Value: strconv.FormatBool(clusterManager.GetCertManagerAddon() != nil),
+							},
 						},
 					},
 					Path:           "application/helm",
-					RepoURL:        util.ArgoDescriptionGitRepo,
+					RepoURL:        repoURL,
 					TargetRevision: util.ArgoDescriptionGitRevision,
 				},
 			},
@@ -715,6 +1082,56 @@ func (r *ClusterManagerReconciler) CreateApplication(clusterManager *clusterV1al
 	return err
 }
 
+// cluster 의 argocd application 의 helm parameter 를 params 의 값으로 설정한다.
+// application 이 아직 없으면 CreateApplication 이 생성할 때 설정하므로 아무것도 하지 않는다.
+func setApplicationHelmParameters(c client.Client, clusterManager *clusterV1alpha1.ClusterManager, params map[string]string) error {
+	application := &argocdV1alpha1.Application{}
+	key := types.NamespacedName{
+		Name:      clusterManager.GetApplicationName(),
+		Namespace: util.ArgoNamespace,
+	}
+	if err := c.Get(context.TODO(), key, application); errors.IsNotFound(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	if application.Spec.Source.Helm == nil {
+		return nil
+	}
+
+	updated := false
+	remains := map[string]string{}
+	for name, value := range params {
+		remains[name] = value
+	}
+	parameters := application.Spec.Source.Helm.Parameters
+	for i := range parameters {
+		value, ok := remains[parameters[i].Name]
+		if !ok {
+			continue
+		}
+		delete(remains, parameters[i].Name)
+		if parameters[i].Value != value {
+			parameters[i].Value = value
+			updated = true
+		}
+	}
+	names := []string{}
+	for name := range remains {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		parameters = append(parameters, argocdV1alpha1.HelmParameter{Name: name, Value: remains[name]})
+		updated = true
+	}
+	if !updated {
+		return nil
+	}
+	application.Spec.Source.Helm.Parameters = parameters
+	return c.Update(context.TODO(), application)
+}
+
 func (r *ClusterManagerReconciler) DeleteCertificate(clusterManager *clusterV1alpha1.ClusterManager) error {
 	log := r.Log.WithValues("clustermanager", clusterManager.GetNamespacedName())
 
@@ -940,13 +1357,13 @@ func (r *ClusterManagerReconciler) DeleteDeprecatedTraefikResources(clusterManag
 	}
 	ingress := &networkingv1.Ingress{}
 	if err := r.Client.Get(context.TODO(), key, ingress); errors.IsNotFound(err) {
-		log.Info("Not found: " + key.Name)
+		log.Info("Not found", "name", key.Name)
 	} else if err != nil {
-		log.Error(err, "Failed to get: "+key.Name)
+		log.Error(err, "Failed to get", "name", key.Name)
 		return ready, err
 	} else {
 		if err := r.Delete(context.TODO(), ingress); err != nil {
-			log.Error(err, "Failed to delete: "+key.Name)
+			log.Error(err, "Failed to delete", "name", key.Name)
 			return ready, err
 		}
 		ready = false
@@ -958,13 +1375,13 @@ func (r *ClusterManagerReconciler) DeleteDeprecatedTraefikResources(clusterManag
 	}
 	service := &coreV1.Service{}
 	if err := r.Client.Get(context.TODO(), key, service); errors.IsNotFound(err) {
-		log.Info("Not found: " + key.Name)
+		log.Info("Not found", "name", key.Name)
 	} else if err != nil {
-		log.Error(err, "Failed to get: "+key.Name)
+		log.Error(err, "Failed to get", "name", key.Name)
 		return ready, err
 	} else {
 		if err := r.Delete(context.TODO(), service); err != nil {
-			log.Error(err, "Failed to delete: "+key.Name)
+			log.Error(err, "Failed to delete", "name", key.Name)
 			return ready, err
 		}
 		ready = false
@@ -972,13 +1389,13 @@ func (r *ClusterManagerReconciler) DeleteDeprecatedTraefikResources(clusterManag
 
 	endpoint := &coreV1.Endpoints{}
 	if err := r.Client.Get(context.TODO(), key, endpoint); errors.IsNotFound(err) {
-		log.Info("Not found: " + key.Name)
+		log.Info("Not found", "name", key.Name)
 	} else if err != nil {
-		log.Error(err, "Failed to get: "+key.Name)
+		log.Error(err, "Failed to get", "name", key.Name)
 		return ready, err
 	} else {
 		if err := r.Delete(context.TODO(), endpoint); err != nil {
-			log.Error(err, "Failed to delete: "+key.Name)
+			log.Error(err, "Failed to delete", "name", key.Name)
 			return ready, err
 		}
 		ready = false
@@ -995,26 +1412,26 @@ func (r *ClusterManagerReconciler) DeleteDeprecatedPrometheusResources(clusterMa
 	}
 	service := &coreV1.Service{}
 	if err := r.Client.Get(context.TODO(), key, service); errors.IsNotFound(err) {
-		log.Info("Not found: " + key.Name)
+		log.Info("Not found", "name", key.Name)
 	} else if err != nil {
-		log.Error(err, "Failed to get: "+key.Name)
+		log.Error(err, "Failed to get", "name", key.Name)
 		return err
 	} else {
 		if err := r.Delete(context.TODO(), service); err != nil {
-			log.Error(err, "Failed to delete: "+key.Name)
+			log.Error(err, "Failed to delete", "name", key.Name)
 			return err
 		}
 	}
 
 	endpoint := &coreV1.Endpoints{}
 	if err := r.Client.Get(context.TODO(), key, endpoint); errors.IsNotFound(err) {
-		log.Info("Not found: " + key.Name)
+		log.Info("Not found", "name", key.Name)
 	} else if err != nil {
-		log.Error(err, "Failed to get: "+key.Name)
+		log.Error(err, "Failed to get", "name", key.Name)
 		return err
 	} else {
 		if err := r.Delete(context.TODO(), endpoint); err != nil {
-			log.Error(err, "Failed to delete: "+key.Name)
+			log.Error(err, "Failed to delete", "name", key.Name)
 			return err
 		}
 	}
@@ -1142,34 +1559,27 @@ func (r *ClusterManagerReconciler) DeleteLoadBalancerServices(clusterManager *cl
 		return nil
 	}
 
-	nsList, err := remoteClientset.CoreV1().Namespaces().List(context.TODO(), metav1.ListOptions{})
-	if err != nil {
-		log.Error(err, "Failed to list namespaces")
-		return err
-	}
-
-	for _, ns := range nsList.Items {
-		if ns.Name == util.KubeNamespace {
-			continue
+	// namespace 마다 list 하지 않고 전체 namespace 의 service 를 page 단위로 조회한다.
+	servicePager := pager.New(pager.SimplePageFunc(func(opts metav1.ListOptions) (runtime.Object, error) {
+		return remoteClientset.CoreV1().Services(metav1.NamespaceAll).List(context.TODO(), opts)
+	}))
+	servicePager.PageSize = util.ListPageSize
+	err = servicePager.EachListItem(context.TODO(), metav1.ListOptions{}, func(obj runtime.Object) error {
+		svc := obj.(*coreV1.Service)
+		if svc.Namespace == util.KubeNamespace || svc.Spec.Type != coreV1.ServiceTypeLoadBalancer {
+			return nil
 		}
 
-		svcList, err := remoteClientset.CoreV1().Services(ns.Name).List(context.TODO(), metav1.ListOptions{})
-		if err != nil {
-			log.Error(err, "Failed to list services in namespace ["+ns.Name+"]")
-			return err
-		}
-
-		for _, svc := range svcList.Items {
-			if svc.Spec.Type != coreV1.ServiceTypeLoadBalancer {
-				continue
-			}
-
-			delErr := remoteClientset.CoreV1().Services(ns.Name).Delete(context.TODO(), svc.Name, metav1.DeleteOptions{})
-			if delErr != nil {
-				log.Error(err, "Failed to delete service ["+svc.Name+"]in namespace ["+ns.Name+"]")
-				return err
-			}
+		delErr := remoteClientset.CoreV1().Services(svc.Namespace).Delete(context.TODO(), svc.Name, metav1.DeleteOptions{})
+		if delErr != nil && !errors.IsNotFound(delErr) {
+			log.Error(delErr, "Failed to delete service in namespace", "service", svc.Name, "namespace", svc.Namespace)
+			return delErr
 		}
+		return nil
+	})
+	if err != nil {
+		log.Error(err, "Failed to delete LoadBalancer services")
+		return err
 	}
 
 	log.Info("Delete LoadBalancer services in single cluster successfully")
@@ -1255,6 +1665,37 @@ func (r *ClusterManagerReconciler) DeleteIngressRoute(clusterManager *clusterV1a
 // 	return nil
 // }
 
+func (r *ClusterManagerReconciler) DeleteAppProject(clusterManager *clusterV1alpha1.ClusterManager) error {
+	log := r.Log.WithValues("clustermanager", clusterManager.GetNamespacedName())
+
+	key := types.NamespacedName{
+		Name:      clusterManager.GetAppProjectName(),
+		Namespace: util.ArgoNamespace,
+	}
+	appProject := &argocdV1alpha1.AppProject{}
+	if err := r.Client.Get(context.TODO(), key, appProject); errors.IsNotFound(err) {
+		log.Info("AppProject is already deleted")
+		return nil
+	} else if err != nil {
+		log.Error(err, "Failed to get AppProject")
+		return err
+	}
+
+	// 사용자가 직접 생성한 같은 이름의 AppProject 는 삭제하지 않는다.
+	if appProject.Labels[clusterV1alpha1.LabelKeyClmName] != clusterManager.Name ||
+		appProject.Labels[clusterV1alpha1.LabelKeyClmNamespace] != clusterManager.Namespace {
+		return nil
+	}
+
+	if err := r.Delete(context.TODO(), appProject); err != nil {
+		log.Error(err, "Failed to delete AppProject")
+		return err
+	}
+
+	log.Info("Delete AppProject successfully")
+	return nil
+}
+
 func (r *ClusterManagerReconciler) DeleteHyperAuthResources(clusterManager *clusterV1alpha1.ClusterManager) error {
 	log := r.Log.WithValues("clustermanager", clusterManager.GetNamespacedName())
 
@@ -1281,7 +1722,7 @@ func (r *ClusterManagerReconciler) DeleteHyperAuthResources(clusterManager *clus
 	for _, config := range clientConfigs {
 		err := hyperauthCaller.DeleteClient(config, secret)
 		if err != nil {
-			log.Error(err, "Failed to delete HyperAuth client ["+config.ClientId+"] for single cluster")
+			log.Error(err, "Failed to delete HyperAuth client for single cluster", "client", config.ClientId)
 			return err
 		}
 	}
@@ -1290,7 +1731,7 @@ func (r *ClusterManagerReconciler) DeleteHyperAuthResources(clusterManager *clus
 	for _, config := range groupConfigs {
 		err := hyperauthCaller.DeleteGroup(config, secret)
 		if err != nil {
-			log.Error(err, "Failed to delete HyperAuth group ["+config.Name+"] for single cluster")
+			log.Error(err, "Failed to delete HyperAuth group for single cluster", "group", config.Name)
 			return err
 		}
 	}
@@ -1298,3 +1739,29 @@ func (r *ClusterManagerReconciler) DeleteHyperAuthResources(clusterManager *clus
 	log.Info("Delete HyperAuth resources for single cluster successfully")
 	return nil
 }
+
+func (r *ClusterManagerReconciler) recordAudit(clusterManager *clusterV1alpha1.ClusterManager, action clusterV1alpha1.ClusterAuditAction, actor, message string) {
+	err := util.RecordAudit(r.Client, clusterManager.Namespace, clusterManager.Name, action, actor, message)
+	if err != nil {
+		r.Log.Error(err, "Failed to record audit for ClusterManager", "action", string(action), "clusterManager", clusterManager.Name)
+	}
+}
+
+func (r *ClusterManagerReconciler) notify(clusterManager *clusterV1alpha1.ClusterManager, event clusterV1alpha1.NotificationEvent, message string) {
+	err := util.Notify(r.Client, util.NewClusterNotification(clusterManager, event, message))
+	if err != nil {
+		r.Log.Error(err, "Failed to send notification for ClusterManager", "event", string(event), "clusterManager", clusterManager.Name)
+	}
+}
+
+func (r *ClusterManagerReconciler) pushClusterEvent(clusterManager *clusterV1alpha1.ClusterManager, eventType util.ClusterEventType) {
+	err := util.PushClusterEvent(util.ClusterEvent{
+		Type:        eventType,
+		Namespace:   clusterManager.Namespace,
+		ClusterName: clusterManager.Name,
+		Owner:       clusterManager.Annotations[util.AnnotationKeyOwner],
+	})
+	if err != nil {
+		r.Log.Error(err, "Failed to push cluster event for ClusterManager", "eventType", string(eventType), "clusterManager", clusterManager.Name)
+	}
+}
@@ -0,0 +1,109 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	clusterV1alpha1 "github.com/tmax-cloud/hypercloud-multi-operator/apis/cluster/v1alpha1"
+	"github.com/tmax-cloud/hypercloud-multi-operator/controllers/util"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+const (
+	versionReasonSupported   = "Supported"
+	versionReasonUnsupported = "Unsupported"
+)
+
+// CheckVersionDrift 는 등록된 cluster 의 control plane, kubelet version 을 주기적으로 지원하는 version 목록과 비교한다.
+// operator 가 등록된 cluster 를 upgrade 할 수는 없으므로 OutdatedVersion condition 과 notification 으로 알리기만 한다.
+func (r *ClusterManagerReconciler) CheckVersionDrift(ctx context.Context, clusterManager *clusterV1alpha1.ClusterManager) (ctrl.Result, error) {
+	supported := util.GetSupportedKubernetesVersions()
+	if len(supported) == 0 {
+		meta.RemoveStatusCondition(&clusterManager.Status.Conditions, clusterV1alpha1.ClusterManagerConditionOutdatedVersion)
+		return ctrl.Result{}, nil
+	}
+	if !clusterManager.Status.ControlPlaneReady {
+		return ctrl.Result{}, nil
+	}
+
+	log := r.Log.WithValues("clustermanager", clusterManager.GetNamespacedName())
+	log.Info("Start to reconcile phase for CheckVersionDrift")
+
+	// version 과 node 조회만 하므로 limited kubeconfig 를 사용한다.
+	remoteClientset, err := r.remoteClients().ClientsetForCluster(context.TODO(), r.Client, clusterManager.Namespace, clusterManager.Name, util.CredentialLimited)
+	if errors.IsNotFound(err) {
+		log.Error(err, "Failed to get kubeconfig secret")
+		return ctrl.Result{Requeue: true}, nil
+	} else if err != nil {
+		log.Error(err, "Failed to get remoteK8sClient")
+		return ctrl.Result{}, err
+	}
+
+	serverVersion, err := remoteClientset.Discovery().ServerVersion()
+	if err != nil {
+		log.Error(err, "Failed to get server version of remote cluster")
+		return ctrl.Result{}, err
+	}
+	nodeList, err := remoteClientset.CoreV1().Nodes().List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		log.Error(err, "Failed to list nodes of remote cluster")
+		return ctrl.Result{}, err
+	}
+
+	unsupported := []string{}
+	if ok, err := util.IsSupportedKubernetesVersion(serverVersion.GitVersion, supported); err != nil || !ok {
+		unsupported = append(unsupported, "control plane "+serverVersion.GitVersion)
+	}
+	kubeletVersions := sets.NewString()
+	for _, node := range nodeList.Items {
+		kubeletVersions.Insert(node.Status.NodeInfo.KubeletVersion)
+	}
+	for _, kubeletVersion := range kubeletVersions.List() {
+		if ok, err := util.IsSupportedKubernetesVersion(kubeletVersion, supported); err != nil || !ok {
+			unsupported = append(unsupported, "kubelet "+kubeletVersion)
+		}
+	}
+
+	condition := metav1.Condition{
+		Type:               clusterV1alpha1.ClusterManagerConditionOutdatedVersion,
+		Status:             metav1.ConditionFalse,
+		Reason:             versionReasonSupported,
+		Message:            "control plane " + serverVersion.GitVersion + " is supported",
+		ObservedGeneration: clusterManager.Generation,
+	}
+	if len(unsupported) > 0 {
+		condition.Status = metav1.ConditionTrue
+		condition.Reason = versionReasonUnsupported
+		condition.Message = fmt.Sprintf("%s not in supported versions [%s]", strings.Join(unsupported, ", "), strings.Join(supported, ", "))
+	}
+
+	// 지원하지 않는 version 이 되었을 때 한 번만 알린다.
+	if !meta.IsStatusConditionTrue(clusterManager.Status.Conditions, condition.Type) && condition.Status == metav1.ConditionTrue {
+		log.Info("Cluster is running unsupported version", "unsupported", unsupported)
+		r.notify(clusterManager, clusterV1alpha1.NotificationEventOutdatedVersion, "Cluster is running unsupported version: "+condition.Message)
+	}
+	meta.SetStatusCondition(&clusterManager.Status.Conditions, condition)
+
+	return util.RequeueAfterWithJitter(getClusterResyncPeriod(clusterManager)), nil
+}
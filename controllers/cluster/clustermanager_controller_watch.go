@@ -23,15 +23,16 @@ import (
 	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/types"
 
-	capiV1alpha3 "sigs.k8s.io/cluster-api/api/v1alpha3"
-	controlplanev1 "sigs.k8s.io/cluster-api/controlplane/kubeadm/api/v1alpha3"
+	capiV1beta1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	controlplanev1 "sigs.k8s.io/cluster-api/controlplane/kubeadm/api/v1beta1"
+	"sigs.k8s.io/cluster-api/util/conditions"
 	"sigs.k8s.io/cluster-api/util/patch"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
 func (r *ClusterManagerReconciler) requeueClusterManagersForCluster(o client.Object) []ctrl.Request {
-	c := o.DeepCopyObject().(*capiV1alpha3.Cluster)
+	c := o.DeepCopyObject().(*capiV1beta1.Cluster)
 	log := r.Log.WithValues("objectMapper", "clusterToClusterManager", "namespace", c.Namespace, c.Kind, c.Name)
 	log.Info("Start to requeueClusterManagersForCluster mapping...")
 
@@ -57,7 +58,7 @@ func (r *ClusterManagerReconciler) requeueClusterManagersForCluster(o client.Obj
 		}
 	}()
 	// clm.Status.SetTypedPhase(clusterV1alpha1.ClusterManagerPhaseProvisioned)
-	clm.Status.ControlPlaneReady = c.Status.ControlPlaneInitialized
+	clm.Status.ControlPlaneReady = conditions.IsTrue(c, capiV1beta1.ControlPlaneInitializedCondition)
 
 	return nil
 }
@@ -103,6 +104,12 @@ func (r *ClusterManagerReconciler) requeueClusterManagersForKubeadmControlPlane(
 		log.Info("Update clusterManager status", "masterRun", clm.Status.MasterRun)
 	}
 
+	// topology 로 생성된 kcp 의 replicas 는 topology controller 가 관리한다.
+	// rollout 상태는 UpdateRolloutStatus 에서 반영한다.
+	if _, ok := cp.Labels[capiV1beta1.ClusterTopologyOwnedLabel]; ok {
+		return []ctrl.Request{{NamespacedName: key}}
+	}
+
 	// kubeadmcontrolplane spec replicas update
 	if cp.Spec.Replicas != nil && *cp.Spec.Replicas != int32(clm.Spec.MasterNum) {
 		masterNum := int32(clm.Spec.MasterNum)
@@ -121,11 +128,11 @@ func (r *ClusterManagerReconciler) requeueClusterManagersForKubeadmControlPlane(
 		return nil
 	}
 
-	return nil
+	return []ctrl.Request{{NamespacedName: key}}
 }
 
 func (r *ClusterManagerReconciler) requeueClusterManagersForMachineDeployment(o client.Object) []ctrl.Request {
-	md := o.DeepCopyObject().(*capiV1alpha3.MachineDeployment)
+	md := o.DeepCopyObject().(*capiV1beta1.MachineDeployment)
 	log := r.Log.WithValues("objectMapper", "MachineDeploymentToClusterManagers", "namespace", md.Namespace, md.Kind, md.Name)
 
 	// Don't handle deleted machinedeployment
@@ -146,6 +153,11 @@ func (r *ClusterManagerReconciler) requeueClusterManagersForMachineDeployment(o
 		Namespace: md.Namespace,
 	}
 
+	// node pool 의 machine deployment 는 worker 수와 무관하며, ReconcileNodePools 에서 replicas 와 status 를 맞춘다.
+	if _, ok := md.Labels[clusterV1alpha1.LabelKeyClmNodePool]; ok {
+		return []ctrl.Request{{NamespacedName: key}}
+	}
+
 	clm := &clusterV1alpha1.ClusterManager{}
 	if err := r.Client.Get(context.TODO(), key, clm); errors.IsNotFound(err) {
 		log.Info("ClusterManager is deleted")
@@ -164,7 +176,13 @@ func (r *ClusterManagerReconciler) requeueClusterManagersForMachineDeployment(o
 			return nil
 		}
 		log.Info("Update clusterManager status", "workerRun", clm.Status.WorkerRun)
-		return nil
+		return []ctrl.Request{{NamespacedName: key}}
+	}
+
+	// topology 로 생성된 machine deployment 의 replicas 는 topology controller 가 관리한다.
+	// rollout 상태는 UpdateRolloutStatus 에서 반영한다.
+	if _, ok := md.Labels[capiV1beta1.ClusterTopologyOwnedLabel]; ok {
+		return []ctrl.Request{{NamespacedName: key}}
 	}
 
 	// machine deployment spec replicas update
@@ -185,7 +203,7 @@ func (r *ClusterManagerReconciler) requeueClusterManagersForMachineDeployment(o
 		return nil
 	}
 
-	return nil
+	return []ctrl.Request{{NamespacedName: key}}
 }
 
 func (r *ClusterManagerReconciler) requeueClusterManagersForSubresources(o client.Object) []ctrl.Request {
@@ -224,3 +242,20 @@ func (r *ClusterManagerReconciler) requeueClusterManagersForSubresources(o clien
 
 	return nil
 }
+
+func (r *ClusterManagerReconciler) requeueClusterManagersForClusterMember(o client.Object) []ctrl.Request {
+	clusterMember := o.DeepCopyObject().(*clusterV1alpha1.ClusterMember)
+	clmName := clusterMember.Labels[clusterV1alpha1.LabelKeyClmName]
+	if clmName == "" {
+		return nil
+	}
+
+	return []ctrl.Request{
+		{
+			NamespacedName: types.NamespacedName{
+				Name:      clmName,
+				Namespace: clusterMember.Namespace,
+			},
+		},
+	}
+}
@@ -12,6 +12,8 @@ import (
 )
 
 func buildClusterParams(clm clusterV1alpha1.ClusterManager) []tmaxv1.ParamSpec {
+	// air-gapped 환경에서는 내부 registry 와 mirror 를 사용한다.
+	artifacts := util.GetProvisioningArtifacts()
 	params := []tmaxv1.ParamSpec{
 		buildParam(CLUSTER_PARAM_NAMESPACE, clm.Namespace, intstr.String),
 		buildParam(CLUSTER_PARAM_CLUSTER_NAME, clm.Name, intstr.String),
@@ -19,6 +21,8 @@ func buildClusterParams(clm clusterV1alpha1.ClusterManager) []tmaxv1.ParamSpec {
 		buildParam(CLUSTER_PARAM_WORKER_NUM, clm.Spec.WorkerNum, intstr.Int),
 		buildParam(CLUSTER_PARAM_OWNER, clm.Annotations[util.AnnotationKeyOwner], intstr.String),
 		buildParam(CLUSTER_PARAM_KUBERNETES_VERSION, clm.Spec.Version, intstr.String),
+		buildParam(CLUSTER_PARAM_IMAGE_REPOSITORY, artifacts.ImageRepository, intstr.String),
+		buildParam(CLUSTER_PARAM_CNI_MANIFEST_URL, artifacts.CNIManifestURL, intstr.String),
 	}
 
 	return params
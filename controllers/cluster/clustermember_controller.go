@@ -0,0 +1,224 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+
+	"github.com/go-logr/logr"
+	clusterV1alpha1 "github.com/tmax-cloud/hypercloud-multi-operator/apis/cluster/v1alpha1"
+	"github.com/tmax-cloud/hypercloud-multi-operator/controllers/pipeline"
+	"github.com/tmax-cloud/hypercloud-multi-operator/controllers/util"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+
+	"sigs.k8s.io/cluster-api/util/patch"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+// ClusterMemberReconciler reconciles a ClusterMember object
+type ClusterMemberReconciler struct {
+	client.Client
+	Log      logr.Logger
+	Scheme   *runtime.Scheme
+	Recorder record.EventRecorder
+	// member cluster 의 clientset 을 생성한다. nil 이면 util.DefaultRemoteClientFactory 를 사용한다.
+	RemoteClients util.RemoteClientFactory
+}
+
+func (r *ClusterMemberReconciler) remoteClients() util.RemoteClientFactory {
+	return util.RemoteClientFactoryOrDefault(r.RemoteClients)
+}
+
+// +kubebuilder:rbac:groups=cluster.tmax.io,resources=clustermembers,verbs=create;delete;get;list;patch;update;watch
+// +kubebuilder:rbac:groups=cluster.tmax.io,resources=clustermembers/status,verbs=get;patch;update
+
+func (r *ClusterMemberReconciler) Reconcile(ctx context.Context, req ctrl.Request) (_ ctrl.Result, reterr error) {
+	_ = context.Background()
+	log := r.Log.WithValues("clustermember", req.NamespacedName)
+
+	// get ClusterMember
+	clusterMember := &clusterV1alpha1.ClusterMember{}
+	if err := r.Client.Get(context.TODO(), req.NamespacedName, clusterMember); errors.IsNotFound(err) {
+		log.Info("ClusterMember not found. Ignoring since object must be deleted")
+		pipeline.Forget(clusterMember, req.NamespacedName)
+		return ctrl.Result{}, nil
+	} else if err != nil {
+		log.Error(err, "Failed to get ClusterMember")
+		return ctrl.Result{}, err
+	}
+
+	patchHelper, err := patch.NewHelper(clusterMember, r.Client)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	defer func() {
+		// Always reconcile the Status.Phase field.
+		r.reconcilePhase(context.TODO(), clusterMember)
+
+		if err := patchHelper.Patch(context.TODO(), clusterMember); err != nil {
+			reterr = err
+		}
+	}()
+
+	// Add finalizer first if not exist to avoid the race condition between init and delete
+	if !controllerutil.ContainsFinalizer(clusterMember, clusterV1alpha1.ClusterMemberFinalizer) {
+		controllerutil.AddFinalizer(clusterMember, clusterV1alpha1.ClusterMemberFinalizer)
+		return ctrl.Result{}, nil
+	}
+
+	// Handle deletion reconciliation loop.
+	if !clusterMember.ObjectMeta.DeletionTimestamp.IsZero() {
+		return r.reconcileDelete(context.TODO(), clusterMember)
+	}
+
+	// Handle normal reconciliation loop.
+	return r.reconcile(context.TODO(), clusterMember)
+}
+
+// reconcile handles cluster member reconciliation.
+func (r *ClusterMemberReconciler) reconcile(ctx context.Context, clusterMember *clusterV1alpha1.ClusterMember) (ctrl.Result, error) {
+	phases := []pipeline.Phase[*clusterV1alpha1.ClusterMember]{
+		// 멤버를 초대할 cluster manager 가 존재하는지 확인하고, cluster manager 에 대한 label 을 달아준다.
+		{Name: "CheckClusterManager", Run: r.CheckClusterManager},
+		// 초대를 수락한 멤버에 대해 db 에 멤버 정보를 저장한다.
+		{Name: "RegisterMember", Run: r.RegisterMember},
+		// 초대를 수락한 멤버에 대해 remote cluster 에 role 에 맞는 cluster rolebinding 을 생성한다.
+		// role 이 변경된 경우 cluster rolebinding 을 다시 생성한다.
+		// 권한이 없는 경우 재시도해도 실패하므로 주기적으로만 다시 확인한다.
+		{
+			Name:    "SyncMemberRoleBinding",
+			Run:     r.SyncMemberRoleBinding,
+			Requeue: pipeline.RequeuePolicy{NonRetryableAfter: resyncPeriod1Minute},
+		},
+		// 수락이 취소된 멤버에 대해 remote cluster 의 cluster rolebinding 과 db 의 멤버 정보를 삭제한다.
+		{Name: "RevokeMember", Run: r.RevokeMember},
+	}
+
+	return pipeline.NewRunner[*clusterV1alpha1.ClusterMember](r.Log, r.Recorder).Run(ctx, clusterMember, phases)
+}
+
+func (r *ClusterMemberReconciler) reconcileDelete(ctx context.Context, clusterMember *clusterV1alpha1.ClusterMember) (ctrl.Result, error) {
+	log := r.Log.WithValues("clustermember", clusterMember.GetNamespacedName())
+	log.Info("Start to reconcile delete for ClusterMember")
+
+	clusterMember.Status.SetTypedPhase(clusterV1alpha1.ClusterMemberPhaseRemoving)
+
+	// remote cluster 에 생성한 cluster rolebinding 삭제
+	if clusterMember.Status.RoleBindingName != "" {
+		if err := r.DeleteMemberRoleBinding(ctx, clusterMember); err != nil {
+			log.Error(err, "Failed to delete ClusterRoleBinding for member from remote cluster")
+			return ctrl.Result{}, err
+		}
+	}
+
+	// db 에서 member 삭제
+	if clusterMember.Status.MemberRegistered {
+		err := util.DeleteMemberOrQueue(ctx, r.Client, clusterMember)
+		if err != nil {
+			log.Error(err, "Failed to delete member from cluster_member table")
+			return ctrl.Result{}, err
+		}
+		clusterMember.Status.MemberRegistered = false
+		r.pushMemberEvent(clusterMember, util.ClusterEventTypeMemberRemoved)
+	}
+
+	controllerutil.RemoveFinalizer(clusterMember, clusterV1alpha1.ClusterMemberFinalizer)
+	log.Info("ClusterMember is removed successfully")
+	return ctrl.Result{}, nil
+}
+
+func (r *ClusterMemberReconciler) reconcilePhase(_ context.Context, clusterMember *clusterV1alpha1.ClusterMember) {
+	if clusterMember.Status.Phase == "" {
+		clusterMember.Status.SetTypedPhase(clusterV1alpha1.ClusterMemberPhaseInvited)
+	}
+
+	if !clusterMember.DeletionTimestamp.IsZero() {
+		clusterMember.Status.SetTypedPhase(clusterV1alpha1.ClusterMemberPhaseRemoving)
+		return
+	}
+
+	if clusterMember.Spec.Accepted && clusterMember.Status.MemberRegistered &&
+		clusterMember.Status.BoundRole == clusterMember.Spec.Role {
+		clusterMember.Status.SetTypedPhase(clusterV1alpha1.ClusterMemberPhaseAccepted)
+	}
+}
+
+func (r *ClusterMemberReconciler) requeueClusterMembersForClusterManager(o client.Object) []ctrl.Request {
+	clm := o.DeepCopyObject().(*clusterV1alpha1.ClusterManager)
+	log := r.Log.WithValues("ClusterMember-ObjectMapper", "clusterManagerToClusterMembers", "ClusterManager", clm.GetNamespacedName())
+
+	clusterMemberList := &clusterV1alpha1.ClusterMemberList{}
+	opts := []client.ListOption{
+		client.InNamespace(clm.Namespace),
+		client.MatchingLabels{clusterV1alpha1.LabelKeyClmName: clm.Name},
+	}
+	if err := r.Client.List(context.TODO(), clusterMemberList, opts...); err != nil {
+		log.Error(err, "Failed to list ClusterMember")
+		return nil
+	}
+
+	reqs := []ctrl.Request{}
+	for _, clusterMember := range clusterMemberList.Items {
+		reqs = append(reqs, ctrl.Request{NamespacedName: clusterMember.GetNamespacedName()})
+	}
+	return reqs
+}
+
+func (r *ClusterMemberReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	controller, err := ctrl.NewControllerManagedBy(mgr).
+		For(&clusterV1alpha1.ClusterMember{}).
+		WithOptions(util.DefaultControllerOptions()).
+		WithEventFilter(util.SpecChangedPredicate()).
+		Build(util.ShardReconciler(mgr.GetClient(), r))
+
+	if err != nil {
+		return err
+	}
+
+	// cluster 의 control plane 이 준비되면, 대기중인 멤버들의 rolebinding 을 생성한다.
+	return controller.Watch(
+		&source.Kind{Type: &clusterV1alpha1.ClusterManager{}},
+		handler.EnqueueRequestsFromMapFunc(r.requeueClusterMembersForClusterManager),
+		predicate.Funcs{
+			UpdateFunc: func(e event.UpdateEvent) bool {
+				oldClm := e.ObjectOld.(*clusterV1alpha1.ClusterManager)
+				newClm := e.ObjectNew.(*clusterV1alpha1.ClusterManager)
+				if !oldClm.Status.ControlPlaneReady && newClm.Status.ControlPlaneReady {
+					return true
+				}
+				return false
+			},
+			CreateFunc: func(e event.CreateEvent) bool {
+				return false
+			},
+			DeleteFunc: func(e event.DeleteEvent) bool {
+				return false
+			},
+			GenericFunc: func(e event.GenericEvent) bool {
+				return false
+			},
+		},
+	)
+}
@@ -0,0 +1,269 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+
+	clusterV1alpha1 "github.com/tmax-cloud/hypercloud-multi-operator/apis/cluster/v1alpha1"
+	util "github.com/tmax-cloud/hypercloud-multi-operator/controllers/util"
+
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+func (r *ClusterMemberReconciler) CheckClusterManager(ctx context.Context, clusterMember *clusterV1alpha1.ClusterMember) (ctrl.Result, error) {
+	log := r.Log.WithValues("clustermember", clusterMember.GetNamespacedName())
+	log.Info("Start to reconcile phase for CheckClusterManager")
+
+	clm := &clusterV1alpha1.ClusterManager{}
+	if err := r.Client.Get(context.TODO(), clusterMember.GetClusterManagerNamespacedName(), clm); errors.IsNotFound(err) {
+		log.Info("ClusterManager not found", "clusterManager", clusterMember.Spec.ClusterName)
+		clusterMember.Status.SetTypedPhase(clusterV1alpha1.ClusterMemberPhaseError)
+		clusterMember.Status.Reason = "cluster not found"
+		return ctrl.Result{Requeue: true}, nil
+	} else if err != nil {
+		log.Error(err, "Failed to get ClusterManager")
+		return ctrl.Result{}, err
+	}
+
+	if clusterMember.Labels == nil {
+		clusterMember.Labels = map[string]string{}
+	}
+	clusterMember.Labels[clusterV1alpha1.LabelKeyClmName] = clm.Name
+
+	if clusterMember.Status.Phase == clusterV1alpha1.ClusterMemberPhaseError {
+		clusterMember.Status.SetTypedPhase(clusterV1alpha1.ClusterMemberPhaseInvited)
+		clusterMember.Status.Reason = ""
+	}
+
+	return ctrl.Result{}, nil
+}
+
+func (r *ClusterMemberReconciler) RegisterMember(ctx context.Context, clusterMember *clusterV1alpha1.ClusterMember) (ctrl.Result, error) {
+	if !clusterMember.Spec.Accepted || clusterMember.Status.MemberRegistered ||
+		clusterMember.Status.Phase == clusterV1alpha1.ClusterMemberPhaseError {
+		return ctrl.Result{}, nil
+	}
+	log := r.Log.WithValues("clustermember", clusterMember.GetNamespacedName())
+	log.Info("Start to reconcile phase for RegisterMember")
+
+	if err := util.InsertMemberOrQueue(ctx, r.Client, clusterMember); err != nil {
+		log.Error(err, "Failed to insert member info to cluster_member table")
+		clusterMember.Status.Reason = util.ErrorReason(err)
+		return ctrl.Result{}, err
+	}
+	clusterMember.Status.MemberRegistered = true
+	clusterMember.Status.Reason = ""
+	log.Info("Insert member info to cluster_member table successfully")
+	r.pushMemberEvent(clusterMember, util.ClusterEventTypeMemberAdded)
+
+	return ctrl.Result{}, nil
+}
+
+func (r *ClusterMemberReconciler) SyncMemberRoleBinding(ctx context.Context, clusterMember *clusterV1alpha1.ClusterMember) (ctrl.Result, error) {
+	if !clusterMember.Spec.Accepted || clusterMember.Status.BoundRole == clusterMember.Spec.Role ||
+		clusterMember.Status.Phase == clusterV1alpha1.ClusterMemberPhaseError {
+		return ctrl.Result{}, nil
+	}
+	log := r.Log.WithValues("clustermember", clusterMember.GetNamespacedName())
+	log.Info("Start to reconcile phase for SyncMemberRoleBinding")
+
+	clm := &clusterV1alpha1.ClusterManager{}
+	if err := r.Client.Get(context.TODO(), clusterMember.GetClusterManagerNamespacedName(), clm); err != nil {
+		log.Error(err, "Failed to get ClusterManager")
+		return ctrl.Result{}, err
+	}
+
+	// control plane 이 준비되면 cluster manager watch 에 의해 다시 reconcile 된다.
+	if !clm.Status.ControlPlaneReady {
+		log.Info("Wait for control plane of cluster to be ready", "cluster", clm.Name)
+		return ctrl.Result{}, nil
+	}
+
+	remoteClientset, err := r.getRemoteClientset(clm)
+	if err != nil {
+		log.Error(err, "Failed to get remoteK8sClient")
+		return ctrl.Result{}, err
+	}
+
+	subjectKind := rbacv1.UserKind
+	if clusterMember.Spec.Attribute == clusterV1alpha1.ClusterMemberAttributeGroup {
+		subjectKind = rbacv1.GroupKind
+	}
+	memberCRB := &rbacv1.ClusterRoleBinding{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: clusterMember.GetRoleBindingName(),
+		},
+		RoleRef: rbacv1.RoleRef{
+			APIGroup: rbacv1.GroupName,
+			Kind:     "ClusterRole",
+			Name:     clusterMember.GetClusterRoleName(),
+		},
+		Subjects: []rbacv1.Subject{
+			{
+				APIGroup: rbacv1.GroupName,
+				Kind:     subjectKind,
+				Name:     clusterMember.Spec.MemberId,
+			},
+		},
+	}
+
+	crb, err := remoteClientset.
+		RbacV1().
+		ClusterRoleBindings().
+		Get(context.TODO(), memberCRB.Name, metav1.GetOptions{})
+	if err == nil && crb.RoleRef.Name != memberCRB.RoleRef.Name {
+		// roleRef 는 수정할 수 없으므로, role 이 변경된 경우 삭제 후 다시 생성한다.
+		err = remoteClientset.
+			RbacV1().
+			ClusterRoleBindings().
+			Delete(context.TODO(), memberCRB.Name, metav1.DeleteOptions{})
+		if err != nil && !errors.IsNotFound(err) {
+			log.Error(err, "Cannot delete ClusterRoleBinding from remote cluster", "clusterRoleBinding", memberCRB.Name)
+			return ctrl.Result{}, err
+		}
+		err = errors.NewNotFound(rbacv1.Resource("clusterrolebindings"), memberCRB.Name)
+	}
+	if errors.IsNotFound(err) {
+		_, err := remoteClientset.
+			RbacV1().
+			ClusterRoleBindings().
+			Create(context.TODO(), memberCRB, metav1.CreateOptions{})
+		if err != nil {
+			err = util.ClassifyRemoteError(err)
+			log.Error(err, "Cannot create ClusterRoleBinding to remote cluster", "clusterRoleBinding", memberCRB.Name)
+			clusterMember.Status.Reason = util.ErrorReason(err)
+			return ctrl.Result{}, err
+		}
+		log.Info("Create ClusterRoleBinding to remote cluster successfully", "clusterRoleBinding", memberCRB.Name)
+	} else if err != nil {
+		log.Error(err, "Failed to get ClusterRoleBinding from remote cluster", "clusterRoleBinding", memberCRB.Name)
+		return ctrl.Result{}, err
+	}
+
+	// 이미 role 이 부여된 멤버의 role 이 변경된 경우, db 의 member 정보도 변경된 role 로 다시 쓴다.
+	if clusterMember.Status.BoundRole != "" {
+		if clusterMember.Status.MemberRegistered {
+			if err := util.UpdateMemberOrQueue(ctx, r.Client, clusterMember); err != nil {
+				log.Error(err, "Failed to update member info in cluster_member table")
+				clusterMember.Status.Reason = util.ErrorReason(err)
+				return ctrl.Result{}, err
+			}
+		}
+		r.pushMemberEvent(clusterMember, util.ClusterEventTypeMemberChanged)
+	}
+	clusterMember.Status.RoleBindingName = memberCRB.Name
+	clusterMember.Status.BoundRole = clusterMember.Spec.Role
+	clusterMember.Status.Reason = ""
+	return ctrl.Result{}, nil
+}
+
+// 수락이 취소된 경우(spec.accepted: false), remote cluster 의 rolebinding 과 db 의 멤버 정보를 삭제한다.
+func (r *ClusterMemberReconciler) RevokeMember(ctx context.Context, clusterMember *clusterV1alpha1.ClusterMember) (ctrl.Result, error) {
+	if clusterMember.Spec.Accepted ||
+		(clusterMember.Status.RoleBindingName == "" && !clusterMember.Status.MemberRegistered) {
+		return ctrl.Result{}, nil
+	}
+	log := r.Log.WithValues("clustermember", clusterMember.GetNamespacedName())
+	log.Info("Start to reconcile phase for RevokeMember")
+
+	if clusterMember.Status.RoleBindingName != "" {
+		if err := r.DeleteMemberRoleBinding(ctx, clusterMember); err != nil {
+			log.Error(err, "Failed to delete ClusterRoleBinding for member from remote cluster")
+			return ctrl.Result{}, err
+		}
+	}
+
+	if clusterMember.Status.MemberRegistered {
+		err := util.DeleteMemberOrQueue(ctx, r.Client, clusterMember)
+		if err != nil {
+			log.Error(err, "Failed to delete member from cluster_member table")
+			return ctrl.Result{}, err
+		}
+		clusterMember.Status.MemberRegistered = false
+		r.pushMemberEvent(clusterMember, util.ClusterEventTypeMemberRemoved)
+	}
+
+	clusterMember.Status.SetTypedPhase(clusterV1alpha1.ClusterMemberPhaseInvited)
+	log.Info("Membership is revoked successfully")
+	return ctrl.Result{}, nil
+}
+
+func (r *ClusterMemberReconciler) DeleteMemberRoleBinding(ctx context.Context, clusterMember *clusterV1alpha1.ClusterMember) error {
+	log := r.Log.WithValues("clustermember", clusterMember.GetNamespacedName())
+
+	clm := &clusterV1alpha1.ClusterManager{}
+	if err := r.Client.Get(context.TODO(), clusterMember.GetClusterManagerNamespacedName(), clm); errors.IsNotFound(err) {
+		// cluster 가 삭제되는 경우, remote cluster 의 리소스는 cluster 와 함께 정리된다.
+		log.Info("ClusterManager is already deleted. Skip deleting ClusterRoleBinding from remote cluster")
+		clusterMember.Status.RoleBindingName = ""
+		clusterMember.Status.BoundRole = ""
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	// cluster 가 남아있는 동안에는 remote cluster 의 rolebinding 이 삭제될 때까지 다시 시도한다.
+	// 삭제하지 못한 채 status 를 비우면 rolebinding 이 remote cluster 에 남은 채로 잊혀진다.
+	remoteClientset, err := r.getRemoteClientset(clm)
+	if err != nil {
+		return fmt.Errorf("cannot get client for remote cluster to delete ClusterRoleBinding [%s]: %w", clusterMember.Status.RoleBindingName, err)
+	}
+
+	if !util.IsClusterHealthy(remoteClientset) {
+		return util.NewError(util.ErrRemoteUnreachable, fmt.Errorf("remote cluster is unhealthy, cannot delete ClusterRoleBinding [%s]", clusterMember.Status.RoleBindingName))
+	}
+
+	err = remoteClientset.
+		RbacV1().
+		ClusterRoleBindings().
+		Delete(context.TODO(), clusterMember.Status.RoleBindingName, metav1.DeleteOptions{})
+	if errors.IsNotFound(err) {
+		log.Info("Cannot find ClusterRoleBinding from remote cluster. Maybe already deleted", "clusterRoleBinding", clusterMember.Status.RoleBindingName)
+	} else if err != nil {
+		return err
+	} else {
+		log.Info("Deleted ClusterRoleBinding from remote cluster successfully", "clusterRoleBinding", clusterMember.Status.RoleBindingName)
+	}
+
+	clusterMember.Status.RoleBindingName = ""
+	clusterMember.Status.BoundRole = ""
+	return nil
+}
+
+// rolebinding 을 생성, 삭제해야 하므로 admin 권한의 client 를 사용한다.
+func (r *ClusterMemberReconciler) getRemoteClientset(clm *clusterV1alpha1.ClusterManager) (kubernetes.Interface, error) {
+	return r.remoteClients().ClientsetForCluster(context.TODO(), r.Client, clm.Namespace, clm.Name, util.CredentialAdmin)
+}
+
+func (r *ClusterMemberReconciler) pushMemberEvent(clusterMember *clusterV1alpha1.ClusterMember, eventType util.ClusterEventType) {
+	err := util.PushClusterEvent(util.ClusterEvent{
+		Type:        eventType,
+		Namespace:   clusterMember.Namespace,
+		ClusterName: clusterMember.Spec.ClusterName,
+		MemberId:    clusterMember.Spec.MemberId,
+		Attribute:   clusterMember.Spec.Attribute,
+		Role:        clusterMember.Spec.Role,
+	})
+	if err != nil {
+		r.Log.Error(err, "Failed to push cluster event for member", "eventType", string(eventType), "member", clusterMember.Spec.MemberId)
+	}
+}
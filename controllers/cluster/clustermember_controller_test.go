@@ -0,0 +1,272 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/go-logr/logr"
+	clusterV1alpha1 "github.com/tmax-cloud/hypercloud-multi-operator/apis/cluster/v1alpha1"
+	util "github.com/tmax-cloud/hypercloud-multi-operator/controllers/util"
+	utilfake "github.com/tmax-cloud/hypercloud-multi-operator/controllers/util/fake"
+
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+const testNamespace = "test"
+
+func newTestScheme(t *testing.T) *runtime.Scheme {
+	scheme := runtime.NewScheme()
+	if err := clientgoscheme.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	if err := clusterV1alpha1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	return scheme
+}
+
+func newTestClusterManager(name string) *clusterV1alpha1.ClusterManager {
+	clm := &clusterV1alpha1.ClusterManager{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: testNamespace,
+		},
+	}
+	clm.Status.ControlPlaneReady = true
+	return clm
+}
+
+func newTestClusterMember(role string) *clusterV1alpha1.ClusterMember {
+	return &clusterV1alpha1.ClusterMember{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "member",
+			Namespace: testNamespace,
+		},
+		Spec: clusterV1alpha1.ClusterMemberSpec{
+			ClusterName: "cluster",
+			MemberId:    "user@tmax.co.kr",
+			Attribute:   clusterV1alpha1.ClusterMemberAttributeUser,
+			Role:        role,
+			Accepted:    true,
+		},
+	}
+}
+
+func newTestClusterMemberReconciler(t *testing.T, remoteClients util.RemoteClientFactory, objects ...client.Object) *ClusterMemberReconciler {
+	return &ClusterMemberReconciler{
+		Client:        fake.NewClientBuilder().WithScheme(newTestScheme(t)).WithObjects(objects...).Build(),
+		Log:           logr.Discard(),
+		RemoteClients: remoteClients,
+	}
+}
+
+func adminClusterKey(clm *clusterV1alpha1.ClusterManager) types.NamespacedName {
+	return types.NamespacedName{Name: clm.Name, Namespace: clm.Namespace}
+}
+
+func TestSyncMemberRoleBinding(t *testing.T) {
+	tests := []struct {
+		name string
+		// spec 의 role
+		role string
+		// 이미 부여된 role, 빈 값이면 처음 부여하는 경우
+		boundRole string
+		// remote cluster 에 이미 있는 rolebinding 의 cluster role
+		existingRole     string
+		controlPlaneDown bool
+		wantClusterRole  string
+		wantBoundRole    string
+	}{
+		{
+			name:            "create rolebinding for new member",
+			role:            clusterV1alpha1.ClusterMemberRoleAdmin,
+			wantClusterRole: "cluster-admin",
+			wantBoundRole:   clusterV1alpha1.ClusterMemberRoleAdmin,
+		},
+		{
+			name:            "keep rolebinding with same role ref",
+			role:            clusterV1alpha1.ClusterMemberRoleDeveloper,
+			existingRole:    clusterV1alpha1.ClusterMemberRoleDeveloper,
+			wantClusterRole: clusterV1alpha1.ClusterMemberRoleDeveloper,
+			wantBoundRole:   clusterV1alpha1.ClusterMemberRoleDeveloper,
+		},
+		{
+			name:            "recreate rolebinding when role is changed",
+			role:            clusterV1alpha1.ClusterMemberRoleGuest,
+			boundRole:       clusterV1alpha1.ClusterMemberRoleDeveloper,
+			existingRole:    clusterV1alpha1.ClusterMemberRoleDeveloper,
+			wantClusterRole: clusterV1alpha1.ClusterMemberRoleGuest,
+			wantBoundRole:   clusterV1alpha1.ClusterMemberRoleGuest,
+		},
+		{
+			name:             "wait for control plane",
+			role:             clusterV1alpha1.ClusterMemberRoleAdmin,
+			controlPlaneDown: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clm := newTestClusterManager("cluster")
+			clm.Status.ControlPlaneReady = !tt.controlPlaneDown
+			member := newTestClusterMember(tt.role)
+			member.Status.BoundRole = tt.boundRole
+
+			remoteClients := utilfake.NewRemoteClientFactory()
+			remote := remoteClients.AddCluster(adminClusterKey(clm), util.CredentialAdmin)
+			if tt.existingRole != "" {
+				existing := &rbacv1.ClusterRoleBinding{
+					ObjectMeta: metav1.ObjectMeta{Name: member.GetRoleBindingName()},
+					RoleRef: rbacv1.RoleRef{
+						APIGroup: rbacv1.GroupName,
+						Kind:     "ClusterRole",
+						Name:     tt.existingRole,
+					},
+					Subjects: []rbacv1.Subject{
+						{
+							APIGroup: rbacv1.GroupName,
+							Kind:     rbacv1.UserKind,
+							Name:     member.Spec.MemberId,
+						},
+					},
+				}
+				if _, err := remote.RbacV1().ClusterRoleBindings().Create(context.TODO(), existing, metav1.CreateOptions{}); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			r := newTestClusterMemberReconciler(t, remoteClients, clm)
+			if _, err := r.SyncMemberRoleBinding(context.TODO(), member); err != nil {
+				t.Fatalf("SyncMemberRoleBinding() error = %v", err)
+			}
+
+			if member.Status.BoundRole != tt.wantBoundRole {
+				t.Errorf("BoundRole = %q, want %q", member.Status.BoundRole, tt.wantBoundRole)
+			}
+			crb, err := remote.RbacV1().ClusterRoleBindings().Get(context.TODO(), member.GetRoleBindingName(), metav1.GetOptions{})
+			if tt.wantClusterRole == "" {
+				if !errors.IsNotFound(err) {
+					t.Errorf("ClusterRoleBinding should not be created, got error %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("failed to get ClusterRoleBinding: %v", err)
+			}
+			if crb.RoleRef.Name != tt.wantClusterRole {
+				t.Errorf("RoleRef.Name = %q, want %q", crb.RoleRef.Name, tt.wantClusterRole)
+			}
+			if len(crb.Subjects) != 1 || crb.Subjects[0].Name != member.Spec.MemberId || crb.Subjects[0].Kind != rbacv1.UserKind {
+				t.Errorf("Subjects = %v, want user %q", crb.Subjects, member.Spec.MemberId)
+			}
+			if member.Status.RoleBindingName != crb.Name {
+				t.Errorf("RoleBindingName = %q, want %q", member.Status.RoleBindingName, crb.Name)
+			}
+		})
+	}
+}
+
+func TestRevokeMember(t *testing.T) {
+	tests := []struct {
+		name string
+		// cluster manager 가 삭제된 경우
+		clusterDeleted bool
+		// remote cluster 의 client 를 가져올 수 없는 경우
+		remoteErr          error
+		rolebindingDeleted bool
+		wantErr            bool
+		wantRoleBinding    string
+		wantPhase          clusterV1alpha1.ClusterMemberPhase
+	}{
+		{
+			name:      "delete rolebinding from remote cluster",
+			wantPhase: clusterV1alpha1.ClusterMemberPhaseInvited,
+		},
+		{
+			name:               "rolebinding is already deleted",
+			rolebindingDeleted: true,
+			wantPhase:          clusterV1alpha1.ClusterMemberPhaseInvited,
+		},
+		{
+			name:           "skip when cluster is deleted",
+			clusterDeleted: true,
+			remoteErr:      fmt.Errorf("cluster is deleted"),
+			wantPhase:      clusterV1alpha1.ClusterMemberPhaseInvited,
+		},
+		{
+			name:            "retry while remote cluster is unreachable",
+			remoteErr:       util.NewError(util.ErrRemoteUnreachable, fmt.Errorf("connection refused")),
+			wantErr:         true,
+			wantRoleBinding: "user@tmax.co.kr-user-rolebinding",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clm := newTestClusterManager("cluster")
+			member := newTestClusterMember(clusterV1alpha1.ClusterMemberRoleDeveloper)
+			member.Spec.Accepted = false
+			member.Status.RoleBindingName = member.GetRoleBindingName()
+			member.Status.BoundRole = member.Spec.Role
+
+			remoteClients := utilfake.NewRemoteClientFactory()
+			var objects []client.Object
+			if !tt.clusterDeleted {
+				objects = append(objects, clm)
+			}
+			if tt.remoteErr != nil {
+				remoteClients.Err = tt.remoteErr
+			} else {
+				remote := remoteClients.AddCluster(adminClusterKey(clm), util.CredentialAdmin)
+				if !tt.rolebindingDeleted {
+					crb := &rbacv1.ClusterRoleBinding{ObjectMeta: metav1.ObjectMeta{Name: member.GetRoleBindingName()}}
+					if _, err := remote.RbacV1().ClusterRoleBindings().Create(context.TODO(), crb, metav1.CreateOptions{}); err != nil {
+						t.Fatal(err)
+					}
+				}
+				defer func() {
+					if _, err := remote.RbacV1().ClusterRoleBindings().Get(context.TODO(), member.GetRoleBindingName(), metav1.GetOptions{}); !errors.IsNotFound(err) {
+						t.Errorf("ClusterRoleBinding should be deleted, got error %v", err)
+					}
+				}()
+			}
+
+			r := newTestClusterMemberReconciler(t, remoteClients, objects...)
+			_, err := r.RevokeMember(context.TODO(), member)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("RevokeMember() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if member.Status.RoleBindingName != tt.wantRoleBinding {
+				t.Errorf("RoleBindingName = %q, want %q", member.Status.RoleBindingName, tt.wantRoleBinding)
+			}
+			if tt.wantRoleBinding == "" && member.Status.BoundRole != "" {
+				t.Errorf("BoundRole = %q, want empty", member.Status.BoundRole)
+			}
+			if member.Status.Phase != tt.wantPhase {
+				t.Errorf("Phase = %q, want %q", member.Status.Phase, tt.wantPhase)
+			}
+		})
+	}
+}
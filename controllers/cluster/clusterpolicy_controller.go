@@ -0,0 +1,195 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+
+	"github.com/go-logr/logr"
+	clusterV1alpha1 "github.com/tmax-cloud/hypercloud-multi-operator/apis/cluster/v1alpha1"
+	"github.com/tmax-cloud/hypercloud-multi-operator/controllers/pipeline"
+	"github.com/tmax-cloud/hypercloud-multi-operator/controllers/util"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+
+	"sigs.k8s.io/cluster-api/util/patch"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+// ClusterPolicyReconciler reconciles a ClusterPolicy object
+type ClusterPolicyReconciler struct {
+	client.Client
+	Log      logr.Logger
+	Scheme   *runtime.Scheme
+	Recorder record.EventRecorder
+}
+
+// +kubebuilder:rbac:groups=cluster.tmax.io,resources=clusterpolicies,verbs=create;delete;get;list;patch;update;watch
+// +kubebuilder:rbac:groups=cluster.tmax.io,resources=clusterpolicies/status,verbs=get;patch;update
+
+func (r *ClusterPolicyReconciler) Reconcile(ctx context.Context, req ctrl.Request) (_ ctrl.Result, reterr error) {
+	_ = context.Background()
+	log := r.Log.WithValues("clusterpolicy", req.NamespacedName)
+
+	// get ClusterPolicy
+	policy := &clusterV1alpha1.ClusterPolicy{}
+	if err := r.Client.Get(context.TODO(), req.NamespacedName, policy); errors.IsNotFound(err) {
+		log.Info("ClusterPolicy not found. Ignoring since object must be deleted")
+		pipeline.Forget(policy, req.NamespacedName)
+		return ctrl.Result{}, nil
+	} else if err != nil {
+		log.Error(err, "Failed to get ClusterPolicy")
+		return ctrl.Result{}, err
+	}
+
+	patchHelper, err := patch.NewHelper(policy, r.Client)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	defer func() {
+		r.reconcilePhase(context.TODO(), policy)
+
+		if err := patchHelper.Patch(context.TODO(), policy); err != nil {
+			reterr = err
+		}
+	}()
+
+	// Add finalizer first if not exist to avoid the race condition between init and delete
+	if !controllerutil.ContainsFinalizer(policy, clusterV1alpha1.ClusterPolicyFinalizer) {
+		controllerutil.AddFinalizer(policy, clusterV1alpha1.ClusterPolicyFinalizer)
+		return ctrl.Result{}, nil
+	}
+
+	// Handle deletion reconciliation loop.
+	if !policy.ObjectMeta.DeletionTimestamp.IsZero() {
+		return r.reconcileDelete(context.TODO(), policy)
+	}
+
+	// Handle normal reconciliation loop.
+	return r.reconcile(context.TODO(), policy)
+}
+
+// reconcile handles cluster policy reconciliation.
+func (r *ClusterPolicyReconciler) reconcile(ctx context.Context, policy *clusterV1alpha1.ClusterPolicy) (ctrl.Result, error) {
+	phases := []pipeline.Phase[*clusterV1alpha1.ClusterPolicy]{
+		// 더 이상 선택되지 않는 cluster 에 적용했던 policy 를 삭제한다.
+		{Name: "PruneUnselectedClusters", Run: r.PruneUnselectedClusters},
+		// 선택된 cluster 들에 policy 를 적용하고 준수 여부를 확인한다.
+		{Name: "EnforcePolicies", Run: r.EnforcePolicies},
+	}
+
+	return pipeline.NewRunner[*clusterV1alpha1.ClusterPolicy](r.Log, r.Recorder).Run(ctx, policy, phases)
+}
+
+func (r *ClusterPolicyReconciler) reconcileDelete(ctx context.Context, policy *clusterV1alpha1.ClusterPolicy) (ctrl.Result, error) {
+	log := r.Log.WithValues("clusterpolicy", policy.GetNamespacedName())
+	log.Info("Start to reconcile delete for ClusterPolicy")
+
+	// 모든 cluster 에 적용했던 policy 를 삭제한다.
+	remains := []clusterV1alpha1.ClusterPolicyClusterStatus{}
+	for _, clusterStatus := range policy.Status.Clusters {
+		if err := r.pruneCluster(policy, &clusterStatus); err != nil {
+			log.Error(err, "Failed to delete resources from cluster", "cluster", clusterStatus.Name)
+			remains = append(remains, clusterStatus)
+		}
+	}
+	policy.Status.Clusters = remains
+	if len(remains) > 0 {
+		return ctrl.Result{Requeue: true}, nil
+	}
+
+	controllerutil.RemoveFinalizer(policy, clusterV1alpha1.ClusterPolicyFinalizer)
+	log.Info("ClusterPolicy is removed successfully")
+	return ctrl.Result{}, nil
+}
+
+func (r *ClusterPolicyReconciler) reconcilePhase(_ context.Context, policy *clusterV1alpha1.ClusterPolicy) {
+	if !policy.DeletionTimestamp.IsZero() {
+		policy.Status.SetTypedPhase(clusterV1alpha1.ClusterPolicyPhaseDeleting)
+		return
+	}
+
+	if policy.Status.CompliantCount < policy.Status.ClusterCount {
+		policy.Status.SetTypedPhase(clusterV1alpha1.ClusterPolicyPhaseNonCompliant)
+		return
+	}
+	policy.Status.SetTypedPhase(clusterV1alpha1.ClusterPolicyPhaseCompliant)
+}
+
+func (r *ClusterPolicyReconciler) requeueClusterPolicysForClusterManager(o client.Object) []ctrl.Request {
+	clm := o.DeepCopyObject().(*clusterV1alpha1.ClusterManager)
+	log := r.Log.WithValues("ClusterPolicy-ObjectMapper", "clusterManagerToClusterPolicys", "ClusterManager", clm.GetNamespacedName())
+
+	policyList := &clusterV1alpha1.ClusterPolicyList{}
+	if err := r.Client.List(context.TODO(), policyList, client.InNamespace(clm.Namespace)); err != nil {
+		log.Error(err, "Failed to list ClusterPolicy")
+		return nil
+	}
+
+	// selector 까지 확인하지 않고, 같은 namespace 의 policy 를 모두 requeue 한다.
+	reqs := []ctrl.Request{}
+	for _, policy := range policyList.Items {
+		reqs = append(reqs, ctrl.Request{NamespacedName: policy.GetNamespacedName()})
+	}
+	return reqs
+}
+
+func (r *ClusterPolicyReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	controller, err := ctrl.NewControllerManagedBy(mgr).
+		For(&clusterV1alpha1.ClusterPolicy{}).
+		WithOptions(util.DefaultControllerOptions()).
+		WithEventFilter(util.SpecChangedPredicate()).
+		Build(util.ShardReconciler(mgr.GetClient(), r))
+
+	if err != nil {
+		return err
+	}
+
+	// cluster 의 label 이 변경되거나 control plane 이 준비되면, policy 를 다시 적용한다.
+	return controller.Watch(
+		&source.Kind{Type: &clusterV1alpha1.ClusterManager{}},
+		handler.EnqueueRequestsFromMapFunc(r.requeueClusterPolicysForClusterManager),
+		predicate.Funcs{
+			CreateFunc: func(e event.CreateEvent) bool {
+				return false
+			},
+			UpdateFunc: func(e event.UpdateEvent) bool {
+				oldClm := e.ObjectOld.(*clusterV1alpha1.ClusterManager)
+				newClm := e.ObjectNew.(*clusterV1alpha1.ClusterManager)
+				if !labels.Equals(oldClm.Labels, newClm.Labels) ||
+					oldClm.Status.ControlPlaneReady != newClm.Status.ControlPlaneReady {
+					return true
+				}
+				return false
+			},
+			DeleteFunc: func(e event.DeleteEvent) bool {
+				return true
+			},
+			GenericFunc: func(e event.GenericEvent) bool {
+				return false
+			},
+		},
+	)
+}
@@ -0,0 +1,440 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	clusterV1alpha1 "github.com/tmax-cloud/hypercloud-multi-operator/apis/cluster/v1alpha1"
+	util "github.com/tmax-cloud/hypercloud-multi-operator/controllers/util"
+
+	coreV1 "k8s.io/api/core/v1"
+	networkingV1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/version"
+
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// pod security admission 의 enforce level label
+	LabelKeyPodSecurityEnforce = "pod-security.kubernetes.io/enforce"
+)
+
+var (
+	podSecurityAdmissionMinVersion = version.MustParseGeneric("v1.23.0")
+	// 모든 namespace 에 level 을 적용하는 경우에도 control plane 의 component 가 실행되는 namespace 는 제외한다.
+	podSecuritySystemNamespaces = []string{util.KubeNamespace, "kube-public", "kube-node-lease"}
+)
+
+func (r *ClusterPolicyReconciler) PruneUnselectedClusters(ctx context.Context, policy *clusterV1alpha1.ClusterPolicy) (ctrl.Result, error) {
+	log := r.Log.WithValues("clusterpolicy", policy.GetNamespacedName())
+	log.Info("Start to reconcile phase for PruneUnselectedClusters")
+
+	clmList, err := r.selectClusters(policy)
+	if err != nil {
+		log.Error(err, "Failed to select ClusterManagers")
+		return ctrl.Result{}, err
+	}
+	selected := map[string]bool{}
+	for _, clm := range clmList {
+		selected[clm.Name] = true
+	}
+
+	clusters := []clusterV1alpha1.ClusterPolicyClusterStatus{}
+	for _, clusterStatus := range policy.Status.Clusters {
+		if selected[clusterStatus.Name] {
+			clusters = append(clusters, clusterStatus)
+			continue
+		}
+		if err := r.pruneCluster(policy, &clusterStatus); err != nil {
+			log.Error(err, "Failed to delete policy from unselected cluster", "cluster", clusterStatus.Name)
+			clusterStatus.Compliant = false
+			clusterStatus.Reason = "Failed to delete policy: " + err.Error()
+			clusters = append(clusters, clusterStatus)
+			continue
+		}
+		log.Info("Deleted policy from unselected cluster successfully", "cluster", clusterStatus.Name)
+	}
+	policy.Status.Clusters = clusters
+
+	return ctrl.Result{}, nil
+}
+
+func (r *ClusterPolicyReconciler) EnforcePolicies(ctx context.Context, policy *clusterV1alpha1.ClusterPolicy) (ctrl.Result, error) {
+	log := r.Log.WithValues("clusterpolicy", policy.GetNamespacedName())
+	log.Info("Start to reconcile phase for EnforcePolicies")
+
+	objs, err := buildPolicyResources(policy)
+	if err != nil {
+		log.Error(err, "Failed to build policy resources")
+		return ctrl.Result{}, err
+	}
+
+	clmList, err := r.selectClusters(policy)
+	if err != nil {
+		log.Error(err, "Failed to select ClusterManagers")
+		return ctrl.Result{}, err
+	}
+
+	// task 가 실행되는 동안 slice 가 다시 할당되지 않도록 모든 cluster 의 status 를 먼저 만든다.
+	for _, clm := range clmList {
+		if policy.Status.GetClusterStatus(clm.Name) == nil {
+			policy.Status.Clusters = append(policy.Status.Clusters, clusterV1alpha1.ClusterPolicyClusterStatus{Name: clm.Name})
+		}
+	}
+
+	tasks := []util.FanOutTask{}
+	for i := range clmList {
+		clm := &clmList[i]
+		clusterStatus := policy.Status.GetClusterStatus(clm.Name)
+
+		if !clm.Status.ControlPlaneReady {
+			clusterStatus.Compliant = false
+			clusterStatus.Reason = "Wait for control plane to be ready"
+			// control plane 이 준비되면 바로 다시 적용하도록 위반 개수를 초기화한다.
+			clusterStatus.Violations = 0
+			clusterStatus.LastCheckedTime = metav1.Now()
+			continue
+		}
+		if !isPolicyEnforceDue(policy, clusterStatus) {
+			continue
+		}
+
+		tasks = append(tasks, util.FanOutTask{
+			Key:      clm.Name,
+			Priority: clm.GetFanOutPriority(),
+			Run: func(ctx context.Context) error {
+				return r.enforceCluster(policy, clm, objs, clusterStatus)
+			},
+		})
+	}
+
+	results, remaining := util.GetFanOutPool().Run(ctx, tasks)
+	for name, err := range results {
+		clusterStatus := policy.Status.GetClusterStatus(name)
+		clusterStatus.ObservedGeneration = policy.Generation
+		clusterStatus.LastCheckedTime = metav1.Now()
+		if err != nil {
+			log.Error(err, "Failed to enforce policy to cluster", "cluster", name)
+			clusterStatus.Compliant = false
+			clusterStatus.Reason = err.Error()
+			continue
+		}
+		if clusterStatus.Violations > 0 {
+			clusterStatus.Compliant = false
+			clusterStatus.Reason = fmt.Sprintf("%d constraint violations found", clusterStatus.Violations)
+			continue
+		}
+		clusterStatus.Compliant = true
+		clusterStatus.Reason = ""
+	}
+
+	sort.Slice(policy.Status.Clusters, func(i, j int) bool {
+		return policy.Status.Clusters[i].Name < policy.Status.Clusters[j].Name
+	})
+	policy.Status.ClusterCount = len(policy.Status.Clusters)
+	policy.Status.CompliantCount = 0
+	for _, clusterStatus := range policy.Status.Clusters {
+		if clusterStatus.Compliant {
+			policy.Status.CompliantCount++
+		}
+	}
+	policy.Status.ObservedGeneration = policy.Generation
+
+	updated, failed := 0, 0
+	for _, clm := range clmList {
+		clusterStatus := policy.Status.GetClusterStatus(clm.Name)
+		if clusterStatus.ObservedGeneration != policy.Generation || !clm.Status.ControlPlaneReady {
+			continue
+		}
+		if isPolicyEnforceFailed(clusterStatus) {
+			failed++
+		} else {
+			updated++
+		}
+	}
+	policy.Status.Progress = newFanOutProgress(policy.Status.Progress, policy.Generation, len(clmList), updated, failed, len(results) > 0)
+
+	if remaining > 0 {
+		log.Info("Wait for the next batch", "remaining", remaining)
+		return ctrl.Result{RequeueAfter: util.FanOutBatchInterval}, nil
+	}
+
+	// member cluster 에서 policy 가 변경되거나 삭제되는 경우를 대비해 주기적으로 다시 적용하고 준수 여부를 확인한다.
+	return util.RequeueAfterWithJitter(getResyncPeriod()), nil
+}
+
+// 현재 generation 을 적용하지 않았거나, 적용에 실패했거나, 마지막 확인 후 resync 주기가 지난 cluster 에 policy 를 다시 적용한다.
+// constraint 위반은 gatekeeper 의 audit 주기에 따라 바뀌므로 resync 주기에 다시 확인한다.
+func isPolicyEnforceDue(policy *clusterV1alpha1.ClusterPolicy, clusterStatus *clusterV1alpha1.ClusterPolicyClusterStatus) bool {
+	return clusterStatus.ObservedGeneration != policy.Generation ||
+		isPolicyEnforceFailed(clusterStatus) ||
+		time.Since(clusterStatus.LastCheckedTime.Time) >= getResyncPeriod()
+}
+
+// constraint 위반이 아닌 이유로 준수하지 않는 상태
+func isPolicyEnforceFailed(clusterStatus *clusterV1alpha1.ClusterPolicyClusterStatus) bool {
+	return !clusterStatus.Compliant && clusterStatus.Violations == 0
+}
+
+// network policy 와 constraint 를 remote cluster 에 적용할 unstructured 리소스로 변환한다.
+func buildPolicyResources(policy *clusterV1alpha1.ClusterPolicy) ([]*unstructured.Unstructured, error) {
+	objs := []*unstructured.Unstructured{}
+	for _, np := range policy.Spec.NetworkPolicies {
+		networkPolicy := &networkingV1.NetworkPolicy{
+			TypeMeta: metav1.TypeMeta{
+				APIVersion: networkingV1.SchemeGroupVersion.String(),
+				Kind:       "NetworkPolicy",
+			},
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      np.Name,
+				Namespace: np.Namespace,
+			},
+			Spec: np.Spec,
+		}
+		obj, err := convertToUnstructured(networkPolicy)
+		if err != nil {
+			return nil, err
+		}
+		objs = append(objs, obj)
+	}
+
+	for i, constraint := range policy.Spec.Constraints {
+		obj := &unstructured.Unstructured{}
+		if err := obj.UnmarshalJSON(constraint.Raw); err != nil {
+			return nil, fmt.Errorf("failed to decode constraint %d: %w", i, err)
+		}
+		objs = append(objs, obj)
+	}
+
+	for _, obj := range objs {
+		labels := obj.GetLabels()
+		if labels == nil {
+			labels = map[string]string{}
+		}
+		labels[clusterV1alpha1.LabelKeyClusterPolicy] = policy.Name
+		labels[clusterV1alpha1.LabelKeyClusterPolicyNamespace] = policy.Namespace
+		obj.SetLabels(labels)
+		util.SetRemoteOwnerLabels(obj, util.RemoteOwner{
+			Kind:      util.RemoteOwnerKindClusterPolicy,
+			Namespace: policy.Namespace,
+			Name:      policy.Name,
+		})
+	}
+	return objs, nil
+}
+
+// remote cluster 에 policy 를 적용하고, constraint 의 위반 개수를 확인한다.
+func (r *ClusterPolicyReconciler) enforceCluster(policy *clusterV1alpha1.ClusterPolicy, clm *clusterV1alpha1.ClusterManager,
+	objs []*unstructured.Unstructured, clusterStatus *clusterV1alpha1.ClusterPolicyClusterStatus) error {
+	remoteClient, err := getRemoteRuntimeClient(r.Client, clm)
+	if err != nil {
+		return err
+	}
+
+	applied, err := applyManifestResources(remoteClient, objs, clusterStatus.Resources)
+	if err != nil {
+		return err
+	}
+	clusterStatus.Resources = applied
+
+	// pod security admission label 적용
+	levels, err := resolvePodSecurityLevels(remoteClient, clm, policy.Spec.PodSecurity)
+	if err != nil {
+		return err
+	}
+	namespaces := []string{}
+	for namespace, level := range levels {
+		if err := setPodSecurityLabel(remoteClient, namespace, string(level)); err != nil {
+			return err
+		}
+		namespaces = append(namespaces, namespace)
+	}
+	for _, namespace := range clusterStatus.Namespaces {
+		if _, ok := levels[namespace]; ok {
+			continue
+		}
+		if err := setPodSecurityLabel(remoteClient, namespace, ""); err != nil {
+			return err
+		}
+	}
+	sort.Strings(namespaces)
+	clusterStatus.Namespaces = namespaces
+
+	// gatekeeper 가 audit 결과를 constraint 의 status.totalViolations 에 기록한다.
+	clusterStatus.Violations = 0
+	for _, obj := range objs {
+		if obj.GetKind() == "NetworkPolicy" {
+			continue
+		}
+		constraint := &unstructured.Unstructured{}
+		constraint.SetGroupVersionKind(obj.GroupVersionKind())
+		key := types.NamespacedName{
+			Name:      obj.GetName(),
+			Namespace: obj.GetNamespace(),
+		}
+		if err := remoteClient.Get(context.TODO(), key, constraint); err != nil {
+			return util.ClassifyRemoteError(err)
+		}
+		violations, _, err := unstructured.NestedInt64(constraint.Object, "status", "totalViolations")
+		if err != nil {
+			continue
+		}
+		clusterStatus.Violations += violations
+	}
+	return nil
+}
+
+// namespace 별로 적용할 pod security admission level 을 구한다.
+// namespaces 를 지정하지 않은 level 은 system namespace 를 제외한 모든 namespace 에 적용하고,
+// namespaces 를 지정한 level 이 우선한다.
+func resolvePodSecurityLevels(remoteClient client.Client, clm *clusterV1alpha1.ClusterManager, podSecurities []clusterV1alpha1.PodSecurity) (map[string]clusterV1alpha1.PodSecurityLevel, error) {
+	levels := map[string]clusterV1alpha1.PodSecurityLevel{}
+	if len(podSecurities) == 0 {
+		return levels, nil
+	}
+
+	// pod security admission 은 v1.23 부터 기본으로 활성화된다.
+	if v, err := version.ParseGeneric(clm.Status.GetK8SVersion()); err == nil && !v.AtLeast(podSecurityAdmissionMinVersion) {
+		return nil, fmt.Errorf("pod security admission requires kubernetes %s or later", podSecurityAdmissionMinVersion)
+	}
+
+	var namespaceList *coreV1.NamespaceList
+	for _, podSecurity := range podSecurities {
+		if len(podSecurity.Namespaces) != 0 {
+			continue
+		}
+		if namespaceList == nil {
+			namespaceList = &coreV1.NamespaceList{}
+			if err := remoteClient.List(context.TODO(), namespaceList); err != nil {
+				return nil, util.ClassifyRemoteError(err)
+			}
+		}
+		excluded := map[string]bool{}
+		for _, namespace := range podSecuritySystemNamespaces {
+			excluded[namespace] = true
+		}
+		for _, namespace := range podSecurity.ExcludeNamespaces {
+			excluded[namespace] = true
+		}
+		for _, ns := range namespaceList.Items {
+			if !excluded[ns.Name] && ns.DeletionTimestamp.IsZero() {
+				levels[ns.Name] = podSecurity.Enforce
+			}
+		}
+	}
+	for _, podSecurity := range podSecurities {
+		for _, namespace := range podSecurity.Namespaces {
+			levels[namespace] = podSecurity.Enforce
+		}
+	}
+	return levels, nil
+}
+
+// namespace 에 pod security admission label 을 설정한다. level 이 비어있는 경우 label 을 삭제한다.
+func setPodSecurityLabel(remoteClient client.Client, namespace string, level string) error {
+	ns := &coreV1.Namespace{}
+	if err := remoteClient.Get(context.TODO(), types.NamespacedName{Name: namespace}, ns); errors.IsNotFound(err) && level == "" {
+		return nil
+	} else if err != nil {
+		return util.ClassifyRemoteError(err)
+	}
+
+	if ns.Labels[LabelKeyPodSecurityEnforce] == level {
+		return nil
+	}
+	base := ns.DeepCopy()
+	if level == "" {
+		delete(ns.Labels, LabelKeyPodSecurityEnforce)
+	} else {
+		if ns.Labels == nil {
+			ns.Labels = map[string]string{}
+		}
+		ns.Labels[LabelKeyPodSecurityEnforce] = level
+	}
+	if err := remoteClient.Patch(context.TODO(), ns, client.MergeFrom(base)); err != nil {
+		return util.ClassifyRemoteError(err)
+	}
+	return nil
+}
+
+// cluster 에 적용했던 policy 를 삭제한다. cluster 가 이미 삭제된 경우에는 건너뛴다.
+func (r *ClusterPolicyReconciler) pruneCluster(policy *clusterV1alpha1.ClusterPolicy, clusterStatus *clusterV1alpha1.ClusterPolicyClusterStatus) error {
+	if len(clusterStatus.Resources) == 0 && len(clusterStatus.Namespaces) == 0 {
+		return nil
+	}
+
+	key := types.NamespacedName{
+		Name:      clusterStatus.Name,
+		Namespace: policy.Namespace,
+	}
+	clm := &clusterV1alpha1.ClusterManager{}
+	if err := r.Client.Get(context.TODO(), key, clm); errors.IsNotFound(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	remoteClient, err := getRemoteRuntimeClient(r.Client, clm)
+	if errors.IsNotFound(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	if err := deleteManifestResources(remoteClient, clusterStatus.Resources); err != nil {
+		return err
+	}
+	for _, namespace := range clusterStatus.Namespaces {
+		if err := setPodSecurityLabel(remoteClient, namespace, ""); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// spec 의 cluster selector 에 해당하는 cluster manager 목록
+func (r *ClusterPolicyReconciler) selectClusters(policy *clusterV1alpha1.ClusterPolicy) ([]clusterV1alpha1.ClusterManager, error) {
+	selector, err := policy.GetClusterSelector()
+	if err != nil {
+		return nil, err
+	}
+	clmList := &clusterV1alpha1.ClusterManagerList{}
+	opts := []client.ListOption{
+		client.InNamespace(policy.Namespace),
+		client.MatchingLabelsSelector{Selector: selector},
+	}
+	if err := r.Client.List(context.TODO(), clmList, opts...); err != nil {
+		return nil, err
+	}
+
+	result := []clusterV1alpha1.ClusterManager{}
+	for _, clm := range clmList.Items {
+		// 삭제중인 cluster 에는 적용하지 않는다.
+		if !clm.DeletionTimestamp.IsZero() {
+			continue
+		}
+		result = append(result, clm)
+	}
+	return result, nil
+}
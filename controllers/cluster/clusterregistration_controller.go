@@ -16,15 +16,19 @@ package controllers
 
 import (
 	"context"
+	goerrors "errors"
+	"reflect"
+	"time"
 
 	"github.com/go-logr/logr"
 	clusterV1alpha1 "github.com/tmax-cloud/hypercloud-multi-operator/apis/cluster/v1alpha1"
-	util "github.com/tmax-cloud/hypercloud-multi-operator/controllers/util"
+	"github.com/tmax-cloud/hypercloud-multi-operator/controllers/metrics"
+	"github.com/tmax-cloud/hypercloud-multi-operator/controllers/pipeline"
+	"github.com/tmax-cloud/hypercloud-multi-operator/controllers/util"
 
 	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/runtime"
-	"k8s.io/apimachinery/pkg/types"
-	kerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/client-go/tools/record"
 
 	"sigs.k8s.io/cluster-api/util/patch"
 	ctrl "sigs.k8s.io/controller-runtime"
@@ -38,8 +42,15 @@ import (
 // ClusterRegistrationReconciler reconciles a ClusterRegistration object
 type ClusterRegistrationReconciler struct {
 	client.Client
-	Log    logr.Logger
-	Scheme *runtime.Scheme
+	Log      logr.Logger
+	Scheme   *runtime.Scheme
+	Recorder record.EventRecorder
+	// member cluster 의 clientset 을 생성한다. nil 이면 util.DefaultRemoteClientFactory 를 사용한다.
+	RemoteClients util.RemoteClientFactory
+}
+
+func (r *ClusterRegistrationReconciler) remoteClients() util.RemoteClientFactory {
+	return util.RemoteClientFactoryOrDefault(r.RemoteClients)
 }
 
 // +kubebuilder:rbac:groups=cluster.tmax.io,resources=clusterregistrations,verbs=create;delete;get;list;patch;update;watch
@@ -53,6 +64,7 @@ func (r *ClusterRegistrationReconciler) Reconcile(ctx context.Context, req ctrl.
 	clusterRegistration := &clusterV1alpha1.ClusterRegistration{}
 	if err := r.Client.Get(context.TODO(), req.NamespacedName, clusterRegistration); errors.IsNotFound(err) {
 		log.Info("ClusterRegistration not found. Ignoring since object must be deleted")
+		pipeline.Forget(clusterRegistration, req.NamespacedName)
 		return ctrl.Result{}, nil
 	} else if err != nil {
 		log.Error(err, "Failed to get ClusterRegistration")
@@ -64,9 +76,11 @@ func (r *ClusterRegistrationReconciler) Reconcile(ctx context.Context, req ctrl.
 		return ctrl.Result{}, err
 	}
 
+	oldPhase := string(clusterRegistration.Status.Phase)
 	defer func() {
 		// Always reconcile the Status.Phase field.
 		r.reconcilePhase(context.TODO(), clusterRegistration)
+		metrics.RecordPhaseTransition("ClusterRegistration", oldPhase, string(clusterRegistration.Status.Phase))
 
 		if err := patchHelper.Patch(context.TODO(), clusterRegistration); err != nil {
 			// if err := patchClusterRegistration(context.TODO(), patchHelper, ClusterRegistration, patchOpts...); err != nil {
@@ -81,34 +95,90 @@ func (r *ClusterRegistrationReconciler) Reconcile(ctx context.Context, req ctrl.
 
 // reconcile handles cluster reconciliation.
 func (r *ClusterRegistrationReconciler) reconcile(ctx context.Context, ClusterRegistration *clusterV1alpha1.ClusterRegistration) (ctrl.Result, error) {
-	phases := []func(context.Context, *clusterV1alpha1.ClusterRegistration) (ctrl.Result, error){
+	log := r.Log.WithValues("ClusterRegistration", ClusterRegistration.GetNamespacedName())
+
+	// kubeconfig 는 여기서 한 번만 decode, parse 하고 모든 phase 가 context 로 공유한다.
+	var kubeconfig *util.Kubeconfig
+	var err error
+	if isManagedClusterRegistration(ClusterRegistration) {
+		// EKS, GKE, AKS cluster 는 cloud api 로 cluster 정보를 조회하여 kubeconfig 를 생성한다.
+		var raw []byte
+		raw, err = r.newManagedClusterKubeconfig(ctx, ClusterRegistration)
+		if goerrors.Is(err, util.ErrRemoteUnreachable) {
+			log.Error(err, "Failed to get managed cluster from cloud api")
+			return ctrl.Result{}, err
+		} else if err != nil {
+			log.Info("Managed cluster is invalid", "cluster", ClusterRegistration.Spec.ClusterName, "reason", err.Error())
+			ClusterRegistration.Status.ClusterValidated = false
+			ClusterRegistration.Status.SetTypedPhase(clusterV1alpha1.ClusterRegistrationPhaseError)
+			ClusterRegistration.Status.SetTypedReason(clusterRegistrationReasonForError(err))
+			return ctrl.Result{}, nil
+		}
+		kubeconfig, err = util.ParseKubeconfig(raw)
+		if err == nil {
+			kubeconfig.Auth = managedClusterAuth(ClusterRegistration)
+		}
+	} else if ClusterRegistration.Spec.KubeConfigSecret != "" {
+		// SealedSecret 으로 제출한 경우 sealed-secrets controller 가 secret 을 생성할 때까지 기다린다.
+		var raw []byte
+		raw, err = r.getKubeconfigFromSecret(ClusterRegistration)
+		if errors.IsNotFound(err) {
+			log.Info("Wait for kubeconfig secret to be created", "secret", ClusterRegistration.Spec.KubeConfigSecret)
+			return util.RequeueAfterWithJitter(10 * time.Second), nil
+		} else if err == nil {
+			kubeconfig, err = util.ParseKubeconfig(raw)
+		}
+	} else if ClusterRegistration.Spec.EncryptedKubeConfig != "" {
+		var raw []byte
+		raw, err = util.DecryptKubeconfig(ctx, r.Client, ClusterRegistration.Namespace, ClusterRegistration.Spec.EncryptedKubeConfig)
+		if goerrors.Is(err, util.ErrRemoteUnreachable) {
+			// tenant key 의 kms, vault 에 접근할 수 없는 경우 다시 시도한다.
+			log.Error(err, "Failed to decrypt kubeconfig with tenant key")
+			return ctrl.Result{}, err
+		} else if err == nil {
+			kubeconfig, err = util.ParseKubeconfig(raw)
+		}
+	} else {
+		kubeconfig, err = util.ParseEncodedKubeconfig(ClusterRegistration.Spec.KubeConfig)
+	}
+	if err != nil {
+		log.Error(err, "Failed to parse ClusterRegistration.Spec.KubeConfig, maybe wrong kubeconfig file")
+		ClusterRegistration.Status.ClusterValidated = false
+		ClusterRegistration.Status.SetTypedPhase(clusterV1alpha1.ClusterRegistrationPhaseError)
+		ClusterRegistration.Status.SetTypedReason(clusterV1alpha1.ClusterRegistrationReasonInvalidKubeconfig)
+		return ctrl.Result{}, nil
+	}
+	ctx = util.WithKubeconfig(ctx, kubeconfig)
+
+	phases := []pipeline.Phase[*clusterV1alpha1.ClusterRegistration]{
 		// cluster 등록전, validation 을 체크하는 과정으로
 		// single cluster 의 kube-config 가 올바른지 체크하기 위해, kube-config 를 사용해 node 들을 가져올수있는지 확인한다.
 		// 또한, 중복성 체크를 위해 해당 name 과 namespace 를 가지는 cluster manager 가 이미 있는지 확인한다.
-		r.CheckValidation,
+		{
+			Name:         "CheckValidation",
+			Run:          r.CheckValidation,
+			When:         needsValidation,
+			SetCondition: setValidatedCondition,
+		},
 		// 해당 cluster 에 대한 cluster manager 를 생성한다.
-		r.CreateClusterManager,
+		{
+			Name:          "CreateClusterManager",
+			Run:           r.CreateClusterManager,
+			When:          isRegistered,
+			DependsOn:     []string{"CheckValidation"},
+			ConditionType: clusterV1alpha1.ClusterRegistrationConditionClusterManagerCreated,
+		},
 		// kube-config 를 secret 으로 생성한다.
-		r.CreateKubeconfigSecret,
-	}
-
-	res := ctrl.Result{}
-	errs := []error{}
-	for _, phase := range phases {
-		// Call the inner reconciliation methods.
-		phaseResult, err := phase(ctx, ClusterRegistration)
-		if err != nil {
-			errs = append(errs, err)
-		}
-		if len(errs) > 0 {
-			continue
-		}
-
-		// Aggregate phases which requeued without err
-		res = util.LowestNonZeroResult(res, phaseResult)
+		{
+			Name:          "CreateKubeconfigSecret",
+			Run:           r.CreateKubeconfigSecret,
+			When:          needsKubeconfigSecret,
+			DependsOn:     []string{"CheckValidation"},
+			ConditionType: clusterV1alpha1.ClusterRegistrationConditionKubeconfigSecretCreated,
+		},
 	}
 
-	return res, kerrors.NewAggregate(errs)
+	return pipeline.NewRunner[*clusterV1alpha1.ClusterRegistration](r.Log, r.Recorder).Run(ctx, ClusterRegistration, phases)
 }
 
 func (r *ClusterRegistrationReconciler) reconcilePhase(_ context.Context, ClusterRegistration *clusterV1alpha1.ClusterRegistration) {
@@ -121,30 +191,28 @@ func (r *ClusterRegistrationReconciler) requeueClusterRegistrationsForClusterMan
 	clm := o.DeepCopyObject().(*clusterV1alpha1.ClusterManager)
 	log := r.Log.WithValues("ClusterRegistration-ObjectMapper", "clusterManagerToClusterClusterRegistrations", "ClusterRegistration", clm.Name)
 
-	//get clusterRegistration
-	key := types.NamespacedName{
-		Name:      clm.Labels[clusterV1alpha1.LabelKeyClrName],
-		Namespace: clm.Namespace,
-	}
-	clr := &clusterV1alpha1.ClusterRegistration{}
-	if err := r.Client.Get(context.TODO(), key, clr); errors.IsNotFound(err) {
-		log.Info("ClusterRegistration resource not found. Ignoring since object must be deleted")
-		return nil
-	} else if err != nil {
-		log.Error(err, "Failed to get ClusterRegistration")
+	// clusterRegistration 의 이름이 아닌 spec.clusterName 으로 조회한다.
+	clrs := &clusterV1alpha1.ClusterRegistrationList{}
+	if err := r.Client.List(context.TODO(), clrs,
+		client.InNamespace(clm.Namespace),
+		client.MatchingFields{util.IndexKeyClusterName: clm.Name},
+	); err != nil {
+		log.Error(err, "Failed to list ClusterRegistrations")
 		return nil
 	}
 
-	if clr.Status.Phase != clusterV1alpha1.ClusterRegistrationPhaseRegistered {
-		log.Info("ClusterRegistration for ClusterManager [" + clr.Spec.ClusterName + "] is already delete... Do not update cluster registration status to delete ")
-		return nil
-	}
+	for i := range clrs.Items {
+		clr := &clrs.Items[i]
+		if clr.Status.Phase != clusterV1alpha1.ClusterRegistrationPhaseRegistered {
+			log.Info("ClusterRegistration for ClusterManager is already delete... Do not update cluster registration status to delete", "clusterManager", clr.Spec.ClusterName)
+			continue
+		}
 
-	clr.Status.Phase = clusterV1alpha1.ClusterRegistrationPhaseClusterDeleted
-	clr.Status.Reason = "cluster is deleted"
-	if err := r.Status().Update(context.TODO(), clr); err != nil {
-		log.Error(err, "Failed to update ClusterRegistration status")
-		return nil //??
+		clr.Status.Phase = clusterV1alpha1.ClusterRegistrationPhaseClusterDeleted
+		clr.Status.Reason = "cluster is deleted"
+		if err := r.Status().Update(context.TODO(), clr); err != nil {
+			log.Error(err, "Failed to update ClusterRegistration status", "clusterRegistration", clr.Name)
+		}
 	}
 	return nil
 }
@@ -152,6 +220,7 @@ func (r *ClusterRegistrationReconciler) requeueClusterRegistrationsForClusterMan
 func (r *ClusterRegistrationReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	controller, err := ctrl.NewControllerManagedBy(mgr).
 		For(&clusterV1alpha1.ClusterRegistration{}).
+		WithOptions(util.DefaultControllerOptions()).
 		WithEventFilter(
 			predicate.Funcs{
 				CreateFunc: func(e event.CreateEvent) bool {
@@ -169,7 +238,7 @@ func (r *ClusterRegistrationReconciler) SetupWithManager(mgr ctrl.Manager) error
 
 					isDeleted := oldClr.DeletionTimestamp.IsZero() && !newClr.DeletionTimestamp.IsZero()
 					fail := oldClr.Status.Phase == clusterV1alpha1.ClusterRegistrationPhaseError
-					kubeconfigUpdate := oldClr.Spec.KubeConfig != newClr.Spec.KubeConfig
+					kubeconfigUpdate := !reflect.DeepEqual(oldClr.Spec, newClr.Spec)
 
 					// 실패한 clr의 kubeconfig를 재 업데이트한 경우
 					errorUpdate := fail && kubeconfigUpdate
@@ -191,7 +260,7 @@ func (r *ClusterRegistrationReconciler) SetupWithManager(mgr ctrl.Manager) error
 				},
 			},
 		).
-		Build(r)
+		Build(util.ShardReconciler(mgr.GetClient(), r))
 
 	if err != nil {
 		return err
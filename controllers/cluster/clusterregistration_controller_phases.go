@@ -16,42 +16,59 @@ package controllers
 
 import (
 	"context"
-	b64 "encoding/base64"
 	"fmt"
 	"os"
-	"regexp"
 
 	clusterV1alpha1 "github.com/tmax-cloud/hypercloud-multi-operator/apis/cluster/v1alpha1"
+	"github.com/tmax-cloud/hypercloud-multi-operator/controllers/pipeline"
 	util "github.com/tmax-cloud/hypercloud-multi-operator/controllers/util"
 
 	coreV1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
-	"k8s.io/client-go/tools/clientcmd"
 
 	ctrl "sigs.k8s.io/controller-runtime"
 )
 
-func (r *ClusterRegistrationReconciler) CheckValidation(ctx context.Context, ClusterRegistration *clusterV1alpha1.ClusterRegistration) (ctrl.Result, error) {
-	if ClusterRegistration.Status.Phase != "" {
-		return ctrl.Result{}, nil
+// 아직 처리되지 않은 ClusterRegistration 만 검사한다.
+func needsValidation(ClusterRegistration *clusterV1alpha1.ClusterRegistration) bool {
+	return ClusterRegistration.Status.Phase == ""
+}
+
+// 검사 결과를 Validated condition 으로 기록한다.
+func setValidatedCondition(ClusterRegistration *clusterV1alpha1.ClusterRegistration, err error) {
+	condition := metav1.Condition{
+		Type:               clusterV1alpha1.ClusterRegistrationConditionValidated,
+		Status:             metav1.ConditionTrue,
+		Reason:             pipeline.ReasonSucceeded,
+		ObservedGeneration: ClusterRegistration.Generation,
 	}
+	if err != nil {
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = pipeline.ReasonFailed
+		condition.Message = err.Error()
+	} else if !ClusterRegistration.Status.ClusterValidated {
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = pipeline.ReasonFailed
+		condition.Message = string(ClusterRegistration.Status.Reason)
+	}
+	meta.SetStatusCondition(&ClusterRegistration.Status.Conditions, condition)
+}
+
+func (r *ClusterRegistrationReconciler) CheckValidation(ctx context.Context, ClusterRegistration *clusterV1alpha1.ClusterRegistration) (ctrl.Result, error) {
 	log := r.Log.WithValues("ClusterRegistration", ClusterRegistration.GetNamespacedName())
 	log.Info("Start to reconcile phase for CheckValidation")
 
 	ClusterRegistration.Status.ClusterValidated = false
-	// decode base64 encoded kubeconfig file
-	encodedKubeConfig, err := b64.StdEncoding.DecodeString(ClusterRegistration.Spec.KubeConfig)
+	kubeconfig, err := util.KubeconfigFrom(ctx)
 	if err != nil {
-		log.Error(err, "Failed to decode ClusterRegistration.Spec.KubeConfig, maybe wrong kubeconfig file")
-		ClusterRegistration.Status.SetTypedPhase(clusterV1alpha1.ClusterRegistrationPhaseError)
-		ClusterRegistration.Status.SetTypedReason(clusterV1alpha1.ClusterRegistrationReasonInvalidKubeconfig)
-		return ctrl.Result{}, nil
+		return ctrl.Result{}, err
 	}
 
 	// validate remote cluster
-	remoteClientset, err := util.GetRemoteK8sClientByKubeConfig(encodedKubeConfig)
+	remoteClientset, err := r.remoteClients().ClientsetForKubeconfig(kubeconfig)
 	if err != nil {
 		log.Error(err, "Failed to get client for remote cluster")
 		ClusterRegistration.Status.SetTypedPhase(clusterV1alpha1.ClusterRegistrationPhaseError)
@@ -59,10 +76,10 @@ func (r *ClusterRegistrationReconciler) CheckValidation(ctx context.Context, Clu
 		return ctrl.Result{}, nil
 	}
 
-	if !util.IsClusterHealthy(remoteClientset) {
-		log.Info("Cluster[" + ClusterRegistration.Spec.ClusterName + "] is invalid")
+	if err := util.CheckClusterHealth(remoteClientset); err != nil {
+		log.Info("Cluster is invalid", "cluster", ClusterRegistration.Spec.ClusterName, "reason", err.Error())
 		ClusterRegistration.Status.SetTypedPhase(clusterV1alpha1.ClusterRegistrationPhaseError)
-		ClusterRegistration.Status.SetTypedReason(clusterV1alpha1.ClusterRegistrationReasonClusterNotFound)
+		ClusterRegistration.Status.SetTypedReason(clusterRegistrationReasonForError(err))
 		return ctrl.Result{}, nil
 	}
 
@@ -75,9 +92,10 @@ func (r *ClusterRegistrationReconciler) CheckValidation(ctx context.Context, Clu
 		log.Error(err, "Failed to get clusterManager")
 		return ctrl.Result{}, err
 	} else if err == nil {
-		log.Info("ClusterManager is already existed")
+		err := util.NewError(util.ErrDuplicateCluster, fmt.Errorf("ClusterManager [%s] is already existed", key.Name))
+		log.Info(err.Error())
 		ClusterRegistration.Status.SetTypedPhase(clusterV1alpha1.ClusterRegistrationPhaseError)
-		ClusterRegistration.Status.SetTypedReason(clusterV1alpha1.ClusterRegistrationReasonClusterNameDuplicated)
+		ClusterRegistration.Status.SetTypedReason(clusterRegistrationReasonForError(err))
 		return ctrl.Result{}, nil
 	}
 
@@ -86,10 +104,12 @@ func (r *ClusterRegistrationReconciler) CheckValidation(ctx context.Context, Clu
 	return ctrl.Result{}, nil
 }
 
+// 검사를 통과했지만 아직 kubeconfig secret 이 생성되지 않은 경우에만 생성한다.
+func needsKubeconfigSecret(ClusterRegistration *clusterV1alpha1.ClusterRegistration) bool {
+	return ClusterRegistration.Status.ClusterValidated && !ClusterRegistration.Status.SecretReady
+}
+
 func (r *ClusterRegistrationReconciler) CreateKubeconfigSecret(ctx context.Context, ClusterRegistration *clusterV1alpha1.ClusterRegistration) (ctrl.Result, error) {
-	if !ClusterRegistration.Status.ClusterValidated || ClusterRegistration.Status.SecretReady {
-		return ctrl.Result{}, nil
-	}
 	log := r.Log.WithValues("ClusterRegistration", ClusterRegistration.GetNamespacedName())
 	log.Info("Start to reconcile phase for CreateKubeconfigSecret")
 
@@ -106,18 +126,19 @@ func (r *ClusterRegistrationReconciler) CreateKubeconfigSecret(ctx context.Conte
 		return ctrl.Result{}, err
 	}
 
-	decodedKubeConfig, _ := b64.StdEncoding.DecodeString(ClusterRegistration.Spec.KubeConfig)
-	kubeConfig, err := clientcmd.Load(decodedKubeConfig)
+	kubeconfig, err := util.KubeconfigFrom(ctx)
 	if err != nil {
-		log.Error(err, "Failed to get secret")
 		return ctrl.Result{}, err
 	}
 
-	serverURI := kubeConfig.Clusters[kubeConfig.Contexts[kubeConfig.CurrentContext].Cluster].Server
-	argoSecretName, err := util.URIToSecretName("cluster", serverURI)
-	if err != nil {
-		log.Error(err, "Failed to parse server uri")
-		return ctrl.Result{}, err
+	// argocd 에서 import 한 cluster 는 기존 argocd cluster secret 을 사용한다.
+	argoSecretName, ok := ClusterRegistration.Annotations[util.AnnotationKeyArgoClusterSecret]
+	if !ok {
+		argoSecretName, err = util.URIToSecretName("cluster", kubeconfig.Server)
+		if err != nil {
+			log.Error(err, "Failed to parse server uri")
+			return ctrl.Result{}, err
+		}
 	}
 
 	kubeconfigSecretName := ClusterRegistration.Spec.ClusterName + util.KubeconfigSuffix
@@ -144,9 +165,15 @@ func (r *ClusterRegistrationReconciler) CreateKubeconfigSecret(ctx context.Conte
 				},
 			},
 			StringData: map[string]string{
-				"value": string(decodedKubeConfig),
+				"value": string(kubeconfig.Raw),
 			},
 		}
+		// operator 가 생성한 managed cluster 의 kubeconfig 에만 인증 정보를 기록한다.
+		if kubeconfig.Auth != nil {
+			for k, v := range kubeconfig.Auth.Annotations() {
+				kubeconfigSecret.Annotations[k] = v
+			}
+		}
 		if err = r.Create(context.TODO(), kubeconfigSecret); err != nil {
 			log.Error(err, "Failed to create kubeconfig Secret")
 			return ctrl.Result{}, err
@@ -164,15 +191,22 @@ func (r *ClusterRegistrationReconciler) CreateKubeconfigSecret(ctx context.Conte
 	return ctrl.Result{}, nil
 }
 
+// 등록된 cluster 에 대해서만 cluster manager 를 생성한다.
+func isRegistered(clusterRegistration *clusterV1alpha1.ClusterRegistration) bool {
+	return clusterRegistration.Status.Phase == clusterV1alpha1.ClusterRegistrationPhaseRegistered
+}
+
 func (r *ClusterRegistrationReconciler) CreateClusterManager(ctx context.Context, clusterRegistration *clusterV1alpha1.ClusterRegistration) (ctrl.Result, error) {
-	if clusterRegistration.Status.Phase != clusterV1alpha1.ClusterRegistrationPhaseRegistered {
-		return ctrl.Result{}, nil
-	}
 	log := r.Log.WithValues("ClusterRegistration", clusterRegistration.GetNamespacedName())
 	log.Info("Start to reconcile phase for CreateClusterManager")
 
-	endpoint, err := GetRegWorkloadClusterEndpoint(clusterRegistration.Spec.KubeConfig)
+	kubeconfig, err := util.KubeconfigFrom(ctx)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	endpoint, err := kubeconfig.Host()
 	if err != nil {
+		log.Error(err, "Failed to get endpoint from kubeconfig")
 		return ctrl.Result{}, err
 	}
 
@@ -185,15 +219,46 @@ func (r *ClusterRegistrationReconciler) CreateClusterManager(ctx context.Context
 		clm.Annotations[clusterV1alpha1.AnnotationKeyClmDomain] = os.Getenv(util.HC_DOMAIN)
 
 		if err = r.Client.Create(context.TODO(), clm); err != nil {
-			log.Error(err, "Failed to create ClusterManager for ["+clusterRegistration.Spec.ClusterName+"]")
+			log.Error(err, "Failed to create ClusterManager", "cluster", clusterRegistration.Spec.ClusterName)
 			return ctrl.Result{}, err
 		}
+
+		err = util.RecordAudit(
+			r.Client,
+			clm.Namespace,
+			clm.Name,
+			clusterV1alpha1.ClusterAuditActionClusterRegistered,
+			clusterRegistration.Annotations[util.AnnotationKeyCreator],
+			"Registered by ClusterRegistration ["+clusterRegistration.Name+"]",
+		)
+		if err != nil {
+			log.Error(err, "Failed to record audit for ClusterManager", "clusterManager", clm.Name)
+		}
+
+		err = util.PushClusterEvent(util.ClusterEvent{
+			Type:        util.ClusterEventTypeClusterCreated,
+			Namespace:   clm.Namespace,
+			ClusterName: clm.Name,
+			Owner:       clm.Annotations[util.AnnotationKeyOwner],
+		})
+		if err != nil {
+			log.Error(err, "Failed to push cluster event for ClusterManager", "clusterManager", clm.Name)
+		}
+
+		err = util.Notify(r.Client, util.NewClusterNotification(
+			clm,
+			clusterV1alpha1.NotificationEventClusterCreated,
+			"Registered by ClusterRegistration ["+clusterRegistration.Name+"]",
+		))
+		if err != nil {
+			log.Error(err, "Failed to send notification for ClusterManager", "clusterManager", clm.Name)
+		}
 	} else if err != nil {
 		log.Error(err, "Failed to get ClusterManager")
 		return ctrl.Result{}, err
 	}
 
-	if err := util.Insert(clm); err != nil {
+	if err := util.InsertOrQueue(ctx, r.Client, clm); err != nil {
 		log.Error(err, "Failed to insert cluster info into cluster_member table")
 		return ctrl.Result{}, err
 	}
@@ -218,16 +283,71 @@ func ConstructClusterManagerByRegistration(clusterRegistration *clusterV1alpha1.
 		},
 		Spec: clusterV1alpha1.ClusterManagerSpec{},
 	}
+	for _, key := range clusterV1alpha1.IntervalOverrideAnnotations {
+		if value, ok := clusterRegistration.Annotations[key]; ok {
+			clm.Annotations[key] = value
+		}
+	}
 	return clm
 }
 
-func GetRegWorkloadClusterEndpoint(kubeconfig string) (string, error) {
-	decodedKubeConfig, _ := b64.StdEncoding.DecodeString(kubeconfig)
-	reg, _ := regexp.Compile(`https://[0-9a-zA-Z./-]+`)
-	endpoint := reg.FindString(string(decodedKubeConfig))[len("https://"):]
-	if endpoint == "" {
-		return "", fmt.Errorf("endpoint is empty")
+func isManagedClusterRegistration(clusterRegistration *clusterV1alpha1.ClusterRegistration) bool {
+	spec := clusterRegistration.Spec
+	return spec.EKS != nil || spec.GKE != nil || spec.AKS != nil
+}
+
+// spec.kubeConfigSecret 의 secret 에서 kubeconfig 를 가져온다.
+func (r *ClusterRegistrationReconciler) getKubeconfigFromSecret(clusterRegistration *clusterV1alpha1.ClusterRegistration) ([]byte, error) {
+	key := types.NamespacedName{
+		Name:      clusterRegistration.Spec.KubeConfigSecret,
+		Namespace: clusterRegistration.Namespace,
+	}
+	secret := &coreV1.Secret{}
+	if err := r.Client.Get(context.TODO(), key, secret); err != nil {
+		return nil, err
+	}
+	raw, ok := secret.Data["value"]
+	if !ok || len(raw) == 0 {
+		return nil, fmt.Errorf("kubeconfig is not found in value of secret %s", secret.Name)
+	}
+	return raw, nil
+}
+
+// cloud 의 IAM 으로 인증하는 kubeconfig 를 생성한다.
+func (r *ClusterRegistrationReconciler) newManagedClusterKubeconfig(ctx context.Context, clusterRegistration *clusterV1alpha1.ClusterRegistration) ([]byte, error) {
+	spec := clusterRegistration.Spec
+	switch {
+	case spec.EKS != nil:
+		return util.NewEKSKubeconfig(ctx, spec.EKS.ClusterARN, spec.EKS.RoleARN)
+	case spec.GKE != nil:
+		return util.NewGKEKubeconfig(ctx, r.Client, clusterRegistration.Namespace, spec.GKE)
+	case spec.AKS != nil:
+		return util.NewAKSKubeconfig(ctx, r.Client, clusterRegistration.Namespace, spec.AKS)
+	}
+	return nil, fmt.Errorf("cluster registration is not for a managed cluster")
+}
+
+// operator 가 생성하는 managed cluster 의 kubeconfig 의 인증 정보
+func managedClusterAuth(clusterRegistration *clusterV1alpha1.ClusterRegistration) *util.ManagedClusterAuth {
+	spec := clusterRegistration.Spec
+	switch {
+	case spec.EKS != nil:
+		return util.NewEKSClusterAuth(clusterRegistration.Namespace, spec.EKS)
+	case spec.GKE != nil:
+		return util.NewGKEClusterAuth(clusterRegistration.Namespace, spec.GKE)
+	case spec.AKS != nil:
+		return util.NewAKSClusterAuth(clusterRegistration.Namespace, spec.AKS)
 	}
+	return nil
+}
 
-	return endpoint, nil
+// validation 과정에서 발생한 error 를 ClusterRegistration 의 reason 으로 변환한다.
+func clusterRegistrationReasonForError(err error) clusterV1alpha1.ClusterRegistrationReason {
+	switch util.ErrorReason(err) {
+	case util.ReasonPermissionDenied:
+		return clusterV1alpha1.ClusterRegistrationReasonPermissionDenied
+	case util.ReasonDuplicateCluster:
+		return clusterV1alpha1.ClusterRegistrationReasonClusterNameDuplicated
+	}
+	return clusterV1alpha1.ClusterRegistrationReasonClusterNotFound
 }
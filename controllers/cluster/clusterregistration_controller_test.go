@@ -0,0 +1,119 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/go-logr/logr"
+	clusterV1alpha1 "github.com/tmax-cloud/hypercloud-multi-operator/apis/cluster/v1alpha1"
+	util "github.com/tmax-cloud/hypercloud-multi-operator/controllers/util"
+	utilfake "github.com/tmax-cloud/hypercloud-multi-operator/controllers/util/fake"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestCheckValidation(t *testing.T) {
+	const server = "https://member.example.com:6443"
+
+	tests := []struct {
+		name string
+		// 같은 이름의 cluster manager 가 이미 있는 경우
+		duplicated bool
+		// member cluster 의 client 를 생성할 수 없는 경우
+		remoteErr     error
+		wantValidated bool
+		wantPhase     clusterV1alpha1.ClusterRegistrationPhase
+		wantReason    clusterV1alpha1.ClusterRegistrationReason
+	}{
+		{
+			name:          "valid cluster",
+			wantValidated: true,
+		},
+		{
+			name:       "invalid kubeconfig",
+			remoteErr:  fmt.Errorf("invalid kubeconfig"),
+			wantPhase:  clusterV1alpha1.ClusterRegistrationPhaseError,
+			wantReason: clusterV1alpha1.ClusterRegistrationReasonInvalidKubeconfig,
+		},
+		{
+			name:       "duplicated cluster name",
+			duplicated: true,
+			wantPhase:  clusterV1alpha1.ClusterRegistrationPhaseError,
+			wantReason: clusterV1alpha1.ClusterRegistrationReasonClusterNameDuplicated,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			registration := &clusterV1alpha1.ClusterRegistration{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "registration",
+					Namespace: testNamespace,
+				},
+				Spec: clusterV1alpha1.ClusterRegistrationSpec{
+					ClusterName: "cluster",
+				},
+			}
+
+			var objects []client.Object
+			if tt.duplicated {
+				objects = append(objects, newTestClusterManager(registration.Spec.ClusterName))
+			}
+			remoteClients := utilfake.NewRemoteClientFactory()
+			if tt.remoteErr != nil {
+				remoteClients.Err = tt.remoteErr
+			} else {
+				remoteClients.AddServerCluster(server)
+			}
+
+			r := &ClusterRegistrationReconciler{
+				Client:        fake.NewClientBuilder().WithScheme(newTestScheme(t)).WithObjects(objects...).Build(),
+				Log:           logr.Discard(),
+				RemoteClients: remoteClients,
+			}
+			ctx := util.WithKubeconfig(context.TODO(), &util.Kubeconfig{Server: server})
+			if _, err := r.CheckValidation(ctx, registration); err != nil {
+				t.Fatalf("CheckValidation() error = %v", err)
+			}
+
+			if registration.Status.ClusterValidated != tt.wantValidated {
+				t.Errorf("ClusterValidated = %v, want %v", registration.Status.ClusterValidated, tt.wantValidated)
+			}
+			if registration.Status.Phase != tt.wantPhase {
+				t.Errorf("Phase = %q, want %q", registration.Status.Phase, tt.wantPhase)
+			}
+			if registration.Status.Reason != tt.wantReason {
+				t.Errorf("Reason = %q, want %q", registration.Status.Reason, tt.wantReason)
+			}
+		})
+	}
+}
+
+func TestCheckValidationWithoutKubeconfig(t *testing.T) {
+	r := &ClusterRegistrationReconciler{
+		Client:        fake.NewClientBuilder().WithScheme(newTestScheme(t)).Build(),
+		Log:           logr.Discard(),
+		RemoteClients: utilfake.NewRemoteClientFactory(),
+	}
+	registration := &clusterV1alpha1.ClusterRegistration{}
+	if _, err := r.CheckValidation(context.TODO(), registration); err == nil {
+		t.Error("CheckValidation() should fail when kubeconfig is not parsed")
+	}
+}
@@ -0,0 +1,187 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"reflect"
+
+	"github.com/go-logr/logr"
+	clusterV1alpha1 "github.com/tmax-cloud/hypercloud-multi-operator/apis/cluster/v1alpha1"
+	"github.com/tmax-cloud/hypercloud-multi-operator/controllers/pipeline"
+	"github.com/tmax-cloud/hypercloud-multi-operator/controllers/util"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+
+	"sigs.k8s.io/cluster-api/util/patch"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+// ClusterRolloutReconciler reconciles a ClusterRollout object
+type ClusterRolloutReconciler struct {
+	client.Client
+	Log      logr.Logger
+	Scheme   *runtime.Scheme
+	Recorder record.EventRecorder
+}
+
+// +kubebuilder:rbac:groups=cluster.tmax.io,resources=clusterrollouts,verbs=get;list;patch;update;watch
+// +kubebuilder:rbac:groups=cluster.tmax.io,resources=clusterrollouts/status,verbs=get;patch;update
+
+func (r *ClusterRolloutReconciler) Reconcile(ctx context.Context, req ctrl.Request) (_ ctrl.Result, reterr error) {
+	_ = context.Background()
+	log := r.Log.WithValues("clusterrollout", req.NamespacedName)
+
+	// get ClusterRollout
+	rollout := &clusterV1alpha1.ClusterRollout{}
+	if err := r.Client.Get(context.TODO(), req.NamespacedName, rollout); errors.IsNotFound(err) {
+		log.Info("ClusterRollout not found. Ignoring since object must be deleted")
+		pipeline.Forget(rollout, req.NamespacedName)
+		return ctrl.Result{}, nil
+	} else if err != nil {
+		log.Error(err, "Failed to get ClusterRollout")
+		return ctrl.Result{}, err
+	}
+
+	// 이미 적용된 변경은 되돌리지 않으므로 삭제 시 정리할 리소스가 없다.
+	if !rollout.DeletionTimestamp.IsZero() {
+		return ctrl.Result{}, nil
+	}
+
+	patchHelper, err := patch.NewHelper(rollout, r.Client)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	defer func() {
+		if err := patchHelper.Patch(context.TODO(), rollout); err != nil {
+			reterr = err
+		}
+	}()
+
+	// Handle normal reconciliation loop.
+	return r.reconcile(context.TODO(), rollout)
+}
+
+// reconcile handles cluster rollout reconciliation.
+func (r *ClusterRolloutReconciler) reconcile(ctx context.Context, rollout *clusterV1alpha1.ClusterRollout) (ctrl.Result, error) {
+	phases := []pipeline.Phase[*clusterV1alpha1.ClusterRollout]{
+		// cluster group 의 cluster 들을 status 에 반영하고 각 cluster 의 stage 를 정한다.
+		{Name: "SyncRolloutClusters", Run: r.SyncRolloutClusters},
+		// 현재 stage 의 cluster 에 변경을 적용하고, 모두 준비되면 다음 stage 로 넘어간다.
+		{Name: "ProgressRollout", Run: r.ProgressRollout, DependsOn: []string{"SyncRolloutClusters"}},
+	}
+
+	return pipeline.NewRunner[*clusterV1alpha1.ClusterRollout](r.Log, r.Recorder).Run(ctx, rollout, phases)
+}
+
+func (r *ClusterRolloutReconciler) requeueClusterRolloutsForClusterManager(o client.Object) []ctrl.Request {
+	log := r.Log.WithValues("ClusterRollout-ObjectMapper", "clusterManagerToClusterRollouts", "ClusterManager", o.GetNamespace()+"/"+o.GetName())
+	return r.requeueClusterRolloutsInNamespace(log, o.GetNamespace(), "")
+}
+
+func (r *ClusterRolloutReconciler) requeueClusterRolloutsForClusterGroup(o client.Object) []ctrl.Request {
+	log := r.Log.WithValues("ClusterRollout-ObjectMapper", "clusterGroupToClusterRollouts", "ClusterGroup", o.GetNamespace()+"/"+o.GetName())
+	return r.requeueClusterRolloutsInNamespace(log, o.GetNamespace(), o.GetName())
+}
+
+// clusterGroup 이 비어있으면 같은 namespace 의 rollout 을 모두 requeue 한다.
+func (r *ClusterRolloutReconciler) requeueClusterRolloutsInNamespace(log logr.Logger, namespace, clusterGroup string) []ctrl.Request {
+	rolloutList := &clusterV1alpha1.ClusterRolloutList{}
+	if err := r.Client.List(context.TODO(), rolloutList, client.InNamespace(namespace)); err != nil {
+		log.Error(err, "Failed to list ClusterRollout")
+		return nil
+	}
+
+	reqs := []ctrl.Request{}
+	for _, rollout := range rolloutList.Items {
+		if clusterGroup != "" && rollout.Spec.ClusterGroup != clusterGroup {
+			continue
+		}
+		reqs = append(reqs, ctrl.Request{NamespacedName: rollout.GetNamespacedName()})
+	}
+	return reqs
+}
+
+func (r *ClusterRolloutReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	controller, err := ctrl.NewControllerManagedBy(mgr).
+		For(&clusterV1alpha1.ClusterRollout{}).
+		WithOptions(util.DefaultControllerOptions()).
+		WithEventFilter(util.SpecChangedPredicate()).
+		Build(util.ShardReconciler(mgr.GetClient(), r))
+
+	if err != nil {
+		return err
+	}
+
+	// cluster group 의 selector 가 변경되면 rollout 대상 cluster 가 바뀐다.
+	err = controller.Watch(
+		&source.Kind{Type: &clusterV1alpha1.ClusterGroup{}},
+		handler.EnqueueRequestsFromMapFunc(r.requeueClusterRolloutsForClusterGroup),
+		predicate.Funcs{
+			CreateFunc: func(e event.CreateEvent) bool {
+				return true
+			},
+			UpdateFunc: func(e event.UpdateEvent) bool {
+				return e.ObjectOld.GetGeneration() != e.ObjectNew.GetGeneration()
+			},
+			DeleteFunc: func(e event.DeleteEvent) bool {
+				return true
+			},
+			GenericFunc: func(e event.GenericEvent) bool {
+				return false
+			},
+		},
+	)
+	if err != nil {
+		return err
+	}
+
+	// 변경을 적용한 cluster 의 상태가 바뀌면 rollout 을 진행한다.
+	return controller.Watch(
+		&source.Kind{Type: &clusterV1alpha1.ClusterManager{}},
+		handler.EnqueueRequestsFromMapFunc(r.requeueClusterRolloutsForClusterManager),
+		predicate.Funcs{
+			CreateFunc: func(e event.CreateEvent) bool {
+				return false
+			},
+			UpdateFunc: func(e event.UpdateEvent) bool {
+				oldClm := e.ObjectOld.(*clusterV1alpha1.ClusterManager)
+				newClm := e.ObjectNew.(*clusterV1alpha1.ClusterManager)
+				if !labels.Equals(oldClm.Labels, newClm.Labels) ||
+					oldClm.Status.Ready != newClm.Status.Ready ||
+					oldClm.Status.Version != newClm.Status.Version ||
+					!reflect.DeepEqual(oldClm.Status.Conditions, newClm.Status.Conditions) {
+					return true
+				}
+				return false
+			},
+			DeleteFunc: func(e event.DeleteEvent) bool {
+				return true
+			},
+			GenericFunc: func(e event.GenericEvent) bool {
+				return false
+			},
+		},
+	)
+}
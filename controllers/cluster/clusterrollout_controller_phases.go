@@ -0,0 +1,342 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	clusterV1alpha1 "github.com/tmax-cloud/hypercloud-multi-operator/apis/cluster/v1alpha1"
+
+	coreV1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// 변경을 적용한 cluster 의 상태를 다시 확인하는 간격
+	clusterRolloutPollInterval = 30 * time.Second
+
+	reasonRolloutStageStarted = "RolloutStageStarted"
+	reasonRolloutPaused       = "RolloutPaused"
+	reasonRolloutCompleted    = "RolloutCompleted"
+)
+
+// 주기적으로 확인되는 addon 의 health condition
+// 변경 이전에 확인된 결과일 수 있으므로 unhealthy 로 확인된 경우에만 준비되지 않은 것으로 본다.
+var rolloutAddonHealthConditions = []string{
+	clusterV1alpha1.ClusterManagerConditionArgoAddonHealthy,
+	clusterV1alpha1.ClusterManagerConditionIngressAddonHealthy,
+	clusterV1alpha1.ClusterManagerConditionCNIAddonHealthy,
+	clusterV1alpha1.ClusterManagerConditionMonitoringAddonHealthy,
+}
+
+func (r *ClusterRolloutReconciler) SyncRolloutClusters(ctx context.Context, rollout *clusterV1alpha1.ClusterRollout) (ctrl.Result, error) {
+	log := r.Log.WithValues("clusterrollout", rollout.GetNamespacedName())
+	log.Info("Start to reconcile phase for SyncRolloutClusters")
+
+	clusterGroup := &clusterV1alpha1.ClusterGroup{}
+	key := types.NamespacedName{Name: rollout.Spec.ClusterGroup, Namespace: rollout.Namespace}
+	if err := r.Client.Get(ctx, key, clusterGroup); errors.IsNotFound(err) {
+		log.Info("ClusterGroup not found. Waiting for the group to be created", "clustergroup", rollout.Spec.ClusterGroup)
+		rollout.Status.Reason = fmt.Sprintf("cluster group %s not found", rollout.Spec.ClusterGroup)
+		return ctrl.Result{RequeueAfter: clusterRolloutPollInterval}, nil
+	} else if err != nil {
+		log.Error(err, "Failed to get ClusterGroup")
+		return ctrl.Result{}, err
+	}
+
+	clmList, err := GetClusterGroupMembers(r.Client, clusterGroup)
+	if err != nil {
+		log.Error(err, "Failed to list ClusterManagers for ClusterGroup")
+		return ctrl.Result{}, err
+	}
+	members := []clusterV1alpha1.ClusterManager{}
+	for _, clm := range clmList {
+		if clm.DeletionTimestamp.IsZero() {
+			members = append(members, clm)
+		}
+	}
+	// fan-out 과 같은 우선순위로 먼저 적용할 cluster 를 고른다.
+	sort.SliceStable(members, func(i, j int) bool {
+		return members[i].GetFanOutPriority() > members[j].GetFanOutPriority()
+	})
+
+	// target 이 변경되면 canary stage 부터 다시 시작한다.
+	targetHash, err := rolloutTargetHash(&rollout.Spec.Target)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	if targetHash != rollout.Status.TargetHash {
+		now := metav1.Now()
+		rollout.Status.TargetHash = targetHash
+		rollout.Status.Stage = clusterV1alpha1.ClusterRolloutStageCanary
+		rollout.Status.StageStartTime = &now
+		rollout.Status.StageCompletionTime = nil
+		rollout.Status.Clusters = nil
+	}
+
+	assign := len(rollout.Status.Clusters) == 0
+	canaryCount, batchSize := rollout.GetCanaryCount(), rollout.GetBatchSize()
+	clusters := []clusterV1alpha1.ClusterRolloutClusterStatus{}
+	for i, clm := range members {
+		if clusterStatus := rollout.Status.GetClusterStatus(clm.Name); clusterStatus != nil {
+			clusters = append(clusters, *clusterStatus)
+			continue
+		}
+		// rollout 도중 group 에 추가된 cluster 는 full stage 에서 적용한다.
+		stage := clusterV1alpha1.ClusterRolloutStageFull
+		if assign && i < canaryCount {
+			stage = clusterV1alpha1.ClusterRolloutStageCanary
+		} else if assign && i < canaryCount+batchSize {
+			stage = clusterV1alpha1.ClusterRolloutStageBatch
+		}
+		clusters = append(clusters, clusterV1alpha1.ClusterRolloutClusterStatus{
+			Name:  clm.Name,
+			Stage: stage,
+			State: clusterV1alpha1.ClusterRolloutClusterStatePending,
+		})
+	}
+	rollout.Status.Clusters = clusters
+	rollout.Status.Total = len(clusters)
+	return ctrl.Result{}, nil
+}
+
+func (r *ClusterRolloutReconciler) ProgressRollout(ctx context.Context, rollout *clusterV1alpha1.ClusterRollout) (ctrl.Result, error) {
+	log := r.Log.WithValues("clusterrollout", rollout.GetNamespacedName())
+	log.Info("Start to reconcile phase for ProgressRollout")
+
+	status := &rollout.Status
+	prevPhase := status.Phase
+	target := &rollout.Spec.Target
+	if target.Version == "" && target.Addons == nil && len(target.Labels) == 0 {
+		status.SetTypedPhase(clusterV1alpha1.ClusterRolloutPhasePaused)
+		status.Reason = "target has no change"
+		return ctrl.Result{}, nil
+	}
+	if rollout.Spec.Paused {
+		if prevPhase != clusterV1alpha1.ClusterRolloutPhasePaused {
+			status.Reason = "paused by user"
+		}
+		status.SetTypedPhase(clusterV1alpha1.ClusterRolloutPhasePaused)
+		return ctrl.Result{}, nil
+	}
+
+	// 중지되었던 rollout 이 재개되면 실패한 cluster 에 다시 적용한다.
+	if prevPhase == clusterV1alpha1.ClusterRolloutPhasePaused {
+		for i := range status.Clusters {
+			if status.Clusters[i].State == clusterV1alpha1.ClusterRolloutClusterStateFailed {
+				status.Clusters[i].State = clusterV1alpha1.ClusterRolloutClusterStatePending
+				status.Clusters[i].Reason = ""
+			}
+		}
+	}
+	status.SetTypedPhase(clusterV1alpha1.ClusterRolloutPhaseProgressing)
+	status.Reason = ""
+
+	now := time.Now()
+	deadline := rollout.GetProgressDeadline()
+	for i := range status.Clusters {
+		clusterStatus := &status.Clusters[i]
+		if clusterStatus.Stage.Index() > status.Stage.Index() ||
+			clusterStatus.State == clusterV1alpha1.ClusterRolloutClusterStateUpdated ||
+			clusterStatus.State == clusterV1alpha1.ClusterRolloutClusterStateFailed {
+			continue
+		}
+
+		clm := &clusterV1alpha1.ClusterManager{}
+		key := types.NamespacedName{Name: clusterStatus.Name, Namespace: rollout.Namespace}
+		if err := r.Client.Get(ctx, key, clm); errors.IsNotFound(err) {
+			// 다음 reconcile 에서 status 에서 제외된다.
+			continue
+		} else if err != nil {
+			log.Error(err, "Failed to get ClusterManager", "cluster", clusterStatus.Name)
+			return ctrl.Result{}, err
+		}
+
+		if clusterStatus.State == clusterV1alpha1.ClusterRolloutClusterStatePending {
+			if target.Version != "" && clm.GetClusterType() != clusterV1alpha1.ClusterTypeCreated {
+				clusterStatus.State = clusterV1alpha1.ClusterRolloutClusterStateFailed
+				clusterStatus.Reason = "version upgrade is only supported for the clusters created by the operator"
+				continue
+			}
+			// webhook 이 거부한 변경은 재시도해도 성공하지 않으므로 실패로 기록한다.
+			if err := applyRolloutTarget(ctx, r.Client, clm, target); errors.IsInvalid(err) || errors.IsForbidden(err) || errors.IsBadRequest(err) {
+				clusterStatus.State = clusterV1alpha1.ClusterRolloutClusterStateFailed
+				clusterStatus.Reason = err.Error()
+				continue
+			} else if err != nil {
+				log.Error(err, "Failed to apply rollout target to ClusterManager", "cluster", clm.Name)
+				return ctrl.Result{}, err
+			}
+			log.Info("Applied rollout target to ClusterManager", "cluster", clm.Name, "stage", clusterStatus.Stage)
+			clusterStatus.State = clusterV1alpha1.ClusterRolloutClusterStateProgressing
+			clusterStatus.ObservedGeneration = clm.Generation
+			clusterStatus.StartTime = &metav1.Time{Time: now}
+		}
+
+		rolledOut, reason := checkClusterRolledOut(clm, target, clusterStatus.ObservedGeneration)
+		switch {
+		case rolledOut:
+			clusterStatus.State = clusterV1alpha1.ClusterRolloutClusterStateUpdated
+			clusterStatus.Reason = ""
+		case clusterStatus.StartTime != nil && now.Sub(clusterStatus.StartTime.Time) > deadline:
+			clusterStatus.State = clusterV1alpha1.ClusterRolloutClusterStateFailed
+			clusterStatus.Reason = fmt.Sprintf("not rolled out within %s: %s", deadline, reason)
+		default:
+			clusterStatus.Reason = reason
+		}
+	}
+
+	// 지금까지 적용한 cluster 중 실패한 비율이 threshold 를 넘으면 rollout 을 중지한다.
+	updated, failed, processed := 0, 0, 0
+	stageDone := true
+	for _, clusterStatus := range status.Clusters {
+		switch clusterStatus.State {
+		case clusterV1alpha1.ClusterRolloutClusterStateUpdated:
+			updated++
+		case clusterV1alpha1.ClusterRolloutClusterStateFailed:
+			failed++
+		}
+		if clusterStatus.Stage.Index() <= status.Stage.Index() {
+			processed++
+			if clusterStatus.State != clusterV1alpha1.ClusterRolloutClusterStateUpdated &&
+				clusterStatus.State != clusterV1alpha1.ClusterRolloutClusterStateFailed {
+				stageDone = false
+			}
+		}
+	}
+	status.Updated, status.Failed = updated, failed
+
+	if failed > 0 && failed*100 > rollout.Spec.Strategy.MaxFailurePercent*processed {
+		rollout.Spec.Paused = true
+		status.SetTypedPhase(clusterV1alpha1.ClusterRolloutPhasePaused)
+		status.Reason = fmt.Sprintf("%d of %d clusters failed until %s stage", failed, processed, status.Stage)
+		log.Info("Paused rollout since failures exceeded the threshold", "reason", status.Reason)
+		r.Recorder.Event(rollout, coreV1.EventTypeWarning, reasonRolloutPaused, status.Reason)
+		return ctrl.Result{}, nil
+	}
+	if !stageDone {
+		return ctrl.Result{RequeueAfter: clusterRolloutPollInterval}, nil
+	}
+
+	if status.Stage == clusterV1alpha1.ClusterRolloutStageFull {
+		status.SetTypedPhase(clusterV1alpha1.ClusterRolloutPhaseCompleted)
+		if prevPhase != clusterV1alpha1.ClusterRolloutPhaseCompleted {
+			r.Recorder.Event(rollout, coreV1.EventTypeNormal, reasonRolloutCompleted,
+				fmt.Sprintf("rolled out to %d clusters", updated))
+		}
+		return ctrl.Result{}, nil
+	}
+
+	// 현재 stage 의 cluster 를 stage interval 동안 지켜본 뒤 다음 stage 를 시작한다.
+	if status.StageCompletionTime == nil {
+		status.StageCompletionTime = &metav1.Time{Time: now}
+	}
+	if wait := status.StageCompletionTime.Add(rollout.GetStageInterval()).Sub(now); wait > 0 {
+		return ctrl.Result{RequeueAfter: wait}, nil
+	}
+	if status.Stage == clusterV1alpha1.ClusterRolloutStageCanary {
+		status.Stage = clusterV1alpha1.ClusterRolloutStageBatch
+	} else {
+		status.Stage = clusterV1alpha1.ClusterRolloutStageFull
+	}
+	status.StageStartTime = &metav1.Time{Time: now}
+	status.StageCompletionTime = nil
+	log.Info("Started next stage of rollout", "stage", status.Stage)
+	r.Recorder.Event(rollout, coreV1.EventTypeNormal, reasonRolloutStageStarted,
+		fmt.Sprintf("%s stage is started", status.Stage))
+	return ctrl.Result{Requeue: true}, nil
+}
+
+// target 을 cluster manager 에 적용한다.
+// 이미 적용된 경우에는 변경 없이 patch 한다.
+func applyRolloutTarget(ctx context.Context, c client.Client, clm *clusterV1alpha1.ClusterManager, target *clusterV1alpha1.ClusterRolloutTarget) error {
+	before := clm.DeepCopy()
+	if target.Version != "" {
+		clm.SetK8SVersion(target.Version)
+	}
+	if target.Addons != nil {
+		clm.Spec.Addons = target.Addons.DeepCopy()
+	}
+	if len(target.Labels) > 0 {
+		if clm.Labels == nil {
+			clm.Labels = map[string]string{}
+		}
+		for key, value := range target.Labels {
+			clm.Labels[key] = value
+		}
+	}
+	return c.Patch(ctx, clm, client.MergeFrom(before))
+}
+
+// cluster 에 target 이 반영되고 준비되었는지 확인한다.
+// 준비되지 않은 경우 그 이유를 함께 반환한다.
+func checkClusterRolledOut(clm *clusterV1alpha1.ClusterManager, target *clusterV1alpha1.ClusterRolloutTarget, generation int64) (bool, string) {
+	if !clm.Status.Ready {
+		return false, "cluster is not ready"
+	}
+	if meta.IsStatusConditionTrue(clm.Status.Conditions, clusterV1alpha1.ClusterManagerConditionUnreachable) {
+		return false, "cluster is unreachable"
+	}
+
+	required := []string{}
+	if target.Version != "" {
+		if clm.Status.GetK8SVersion() != target.Version {
+			return false, fmt.Sprintf("cluster version is %s", clm.Status.GetK8SVersion())
+		}
+		required = append(required,
+			clusterV1alpha1.ClusterManagerConditionControlPlaneRolledOut,
+			clusterV1alpha1.ClusterManagerConditionWorkersRolledOut)
+	}
+	if target.Addons != nil && target.Addons.CertManager != nil {
+		required = append(required, clusterV1alpha1.ClusterManagerConditionCertManagerReady)
+	}
+	if target.Addons != nil && target.Addons.AuditPolicy != nil {
+		required = append(required, clusterV1alpha1.ClusterManagerConditionAuditPolicyApplied)
+	}
+	// 변경을 적용한 generation 이후에 설정된 condition 만 확인한다.
+	for _, conditionType := range required {
+		condition := meta.FindStatusCondition(clm.Status.Conditions, conditionType)
+		if condition == nil || condition.ObservedGeneration < generation || condition.Status != metav1.ConditionTrue {
+			return false, fmt.Sprintf("%s condition is not true", conditionType)
+		}
+	}
+	for _, conditionType := range rolloutAddonHealthConditions {
+		if meta.IsStatusConditionFalse(clm.Status.Conditions, conditionType) {
+			return false, fmt.Sprintf("%s condition is false", conditionType)
+		}
+	}
+	return true, ""
+}
+
+func rolloutTargetHash(target *clusterV1alpha1.ClusterRolloutTarget) (string, error) {
+	content, err := json.Marshal(target)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])[:16], nil
+}
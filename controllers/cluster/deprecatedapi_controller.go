@@ -0,0 +1,169 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-logr/logr"
+	clusterV1alpha1 "github.com/tmax-cloud/hypercloud-multi-operator/apis/cluster/v1alpha1"
+	"github.com/tmax-cloud/hypercloud-multi-operator/controllers/metrics"
+	util "github.com/tmax-cloud/hypercloud-multi-operator/controllers/util"
+
+	coreV1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/version"
+	"k8s.io/client-go/tools/record"
+
+	"sigs.k8s.io/cluster-api/util/patch"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+)
+
+const (
+	// member cluster 의 모든 object 를 조회하므로 긴 주기로 scan 한다.
+	deprecatedAPIScanInterval = 1 * time.Hour
+
+	reasonDeprecatedAPIsFound = "DeprecatedAPIsFound"
+)
+
+// DeprecatedAPIReconciler periodically scans each ClusterManager for the usage of the apis
+// removed in the next minor version and records the findings for the upgrade preflight checks.
+type DeprecatedAPIReconciler struct {
+	client.Client
+	Log      logr.Logger
+	Scheme   *runtime.Scheme
+	Recorder record.EventRecorder
+}
+
+// +kubebuilder:rbac:groups=cluster.tmax.io,resources=clustermanagers/status,verbs=get;patch;update
+
+func (r *DeprecatedAPIReconciler) Reconcile(ctx context.Context, req ctrl.Request) (_ ctrl.Result, reterr error) {
+	_ = context.Background()
+	log := r.Log.WithValues("clustermanager", req.NamespacedName)
+
+	clm := &clusterV1alpha1.ClusterManager{}
+	if err := r.Client.Get(context.TODO(), req.NamespacedName, clm); errors.IsNotFound(err) {
+		metrics.ClusterDeprecatedAPIs.DeleteLabelValues(req.Namespace, req.Name)
+		return ctrl.Result{}, nil
+	} else if err != nil {
+		log.Error(err, "Failed to get ClusterManager")
+		return ctrl.Result{}, err
+	}
+
+	if !clm.DeletionTimestamp.IsZero() {
+		metrics.ClusterDeprecatedAPIs.DeleteLabelValues(clm.Namespace, clm.Name)
+		return ctrl.Result{}, nil
+	}
+	// version 을 알아야 다음 minor version 을 정할 수 있다.
+	current, err := version.ParseGeneric(clm.Status.GetK8SVersion())
+	if !clm.Status.ControlPlaneReady || err != nil {
+		return ctrl.Result{}, nil
+	}
+	target := version.MustParseGeneric(fmt.Sprintf("%d.%d", current.Major(), current.Minor()+1))
+
+	patchHelper, err := patch.NewHelper(clm, r.Client)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	defer func() {
+		if err := patchHelper.Patch(context.TODO(), clm); err != nil {
+			reterr = err
+		}
+	}()
+
+	findings, err := r.scan(ctx, clm, target)
+	if err != nil {
+		// 일시적으로 접근할 수 없는 경우 이전 결과를 유지하고 다음 주기에 다시 scan 한다.
+		log.Info("Failed to scan deprecated apis", "reason", err.Error())
+		return util.RequeueAfterWithJitter(deprecatedAPIScanInterval), nil
+	}
+
+	previous := clm.Status.DeprecatedAPIs
+	if len(findings) != 0 && (previous == nil || len(previous.Findings) == 0) && r.Recorder != nil {
+		r.Recorder.Event(clm, coreV1.EventTypeWarning, reasonDeprecatedAPIsFound,
+			fmt.Sprintf("%d apis removed in %s are still used", len(findings), target.String()))
+	}
+	clm.Status.DeprecatedAPIs = &clusterV1alpha1.DeprecatedAPIScan{
+		TargetVersion: target.String(),
+		Findings:      findings,
+		LastScanTime:  &metav1.Time{Time: time.Now()},
+	}
+	metrics.ClusterDeprecatedAPIs.WithLabelValues(clm.Namespace, clm.Name).Set(float64(len(findings)))
+
+	return util.RequeueAfterWithJitter(deprecatedAPIScanInterval), nil
+}
+
+// api server 의 요청 기록과 object 의 last-applied-configuration 을 함께 확인한다.
+func (r *DeprecatedAPIReconciler) scan(ctx context.Context, clm *clusterV1alpha1.ClusterManager, target *version.Version) ([]clusterV1alpha1.DeprecatedAPIFinding, error) {
+	kubeconfigSecret, err := util.GetKubeconfigSecret(ctx, r.Client, clm.Namespace, clm.Name)
+	if err != nil {
+		return nil, err
+	}
+	remoteClientset, err := util.GetRemoteK8sClient(kubeconfigSecret)
+	if err != nil {
+		return nil, err
+	}
+	remoteClient, err := util.GetRemoteK8sRuntimeClient(kubeconfigSecret)
+	if err != nil {
+		return nil, err
+	}
+
+	requested, err := util.GetRequestedDeprecatedAPIs(ctx, remoteClientset, target)
+	if err != nil {
+		return nil, err
+	}
+	applied, err := util.GetAppliedDeprecatedAPIs(ctx, remoteClient, target)
+	if err != nil {
+		return nil, err
+	}
+	return append(requested, applied...), nil
+}
+
+func (r *DeprecatedAPIReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		Named("deprecatedapi").
+		For(&clusterV1alpha1.ClusterManager{}).
+		WithOptions(util.DefaultControllerOptions()).
+		WithEventFilter(
+			predicate.Funcs{
+				CreateFunc: func(e event.CreateEvent) bool {
+					return true
+				},
+				UpdateFunc: func(e event.UpdateEvent) bool {
+					oldClm := e.ObjectOld.(*clusterV1alpha1.ClusterManager)
+					newClm := e.ObjectNew.(*clusterV1alpha1.ClusterManager)
+					// upgrade 가 끝나면 다음 minor version 이 바뀌므로 다시 scan 하고, 이후에는 주기적으로 requeue 된다.
+					isDeleted := oldClm.DeletionTimestamp.IsZero() && !newClm.DeletionTimestamp.IsZero()
+					isReady := !oldClm.Status.ControlPlaneReady && newClm.Status.ControlPlaneReady
+					isUpgraded := oldClm.Status.GetK8SVersion() != newClm.Status.GetK8SVersion()
+					return isDeleted || isReady || isUpgraded
+				},
+				DeleteFunc: func(e event.DeleteEvent) bool {
+					return true
+				},
+				GenericFunc: func(e event.GenericEvent) bool {
+					return false
+				},
+			},
+		).
+		Complete(util.ShardReconciler(mgr.GetClient(), r))
+}
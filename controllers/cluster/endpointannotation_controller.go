@@ -0,0 +1,149 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"reflect"
+
+	"github.com/go-logr/logr"
+	clusterV1alpha1 "github.com/tmax-cloud/hypercloud-multi-operator/apis/cluster/v1alpha1"
+	util "github.com/tmax-cloud/hypercloud-multi-operator/controllers/util"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"sigs.k8s.io/cluster-api/util/patch"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+)
+
+// EndpointAnnotationReconciler keeps the deprecated apiserver, gateway and domain annotations of the ClusterManager
+// mirrored with status.endpoints, so that the clients reading the annotations can migrate gradually.
+// status.endpoints 가 비어 있으면 이전 버전에서 기록한 annotation 을 status.endpoints 로 옮기고,
+// 값이 있으면 status.endpoints 의 값을 annotation 에 기록한다.
+type EndpointAnnotationReconciler struct {
+	client.Client
+	Log    logr.Logger
+	Scheme *runtime.Scheme
+}
+
+func (r *EndpointAnnotationReconciler) Reconcile(ctx context.Context, req ctrl.Request) (_ ctrl.Result, reterr error) {
+	_ = context.Background()
+	log := r.Log.WithValues("clustermanager", req.NamespacedName)
+
+	clm := &clusterV1alpha1.ClusterManager{}
+	if err := r.Client.Get(context.TODO(), req.NamespacedName, clm); errors.IsNotFound(err) {
+		return ctrl.Result{}, nil
+	} else if err != nil {
+		log.Error(err, "Failed to get ClusterManager")
+		return ctrl.Result{}, err
+	}
+	if !clm.DeletionTimestamp.IsZero() {
+		return ctrl.Result{}, nil
+	}
+
+	patchHelper, err := patch.NewHelper(clm, r.Client)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	if !syncEndpointAnnotations(clm) {
+		return ctrl.Result{}, nil
+	}
+	if err := patchHelper.Patch(context.TODO(), clm); err != nil {
+		log.Error(err, "Failed to patch endpoint annotations")
+		return ctrl.Result{}, err
+	}
+	log.Info("Synced endpoint annotations with status.endpoints")
+	return ctrl.Result{}, nil
+}
+
+// annotation 과 status.endpoints 를 맞추고, 변경된 값이 있으면 true 를 반환한다.
+func syncEndpointAnnotations(clm *clusterV1alpha1.ClusterManager) bool {
+	endpoints := clusterV1alpha1.ClusterEndpoints{}
+	if clm.Status.Endpoints != nil {
+		endpoints = *clm.Status.Endpoints
+	}
+	if clm.Annotations == nil {
+		clm.Annotations = map[string]string{}
+	}
+
+	changed := false
+	fields := []struct {
+		key   string
+		value *string
+	}{
+		{clusterV1alpha1.AnnotationKeyClmApiserver, &endpoints.APIServer},
+		{clusterV1alpha1.AnnotationKeyClmGateway, &endpoints.Gateway},
+		{clusterV1alpha1.AnnotationKeyClmDomain, &endpoints.Domain},
+	}
+	for _, field := range fields {
+		annotation := clm.Annotations[field.key]
+		if *field.value == "" && annotation != "" {
+			*field.value = annotation
+			changed = true
+		} else if *field.value != "" && annotation != *field.value {
+			clm.Annotations[field.key] = *field.value
+			changed = true
+		}
+	}
+
+	if changed && endpoints != (clusterV1alpha1.ClusterEndpoints{}) {
+		clm.Status.Endpoints = &endpoints
+	}
+	return changed
+}
+
+func (r *EndpointAnnotationReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	endpointAnnotationsChanged := func(oldClm, newClm *clusterV1alpha1.ClusterManager) bool {
+		for _, key := range []string{
+			clusterV1alpha1.AnnotationKeyClmApiserver,
+			clusterV1alpha1.AnnotationKeyClmGateway,
+			clusterV1alpha1.AnnotationKeyClmDomain,
+		} {
+			if oldClm.Annotations[key] != newClm.Annotations[key] {
+				return true
+			}
+		}
+		return !reflect.DeepEqual(oldClm.Status.Endpoints, newClm.Status.Endpoints)
+	}
+
+	return ctrl.NewControllerManagedBy(mgr).
+		Named("endpointannotation").
+		For(&clusterV1alpha1.ClusterManager{}).
+		WithOptions(util.DefaultControllerOptions()).
+		WithEventFilter(
+			predicate.Funcs{
+				CreateFunc: func(e event.CreateEvent) bool {
+					return true
+				},
+				UpdateFunc: func(e event.UpdateEvent) bool {
+					return endpointAnnotationsChanged(
+						e.ObjectOld.(*clusterV1alpha1.ClusterManager),
+						e.ObjectNew.(*clusterV1alpha1.ClusterManager),
+					)
+				},
+				DeleteFunc: func(e event.DeleteEvent) bool {
+					return false
+				},
+				GenericFunc: func(e event.GenericEvent) bool {
+					return false
+				},
+			},
+		).
+		Complete(util.ShardReconciler(mgr.GetClient(), r))
+}
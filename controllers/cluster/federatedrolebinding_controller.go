@@ -0,0 +1,206 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+
+	"github.com/go-logr/logr"
+	clusterV1alpha1 "github.com/tmax-cloud/hypercloud-multi-operator/apis/cluster/v1alpha1"
+	"github.com/tmax-cloud/hypercloud-multi-operator/controllers/pipeline"
+	"github.com/tmax-cloud/hypercloud-multi-operator/controllers/util"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+
+	"sigs.k8s.io/cluster-api/util/patch"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+// FederatedRoleBindingReconciler reconciles a FederatedRoleBinding object
+type FederatedRoleBindingReconciler struct {
+	client.Client
+	Log      logr.Logger
+	Scheme   *runtime.Scheme
+	Recorder record.EventRecorder
+}
+
+// +kubebuilder:rbac:groups=cluster.tmax.io,resources=federatedrolebindings,verbs=create;delete;get;list;patch;update;watch
+// +kubebuilder:rbac:groups=cluster.tmax.io,resources=federatedrolebindings/status,verbs=get;patch;update
+
+func (r *FederatedRoleBindingReconciler) Reconcile(ctx context.Context, req ctrl.Request) (_ ctrl.Result, reterr error) {
+	_ = context.Background()
+	log := r.Log.WithValues("federatedrolebinding", req.NamespacedName)
+
+	// get FederatedRoleBinding
+	frb := &clusterV1alpha1.FederatedRoleBinding{}
+	if err := r.Client.Get(context.TODO(), req.NamespacedName, frb); errors.IsNotFound(err) {
+		log.Info("FederatedRoleBinding not found. Ignoring since object must be deleted")
+		pipeline.Forget(frb, req.NamespacedName)
+		return ctrl.Result{}, nil
+	} else if err != nil {
+		log.Error(err, "Failed to get FederatedRoleBinding")
+		return ctrl.Result{}, err
+	}
+
+	patchHelper, err := patch.NewHelper(frb, r.Client)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	defer func() {
+		r.reconcilePhase(context.TODO(), frb)
+
+		if err := patchHelper.Patch(context.TODO(), frb); err != nil {
+			reterr = err
+		}
+	}()
+
+	// Add finalizer first if not exist to avoid the race condition between init and delete
+	if !controllerutil.ContainsFinalizer(frb, clusterV1alpha1.FederatedRoleBindingFinalizer) {
+		controllerutil.AddFinalizer(frb, clusterV1alpha1.FederatedRoleBindingFinalizer)
+		return ctrl.Result{}, nil
+	}
+
+	// Handle deletion reconciliation loop.
+	if !frb.ObjectMeta.DeletionTimestamp.IsZero() {
+		return r.reconcileDelete(context.TODO(), frb)
+	}
+
+	// Handle normal reconciliation loop.
+	return r.reconcile(context.TODO(), frb)
+}
+
+// reconcile handles federated role binding reconciliation.
+func (r *FederatedRoleBindingReconciler) reconcile(ctx context.Context, frb *clusterV1alpha1.FederatedRoleBinding) (ctrl.Result, error) {
+	phases := []pipeline.Phase[*clusterV1alpha1.FederatedRoleBinding]{
+		// 더 이상 선택되지 않는 cluster 에 생성했던 role binding 을 삭제한다.
+		{Name: "PruneUnselectedClusters", Run: r.PruneUnselectedClusters},
+		// 선택된 cluster 들에 role binding 을 생성하고, 더 이상 필요 없는 role binding 을 삭제한다.
+		{Name: "SyncRoleBindings", Run: r.SyncRoleBindings},
+	}
+
+	return pipeline.NewRunner[*clusterV1alpha1.FederatedRoleBinding](r.Log, r.Recorder).Run(ctx, frb, phases)
+}
+
+func (r *FederatedRoleBindingReconciler) reconcileDelete(ctx context.Context, frb *clusterV1alpha1.FederatedRoleBinding) (ctrl.Result, error) {
+	log := r.Log.WithValues("federatedrolebinding", frb.GetNamespacedName())
+	log.Info("Start to reconcile delete for FederatedRoleBinding")
+
+	// 모든 cluster 에 생성했던 role binding 을 삭제한다.
+	remains := []clusterV1alpha1.FederatedRoleBindingClusterStatus{}
+	for _, clusterStatus := range frb.Status.Clusters {
+		if err := r.pruneCluster(frb, clusterStatus.Name, clusterStatus.Resources); err != nil {
+			log.Error(err, "Failed to delete resources from cluster", "cluster", clusterStatus.Name)
+			remains = append(remains, clusterStatus)
+		}
+	}
+	frb.Status.Clusters = remains
+	if len(remains) > 0 {
+		return ctrl.Result{Requeue: true}, nil
+	}
+
+	controllerutil.RemoveFinalizer(frb, clusterV1alpha1.FederatedRoleBindingFinalizer)
+	log.Info("FederatedRoleBinding is removed successfully")
+	return ctrl.Result{}, nil
+}
+
+func (r *FederatedRoleBindingReconciler) reconcilePhase(_ context.Context, frb *clusterV1alpha1.FederatedRoleBinding) {
+	if !frb.DeletionTimestamp.IsZero() {
+		frb.Status.SetTypedPhase(clusterV1alpha1.FederatedRoleBindingPhaseDeleting)
+		return
+	}
+
+	// 현재 generation 이 아직 모든 cluster 에 적용되지 않은 경우
+	if progress := frb.Status.Progress; progress != nil && progress.Updated < progress.Total {
+		frb.Status.SetTypedPhase(clusterV1alpha1.FederatedRoleBindingPhaseProgressing)
+		return
+	}
+	for _, clusterStatus := range frb.Status.Clusters {
+		if !clusterStatus.Synced {
+			frb.Status.SetTypedPhase(clusterV1alpha1.FederatedRoleBindingPhaseProgressing)
+			return
+		}
+	}
+	if frb.Status.Reason != "" {
+		frb.Status.SetTypedPhase(clusterV1alpha1.FederatedRoleBindingPhaseProgressing)
+		return
+	}
+	frb.Status.SetTypedPhase(clusterV1alpha1.FederatedRoleBindingPhaseSynced)
+}
+
+func (r *FederatedRoleBindingReconciler) requeueFederatedRoleBindingsForClusterManager(o client.Object) []ctrl.Request {
+	clm := o.DeepCopyObject().(*clusterV1alpha1.ClusterManager)
+	log := r.Log.WithValues("FederatedRoleBinding-ObjectMapper", "clusterManagerToFederatedRoleBindings", "ClusterManager", clm.GetNamespacedName())
+
+	frbList := &clusterV1alpha1.FederatedRoleBindingList{}
+	if err := r.Client.List(context.TODO(), frbList, client.InNamespace(clm.Namespace)); err != nil {
+		log.Error(err, "Failed to list FederatedRoleBinding")
+		return nil
+	}
+
+	// selector 까지 확인하지 않고, 같은 namespace 의 federated role binding 을 모두 requeue 한다.
+	reqs := []ctrl.Request{}
+	for _, frb := range frbList.Items {
+		reqs = append(reqs, ctrl.Request{NamespacedName: frb.GetNamespacedName()})
+	}
+	return reqs
+}
+
+func (r *FederatedRoleBindingReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	controller, err := ctrl.NewControllerManagedBy(mgr).
+		For(&clusterV1alpha1.FederatedRoleBinding{}).
+		WithOptions(util.DefaultControllerOptions()).
+		WithEventFilter(util.SpecChangedPredicate()).
+		Build(util.ShardReconciler(mgr.GetClient(), r))
+
+	if err != nil {
+		return err
+	}
+
+	// cluster 의 label 이 변경되거나 control plane 이 준비되면, role binding 을 다시 동기화한다.
+	return controller.Watch(
+		&source.Kind{Type: &clusterV1alpha1.ClusterManager{}},
+		handler.EnqueueRequestsFromMapFunc(r.requeueFederatedRoleBindingsForClusterManager),
+		predicate.Funcs{
+			CreateFunc: func(e event.CreateEvent) bool {
+				return false
+			},
+			UpdateFunc: func(e event.UpdateEvent) bool {
+				oldClm := e.ObjectOld.(*clusterV1alpha1.ClusterManager)
+				newClm := e.ObjectNew.(*clusterV1alpha1.ClusterManager)
+				if !labels.Equals(oldClm.Labels, newClm.Labels) ||
+					oldClm.Status.ControlPlaneReady != newClm.Status.ControlPlaneReady {
+					return true
+				}
+				return false
+			},
+			DeleteFunc: func(e event.DeleteEvent) bool {
+				return true
+			},
+			GenericFunc: func(e event.GenericEvent) bool {
+				return false
+			},
+		},
+	)
+}
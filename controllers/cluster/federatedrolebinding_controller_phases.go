@@ -0,0 +1,257 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"sort"
+
+	clusterV1alpha1 "github.com/tmax-cloud/hypercloud-multi-operator/apis/cluster/v1alpha1"
+	util "github.com/tmax-cloud/hypercloud-multi-operator/controllers/util"
+
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func (r *FederatedRoleBindingReconciler) PruneUnselectedClusters(ctx context.Context, frb *clusterV1alpha1.FederatedRoleBinding) (ctrl.Result, error) {
+	log := r.Log.WithValues("federatedrolebinding", frb.GetNamespacedName())
+	log.Info("Start to reconcile phase for PruneUnselectedClusters")
+
+	clmList, err := r.selectClusters(frb)
+	if err != nil {
+		log.Error(err, "Failed to select ClusterManagers")
+		return ctrl.Result{}, err
+	}
+	selected := map[string]bool{}
+	for _, clm := range clmList {
+		selected[clm.Name] = true
+	}
+
+	clusters := []clusterV1alpha1.FederatedRoleBindingClusterStatus{}
+	for _, clusterStatus := range frb.Status.Clusters {
+		if selected[clusterStatus.Name] {
+			clusters = append(clusters, clusterStatus)
+			continue
+		}
+		if err := r.pruneCluster(frb, clusterStatus.Name, clusterStatus.Resources); err != nil {
+			log.Error(err, "Failed to delete role bindings from unselected cluster", "cluster", clusterStatus.Name)
+			clusterStatus.Synced = false
+			clusterStatus.Reason = "Failed to delete role bindings: " + err.Error()
+			clusters = append(clusters, clusterStatus)
+			continue
+		}
+		log.Info("Deleted role bindings from unselected cluster successfully", "cluster", clusterStatus.Name)
+	}
+	frb.Status.Clusters = clusters
+
+	return ctrl.Result{}, nil
+}
+
+func (r *FederatedRoleBindingReconciler) SyncRoleBindings(ctx context.Context, frb *clusterV1alpha1.FederatedRoleBinding) (ctrl.Result, error) {
+	log := r.Log.WithValues("federatedrolebinding", frb.GetNamespacedName())
+	log.Info("Start to reconcile phase for SyncRoleBindings")
+
+	// Role 은 namespace 에 속하므로 cluster role binding 으로 bind 할 수 없다.
+	if frb.Spec.RoleRef.Kind == "Role" && len(frb.Spec.Namespaces) == 0 {
+		log.Info("Role can be bound only with namespaces")
+		frb.Status.Reason = "Role can be bound only with namespaces"
+		return ctrl.Result{}, nil
+	}
+	frb.Status.Reason = ""
+
+	objs, err := r.buildRoleBindings(frb)
+	if err != nil {
+		log.Error(err, "Failed to build role bindings")
+		return ctrl.Result{}, err
+	}
+
+	clmList, err := r.selectClusters(frb)
+	if err != nil {
+		log.Error(err, "Failed to select ClusterManagers")
+		return ctrl.Result{}, err
+	}
+
+	// task 가 실행되는 동안 slice 가 다시 할당되지 않도록 모든 cluster 의 status 를 먼저 만든다.
+	for _, clm := range clmList {
+		if frb.Status.GetClusterStatus(clm.Name) == nil {
+			frb.Status.Clusters = append(frb.Status.Clusters, clusterV1alpha1.FederatedRoleBindingClusterStatus{Name: clm.Name})
+		}
+	}
+
+	tasks := []util.FanOutTask{}
+	for i := range clmList {
+		clm := &clmList[i]
+		clusterStatus := frb.Status.GetClusterStatus(clm.Name)
+
+		// control plane 이 준비되면 cluster manager watch 에 의해 다시 reconcile 된다.
+		if !clm.Status.ControlPlaneReady {
+			clusterStatus.Synced = false
+			clusterStatus.Reason = "Wait for control plane to be ready"
+			continue
+		}
+		// 현재 generation 이 이미 적용된 cluster 는 건너뛴다.
+		if clusterStatus.Synced && clusterStatus.ObservedGeneration == frb.Generation {
+			continue
+		}
+
+		tasks = append(tasks, util.FanOutTask{
+			Key:      clm.Name,
+			Priority: clm.GetFanOutPriority(),
+			Run: func(ctx context.Context) error {
+				remoteClient, err := getRemoteRuntimeClient(r.Client, clm)
+				if err != nil {
+					return err
+				}
+				resources, err := applyManifestResources(remoteClient, objs, clusterStatus.Resources)
+				if err != nil {
+					return err
+				}
+				clusterStatus.Resources = resources
+				return nil
+			},
+		})
+	}
+
+	results, remaining := util.GetFanOutPool().Run(ctx, tasks)
+	allSynced := true
+	for name, err := range results {
+		clusterStatus := frb.Status.GetClusterStatus(name)
+		clusterStatus.ObservedGeneration = frb.Generation
+		if err != nil {
+			log.Error(err, "Failed to sync role bindings to cluster", "cluster", name)
+			clusterStatus.Synced = false
+			clusterStatus.Reason = err.Error()
+			allSynced = false
+			continue
+		}
+		clusterStatus.Synced = true
+		clusterStatus.Reason = ""
+		clusterStatus.LastSyncTime = metav1.Now()
+	}
+
+	sort.Slice(frb.Status.Clusters, func(i, j int) bool {
+		return frb.Status.Clusters[i].Name < frb.Status.Clusters[j].Name
+	})
+
+	updated, failed := 0, 0
+	for _, clm := range clmList {
+		clusterStatus := frb.Status.GetClusterStatus(clm.Name)
+		if clusterStatus.ObservedGeneration != frb.Generation {
+			continue
+		}
+		if clusterStatus.Synced {
+			updated++
+		} else if clm.Status.ControlPlaneReady {
+			failed++
+		}
+	}
+	frb.Status.Progress = newFanOutProgress(frb.Status.Progress, frb.Generation, len(clmList), updated, failed, len(results) > 0)
+
+	if remaining > 0 {
+		log.Info("Wait for the next batch", "remaining", remaining)
+		return ctrl.Result{RequeueAfter: util.FanOutBatchInterval}, nil
+	}
+	if !allSynced {
+		return ctrl.Result{Requeue: true}, nil
+	}
+	return ctrl.Result{}, nil
+}
+
+// namespaces 가 지정된 경우 각 namespace 에 role binding 을, 아닌 경우 cluster role binding 을 생성한다.
+func (r *FederatedRoleBindingReconciler) buildRoleBindings(frb *clusterV1alpha1.FederatedRoleBinding) ([]*unstructured.Unstructured, error) {
+	labels := map[string]string{
+		clusterV1alpha1.LabelKeyFederatedRoleBinding:          frb.Name,
+		clusterV1alpha1.LabelKeyFederatedRoleBindingNamespace: frb.Namespace,
+	}
+	roleRef := frb.Spec.RoleRef
+	if roleRef.APIGroup == "" {
+		roleRef.APIGroup = rbacv1.GroupName
+	}
+
+	objs := []client.Object{}
+	if len(frb.Spec.Namespaces) == 0 {
+		objs = append(objs, &rbacv1.ClusterRoleBinding{
+			TypeMeta: metav1.TypeMeta{
+				APIVersion: rbacv1.SchemeGroupVersion.String(),
+				Kind:       "ClusterRoleBinding",
+			},
+			ObjectMeta: metav1.ObjectMeta{
+				Name:   frb.GetRoleBindingName(),
+				Labels: labels,
+			},
+			RoleRef:  roleRef,
+			Subjects: frb.Spec.Subjects,
+		})
+	}
+	for _, namespace := range frb.Spec.Namespaces {
+		objs = append(objs, &rbacv1.RoleBinding{
+			TypeMeta: metav1.TypeMeta{
+				APIVersion: rbacv1.SchemeGroupVersion.String(),
+				Kind:       "RoleBinding",
+			},
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      frb.GetRoleBindingName(),
+				Namespace: namespace,
+				Labels:    labels,
+			},
+			RoleRef:  roleRef,
+			Subjects: frb.Spec.Subjects,
+		})
+	}
+
+	result := []*unstructured.Unstructured{}
+	for _, obj := range objs {
+		u, err := convertToUnstructured(obj)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, u)
+	}
+	return result, nil
+}
+
+func (r *FederatedRoleBindingReconciler) pruneCluster(frb *clusterV1alpha1.FederatedRoleBinding, clusterName string, resources []clusterV1alpha1.ManifestStatus) error {
+	return pruneRemoteResources(r.Client, frb.Namespace, clusterName, resources)
+}
+
+// spec 의 cluster selector 에 해당하는 cluster manager 목록
+func (r *FederatedRoleBindingReconciler) selectClusters(frb *clusterV1alpha1.FederatedRoleBinding) ([]clusterV1alpha1.ClusterManager, error) {
+	selector, err := frb.GetClusterSelector()
+	if err != nil {
+		return nil, err
+	}
+	clmList := &clusterV1alpha1.ClusterManagerList{}
+	opts := []client.ListOption{
+		client.InNamespace(frb.Namespace),
+		client.MatchingLabelsSelector{Selector: selector},
+	}
+	if err := r.Client.List(context.TODO(), clmList, opts...); err != nil {
+		return nil, err
+	}
+
+	result := []clusterV1alpha1.ClusterManager{}
+	for _, clm := range clmList.Items {
+		// 삭제중인 cluster 에는 생성하지 않는다.
+		if !clm.DeletionTimestamp.IsZero() {
+			continue
+		}
+		result = append(result, clm)
+	}
+	return result, nil
+}
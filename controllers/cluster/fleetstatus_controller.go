@@ -0,0 +1,171 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"reflect"
+
+	"github.com/go-logr/logr"
+	clusterV1alpha1 "github.com/tmax-cloud/hypercloud-multi-operator/apis/cluster/v1alpha1"
+	"github.com/tmax-cloud/hypercloud-multi-operator/controllers/pipeline"
+	"github.com/tmax-cloud/hypercloud-multi-operator/controllers/util"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+
+	"sigs.k8s.io/cluster-api/util/patch"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+// FleetStatusReconciler reconciles a FleetStatus object
+type FleetStatusReconciler struct {
+	client.Client
+	Log      logr.Logger
+	Scheme   *runtime.Scheme
+	Recorder record.EventRecorder
+	// member cluster 의 clientset 을 생성한다. nil 이면 util.DefaultRemoteClientFactory 를 사용한다.
+	RemoteClients util.RemoteClientFactory
+}
+
+func (r *FleetStatusReconciler) remoteClients() util.RemoteClientFactory {
+	return util.RemoteClientFactoryOrDefault(r.RemoteClients)
+}
+
+// +kubebuilder:rbac:groups=cluster.tmax.io,resources=fleetstatuses,verbs=create;delete;get;list;patch;update;watch
+// +kubebuilder:rbac:groups=cluster.tmax.io,resources=fleetstatuses/status,verbs=get;patch;update
+
+func (r *FleetStatusReconciler) Reconcile(ctx context.Context, req ctrl.Request) (_ ctrl.Result, reterr error) {
+	_ = context.Background()
+	log := r.Log.WithValues("fleetstatus", req.NamespacedName)
+
+	// get FleetStatus
+	fleetStatus := &clusterV1alpha1.FleetStatus{}
+	if err := r.Client.Get(context.TODO(), req.NamespacedName, fleetStatus); errors.IsNotFound(err) {
+		log.Info("FleetStatus not found. Ignoring since object must be deleted")
+		pipeline.Forget(fleetStatus, req.NamespacedName)
+		return ctrl.Result{}, nil
+	} else if err != nil {
+		log.Error(err, "Failed to get FleetStatus")
+		return ctrl.Result{}, err
+	}
+
+	patchHelper, err := patch.NewHelper(fleetStatus, r.Client)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	defer func() {
+		r.reconcilePhase(context.TODO(), fleetStatus)
+
+		if err := patchHelper.Patch(context.TODO(), fleetStatus); err != nil {
+			reterr = err
+		}
+	}()
+
+	// member cluster 에 생성하는 리소스가 없으므로 삭제 시 처리할 것이 없다.
+	if !fleetStatus.ObjectMeta.DeletionTimestamp.IsZero() {
+		return ctrl.Result{}, nil
+	}
+
+	// Handle normal reconciliation loop.
+	return r.reconcile(context.TODO(), fleetStatus)
+}
+
+// reconcile handles fleet status reconciliation.
+func (r *FleetStatusReconciler) reconcile(ctx context.Context, fleetStatus *clusterV1alpha1.FleetStatus) (ctrl.Result, error) {
+	phases := []pipeline.Phase[*clusterV1alpha1.FleetStatus]{
+		// 선택된 cluster 들의 상태를 확인하여 Ready/Degraded/Unreachable 개수와 가장 심각한 상태를 집계한다.
+		{Name: "AggregateClusterHealth", Run: r.AggregateClusterHealth},
+	}
+
+	return pipeline.NewRunner[*clusterV1alpha1.FleetStatus](r.Log, r.Recorder).Run(ctx, fleetStatus, phases)
+}
+
+func (r *FleetStatusReconciler) reconcilePhase(_ context.Context, fleetStatus *clusterV1alpha1.FleetStatus) {
+	if fleetStatus.Status.DegradedCount > 0 || fleetStatus.Status.UnreachableCount > 0 {
+		fleetStatus.Status.SetTypedPhase(clusterV1alpha1.FleetStatusPhaseDegraded)
+		return
+	}
+	fleetStatus.Status.SetTypedPhase(clusterV1alpha1.FleetStatusPhaseHealthy)
+}
+
+func (r *FleetStatusReconciler) requeueFleetStatusesForClusterManager(o client.Object) []ctrl.Request {
+	clm := o.DeepCopyObject().(*clusterV1alpha1.ClusterManager)
+	log := r.Log.WithValues("FleetStatus-ObjectMapper", "clusterManagerToFleetStatuses", "ClusterManager", clm.GetNamespacedName())
+
+	fleetList := &clusterV1alpha1.FleetStatusList{}
+	if err := r.Client.List(context.TODO(), fleetList, client.InNamespace(clm.Namespace)); err != nil {
+		log.Error(err, "Failed to list FleetStatus")
+		return nil
+	}
+
+	// selector 까지 확인하지 않고, 같은 namespace 의 fleet status 를 모두 requeue 한다.
+	reqs := []ctrl.Request{}
+	for _, fleetStatus := range fleetList.Items {
+		reqs = append(reqs, ctrl.Request{NamespacedName: fleetStatus.GetNamespacedName()})
+	}
+	return reqs
+}
+
+func (r *FleetStatusReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	controller, err := ctrl.NewControllerManagedBy(mgr).
+		For(&clusterV1alpha1.FleetStatus{}).
+		WithOptions(util.DefaultControllerOptions()).
+		WithEventFilter(util.SpecChangedPredicate()).
+		Build(util.ShardReconciler(mgr.GetClient(), r))
+
+	if err != nil {
+		return err
+	}
+
+	// cluster 의 label 이나 상태가 변경되면 바로 다시 집계한다.
+	return controller.Watch(
+		&source.Kind{Type: &clusterV1alpha1.ClusterManager{}},
+		handler.EnqueueRequestsFromMapFunc(r.requeueFleetStatusesForClusterManager),
+		predicate.Funcs{
+			CreateFunc: func(e event.CreateEvent) bool {
+				return true
+			},
+			UpdateFunc: func(e event.UpdateEvent) bool {
+				oldClm := e.ObjectOld.(*clusterV1alpha1.ClusterManager)
+				newClm := e.ObjectNew.(*clusterV1alpha1.ClusterManager)
+				if !labels.Equals(oldClm.Labels, newClm.Labels) ||
+					oldClm.Status.Ready != newClm.Status.Ready ||
+					oldClm.Status.ControlPlaneReady != newClm.Status.ControlPlaneReady ||
+					oldClm.Status.Phase != newClm.Status.Phase ||
+					oldClm.Status.MasterRun != newClm.Status.MasterRun ||
+					oldClm.Status.WorkerRun != newClm.Status.WorkerRun ||
+					!reflect.DeepEqual(oldClm.Status.Certificates, newClm.Status.Certificates) {
+					return true
+				}
+				return false
+			},
+			DeleteFunc: func(e event.DeleteEvent) bool {
+				return true
+			},
+			GenericFunc: func(e event.GenericEvent) bool {
+				return false
+			},
+		},
+	)
+}
@@ -0,0 +1,192 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	clusterV1alpha1 "github.com/tmax-cloud/hypercloud-multi-operator/apis/cluster/v1alpha1"
+	util "github.com/tmax-cloud/hypercloud-multi-operator/controllers/util"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func (r *FleetStatusReconciler) AggregateClusterHealth(ctx context.Context, fleetStatus *clusterV1alpha1.FleetStatus) (ctrl.Result, error) {
+	log := r.Log.WithValues("fleetstatus", fleetStatus.GetNamespacedName())
+	log.Info("Start to reconcile phase for AggregateClusterHealth")
+
+	selector, err := fleetStatus.GetClusterSelector()
+	if err != nil {
+		log.Error(err, "Failed to convert cluster selector")
+		return ctrl.Result{}, err
+	}
+	clmList := &clusterV1alpha1.ClusterManagerList{}
+	opts := []client.ListOption{
+		client.InNamespace(fleetStatus.Namespace),
+		client.MatchingLabelsSelector{Selector: selector},
+	}
+	if err := r.Client.List(context.TODO(), clmList, opts...); err != nil {
+		log.Error(err, "Failed to list ClusterManagers")
+		return ctrl.Result{}, err
+	}
+
+	now := metav1.Now()
+	clusters := []clusterV1alpha1.FleetClusterStatus{}
+	counts := map[clusterV1alpha1.ClusterHealth]int{}
+	certificates := []clusterV1alpha1.FleetCertificateStatus{}
+	warningThreshold := util.GetCertExpiryWarningThreshold()
+	for _, clm := range clmList.Items {
+		// 삭제중인 cluster 는 집계하지 않는다.
+		if !clm.DeletionTimestamp.IsZero() {
+			continue
+		}
+
+		health, reason, message := r.getClusterHealth(&clm)
+		clusterStatus := clusterV1alpha1.FleetClusterStatus{
+			Name:               clm.Name,
+			Health:             health,
+			Reason:             reason,
+			Message:            message,
+			LastTransitionTime: now,
+		}
+		// health 가 변경되지 않았으면 이전 transition 시각을 유지한다.
+		previous := fleetStatus.Status.GetClusterStatus(clm.Name)
+		if previous != nil && previous.Health == health {
+			clusterStatus.LastTransitionTime = previous.LastTransitionTime
+		}
+		// 더 심각한 Degraded, Unreachable 상태로 변경된 경우에만 알림을 전송한다.
+		if health.Severity() >= clusterV1alpha1.ClusterHealthDegraded.Severity() &&
+			(previous == nil || previous.Health.Severity() < health.Severity()) {
+			r.notify(&clm, clusterV1alpha1.NotificationEventClusterUnhealthy, "Cluster is "+string(health)+": "+message)
+		}
+		clusters = append(clusters, clusterStatus)
+		counts[health]++
+		certificates = append(certificates, getExpiringCertificates(&clm, warningThreshold)...)
+	}
+
+	sort.Slice(clusters, func(i, j int) bool {
+		return clusters[i].Name < clusters[j].Name
+	})
+	fleetStatus.Status.Clusters = clusters
+	fleetStatus.Status.Total = len(clusters)
+	fleetStatus.Status.ReadyCount = counts[clusterV1alpha1.ClusterHealthReady]
+	fleetStatus.Status.DegradedCount = counts[clusterV1alpha1.ClusterHealthDegraded]
+	fleetStatus.Status.UnreachableCount = counts[clusterV1alpha1.ClusterHealthUnreachable]
+	fleetStatus.Status.ProgressingCount = counts[clusterV1alpha1.ClusterHealthProgressing]
+	fleetStatus.Status.WorstConditions = getWorstConditions(clusters, fleetStatus.Spec.MaxWorstConditions)
+	// 만료가 가까운 인증서부터 나열한다.
+	sort.SliceStable(certificates, func(i, j int) bool {
+		return certificates[i].NotAfter.Before(&certificates[j].NotAfter)
+	})
+	fleetStatus.Status.ExpiringCertificateCount = len(certificates)
+	if len(certificates) > fleetStatus.Spec.MaxExpiringCertificates {
+		certificates = certificates[:fleetStatus.Spec.MaxExpiringCertificates]
+	}
+	fleetStatus.Status.ExpiringCertificates = certificates
+	fleetStatus.Status.LastUpdateTime = now
+
+	// member cluster 의 api server 상태는 watch 할 수 없으므로 주기적으로 확인한다.
+	return util.RequeueAfterWithJitter(getResyncPeriod()), nil
+}
+
+// cluster manager 의 status 와 api server 응답으로 health 를 판단한다.
+func (r *FleetStatusReconciler) getClusterHealth(clm *clusterV1alpha1.ClusterManager) (clusterV1alpha1.ClusterHealth, string, string) {
+	switch clm.Status.Phase {
+	case clusterV1alpha1.ClusterManagerPhaseProcessing, clusterV1alpha1.ClusterManagerPhaseSyncNeeded,
+		clusterV1alpha1.ClusterManagerPhaseUpgrading, clusterV1alpha1.ClusterManagerPhaseScaling:
+		return clusterV1alpha1.ClusterHealthProgressing, string(clm.Status.Phase), "Cluster is " + string(clm.Status.Phase)
+	}
+	if !clm.Status.ControlPlaneReady {
+		return clusterV1alpha1.ClusterHealthProgressing, "ControlPlaneNotReady", "Wait for control plane to be ready"
+	}
+
+	// 상태 확인만 하므로 limited kubeconfig 를 사용한다.
+	remoteClientset, err := r.remoteClients().ClientsetForCluster(context.TODO(), r.Client, clm.Namespace, clm.Name, util.CredentialLimited)
+	if errors.IsNotFound(err) {
+		return clusterV1alpha1.ClusterHealthUnreachable, "KubeconfigNotFound", "Kubeconfig secret is not found"
+	}
+	if err == nil {
+		err = util.CheckClusterHealth(remoteClientset)
+	}
+	if err != nil {
+		return clusterV1alpha1.ClusterHealthUnreachable, util.ErrorReason(err), err.Error()
+	}
+
+	if !clm.Status.Ready {
+		return clusterV1alpha1.ClusterHealthDegraded, "ClusterNotReady", "Cluster is reachable but not ready"
+	}
+	if clm.Status.MasterRun < clm.Spec.MasterNum || clm.Status.WorkerRun < clm.Spec.WorkerNum {
+		message := fmt.Sprintf("Running nodes: master %d/%d, worker %d/%d",
+			clm.Status.MasterRun, clm.Spec.MasterNum, clm.Status.WorkerRun, clm.Spec.WorkerNum)
+		return clusterV1alpha1.ClusterHealthDegraded, "NodesNotReady", message
+	}
+	return clusterV1alpha1.ClusterHealthReady, "", ""
+}
+
+// CertificateInventoryReconciler 가 마지막으로 scan 한 인증서 중 만료되었거나 threshold 안에 만료되는 인증서를 반환한다.
+func getExpiringCertificates(clm *clusterV1alpha1.ClusterManager, threshold time.Duration) []clusterV1alpha1.FleetCertificateStatus {
+	if clm.Status.Certificates == nil {
+		return nil
+	}
+	deadline := time.Now().Add(threshold)
+	certificates := []clusterV1alpha1.FleetCertificateStatus{}
+	for _, cert := range clm.Status.Certificates.Certificates {
+		if !cert.NotAfter.Time.Before(deadline) {
+			continue
+		}
+		certificates = append(certificates, clusterV1alpha1.FleetCertificateStatus{
+			Cluster:  clm.Name,
+			Type:     cert.Type,
+			Name:     cert.Name,
+			NotAfter: cert.NotAfter,
+		})
+	}
+	return certificates
+}
+
+// Unreachable, Degraded 인 cluster 를 심각한 순서로 최대 max 개 반환한다.
+// 같은 health 인 경우 오래 지속된 cluster 가 먼저 온다.
+func getWorstConditions(clusters []clusterV1alpha1.FleetClusterStatus, max int) []clusterV1alpha1.FleetClusterStatus {
+	worst := []clusterV1alpha1.FleetClusterStatus{}
+	for _, clusterStatus := range clusters {
+		if clusterStatus.Health.Severity() >= clusterV1alpha1.ClusterHealthDegraded.Severity() {
+			worst = append(worst, clusterStatus)
+		}
+	}
+	sort.SliceStable(worst, func(i, j int) bool {
+		if worst[i].Health.Severity() != worst[j].Health.Severity() {
+			return worst[i].Health.Severity() > worst[j].Health.Severity()
+		}
+		return worst[i].LastTransitionTime.Before(&worst[j].LastTransitionTime)
+	})
+	if len(worst) > max {
+		worst = worst[:max]
+	}
+	return worst
+}
+
+func (r *FleetStatusReconciler) notify(clm *clusterV1alpha1.ClusterManager, event clusterV1alpha1.NotificationEvent, message string) {
+	err := util.Notify(r.Client, util.NewClusterNotification(clm, event, message))
+	if err != nil {
+		r.Log.Error(err, "Failed to send notification for ClusterManager", "event", string(event), "clusterManager", clm.Name)
+	}
+}
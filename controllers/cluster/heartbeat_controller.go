@@ -0,0 +1,186 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-logr/logr"
+	clusterV1alpha1 "github.com/tmax-cloud/hypercloud-multi-operator/apis/cluster/v1alpha1"
+	"github.com/tmax-cloud/hypercloud-multi-operator/controllers/metrics"
+	util "github.com/tmax-cloud/hypercloud-multi-operator/controllers/util"
+
+	coreV1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+
+	"sigs.k8s.io/cluster-api/util/patch"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+)
+
+const (
+	// api server 에 health check 를 요청하는 주기
+	heartbeatInterval = 1 * time.Minute
+	// HEARTBEAT_STALE_THRESHOLD 가 설정되지 않은 경우의 기본값
+	heartbeatStaleThresholdDefault = 5 * time.Minute
+
+	reasonHeartbeatReceived = "HeartbeatReceived"
+	reasonHeartbeatStale    = "HeartbeatStale"
+)
+
+// HeartbeatReconciler periodically checks the api server of each ClusterManager,
+// records the last successful contact and marks stale clusters as Unreachable.
+type HeartbeatReconciler struct {
+	client.Client
+	Log      logr.Logger
+	Scheme   *runtime.Scheme
+	Recorder record.EventRecorder
+	// heartbeat 가 이 시간 이상 갱신되지 않으면 Unreachable condition 을 True 로 설정한다.
+	StaleThreshold time.Duration
+	// member cluster 의 clientset 을 생성한다. nil 이면 util.DefaultRemoteClientFactory 를 사용한다.
+	RemoteClients util.RemoteClientFactory
+}
+
+func (r *HeartbeatReconciler) remoteClients() util.RemoteClientFactory {
+	return util.RemoteClientFactoryOrDefault(r.RemoteClients)
+}
+
+// +kubebuilder:rbac:groups=cluster.tmax.io,resources=clustermanagers/status,verbs=get;patch;update
+
+func (r *HeartbeatReconciler) Reconcile(ctx context.Context, req ctrl.Request) (_ ctrl.Result, reterr error) {
+	_ = context.Background()
+	log := r.Log.WithValues("clustermanager", req.NamespacedName)
+
+	clm := &clusterV1alpha1.ClusterManager{}
+	if err := r.Client.Get(context.TODO(), req.NamespacedName, clm); errors.IsNotFound(err) {
+		metrics.ClusterHeartbeat.Delete(req.Namespace, req.Name)
+		return ctrl.Result{}, nil
+	} else if err != nil {
+		log.Error(err, "Failed to get ClusterManager")
+		return ctrl.Result{}, err
+	}
+
+	if !clm.DeletionTimestamp.IsZero() {
+		metrics.ClusterHeartbeat.Delete(clm.Namespace, clm.Name)
+		return ctrl.Result{}, nil
+	}
+	// control plane 이 준비되기 전에는 api server 에 접근할 수 없으므로 heartbeat 를 확인하지 않는다.
+	if !clm.Status.ControlPlaneReady {
+		return ctrl.Result{}, nil
+	}
+
+	patchHelper, err := patch.NewHelper(clm, r.Client)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	defer func() {
+		if err := patchHelper.Patch(context.TODO(), clm); err != nil {
+			reterr = err
+		}
+	}()
+
+	now := time.Now()
+	checkErr := r.checkHealth(clm)
+	if checkErr == nil {
+		clm.Status.LastHeartbeatTime = &metav1.Time{Time: now}
+	} else {
+		log.Info("Failed to get heartbeat from remote cluster", "reason", checkErr.Error())
+	}
+	if clm.Status.LastHeartbeatTime != nil {
+		metrics.ClusterHeartbeat.Set(clm.Namespace, clm.Name, clm.Status.LastHeartbeatTime.Time)
+	}
+
+	r.setUnreachableCondition(clm, now, checkErr)
+	return util.RequeueAfterWithJitter(clm.GetIntervalOverride(clusterV1alpha1.AnnotationKeyClmHealthCheckInterval, heartbeatInterval)), nil
+}
+
+// limited kubeconfig 로 remote cluster 의 api server 에 요청한다.
+func (r *HeartbeatReconciler) checkHealth(clm *clusterV1alpha1.ClusterManager) error {
+	remoteClientset, err := r.remoteClients().ClientsetForCluster(context.TODO(), r.Client, clm.Namespace, clm.Name, util.CredentialLimited)
+	if err != nil {
+		return err
+	}
+	return util.CheckClusterHealth(remoteClientset)
+}
+
+// 마지막 heartbeat 가 threshold 보다 오래된 경우 Unreachable condition 을 True 로 설정한다.
+// heartbeat 를 한 번도 받지 못한 경우에는 health check 가 실패하면 바로 Unreachable 로 판단한다.
+func (r *HeartbeatReconciler) setUnreachableCondition(clm *clusterV1alpha1.ClusterManager, now time.Time, checkErr error) {
+	last := clm.Status.LastHeartbeatTime
+	stale := checkErr != nil && (last == nil || now.Sub(last.Time) > r.StaleThreshold)
+
+	condition := metav1.Condition{
+		Type:               clusterV1alpha1.ClusterManagerConditionUnreachable,
+		Status:             metav1.ConditionFalse,
+		Reason:             reasonHeartbeatReceived,
+		ObservedGeneration: clm.Generation,
+	}
+	if stale {
+		condition.Status = metav1.ConditionTrue
+		condition.Reason = reasonHeartbeatStale
+		if reason := util.ErrorReason(checkErr); reason != util.ReasonUnknown {
+			condition.Reason = reason
+		}
+		condition.Message = checkErr.Error()
+	}
+
+	wasUnreachable := meta.IsStatusConditionTrue(clm.Status.Conditions, clusterV1alpha1.ClusterManagerConditionUnreachable)
+	meta.SetStatusCondition(&clm.Status.Conditions, condition)
+
+	if stale && !wasUnreachable && r.Recorder != nil {
+		r.Recorder.Event(clm, coreV1.EventTypeWarning, clusterV1alpha1.ClusterManagerConditionUnreachable,
+			"Heartbeat is not received for more than "+r.StaleThreshold.String()+": "+checkErr.Error())
+	}
+}
+
+func (r *HeartbeatReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	if r.StaleThreshold <= 0 {
+		r.StaleThreshold = heartbeatStaleThresholdDefault
+	}
+
+	return ctrl.NewControllerManagedBy(mgr).
+		Named("heartbeat").
+		For(&clusterV1alpha1.ClusterManager{}).
+		WithOptions(util.DefaultControllerOptions()).
+		WithEventFilter(
+			predicate.Funcs{
+				CreateFunc: func(e event.CreateEvent) bool {
+					return true
+				},
+				UpdateFunc: func(e event.UpdateEvent) bool {
+					oldClm := e.ObjectOld.(*clusterV1alpha1.ClusterManager)
+					newClm := e.ObjectNew.(*clusterV1alpha1.ClusterManager)
+					// heartbeat 갱신에 의한 status 변경은 무시하고, 이후에는 주기적으로 requeue 된다.
+					isDeleted := oldClm.DeletionTimestamp.IsZero() && !newClm.DeletionTimestamp.IsZero()
+					isReady := !oldClm.Status.ControlPlaneReady && newClm.Status.ControlPlaneReady
+					return isDeleted || isReady
+				},
+				DeleteFunc: func(e event.DeleteEvent) bool {
+					return true
+				},
+				GenericFunc: func(e event.GenericEvent) bool {
+					return false
+				},
+			},
+		).
+		Complete(util.ShardReconciler(mgr.GetClient(), r))
+}
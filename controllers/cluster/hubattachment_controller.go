@@ -0,0 +1,234 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	goerrors "errors"
+	"os"
+
+	"github.com/go-logr/logr"
+	clusterV1alpha1 "github.com/tmax-cloud/hypercloud-multi-operator/apis/cluster/v1alpha1"
+	util "github.com/tmax-cloud/hypercloud-multi-operator/controllers/util"
+
+	coreV1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+)
+
+// member cluster 의 service account token 이 아직 생성되지 않은 경우
+var errHubTokenNotReady = goerrors.New("service account token for hub is not ready")
+
+// HubAttachmentReconciler joins member clusters of ClusterManagers into an existing
+// Karmada control plane or OCM hub, using the stored kubeconfig of each cluster.
+type HubAttachmentReconciler struct {
+	client.Client
+	Log      logr.Logger
+	Scheme   *runtime.Scheme
+	Recorder record.EventRecorder
+	// karmada 또는 ocm
+	Mode string
+}
+
+// +kubebuilder:rbac:groups=cluster.tmax.io,resources=clustermanagers,verbs=get;list;patch;update;watch
+// +kubebuilder:rbac:groups="",resources=secrets,verbs=get;list;watch
+
+func (r *HubAttachmentReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	_ = context.Background()
+	log := r.Log.WithValues("clustermanager", req.NamespacedName)
+
+	clm := &clusterV1alpha1.ClusterManager{}
+	if err := r.Client.Get(context.TODO(), req.NamespacedName, clm); errors.IsNotFound(err) {
+		log.Info("ClusterManager resource not found. Ignoring since object must be deleted")
+		return ctrl.Result{}, nil
+	} else if err != nil {
+		log.Error(err, "Failed to get ClusterManager")
+		return ctrl.Result{}, err
+	}
+
+	if !clm.DeletionTimestamp.IsZero() {
+		return r.reconcileDelete(clm)
+	}
+
+	// control plane 이 준비되면 watch 에 의해 다시 reconcile 된다.
+	if !clm.Status.ControlPlaneReady {
+		return ctrl.Result{}, nil
+	}
+
+	if !controllerutil.ContainsFinalizer(clm, clusterV1alpha1.ClusterManagerHubAttachmentFinalizer) {
+		patch := client.MergeFromWithOptions(clm.DeepCopy(), client.MergeFromWithOptimisticLock{})
+		controllerutil.AddFinalizer(clm, clusterV1alpha1.ClusterManagerHubAttachmentFinalizer)
+		if err := r.Client.Patch(context.TODO(), clm, patch); err != nil {
+			log.Error(err, "Failed to add finalizer for hub attachment")
+			return ctrl.Result{}, err
+		}
+	}
+
+	if clm.Annotations[clusterV1alpha1.AnnotationKeyClmHubAttached] == r.Mode {
+		return ctrl.Result{}, nil
+	}
+	log.Info("Start to attach cluster to hub", "mode", r.Mode)
+
+	hubSecret, hubClient, err := r.getHubClient()
+	if errors.IsNotFound(err) {
+		log.Info("Hub kubeconfig secret not found. Wait for the secret to be created")
+		return ctrl.Result{Requeue: true}, nil
+	} else if err != nil {
+		log.Error(err, "Failed to get hub client")
+		return ctrl.Result{Requeue: true}, nil
+	}
+
+	remoteClient, err := getRemoteRuntimeClient(r.Client, clm)
+	if errors.IsNotFound(err) {
+		log.Info("Kubeconfig secret not found. Wait for the secret to be created")
+		return ctrl.Result{Requeue: true}, nil
+	} else if err != nil {
+		log.Error(err, "Failed to get remote cluster client")
+		return ctrl.Result{Requeue: true}, nil
+	}
+
+	switch r.Mode {
+	case util.HubAttachModeKarmada:
+		err = r.attachToKarmada(clm, hubClient, remoteClient)
+	case util.HubAttachModeOCM:
+		err = r.attachToOCM(clm, hubSecret, hubClient, remoteClient)
+	}
+	if goerrors.Is(err, errHubTokenNotReady) {
+		log.Info("Service account token is not ready. Wait for creating")
+		return ctrl.Result{Requeue: true}, nil
+	} else if err != nil {
+		log.Error(err, "Failed to attach cluster to hub", "mode", r.Mode)
+		r.Recorder.Event(clm, coreV1.EventTypeWarning, "HubAttachFailed", err.Error())
+		return ctrl.Result{Requeue: true}, nil
+	}
+
+	patch := client.MergeFrom(clm.DeepCopy())
+	if clm.Annotations == nil {
+		clm.Annotations = map[string]string{}
+	}
+	clm.Annotations[clusterV1alpha1.AnnotationKeyClmHubAttached] = r.Mode
+	if err := r.Client.Patch(context.TODO(), clm, patch); err != nil {
+		log.Error(err, "Failed to update hub attached annotation")
+		return ctrl.Result{}, err
+	}
+
+	r.Recorder.Event(clm, coreV1.EventTypeNormal, "HubAttached", "Cluster is attached to "+r.Mode+" hub as ["+clm.GetHubClusterName()+"]")
+	log.Info("Attach cluster to hub successfully", "mode", r.Mode)
+	return ctrl.Result{}, nil
+}
+
+func (r *HubAttachmentReconciler) reconcileDelete(clm *clusterV1alpha1.ClusterManager) (ctrl.Result, error) {
+	if !controllerutil.ContainsFinalizer(clm, clusterV1alpha1.ClusterManagerHubAttachmentFinalizer) {
+		return ctrl.Result{}, nil
+	}
+	log := r.Log.WithValues("clustermanager", clm.GetNamespacedName())
+
+	if mode := clm.Annotations[clusterV1alpha1.AnnotationKeyClmHubAttached]; mode != "" {
+		log.Info("Start to detach cluster from hub", "mode", mode)
+
+		_, hubClient, err := r.getHubClient()
+		if errors.IsNotFound(err) {
+			log.Info("Hub kubeconfig secret not found. Skip detaching cluster from hub")
+		} else if err != nil {
+			log.Error(err, "Failed to get hub client")
+			return ctrl.Result{Requeue: true}, nil
+		} else {
+			// member cluster 는 이미 삭제되었을 수 있으므로, member cluster 의 agent 는 가능한 경우에만 정리한다.
+			remoteClient, err := getRemoteRuntimeClient(r.Client, clm)
+			if err != nil {
+				log.Info("Failed to get remote cluster client. Skip cleaning up agent of member cluster")
+				remoteClient = nil
+			}
+
+			switch mode {
+			case util.HubAttachModeKarmada:
+				err = r.detachFromKarmada(clm, hubClient, remoteClient)
+			case util.HubAttachModeOCM:
+				err = r.detachFromOCM(clm, hubClient, remoteClient)
+			}
+			if err != nil {
+				log.Error(err, "Failed to detach cluster from hub", "mode", mode)
+				return ctrl.Result{Requeue: true}, nil
+			}
+			log.Info("Detach cluster from hub successfully", "mode", mode)
+		}
+	}
+
+	patch := client.MergeFromWithOptions(clm.DeepCopy(), client.MergeFromWithOptimisticLock{})
+	controllerutil.RemoveFinalizer(clm, clusterV1alpha1.ClusterManagerHubAttachmentFinalizer)
+	if err := r.Client.Patch(context.TODO(), clm, patch); err != nil {
+		log.Error(err, "Failed to remove finalizer for hub attachment")
+		return ctrl.Result{}, err
+	}
+	return ctrl.Result{}, nil
+}
+
+// hub 의 kubeconfig secret 과 hub 에 접근하기 위한 client
+func (r *HubAttachmentReconciler) getHubClient() (*coreV1.Secret, client.Client, error) {
+	name := os.Getenv(util.HUB_KUBECONFIG_SECRET)
+	if name == "" {
+		name = util.HubKubeconfigSecretDefault
+	}
+	key := types.NamespacedName{
+		Name:      name,
+		Namespace: util.HypercloudNamespace,
+	}
+	hubSecret := &coreV1.Secret{}
+	if err := r.Client.Get(context.TODO(), key, hubSecret); err != nil {
+		return nil, nil, err
+	}
+
+	hubClient, err := util.GetRemoteK8sRuntimeClient(hubSecret)
+	if err != nil {
+		return nil, nil, err
+	}
+	return hubSecret, hubClient, nil
+}
+
+func (r *HubAttachmentReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		Named("hubattachment").
+		For(&clusterV1alpha1.ClusterManager{}).
+		WithOptions(util.DefaultControllerOptions()).
+		WithEventFilter(
+			predicate.Funcs{
+				CreateFunc: func(e event.CreateEvent) bool {
+					return true
+				},
+				UpdateFunc: func(e event.UpdateEvent) bool {
+					oldClm := e.ObjectOld.(*clusterV1alpha1.ClusterManager)
+					newClm := e.ObjectNew.(*clusterV1alpha1.ClusterManager)
+					isControlPlaneReady := !oldClm.Status.ControlPlaneReady && newClm.Status.ControlPlaneReady
+					isDelete := oldClm.DeletionTimestamp.IsZero() && !newClm.DeletionTimestamp.IsZero()
+					return isControlPlaneReady || isDelete
+				},
+				DeleteFunc: func(e event.DeleteEvent) bool {
+					return false
+				},
+				GenericFunc: func(e event.GenericEvent) bool {
+					return false
+				},
+			},
+		).
+		Complete(util.ShardReconciler(mgr.GetClient(), r))
+}
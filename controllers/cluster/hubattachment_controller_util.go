@@ -0,0 +1,374 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"io"
+
+	clusterV1alpha1 "github.com/tmax-cloud/hypercloud-multi-operator/apis/cluster/v1alpha1"
+	util "github.com/tmax-cloud/hypercloud-multi-operator/controllers/util"
+
+	coreV1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/yaml"
+	"k8s.io/client-go/tools/clientcmd"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	karmadaClusterNamespace  = "karmada-cluster"
+	karmadaClusterAPIVersion = "cluster.karmada.io/v1alpha1"
+	// karmada 가 member cluster 에 접근할 때 사용하는 service account
+	karmadaServiceAccount = "karmada-manager"
+
+	ocmAgentNamespace             = "open-cluster-management-agent"
+	ocmBootstrapSecret            = "bootstrap-hub-kubeconfig"
+	ocmKlusterletName             = "klusterlet"
+	ocmKlusterletAPIVersion       = "operator.open-cluster-management.io/v1"
+	ocmManagedClusterAPIVersion   = "cluster.open-cluster-management.io/v1"
+	ocmHubSecretKeyBootstrap      = "bootstrap"
+	ocmHubSecretKeyAgentManifests = "agent"
+)
+
+// push mode 로 karmada control plane 에 cluster 를 등록한다.
+// member cluster 에 karmada 용 service account 를 만들고, 그 token 으로 karmada 의 Cluster 를 생성한다.
+func (r *HubAttachmentReconciler) attachToKarmada(clm *clusterV1alpha1.ClusterManager, hubClient client.Client, remoteClient client.Client) error {
+	server, caData, err := r.getClusterEndpoint(clm)
+	if err != nil {
+		return err
+	}
+
+	tokenSecretName := karmadaServiceAccount + "-token"
+	memberObjs := []client.Object{
+		&coreV1.Namespace{
+			TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "Namespace"},
+			ObjectMeta: metav1.ObjectMeta{Name: karmadaClusterNamespace},
+		},
+		&coreV1.ServiceAccount{
+			TypeMeta: metav1.TypeMeta{APIVersion: "v1", Kind: "ServiceAccount"},
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      karmadaServiceAccount,
+				Namespace: karmadaClusterNamespace,
+			},
+		},
+		&coreV1.Secret{
+			TypeMeta: metav1.TypeMeta{APIVersion: "v1", Kind: "Secret"},
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      tokenSecretName,
+				Namespace: karmadaClusterNamespace,
+				Annotations: map[string]string{
+					coreV1.ServiceAccountNameKey: karmadaServiceAccount,
+				},
+			},
+			Type: coreV1.SecretTypeServiceAccountToken,
+		},
+		&rbacv1.ClusterRole{
+			TypeMeta:   metav1.TypeMeta{APIVersion: rbacv1.SchemeGroupVersion.String(), Kind: "ClusterRole"},
+			ObjectMeta: metav1.ObjectMeta{Name: karmadaServiceAccount},
+			Rules: []rbacv1.PolicyRule{
+				{
+					APIGroups: []string{"*"},
+					Resources: []string{"*"},
+					Verbs:     []string{"*"},
+				},
+				{
+					NonResourceURLs: []string{"*"},
+					Verbs:           []string{"get"},
+				},
+			},
+		},
+		&rbacv1.ClusterRoleBinding{
+			TypeMeta:   metav1.TypeMeta{APIVersion: rbacv1.SchemeGroupVersion.String(), Kind: "ClusterRoleBinding"},
+			ObjectMeta: metav1.ObjectMeta{Name: karmadaServiceAccount},
+			RoleRef: rbacv1.RoleRef{
+				APIGroup: rbacv1.GroupName,
+				Kind:     "ClusterRole",
+				Name:     karmadaServiceAccount,
+			},
+			Subjects: []rbacv1.Subject{
+				{
+					Kind:      rbacv1.ServiceAccountKind,
+					Name:      karmadaServiceAccount,
+					Namespace: karmadaClusterNamespace,
+				},
+			},
+		},
+	}
+	if err := applyObjects(remoteClient, memberObjs); err != nil {
+		return err
+	}
+
+	tokenSecret := &coreV1.Secret{}
+	key := types.NamespacedName{
+		Name:      tokenSecretName,
+		Namespace: karmadaClusterNamespace,
+	}
+	if err := remoteClient.Get(context.TODO(), key, tokenSecret); err != nil {
+		return util.ClassifyRemoteError(err)
+	}
+	if len(tokenSecret.Data[coreV1.ServiceAccountTokenKey]) == 0 {
+		return errHubTokenNotReady
+	}
+
+	hubClusterName := clm.GetHubClusterName()
+	hubObjs := []client.Object{
+		&coreV1.Namespace{
+			TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "Namespace"},
+			ObjectMeta: metav1.ObjectMeta{Name: karmadaClusterNamespace},
+		},
+		&coreV1.Secret{
+			TypeMeta: metav1.TypeMeta{APIVersion: "v1", Kind: "Secret"},
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      hubClusterName,
+				Namespace: karmadaClusterNamespace,
+			},
+			Data: map[string][]byte{
+				"token":    tokenSecret.Data[coreV1.ServiceAccountTokenKey],
+				"caBundle": caData,
+			},
+		},
+	}
+	if err := applyObjects(hubClient, hubObjs); err != nil {
+		return err
+	}
+
+	karmadaCluster := &unstructured.Unstructured{}
+	karmadaCluster.SetAPIVersion(karmadaClusterAPIVersion)
+	karmadaCluster.SetKind("Cluster")
+	karmadaCluster.SetName(hubClusterName)
+	karmadaCluster.SetLabels(map[string]string{
+		clusterV1alpha1.LabelKeyClmName:      clm.Name,
+		clusterV1alpha1.LabelKeyClmNamespace: clm.Namespace,
+	})
+	spec := map[string]interface{}{
+		"syncMode":    "Push",
+		"apiEndpoint": server,
+		"secretRef": map[string]interface{}{
+			"namespace": karmadaClusterNamespace,
+			"name":      hubClusterName,
+		},
+	}
+	if len(caData) == 0 {
+		spec["insecureSkipTLSVerification"] = true
+	}
+	if err := unstructured.SetNestedMap(karmadaCluster.Object, spec, "spec"); err != nil {
+		return err
+	}
+	return applyRemoteObject(hubClient, karmadaCluster)
+}
+
+func (r *HubAttachmentReconciler) detachFromKarmada(clm *clusterV1alpha1.ClusterManager, hubClient client.Client, remoteClient client.Client) error {
+	karmadaCluster := &unstructured.Unstructured{}
+	karmadaCluster.SetAPIVersion(karmadaClusterAPIVersion)
+	karmadaCluster.SetKind("Cluster")
+	karmadaCluster.SetName(clm.GetHubClusterName())
+	hubObjs := []client.Object{
+		karmadaCluster,
+		&coreV1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      clm.GetHubClusterName(),
+				Namespace: karmadaClusterNamespace,
+			},
+		},
+	}
+	if err := deleteObjects(hubClient, hubObjs); err != nil {
+		return err
+	}
+
+	if remoteClient == nil {
+		return nil
+	}
+	// namespace 를 삭제하면 service account 와 token secret 도 함께 삭제된다.
+	memberObjs := []client.Object{
+		&rbacv1.ClusterRoleBinding{ObjectMeta: metav1.ObjectMeta{Name: karmadaServiceAccount}},
+		&rbacv1.ClusterRole{ObjectMeta: metav1.ObjectMeta{Name: karmadaServiceAccount}},
+		&coreV1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: karmadaClusterNamespace}},
+	}
+	if err := deleteObjects(remoteClient, memberObjs); err != nil {
+		r.Log.Info("Failed to clean up karmada resources of member cluster", "reason", err.Error())
+	}
+	return nil
+}
+
+// ocm hub 에 ManagedCluster 를 생성하고, member cluster 에 klusterlet 을 설치한다.
+// hub kubeconfig secret 의 bootstrap 에 klusterlet 이 사용할 bootstrap kubeconfig 를,
+// agent 에 registration operator 의 manifest 를 넣어두면 함께 설치한다.
+func (r *HubAttachmentReconciler) attachToOCM(clm *clusterV1alpha1.ClusterManager, hubSecret *coreV1.Secret,
+	hubClient client.Client, remoteClient client.Client) error {
+	if manifests, ok := hubSecret.Data[ocmHubSecretKeyAgentManifests]; ok {
+		objs, err := decodeManifests(manifests)
+		if err != nil {
+			return err
+		}
+		for _, obj := range objs {
+			if err := applyRemoteObject(remoteClient, obj); err != nil {
+				return err
+			}
+		}
+	}
+
+	bootstrap := hubSecret.Data[ocmHubSecretKeyBootstrap]
+	if len(bootstrap) == 0 {
+		bootstrap = hubSecret.Data["value"]
+	}
+	hubClusterName := clm.GetHubClusterName()
+	memberObjs := []client.Object{
+		&coreV1.Namespace{
+			TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "Namespace"},
+			ObjectMeta: metav1.ObjectMeta{Name: ocmAgentNamespace},
+		},
+		&coreV1.Secret{
+			TypeMeta: metav1.TypeMeta{APIVersion: "v1", Kind: "Secret"},
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      ocmBootstrapSecret,
+				Namespace: ocmAgentNamespace,
+			},
+			Data: map[string][]byte{
+				"kubeconfig": bootstrap,
+			},
+		},
+	}
+	if err := applyObjects(remoteClient, memberObjs); err != nil {
+		return err
+	}
+
+	klusterlet := &unstructured.Unstructured{}
+	klusterlet.SetAPIVersion(ocmKlusterletAPIVersion)
+	klusterlet.SetKind("Klusterlet")
+	klusterlet.SetName(ocmKlusterletName)
+	spec := map[string]interface{}{
+		"clusterName": hubClusterName,
+		"namespace":   ocmAgentNamespace,
+		"deployOption": map[string]interface{}{
+			"mode": "Default",
+		},
+	}
+	if err := unstructured.SetNestedMap(klusterlet.Object, spec, "spec"); err != nil {
+		return err
+	}
+	if err := applyRemoteObject(remoteClient, klusterlet); err != nil {
+		return err
+	}
+
+	// klusterlet 의 csr 승인은 hub 의 설정에 따른다.
+	managedCluster := &unstructured.Unstructured{}
+	managedCluster.SetAPIVersion(ocmManagedClusterAPIVersion)
+	managedCluster.SetKind("ManagedCluster")
+	managedCluster.SetName(hubClusterName)
+	managedCluster.SetLabels(map[string]string{
+		clusterV1alpha1.LabelKeyClmName:      clm.Name,
+		clusterV1alpha1.LabelKeyClmNamespace: clm.Namespace,
+	})
+	if err := unstructured.SetNestedField(managedCluster.Object, true, "spec", "hubAcceptsClient"); err != nil {
+		return err
+	}
+	return applyRemoteObject(hubClient, managedCluster)
+}
+
+func (r *HubAttachmentReconciler) detachFromOCM(clm *clusterV1alpha1.ClusterManager, hubClient client.Client, remoteClient client.Client) error {
+	managedCluster := &unstructured.Unstructured{}
+	managedCluster.SetAPIVersion(ocmManagedClusterAPIVersion)
+	managedCluster.SetKind("ManagedCluster")
+	managedCluster.SetName(clm.GetHubClusterName())
+	if err := deleteObjects(hubClient, []client.Object{managedCluster}); err != nil {
+		return err
+	}
+
+	if remoteClient == nil {
+		return nil
+	}
+	// klusterlet 을 삭제하면 registration operator 가 agent 를 정리한다.
+	klusterlet := &unstructured.Unstructured{}
+	klusterlet.SetAPIVersion(ocmKlusterletAPIVersion)
+	klusterlet.SetKind("Klusterlet")
+	klusterlet.SetName(ocmKlusterletName)
+	if err := deleteObjects(remoteClient, []client.Object{klusterlet}); err != nil {
+		r.Log.Info("Failed to clean up klusterlet of member cluster", "reason", err.Error())
+	}
+	return nil
+}
+
+// kubeconfig secret 으로부터 member cluster 의 api server 주소와 ca 를 가져온다.
+func (r *HubAttachmentReconciler) getClusterEndpoint(clm *clusterV1alpha1.ClusterManager) (string, []byte, error) {
+	kubeconfigSecret, err := util.GetKubeconfigSecret(context.TODO(), r.Client, clm.Namespace, clm.Name)
+	if err != nil {
+		return "", nil, err
+	}
+
+	kubeConfig, err := clientcmd.Load(kubeconfigSecret.Data["value"])
+	if err != nil {
+		return "", nil, err
+	}
+	kubeContext, ok := kubeConfig.Contexts[kubeConfig.CurrentContext]
+	if !ok {
+		return "", nil, errors.NewBadRequest("kubeconfig does not have current context")
+	}
+	cluster, ok := kubeConfig.Clusters[kubeContext.Cluster]
+	if !ok {
+		return "", nil, errors.NewBadRequest("kubeconfig does not have cluster of current context")
+	}
+	return cluster.Server, cluster.CertificateAuthorityData, nil
+}
+
+func applyObjects(c client.Client, objs []client.Object) error {
+	for _, obj := range objs {
+		u, err := convertToUnstructured(obj)
+		if err != nil {
+			return err
+		}
+		if err := applyRemoteObject(c, u); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// 이미 삭제되었거나 crd 가 없는 경우는 무시한다.
+func deleteObjects(c client.Client, objs []client.Object) error {
+	for _, obj := range objs {
+		err := c.Delete(context.TODO(), obj)
+		if err != nil && !errors.IsNotFound(err) && !meta.IsNoMatchError(err) {
+			return util.ClassifyRemoteError(err)
+		}
+	}
+	return nil
+}
+
+// multi document yaml 을 unstructured 목록으로 변환한다.
+func decodeManifests(data []byte) ([]*unstructured.Unstructured, error) {
+	objs := []*unstructured.Unstructured{}
+	decoder := yaml.NewYAMLOrJSONDecoder(bytes.NewReader(data), 4096)
+	for {
+		obj := &unstructured.Unstructured{}
+		if err := decoder.Decode(&obj.Object); err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+		if len(obj.Object) == 0 {
+			continue
+		}
+		objs = append(objs, obj)
+	}
+	return objs, nil
+}
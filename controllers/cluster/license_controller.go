@@ -0,0 +1,142 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+
+	"github.com/go-logr/logr"
+	clusterV1alpha1 "github.com/tmax-cloud/hypercloud-multi-operator/apis/cluster/v1alpha1"
+	"github.com/tmax-cloud/hypercloud-multi-operator/controllers/pipeline"
+	"github.com/tmax-cloud/hypercloud-multi-operator/controllers/util"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+
+	"sigs.k8s.io/cluster-api/util/patch"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+// LicenseReconciler reconciles a License object
+type LicenseReconciler struct {
+	client.Client
+	Log      logr.Logger
+	Scheme   *runtime.Scheme
+	Recorder record.EventRecorder
+}
+
+// +kubebuilder:rbac:groups=cluster.tmax.io,resources=licenses,verbs=get;list;watch
+// +kubebuilder:rbac:groups=cluster.tmax.io,resources=licenses/status,verbs=get;patch;update
+
+func (r *LicenseReconciler) Reconcile(ctx context.Context, req ctrl.Request) (_ ctrl.Result, reterr error) {
+	_ = context.Background()
+	log := r.Log.WithValues("license", req.NamespacedName)
+
+	// get License
+	license := &clusterV1alpha1.License{}
+	if err := r.Client.Get(context.TODO(), req.NamespacedName, license); errors.IsNotFound(err) {
+		log.Info("License not found. Ignoring since object must be deleted")
+		pipeline.Forget(license, req.NamespacedName)
+		return ctrl.Result{}, nil
+	} else if err != nil {
+		log.Error(err, "Failed to get License")
+		return ctrl.Result{}, err
+	}
+
+	patchHelper, err := patch.NewHelper(license, r.Client)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	defer func() {
+		if err := patchHelper.Patch(context.TODO(), license); err != nil {
+			reterr = err
+		}
+	}()
+
+	// member cluster 에 생성하는 리소스가 없으므로 삭제 시 처리할 것이 없다.
+	if !license.ObjectMeta.DeletionTimestamp.IsZero() {
+		return ctrl.Result{}, nil
+	}
+
+	// Handle normal reconciliation loop.
+	return r.reconcile(context.TODO(), license)
+}
+
+// reconcile handles license reconciliation.
+func (r *LicenseReconciler) reconcile(ctx context.Context, license *clusterV1alpha1.License) (ctrl.Result, error) {
+	phases := []pipeline.Phase[*clusterV1alpha1.License]{
+		// token 의 서명과 만료일을 검증한다.
+		{Name: "VerifyLicense", Run: r.VerifyLicense},
+		// 관리중인 cluster 수를 세어 license 의 최대 cluster 수를 초과했는지 확인한다.
+		{Name: "CheckEntitlement", Run: r.CheckEntitlement},
+	}
+
+	return pipeline.NewRunner[*clusterV1alpha1.License](r.Log, r.Recorder).Run(ctx, license, phases)
+}
+
+func (r *LicenseReconciler) requeueLicensesForClusterManager(o client.Object) []ctrl.Request {
+	log := r.Log.WithValues("License-ObjectMapper", "clusterManagerToLicenses", "ClusterManager", o.GetNamespace()+"/"+o.GetName())
+
+	licenseList := &clusterV1alpha1.LicenseList{}
+	if err := r.Client.List(context.TODO(), licenseList); err != nil {
+		log.Error(err, "Failed to list License")
+		return nil
+	}
+
+	reqs := []ctrl.Request{}
+	for _, license := range licenseList.Items {
+		reqs = append(reqs, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(&license)})
+	}
+	return reqs
+}
+
+func (r *LicenseReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	controller, err := ctrl.NewControllerManagedBy(mgr).
+		For(&clusterV1alpha1.License{}).
+		WithOptions(util.DefaultControllerOptions()).
+		WithEventFilter(util.SpecChangedPredicate()).
+		Build(util.ShardReconciler(mgr.GetClient(), r))
+
+	if err != nil {
+		return err
+	}
+
+	// cluster 가 생성되거나 삭제되면 사용중인 cluster 수를 다시 센다.
+	return controller.Watch(
+		&source.Kind{Type: &clusterV1alpha1.ClusterManager{}},
+		handler.EnqueueRequestsFromMapFunc(r.requeueLicensesForClusterManager),
+		predicate.Funcs{
+			CreateFunc: func(e event.CreateEvent) bool {
+				return true
+			},
+			UpdateFunc: func(e event.UpdateEvent) bool {
+				return e.ObjectOld.GetDeletionTimestamp().IsZero() != e.ObjectNew.GetDeletionTimestamp().IsZero()
+			},
+			DeleteFunc: func(e event.DeleteEvent) bool {
+				return true
+			},
+			GenericFunc: func(e event.GenericEvent) bool {
+				return false
+			},
+		},
+	)
+}
@@ -0,0 +1,145 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	clusterV1alpha1 "github.com/tmax-cloud/hypercloud-multi-operator/apis/cluster/v1alpha1"
+	util "github.com/tmax-cloud/hypercloud-multi-operator/controllers/util"
+
+	coreV1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+func (r *LicenseReconciler) VerifyLicense(ctx context.Context, license *clusterV1alpha1.License) (ctrl.Result, error) {
+	log := r.Log.WithValues("license", license.Name)
+	log.Info("Start to reconcile phase for VerifyLicense")
+
+	license.Status.ObservedGeneration = license.Generation
+	setInvalid := func(reason, message string) {
+		license.Status.Customer = ""
+		license.Status.MaxClusters = 0
+		license.Status.ExpirationTime = nil
+		meta.SetStatusCondition(&license.Status.Conditions, metav1.Condition{
+			Type:    clusterV1alpha1.LicenseConditionValid,
+			Status:  metav1.ConditionFalse,
+			Reason:  reason,
+			Message: message,
+		})
+	}
+
+	publicKey, err := util.GetLicensePublicKey()
+	if err != nil {
+		log.Error(err, "Failed to get license public key")
+		setInvalid(clusterV1alpha1.LicenseReasonPublicKeyNotConfigured, "invalid "+util.LICENSE_PUBLIC_KEY+": "+err.Error())
+		return ctrl.Result{}, nil
+	} else if publicKey == nil {
+		setInvalid(clusterV1alpha1.LicenseReasonPublicKeyNotConfigured, util.LICENSE_PUBLIC_KEY+" is not configured in the operator")
+		return ctrl.Result{}, nil
+	}
+
+	payload, err := util.VerifyLicenseToken(license.Spec.Token, publicKey)
+	if err != nil {
+		log.Info("License token is invalid", "reason", err.Error())
+		r.Recorder.Event(license, coreV1.EventTypeWarning, clusterV1alpha1.LicenseReasonInvalidToken, err.Error())
+		setInvalid(clusterV1alpha1.LicenseReasonInvalidToken, err.Error())
+		return ctrl.Result{}, nil
+	}
+
+	license.Status.Customer = payload.Customer
+	license.Status.MaxClusters = payload.MaxClusters
+	license.Status.ExpirationTime = nil
+	if payload.ExpiresAt != nil {
+		license.Status.ExpirationTime = &metav1.Time{Time: *payload.ExpiresAt}
+	}
+
+	now := metav1.Now()
+	if license.IsExpired(now) {
+		meta.SetStatusCondition(&license.Status.Conditions, metav1.Condition{
+			Type:    clusterV1alpha1.LicenseConditionValid,
+			Status:  metav1.ConditionFalse,
+			Reason:  clusterV1alpha1.LicenseReasonExpired,
+			Message: "license expired at " + license.Status.ExpirationTime.Format(time.RFC3339),
+		})
+		return ctrl.Result{}, nil
+	}
+
+	meta.SetStatusCondition(&license.Status.Conditions, metav1.Condition{
+		Type:    clusterV1alpha1.LicenseConditionValid,
+		Status:  metav1.ConditionTrue,
+		Reason:  clusterV1alpha1.LicenseReasonVerified,
+		Message: fmt.Sprintf("license for %s is verified", payload.Customer),
+	})
+	// 만료되는 시점에 다시 검증한다.
+	if license.Status.ExpirationTime != nil {
+		return ctrl.Result{RequeueAfter: license.Status.ExpirationTime.Sub(now.Time)}, nil
+	}
+	return ctrl.Result{}, nil
+}
+
+func (r *LicenseReconciler) CheckEntitlement(ctx context.Context, license *clusterV1alpha1.License) (ctrl.Result, error) {
+	log := r.Log.WithValues("license", license.Name)
+	log.Info("Start to reconcile phase for CheckEntitlement")
+
+	usedClusters, err := clusterV1alpha1.CountManagedClusters(context.TODO(), r.Client)
+	if err != nil {
+		log.Error(err, "Failed to count ClusterManagers")
+		return ctrl.Result{}, err
+	}
+	license.Status.UsedClusters = usedClusters
+
+	// webhook 과 동일하게 유효한 License 들의 최대 cluster 수를 합산한다.
+	// 이 License 의 status 는 아직 반영되지 않았으므로 목록의 것 대신 갱신한 값을 사용한다.
+	licenseList := &clusterV1alpha1.LicenseList{}
+	if err := r.Client.List(context.TODO(), licenseList); err != nil {
+		log.Error(err, "Failed to list License")
+		return ctrl.Result{}, err
+	}
+	for i := range licenseList.Items {
+		if licenseList.Items[i].Name == license.Name {
+			licenseList.Items[i] = *license
+		}
+	}
+	maxClusters := licenseList.GetLicensedClusters(metav1.Now())
+
+	condition := metav1.Condition{
+		Type:    clusterV1alpha1.LicenseConditionEntitlementExceeded,
+		Status:  metav1.ConditionFalse,
+		Reason:  clusterV1alpha1.LicenseReasonWithinLimit,
+		Message: fmt.Sprintf("%d of %d clusters licensed are in use", usedClusters, maxClusters),
+	}
+	if usedClusters > maxClusters {
+		condition.Status = metav1.ConditionTrue
+		condition.Reason = clusterV1alpha1.LicenseReasonLimitExceeded
+		condition.Message += ", new clusters cannot be claimed or registered until clusters are removed"
+	} else if usedClusters == maxClusters {
+		condition.Status = metav1.ConditionTrue
+		condition.Reason = clusterV1alpha1.LicenseReasonLimitReached
+		condition.Message += ", new clusters cannot be claimed or registered"
+	}
+	if condition.Status == metav1.ConditionTrue &&
+		!meta.IsStatusConditionTrue(license.Status.Conditions, clusterV1alpha1.LicenseConditionEntitlementExceeded) {
+		r.Recorder.Event(license, coreV1.EventTypeWarning, condition.Reason, condition.Message)
+	}
+	meta.SetStatusCondition(&license.Status.Conditions, condition)
+
+	return ctrl.Result{}, nil
+}
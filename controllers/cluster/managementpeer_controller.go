@@ -0,0 +1,108 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+
+	"github.com/go-logr/logr"
+	clusterV1alpha1 "github.com/tmax-cloud/hypercloud-multi-operator/apis/cluster/v1alpha1"
+	"github.com/tmax-cloud/hypercloud-multi-operator/controllers/pipeline"
+	"github.com/tmax-cloud/hypercloud-multi-operator/controllers/util"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+
+	"sigs.k8s.io/cluster-api/util/patch"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// ManagementPeerReconciler reconciles a ManagementPeer object
+// peer management cluster 에 등록된 cluster 목록을 주기적으로 가져와 status 에 기록한다.
+// 가져온 cluster 는 inventory 에 read-only 로 표시되며, 이 management cluster 의 controller 는 관리하지 않는다.
+type ManagementPeerReconciler struct {
+	client.Client
+	Log      logr.Logger
+	Scheme   *runtime.Scheme
+	Recorder record.EventRecorder
+}
+
+// +kubebuilder:rbac:groups=cluster.tmax.io,resources=managementpeers,verbs=create;delete;get;list;patch;update;watch
+// +kubebuilder:rbac:groups=cluster.tmax.io,resources=managementpeers/status,verbs=get;patch;update
+
+func (r *ManagementPeerReconciler) Reconcile(ctx context.Context, req ctrl.Request) (_ ctrl.Result, reterr error) {
+	_ = context.Background()
+	log := r.Log.WithValues("managementpeer", req.NamespacedName)
+
+	// get ManagementPeer
+	peer := &clusterV1alpha1.ManagementPeer{}
+	if err := r.Client.Get(context.TODO(), req.NamespacedName, peer); errors.IsNotFound(err) {
+		log.Info("ManagementPeer not found. Ignoring since object must be deleted")
+		pipeline.Forget(peer, req.NamespacedName)
+		return ctrl.Result{}, nil
+	} else if err != nil {
+		log.Error(err, "Failed to get ManagementPeer")
+		return ctrl.Result{}, err
+	}
+
+	patchHelper, err := patch.NewHelper(peer, r.Client)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	defer func() {
+		r.reconcilePhase(context.TODO(), peer)
+
+		if err := patchHelper.Patch(context.TODO(), peer); err != nil {
+			reterr = err
+		}
+	}()
+
+	// peer 에 생성하는 리소스가 없으므로 삭제 시 처리할 것이 없다.
+	if !peer.ObjectMeta.DeletionTimestamp.IsZero() {
+		return ctrl.Result{}, nil
+	}
+
+	// Handle normal reconciliation loop.
+	return r.reconcile(context.TODO(), peer)
+}
+
+// reconcile handles management peer reconciliation.
+func (r *ManagementPeerReconciler) reconcile(ctx context.Context, peer *clusterV1alpha1.ManagementPeer) (ctrl.Result, error) {
+	phases := []pipeline.Phase[*clusterV1alpha1.ManagementPeer]{
+		// peer management cluster 의 cluster manager 목록을 가져와 status 에 기록한다.
+		{Name: "SyncPeerClusters", Run: r.SyncPeerClusters},
+	}
+
+	return pipeline.NewRunner[*clusterV1alpha1.ManagementPeer](r.Log, r.Recorder).Run(ctx, peer, phases)
+}
+
+func (r *ManagementPeerReconciler) reconcilePhase(_ context.Context, peer *clusterV1alpha1.ManagementPeer) {
+	if peer.Status.Reason != "" {
+		peer.Status.SetTypedPhase(clusterV1alpha1.ManagementPeerPhaseDisconnected)
+		return
+	}
+	peer.Status.SetTypedPhase(clusterV1alpha1.ManagementPeerPhaseConnected)
+}
+
+func (r *ManagementPeerReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&clusterV1alpha1.ManagementPeer{}).
+		WithOptions(util.DefaultControllerOptions()).
+		WithEventFilter(util.SpecChangedPredicate()).
+		Complete(util.ShardReconciler(mgr.GetClient(), r))
+}
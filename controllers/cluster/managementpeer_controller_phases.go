@@ -0,0 +1,122 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	clusterV1alpha1 "github.com/tmax-cloud/hypercloud-multi-operator/apis/cluster/v1alpha1"
+	util "github.com/tmax-cloud/hypercloud-multi-operator/controllers/util"
+
+	coreV1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// peer management cluster 의 cluster 목록을 가져오는 간격
+const managementPeerSyncInterval = time.Minute
+
+func (r *ManagementPeerReconciler) SyncPeerClusters(ctx context.Context, peer *clusterV1alpha1.ManagementPeer) (ctrl.Result, error) {
+	log := r.Log.WithValues("managementpeer", peer.GetNamespacedName())
+	log.Info("Start to reconcile phase for SyncPeerClusters")
+
+	secret := &coreV1.Secret{}
+	key := types.NamespacedName{Name: peer.Spec.KubeconfigSecret, Namespace: peer.Namespace}
+	if err := r.Client.Get(context.TODO(), key, secret); errors.IsNotFound(err) {
+		log.Info("Kubeconfig secret of the peer is not found")
+		peer.Status.Reason = fmt.Sprintf("secret %s is not found", peer.Spec.KubeconfigSecret)
+		return util.RequeueAfterWithJitter(managementPeerSyncInterval), nil
+	} else if err != nil {
+		log.Error(err, "Failed to get kubeconfig secret of the peer")
+		return ctrl.Result{}, err
+	}
+
+	// 마지막으로 가져온 목록은 peer 에 다시 접근할 수 있을 때까지 유지한다.
+	clusters, err := r.listPeerClusters(ctx, secret, peer.Spec.Namespaces)
+	if err != nil {
+		log.Error(err, "Failed to list cluster managers of the peer")
+		peer.Status.Reason = err.Error()
+		return util.RequeueAfterWithJitter(managementPeerSyncInterval), nil
+	}
+
+	now := metav1.Now()
+	peer.Status.Reason = ""
+	peer.Status.Clusters = clusters
+	peer.Status.ClusterCount = len(clusters)
+	peer.Status.LastSyncTime = &now
+	return util.RequeueAfterWithJitter(managementPeerSyncInterval), nil
+}
+
+// peer 의 scheme 을 알 수 없으므로 unstructured 로 조회한 뒤 변환한다.
+func (r *ManagementPeerReconciler) listPeerClusters(ctx context.Context, secret *coreV1.Secret, namespaces []string) ([]clusterV1alpha1.PeerClusterStatus, error) {
+	remoteClient, err := util.GetRemoteK8sRuntimeClient(secret)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(namespaces) == 0 {
+		namespaces = []string{metav1.NamespaceAll}
+	}
+	clusters := []clusterV1alpha1.PeerClusterStatus{}
+	for _, namespace := range namespaces {
+		list := &unstructured.UnstructuredList{}
+		list.SetGroupVersionKind(clusterV1alpha1.GroupVersion.WithKind("ClusterManagerList"))
+		if err := remoteClient.List(ctx, list, client.InNamespace(namespace)); err != nil {
+			return nil, util.ClassifyRemoteError(err)
+		}
+
+		for _, item := range list.Items {
+			clm := &clusterV1alpha1.ClusterManager{}
+			if err := runtime.DefaultUnstructuredConverter.FromUnstructured(item.Object, clm); err != nil {
+				return nil, err
+			}
+			// 삭제중인 cluster 는 가져오지 않는다.
+			if !clm.DeletionTimestamp.IsZero() {
+				continue
+			}
+			// 등록된 cluster 는 spec 에 version 이 없으므로 status 의 version 을 우선한다.
+			version := clm.Status.Version
+			if version == "" {
+				version = clm.Spec.Version
+			}
+			clusters = append(clusters, clusterV1alpha1.PeerClusterStatus{
+				Name:                 clm.Name,
+				Namespace:            clm.Namespace,
+				Type:                 clm.GetClusterType(),
+				Provider:             clm.Spec.Provider,
+				Version:              version,
+				Phase:                clm.Status.Phase,
+				Ready:                clm.Status.Ready,
+				ControlPlaneEndpoint: clm.Status.ControlPlaneEndpoint,
+			})
+		}
+	}
+	sort.Slice(clusters, func(i, j int) bool {
+		if clusters[i].Namespace != clusters[j].Namespace {
+			return clusters[i].Namespace < clusters[j].Namespace
+		}
+		return clusters[i].Name < clusters[j].Name
+	})
+	return clusters, nil
+}
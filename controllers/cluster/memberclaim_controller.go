@@ -0,0 +1,160 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+
+	"github.com/go-logr/logr"
+	clusterV1alpha1 "github.com/tmax-cloud/hypercloud-multi-operator/apis/cluster/v1alpha1"
+	"github.com/tmax-cloud/hypercloud-multi-operator/controllers/pipeline"
+	"github.com/tmax-cloud/hypercloud-multi-operator/controllers/util"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+
+	"sigs.k8s.io/cluster-api/util/patch"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+// MemberClaimReconciler reconciles a MemberClaim object
+type MemberClaimReconciler struct {
+	client.Client
+	Log      logr.Logger
+	Scheme   *runtime.Scheme
+	Recorder record.EventRecorder
+}
+
+// +kubebuilder:rbac:groups=cluster.tmax.io,resources=memberclaims,verbs=get;list;patch;update;watch
+// +kubebuilder:rbac:groups=cluster.tmax.io,resources=memberclaims/status,verbs=get;patch;update
+
+func (r *MemberClaimReconciler) Reconcile(ctx context.Context, req ctrl.Request) (_ ctrl.Result, reterr error) {
+	_ = context.Background()
+	log := r.Log.WithValues("memberclaim", req.NamespacedName)
+
+	// get MemberClaim
+	memberClaim := &clusterV1alpha1.MemberClaim{}
+	if err := r.Client.Get(context.TODO(), req.NamespacedName, memberClaim); errors.IsNotFound(err) {
+		log.Info("MemberClaim not found. Ignoring since object must be deleted")
+		pipeline.Forget(memberClaim, req.NamespacedName)
+		return ctrl.Result{}, nil
+	} else if err != nil {
+		log.Error(err, "Failed to get MemberClaim")
+		return ctrl.Result{}, err
+	}
+
+	patchHelper, err := patch.NewHelper(memberClaim, r.Client)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	defer func() {
+		if err := patchHelper.Patch(context.TODO(), memberClaim); err != nil {
+			reterr = err
+		}
+	}()
+
+	// 승인되어 생성된 ClusterMember 는 claim 과 별개로 관리하므로, claim 을 삭제해도 권한은 유지된다.
+	if !memberClaim.ObjectMeta.DeletionTimestamp.IsZero() {
+		return ctrl.Result{}, nil
+	}
+
+	// Handle normal reconciliation loop.
+	return r.reconcile(context.TODO(), memberClaim)
+}
+
+// reconcile handles member claim reconciliation.
+func (r *MemberClaimReconciler) reconcile(ctx context.Context, memberClaim *clusterV1alpha1.MemberClaim) (ctrl.Result, error) {
+	phases := []pipeline.Phase[*clusterV1alpha1.MemberClaim]{
+		// 권한을 요청한 cluster manager 가 존재하는지 확인하고, cluster manager 에 대한 label 을 달아준다.
+		{Name: "CheckClusterManager", Run: r.CheckClusterManager},
+		// owner 가 승인한 claim 에 대해 수락된 ClusterMember 를 생성한다.
+		// db 저장과 remote cluster 의 rolebinding 생성은 ClusterMember controller 가 수행한다.
+		{Name: "CreateClusterMember", Run: r.CreateClusterMember},
+		// ClusterMember 의 권한 부여가 완료되면 Granted 로 변경한다.
+		{Name: "CheckClusterMember", Run: r.CheckClusterMember},
+	}
+
+	return pipeline.NewRunner[*clusterV1alpha1.MemberClaim](r.Log, r.Recorder).Run(ctx, memberClaim, phases)
+}
+
+func (r *MemberClaimReconciler) requeueMemberClaimsForClusterMember(o client.Object) []ctrl.Request {
+	name := o.GetLabels()[clusterV1alpha1.LabelKeyMemberClaimName]
+	if name == "" {
+		return nil
+	}
+	return []ctrl.Request{
+		{NamespacedName: types.NamespacedName{Name: name, Namespace: o.GetNamespace()}},
+	}
+}
+
+func (r *MemberClaimReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	controller, err := ctrl.NewControllerManagedBy(mgr).
+		For(&clusterV1alpha1.MemberClaim{}).
+		WithOptions(util.DefaultControllerOptions()).
+		WithEventFilter(
+			predicate.Funcs{
+				CreateFunc: func(e event.CreateEvent) bool {
+					return true
+				},
+				UpdateFunc: func(e event.UpdateEvent) bool {
+					oldMcl := e.ObjectOld.(*clusterV1alpha1.MemberClaim)
+					newMcl := e.ObjectNew.(*clusterV1alpha1.MemberClaim)
+					// 승인, 거절은 status.phase 를 변경하는 방식이므로 phase 변경은 통과시킨다.
+					return util.IsSpecChanged(oldMcl, newMcl) || oldMcl.Status.Phase != newMcl.Status.Phase
+				},
+				DeleteFunc: func(e event.DeleteEvent) bool {
+					return false
+				},
+				GenericFunc: func(e event.GenericEvent) bool {
+					return false
+				},
+			},
+		).
+		Build(util.ShardReconciler(mgr.GetClient(), r))
+
+	if err != nil {
+		return err
+	}
+
+	// ClusterMember 의 phase 가 바뀌면 claim 의 phase 를 갱신한다.
+	return controller.Watch(
+		&source.Kind{Type: &clusterV1alpha1.ClusterMember{}},
+		handler.EnqueueRequestsFromMapFunc(r.requeueMemberClaimsForClusterMember),
+		predicate.Funcs{
+			UpdateFunc: func(e event.UpdateEvent) bool {
+				oldCm := e.ObjectOld.(*clusterV1alpha1.ClusterMember)
+				newCm := e.ObjectNew.(*clusterV1alpha1.ClusterMember)
+				return oldCm.Status.Phase != newCm.Status.Phase
+			},
+			CreateFunc: func(e event.CreateEvent) bool {
+				return false
+			},
+			DeleteFunc: func(e event.DeleteEvent) bool {
+				return false
+			},
+			GenericFunc: func(e event.GenericEvent) bool {
+				return false
+			},
+		},
+	)
+}
@@ -0,0 +1,158 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+
+	clusterV1alpha1 "github.com/tmax-cloud/hypercloud-multi-operator/apis/cluster/v1alpha1"
+
+	coreV1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func (r *MemberClaimReconciler) CheckClusterManager(ctx context.Context, memberClaim *clusterV1alpha1.MemberClaim) (ctrl.Result, error) {
+	log := r.Log.WithValues("memberclaim", memberClaim.GetNamespacedName())
+	log.Info("Start to reconcile phase for CheckClusterManager")
+
+	if memberClaim.Status.Phase == "" {
+		memberClaim.Status.SetTypedPhase(clusterV1alpha1.MemberClaimPhaseAwaiting)
+	}
+
+	clm := &clusterV1alpha1.ClusterManager{}
+	if err := r.Client.Get(context.TODO(), memberClaim.GetClusterManagerNamespacedName(), clm); errors.IsNotFound(err) {
+		log.Info("ClusterManager not found", "clusterManager", memberClaim.Spec.ClusterName)
+		memberClaim.Status.SetTypedPhase(clusterV1alpha1.MemberClaimPhaseError)
+		memberClaim.Status.Reason = "cluster not found"
+		return ctrl.Result{}, nil
+	} else if err != nil {
+		log.Error(err, "Failed to get ClusterManager")
+		return ctrl.Result{}, err
+	}
+
+	if memberClaim.Labels == nil {
+		memberClaim.Labels = map[string]string{}
+	}
+	memberClaim.Labels[clusterV1alpha1.LabelKeyClmName] = clm.Name
+
+	return ctrl.Result{}, nil
+}
+
+func (r *MemberClaimReconciler) CreateClusterMember(ctx context.Context, memberClaim *clusterV1alpha1.MemberClaim) (ctrl.Result, error) {
+	if memberClaim.Status.Phase != clusterV1alpha1.MemberClaimPhaseApproved || memberClaim.Status.ClusterMemberName != "" {
+		return ctrl.Result{}, nil
+	}
+	log := r.Log.WithValues("memberclaim", memberClaim.GetNamespacedName())
+	log.Info("Start to reconcile phase for CreateClusterMember")
+
+	// 이미 멤버인 경우 새로 만들지 않고 요청한 role 로 변경한다.
+	clusterMemberList := &clusterV1alpha1.ClusterMemberList{}
+	if err := r.Client.List(context.TODO(), clusterMemberList, client.InNamespace(memberClaim.Namespace)); err != nil {
+		log.Error(err, "Failed to list ClusterMember")
+		return ctrl.Result{}, err
+	}
+	for i := range clusterMemberList.Items {
+		clusterMember := &clusterMemberList.Items[i]
+		if clusterMember.Spec.ClusterName != memberClaim.Spec.ClusterName ||
+			clusterMember.Spec.MemberId != memberClaim.Spec.Requester ||
+			clusterMember.Spec.Attribute != clusterV1alpha1.ClusterMemberAttributeUser {
+			continue
+		}
+
+		clusterMember.Spec.Role = memberClaim.Spec.Role
+		clusterMember.Spec.Accepted = true
+		if clusterMember.Labels == nil {
+			clusterMember.Labels = map[string]string{}
+		}
+		clusterMember.Labels[clusterV1alpha1.LabelKeyMemberClaimName] = memberClaim.Name
+		if err := r.Client.Update(context.TODO(), clusterMember); err != nil {
+			log.Error(err, "Failed to update ClusterMember")
+			return ctrl.Result{}, err
+		}
+		log.Info("Update existing ClusterMember successfully", "clusterMember", clusterMember.Name)
+		memberClaim.Status.ClusterMemberName = clusterMember.Name
+		return ctrl.Result{}, nil
+	}
+
+	// owner 의 승인이 요청한 사용자의 수락을 겸하므로 수락된 상태로 생성한다.
+	clusterMember := &clusterV1alpha1.ClusterMember{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      memberClaim.Name,
+			Namespace: memberClaim.Namespace,
+			Labels: map[string]string{
+				clusterV1alpha1.LabelKeyClmName:         memberClaim.Spec.ClusterName,
+				clusterV1alpha1.LabelKeyMemberClaimName: memberClaim.Name,
+			},
+		},
+		Spec: clusterV1alpha1.ClusterMemberSpec{
+			ClusterName: memberClaim.Spec.ClusterName,
+			MemberId:    memberClaim.Spec.Requester,
+			Attribute:   clusterV1alpha1.ClusterMemberAttributeUser,
+			Role:        memberClaim.Spec.Role,
+			Accepted:    true,
+		},
+	}
+	if err := r.Client.Create(context.TODO(), clusterMember); errors.IsAlreadyExists(err) {
+		log.Info("ClusterMember with the same name already exists for another member", "clusterMember", clusterMember.Name)
+		memberClaim.Status.SetTypedPhase(clusterV1alpha1.MemberClaimPhaseError)
+		memberClaim.Status.Reason = fmt.Sprintf("ClusterMember %s already exists for another member", clusterMember.Name)
+		return ctrl.Result{}, nil
+	} else if err != nil {
+		log.Error(err, "Failed to create ClusterMember")
+		return ctrl.Result{}, err
+	}
+	log.Info("Create ClusterMember successfully", "clusterMember", clusterMember.Name)
+	memberClaim.Status.ClusterMemberName = clusterMember.Name
+
+	return ctrl.Result{}, nil
+}
+
+func (r *MemberClaimReconciler) CheckClusterMember(ctx context.Context, memberClaim *clusterV1alpha1.MemberClaim) (ctrl.Result, error) {
+	if memberClaim.Status.ClusterMemberName == "" ||
+		memberClaim.Status.Phase == clusterV1alpha1.MemberClaimPhaseGranted {
+		return ctrl.Result{}, nil
+	}
+	log := r.Log.WithValues("memberclaim", memberClaim.GetNamespacedName())
+	log.Info("Start to reconcile phase for CheckClusterMember")
+
+	key := types.NamespacedName{Name: memberClaim.Status.ClusterMemberName, Namespace: memberClaim.Namespace}
+	clusterMember := &clusterV1alpha1.ClusterMember{}
+	if err := r.Client.Get(context.TODO(), key, clusterMember); errors.IsNotFound(err) {
+		// 권한이 부여되기 전에 ClusterMember 가 삭제된 경우 다시 생성한다.
+		memberClaim.Status.ClusterMemberName = ""
+		return ctrl.Result{Requeue: true}, nil
+	} else if err != nil {
+		log.Error(err, "Failed to get ClusterMember")
+		return ctrl.Result{}, err
+	}
+
+	switch clusterMember.Status.Phase {
+	case clusterV1alpha1.ClusterMemberPhaseAccepted:
+		memberClaim.Status.SetTypedPhase(clusterV1alpha1.MemberClaimPhaseGranted)
+		memberClaim.Status.Reason = ""
+		r.Recorder.Eventf(memberClaim, coreV1.EventTypeNormal, string(clusterV1alpha1.MemberClaimPhaseGranted),
+			"%s is granted %s role in cluster %s", memberClaim.Spec.Requester, memberClaim.Spec.Role, memberClaim.Spec.ClusterName)
+	case clusterV1alpha1.ClusterMemberPhaseError:
+		memberClaim.Status.Reason = clusterMember.Status.Reason
+	}
+
+	return ctrl.Result{}, nil
+}
@@ -0,0 +1,234 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+
+	"github.com/go-logr/logr"
+	clusterV1alpha1 "github.com/tmax-cloud/hypercloud-multi-operator/apis/cluster/v1alpha1"
+	"github.com/tmax-cloud/hypercloud-multi-operator/controllers/pipeline"
+	"github.com/tmax-cloud/hypercloud-multi-operator/controllers/util"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+
+	"sigs.k8s.io/cluster-api/util/patch"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+// MeshFederationReconciler reconciles a MeshFederation object
+type MeshFederationReconciler struct {
+	client.Client
+	Log      logr.Logger
+	Scheme   *runtime.Scheme
+	Recorder record.EventRecorder
+}
+
+// +kubebuilder:rbac:groups=cluster.tmax.io,resources=meshfederations,verbs=create;delete;get;list;patch;update;watch
+// +kubebuilder:rbac:groups=cluster.tmax.io,resources=meshfederations/status,verbs=get;patch;update
+
+func (r *MeshFederationReconciler) Reconcile(ctx context.Context, req ctrl.Request) (_ ctrl.Result, reterr error) {
+	_ = context.Background()
+	log := r.Log.WithValues("meshfederation", req.NamespacedName)
+
+	// get MeshFederation
+	mfd := &clusterV1alpha1.MeshFederation{}
+	if err := r.Client.Get(context.TODO(), req.NamespacedName, mfd); errors.IsNotFound(err) {
+		log.Info("MeshFederation not found. Ignoring since object must be deleted")
+		pipeline.Forget(mfd, req.NamespacedName)
+		return ctrl.Result{}, nil
+	} else if err != nil {
+		log.Error(err, "Failed to get MeshFederation")
+		return ctrl.Result{}, err
+	}
+
+	patchHelper, err := patch.NewHelper(mfd, r.Client)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	defer func() {
+		r.reconcilePhase(context.TODO(), mfd)
+
+		if err := patchHelper.Patch(context.TODO(), mfd); err != nil {
+			reterr = err
+		}
+	}()
+
+	// Add finalizer first if not exist to avoid the race condition between init and delete
+	if !controllerutil.ContainsFinalizer(mfd, clusterV1alpha1.MeshFederationFinalizer) {
+		controllerutil.AddFinalizer(mfd, clusterV1alpha1.MeshFederationFinalizer)
+		return ctrl.Result{}, nil
+	}
+
+	// Handle deletion reconciliation loop.
+	if !mfd.ObjectMeta.DeletionTimestamp.IsZero() {
+		return r.reconcileDelete(context.TODO(), mfd)
+	}
+
+	// Handle normal reconciliation loop.
+	return r.reconcile(context.TODO(), mfd)
+}
+
+// reconcile handles mesh federation reconciliation.
+func (r *MeshFederationReconciler) reconcile(ctx context.Context, mfd *clusterV1alpha1.MeshFederation) (ctrl.Result, error) {
+	phases := []pipeline.Phase[*clusterV1alpha1.MeshFederation]{
+		// cluster group 에서 제외된 cluster 를 mesh 에서 분리한다.
+		{Name: "PruneUnselectedClusters", Run: r.PruneUnselectedClusters},
+		// cluster 들이 공유할 mesh 의 root CA 를 생성한다.
+		{Name: "EnsureRootCA", Run: r.EnsureRootCA},
+		// cluster 마다 istio 를 multi-primary 로 설치하고, intermediate CA 와 east-west gateway,
+		// 다른 cluster 의 kubeconfig 로 만든 remote secret 을 배포한다.
+		{Name: "FederateClusters", Run: r.FederateClusters},
+	}
+
+	return pipeline.NewRunner[*clusterV1alpha1.MeshFederation](r.Log, r.Recorder).Run(ctx, mfd, phases)
+}
+
+func (r *MeshFederationReconciler) reconcileDelete(ctx context.Context, mfd *clusterV1alpha1.MeshFederation) (ctrl.Result, error) {
+	log := r.Log.WithValues("meshfederation", mfd.GetNamespacedName())
+	log.Info("Start to reconcile delete for MeshFederation")
+
+	// root CA secret 은 owner reference 로 함께 삭제된다.
+	remains := []clusterV1alpha1.MeshFederationClusterStatus{}
+	for _, clusterStatus := range mfd.Status.Clusters {
+		if err := r.leaveMesh(mfd.Namespace, &clusterStatus); err != nil {
+			log.Error(err, "Failed to detach cluster from mesh", "cluster", clusterStatus.Name)
+			remains = append(remains, clusterStatus)
+		}
+	}
+	mfd.Status.Clusters = remains
+	if len(remains) > 0 {
+		return ctrl.Result{Requeue: true}, nil
+	}
+
+	controllerutil.RemoveFinalizer(mfd, clusterV1alpha1.MeshFederationFinalizer)
+	log.Info("MeshFederation is removed successfully")
+	return ctrl.Result{}, nil
+}
+
+func (r *MeshFederationReconciler) reconcilePhase(_ context.Context, mfd *clusterV1alpha1.MeshFederation) {
+	if !mfd.DeletionTimestamp.IsZero() {
+		mfd.Status.SetTypedPhase(clusterV1alpha1.MeshFederationPhaseDeleting)
+		return
+	}
+
+	for _, clusterStatus := range mfd.Status.Clusters {
+		if !clusterStatus.Federated {
+			mfd.Status.SetTypedPhase(clusterV1alpha1.MeshFederationPhaseProgressing)
+			return
+		}
+	}
+	mfd.Status.SetTypedPhase(clusterV1alpha1.MeshFederationPhaseFederated)
+}
+
+func (r *MeshFederationReconciler) requeueMeshFederationsForClusterManager(o client.Object) []ctrl.Request {
+	log := r.Log.WithValues("MeshFederation-ObjectMapper", "clusterManagerToMeshFederations", "ClusterManager", o.GetNamespace()+"/"+o.GetName())
+	return r.requeueMeshFederationsInNamespace(log, o.GetNamespace(), "")
+}
+
+func (r *MeshFederationReconciler) requeueMeshFederationsForClusterGroup(o client.Object) []ctrl.Request {
+	log := r.Log.WithValues("MeshFederation-ObjectMapper", "clusterGroupToMeshFederations", "ClusterGroup", o.GetNamespace()+"/"+o.GetName())
+	return r.requeueMeshFederationsInNamespace(log, o.GetNamespace(), o.GetName())
+}
+
+// clusterGroup 이 비어있으면 group 의 selector 까지 확인하지 않고, 같은 namespace 의 federation 을 모두 requeue 한다.
+func (r *MeshFederationReconciler) requeueMeshFederationsInNamespace(log logr.Logger, namespace, clusterGroup string) []ctrl.Request {
+	mfdList := &clusterV1alpha1.MeshFederationList{}
+	if err := r.Client.List(context.TODO(), mfdList, client.InNamespace(namespace)); err != nil {
+		log.Error(err, "Failed to list MeshFederation")
+		return nil
+	}
+
+	reqs := []ctrl.Request{}
+	for _, mfd := range mfdList.Items {
+		if clusterGroup != "" && mfd.Spec.ClusterGroup != clusterGroup {
+			continue
+		}
+		reqs = append(reqs, ctrl.Request{NamespacedName: mfd.GetNamespacedName()})
+	}
+	return reqs
+}
+
+func (r *MeshFederationReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	controller, err := ctrl.NewControllerManagedBy(mgr).
+		For(&clusterV1alpha1.MeshFederation{}).
+		WithOptions(util.DefaultControllerOptions()).
+		WithEventFilter(util.SpecChangedPredicate()).
+		Build(util.ShardReconciler(mgr.GetClient(), r))
+
+	if err != nil {
+		return err
+	}
+
+	// cluster group 의 selector 가 변경되면 group 의 cluster 가 바뀌므로 다시 연결한다.
+	err = controller.Watch(
+		&source.Kind{Type: &clusterV1alpha1.ClusterGroup{}},
+		handler.EnqueueRequestsFromMapFunc(r.requeueMeshFederationsForClusterGroup),
+		predicate.Funcs{
+			CreateFunc: func(e event.CreateEvent) bool {
+				return true
+			},
+			UpdateFunc: func(e event.UpdateEvent) bool {
+				return e.ObjectOld.GetGeneration() != e.ObjectNew.GetGeneration()
+			},
+			DeleteFunc: func(e event.DeleteEvent) bool {
+				return true
+			},
+			GenericFunc: func(e event.GenericEvent) bool {
+				return false
+			},
+		},
+	)
+	if err != nil {
+		return err
+	}
+
+	// cluster 의 label 이 변경되거나 control plane, argocd application 이 준비되면 다시 연결한다.
+	return controller.Watch(
+		&source.Kind{Type: &clusterV1alpha1.ClusterManager{}},
+		handler.EnqueueRequestsFromMapFunc(r.requeueMeshFederationsForClusterManager),
+		predicate.Funcs{
+			CreateFunc: func(e event.CreateEvent) bool {
+				return false
+			},
+			UpdateFunc: func(e event.UpdateEvent) bool {
+				oldClm := e.ObjectOld.(*clusterV1alpha1.ClusterManager)
+				newClm := e.ObjectNew.(*clusterV1alpha1.ClusterManager)
+				if !labels.Equals(oldClm.Labels, newClm.Labels) ||
+					oldClm.Status.ControlPlaneReady != newClm.Status.ControlPlaneReady ||
+					oldClm.Status.ArgoReady != newClm.Status.ArgoReady {
+					return true
+				}
+				return false
+			},
+			DeleteFunc: func(e event.DeleteEvent) bool {
+				return true
+			},
+			GenericFunc: func(e event.GenericEvent) bool {
+				return false
+			},
+		},
+	)
+}
@@ -0,0 +1,455 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	clusterV1alpha1 "github.com/tmax-cloud/hypercloud-multi-operator/apis/cluster/v1alpha1"
+	util "github.com/tmax-cloud/hypercloud-multi-operator/controllers/util"
+
+	appsv1 "k8s.io/api/apps/v1"
+	coreV1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+const (
+	istioNamespace = "istio-system"
+	// istiod 가 plugin CA 로 사용하는 secret
+	istioCACertsSecret = "cacerts"
+	// cluster 사이의 트래픽을 받는 east-west gateway 의 Gateway 리소스
+	istioCrossNetworkGateway      = "cross-network-gateway"
+	istioRemoteSecretPrefix       = "istio-remote-secret-"
+	istioLabelKeyNetwork          = "topology.istio.io/network"
+	istioLabelKeyMultiCluster     = "istio/multiCluster"
+	istioAnnotationKeyCluster     = "networking.istio.io/cluster"
+	istioAnnotationKeyRestart     = "meshfederation.cluster.tmax.io/restartedAt"
+	argoParameterMeshMultiCluster = "modules.serviceMesh.multiCluster"
+)
+
+func (r *MeshFederationReconciler) PruneUnselectedClusters(ctx context.Context, mfd *clusterV1alpha1.MeshFederation) (ctrl.Result, error) {
+	log := r.Log.WithValues("meshfederation", mfd.GetNamespacedName())
+	log.Info("Start to reconcile phase for PruneUnselectedClusters")
+
+	clmList, err := r.selectClusters(mfd)
+	if err != nil {
+		log.Error(err, "Failed to select ClusterManagers")
+		return ctrl.Result{}, err
+	}
+	selected := map[string]bool{}
+	for _, clm := range clmList {
+		selected[clm.Name] = true
+	}
+
+	clusters := []clusterV1alpha1.MeshFederationClusterStatus{}
+	for _, clusterStatus := range mfd.Status.Clusters {
+		if selected[clusterStatus.Name] {
+			clusters = append(clusters, clusterStatus)
+			continue
+		}
+		if err := r.leaveMesh(mfd.Namespace, &clusterStatus); err != nil {
+			log.Error(err, "Failed to detach unselected cluster from mesh", "cluster", clusterStatus.Name)
+			clusterStatus.Federated = false
+			clusterStatus.Reason = "Failed to detach from mesh: " + err.Error()
+			clusters = append(clusters, clusterStatus)
+			continue
+		}
+		log.Info("Detached unselected cluster from mesh successfully", "cluster", clusterStatus.Name)
+	}
+	mfd.Status.Clusters = clusters
+
+	return ctrl.Result{}, nil
+}
+
+func (r *MeshFederationReconciler) EnsureRootCA(ctx context.Context, mfd *clusterV1alpha1.MeshFederation) (ctrl.Result, error) {
+	log := r.Log.WithValues("meshfederation", mfd.GetNamespacedName())
+
+	key := types.NamespacedName{
+		Name:      mfd.GetRootCASecretName(),
+		Namespace: mfd.Namespace,
+	}
+	if err := r.Client.Get(context.TODO(), key, &coreV1.Secret{}); err == nil {
+		mfd.Status.RootCASecret = key.Name
+		return ctrl.Result{}, nil
+	} else if !errors.IsNotFound(err) {
+		log.Error(err, "Failed to get root CA secret")
+		return ctrl.Result{}, err
+	}
+
+	log.Info("Start to reconcile phase for EnsureRootCA")
+	certPEM, keyPEM, err := util.NewMeshRootCA(mfd.GetMeshID())
+	if err != nil {
+		log.Error(err, "Failed to generate root CA")
+		return ctrl.Result{}, err
+	}
+	secret := &coreV1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      key.Name,
+			Namespace: key.Namespace,
+		},
+		Type: coreV1.SecretTypeTLS,
+		Data: map[string][]byte{
+			coreV1.TLSCertKey:       certPEM,
+			coreV1.TLSPrivateKeyKey: keyPEM,
+		},
+	}
+	if err := controllerutil.SetControllerReference(mfd, secret, r.Scheme); err != nil {
+		return ctrl.Result{}, err
+	}
+	if err := r.Client.Create(context.TODO(), secret); err != nil {
+		log.Error(err, "Failed to create root CA secret")
+		return ctrl.Result{}, err
+	}
+
+	mfd.Status.RootCASecret = key.Name
+	log.Info("Created root CA of mesh successfully")
+	return ctrl.Result{}, nil
+}
+
+func (r *MeshFederationReconciler) FederateClusters(ctx context.Context, mfd *clusterV1alpha1.MeshFederation) (ctrl.Result, error) {
+	log := r.Log.WithValues("meshfederation", mfd.GetNamespacedName())
+	log.Info("Start to reconcile phase for FederateClusters")
+
+	rootCA := &coreV1.Secret{}
+	key := types.NamespacedName{
+		Name:      mfd.GetRootCASecretName(),
+		Namespace: mfd.Namespace,
+	}
+	if err := r.Client.Get(context.TODO(), key, rootCA); err != nil {
+		log.Error(err, "Failed to get root CA secret")
+		return ctrl.Result{}, err
+	}
+
+	clmList, err := r.selectClusters(mfd)
+	if err != nil {
+		log.Error(err, "Failed to select ClusterManagers")
+		return ctrl.Result{}, err
+	}
+
+	// control plane 이 준비된 cluster 의 kubeconfig 로 다른 cluster 에 remote secret 을 만든다.
+	kubeconfigs := map[string][]byte{}
+	for _, clm := range clmList {
+		if !clm.Status.ControlPlaneReady {
+			continue
+		}
+		kubeconfigSecret, err := util.GetKubeconfigSecret(context.TODO(), r.Client, clm.Namespace, clm.Name)
+		if err != nil {
+			continue
+		}
+		kubeconfigs[clm.Name] = kubeconfigSecret.Data["value"]
+	}
+
+	allFederated := true
+	for _, clm := range clmList {
+		clusterStatus := mfd.Status.GetClusterStatus(clm.Name)
+		if clusterStatus == nil {
+			mfd.Status.Clusters = append(mfd.Status.Clusters, clusterV1alpha1.MeshFederationClusterStatus{Name: clm.Name})
+			clusterStatus = &mfd.Status.Clusters[len(mfd.Status.Clusters)-1]
+		}
+
+		// control plane 과 argocd application 이 준비되면 cluster manager watch 에 의해 다시 reconcile 된다.
+		if _, ok := kubeconfigs[clm.Name]; !ok {
+			clusterStatus.Federated = false
+			clusterStatus.Reason = "Wait for control plane to be ready"
+			continue
+		}
+		if !clm.Status.ArgoReady {
+			clusterStatus.Federated = false
+			clusterStatus.Reason = "Wait for argocd application to be ready"
+			continue
+		}
+
+		if err := r.federateCluster(mfd, &clm, rootCA, kubeconfigs, clusterStatus); err != nil {
+			log.Error(err, "Failed to federate cluster", "cluster", clm.Name)
+			clusterStatus.Federated = false
+			clusterStatus.Reason = err.Error()
+			allFederated = false
+			continue
+		}
+		clusterStatus.Federated = true
+		clusterStatus.Reason = ""
+		clusterStatus.LastFederatedTime = metav1.Now()
+	}
+
+	sort.Slice(mfd.Status.Clusters, func(i, j int) bool {
+		return mfd.Status.Clusters[i].Name < mfd.Status.Clusters[j].Name
+	})
+	mfd.Status.ObservedGeneration = mfd.Generation
+
+	if !allFederated {
+		return ctrl.Result{Requeue: true}, nil
+	}
+	// kubeconfig 가 갱신되면 다른 cluster 의 remote secret 에도 반영한다.
+	return util.RequeueAfterWithJitter(getResyncPeriod()), nil
+}
+
+// cluster 를 mesh 에 연결한다.
+// istio 는 argocd application 의 service mesh module 로 설치되며, 설치가 끝나기 전에는 Gateway crd 가 없어 실패한다.
+func (r *MeshFederationReconciler) federateCluster(mfd *clusterV1alpha1.MeshFederation, clm *clusterV1alpha1.ClusterManager,
+	rootCA *coreV1.Secret, kubeconfigs map[string][]byte, clusterStatus *clusterV1alpha1.MeshFederationClusterStatus) error {
+	if err := setApplicationHelmParameters(r.Client, clm, meshApplicationParameters(mfd, clm.Name)); err != nil {
+		return err
+	}
+
+	remoteClient, err := getRemoteRuntimeClient(r.Client, clm)
+	if err != nil {
+		return err
+	}
+
+	// multi-network 구성에서 istio 는 namespace 의 network label 로 endpoint 의 network 를 구분한다.
+	namespace := &unstructured.Unstructured{}
+	namespace.SetAPIVersion("v1")
+	namespace.SetKind("Namespace")
+	namespace.SetName(istioNamespace)
+	namespace.SetLabels(map[string]string{istioLabelKeyNetwork: meshNetworkName(clm.Name)})
+	if err := applyRemoteObject(remoteClient, namespace); err != nil {
+		return err
+	}
+
+	if err := ensureIstioCACerts(remoteClient, mfd, rootCA, clm.Name); err != nil {
+		return err
+	}
+
+	objs, peers, err := buildMeshFederationObjects(mfd, clm.Name, kubeconfigs)
+	if err != nil {
+		return err
+	}
+	applied, err := applyManifestResources(remoteClient, objs, clusterStatus.Resources)
+	if meta.IsNoMatchError(err) {
+		return fmt.Errorf("istio is not installed yet")
+	} else if err != nil {
+		return err
+	}
+	clusterStatus.Resources = applied
+	clusterStatus.Peers = peers
+	return nil
+}
+
+// cluster 를 mesh 에서 분리한다.
+// cacerts 는 workload 인증서의 발급자이므로 삭제하지 않는다.
+func (r *MeshFederationReconciler) leaveMesh(namespace string, clusterStatus *clusterV1alpha1.MeshFederationClusterStatus) error {
+	clm := &clusterV1alpha1.ClusterManager{}
+	key := types.NamespacedName{
+		Name:      clusterStatus.Name,
+		Namespace: namespace,
+	}
+	if err := r.Client.Get(context.TODO(), key, clm); errors.IsNotFound(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	if err := setApplicationHelmParameters(r.Client, clm, map[string]string{
+		argoParameterMeshMultiCluster + ".enabled": "false",
+	}); err != nil {
+		return err
+	}
+	return pruneRemoteResources(r.Client, namespace, clusterStatus.Name, clusterStatus.Resources)
+}
+
+// cluster 의 argocd application 에서 istio 를 multi-primary 로 설치하기 위한 helm parameter
+func meshApplicationParameters(mfd *clusterV1alpha1.MeshFederation, clusterName string) map[string]string {
+	return map[string]string{
+		argoParameterMeshMultiCluster + ".enabled":     "true",
+		argoParameterMeshMultiCluster + ".meshID":      mfd.GetMeshID(),
+		argoParameterMeshMultiCluster + ".clusterName": clusterName,
+		argoParameterMeshMultiCluster + ".network":     meshNetworkName(clusterName),
+	}
+}
+
+// cluster 마다 별도의 network 로 보고 east-west gateway 를 통해 통신한다.
+func meshNetworkName(clusterName string) string {
+	return "network-" + clusterName
+}
+
+// root CA 로 서명한 intermediate CA 를 istiod 의 plugin CA 로 설정한다.
+// 이미 있는 cacerts 는 교체하지 않는다. 교체하면 기존 workload 인증서를 검증할 수 없게 된다.
+func ensureIstioCACerts(remoteClient client.Client, mfd *clusterV1alpha1.MeshFederation, rootCA *coreV1.Secret, clusterName string) error {
+	exist := &coreV1.Secret{}
+	key := types.NamespacedName{
+		Name:      istioCACertsSecret,
+		Namespace: istioNamespace,
+	}
+	if err := remoteClient.Get(context.TODO(), key, exist); err == nil {
+		if exist.Labels[clusterV1alpha1.LabelKeyMeshFederation] != mfd.Name ||
+			exist.Labels[clusterV1alpha1.LabelKeyMeshFederationNamespace] != mfd.Namespace {
+			return fmt.Errorf("%s/%s already exists and is not managed by the MeshFederation", istioNamespace, istioCACertsSecret)
+		}
+		return nil
+	} else if !errors.IsNotFound(err) {
+		return util.ClassifyRemoteError(err)
+	}
+
+	rootCert := rootCA.Data[coreV1.TLSCertKey]
+	certPEM, keyPEM, err := util.NewMeshIntermediateCA(rootCert, rootCA.Data[coreV1.TLSPrivateKeyKey], clusterName)
+	if err != nil {
+		return err
+	}
+	secret := &coreV1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      istioCACertsSecret,
+			Namespace: istioNamespace,
+			Labels:    meshFederationLabels(mfd),
+		},
+		Type: coreV1.SecretTypeOpaque,
+		Data: map[string][]byte{
+			"ca-cert.pem":    certPEM,
+			"ca-key.pem":     keyPEM,
+			"root-cert.pem":  rootCert,
+			"cert-chain.pem": append(append([]byte{}, certPEM...), rootCert...),
+		},
+	}
+	if err := remoteClient.Create(context.TODO(), secret); err != nil {
+		return util.ClassifyRemoteError(err)
+	}
+	return restartIstiod(remoteClient)
+}
+
+// istiod 는 시작할 때만 cacerts 를 읽으므로, 이미 설치되어 있으면 재시작한다.
+func restartIstiod(remoteClient client.Client) error {
+	istiod := &appsv1.Deployment{}
+	key := types.NamespacedName{
+		Name:      "istiod",
+		Namespace: istioNamespace,
+	}
+	if err := remoteClient.Get(context.TODO(), key, istiod); errors.IsNotFound(err) {
+		return nil
+	} else if err != nil {
+		return util.ClassifyRemoteError(err)
+	}
+	if istiod.Spec.Template.Annotations == nil {
+		istiod.Spec.Template.Annotations = map[string]string{}
+	}
+	istiod.Spec.Template.Annotations[istioAnnotationKeyRestart] = time.Now().Format(time.RFC3339)
+	return util.ClassifyRemoteError(remoteClient.Update(context.TODO(), istiod))
+}
+
+func meshFederationLabels(mfd *clusterV1alpha1.MeshFederation) map[string]string {
+	return map[string]string{
+		clusterV1alpha1.LabelKeyMeshFederation:          mfd.Name,
+		clusterV1alpha1.LabelKeyMeshFederationNamespace: mfd.Namespace,
+	}
+}
+
+// cluster 에 배포할 다른 cluster 들의 remote secret 과 east-west gateway 를 만든다.
+// remote secret 을 만든 cluster 의 목록을 함께 반환한다.
+func buildMeshFederationObjects(mfd *clusterV1alpha1.MeshFederation, clusterName string,
+	kubeconfigs map[string][]byte) ([]*unstructured.Unstructured, []string, error) {
+	peers := []string{}
+	for peer := range kubeconfigs {
+		if peer != clusterName {
+			peers = append(peers, peer)
+		}
+	}
+	sort.Strings(peers)
+
+	objs := []*unstructured.Unstructured{}
+	for _, peer := range peers {
+		labels := meshFederationLabels(mfd)
+		labels[istioLabelKeyMultiCluster] = "true"
+		secret := &coreV1.Secret{
+			TypeMeta: metav1.TypeMeta{
+				APIVersion: coreV1.SchemeGroupVersion.String(),
+				Kind:       "Secret",
+			},
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      istioRemoteSecretPrefix + peer,
+				Namespace: istioNamespace,
+				Labels:    labels,
+				Annotations: map[string]string{
+					istioAnnotationKeyCluster: peer,
+				},
+			},
+			Type: coreV1.SecretTypeOpaque,
+			Data: map[string][]byte{
+				peer: kubeconfigs[peer],
+			},
+		}
+		obj, err := convertToUnstructured(secret)
+		if err != nil {
+			return nil, nil, err
+		}
+		objs = append(objs, obj)
+	}
+
+	// 다른 network 의 cluster 가 east-west gateway 의 15443 port 로 서비스에 접근할 수 있도록 노출한다.
+	gateway := &unstructured.Unstructured{}
+	gateway.SetAPIVersion("networking.istio.io/v1beta1")
+	gateway.SetKind("Gateway")
+	gateway.SetName(istioCrossNetworkGateway)
+	gateway.SetNamespace(istioNamespace)
+	gateway.SetLabels(meshFederationLabels(mfd))
+	_ = unstructured.SetNestedMap(gateway.Object, map[string]interface{}{
+		"selector": map[string]interface{}{
+			"istio": "eastwestgateway",
+		},
+		"servers": []interface{}{
+			map[string]interface{}{
+				"port": map[string]interface{}{
+					"number":   int64(15443),
+					"name":     "tls",
+					"protocol": "TLS",
+				},
+				"tls": map[string]interface{}{
+					"mode": "AUTO_PASSTHROUGH",
+				},
+				"hosts": []interface{}{"*.local"},
+			},
+		},
+	}, "spec")
+	objs = append(objs, gateway)
+
+	return objs, peers, nil
+}
+
+// spec 의 cluster group 에 속한 cluster manager 목록, group 이 없으면 빈 목록을 반환한다.
+func (r *MeshFederationReconciler) selectClusters(mfd *clusterV1alpha1.MeshFederation) ([]clusterV1alpha1.ClusterManager, error) {
+	key := types.NamespacedName{
+		Name:      mfd.Spec.ClusterGroup,
+		Namespace: mfd.Namespace,
+	}
+	clusterGroup := &clusterV1alpha1.ClusterGroup{}
+	if err := r.Client.Get(context.TODO(), key, clusterGroup); errors.IsNotFound(err) {
+		return []clusterV1alpha1.ClusterManager{}, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	members, err := GetClusterGroupMembers(r.Client, clusterGroup)
+	if err != nil {
+		return nil, err
+	}
+
+	result := []clusterV1alpha1.ClusterManager{}
+	for _, clm := range members {
+		// 삭제중인 cluster 는 mesh 에 연결하지 않는다.
+		if !clm.DeletionTimestamp.IsZero() {
+			continue
+		}
+		result = append(result, clm)
+	}
+	return result, nil
+}
@@ -0,0 +1,230 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+
+	"github.com/go-logr/logr"
+	clusterV1alpha1 "github.com/tmax-cloud/hypercloud-multi-operator/apis/cluster/v1alpha1"
+	"github.com/tmax-cloud/hypercloud-multi-operator/controllers/pipeline"
+	"github.com/tmax-cloud/hypercloud-multi-operator/controllers/util"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+
+	"sigs.k8s.io/cluster-api/util/patch"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+// NamespaceTemplateReconciler reconciles a NamespaceTemplate object
+type NamespaceTemplateReconciler struct {
+	client.Client
+	Log      logr.Logger
+	Scheme   *runtime.Scheme
+	Recorder record.EventRecorder
+}
+
+// +kubebuilder:rbac:groups=cluster.tmax.io,resources=namespacetemplates,verbs=create;delete;get;list;patch;update;watch
+// +kubebuilder:rbac:groups=cluster.tmax.io,resources=namespacetemplates/status,verbs=get;patch;update
+
+func (r *NamespaceTemplateReconciler) Reconcile(ctx context.Context, req ctrl.Request) (_ ctrl.Result, reterr error) {
+	_ = context.Background()
+	log := r.Log.WithValues("namespacetemplate", req.NamespacedName)
+
+	// get NamespaceTemplate
+	nsTemplate := &clusterV1alpha1.NamespaceTemplate{}
+	if err := r.Client.Get(context.TODO(), req.NamespacedName, nsTemplate); errors.IsNotFound(err) {
+		log.Info("NamespaceTemplate not found. Ignoring since object must be deleted")
+		pipeline.Forget(nsTemplate, req.NamespacedName)
+		return ctrl.Result{}, nil
+	} else if err != nil {
+		log.Error(err, "Failed to get NamespaceTemplate")
+		return ctrl.Result{}, err
+	}
+
+	patchHelper, err := patch.NewHelper(nsTemplate, r.Client)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	defer func() {
+		r.reconcilePhase(context.TODO(), nsTemplate)
+
+		if err := patchHelper.Patch(context.TODO(), nsTemplate); err != nil {
+			reterr = err
+		}
+	}()
+
+	// Add finalizer first if not exist to avoid the race condition between init and delete
+	if !controllerutil.ContainsFinalizer(nsTemplate, clusterV1alpha1.NamespaceTemplateFinalizer) {
+		controllerutil.AddFinalizer(nsTemplate, clusterV1alpha1.NamespaceTemplateFinalizer)
+		return ctrl.Result{}, nil
+	}
+
+	// Handle deletion reconciliation loop.
+	if !nsTemplate.ObjectMeta.DeletionTimestamp.IsZero() {
+		return r.reconcileDelete(context.TODO(), nsTemplate)
+	}
+
+	// Handle normal reconciliation loop.
+	return r.reconcile(context.TODO(), nsTemplate)
+}
+
+// reconcile handles namespace template reconciliation.
+func (r *NamespaceTemplateReconciler) reconcile(ctx context.Context, nsTemplate *clusterV1alpha1.NamespaceTemplate) (ctrl.Result, error) {
+	phases := []pipeline.Phase[*clusterV1alpha1.NamespaceTemplate]{
+		// cluster group 에서 제외된 cluster 에 생성했던 리소스를 삭제한다.
+		{Name: "PruneUnselectedClusters", Run: r.PruneUnselectedClusters},
+		// cluster group 의 cluster 들에 namespace 와 ResourceQuota, LimitRange, RoleBinding 을 생성한다.
+		{Name: "ApplyNamespaces", Run: r.ApplyNamespaces},
+	}
+
+	return pipeline.NewRunner[*clusterV1alpha1.NamespaceTemplate](r.Log, r.Recorder).Run(ctx, nsTemplate, phases)
+}
+
+func (r *NamespaceTemplateReconciler) reconcileDelete(ctx context.Context, nsTemplate *clusterV1alpha1.NamespaceTemplate) (ctrl.Result, error) {
+	log := r.Log.WithValues("namespacetemplate", nsTemplate.GetNamespacedName())
+	log.Info("Start to reconcile delete for NamespaceTemplate")
+
+	// namespace 안의 workload 를 보호하기 위해 namespace 는 남겨두고, template 으로 생성한 리소스만 삭제한다.
+	remains := []clusterV1alpha1.NamespaceTemplateClusterStatus{}
+	for _, clusterStatus := range nsTemplate.Status.Clusters {
+		if err := pruneRemoteResources(r.Client, nsTemplate.Namespace, clusterStatus.Name, clusterStatus.Resources); err != nil {
+			log.Error(err, "Failed to delete resources from cluster", "cluster", clusterStatus.Name)
+			remains = append(remains, clusterStatus)
+		}
+	}
+	nsTemplate.Status.Clusters = remains
+	if len(remains) > 0 {
+		return ctrl.Result{Requeue: true}, nil
+	}
+
+	controllerutil.RemoveFinalizer(nsTemplate, clusterV1alpha1.NamespaceTemplateFinalizer)
+	log.Info("NamespaceTemplate is removed successfully")
+	return ctrl.Result{}, nil
+}
+
+func (r *NamespaceTemplateReconciler) reconcilePhase(_ context.Context, nsTemplate *clusterV1alpha1.NamespaceTemplate) {
+	if !nsTemplate.DeletionTimestamp.IsZero() {
+		nsTemplate.Status.SetTypedPhase(clusterV1alpha1.NamespaceTemplatePhaseDeleting)
+		return
+	}
+
+	for _, clusterStatus := range nsTemplate.Status.Clusters {
+		if !clusterStatus.Applied {
+			nsTemplate.Status.SetTypedPhase(clusterV1alpha1.NamespaceTemplatePhaseProgressing)
+			return
+		}
+	}
+	nsTemplate.Status.SetTypedPhase(clusterV1alpha1.NamespaceTemplatePhaseApplied)
+}
+
+func (r *NamespaceTemplateReconciler) requeueNamespaceTemplatesForClusterManager(o client.Object) []ctrl.Request {
+	log := r.Log.WithValues("NamespaceTemplate-ObjectMapper", "clusterManagerToNamespaceTemplates", "ClusterManager", o.GetNamespace()+"/"+o.GetName())
+	return r.requeueNamespaceTemplatesInNamespace(log, o.GetNamespace(), "")
+}
+
+func (r *NamespaceTemplateReconciler) requeueNamespaceTemplatesForClusterGroup(o client.Object) []ctrl.Request {
+	log := r.Log.WithValues("NamespaceTemplate-ObjectMapper", "clusterGroupToNamespaceTemplates", "ClusterGroup", o.GetNamespace()+"/"+o.GetName())
+	return r.requeueNamespaceTemplatesInNamespace(log, o.GetNamespace(), o.GetName())
+}
+
+// clusterGroup 이 비어있으면 group 의 selector 까지 확인하지 않고, 같은 namespace 의 template 을 모두 requeue 한다.
+func (r *NamespaceTemplateReconciler) requeueNamespaceTemplatesInNamespace(log logr.Logger, namespace, clusterGroup string) []ctrl.Request {
+	nsTemplateList := &clusterV1alpha1.NamespaceTemplateList{}
+	if err := r.Client.List(context.TODO(), nsTemplateList, client.InNamespace(namespace)); err != nil {
+		log.Error(err, "Failed to list NamespaceTemplate")
+		return nil
+	}
+
+	reqs := []ctrl.Request{}
+	for _, nsTemplate := range nsTemplateList.Items {
+		if clusterGroup != "" && nsTemplate.Spec.ClusterGroup != clusterGroup {
+			continue
+		}
+		reqs = append(reqs, ctrl.Request{NamespacedName: nsTemplate.GetNamespacedName()})
+	}
+	return reqs
+}
+
+func (r *NamespaceTemplateReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	controller, err := ctrl.NewControllerManagedBy(mgr).
+		For(&clusterV1alpha1.NamespaceTemplate{}).
+		WithOptions(util.DefaultControllerOptions()).
+		WithEventFilter(util.SpecChangedPredicate()).
+		Build(util.ShardReconciler(mgr.GetClient(), r))
+
+	if err != nil {
+		return err
+	}
+
+	// cluster group 의 selector 가 변경되면 group 의 cluster 가 바뀌므로 다시 적용한다.
+	err = controller.Watch(
+		&source.Kind{Type: &clusterV1alpha1.ClusterGroup{}},
+		handler.EnqueueRequestsFromMapFunc(r.requeueNamespaceTemplatesForClusterGroup),
+		predicate.Funcs{
+			CreateFunc: func(e event.CreateEvent) bool {
+				return true
+			},
+			UpdateFunc: func(e event.UpdateEvent) bool {
+				return e.ObjectOld.GetGeneration() != e.ObjectNew.GetGeneration()
+			},
+			DeleteFunc: func(e event.DeleteEvent) bool {
+				return true
+			},
+			GenericFunc: func(e event.GenericEvent) bool {
+				return false
+			},
+		},
+	)
+	if err != nil {
+		return err
+	}
+
+	// cluster 의 label 이 변경되거나 control plane 이 준비되면, namespace 를 다시 적용한다.
+	return controller.Watch(
+		&source.Kind{Type: &clusterV1alpha1.ClusterManager{}},
+		handler.EnqueueRequestsFromMapFunc(r.requeueNamespaceTemplatesForClusterManager),
+		predicate.Funcs{
+			CreateFunc: func(e event.CreateEvent) bool {
+				return false
+			},
+			UpdateFunc: func(e event.UpdateEvent) bool {
+				oldClm := e.ObjectOld.(*clusterV1alpha1.ClusterManager)
+				newClm := e.ObjectNew.(*clusterV1alpha1.ClusterManager)
+				if !labels.Equals(oldClm.Labels, newClm.Labels) ||
+					oldClm.Status.ControlPlaneReady != newClm.Status.ControlPlaneReady {
+					return true
+				}
+				return false
+			},
+			DeleteFunc: func(e event.DeleteEvent) bool {
+				return true
+			},
+			GenericFunc: func(e event.GenericEvent) bool {
+				return false
+			},
+		},
+	)
+}
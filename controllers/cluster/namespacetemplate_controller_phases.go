@@ -0,0 +1,281 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"sort"
+
+	clusterV1alpha1 "github.com/tmax-cloud/hypercloud-multi-operator/apis/cluster/v1alpha1"
+	util "github.com/tmax-cloud/hypercloud-multi-operator/controllers/util"
+
+	coreV1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+func (r *NamespaceTemplateReconciler) PruneUnselectedClusters(ctx context.Context, nsTemplate *clusterV1alpha1.NamespaceTemplate) (ctrl.Result, error) {
+	log := r.Log.WithValues("namespacetemplate", nsTemplate.GetNamespacedName())
+	log.Info("Start to reconcile phase for PruneUnselectedClusters")
+
+	clmList, err := r.selectClusters(nsTemplate)
+	if err != nil {
+		log.Error(err, "Failed to select ClusterManagers")
+		return ctrl.Result{}, err
+	}
+	selected := map[string]bool{}
+	for _, clm := range clmList {
+		selected[clm.Name] = true
+	}
+
+	clusters := []clusterV1alpha1.NamespaceTemplateClusterStatus{}
+	for _, clusterStatus := range nsTemplate.Status.Clusters {
+		if selected[clusterStatus.Name] {
+			clusters = append(clusters, clusterStatus)
+			continue
+		}
+		if err := pruneRemoteResources(r.Client, nsTemplate.Namespace, clusterStatus.Name, clusterStatus.Resources); err != nil {
+			log.Error(err, "Failed to delete resources from unselected cluster", "cluster", clusterStatus.Name)
+			clusterStatus.Applied = false
+			clusterStatus.Reason = "Failed to delete resources: " + err.Error()
+			clusters = append(clusters, clusterStatus)
+			continue
+		}
+		log.Info("Deleted resources from unselected cluster successfully", "cluster", clusterStatus.Name)
+	}
+	nsTemplate.Status.Clusters = clusters
+
+	return ctrl.Result{}, nil
+}
+
+func (r *NamespaceTemplateReconciler) ApplyNamespaces(ctx context.Context, nsTemplate *clusterV1alpha1.NamespaceTemplate) (ctrl.Result, error) {
+	log := r.Log.WithValues("namespacetemplate", nsTemplate.GetNamespacedName())
+	log.Info("Start to reconcile phase for ApplyNamespaces")
+
+	namespaces, objs, err := buildNamespaceTemplateObjects(nsTemplate)
+	if err != nil {
+		log.Error(err, "Failed to build resources of NamespaceTemplate")
+		return ctrl.Result{}, err
+	}
+
+	clmList, err := r.selectClusters(nsTemplate)
+	if err != nil {
+		log.Error(err, "Failed to select ClusterManagers")
+		return ctrl.Result{}, err
+	}
+
+	allApplied := true
+	for _, clm := range clmList {
+		clusterStatus := nsTemplate.Status.GetClusterStatus(clm.Name)
+		if clusterStatus == nil {
+			nsTemplate.Status.Clusters = append(nsTemplate.Status.Clusters, clusterV1alpha1.NamespaceTemplateClusterStatus{Name: clm.Name})
+			clusterStatus = &nsTemplate.Status.Clusters[len(nsTemplate.Status.Clusters)-1]
+		}
+
+		// control plane 이 준비되면 cluster manager watch 에 의해 다시 reconcile 된다.
+		if !clm.Status.ControlPlaneReady {
+			clusterStatus.Applied = false
+			clusterStatus.Reason = "Wait for control plane to be ready"
+			continue
+		}
+
+		if err := r.applyCluster(&clm, namespaces, objs, clusterStatus); err != nil {
+			log.Error(err, "Failed to apply namespaces to cluster", "cluster", clm.Name)
+			clusterStatus.Applied = false
+			clusterStatus.Reason = err.Error()
+			allApplied = false
+			continue
+		}
+		clusterStatus.Applied = true
+		clusterStatus.Reason = ""
+		clusterStatus.LastAppliedTime = metav1.Now()
+	}
+
+	sort.Slice(nsTemplate.Status.Clusters, func(i, j int) bool {
+		return nsTemplate.Status.Clusters[i].Name < nsTemplate.Status.Clusters[j].Name
+	})
+	nsTemplate.Status.ObservedGeneration = nsTemplate.Generation
+
+	if !allApplied {
+		return ctrl.Result{Requeue: true}, nil
+	}
+	return ctrl.Result{}, nil
+}
+
+// namespace 를 먼저 생성한 뒤 namespace 안의 리소스를 적용한다.
+// namespace 는 status 의 resources 에 기록하지 않아서 template 에서 제외되어도 삭제되지 않는다.
+func (r *NamespaceTemplateReconciler) applyCluster(clm *clusterV1alpha1.ClusterManager, namespaces []*unstructured.Unstructured,
+	objs []*unstructured.Unstructured, clusterStatus *clusterV1alpha1.NamespaceTemplateClusterStatus) error {
+	remoteClient, err := getRemoteRuntimeClient(r.Client, clm)
+	if err != nil {
+		return err
+	}
+
+	for _, namespace := range namespaces {
+		if err := applyRemoteObject(remoteClient, namespace); err != nil {
+			return err
+		}
+	}
+
+	applied, err := applyManifestResources(remoteClient, objs, clusterStatus.Resources)
+	if err != nil {
+		return err
+	}
+	clusterStatus.Resources = applied
+	return nil
+}
+
+// template 으로부터 namespace 목록과 각 namespace 에 생성할 리소스 목록을 만든다.
+func buildNamespaceTemplateObjects(nsTemplate *clusterV1alpha1.NamespaceTemplate) ([]*unstructured.Unstructured, []*unstructured.Unstructured, error) {
+	templateLabels := map[string]string{
+		clusterV1alpha1.LabelKeyNamespaceTemplate:          nsTemplate.Name,
+		clusterV1alpha1.LabelKeyNamespaceTemplateNamespace: nsTemplate.Namespace,
+	}
+
+	namespaces := []*unstructured.Unstructured{}
+	objs := []*unstructured.Unstructured{}
+	add := func(list []*unstructured.Unstructured, obj runtime.Object) ([]*unstructured.Unstructured, error) {
+		u, err := convertToUnstructured(obj)
+		if err != nil {
+			return nil, err
+		}
+		return append(list, u), nil
+	}
+
+	for _, name := range nsTemplate.Spec.Namespaces {
+		nsLabels := map[string]string{}
+		for k, v := range nsTemplate.Spec.Labels {
+			nsLabels[k] = v
+		}
+		for k, v := range templateLabels {
+			nsLabels[k] = v
+		}
+		namespace := &coreV1.Namespace{
+			TypeMeta: metav1.TypeMeta{
+				APIVersion: coreV1.SchemeGroupVersion.String(),
+				Kind:       "Namespace",
+			},
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        name,
+				Labels:      nsLabels,
+				Annotations: nsTemplate.Spec.Annotations,
+			},
+		}
+		var err error
+		if namespaces, err = add(namespaces, namespace); err != nil {
+			return nil, nil, err
+		}
+
+		if nsTemplate.Spec.ResourceQuota != nil {
+			quota := &coreV1.ResourceQuota{
+				TypeMeta: metav1.TypeMeta{
+					APIVersion: coreV1.SchemeGroupVersion.String(),
+					Kind:       "ResourceQuota",
+				},
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      nsTemplate.Name,
+					Namespace: name,
+					Labels:    templateLabels,
+				},
+				Spec: *nsTemplate.Spec.ResourceQuota,
+			}
+			if objs, err = add(objs, quota); err != nil {
+				return nil, nil, err
+			}
+		}
+
+		if nsTemplate.Spec.LimitRange != nil {
+			limitRange := &coreV1.LimitRange{
+				TypeMeta: metav1.TypeMeta{
+					APIVersion: coreV1.SchemeGroupVersion.String(),
+					Kind:       "LimitRange",
+				},
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      nsTemplate.Name,
+					Namespace: name,
+					Labels:    templateLabels,
+				},
+				Spec: *nsTemplate.Spec.LimitRange,
+			}
+			if objs, err = add(objs, limitRange); err != nil {
+				return nil, nil, err
+			}
+		}
+
+		for _, binding := range nsTemplate.Spec.RoleBindings {
+			roleBinding := &rbacv1.RoleBinding{
+				TypeMeta: metav1.TypeMeta{
+					APIVersion: rbacv1.SchemeGroupVersion.String(),
+					Kind:       "RoleBinding",
+				},
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      binding.Name,
+					Namespace: name,
+					Labels:    templateLabels,
+				},
+				RoleRef:  binding.RoleRef,
+				Subjects: binding.Subjects,
+			}
+			if objs, err = add(objs, roleBinding); err != nil {
+				return nil, nil, err
+			}
+		}
+
+		if nsTemplate.Spec.DefaultNetworkPolicies {
+			platformNamespaces := util.GetDefaultNetworkPolicyPlatformNamespaces()
+			for _, policy := range util.DefaultNetworkPolicies(name, platformNamespaces, templateLabels) {
+				if objs, err = add(objs, policy); err != nil {
+					return nil, nil, err
+				}
+			}
+		}
+	}
+	return namespaces, objs, nil
+}
+
+// spec 의 cluster group 에 속한 cluster manager 목록, group 이 없으면 빈 목록을 반환한다.
+func (r *NamespaceTemplateReconciler) selectClusters(nsTemplate *clusterV1alpha1.NamespaceTemplate) ([]clusterV1alpha1.ClusterManager, error) {
+	key := types.NamespacedName{
+		Name:      nsTemplate.Spec.ClusterGroup,
+		Namespace: nsTemplate.Namespace,
+	}
+	clusterGroup := &clusterV1alpha1.ClusterGroup{}
+	if err := r.Client.Get(context.TODO(), key, clusterGroup); errors.IsNotFound(err) {
+		return []clusterV1alpha1.ClusterManager{}, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	members, err := GetClusterGroupMembers(r.Client, clusterGroup)
+	if err != nil {
+		return nil, err
+	}
+
+	result := []clusterV1alpha1.ClusterManager{}
+	for _, clm := range members {
+		// 삭제중인 cluster 에는 적용하지 않는다.
+		if !clm.DeletionTimestamp.IsZero() {
+			continue
+		}
+		result = append(result, clm)
+	}
+	return result, nil
+}
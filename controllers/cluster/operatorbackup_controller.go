@@ -0,0 +1,111 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+
+	"github.com/go-logr/logr"
+	clusterV1alpha1 "github.com/tmax-cloud/hypercloud-multi-operator/apis/cluster/v1alpha1"
+	"github.com/tmax-cloud/hypercloud-multi-operator/controllers/pipeline"
+	"github.com/tmax-cloud/hypercloud-multi-operator/controllers/util"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+
+	"sigs.k8s.io/cluster-api/util/patch"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// OperatorBackupReconciler reconciles a OperatorBackup object
+// operator 의 custom resource, kubeconfig secret, db 의 cluster member 를 bundle 로 만들어 object store 에 업로드한다.
+type OperatorBackupReconciler struct {
+	client.Client
+	Log      logr.Logger
+	Scheme   *runtime.Scheme
+	Recorder record.EventRecorder
+}
+
+// +kubebuilder:rbac:groups=cluster.tmax.io,resources=operatorbackups,verbs=create;delete;get;list;patch;update;watch
+// +kubebuilder:rbac:groups=cluster.tmax.io,resources=operatorbackups/status,verbs=get;patch;update
+// +kubebuilder:rbac:groups=cluster.tmax.io,resources=*,verbs=get;list
+// +kubebuilder:rbac:groups=claim.tmax.io,resources=*,verbs=get;list
+
+func (r *OperatorBackupReconciler) Reconcile(ctx context.Context, req ctrl.Request) (_ ctrl.Result, reterr error) {
+	_ = context.Background()
+	log := r.Log.WithValues("operatorbackup", req.NamespacedName)
+
+	// get OperatorBackup
+	backup := &clusterV1alpha1.OperatorBackup{}
+	if err := r.Client.Get(context.TODO(), req.NamespacedName, backup); errors.IsNotFound(err) {
+		log.Info("OperatorBackup not found. Ignoring since object must be deleted")
+		pipeline.Forget(backup, req.NamespacedName)
+		return ctrl.Result{}, nil
+	} else if err != nil {
+		log.Error(err, "Failed to get OperatorBackup")
+		return ctrl.Result{}, err
+	}
+
+	patchHelper, err := patch.NewHelper(backup, r.Client)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	defer func() {
+		r.reconcilePhase(context.TODO(), backup)
+
+		if err := patchHelper.Patch(context.TODO(), backup); err != nil {
+			reterr = err
+		}
+	}()
+
+	// 업로드한 bundle 은 삭제하지 않는다.
+	if !backup.ObjectMeta.DeletionTimestamp.IsZero() {
+		return ctrl.Result{}, nil
+	}
+
+	// Handle normal reconciliation loop.
+	return r.reconcile(context.TODO(), backup)
+}
+
+// reconcile handles operator backup reconciliation.
+func (r *OperatorBackupReconciler) reconcile(ctx context.Context, backup *clusterV1alpha1.OperatorBackup) (ctrl.Result, error) {
+	phases := []pipeline.Phase[*clusterV1alpha1.OperatorBackup]{
+		// schedule 에 따라 bundle 을 만들어 object store 에 업로드한다.
+		{Name: "ExportBundle", Run: r.ExportBundle},
+	}
+
+	return pipeline.NewRunner[*clusterV1alpha1.OperatorBackup](r.Log, r.Recorder).Run(ctx, backup, phases)
+}
+
+func (r *OperatorBackupReconciler) reconcilePhase(_ context.Context, backup *clusterV1alpha1.OperatorBackup) {
+	if backup.Status.Reason != "" {
+		backup.Status.SetTypedPhase(clusterV1alpha1.OperatorBackupPhaseFailed)
+		return
+	}
+	if backup.Status.LastBackupTime != nil {
+		backup.Status.SetTypedPhase(clusterV1alpha1.OperatorBackupPhaseCompleted)
+	}
+}
+
+func (r *OperatorBackupReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&clusterV1alpha1.OperatorBackup{}).
+		WithOptions(util.DefaultControllerOptions()).
+		WithEventFilter(util.SpecChangedPredicate()).
+		Complete(util.ShardReconciler(mgr.GetClient(), r))
+}
@@ -0,0 +1,210 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/robfig/cron"
+	claimV1alpha1 "github.com/tmax-cloud/hypercloud-multi-operator/apis/claim/v1alpha1"
+	clusterV1alpha1 "github.com/tmax-cloud/hypercloud-multi-operator/apis/cluster/v1alpha1"
+	util "github.com/tmax-cloud/hypercloud-multi-operator/controllers/util"
+
+	coreV1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// backup, restore 자체는 bundle 에 넣지 않는다.
+var backupExcludedKinds = map[string]bool{
+	"OperatorBackup":  true,
+	"OperatorRestore": true,
+}
+
+// 다른 management cluster 에서 의미가 없는 metadata
+var backupStrippedMetadata = []string{
+	"resourceVersion", "uid", "selfLink", "creationTimestamp", "generation",
+	"managedFields", "ownerReferences", "deletionTimestamp", "deletionGracePeriodSeconds",
+}
+
+func (r *OperatorBackupReconciler) ExportBundle(ctx context.Context, backup *clusterV1alpha1.OperatorBackup) (ctrl.Result, error) {
+	log := r.Log.WithValues("operatorbackup", backup.GetNamespacedName())
+
+	now := time.Now()
+	var schedule cron.Schedule
+	if backup.Spec.Schedule == "" {
+		// schedule 이 없으면 한 번만 export 한다.
+		if backup.Status.LastBackupTime != nil {
+			return ctrl.Result{}, nil
+		}
+	} else {
+		var err error
+		if schedule, err = cron.ParseStandard(backup.Spec.Schedule); err != nil {
+			// spec 이 수정될 때까지 export 하지 않는다.
+			log.Error(err, "Invalid backup schedule")
+			backup.Status.Reason = fmt.Sprintf("invalid schedule: %s", err.Error())
+			backup.Status.NextBackupTime = nil
+			return ctrl.Result{}, nil
+		}
+		last := backup.CreationTimestamp.Time
+		if backup.Status.LastBackupTime != nil {
+			last = backup.Status.LastBackupTime.Time
+		}
+		if next := schedule.Next(last.UTC()); now.Before(next) {
+			backup.Status.NextBackupTime = &metav1.Time{Time: next}
+			return ctrl.Result{RequeueAfter: time.Until(next) + time.Second}, nil
+		}
+	}
+	log.Info("Start to reconcile phase for ExportBundle")
+
+	bundle, err := r.buildBackupBundle(ctx, backup)
+	if err != nil {
+		log.Error(err, "Failed to build backup bundle")
+		backup.Status.Reason = err.Error()
+		return ctrl.Result{}, err
+	}
+	data, err := util.MarshalBackupBundle(bundle)
+	if err != nil {
+		log.Error(err, "Failed to marshal backup bundle")
+		backup.Status.Reason = err.Error()
+		return ctrl.Result{}, err
+	}
+
+	store, err := util.NewObjectStore(ctx, r.Client, backup.Namespace, backup.Spec.Storage)
+	if err != nil {
+		log.Error(err, "Failed to create object store client")
+		backup.Status.Reason = err.Error()
+		return ctrl.Result{}, err
+	}
+	key := util.ObjectKey(backup.Spec.Storage.Prefix, "hypercloud-multi-operator-"+now.UTC().Format("20060102-150405")+".json.gz")
+	if err := store.Put(ctx, key, data); err != nil {
+		log.Error(err, "Failed to upload backup bundle")
+		backup.Status.Reason = err.Error()
+		return ctrl.Result{}, err
+	}
+	log.Info("Uploaded backup bundle", "bundle", key, "size", len(data))
+	r.Recorder.Event(backup, coreV1.EventTypeNormal, "BundleExported", "Uploaded backup bundle "+key)
+
+	backup.Status.Reason = ""
+	backup.Status.LastBundle = key
+	backup.Status.LastBackupTime = &metav1.Time{Time: now}
+	backup.Status.Resources = len(bundle.Resources)
+	backup.Status.KubeconfigSecrets = len(bundle.KubeconfigSecrets)
+	backup.Status.Members = len(bundle.Members)
+	if schedule == nil {
+		backup.Status.NextBackupTime = nil
+		return ctrl.Result{}, nil
+	}
+	next := schedule.Next(now.UTC())
+	backup.Status.NextBackupTime = &metav1.Time{Time: next}
+	return ctrl.Result{RequeueAfter: time.Until(next) + time.Second}, nil
+}
+
+func (r *OperatorBackupReconciler) buildBackupBundle(ctx context.Context, backup *clusterV1alpha1.OperatorBackup) (*util.BackupBundle, error) {
+	key, err := util.GetBackupEncryptionKey(ctx, r.Client, backup.Namespace, backup.Spec.EncryptionKeySecret)
+	if err != nil {
+		return nil, err
+	}
+
+	bundle := &util.BackupBundle{
+		Version:           util.BackupBundleVersion,
+		CreatedTime:       metav1.Now(),
+		Resources:         []*unstructured.Unstructured{},
+		KubeconfigSecrets: []util.BackupSecret{},
+		Members:           []util.DBMember{},
+	}
+
+	// scheme 에 등록된 operator 의 모든 custom resource 를 가져온다.
+	for _, gv := range []schema.GroupVersion{clusterV1alpha1.GroupVersion, claimV1alpha1.GroupVersion} {
+		knownTypes := r.Scheme.KnownTypes(gv)
+		kinds := []string{}
+		for kind := range knownTypes {
+			if _, ok := knownTypes[kind+"List"]; ok && !backupExcludedKinds[kind] {
+				kinds = append(kinds, kind)
+			}
+		}
+		sort.Strings(kinds)
+
+		for _, kind := range kinds {
+			list := &unstructured.UnstructuredList{}
+			list.SetGroupVersionKind(gv.WithKind(kind + "List"))
+			if err := r.Client.List(ctx, list); err != nil {
+				return nil, fmt.Errorf("failed to list %s: %w", kind, err)
+			}
+			for i := range list.Items {
+				obj := &list.Items[i]
+				if obj.GetDeletionTimestamp() != nil {
+					continue
+				}
+				for _, field := range backupStrippedMetadata {
+					unstructured.RemoveNestedField(obj.Object, "metadata", field)
+				}
+				bundle.Resources = append(bundle.Resources, obj)
+			}
+		}
+	}
+
+	// cluster 에 접근하는 admin, limited kubeconfig 는 bundle 의 key 로 다시 암호화한다.
+	selector, err := labels.Parse(fmt.Sprintf("%s in (%s,%s)",
+		util.LabelKeyClmSecretType, util.ClmSecretTypeKubeconfig, util.ClmSecretTypeLimitedKubeconfig))
+	if err != nil {
+		return nil, err
+	}
+	secrets := &coreV1.SecretList{}
+	if err := r.Client.List(ctx, secrets, client.MatchingLabelsSelector{Selector: selector}); err != nil {
+		return nil, fmt.Errorf("failed to list kubeconfig secrets: %w", err)
+	}
+	// tenant key 가 설정된 namespace 의 secret 은 tenant key 로 암호화한다.
+	tenantKeys := map[string]*util.TenantKey{}
+	for i := range secrets.Items {
+		namespace := secrets.Items[i].Namespace
+		tenantKey, ok := tenantKeys[namespace]
+		if !ok {
+			if tenantKey, err = util.GetTenantKey(ctx, r.Client, namespace); err != nil {
+				return nil, fmt.Errorf("failed to get tenant key of namespace %s: %w", namespace, err)
+			}
+			tenantKeys[namespace] = tenantKey
+		}
+		secret, err := util.NewBackupSecret(ctx, &secrets.Items[i], key, tenantKey)
+		if err != nil {
+			return nil, err
+		}
+		bundle.KubeconfigSecrets = append(bundle.KubeconfigSecrets, secret)
+	}
+
+	clmList := &clusterV1alpha1.ClusterManagerList{}
+	if err := r.Client.List(ctx, clmList); err != nil {
+		return nil, fmt.Errorf("failed to list cluster managers: %w", err)
+	}
+	for _, clm := range clmList.Items {
+		if !clm.DeletionTimestamp.IsZero() {
+			continue
+		}
+		members, err := util.ListDBMembers(clm.Namespace, clm.Name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list members of cluster %s/%s: %w", clm.Namespace, clm.Name, err)
+		}
+		bundle.Members = append(bundle.Members, members...)
+	}
+	return bundle, nil
+}
@@ -0,0 +1,127 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+
+	"github.com/go-logr/logr"
+	clusterV1alpha1 "github.com/tmax-cloud/hypercloud-multi-operator/apis/cluster/v1alpha1"
+	"github.com/tmax-cloud/hypercloud-multi-operator/controllers/pipeline"
+	"github.com/tmax-cloud/hypercloud-multi-operator/controllers/util"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+
+	"sigs.k8s.io/cluster-api/util/patch"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// OperatorRestoreReconciler reconciles a OperatorRestore object
+// object store 의 bundle 을 새로운 management cluster 에 복원하고, 복원한 cluster 에 접근할 수 있는지 확인한다.
+type OperatorRestoreReconciler struct {
+	client.Client
+	Log      logr.Logger
+	Scheme   *runtime.Scheme
+	Recorder record.EventRecorder
+	// member cluster 의 clientset 을 생성한다. nil 이면 util.DefaultRemoteClientFactory 를 사용한다.
+	RemoteClients util.RemoteClientFactory
+}
+
+func (r *OperatorRestoreReconciler) remoteClients() util.RemoteClientFactory {
+	return util.RemoteClientFactoryOrDefault(r.RemoteClients)
+}
+
+// +kubebuilder:rbac:groups=cluster.tmax.io,resources=operatorrestores,verbs=create;delete;get;list;patch;update;watch
+// +kubebuilder:rbac:groups=cluster.tmax.io,resources=operatorrestores/status,verbs=get;patch;update
+// +kubebuilder:rbac:groups=cluster.tmax.io,resources=*,verbs=create;get;list;update
+// +kubebuilder:rbac:groups=claim.tmax.io,resources=*,verbs=create;get;list;update
+
+func (r *OperatorRestoreReconciler) Reconcile(ctx context.Context, req ctrl.Request) (_ ctrl.Result, reterr error) {
+	_ = context.Background()
+	log := r.Log.WithValues("operatorrestore", req.NamespacedName)
+
+	// get OperatorRestore
+	restore := &clusterV1alpha1.OperatorRestore{}
+	if err := r.Client.Get(context.TODO(), req.NamespacedName, restore); errors.IsNotFound(err) {
+		log.Info("OperatorRestore not found. Ignoring since object must be deleted")
+		pipeline.Forget(restore, req.NamespacedName)
+		return ctrl.Result{}, nil
+	} else if err != nil {
+		log.Error(err, "Failed to get OperatorRestore")
+		return ctrl.Result{}, err
+	}
+
+	patchHelper, err := patch.NewHelper(restore, r.Client)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	defer func() {
+		r.reconcilePhase(context.TODO(), restore)
+
+		if err := patchHelper.Patch(context.TODO(), restore); err != nil {
+			reterr = err
+		}
+	}()
+
+	// 복원한 resource 는 삭제하지 않는다.
+	if !restore.ObjectMeta.DeletionTimestamp.IsZero() {
+		return ctrl.Result{}, nil
+	}
+
+	// Handle normal reconciliation loop.
+	return r.reconcile(context.TODO(), restore)
+}
+
+// reconcile handles operator restore reconciliation.
+func (r *OperatorRestoreReconciler) reconcile(ctx context.Context, restore *clusterV1alpha1.OperatorRestore) (ctrl.Result, error) {
+	phases := []pipeline.Phase[*clusterV1alpha1.OperatorRestore]{
+		// bundle 을 내려받아 resource, secret, db 의 member 를 복원한다.
+		{Name: "RestoreBundle", Run: r.RestoreBundle},
+		// 복원한 kubeconfig 로 각 cluster 에 접근할 수 있는지 확인한다.
+		{Name: "ValidateClusters", Run: r.ValidateClusters, DependsOn: []string{"RestoreBundle"}},
+	}
+
+	return pipeline.NewRunner[*clusterV1alpha1.OperatorRestore](r.Log, r.Recorder).Run(ctx, restore, phases)
+}
+
+func (r *OperatorRestoreReconciler) reconcilePhase(_ context.Context, restore *clusterV1alpha1.OperatorRestore) {
+	if restore.Status.RestoredTime == nil {
+		if restore.Status.Reason != "" {
+			restore.Status.SetTypedPhase(clusterV1alpha1.OperatorRestorePhaseFailed)
+		} else {
+			restore.Status.SetTypedPhase(clusterV1alpha1.OperatorRestorePhaseRestoring)
+		}
+		return
+	}
+	for _, cluster := range restore.Status.Clusters {
+		if !cluster.Validated {
+			restore.Status.SetTypedPhase(clusterV1alpha1.OperatorRestorePhaseValidating)
+			return
+		}
+	}
+	restore.Status.SetTypedPhase(clusterV1alpha1.OperatorRestorePhaseCompleted)
+}
+
+func (r *OperatorRestoreReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&clusterV1alpha1.OperatorRestore{}).
+		WithOptions(util.DefaultControllerOptions()).
+		WithEventFilter(util.SpecChangedPredicate()).
+		Complete(util.ShardReconciler(mgr.GetClient(), r))
+}
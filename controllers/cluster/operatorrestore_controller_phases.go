@@ -0,0 +1,305 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	goerrors "errors"
+	"fmt"
+	"sort"
+	"time"
+
+	clusterV1alpha1 "github.com/tmax-cloud/hypercloud-multi-operator/apis/cluster/v1alpha1"
+	util "github.com/tmax-cloud/hypercloud-multi-operator/controllers/util"
+
+	coreV1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+// 복원한 cluster 에 다시 접근할 수 있는지 확인하는 주기
+const restoreValidationInterval = 1 * time.Minute
+
+func (r *OperatorRestoreReconciler) RestoreBundle(ctx context.Context, restore *clusterV1alpha1.OperatorRestore) (ctrl.Result, error) {
+	// bundle 은 한 번만 복원한다.
+	if restore.Status.RestoredTime != nil {
+		return ctrl.Result{}, nil
+	}
+	log := r.Log.WithValues("operatorrestore", restore.GetNamespacedName())
+	log.Info("Start to reconcile phase for RestoreBundle")
+
+	store, err := util.NewObjectStore(ctx, r.Client, restore.Namespace, restore.Spec.Storage)
+	if err != nil {
+		log.Error(err, "Failed to create object store client")
+		restore.Status.Reason = err.Error()
+		return ctrl.Result{}, err
+	}
+	data, err := store.Get(ctx, restore.Spec.Bundle)
+	if err != nil {
+		log.Error(err, "Failed to download backup bundle")
+		restore.Status.Reason = err.Error()
+		return ctrl.Result{}, err
+	}
+	bundle, err := util.UnmarshalBackupBundle(data)
+	if err != nil {
+		// bundle 이 바뀌지 않는 한 다시 시도해도 실패한다.
+		log.Error(err, "Invalid backup bundle")
+		restore.Status.Reason = err.Error()
+		return ctrl.Result{}, nil
+	}
+	key, err := util.GetBackupEncryptionKey(ctx, r.Client, restore.Namespace, restore.Spec.EncryptionKeySecret)
+	if err != nil {
+		log.Error(err, "Failed to get encryption key")
+		restore.Status.Reason = err.Error()
+		return ctrl.Result{}, err
+	}
+
+	if err := r.restoreNamespaces(ctx, bundle); err != nil {
+		log.Error(err, "Failed to create namespaces")
+		restore.Status.Reason = err.Error()
+		return ctrl.Result{}, err
+	}
+
+	// cluster manager 가 kubeconfig secret 을 찾을 수 있도록 secret 을 먼저 복원한다.
+	secrets := 0
+	for _, backupSecret := range bundle.KubeconfigSecrets {
+		secret, err := backupSecret.ToSecret(ctx, key)
+		if goerrors.Is(err, util.ErrRemoteUnreachable) {
+			log.Error(err, "Failed to decrypt kubeconfig secret with tenant key")
+			restore.Status.Reason = err.Error()
+			return ctrl.Result{}, err
+		} else if err != nil {
+			log.Error(err, "Failed to decrypt kubeconfig secret")
+			restore.Status.Reason = err.Error()
+			return ctrl.Result{}, nil
+		}
+		if err := r.Client.Create(ctx, secret); errors.IsAlreadyExists(err) {
+			continue
+		} else if err != nil {
+			log.Error(err, "Failed to create kubeconfig secret", "secret", secret.Namespace+"/"+secret.Name)
+			restore.Status.Reason = err.Error()
+			return ctrl.Result{}, err
+		}
+		secrets++
+	}
+
+	// claim, member 등은 cluster manager 를 참조하므로 cluster manager 를 먼저 복원한다.
+	sort.SliceStable(bundle.Resources, func(i, j int) bool {
+		return bundle.Resources[i].GetKind() == "ClusterManager" && bundle.Resources[j].GetKind() != "ClusterManager"
+	})
+	resources := 0
+	clusters := []clusterV1alpha1.RestoredClusterStatus{}
+	for _, obj := range bundle.Resources {
+		created, err := r.restoreResource(ctx, restore, obj)
+		if err != nil {
+			log.Error(err, "Failed to restore resource", "kind", obj.GetKind(), "resource", obj.GetNamespace()+"/"+obj.GetName())
+			restore.Status.Reason = err.Error()
+			return ctrl.Result{}, err
+		}
+		if created {
+			resources++
+		}
+		if obj.GetKind() != "ClusterManager" {
+			continue
+		}
+
+		clm := &clusterV1alpha1.ClusterManager{}
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj.Object, clm); err != nil {
+			restore.Status.Reason = err.Error()
+			return ctrl.Result{}, nil
+		}
+		if err := util.InsertOrQueue(ctx, r.Client, clm); err != nil {
+			log.Error(err, "Failed to insert cluster info into DB", "clustermanager", clm.GetNamespacedName())
+			restore.Status.Reason = err.Error()
+			return ctrl.Result{}, err
+		}
+		clusters = append(clusters, clusterV1alpha1.RestoredClusterStatus{
+			Name:      clm.Name,
+			Namespace: clm.Namespace,
+		})
+	}
+
+	// owner 는 cluster 를 db 에 쓸 때 함께 생성되므로 초대된 member 만 복원한다.
+	members := 0
+	for _, member := range bundle.Members {
+		if member.Status != "invited" {
+			continue
+		}
+		clusterMember := &clusterV1alpha1.ClusterMember{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: member.Namespace,
+			},
+			Spec: clusterV1alpha1.ClusterMemberSpec{
+				ClusterName: member.Cluster,
+				MemberId:    member.MemberId,
+				MemberName:  member.MemberName,
+				Attribute:   member.Attribute,
+				Role:        member.Role,
+				Accepted:    true,
+			},
+		}
+		if err := util.InsertMemberOrQueue(ctx, r.Client, clusterMember); err != nil {
+			log.Error(err, "Failed to insert member into DB", "cluster", member.Namespace+"/"+member.Cluster, "member", member.MemberId)
+			restore.Status.Reason = err.Error()
+			return ctrl.Result{}, err
+		}
+		members++
+	}
+
+	log.Info("Restored backup bundle", "bundle", restore.Spec.Bundle, "resources", resources, "secrets", secrets, "members", members)
+	r.Recorder.Event(restore, coreV1.EventTypeNormal, "BundleRestored",
+		fmt.Sprintf("Restored %d resources, %d kubeconfig secrets and %d members from %s", resources, secrets, members, restore.Spec.Bundle))
+
+	restore.Status.Reason = ""
+	restore.Status.RestoredTime = &metav1.Time{Time: time.Now()}
+	restore.Status.Resources = resources
+	restore.Status.KubeconfigSecrets = secrets
+	restore.Status.Members = members
+	restore.Status.Clusters = clusters
+	return ctrl.Result{}, nil
+}
+
+// bundle 의 resource, secret 이 있던 namespace 를 생성한다.
+func (r *OperatorRestoreReconciler) restoreNamespaces(ctx context.Context, bundle *util.BackupBundle) error {
+	namespaces := map[string]*util.TenantKey{}
+	for _, obj := range bundle.Resources {
+		namespaces[obj.GetNamespace()] = nil
+	}
+	for _, secret := range bundle.KubeconfigSecrets {
+		namespaces[secret.Namespace] = secret.TenantKey
+	}
+	delete(namespaces, "")
+
+	for name, tenantKey := range namespaces {
+		ns := &coreV1.Namespace{}
+		if err := r.Client.Get(ctx, types.NamespacedName{Name: name}, ns); err == nil {
+			continue
+		} else if !errors.IsNotFound(err) {
+			return err
+		}
+		ns.Name = name
+		// 복원한 namespace 에서도 같은 tenant key 를 사용하도록 annotation 을 복원한다.
+		if tenantKey != nil {
+			ns.Annotations = map[string]string{}
+			if tenantKey.KMSKeyARN != "" {
+				ns.Annotations[util.AnnotationKeyTenantKMSKeyARN] = tenantKey.KMSKeyARN
+			} else {
+				ns.Annotations[util.AnnotationKeyTenantVaultTransitKey] = tenantKey.VaultTransitKey
+			}
+		}
+		if err := r.Client.Create(ctx, ns); err != nil && !errors.IsAlreadyExists(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// resource 를 생성하고 bundle 의 status 를 복원한다. 이미 있는 resource 는 변경하지 않는다.
+func (r *OperatorRestoreReconciler) restoreResource(ctx context.Context, restore *clusterV1alpha1.OperatorRestore, obj *unstructured.Unstructured) (bool, error) {
+	obj = obj.DeepCopy()
+	status, hasStatus, _ := unstructured.NestedFieldCopy(obj.Object, "status")
+
+	if obj.GetKind() == "ClusterManager" {
+		annotations := obj.GetAnnotations()
+		if annotations == nil {
+			annotations = map[string]string{}
+		}
+		annotations[clusterV1alpha1.AnnotationKeyClmRestoredFrom] = restore.Spec.Bundle
+		obj.SetAnnotations(annotations)
+
+		// capi object 가 옮겨지기 전에 cluster 를 다시 생성하지 않도록 멈춰둔다.
+		// clusterctl move 등으로 capi object 를 옮긴 뒤 spec.paused 를 해제해야 한다.
+		if clusterType, _, _ := unstructured.NestedString(obj.Object, "spec", "type"); clusterType == clusterV1alpha1.ClusterTypeCreated {
+			if err := unstructured.SetNestedField(obj.Object, true, "spec", "paused"); err != nil {
+				return false, err
+			}
+		}
+	}
+
+	if err := r.Client.Create(ctx, obj); errors.IsAlreadyExists(err) {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+
+	if !hasStatus {
+		return true, nil
+	}
+	if err := unstructured.SetNestedField(obj.Object, status, "status"); err != nil {
+		return true, err
+	}
+	return true, r.Client.Status().Update(ctx, obj)
+}
+
+func (r *OperatorRestoreReconciler) ValidateClusters(ctx context.Context, restore *clusterV1alpha1.OperatorRestore) (ctrl.Result, error) {
+	pending := false
+	for _, cluster := range restore.Status.Clusters {
+		if !cluster.Validated {
+			pending = true
+		}
+	}
+	if !pending {
+		return ctrl.Result{}, nil
+	}
+	log := r.Log.WithValues("operatorrestore", restore.GetNamespacedName())
+	log.Info("Start to reconcile phase for ValidateClusters")
+
+	pending = false
+	for i := range restore.Status.Clusters {
+		cluster := &restore.Status.Clusters[i]
+		if cluster.Validated {
+			continue
+		}
+		version, err := r.getClusterVersion(ctx, cluster.Namespace, cluster.Name)
+		if err != nil {
+			log.Info("Restored cluster is not reachable yet", "clustermanager", cluster.Namespace+"/"+cluster.Name, "reason", err.Error())
+			cluster.Reason = err.Error()
+			pending = true
+			continue
+		}
+		cluster.Validated = true
+		cluster.Version = version
+		cluster.Reason = ""
+		r.Recorder.Event(restore, coreV1.EventTypeNormal, "ClusterValidated",
+			fmt.Sprintf("Cluster %s/%s is reachable with the restored kubeconfig", cluster.Namespace, cluster.Name))
+	}
+	restore.Status.LastValidationTime = &metav1.Time{Time: time.Now()}
+
+	if pending {
+		return util.RequeueAfterWithJitter(restoreValidationInterval), nil
+	}
+	return ctrl.Result{}, nil
+}
+
+func (r *OperatorRestoreReconciler) getClusterVersion(ctx context.Context, namespace, name string) (string, error) {
+	secret, err := util.GetKubeconfigSecret(ctx, r.Client, namespace, name)
+	if err != nil {
+		return "", err
+	}
+	clientset, err := r.remoteClients().ClientsetForSecret(secret)
+	if err != nil {
+		return "", err
+	}
+	version, err := clientset.Discovery().ServerVersion()
+	if err != nil {
+		return "", util.ClassifyRemoteError(err)
+	}
+	return version.GitVersion, nil
+}
@@ -0,0 +1,178 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	goerrors "errors"
+	"fmt"
+	"time"
+
+	"github.com/go-logr/logr"
+	clusterV1alpha1 "github.com/tmax-cloud/hypercloud-multi-operator/apis/cluster/v1alpha1"
+	"github.com/tmax-cloud/hypercloud-multi-operator/controllers/util"
+
+	coreV1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// db 에 접근할 수 없어 쓰지 못한 요청을 다시 시도하는 간격
+	pendingDBWriteRetryInterval = 30 * time.Second
+
+	reasonDBWritesDrained = "DBWritesDrained"
+)
+
+// PendingDBWriteReconciler replays the membership writes queued while the db was unavailable
+type PendingDBWriteReconciler struct {
+	client.Client
+	Log      logr.Logger
+	Scheme   *runtime.Scheme
+	Recorder record.EventRecorder
+}
+
+// +kubebuilder:rbac:groups=cluster.tmax.io,resources=pendingdbwrites,verbs=create;delete;get;list;patch;update;watch
+// +kubebuilder:rbac:groups=cluster.tmax.io,resources=pendingdbwrites/status,verbs=get;patch;update
+// +kubebuilder:rbac:groups=cluster.tmax.io,resources=clustermanagers/status,verbs=get;patch;update
+
+func (r *PendingDBWriteReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	_ = context.Background()
+	log := r.Log.WithValues("pendingdbwrite", req.NamespacedName)
+
+	// get PendingDBWrite
+	write := &clusterV1alpha1.PendingDBWrite{}
+	if err := r.Client.Get(context.TODO(), req.NamespacedName, write); errors.IsNotFound(err) {
+		log.Info("PendingDBWrite not found. Ignoring since object must be deleted")
+		return ctrl.Result{}, nil
+	} else if err != nil {
+		log.Error(err, "Failed to get PendingDBWrite")
+		return ctrl.Result{}, err
+	}
+
+	res, err := r.replay(context.TODO(), write)
+	// 요청을 처리한 결과에 따라 cluster 의 DBDegraded condition 을 갱신한다.
+	if err := r.syncDBDegradedCondition(context.TODO(), write.Namespace, write.Spec.ClusterName); err != nil {
+		log.Error(err, "Failed to update DBDegraded condition", "clusterManager", write.Spec.ClusterName)
+	}
+	return res, err
+}
+
+func (r *PendingDBWriteReconciler) replay(ctx context.Context, write *clusterV1alpha1.PendingDBWrite) (ctrl.Result, error) {
+	log := r.Log.WithValues("PendingDBWrite", write.GetNamespacedName())
+
+	// circuit 이 열려있는 동안에는 db 에 요청하지 않는다.
+	if retryAfter := util.DBRetryAfter(); retryAfter > 0 {
+		return util.RequeueAfterWithJitter(retryAfter), nil
+	}
+
+	// member 는 cluster 가 db 에 쓰인 뒤에 써야 한다.
+	if write.IsMemberWrite() {
+		clusterWrite := &clusterV1alpha1.PendingDBWrite{}
+		key := client.ObjectKey{Name: "cluster-" + write.Spec.ClusterName, Namespace: write.Namespace}
+		if err := r.Client.Get(ctx, key, clusterWrite); err == nil &&
+			clusterWrite.Spec.Operation == clusterV1alpha1.PendingDBWriteOperationInsertCluster {
+			log.Info("Wait for the cluster to be written to db")
+			return util.RequeueAfterWithJitter(pendingDBWriteRetryInterval), nil
+		} else if err != nil && !errors.IsNotFound(err) {
+			return ctrl.Result{}, err
+		}
+	}
+
+	if err := util.ReplayPendingDBWrite(ctx, r.Client, write); err != nil {
+		log.Info("Failed to replay db write", "operation", write.Spec.Operation, "reason", err.Error())
+		before := write.DeepCopy()
+		now := metav1.Now()
+		write.Status.Attempts++
+		write.Status.LastAttemptTime = &now
+		write.Status.LastError = err.Error()
+		if perr := r.Client.Status().Patch(ctx, write, client.MergeFrom(before)); perr != nil {
+			return ctrl.Result{}, perr
+		}
+		if goerrors.Is(err, util.ErrDBUnavailable) {
+			return util.RequeueAfterWithJitter(pendingDBWriteRetryInterval), nil
+		}
+		// db 가 거부한 요청은 controller 의 backoff 에 따라 다시 시도한다.
+		return ctrl.Result{}, err
+	}
+
+	log.Info("Replayed db write", "operation", write.Spec.Operation, "attempts", write.Status.Attempts)
+	if err := r.Client.Delete(ctx, write); err != nil && !errors.IsNotFound(err) {
+		return ctrl.Result{}, err
+	}
+	return ctrl.Result{}, nil
+}
+
+// 저장된 요청이 남아있으면 DBDegraded 를 True 로 설정하고, 모두 처리되면 False 로 변경한다.
+func (r *PendingDBWriteReconciler) syncDBDegradedCondition(ctx context.Context, namespace, cluster string) error {
+	clm := &clusterV1alpha1.ClusterManager{}
+	if err := r.Client.Get(ctx, client.ObjectKey{Name: cluster, Namespace: namespace}, clm); errors.IsNotFound(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	writes, err := util.ListPendingDBWrites(ctx, r.Client, namespace, cluster)
+	if err != nil {
+		return err
+	}
+
+	condition := metav1.Condition{
+		Type:               clusterV1alpha1.ClusterManagerConditionDBDegraded,
+		Status:             metav1.ConditionTrue,
+		Reason:             util.ReasonDBUnavailable,
+		Message:            fmt.Sprintf("%d membership writes are queued until the db is available", len(writes)),
+		ObservedGeneration: clm.Generation,
+	}
+	if len(writes) == 0 {
+		// db 에 문제가 없었던 cluster 에는 condition 을 추가하지 않는다.
+		if meta.FindStatusCondition(clm.Status.Conditions, clusterV1alpha1.ClusterManagerConditionDBDegraded) == nil {
+			return nil
+		}
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = reasonDBWritesDrained
+		condition.Message = "All queued membership writes are written to the db"
+	}
+
+	existing := meta.FindStatusCondition(clm.Status.Conditions, condition.Type)
+	if existing != nil && existing.Status == condition.Status && existing.Message == condition.Message {
+		return nil
+	}
+	before := clm.DeepCopy()
+	meta.SetStatusCondition(&clm.Status.Conditions, condition)
+	if err := r.Client.Status().Patch(ctx, clm, client.MergeFrom(before)); err != nil {
+		return err
+	}
+	if condition.Status == metav1.ConditionFalse {
+		r.Recorder.Event(clm, coreV1.EventTypeNormal, reasonDBWritesDrained, condition.Message)
+	}
+	return nil
+}
+
+func (r *PendingDBWriteReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	_, err := ctrl.NewControllerManagedBy(mgr).
+		For(&clusterV1alpha1.PendingDBWrite{}).
+		WithOptions(util.DefaultControllerOptions()).
+		WithEventFilter(util.SpecChangedPredicate()).
+		Build(util.ShardReconciler(mgr.GetClient(), r))
+
+	return err
+}
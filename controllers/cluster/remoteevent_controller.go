@@ -0,0 +1,291 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+	clusterV1alpha1 "github.com/tmax-cloud/hypercloud-multi-operator/apis/cluster/v1alpha1"
+	util "github.com/tmax-cloud/hypercloud-multi-operator/controllers/util"
+
+	coreV1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+)
+
+const (
+	// operator 가 처음 시작되었을 때 mirror 할 event 의 범위
+	remoteEventInitialWindow = 10 * time.Minute
+	// 한 번의 동기화에서 mirror 할 최대 event 개수
+	remoteEventMaxPerSync = 100
+	// mirror 된 event 의 source component
+	remoteEventComponent = "hypercloud-multi-operator"
+)
+
+// RemoteEventReconciler mirrors warning events and node NotReady transitions
+// of member clusters into the namespace of the corresponding ClusterManager.
+type RemoteEventReconciler struct {
+	client.Client
+	Log      logr.Logger
+	Scheme   *runtime.Scheme
+	Recorder record.EventRecorder
+
+	mutex sync.Mutex
+	// cluster manager 별로 마지막으로 동기화한 시각
+	lastSyncTime map[types.NamespacedName]time.Time
+	// cluster manager 별 node 의 이전 ready 상태
+	nodeReady map[types.NamespacedName]map[string]bool
+}
+
+// +kubebuilder:rbac:groups="",resources=events,verbs=create;get;list;patch;update;watch
+
+func (r *RemoteEventReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	_ = context.Background()
+	log := r.Log.WithValues("clustermanager", req.NamespacedName)
+
+	clm := &clusterV1alpha1.ClusterManager{}
+	if err := r.Client.Get(context.TODO(), req.NamespacedName, clm); errors.IsNotFound(err) {
+		r.forget(req.NamespacedName)
+		return ctrl.Result{}, nil
+	} else if err != nil {
+		log.Error(err, "Failed to get ClusterManager")
+		return ctrl.Result{}, err
+	}
+
+	if !clm.DeletionTimestamp.IsZero() {
+		r.forget(req.NamespacedName)
+		return ctrl.Result{}, nil
+	}
+	if !clm.Status.ControlPlaneReady {
+		return ctrl.Result{Requeue: true}, nil
+	}
+
+	remoteClient, err := getRemoteRuntimeClient(r.Client, clm)
+	if errors.IsNotFound(err) {
+		log.Info("Kubeconfig secret not found. Wait for the secret to be created")
+		return ctrl.Result{Requeue: true}, nil
+	} else if err != nil {
+		log.Error(err, "Failed to get remote cluster client")
+		return ctrl.Result{Requeue: true}, nil
+	}
+
+	now := time.Now()
+	if err := r.mirrorWarningEvents(remoteClient, clm, r.getLastSyncTime(req.NamespacedName, now)); err != nil {
+		log.Error(err, "Failed to mirror warning events from remote cluster")
+	} else {
+		r.setLastSyncTime(req.NamespacedName, now)
+	}
+	if err := r.mirrorNodeTransitions(remoteClient, clm); err != nil {
+		log.Error(err, "Failed to check node status of remote cluster")
+	}
+
+	// member cluster 의 event 는 watch 하지 않고 주기적으로 가져온다.
+	return util.RequeueAfterWithJitter(getClusterResyncPeriod(clm)), nil
+}
+
+// since 이후에 발생한 warning event 를 cluster manager 의 namespace 에 event 로 생성한다.
+// 이름은 remote event 의 uid 로 정해지므로, 같은 event 는 count 와 시각만 갱신된다.
+func (r *RemoteEventReconciler) mirrorWarningEvents(remoteClient client.Client, clm *clusterV1alpha1.ClusterManager, since time.Time) error {
+	eventList := &coreV1.EventList{}
+	mirrored := 0
+	// hub cluster 에 event 를 기록하다 실패한 경우는 remote cluster 의 error 로 분류하지 않는다.
+	var upsertErr error
+	err := util.ListPages(context.TODO(), remoteClient, eventList, func() (bool, error) {
+		for _, remoteEvent := range eventList.Items {
+			if mirrored >= remoteEventMaxPerSync {
+				return false, nil
+			}
+			lastTimestamp := remoteEvent.LastTimestamp.Time
+			if lastTimestamp.IsZero() {
+				lastTimestamp = remoteEvent.EventTime.Time
+			}
+			if lastTimestamp.Before(since) {
+				continue
+			}
+
+			if upsertErr = r.upsertMirroredEvent(clm, &remoteEvent, lastTimestamp); upsertErr != nil {
+				return false, nil
+			}
+			mirrored++
+		}
+		return true, nil
+	}, client.MatchingFields{"type": coreV1.EventTypeWarning})
+	if err != nil {
+		return util.ClassifyRemoteError(err)
+	}
+	return upsertErr
+}
+
+func (r *RemoteEventReconciler) upsertMirroredEvent(clm *clusterV1alpha1.ClusterManager, remoteEvent *coreV1.Event, lastTimestamp time.Time) error {
+	hash := sha1.Sum([]byte(string(remoteEvent.UID)))
+	key := types.NamespacedName{
+		Name:      clm.Name + "-" + hex.EncodeToString(hash[:])[:10],
+		Namespace: clm.Namespace,
+	}
+
+	involved := remoteEvent.InvolvedObject
+	message := "[" + involved.Kind + " " + involved.Namespace + "/" + involved.Name + "] " + remoteEvent.Message
+	count := remoteEvent.Count
+	if count == 0 {
+		count = 1
+	}
+
+	mirroredEvent := &coreV1.Event{}
+	if err := r.Client.Get(context.TODO(), key, mirroredEvent); errors.IsNotFound(err) {
+		firstTimestamp := remoteEvent.FirstTimestamp
+		if firstTimestamp.IsZero() {
+			firstTimestamp = metav1.NewTime(lastTimestamp)
+		}
+		mirroredEvent = &coreV1.Event{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      key.Name,
+				Namespace: key.Namespace,
+				Labels: map[string]string{
+					clusterV1alpha1.LabelKeyClmName: clm.Name,
+				},
+			},
+			InvolvedObject: coreV1.ObjectReference{
+				APIVersion: clusterV1alpha1.GroupVersion.String(),
+				Kind:       "ClusterManager",
+				Name:       clm.Name,
+				Namespace:  clm.Namespace,
+				UID:        clm.UID,
+			},
+			Reason:         remoteEvent.Reason,
+			Message:        message,
+			Type:           coreV1.EventTypeWarning,
+			Count:          count,
+			FirstTimestamp: firstTimestamp,
+			LastTimestamp:  metav1.NewTime(lastTimestamp),
+			Source: coreV1.EventSource{
+				Component: remoteEventComponent,
+			},
+		}
+		return r.Client.Create(context.TODO(), mirroredEvent)
+	} else if err != nil {
+		return err
+	}
+
+	if mirroredEvent.Count == count && mirroredEvent.LastTimestamp.Time.Equal(lastTimestamp) {
+		return nil
+	}
+	mirroredEvent.Count = count
+	mirroredEvent.Message = message
+	mirroredEvent.LastTimestamp = metav1.NewTime(lastTimestamp)
+	return r.Client.Update(context.TODO(), mirroredEvent)
+}
+
+// node 의 ready 상태가 변경되면 cluster manager 에 event 를 기록한다.
+func (r *RemoteEventReconciler) mirrorNodeTransitions(remoteClient client.Client, clm *clusterV1alpha1.ClusterManager) error {
+	nodeList := &coreV1.NodeList{}
+	current := map[string]bool{}
+	err := util.ListPages(context.TODO(), remoteClient, nodeList, func() (bool, error) {
+		for _, node := range nodeList.Items {
+			ready := false
+			for _, condition := range node.Status.Conditions {
+				if condition.Type == coreV1.NodeReady {
+					ready = condition.Status == coreV1.ConditionTrue
+				}
+			}
+			current[node.Name] = ready
+		}
+		return true, nil
+	})
+	if err != nil {
+		return util.ClassifyRemoteError(err)
+	}
+
+	r.mutex.Lock()
+	previous, observed := r.nodeReady[clm.GetNamespacedName()]
+	r.nodeReady[clm.GetNamespacedName()] = current
+	r.mutex.Unlock()
+
+	for name, ready := range current {
+		wasReady, ok := previous[name]
+		switch {
+		case !ready && (!observed || !ok || wasReady):
+			// 처음 확인하는 node 도 NotReady 인 경우에는 알려준다.
+			r.Recorder.Event(clm, coreV1.EventTypeWarning, "NodeNotReady", "Node ["+name+"] of the cluster is not ready")
+		case ready && ok && !wasReady:
+			r.Recorder.Event(clm, coreV1.EventTypeNormal, "NodeReady", "Node ["+name+"] of the cluster is ready")
+		}
+	}
+	return nil
+}
+
+func (r *RemoteEventReconciler) getLastSyncTime(key types.NamespacedName, now time.Time) time.Time {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if t, ok := r.lastSyncTime[key]; ok {
+		return t
+	}
+	return now.Add(-remoteEventInitialWindow)
+}
+
+func (r *RemoteEventReconciler) setLastSyncTime(key types.NamespacedName, t time.Time) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.lastSyncTime[key] = t
+}
+
+func (r *RemoteEventReconciler) forget(key types.NamespacedName) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	delete(r.lastSyncTime, key)
+	delete(r.nodeReady, key)
+}
+
+func (r *RemoteEventReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	r.lastSyncTime = map[types.NamespacedName]time.Time{}
+	r.nodeReady = map[types.NamespacedName]map[string]bool{}
+
+	return ctrl.NewControllerManagedBy(mgr).
+		Named("remoteevent").
+		For(&clusterV1alpha1.ClusterManager{}).
+		WithOptions(util.DefaultControllerOptions()).
+		WithEventFilter(
+			predicate.Funcs{
+				CreateFunc: func(e event.CreateEvent) bool {
+					return true
+				},
+				UpdateFunc: func(e event.UpdateEvent) bool {
+					oldClm := e.ObjectOld.(*clusterV1alpha1.ClusterManager)
+					newClm := e.ObjectNew.(*clusterV1alpha1.ClusterManager)
+					// 이후에는 주기적으로 requeue 되므로 control plane 이 준비된 경우만 확인한다.
+					return !oldClm.Status.ControlPlaneReady && newClm.Status.ControlPlaneReady
+				},
+				DeleteFunc: func(e event.DeleteEvent) bool {
+					return true
+				},
+				GenericFunc: func(e event.GenericEvent) bool {
+					return false
+				},
+			},
+		).
+		Complete(util.ShardReconciler(mgr.GetClient(), r))
+}
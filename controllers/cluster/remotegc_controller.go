@@ -0,0 +1,238 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-logr/logr"
+	clusterV1alpha1 "github.com/tmax-cloud/hypercloud-multi-operator/apis/cluster/v1alpha1"
+	"github.com/tmax-cloud/hypercloud-multi-operator/controllers/metrics"
+	util "github.com/tmax-cloud/hypercloud-multi-operator/controllers/util"
+
+	coreV1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+)
+
+const (
+	// member cluster 의 모든 resource 를 조회하므로 긴 주기로 수행한다.
+	remoteGCInterval = 30 * time.Minute
+	// 적용 직후 owner 의 status 가 갱신되기 전에 삭제하지 않도록, 생성된 지 오래되지 않은 object 는 건너뛴다.
+	remoteGCGracePeriod = 10 * time.Minute
+
+	reasonRemoteObjectsPruned = "RemoteObjectsPruned"
+)
+
+// RemoteGCReconciler periodically deletes the objects which the operator created in each member cluster
+// but are no longer desired by their owners, such as removed addons, policies and distributed manifests.
+type RemoteGCReconciler struct {
+	client.Client
+	Log      logr.Logger
+	Scheme   *runtime.Scheme
+	Recorder record.EventRecorder
+}
+
+// +kubebuilder:rbac:groups=cluster.tmax.io,resources=clusterpolicies,verbs=get;list;watch
+// +kubebuilder:rbac:groups=cluster.tmax.io,resources=workloaddistributions,verbs=get;list;watch
+
+func (r *RemoteGCReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	_ = context.Background()
+	log := r.Log.WithValues("clustermanager", req.NamespacedName)
+
+	clm := &clusterV1alpha1.ClusterManager{}
+	if err := r.Client.Get(context.TODO(), req.NamespacedName, clm); errors.IsNotFound(err) {
+		return ctrl.Result{}, nil
+	} else if err != nil {
+		log.Error(err, "Failed to get ClusterManager")
+		return ctrl.Result{}, err
+	}
+
+	// 삭제중인 cluster 는 각 owner 의 finalizer 가 정리한다.
+	if !clm.DeletionTimestamp.IsZero() || !clm.Status.ControlPlaneReady {
+		return ctrl.Result{}, nil
+	}
+
+	pruned, err := r.prune(ctx, clm)
+	if err != nil {
+		// 일시적으로 접근할 수 없는 경우 다음 주기에 다시 수행한다.
+		log.Info("Failed to prune remote objects", "reason", err.Error())
+		return util.RequeueAfterWithJitter(remoteGCInterval), nil
+	}
+	if pruned != 0 {
+		log.Info("Pruned remote objects successfully", "count", pruned)
+		r.Recorder.Event(clm, coreV1.EventTypeNormal, reasonRemoteObjectsPruned,
+			fmt.Sprintf("%d objects no longer desired are deleted from the cluster", pruned))
+	}
+
+	return util.RequeueAfterWithJitter(remoteGCInterval), nil
+}
+
+// operator 가 생성한 object 중 owner 가 더 이상 원하지 않는 object 를 삭제하고, 삭제한 object 의 수를 반환한다.
+func (r *RemoteGCReconciler) prune(ctx context.Context, clm *clusterV1alpha1.ClusterManager) (int, error) {
+	kubeconfigSecret, err := util.GetKubeconfigSecret(ctx, r.Client, clm.Namespace, clm.Name)
+	if err != nil {
+		return 0, err
+	}
+	remoteClientset, err := util.GetRemoteK8sClient(kubeconfigSecret)
+	if err != nil {
+		return 0, err
+	}
+	remoteClient, err := util.GetRemoteK8sRuntimeClient(kubeconfigSecret)
+	if err != nil {
+		return 0, err
+	}
+
+	objects, err := util.ListRemoteManagedObjects(ctx, remoteClientset, remoteClient)
+	if err != nil {
+		return 0, err
+	}
+
+	pruned := 0
+	for i := range objects {
+		obj := &objects[i]
+		owner, ok := util.GetRemoteOwner(obj)
+		if !ok || !obj.DeletionTimestamp.IsZero() || time.Since(obj.CreationTimestamp.Time) < remoteGCGracePeriod {
+			continue
+		}
+		desired, err := r.isDesired(ctx, clm, owner, obj)
+		if err != nil {
+			return pruned, err
+		} else if desired {
+			continue
+		}
+
+		propagation := client.PropagationPolicy(metav1.DeletePropagationBackground)
+		if err := remoteClient.Delete(ctx, obj, propagation); err != nil && !errors.IsNotFound(err) {
+			return pruned, util.ClassifyRemoteError(err)
+		}
+		r.Log.Info("Deleted remote object no longer desired",
+			"clustermanager", clm.GetNamespacedName(), "kind", obj.Kind, "namespace", obj.Namespace, "name", obj.Name, "owner", owner)
+		metrics.RemoteGCDeletedTotal.WithLabelValues(owner.Kind).Inc()
+		pruned++
+	}
+	return pruned, nil
+}
+
+// owner 의 현재 spec 이 cluster 에 object 를 적용하도록 하는지 확인한다.
+// 알 수 없는 종류의 owner 가 생성한 object 는 삭제하지 않는다.
+func (r *RemoteGCReconciler) isDesired(ctx context.Context, clm *clusterV1alpha1.ClusterManager,
+	owner util.RemoteOwner, obj *metav1.PartialObjectMetadata) (bool, error) {
+	key := types.NamespacedName{Name: owner.Name, Namespace: owner.Namespace}
+
+	switch owner.Kind {
+	case util.RemoteOwnerKindWorkloadDistribution:
+		wd := &clusterV1alpha1.WorkloadDistribution{}
+		if err := r.Client.Get(ctx, key, wd); errors.IsNotFound(err) {
+			return false, nil
+		} else if err != nil {
+			return false, err
+		}
+		// 삭제중인 owner 는 finalizer 가 정리한다.
+		if !wd.DeletionTimestamp.IsZero() {
+			return true, nil
+		}
+		if wd.Namespace != clm.Namespace || wd.Status.GetClusterStatus(clm.Name) == nil {
+			return false, nil
+		}
+		objs, err := buildDistributionResources(wd)
+		if err != nil {
+			return true, nil
+		}
+		return containsRemoteObject(objs, obj), nil
+
+	case util.RemoteOwnerKindClusterPolicy:
+		policy := &clusterV1alpha1.ClusterPolicy{}
+		if err := r.Client.Get(ctx, key, policy); errors.IsNotFound(err) {
+			return false, nil
+		} else if err != nil {
+			return false, err
+		}
+		if !policy.DeletionTimestamp.IsZero() {
+			return true, nil
+		}
+		if policy.Namespace != clm.Namespace || policy.Status.GetClusterStatus(clm.Name) == nil {
+			return false, nil
+		}
+		objs, err := buildPolicyResources(policy)
+		if err != nil {
+			return true, nil
+		}
+		return containsRemoteObject(objs, obj), nil
+
+	case util.RemoteOwnerKindCertManagerAddon:
+		// 같은 cluster 를 다른 cluster manager 로 등록한 경우를 위해 owner 를 조회한다.
+		ownerClm := clm
+		if key != clm.GetNamespacedName() {
+			ownerClm = &clusterV1alpha1.ClusterManager{}
+			if err := r.Client.Get(ctx, key, ownerClm); errors.IsNotFound(err) {
+				return false, nil
+			} else if err != nil {
+				return false, err
+			}
+		}
+		return ownerClm.GetCertManagerAddon() != nil, nil
+	}
+	return true, nil
+}
+
+// version 은 조회한 api 에 따라 다를 수 있으므로 group, kind, namespace, name 으로 비교한다.
+func containsRemoteObject(objs []*unstructured.Unstructured, obj *metav1.PartialObjectMetadata) bool {
+	groupKind := obj.GroupVersionKind().GroupKind()
+	for _, o := range objs {
+		if o.GroupVersionKind().GroupKind() == groupKind && o.GetNamespace() == obj.Namespace && o.GetName() == obj.Name {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *RemoteGCReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		Named("remotegc").
+		For(&clusterV1alpha1.ClusterManager{}).
+		WithOptions(util.DefaultControllerOptions()).
+		WithEventFilter(
+			predicate.Funcs{
+				CreateFunc: func(e event.CreateEvent) bool {
+					return true
+				},
+				UpdateFunc: func(e event.UpdateEvent) bool {
+					oldClm := e.ObjectOld.(*clusterV1alpha1.ClusterManager)
+					newClm := e.ObjectNew.(*clusterV1alpha1.ClusterManager)
+					// 준비된 이후에는 주기적으로 requeue 된다.
+					return !oldClm.Status.ControlPlaneReady && newClm.Status.ControlPlaneReady
+				},
+				DeleteFunc: func(e event.DeleteEvent) bool {
+					return false
+				},
+				GenericFunc: func(e event.GenericEvent) bool {
+					return false
+				},
+			},
+		).
+		Complete(util.ShardReconciler(mgr.GetClient(), r))
+}
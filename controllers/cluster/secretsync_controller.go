@@ -0,0 +1,251 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"reflect"
+
+	"github.com/go-logr/logr"
+	clusterV1alpha1 "github.com/tmax-cloud/hypercloud-multi-operator/apis/cluster/v1alpha1"
+	"github.com/tmax-cloud/hypercloud-multi-operator/controllers/pipeline"
+	"github.com/tmax-cloud/hypercloud-multi-operator/controllers/util"
+
+	coreV1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+
+	"sigs.k8s.io/cluster-api/util/patch"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+// SecretSyncReconciler reconciles a SecretSync object
+type SecretSyncReconciler struct {
+	client.Client
+	Log      logr.Logger
+	Scheme   *runtime.Scheme
+	Recorder record.EventRecorder
+}
+
+// +kubebuilder:rbac:groups=cluster.tmax.io,resources=secretsyncs,verbs=create;delete;get;list;patch;update;watch
+// +kubebuilder:rbac:groups=cluster.tmax.io,resources=secretsyncs/status,verbs=get;patch;update
+
+func (r *SecretSyncReconciler) Reconcile(ctx context.Context, req ctrl.Request) (_ ctrl.Result, reterr error) {
+	_ = context.Background()
+	log := r.Log.WithValues("secretsync", req.NamespacedName)
+
+	// get SecretSync
+	secretSync := &clusterV1alpha1.SecretSync{}
+	if err := r.Client.Get(context.TODO(), req.NamespacedName, secretSync); errors.IsNotFound(err) {
+		log.Info("SecretSync not found. Ignoring since object must be deleted")
+		pipeline.Forget(secretSync, req.NamespacedName)
+		return ctrl.Result{}, nil
+	} else if err != nil {
+		log.Error(err, "Failed to get SecretSync")
+		return ctrl.Result{}, err
+	}
+
+	patchHelper, err := patch.NewHelper(secretSync, r.Client)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	defer func() {
+		r.reconcilePhase(context.TODO(), secretSync)
+
+		if err := patchHelper.Patch(context.TODO(), secretSync); err != nil {
+			reterr = err
+		}
+	}()
+
+	// Add finalizer first if not exist to avoid the race condition between init and delete
+	if !controllerutil.ContainsFinalizer(secretSync, clusterV1alpha1.SecretSyncFinalizer) {
+		controllerutil.AddFinalizer(secretSync, clusterV1alpha1.SecretSyncFinalizer)
+		return ctrl.Result{}, nil
+	}
+
+	// Handle deletion reconciliation loop.
+	if !secretSync.ObjectMeta.DeletionTimestamp.IsZero() {
+		return r.reconcileDelete(context.TODO(), secretSync)
+	}
+
+	// Handle normal reconciliation loop.
+	return r.reconcile(context.TODO(), secretSync)
+}
+
+// reconcile handles secret sync reconciliation.
+func (r *SecretSyncReconciler) reconcile(ctx context.Context, secretSync *clusterV1alpha1.SecretSync) (ctrl.Result, error) {
+	phases := []pipeline.Phase[*clusterV1alpha1.SecretSync]{
+		// 더 이상 선택되지 않는 cluster 에 복제했던 secret 을 삭제한다.
+		{Name: "PruneUnselectedClusters", Run: r.PruneUnselectedClusters},
+		// 선택된 cluster 들에 secret 을 복제하고, 변경된 secret 을 다시 동기화한다.
+		{Name: "SyncSecrets", Run: r.SyncSecrets},
+	}
+
+	return pipeline.NewRunner[*clusterV1alpha1.SecretSync](r.Log, r.Recorder).Run(ctx, secretSync, phases)
+}
+
+func (r *SecretSyncReconciler) reconcileDelete(ctx context.Context, secretSync *clusterV1alpha1.SecretSync) (ctrl.Result, error) {
+	log := r.Log.WithValues("secretsync", secretSync.GetNamespacedName())
+	log.Info("Start to reconcile delete for SecretSync")
+
+	// 모든 cluster 에 복제했던 secret 을 삭제한다.
+	remains := []clusterV1alpha1.SecretSyncClusterStatus{}
+	for _, clusterStatus := range secretSync.Status.Clusters {
+		if err := r.pruneCluster(secretSync, clusterStatus.Name, clusterStatus.Resources); err != nil {
+			log.Error(err, "Failed to delete resources from cluster", "cluster", clusterStatus.Name)
+			remains = append(remains, clusterStatus)
+		}
+	}
+	secretSync.Status.Clusters = remains
+	if len(remains) > 0 {
+		return ctrl.Result{Requeue: true}, nil
+	}
+
+	controllerutil.RemoveFinalizer(secretSync, clusterV1alpha1.SecretSyncFinalizer)
+	log.Info("SecretSync is removed successfully")
+	return ctrl.Result{}, nil
+}
+
+func (r *SecretSyncReconciler) reconcilePhase(_ context.Context, secretSync *clusterV1alpha1.SecretSync) {
+	if !secretSync.DeletionTimestamp.IsZero() {
+		secretSync.Status.SetTypedPhase(clusterV1alpha1.SecretSyncPhaseDeleting)
+		return
+	}
+
+	for _, clusterStatus := range secretSync.Status.Clusters {
+		if !clusterStatus.Synced {
+			secretSync.Status.SetTypedPhase(clusterV1alpha1.SecretSyncPhaseProgressing)
+			return
+		}
+	}
+	if secretSync.Status.Reason != "" {
+		secretSync.Status.SetTypedPhase(clusterV1alpha1.SecretSyncPhaseProgressing)
+		return
+	}
+	secretSync.Status.SetTypedPhase(clusterV1alpha1.SecretSyncPhaseSynced)
+}
+
+func (r *SecretSyncReconciler) requeueSecretSyncsForClusterManager(o client.Object) []ctrl.Request {
+	clm := o.DeepCopyObject().(*clusterV1alpha1.ClusterManager)
+	log := r.Log.WithValues("SecretSync-ObjectMapper", "clusterManagerToSecretSyncs", "ClusterManager", clm.GetNamespacedName())
+
+	syncList := &clusterV1alpha1.SecretSyncList{}
+	if err := r.Client.List(context.TODO(), syncList, client.InNamespace(clm.Namespace)); err != nil {
+		log.Error(err, "Failed to list SecretSync")
+		return nil
+	}
+
+	// selector 까지 확인하지 않고, 같은 namespace 의 secret sync 를 모두 requeue 한다.
+	reqs := []ctrl.Request{}
+	for _, secretSync := range syncList.Items {
+		reqs = append(reqs, ctrl.Request{NamespacedName: secretSync.GetNamespacedName()})
+	}
+	return reqs
+}
+
+func (r *SecretSyncReconciler) requeueSecretSyncsForSecret(o client.Object) []ctrl.Request {
+	secret := o.DeepCopyObject().(*coreV1.Secret)
+	log := r.Log.WithValues("SecretSync-ObjectMapper", "secretToSecretSyncs", "Secret", types.NamespacedName{Name: secret.Name, Namespace: secret.Namespace})
+
+	syncList := &clusterV1alpha1.SecretSyncList{}
+	if err := r.Client.List(context.TODO(), syncList, client.InNamespace(secret.Namespace)); err != nil {
+		log.Error(err, "Failed to list SecretSync")
+		return nil
+	}
+
+	reqs := []ctrl.Request{}
+	for _, secretSync := range syncList.Items {
+		for _, source := range secretSync.Spec.Secrets {
+			if source.Name == secret.Name {
+				reqs = append(reqs, ctrl.Request{NamespacedName: secretSync.GetNamespacedName()})
+				break
+			}
+		}
+	}
+	return reqs
+}
+
+func (r *SecretSyncReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	controller, err := ctrl.NewControllerManagedBy(mgr).
+		For(&clusterV1alpha1.SecretSync{}).
+		WithOptions(util.DefaultControllerOptions()).
+		WithEventFilter(util.SpecChangedPredicate()).
+		Build(util.ShardReconciler(mgr.GetClient(), r))
+
+	if err != nil {
+		return err
+	}
+
+	// 원본 secret 이 변경되면 다시 동기화한다.
+	err = controller.Watch(
+		&source.Kind{Type: &coreV1.Secret{}},
+		handler.EnqueueRequestsFromMapFunc(r.requeueSecretSyncsForSecret),
+		predicate.Funcs{
+			CreateFunc: func(e event.CreateEvent) bool {
+				return true
+			},
+			UpdateFunc: func(e event.UpdateEvent) bool {
+				oldSecret := e.ObjectOld.(*coreV1.Secret)
+				newSecret := e.ObjectNew.(*coreV1.Secret)
+				return !reflect.DeepEqual(oldSecret.Data, newSecret.Data) || oldSecret.Type != newSecret.Type
+			},
+			DeleteFunc: func(e event.DeleteEvent) bool {
+				return true
+			},
+			GenericFunc: func(e event.GenericEvent) bool {
+				return false
+			},
+		},
+	)
+	if err != nil {
+		return err
+	}
+
+	// cluster 의 label 이 변경되거나 control plane 이 준비되면, secret 을 다시 동기화한다.
+	return controller.Watch(
+		&source.Kind{Type: &clusterV1alpha1.ClusterManager{}},
+		handler.EnqueueRequestsFromMapFunc(r.requeueSecretSyncsForClusterManager),
+		predicate.Funcs{
+			CreateFunc: func(e event.CreateEvent) bool {
+				return false
+			},
+			UpdateFunc: func(e event.UpdateEvent) bool {
+				oldClm := e.ObjectOld.(*clusterV1alpha1.ClusterManager)
+				newClm := e.ObjectNew.(*clusterV1alpha1.ClusterManager)
+				if !labels.Equals(oldClm.Labels, newClm.Labels) ||
+					oldClm.Status.ControlPlaneReady != newClm.Status.ControlPlaneReady {
+					return true
+				}
+				return false
+			},
+			DeleteFunc: func(e event.DeleteEvent) bool {
+				return true
+			},
+			GenericFunc: func(e event.GenericEvent) bool {
+				return false
+			},
+		},
+	)
+}
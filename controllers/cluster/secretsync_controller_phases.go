@@ -0,0 +1,258 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"sort"
+
+	clusterV1alpha1 "github.com/tmax-cloud/hypercloud-multi-operator/apis/cluster/v1alpha1"
+	util "github.com/tmax-cloud/hypercloud-multi-operator/controllers/util"
+
+	coreV1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func (r *SecretSyncReconciler) PruneUnselectedClusters(ctx context.Context, secretSync *clusterV1alpha1.SecretSync) (ctrl.Result, error) {
+	log := r.Log.WithValues("secretsync", secretSync.GetNamespacedName())
+	log.Info("Start to reconcile phase for PruneUnselectedClusters")
+
+	clmList, err := r.selectClusters(secretSync)
+	if err != nil {
+		log.Error(err, "Failed to select ClusterManagers")
+		return ctrl.Result{}, err
+	}
+	selected := map[string]bool{}
+	for _, clm := range clmList {
+		selected[clm.Name] = true
+	}
+
+	clusters := []clusterV1alpha1.SecretSyncClusterStatus{}
+	for _, clusterStatus := range secretSync.Status.Clusters {
+		if selected[clusterStatus.Name] {
+			clusters = append(clusters, clusterStatus)
+			continue
+		}
+		if err := r.pruneCluster(secretSync, clusterStatus.Name, clusterStatus.Resources); err != nil {
+			log.Error(err, "Failed to delete secrets from unselected cluster", "cluster", clusterStatus.Name)
+			clusterStatus.Synced = false
+			clusterStatus.Reason = "Failed to delete secrets: " + err.Error()
+			clusters = append(clusters, clusterStatus)
+			continue
+		}
+		log.Info("Deleted secrets from unselected cluster successfully", "cluster", clusterStatus.Name)
+	}
+	secretSync.Status.Clusters = clusters
+
+	return ctrl.Result{}, nil
+}
+
+func (r *SecretSyncReconciler) SyncSecrets(ctx context.Context, secretSync *clusterV1alpha1.SecretSync) (ctrl.Result, error) {
+	log := r.Log.WithValues("secretsync", secretSync.GetNamespacedName())
+	log.Info("Start to reconcile phase for SyncSecrets")
+
+	objs := []*unstructured.Unstructured{}
+	for _, source := range secretSync.Spec.Secrets {
+		secret := &coreV1.Secret{}
+		key := types.NamespacedName{
+			Name:      source.Name,
+			Namespace: secretSync.Namespace,
+		}
+		if err := r.Client.Get(context.TODO(), key, secret); errors.IsNotFound(err) {
+			// secret 이 생성되면 watch 에 의해 다시 reconcile 된다.
+			log.Info("Secret not found", "secret", source.Name)
+			secretSync.Status.Reason = "Secret [" + source.Name + "] not found"
+			return ctrl.Result{}, nil
+		} else if err != nil {
+			log.Error(err, "Failed to get Secret", "secret", source.Name)
+			return ctrl.Result{}, err
+		}
+
+		obj, err := r.buildTargetSecret(secretSync, source, secret)
+		if err != nil {
+			log.Error(err, "Failed to build Secret", "secret", source.Name)
+			return ctrl.Result{}, err
+		}
+		objs = append(objs, obj)
+	}
+	secretSync.Status.Reason = ""
+
+	clmList, err := r.selectClusters(secretSync)
+	if err != nil {
+		log.Error(err, "Failed to select ClusterManagers")
+		return ctrl.Result{}, err
+	}
+
+	for _, clm := range clmList {
+		clusterStatus := secretSync.Status.GetClusterStatus(clm.Name)
+		if clusterStatus == nil {
+			secretSync.Status.Clusters = append(secretSync.Status.Clusters, clusterV1alpha1.SecretSyncClusterStatus{Name: clm.Name})
+			clusterStatus = &secretSync.Status.Clusters[len(secretSync.Status.Clusters)-1]
+		}
+
+		if !clm.Status.ControlPlaneReady {
+			clusterStatus.Synced = false
+			clusterStatus.Reason = "Wait for control plane to be ready"
+			continue
+		}
+
+		if err := r.syncCluster(&clm, objs, clusterStatus); err != nil {
+			log.Error(err, "Failed to sync secrets to cluster", "cluster", clm.Name)
+			clusterStatus.Synced = false
+			clusterStatus.Reason = err.Error()
+			continue
+		}
+		clusterStatus.Synced = true
+		clusterStatus.Reason = ""
+		clusterStatus.LastSyncTime = metav1.Now()
+	}
+
+	sort.Slice(secretSync.Status.Clusters, func(i, j int) bool {
+		return secretSync.Status.Clusters[i].Name < secretSync.Status.Clusters[j].Name
+	})
+
+	// member cluster 에서 secret 이 변경되거나 삭제되는 경우를 감지하기 위해 주기적으로 확인한다.
+	return util.RequeueAfterWithJitter(getResyncPeriod()), nil
+}
+
+// remote cluster 의 secret 의 hash 가 원본과 다른 경우에만 다시 적용한다.
+func (r *SecretSyncReconciler) syncCluster(clm *clusterV1alpha1.ClusterManager, objs []*unstructured.Unstructured,
+	clusterStatus *clusterV1alpha1.SecretSyncClusterStatus) error {
+	remoteClient, err := getRemoteRuntimeClient(r.Client, clm)
+	if err != nil {
+		return err
+	}
+
+	synced := []clusterV1alpha1.ManifestStatus{}
+	syncedKeys := map[string]bool{}
+	for _, obj := range objs {
+		hash := obj.GetAnnotations()[clusterV1alpha1.AnnotationKeySecretSyncHash]
+
+		remoteSecret := &coreV1.Secret{}
+		key := types.NamespacedName{
+			Name:      obj.GetName(),
+			Namespace: obj.GetNamespace(),
+		}
+		err := remoteClient.Get(context.TODO(), key, remoteSecret)
+		switch {
+		case errors.IsNotFound(err):
+		case err != nil:
+			return util.ClassifyRemoteError(err)
+		case remoteSecret.Annotations[clusterV1alpha1.AnnotationKeySecretSyncHash] == hash && getSecretHash(remoteSecret) == hash:
+			// 변경 사항이 없는 경우
+			resource := getManifestStatus(obj)
+			synced = append(synced, resource)
+			syncedKeys[resource.Key()] = true
+			continue
+		case remoteSecret.Annotations[clusterV1alpha1.AnnotationKeySecretSyncHash] == hash:
+			// 원본은 그대로인데 remote 의 data 가 변경된 경우
+			clusterStatus.DriftCount++
+		}
+
+		if err := applyRemoteObject(remoteClient, obj); err != nil {
+			return err
+		}
+		resource := getManifestStatus(obj)
+		synced = append(synced, resource)
+		syncedKeys[resource.Key()] = true
+	}
+
+	if err := pruneManifestResources(remoteClient, clusterStatus.Resources, syncedKeys); err != nil {
+		return err
+	}
+	clusterStatus.Resources = synced
+	return nil
+}
+
+func (r *SecretSyncReconciler) buildTargetSecret(secretSync *clusterV1alpha1.SecretSync, source clusterV1alpha1.SecretSyncSource,
+	secret *coreV1.Secret) (*unstructured.Unstructured, error) {
+	target := &coreV1.Secret{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: coreV1.SchemeGroupVersion.String(),
+			Kind:       "Secret",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      source.GetTargetName(),
+			Namespace: source.TargetNamespace,
+			Labels: map[string]string{
+				clusterV1alpha1.LabelKeySecretSync:          secretSync.Name,
+				clusterV1alpha1.LabelKeySecretSyncNamespace: secretSync.Namespace,
+			},
+			Annotations: map[string]string{
+				clusterV1alpha1.AnnotationKeySecretSyncHash: getSecretHash(secret),
+			},
+		},
+		Type: secret.Type,
+		Data: secret.Data,
+	}
+	return convertToUnstructured(target)
+}
+
+// secret 의 type 과 data 로 계산한 hash
+func getSecretHash(secret *coreV1.Secret) string {
+	keys := []string{}
+	for k := range secret.Data {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	h := sha256.New()
+	h.Write([]byte(secret.Type))
+	for _, k := range keys {
+		h.Write([]byte{0})
+		h.Write([]byte(k))
+		h.Write([]byte{0})
+		h.Write(secret.Data[k])
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func (r *SecretSyncReconciler) pruneCluster(secretSync *clusterV1alpha1.SecretSync, clusterName string, resources []clusterV1alpha1.ManifestStatus) error {
+	return pruneRemoteResources(r.Client, secretSync.Namespace, clusterName, resources)
+}
+
+// spec 의 cluster selector 에 해당하는 cluster manager 목록
+func (r *SecretSyncReconciler) selectClusters(secretSync *clusterV1alpha1.SecretSync) ([]clusterV1alpha1.ClusterManager, error) {
+	selector, err := secretSync.GetClusterSelector()
+	if err != nil {
+		return nil, err
+	}
+	clmList := &clusterV1alpha1.ClusterManagerList{}
+	opts := []client.ListOption{
+		client.InNamespace(secretSync.Namespace),
+		client.MatchingLabelsSelector{Selector: selector},
+	}
+	if err := r.Client.List(context.TODO(), clmList, opts...); err != nil {
+		return nil, err
+	}
+
+	result := []clusterV1alpha1.ClusterManager{}
+	for _, clm := range clmList.Items {
+		// 삭제중인 cluster 에는 복제하지 않는다.
+		if !clm.DeletionTimestamp.IsZero() {
+			continue
+		}
+		result = append(result, clm)
+	}
+	return result, nil
+}
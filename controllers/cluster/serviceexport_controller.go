@@ -0,0 +1,200 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"sort"
+
+	"github.com/go-logr/logr"
+	clusterV1alpha1 "github.com/tmax-cloud/hypercloud-multi-operator/apis/cluster/v1alpha1"
+	"github.com/tmax-cloud/hypercloud-multi-operator/controllers/pipeline"
+	"github.com/tmax-cloud/hypercloud-multi-operator/controllers/util"
+
+	coreV1 "k8s.io/api/core/v1"
+	discoveryV1 "k8s.io/api/discovery/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+
+	"sigs.k8s.io/cluster-api/util/patch"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// ServiceExportReconciler reconciles a ServiceExport object
+type ServiceExportReconciler struct {
+	client.Client
+	Log      logr.Logger
+	Scheme   *runtime.Scheme
+	Recorder record.EventRecorder
+}
+
+// +kubebuilder:rbac:groups=cluster.tmax.io,resources=serviceexports,verbs=create;delete;get;list;patch;update;watch
+// +kubebuilder:rbac:groups=cluster.tmax.io,resources=serviceexports/status,verbs=get;patch;update
+
+func (r *ServiceExportReconciler) Reconcile(ctx context.Context, req ctrl.Request) (_ ctrl.Result, reterr error) {
+	_ = context.Background()
+	log := r.Log.WithValues("serviceexport", req.NamespacedName)
+
+	// get ServiceExport
+	serviceExport := &clusterV1alpha1.ServiceExport{}
+	if err := r.Client.Get(context.TODO(), req.NamespacedName, serviceExport); errors.IsNotFound(err) {
+		log.Info("ServiceExport not found. Ignoring since object must be deleted")
+		pipeline.Forget(serviceExport, req.NamespacedName)
+		return ctrl.Result{}, nil
+	} else if err != nil {
+		log.Error(err, "Failed to get ServiceExport")
+		return ctrl.Result{}, err
+	}
+
+	patchHelper, err := patch.NewHelper(serviceExport, r.Client)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	defer func() {
+		if err := patchHelper.Patch(context.TODO(), serviceExport); err != nil {
+			reterr = err
+		}
+	}()
+
+	// Handle normal reconciliation loop.
+	return r.reconcile(context.TODO(), serviceExport)
+}
+
+// reconcile handles service export reconciliation.
+func (r *ServiceExportReconciler) reconcile(ctx context.Context, serviceExport *clusterV1alpha1.ServiceExport) (ctrl.Result, error) {
+	phases := []pipeline.Phase[*clusterV1alpha1.ServiceExport]{
+		// member cluster 의 service 와 endpoint slice 를 조회해 status 에 반영한다.
+		{Name: "SyncServiceEndpoints", Run: r.SyncServiceEndpoints},
+	}
+
+	return pipeline.NewRunner[*clusterV1alpha1.ServiceExport](r.Log, r.Recorder).Run(ctx, serviceExport, phases)
+}
+
+func (r *ServiceExportReconciler) SyncServiceEndpoints(ctx context.Context, serviceExport *clusterV1alpha1.ServiceExport) (ctrl.Result, error) {
+	log := r.Log.WithValues("serviceexport", serviceExport.GetNamespacedName())
+	log.Info("Start to reconcile phase for SyncServiceEndpoints")
+
+	key := types.NamespacedName{
+		Name:      serviceExport.Spec.ClusterName,
+		Namespace: serviceExport.Namespace,
+	}
+	clm := &clusterV1alpha1.ClusterManager{}
+	if err := r.Client.Get(context.TODO(), key, clm); errors.IsNotFound(err) {
+		log.Info("ClusterManager not found", "clusterManager", key.Name)
+		serviceExport.Status.Ready = false
+		serviceExport.Status.Reason = "Cluster not found"
+		return ctrl.Result{Requeue: true}, nil
+	} else if err != nil {
+		log.Error(err, "Failed to get ClusterManager")
+		return ctrl.Result{}, err
+	}
+
+	if !clm.Status.ControlPlaneReady {
+		serviceExport.Status.Ready = false
+		serviceExport.Status.Reason = "Wait for control plane to be ready"
+		return ctrl.Result{Requeue: true}, nil
+	}
+
+	remoteClient, err := getRemoteRuntimeClient(r.Client, clm)
+	if err != nil {
+		log.Error(err, "Failed to get remote cluster client")
+		return ctrl.Result{}, err
+	}
+
+	service := &coreV1.Service{}
+	key = types.NamespacedName{
+		Name:      serviceExport.Spec.ServiceName,
+		Namespace: serviceExport.Spec.ServiceNamespace,
+	}
+	if err := remoteClient.Get(context.TODO(), key, service); errors.IsNotFound(err) {
+		log.Info("Service not found in cluster", "service", key.String(), "cluster", clm.Name)
+		serviceExport.Status.Ready = false
+		serviceExport.Status.Reason = "Service not found"
+		serviceExport.Status.Ports = nil
+		serviceExport.Status.Addresses = nil
+		return ctrl.Result{Requeue: true}, nil
+	} else if err != nil {
+		log.Error(err, "Failed to get Service")
+		return ctrl.Result{}, util.ClassifyRemoteError(err)
+	}
+
+	sliceList := &discoveryV1.EndpointSliceList{}
+	opts := []client.ListOption{
+		client.InNamespace(service.Namespace),
+		client.MatchingLabels{discoveryV1.LabelServiceName: service.Name},
+	}
+	if err := remoteClient.List(context.TODO(), sliceList, opts...); err != nil {
+		log.Error(err, "Failed to list EndpointSlices")
+		return ctrl.Result{}, util.ClassifyRemoteError(err)
+	}
+
+	// endpoint slice 의 port 는 service port 의 이름으로 매칭된다.
+	targetPorts := map[string]int32{}
+	addresses := map[string]bool{}
+	for _, slice := range sliceList.Items {
+		for _, port := range slice.Ports {
+			if port.Name != nil && port.Port != nil {
+				targetPorts[*port.Name] = *port.Port
+			} else if port.Port != nil {
+				targetPorts[""] = *port.Port
+			}
+		}
+		for _, endpoint := range slice.Endpoints {
+			if endpoint.Conditions.Ready != nil && !*endpoint.Conditions.Ready {
+				continue
+			}
+			for _, address := range endpoint.Addresses {
+				addresses[address] = true
+			}
+		}
+	}
+
+	ports := []clusterV1alpha1.ServiceExportPort{}
+	for _, port := range service.Spec.Ports {
+		ports = append(ports, clusterV1alpha1.ServiceExportPort{
+			Name:       port.Name,
+			Protocol:   port.Protocol,
+			Port:       port.Port,
+			TargetPort: targetPorts[port.Name],
+		})
+	}
+	serviceExport.Status.Ports = ports
+
+	serviceExport.Status.Addresses = []string{}
+	for address := range addresses {
+		serviceExport.Status.Addresses = append(serviceExport.Status.Addresses, address)
+	}
+	sort.Strings(serviceExport.Status.Addresses)
+
+	serviceExport.Status.Ready = true
+	serviceExport.Status.Reason = ""
+	serviceExport.Status.LastSyncTime = metav1.Now()
+
+	// member cluster 의 endpoint 변경을 watch 할 수 없으므로 주기적으로 동기화한다.
+	return util.RequeueAfterWithJitter(resyncPeriod30Second), nil
+}
+
+func (r *ServiceExportReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&clusterV1alpha1.ServiceExport{}).
+		WithOptions(util.DefaultControllerOptions()).
+		WithEventFilter(util.SpecChangedPredicate()).
+		Complete(util.ShardReconciler(mgr.GetClient(), r))
+}
@@ -0,0 +1,245 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"reflect"
+
+	"github.com/go-logr/logr"
+	clusterV1alpha1 "github.com/tmax-cloud/hypercloud-multi-operator/apis/cluster/v1alpha1"
+	"github.com/tmax-cloud/hypercloud-multi-operator/controllers/pipeline"
+	"github.com/tmax-cloud/hypercloud-multi-operator/controllers/util"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+
+	"sigs.k8s.io/cluster-api/util/patch"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+// ServiceImportReconciler reconciles a ServiceImport object
+type ServiceImportReconciler struct {
+	client.Client
+	Log      logr.Logger
+	Scheme   *runtime.Scheme
+	Recorder record.EventRecorder
+}
+
+// +kubebuilder:rbac:groups=cluster.tmax.io,resources=serviceimports,verbs=create;delete;get;list;patch;update;watch
+// +kubebuilder:rbac:groups=cluster.tmax.io,resources=serviceimports/status,verbs=get;patch;update
+
+func (r *ServiceImportReconciler) Reconcile(ctx context.Context, req ctrl.Request) (_ ctrl.Result, reterr error) {
+	_ = context.Background()
+	log := r.Log.WithValues("serviceimport", req.NamespacedName)
+
+	// get ServiceImport
+	serviceImport := &clusterV1alpha1.ServiceImport{}
+	if err := r.Client.Get(context.TODO(), req.NamespacedName, serviceImport); errors.IsNotFound(err) {
+		log.Info("ServiceImport not found. Ignoring since object must be deleted")
+		pipeline.Forget(serviceImport, req.NamespacedName)
+		return ctrl.Result{}, nil
+	} else if err != nil {
+		log.Error(err, "Failed to get ServiceImport")
+		return ctrl.Result{}, err
+	}
+
+	patchHelper, err := patch.NewHelper(serviceImport, r.Client)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	defer func() {
+		r.reconcilePhase(context.TODO(), serviceImport)
+
+		if err := patchHelper.Patch(context.TODO(), serviceImport); err != nil {
+			reterr = err
+		}
+	}()
+
+	// Add finalizer first if not exist to avoid the race condition between init and delete
+	if !controllerutil.ContainsFinalizer(serviceImport, clusterV1alpha1.ServiceImportFinalizer) {
+		controllerutil.AddFinalizer(serviceImport, clusterV1alpha1.ServiceImportFinalizer)
+		return ctrl.Result{}, nil
+	}
+
+	// Handle deletion reconciliation loop.
+	if !serviceImport.ObjectMeta.DeletionTimestamp.IsZero() {
+		return r.reconcileDelete(context.TODO(), serviceImport)
+	}
+
+	// Handle normal reconciliation loop.
+	return r.reconcile(context.TODO(), serviceImport)
+}
+
+// reconcile handles service import reconciliation.
+func (r *ServiceImportReconciler) reconcile(ctx context.Context, serviceImport *clusterV1alpha1.ServiceImport) (ctrl.Result, error) {
+	phases := []pipeline.Phase[*clusterV1alpha1.ServiceImport]{
+		// 더 이상 선택되지 않는 cluster 에 생성했던 service 를 삭제한다.
+		{Name: "PruneUnselectedClusters", Run: r.PruneUnselectedClusters},
+		// 선택된 cluster 들에 export 된 service 의 endpoint 를 가리키는 service 와 endpoint slice 를 생성한다.
+		{Name: "ImportService", Run: r.ImportService},
+	}
+
+	return pipeline.NewRunner[*clusterV1alpha1.ServiceImport](r.Log, r.Recorder).Run(ctx, serviceImport, phases)
+}
+
+func (r *ServiceImportReconciler) reconcileDelete(ctx context.Context, serviceImport *clusterV1alpha1.ServiceImport) (ctrl.Result, error) {
+	log := r.Log.WithValues("serviceimport", serviceImport.GetNamespacedName())
+	log.Info("Start to reconcile delete for ServiceImport")
+
+	// 모든 cluster 에 생성했던 service 를 삭제한다.
+	remains := []clusterV1alpha1.ServiceImportClusterStatus{}
+	for _, clusterStatus := range serviceImport.Status.Clusters {
+		if err := r.pruneCluster(serviceImport, clusterStatus.Name, clusterStatus.Resources); err != nil {
+			log.Error(err, "Failed to delete resources from cluster", "cluster", clusterStatus.Name)
+			remains = append(remains, clusterStatus)
+		}
+	}
+	serviceImport.Status.Clusters = remains
+	if len(remains) > 0 {
+		return ctrl.Result{Requeue: true}, nil
+	}
+
+	controllerutil.RemoveFinalizer(serviceImport, clusterV1alpha1.ServiceImportFinalizer)
+	log.Info("ServiceImport is removed successfully")
+	return ctrl.Result{}, nil
+}
+
+func (r *ServiceImportReconciler) reconcilePhase(_ context.Context, serviceImport *clusterV1alpha1.ServiceImport) {
+	if !serviceImport.DeletionTimestamp.IsZero() {
+		serviceImport.Status.SetTypedPhase(clusterV1alpha1.ServiceImportPhaseDeleting)
+		return
+	}
+
+	for _, clusterStatus := range serviceImport.Status.Clusters {
+		if !clusterStatus.Synced {
+			serviceImport.Status.SetTypedPhase(clusterV1alpha1.ServiceImportPhaseProgressing)
+			return
+		}
+	}
+	serviceImport.Status.SetTypedPhase(clusterV1alpha1.ServiceImportPhaseSynced)
+}
+
+func (r *ServiceImportReconciler) requeueServiceImportsForClusterManager(o client.Object) []ctrl.Request {
+	clm := o.DeepCopyObject().(*clusterV1alpha1.ClusterManager)
+	log := r.Log.WithValues("ServiceImport-ObjectMapper", "clusterManagerToServiceImports", "ClusterManager", clm.GetNamespacedName())
+
+	importList := &clusterV1alpha1.ServiceImportList{}
+	if err := r.Client.List(context.TODO(), importList, client.InNamespace(clm.Namespace)); err != nil {
+		log.Error(err, "Failed to list ServiceImport")
+		return nil
+	}
+
+	// selector 까지 확인하지 않고, 같은 namespace 의 import 를 모두 requeue 한다.
+	reqs := []ctrl.Request{}
+	for _, serviceImport := range importList.Items {
+		reqs = append(reqs, ctrl.Request{NamespacedName: serviceImport.GetNamespacedName()})
+	}
+	return reqs
+}
+
+func (r *ServiceImportReconciler) requeueServiceImportsForServiceExport(o client.Object) []ctrl.Request {
+	serviceExport := o.DeepCopyObject().(*clusterV1alpha1.ServiceExport)
+	log := r.Log.WithValues("ServiceImport-ObjectMapper", "serviceExportToServiceImports", "ServiceExport", serviceExport.GetNamespacedName())
+
+	importList := &clusterV1alpha1.ServiceImportList{}
+	if err := r.Client.List(context.TODO(), importList, client.InNamespace(serviceExport.Namespace)); err != nil {
+		log.Error(err, "Failed to list ServiceImport")
+		return nil
+	}
+
+	reqs := []ctrl.Request{}
+	for _, serviceImport := range importList.Items {
+		if serviceImport.Spec.ServiceExport == serviceExport.Name {
+			reqs = append(reqs, ctrl.Request{NamespacedName: serviceImport.GetNamespacedName()})
+		}
+	}
+	return reqs
+}
+
+func (r *ServiceImportReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	controller, err := ctrl.NewControllerManagedBy(mgr).
+		For(&clusterV1alpha1.ServiceImport{}).
+		WithOptions(util.DefaultControllerOptions()).
+		WithEventFilter(util.SpecChangedPredicate()).
+		Build(util.ShardReconciler(mgr.GetClient(), r))
+
+	if err != nil {
+		return err
+	}
+
+	// export 된 service 의 endpoint 가 변경되면 다시 동기화한다.
+	err = controller.Watch(
+		&source.Kind{Type: &clusterV1alpha1.ServiceExport{}},
+		handler.EnqueueRequestsFromMapFunc(r.requeueServiceImportsForServiceExport),
+		predicate.Funcs{
+			CreateFunc: func(e event.CreateEvent) bool {
+				return true
+			},
+			UpdateFunc: func(e event.UpdateEvent) bool {
+				oldExport := e.ObjectOld.(*clusterV1alpha1.ServiceExport)
+				newExport := e.ObjectNew.(*clusterV1alpha1.ServiceExport)
+				return !reflect.DeepEqual(oldExport.Spec, newExport.Spec) ||
+					oldExport.Status.Ready != newExport.Status.Ready ||
+					!reflect.DeepEqual(oldExport.Status.Ports, newExport.Status.Ports) ||
+					!reflect.DeepEqual(oldExport.Status.Addresses, newExport.Status.Addresses)
+			},
+			DeleteFunc: func(e event.DeleteEvent) bool {
+				return true
+			},
+			GenericFunc: func(e event.GenericEvent) bool {
+				return false
+			},
+		},
+	)
+	if err != nil {
+		return err
+	}
+
+	// cluster 의 label 이 변경되거나 control plane 이 준비되면, service 를 다시 동기화한다.
+	return controller.Watch(
+		&source.Kind{Type: &clusterV1alpha1.ClusterManager{}},
+		handler.EnqueueRequestsFromMapFunc(r.requeueServiceImportsForClusterManager),
+		predicate.Funcs{
+			CreateFunc: func(e event.CreateEvent) bool {
+				return false
+			},
+			UpdateFunc: func(e event.UpdateEvent) bool {
+				oldClm := e.ObjectOld.(*clusterV1alpha1.ClusterManager)
+				newClm := e.ObjectNew.(*clusterV1alpha1.ClusterManager)
+				if !labels.Equals(oldClm.Labels, newClm.Labels) ||
+					oldClm.Status.ControlPlaneReady != newClm.Status.ControlPlaneReady {
+					return true
+				}
+				return false
+			},
+			DeleteFunc: func(e event.DeleteEvent) bool {
+				return true
+			},
+			GenericFunc: func(e event.GenericEvent) bool {
+				return false
+			},
+		},
+	)
+}
@@ -0,0 +1,286 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"sort"
+
+	clusterV1alpha1 "github.com/tmax-cloud/hypercloud-multi-operator/apis/cluster/v1alpha1"
+
+	coreV1 "k8s.io/api/core/v1"
+	discoveryV1 "k8s.io/api/discovery/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// import 된 endpoint slice 를 endpointslice controller 가 관리하지 않도록 설정하는 값
+	EndpointSliceManagedBy = "hypercloud-multi-operator"
+	// import 된 endpoint slice 이름의 suffix
+	ImportedEndpointSliceSuffix = "-imported"
+)
+
+func (r *ServiceImportReconciler) PruneUnselectedClusters(ctx context.Context, serviceImport *clusterV1alpha1.ServiceImport) (ctrl.Result, error) {
+	log := r.Log.WithValues("serviceimport", serviceImport.GetNamespacedName())
+	log.Info("Start to reconcile phase for PruneUnselectedClusters")
+
+	clmList, err := r.selectClusters(serviceImport)
+	if err != nil {
+		log.Error(err, "Failed to select ClusterManagers")
+		return ctrl.Result{}, err
+	}
+	selected := map[string]bool{}
+	for _, clm := range clmList {
+		selected[clm.Name] = true
+	}
+
+	clusters := []clusterV1alpha1.ServiceImportClusterStatus{}
+	for _, clusterStatus := range serviceImport.Status.Clusters {
+		if selected[clusterStatus.Name] {
+			clusters = append(clusters, clusterStatus)
+			continue
+		}
+		if err := r.pruneCluster(serviceImport, clusterStatus.Name, clusterStatus.Resources); err != nil {
+			log.Error(err, "Failed to delete service from unselected cluster", "cluster", clusterStatus.Name)
+			clusterStatus.Synced = false
+			clusterStatus.Reason = "Failed to delete service: " + err.Error()
+			clusters = append(clusters, clusterStatus)
+			continue
+		}
+		log.Info("Deleted service from unselected cluster successfully", "cluster", clusterStatus.Name)
+	}
+	serviceImport.Status.Clusters = clusters
+
+	return ctrl.Result{}, nil
+}
+
+func (r *ServiceImportReconciler) ImportService(ctx context.Context, serviceImport *clusterV1alpha1.ServiceImport) (ctrl.Result, error) {
+	log := r.Log.WithValues("serviceimport", serviceImport.GetNamespacedName())
+	log.Info("Start to reconcile phase for ImportService")
+
+	key := types.NamespacedName{
+		Name:      serviceImport.Spec.ServiceExport,
+		Namespace: serviceImport.Namespace,
+	}
+	serviceExport := &clusterV1alpha1.ServiceExport{}
+	if err := r.Client.Get(context.TODO(), key, serviceExport); errors.IsNotFound(err) {
+		// service export 가 생성되면 watch 에 의해 다시 reconcile 된다.
+		log.Info("ServiceExport not found", "serviceExport", key.Name)
+		r.setClustersNotSynced(serviceImport, "ServiceExport not found")
+		return ctrl.Result{}, nil
+	} else if err != nil {
+		log.Error(err, "Failed to get ServiceExport")
+		return ctrl.Result{}, err
+	}
+
+	if !serviceExport.Status.Ready {
+		log.Info("Wait for ServiceExport to be ready", "serviceExport", key.Name)
+		r.setClustersNotSynced(serviceImport, "Wait for service export to be ready")
+		return ctrl.Result{}, nil
+	}
+
+	objs, err := r.buildImportResources(serviceImport, serviceExport)
+	if err != nil {
+		log.Error(err, "Failed to build service resources")
+		return ctrl.Result{}, err
+	}
+
+	clmList, err := r.selectClusters(serviceImport)
+	if err != nil {
+		log.Error(err, "Failed to select ClusterManagers")
+		return ctrl.Result{}, err
+	}
+
+	allSynced := true
+	for _, clm := range clmList {
+		// export 한 cluster 에는 생성하지 않는다.
+		if clm.Name == serviceExport.Spec.ClusterName {
+			continue
+		}
+
+		clusterStatus := serviceImport.Status.GetClusterStatus(clm.Name)
+		if clusterStatus == nil {
+			serviceImport.Status.Clusters = append(serviceImport.Status.Clusters, clusterV1alpha1.ServiceImportClusterStatus{Name: clm.Name})
+			clusterStatus = &serviceImport.Status.Clusters[len(serviceImport.Status.Clusters)-1]
+		}
+
+		if !clm.Status.ControlPlaneReady {
+			clusterStatus.Synced = false
+			clusterStatus.Reason = "Wait for control plane to be ready"
+			continue
+		}
+
+		remoteClient, err := getRemoteRuntimeClient(r.Client, &clm)
+		if err == nil {
+			clusterStatus.Resources, err = applyManifestResources(remoteClient, objs, clusterStatus.Resources)
+		}
+		if err != nil {
+			log.Error(err, "Failed to sync service to cluster", "cluster", clm.Name)
+			clusterStatus.Synced = false
+			clusterStatus.Reason = err.Error()
+			allSynced = false
+			continue
+		}
+		clusterStatus.Synced = true
+		clusterStatus.Reason = ""
+		clusterStatus.LastSyncTime = metav1.Now()
+	}
+
+	sort.Slice(serviceImport.Status.Clusters, func(i, j int) bool {
+		return serviceImport.Status.Clusters[i].Name < serviceImport.Status.Clusters[j].Name
+	})
+
+	if !allSynced {
+		return ctrl.Result{Requeue: true}, nil
+	}
+	return ctrl.Result{}, nil
+}
+
+func (r *ServiceImportReconciler) setClustersNotSynced(serviceImport *clusterV1alpha1.ServiceImport, reason string) {
+	for i := range serviceImport.Status.Clusters {
+		serviceImport.Status.Clusters[i].Synced = false
+		serviceImport.Status.Clusters[i].Reason = reason
+	}
+}
+
+// selector 가 없는 service 와, export 된 endpoint 를 가리키는 endpoint slice 를 생성한다.
+func (r *ServiceImportReconciler) buildImportResources(serviceImport *clusterV1alpha1.ServiceImport,
+	serviceExport *clusterV1alpha1.ServiceExport) ([]*unstructured.Unstructured, error) {
+	name := serviceImport.Spec.ServiceName
+	if name == "" {
+		name = serviceExport.Spec.ServiceName
+	}
+	namespace := serviceImport.Spec.ServiceNamespace
+	if namespace == "" {
+		namespace = serviceExport.Spec.ServiceNamespace
+	}
+	labels := map[string]string{
+		clusterV1alpha1.LabelKeyServiceImport:          serviceImport.Name,
+		clusterV1alpha1.LabelKeyServiceImportNamespace: serviceImport.Namespace,
+	}
+
+	service := &coreV1.Service{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: coreV1.SchemeGroupVersion.String(),
+			Kind:       "Service",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+			Labels:    labels,
+		},
+		Spec: coreV1.ServiceSpec{
+			Type: coreV1.ServiceTypeClusterIP,
+		},
+	}
+
+	sliceLabels := map[string]string{
+		discoveryV1.LabelServiceName: name,
+		discoveryV1.LabelManagedBy:   EndpointSliceManagedBy,
+	}
+	for k, v := range labels {
+		sliceLabels[k] = v
+	}
+	endpointSlice := &discoveryV1.EndpointSlice{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: discoveryV1.SchemeGroupVersion.String(),
+			Kind:       "EndpointSlice",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name + ImportedEndpointSliceSuffix,
+			Namespace: namespace,
+			Labels:    sliceLabels,
+		},
+		AddressType: discoveryV1.AddressTypeIPv4,
+		Endpoints:   []discoveryV1.Endpoint{},
+	}
+
+	for _, port := range serviceExport.Status.Ports {
+		targetPort := port.TargetPort
+		if targetPort == 0 {
+			targetPort = port.Port
+		}
+		protocol := port.Protocol
+		if protocol == "" {
+			protocol = coreV1.ProtocolTCP
+		}
+		service.Spec.Ports = append(service.Spec.Ports, coreV1.ServicePort{
+			Name:     port.Name,
+			Protocol: protocol,
+			Port:     port.Port,
+		})
+		portName := port.Name
+		endpointSlice.Ports = append(endpointSlice.Ports, discoveryV1.EndpointPort{
+			Name:     &portName,
+			Protocol: &protocol,
+			Port:     &targetPort,
+		})
+	}
+	ready := true
+	for _, address := range serviceExport.Status.Addresses {
+		endpointSlice.Endpoints = append(endpointSlice.Endpoints, discoveryV1.Endpoint{
+			Addresses: []string{address},
+			Conditions: discoveryV1.EndpointConditions{
+				Ready: &ready,
+			},
+		})
+	}
+
+	objs := []*unstructured.Unstructured{}
+	for _, obj := range []client.Object{service, endpointSlice} {
+		u, err := convertToUnstructured(obj)
+		if err != nil {
+			return nil, err
+		}
+		objs = append(objs, u)
+	}
+	return objs, nil
+}
+
+func (r *ServiceImportReconciler) pruneCluster(serviceImport *clusterV1alpha1.ServiceImport, clusterName string, resources []clusterV1alpha1.ManifestStatus) error {
+	return pruneRemoteResources(r.Client, serviceImport.Namespace, clusterName, resources)
+}
+
+// spec 의 cluster selector 에 해당하는 cluster manager 목록
+func (r *ServiceImportReconciler) selectClusters(serviceImport *clusterV1alpha1.ServiceImport) ([]clusterV1alpha1.ClusterManager, error) {
+	selector, err := serviceImport.GetClusterSelector()
+	if err != nil {
+		return nil, err
+	}
+	clmList := &clusterV1alpha1.ClusterManagerList{}
+	opts := []client.ListOption{
+		client.InNamespace(serviceImport.Namespace),
+		client.MatchingLabelsSelector{Selector: selector},
+	}
+	if err := r.Client.List(context.TODO(), clmList, opts...); err != nil {
+		return nil, err
+	}
+
+	result := []clusterV1alpha1.ClusterManager{}
+	for _, clm := range clmList.Items {
+		// 삭제중인 cluster 에는 생성하지 않는다.
+		if !clm.DeletionTimestamp.IsZero() {
+			continue
+		}
+		result = append(result, clm)
+	}
+	return result, nil
+}
@@ -9,6 +9,8 @@ const (
 	CLUSTER_PARAM_WORKER_NUM         = "WORKER_MACHINE_COUNT"
 	CLUSTER_PARAM_OWNER              = "OWNER"
 	CLUSTER_PARAM_KUBERNETES_VERSION = "KUBERNETES_VERSION"
+	CLUSTER_PARAM_IMAGE_REPOSITORY   = "IMAGE_REPOSITORY"
+	CLUSTER_PARAM_CNI_MANIFEST_URL   = "CNI_MANIFEST_URL"
 
 	// Aws Parameter
 	AWS_PARAM_AWS_SSH_KEY_NAME               = "AWS_SSH_KEY_NAME"
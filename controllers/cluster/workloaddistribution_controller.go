@@ -0,0 +1,202 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+
+	"github.com/go-logr/logr"
+	clusterV1alpha1 "github.com/tmax-cloud/hypercloud-multi-operator/apis/cluster/v1alpha1"
+	"github.com/tmax-cloud/hypercloud-multi-operator/controllers/pipeline"
+	"github.com/tmax-cloud/hypercloud-multi-operator/controllers/util"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+
+	"sigs.k8s.io/cluster-api/util/patch"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+// WorkloadDistributionReconciler reconciles a WorkloadDistribution object
+type WorkloadDistributionReconciler struct {
+	client.Client
+	Log      logr.Logger
+	Scheme   *runtime.Scheme
+	Recorder record.EventRecorder
+}
+
+// +kubebuilder:rbac:groups=cluster.tmax.io,resources=workloaddistributions,verbs=create;delete;get;list;patch;update;watch
+// +kubebuilder:rbac:groups=cluster.tmax.io,resources=workloaddistributions/status,verbs=get;patch;update
+
+func (r *WorkloadDistributionReconciler) Reconcile(ctx context.Context, req ctrl.Request) (_ ctrl.Result, reterr error) {
+	_ = context.Background()
+	log := r.Log.WithValues("workloaddistribution", req.NamespacedName)
+
+	// get WorkloadDistribution
+	wd := &clusterV1alpha1.WorkloadDistribution{}
+	if err := r.Client.Get(context.TODO(), req.NamespacedName, wd); errors.IsNotFound(err) {
+		log.Info("WorkloadDistribution not found. Ignoring since object must be deleted")
+		pipeline.Forget(wd, req.NamespacedName)
+		return ctrl.Result{}, nil
+	} else if err != nil {
+		log.Error(err, "Failed to get WorkloadDistribution")
+		return ctrl.Result{}, err
+	}
+
+	patchHelper, err := patch.NewHelper(wd, r.Client)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	defer func() {
+		r.reconcilePhase(context.TODO(), wd)
+
+		if err := patchHelper.Patch(context.TODO(), wd); err != nil {
+			reterr = err
+		}
+	}()
+
+	// Add finalizer first if not exist to avoid the race condition between init and delete
+	if !controllerutil.ContainsFinalizer(wd, clusterV1alpha1.WorkloadDistributionFinalizer) {
+		controllerutil.AddFinalizer(wd, clusterV1alpha1.WorkloadDistributionFinalizer)
+		return ctrl.Result{}, nil
+	}
+
+	// Handle deletion reconciliation loop.
+	if !wd.ObjectMeta.DeletionTimestamp.IsZero() {
+		return r.reconcileDelete(context.TODO(), wd)
+	}
+
+	// Handle normal reconciliation loop.
+	return r.reconcile(context.TODO(), wd)
+}
+
+// reconcile handles workload distribution reconciliation.
+func (r *WorkloadDistributionReconciler) reconcile(ctx context.Context, wd *clusterV1alpha1.WorkloadDistribution) (ctrl.Result, error) {
+	phases := []pipeline.Phase[*clusterV1alpha1.WorkloadDistribution]{
+		// 더 이상 선택되지 않는 cluster 에 적용했던 리소스를 삭제한다.
+		{Name: "PruneUnselectedClusters", Run: r.PruneUnselectedClusters},
+		// 선택된 cluster 들에 manifest 를 적용하고, manifest 에서 제외된 리소스를 삭제한다.
+		{Name: "DistributeManifests", Run: r.DistributeManifests},
+	}
+
+	return pipeline.NewRunner[*clusterV1alpha1.WorkloadDistribution](r.Log, r.Recorder).Run(ctx, wd, phases)
+}
+
+func (r *WorkloadDistributionReconciler) reconcileDelete(ctx context.Context, wd *clusterV1alpha1.WorkloadDistribution) (ctrl.Result, error) {
+	log := r.Log.WithValues("workloaddistribution", wd.GetNamespacedName())
+	log.Info("Start to reconcile delete for WorkloadDistribution")
+
+	// 모든 cluster 에 적용했던 리소스를 삭제한다.
+	remains := []clusterV1alpha1.WorkloadDistributionClusterStatus{}
+	for _, clusterStatus := range wd.Status.Clusters {
+		if err := r.pruneCluster(wd, clusterStatus.Name, clusterStatus.Resources); err != nil {
+			log.Error(err, "Failed to delete resources from cluster", "cluster", clusterStatus.Name)
+			remains = append(remains, clusterStatus)
+		}
+	}
+	wd.Status.Clusters = remains
+	if len(remains) > 0 {
+		return ctrl.Result{Requeue: true}, nil
+	}
+
+	controllerutil.RemoveFinalizer(wd, clusterV1alpha1.WorkloadDistributionFinalizer)
+	log.Info("WorkloadDistribution is removed successfully")
+	return ctrl.Result{}, nil
+}
+
+func (r *WorkloadDistributionReconciler) reconcilePhase(_ context.Context, wd *clusterV1alpha1.WorkloadDistribution) {
+	if !wd.DeletionTimestamp.IsZero() {
+		wd.Status.SetTypedPhase(clusterV1alpha1.WorkloadDistributionPhaseDeleting)
+		return
+	}
+
+	// 현재 generation 이 아직 모든 cluster 에 적용되지 않은 경우
+	if progress := wd.Status.Progress; progress != nil && progress.Updated < progress.Total {
+		wd.Status.SetTypedPhase(clusterV1alpha1.WorkloadDistributionPhaseProgressing)
+		return
+	}
+	for _, clusterStatus := range wd.Status.Clusters {
+		if !clusterStatus.Applied {
+			wd.Status.SetTypedPhase(clusterV1alpha1.WorkloadDistributionPhaseProgressing)
+			return
+		}
+	}
+	wd.Status.SetTypedPhase(clusterV1alpha1.WorkloadDistributionPhaseApplied)
+}
+
+func (r *WorkloadDistributionReconciler) requeueWorkloadDistributionsForClusterManager(o client.Object) []ctrl.Request {
+	clm := o.DeepCopyObject().(*clusterV1alpha1.ClusterManager)
+	log := r.Log.WithValues("WorkloadDistribution-ObjectMapper", "clusterManagerToWorkloadDistributions", "ClusterManager", clm.GetNamespacedName())
+
+	wdList := &clusterV1alpha1.WorkloadDistributionList{}
+	if err := r.Client.List(context.TODO(), wdList, client.InNamespace(clm.Namespace)); err != nil {
+		log.Error(err, "Failed to list WorkloadDistribution")
+		return nil
+	}
+
+	// group 의 selector 까지 확인하지 않고, 같은 namespace 의 distribution 을 모두 requeue 한다.
+	reqs := []ctrl.Request{}
+	for _, wd := range wdList.Items {
+		reqs = append(reqs, ctrl.Request{NamespacedName: wd.GetNamespacedName()})
+	}
+	return reqs
+}
+
+func (r *WorkloadDistributionReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	controller, err := ctrl.NewControllerManagedBy(mgr).
+		For(&clusterV1alpha1.WorkloadDistribution{}).
+		WithOptions(util.DefaultControllerOptions()).
+		WithEventFilter(util.SpecChangedPredicate()).
+		Build(util.ShardReconciler(mgr.GetClient(), r))
+
+	if err != nil {
+		return err
+	}
+
+	// cluster 의 label 이 변경되거나 control plane 이 준비되면, manifest 를 다시 배포한다.
+	return controller.Watch(
+		&source.Kind{Type: &clusterV1alpha1.ClusterManager{}},
+		handler.EnqueueRequestsFromMapFunc(r.requeueWorkloadDistributionsForClusterManager),
+		predicate.Funcs{
+			CreateFunc: func(e event.CreateEvent) bool {
+				return false
+			},
+			UpdateFunc: func(e event.UpdateEvent) bool {
+				oldClm := e.ObjectOld.(*clusterV1alpha1.ClusterManager)
+				newClm := e.ObjectNew.(*clusterV1alpha1.ClusterManager)
+				if !labels.Equals(oldClm.Labels, newClm.Labels) ||
+					oldClm.Status.ControlPlaneReady != newClm.Status.ControlPlaneReady {
+					return true
+				}
+				return false
+			},
+			DeleteFunc: func(e event.DeleteEvent) bool {
+				return true
+			},
+			GenericFunc: func(e event.GenericEvent) bool {
+				return false
+			},
+		},
+	)
+}
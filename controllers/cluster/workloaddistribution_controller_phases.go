@@ -0,0 +1,403 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	clusterV1alpha1 "github.com/tmax-cloud/hypercloud-multi-operator/apis/cluster/v1alpha1"
+	util "github.com/tmax-cloud/hypercloud-multi-operator/controllers/util"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// remote cluster 에 server side apply 할 때 사용하는 field manager
+	RemoteApplyFieldOwner = "hypercloud-multi-operator"
+)
+
+func (r *WorkloadDistributionReconciler) PruneUnselectedClusters(ctx context.Context, wd *clusterV1alpha1.WorkloadDistribution) (ctrl.Result, error) {
+	log := r.Log.WithValues("workloaddistribution", wd.GetNamespacedName())
+	log.Info("Start to reconcile phase for PruneUnselectedClusters")
+
+	clmList, err := r.selectClusters(wd)
+	if err != nil {
+		log.Error(err, "Failed to select ClusterManagers")
+		return ctrl.Result{}, err
+	}
+	selected := map[string]bool{}
+	for _, clm := range clmList {
+		selected[clm.Name] = true
+	}
+
+	clusters := []clusterV1alpha1.WorkloadDistributionClusterStatus{}
+	for _, clusterStatus := range wd.Status.Clusters {
+		if selected[clusterStatus.Name] {
+			clusters = append(clusters, clusterStatus)
+			continue
+		}
+		if err := r.pruneCluster(wd, clusterStatus.Name, clusterStatus.Resources); err != nil {
+			log.Error(err, "Failed to delete resources from unselected cluster", "cluster", clusterStatus.Name)
+			clusterStatus.Applied = false
+			clusterStatus.Reason = "Failed to delete resources: " + err.Error()
+			clusters = append(clusters, clusterStatus)
+			continue
+		}
+		log.Info("Deleted resources from unselected cluster successfully", "cluster", clusterStatus.Name)
+	}
+	wd.Status.Clusters = clusters
+
+	return ctrl.Result{}, nil
+}
+
+func (r *WorkloadDistributionReconciler) DistributeManifests(ctx context.Context, wd *clusterV1alpha1.WorkloadDistribution) (ctrl.Result, error) {
+	log := r.Log.WithValues("workloaddistribution", wd.GetNamespacedName())
+	log.Info("Start to reconcile phase for DistributeManifests")
+
+	objs, err := buildDistributionResources(wd)
+	if err != nil {
+		log.Error(err, "Failed to decode manifests")
+		return ctrl.Result{}, err
+	}
+
+	clmList, err := r.selectClusters(wd)
+	if err != nil {
+		log.Error(err, "Failed to select ClusterManagers")
+		return ctrl.Result{}, err
+	}
+
+	// task 가 실행되는 동안 slice 가 다시 할당되지 않도록 모든 cluster 의 status 를 먼저 만든다.
+	for _, clm := range clmList {
+		if wd.Status.GetClusterStatus(clm.Name) == nil {
+			wd.Status.Clusters = append(wd.Status.Clusters, clusterV1alpha1.WorkloadDistributionClusterStatus{Name: clm.Name})
+		}
+	}
+
+	tasks := []util.FanOutTask{}
+	for i := range clmList {
+		clm := &clmList[i]
+		clusterStatus := wd.Status.GetClusterStatus(clm.Name)
+
+		// control plane 이 준비되면 cluster manager watch 에 의해 다시 reconcile 된다.
+		if !clm.Status.ControlPlaneReady {
+			clusterStatus.Applied = false
+			clusterStatus.Reason = "Wait for control plane to be ready"
+			continue
+		}
+		// 현재 generation 이 이미 적용된 cluster 는 건너뛴다.
+		if clusterStatus.Applied && clusterStatus.ObservedGeneration == wd.Generation {
+			continue
+		}
+
+		tasks = append(tasks, util.FanOutTask{
+			Key:      clm.Name,
+			Priority: clm.GetFanOutPriority(),
+			Run: func(ctx context.Context) error {
+				return r.applyCluster(wd, clm, objs, clusterStatus)
+			},
+		})
+	}
+
+	results, remaining := util.GetFanOutPool().Run(ctx, tasks)
+	allApplied := true
+	for name, err := range results {
+		clusterStatus := wd.Status.GetClusterStatus(name)
+		clusterStatus.ObservedGeneration = wd.Generation
+		if err != nil {
+			log.Error(err, "Failed to apply manifests to cluster", "cluster", name)
+			clusterStatus.Applied = false
+			clusterStatus.Reason = err.Error()
+			allApplied = false
+			continue
+		}
+		clusterStatus.Applied = true
+		clusterStatus.Reason = ""
+		clusterStatus.LastAppliedTime = metav1.Now()
+	}
+
+	sort.Slice(wd.Status.Clusters, func(i, j int) bool {
+		return wd.Status.Clusters[i].Name < wd.Status.Clusters[j].Name
+	})
+	wd.Status.ObservedGeneration = wd.Generation
+
+	updated, failed := 0, 0
+	for _, clm := range clmList {
+		clusterStatus := wd.Status.GetClusterStatus(clm.Name)
+		if clusterStatus.ObservedGeneration != wd.Generation {
+			continue
+		}
+		if clusterStatus.Applied {
+			updated++
+		} else if clm.Status.ControlPlaneReady {
+			failed++
+		}
+	}
+	wd.Status.Progress = newFanOutProgress(wd.Status.Progress, wd.Generation, len(clmList), updated, failed, len(results) > 0)
+
+	if remaining > 0 {
+		log.Info("Wait for the next batch", "remaining", remaining)
+		return ctrl.Result{RequeueAfter: util.FanOutBatchInterval}, nil
+	}
+	if !allApplied {
+		return ctrl.Result{Requeue: true}, nil
+	}
+	return ctrl.Result{}, nil
+}
+
+// manifest 를 remote cluster 에 적용할 unstructured 리소스로 변환한다.
+func buildDistributionResources(wd *clusterV1alpha1.WorkloadDistribution) ([]*unstructured.Unstructured, error) {
+	objs := []*unstructured.Unstructured{}
+	for i, manifest := range wd.Spec.Manifests {
+		obj := &unstructured.Unstructured{}
+		if err := obj.UnmarshalJSON(manifest.Raw); err != nil {
+			return nil, fmt.Errorf("failed to decode manifest %d: %w", i, err)
+		}
+		labels := obj.GetLabels()
+		if labels == nil {
+			labels = map[string]string{}
+		}
+		labels[clusterV1alpha1.LabelKeyWorkloadDistribution] = wd.Name
+		labels[clusterV1alpha1.LabelKeyWorkloadDistributionNamespace] = wd.Namespace
+		obj.SetLabels(labels)
+		util.SetRemoteOwnerLabels(obj, util.RemoteOwner{
+			Kind:      util.RemoteOwnerKindWorkloadDistribution,
+			Namespace: wd.Namespace,
+			Name:      wd.Name,
+		})
+		objs = append(objs, obj)
+	}
+	return objs, nil
+}
+
+// remote cluster 에 manifest 를 server side apply 로 적용하고, 이전에 적용했지만 manifest 에서 제외된 리소스를 삭제한다.
+func (r *WorkloadDistributionReconciler) applyCluster(wd *clusterV1alpha1.WorkloadDistribution, clm *clusterV1alpha1.ClusterManager,
+	objs []*unstructured.Unstructured, clusterStatus *clusterV1alpha1.WorkloadDistributionClusterStatus) error {
+	remoteClient, err := getRemoteRuntimeClient(r.Client, clm)
+	if err != nil {
+		return err
+	}
+
+	applied, err := applyManifestResources(remoteClient, objs, clusterStatus.Resources)
+	if err != nil {
+		return err
+	}
+	clusterStatus.Resources = applied
+	return nil
+}
+
+func (r *WorkloadDistributionReconciler) pruneCluster(wd *clusterV1alpha1.WorkloadDistribution, clusterName string, resources []clusterV1alpha1.ManifestStatus) error {
+	return pruneRemoteResources(r.Client, wd.Namespace, clusterName, resources)
+}
+
+// cluster 에 적용했던 리소스를 삭제한다. cluster 가 이미 삭제된 경우에는 건너뛴다.
+func pruneRemoteResources(c client.Client, namespace string, clusterName string, resources []clusterV1alpha1.ManifestStatus) error {
+	if len(resources) == 0 {
+		return nil
+	}
+
+	key := types.NamespacedName{
+		Name:      clusterName,
+		Namespace: namespace,
+	}
+	clm := &clusterV1alpha1.ClusterManager{}
+	if err := c.Get(context.TODO(), key, clm); errors.IsNotFound(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	remoteClient, err := getRemoteRuntimeClient(c, clm)
+	if errors.IsNotFound(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	return deleteManifestResources(remoteClient, resources)
+}
+
+// remote cluster 에 리소스를 server side apply 로 적용하고, previous 중 이번에 적용되지 않은 리소스는 삭제한다.
+// 적용된 리소스 목록을 반환한다.
+func applyManifestResources(remoteClient client.Client, objs []*unstructured.Unstructured,
+	previous []clusterV1alpha1.ManifestStatus) ([]clusterV1alpha1.ManifestStatus, error) {
+	applied := []clusterV1alpha1.ManifestStatus{}
+	appliedKeys := map[string]bool{}
+	for _, obj := range objs {
+		if err := applyRemoteObject(remoteClient, obj); err != nil {
+			return nil, err
+		}
+		resource := getManifestStatus(obj)
+		applied = append(applied, resource)
+		appliedKeys[resource.Key()] = true
+	}
+
+	if err := pruneManifestResources(remoteClient, previous, appliedKeys); err != nil {
+		return nil, err
+	}
+	return applied, nil
+}
+
+// obj 를 remote cluster 에 server side apply 로 적용한다.
+func applyRemoteObject(remoteClient client.Client, obj *unstructured.Unstructured) error {
+	err := remoteClient.Patch(
+		context.TODO(),
+		obj.DeepCopy(),
+		client.Apply,
+		client.ForceOwnership,
+		client.FieldOwner(RemoteApplyFieldOwner),
+	)
+	return util.ClassifyRemoteError(err)
+}
+
+// previous 중 keep 에 없는 리소스를 삭제한다.
+func pruneManifestResources(remoteClient client.Client, previous []clusterV1alpha1.ManifestStatus, keep map[string]bool) error {
+	pruned := []clusterV1alpha1.ManifestStatus{}
+	for _, resource := range previous {
+		if !keep[resource.Key()] {
+			pruned = append(pruned, resource)
+		}
+	}
+	return deleteManifestResources(remoteClient, pruned)
+}
+
+func getManifestStatus(obj *unstructured.Unstructured) clusterV1alpha1.ManifestStatus {
+	return clusterV1alpha1.ManifestStatus{
+		APIVersion: obj.GetAPIVersion(),
+		Kind:       obj.GetKind(),
+		Namespace:  obj.GetNamespace(),
+		Name:       obj.GetName(),
+	}
+}
+
+// typed object 를 remote cluster 에 apply 할 unstructured 리소스로 변환한다.
+func convertToUnstructured(obj runtime.Object) (*unstructured.Unstructured, error) {
+	content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+	if err != nil {
+		return nil, err
+	}
+	u := &unstructured.Unstructured{Object: content}
+	// creationTimestamp: null 과 status 는 apply 시 불필요하므로 제거한다.
+	unstructured.RemoveNestedField(u.Object, "metadata", "creationTimestamp")
+	unstructured.RemoveNestedField(u.Object, "status")
+	return u, nil
+}
+
+func deleteManifestResources(remoteClient client.Client, resources []clusterV1alpha1.ManifestStatus) error {
+	for _, resource := range resources {
+		obj := &unstructured.Unstructured{}
+		obj.SetAPIVersion(resource.APIVersion)
+		obj.SetKind(resource.Kind)
+		obj.SetNamespace(resource.Namespace)
+		obj.SetName(resource.Name)
+		if err := remoteClient.Delete(context.TODO(), obj); err != nil && !errors.IsNotFound(err) {
+			return util.ClassifyRemoteError(err)
+		}
+	}
+	return nil
+}
+
+// spec 의 cluster selector 와 cluster group 에 해당하는 cluster manager 목록
+func (r *WorkloadDistributionReconciler) selectClusters(wd *clusterV1alpha1.WorkloadDistribution) ([]clusterV1alpha1.ClusterManager, error) {
+	clms := map[string]clusterV1alpha1.ClusterManager{}
+
+	if wd.Spec.ClusterGroup != "" {
+		key := types.NamespacedName{
+			Name:      wd.Spec.ClusterGroup,
+			Namespace: wd.Namespace,
+		}
+		clusterGroup := &clusterV1alpha1.ClusterGroup{}
+		if err := r.Client.Get(context.TODO(), key, clusterGroup); err != nil && !errors.IsNotFound(err) {
+			return nil, err
+		} else if err == nil {
+			members, err := GetClusterGroupMembers(r.Client, clusterGroup)
+			if err != nil {
+				return nil, err
+			}
+			for _, clm := range members {
+				clms[clm.Name] = clm
+			}
+		}
+	}
+
+	selectorEmpty := len(wd.Spec.ClusterSelector.MatchLabels) == 0 && len(wd.Spec.ClusterSelector.MatchExpressions) == 0
+	if wd.Spec.ClusterGroup == "" || !selectorEmpty {
+		selector, err := wd.GetClusterSelector()
+		if err != nil {
+			return nil, err
+		}
+		clmList := &clusterV1alpha1.ClusterManagerList{}
+		opts := []client.ListOption{
+			client.InNamespace(wd.Namespace),
+			client.MatchingLabelsSelector{Selector: selector},
+		}
+		if err := r.Client.List(context.TODO(), clmList, opts...); err != nil {
+			return nil, err
+		}
+		for _, clm := range clmList.Items {
+			clms[clm.Name] = clm
+		}
+	}
+
+	result := []clusterV1alpha1.ClusterManager{}
+	for _, clm := range clms {
+		// 삭제중인 cluster 에는 배포하지 않는다.
+		if !clm.DeletionTimestamp.IsZero() {
+			continue
+		}
+		result = append(result, clm)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Name < result[j].Name
+	})
+	return result, nil
+}
+
+// cluster manager 의 kubeconfig secret 으로 remote cluster 의 client 를 생성한다.
+// 선택된 cluster 에 현재 generation 을 적용하는 진행 상황을 만든다.
+// 이번 reconcile 에서 처리한 cluster 가 있는 경우에만 batch 시각을 갱신한다.
+func newFanOutProgress(previous *clusterV1alpha1.FanOutProgress, generation int64, total, updated, failed int, processed bool) *clusterV1alpha1.FanOutProgress {
+	progress := &clusterV1alpha1.FanOutProgress{
+		ObservedGeneration: generation,
+		Total:              total,
+		Updated:            updated,
+		Failed:             failed,
+		Pending:            total - updated - failed,
+	}
+	if previous != nil {
+		progress.LastBatchTime = previous.LastBatchTime
+	}
+	if processed {
+		progress.LastBatchTime = &metav1.Time{Time: time.Now()}
+	}
+	return progress
+}
+
+func getRemoteRuntimeClient(c client.Client, clm *clusterV1alpha1.ClusterManager) (client.Client, error) {
+	kubeconfigSecret, err := util.GetKubeconfigSecret(context.TODO(), c, clm.Namespace, clm.Name)
+	if err != nil {
+		return nil, err
+	}
+	return util.GetRemoteK8sRuntimeClient(kubeconfigSecret)
+}
@@ -16,6 +16,7 @@ package hyperAuth
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
@@ -756,3 +757,34 @@ func DeleteGroup(config GroupConfig, secret *coreV1.Secret) error {
 
 	return nil
 }
+
+// 사용자의 access token 으로 userinfo 를 조회한다.
+// hyperauth 가 token 을 거부하면 nil 을 반환한다.
+func GetUserInfo(ctx context.Context, token string) (*UserInfo, error) {
+	url := SetServiceDomainURI(KEYCLOAK_SERVICE_GET_USERINFO, nil)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Add("Authorization", "Bearer "+token)
+
+	client := &http.Client{}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden {
+		return nil, nil
+	}
+	if !IsOK(resp.StatusCode) {
+		return nil, fmt.Errorf("failed to get userinfo: %s", resp.Status)
+	}
+
+	userInfo := &UserInfo{}
+	if err := json.NewDecoder(resp.Body).Decode(userInfo); err != nil {
+		return nil, err
+	}
+	return userInfo, nil
+}
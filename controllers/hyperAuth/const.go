@@ -33,6 +33,9 @@ const (
 	KEYCLOAK_ADMIN_SERVICE_DELETE_GROUP                       = "/auth/admin/realms/tmax/groups/@@groupId@@"
 	KEYCLOAK_ADMIN_SERVICE_ADD_GROUP_TO_USER                  = "/auth/admin/realms/tmax/users/@@userId@@/groups/@@groupId@@"
 	KEYCLOAK_ADMIN_SERVICE_GET_USERS_BY_EMAIL                 = "/auth/admin/realms/tmax/users?exact=true&email=@@userEmail@@"
+
+	// user api
+	KEYCLOAK_SERVICE_GET_USERINFO = "/auth/realms/tmax/protocol/openid-connect/userinfo"
 )
 
 const (
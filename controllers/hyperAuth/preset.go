@@ -24,6 +24,13 @@ import (
 func GetClientConfigPreset(prefix string) []ClientConfig {
 	AUTH_CLIENT_SECRET := util.AUTH_CLIENT_SECRET
 	configs := []ClientConfig{
+		{
+			ClientId:                  strings.Join([]string{prefix, "console"}, "-"),
+			Secret:                    os.Getenv(AUTH_CLIENT_SECRET),
+			DirectAccessGrantsEnabled: true,
+			ImplicitFlowEnabled:       false,
+			RedirectUris:              []string{"*"},
+		},
 		{
 			ClientId:                  strings.Join([]string{prefix, "kibana"}, "-"),
 			Secret:                    os.Getenv(AUTH_CLIENT_SECRET),
@@ -82,6 +82,15 @@ type UserConfig struct {
 	Id string `json:"id,omitempty"`
 }
 
+// userinfo endpoint 의 응답
+// api server 의 oidc 설정과 같이 preferred_username 과 group claim 을 사용한다.
+type UserInfo struct {
+	Sub               string   `json:"sub,omitempty"`
+	PreferredUsername string   `json:"preferred_username,omitempty"`
+	Email             string   `json:"email,omitempty"`
+	Group             []string `json:"group,omitempty"`
+}
+
 type ClientScopeMappingConfig struct {
 	ClientId    string
 	ClientScope ClientScopeConfig
@@ -0,0 +1,249 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package inventory
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"reflect"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	util "github.com/tmax-cloud/hypercloud-multi-operator/controllers/util"
+
+	coreV1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// inventory 를 export 하는 ConfigMap (hypercloud5-system namespace)
+	CatalogConfigMapName = "hypercloud-cluster-catalog"
+	// backstage 의 catalog entity 목록 (multi document yaml)
+	CatalogKeyBackstage = "catalog-info.yaml"
+	// inventory api 와 같은 형식의 json
+	CatalogKeyInventory = "inventory.json"
+)
+
+const (
+	backstageAPIVersion   = "backstage.io/v1alpha1"
+	backstageKindResource = "Resource"
+	backstageResourceType = "kubernetes-cluster"
+	// backstage 의 kubernetes plugin 이 entity 와 cluster 를 연결할 때 사용하는 annotation
+	backstageAnnotationKubernetesID = "backstage.io/kubernetes-id"
+	// 소유자를 알 수 없는 cluster 의 owner
+	backstageOwnerUnknown = "unknown"
+
+	annotationKeyClusterName      = "cluster.tmax.io/name"
+	annotationKeyClusterNamespace = "cluster.tmax.io/namespace"
+	annotationKeyClusterEndpoint  = "cluster.tmax.io/control-plane-endpoint"
+	annotationKeyClusterPeer      = "cluster.tmax.io/management-peer"
+)
+
+// backstage 의 entity name 은 영문, 숫자와 -_. 로만 구성되고 63자를 넘을 수 없다.
+var invalidEntityNameChars = regexp.MustCompile(`[^a-zA-Z0-9._-]+`)
+
+// BackstageEntity 는 backstage catalog 의 entity
+type BackstageEntity struct {
+	APIVersion string                `json:"apiVersion"`
+	Kind       string                `json:"kind"`
+	Metadata   BackstageMetadata     `json:"metadata"`
+	Spec       BackstageResourceSpec `json:"spec"`
+}
+
+type BackstageMetadata struct {
+	Name        string            `json:"name"`
+	Title       string            `json:"title,omitempty"`
+	Description string            `json:"description,omitempty"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+	Tags        []string          `json:"tags,omitempty"`
+}
+
+type BackstageResourceSpec struct {
+	Type  string `json:"type"`
+	Owner string `json:"owner"`
+}
+
+// inventory 의 cluster 들을 backstage 의 Resource entity 로 변환한다.
+func RenderBackstageCatalog(inventory *Inventory) ([]byte, error) {
+	buf := &bytes.Buffer{}
+	for i, cluster := range inventory.Clusters {
+		data, err := yaml.Marshal(backstageEntity(cluster))
+		if err != nil {
+			return nil, err
+		}
+		if i > 0 {
+			buf.WriteString("---\n")
+		}
+		buf.Write(data)
+	}
+	return buf.Bytes(), nil
+}
+
+func backstageEntity(cluster ClusterInventory) BackstageEntity {
+	annotations := map[string]string{
+		backstageAnnotationKubernetesID: cluster.Name,
+		annotationKeyClusterName:        cluster.Name,
+		annotationKeyClusterNamespace:   cluster.Namespace,
+	}
+	if cluster.ControlPlaneEndpoint != "" {
+		annotations[annotationKeyClusterEndpoint] = cluster.ControlPlaneEndpoint
+	}
+	if cluster.Peer != "" {
+		annotations[annotationKeyClusterPeer] = cluster.Peer
+	}
+
+	tags := []string{}
+	for _, tag := range []string{cluster.Type, cluster.Provider, cluster.Distribution} {
+		if tag = entityTag(tag); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+
+	return BackstageEntity{
+		APIVersion: backstageAPIVersion,
+		Kind:       backstageKindResource,
+		Metadata: BackstageMetadata{
+			Name:        entityName(cluster.Namespace + "-" + cluster.Name),
+			Title:       cluster.Name,
+			Description: "HyperCloud cluster [" + cluster.Namespace + "/" + cluster.Name + "] " + cluster.Version,
+			Annotations: annotations,
+			Tags:        tags,
+		},
+		Spec: BackstageResourceSpec{
+			Type:  backstageResourceType,
+			Owner: entityOwner(cluster),
+		},
+	}
+}
+
+// team 이 있으면 team 을, 없으면 cluster 의 owner 를 소유자로 한다.
+func entityOwner(cluster ClusterInventory) string {
+	if cluster.Team != "" {
+		return "group:" + entityName(cluster.Team)
+	}
+	if cluster.Owner != "" {
+		return "user:" + entityName(cluster.Owner)
+	}
+	return backstageOwnerUnknown
+}
+
+func entityName(name string) string {
+	name = strings.Trim(invalidEntityNameChars.ReplaceAllString(name, "-"), "-_.")
+	if len(name) > 63 {
+		name = strings.TrimRight(name[:63], "-_.")
+	}
+	return name
+}
+
+// backstage 의 tag 는 소문자, 숫자와 - 로만 구성된다.
+func entityTag(tag string) string {
+	return strings.Trim(invalidEntityNameChars.ReplaceAllString(strings.ToLower(tag), "-"), "-_.")
+}
+
+// CatalogExporter 는 Interval 마다 fleet inventory 를 ConfigMap 에 export 한다.
+// backstage 등 portal 은 ConfigMap 을 읽거나 inventory api 의 format=backstage 로 cluster 를 조회할 수 있다.
+type CatalogExporter struct {
+	Client client.Client
+	// ConfigMap 은 cache 하지 않으므로 api server 에서 직접 조회한다.
+	Reader   client.Reader
+	Log      logr.Logger
+	Interval time.Duration
+}
+
+// +kubebuilder:rbac:groups="",resources=configmaps,verbs=create;get;update
+
+// 여러 replica 가 같은 ConfigMap 을 쓰지 않도록 leader 에서만 동작한다.
+func (e *CatalogExporter) NeedLeaderElection() bool {
+	return true
+}
+
+func (e *CatalogExporter) Start(ctx context.Context) error {
+	ticker := time.NewTicker(e.Interval)
+	defer ticker.Stop()
+
+	for {
+		if err := e.export(ctx); err != nil {
+			e.Log.Error(err, "Failed to export cluster catalog")
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+func (e *CatalogExporter) export(ctx context.Context) error {
+	inventory, err := ListInventory(ctx, e.Client, "")
+	if err != nil {
+		return err
+	}
+	// heartbeat 는 계속 갱신되므로 ConfigMap 을 매번 update 하지 않도록 export 하지 않는다.
+	for i := range inventory.Clusters {
+		inventory.Clusters[i].LastHeartbeatTime = nil
+	}
+	catalog, err := RenderBackstageCatalog(inventory)
+	if err != nil {
+		return err
+	}
+	inventoryJSON, err := json.Marshal(inventory)
+	if err != nil {
+		return err
+	}
+	data := map[string]string{
+		CatalogKeyBackstage: string(catalog),
+		CatalogKeyInventory: string(inventoryJSON),
+	}
+
+	key := types.NamespacedName{
+		Name:      CatalogConfigMapName,
+		Namespace: util.HypercloudNamespace,
+	}
+	configMap := &coreV1.ConfigMap{}
+	if err := e.Reader.Get(ctx, key, configMap); errors.IsNotFound(err) {
+		configMap = &coreV1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      key.Name,
+				Namespace: key.Namespace,
+			},
+			Data: data,
+		}
+		if err := e.Client.Create(ctx, configMap); err != nil {
+			return err
+		}
+		e.Log.Info("Create cluster catalog successfully", "clusters", inventory.Total)
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	if reflect.DeepEqual(configMap.Data, data) {
+		return nil
+	}
+	configMap.Data = data
+	if err := e.Client.Update(ctx, configMap); err != nil {
+		return err
+	}
+	e.Log.Info("Update cluster catalog successfully", "clusters", inventory.Total)
+	return nil
+}
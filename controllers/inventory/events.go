@@ -0,0 +1,269 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package inventory
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	claimV1alpha1 "github.com/tmax-cloud/hypercloud-multi-operator/apis/claim/v1alpha1"
+	clusterV1alpha1 "github.com/tmax-cloud/hypercloud-multi-operator/apis/cluster/v1alpha1"
+
+	authenticationV1 "k8s.io/api/authentication/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	toolscache "k8s.io/client-go/tools/cache"
+
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	EventsPath = "/inventory/events"
+
+	// 구독자가 읽지 못하고 쌓아둘 수 있는 event 수. 넘으면 연결을 끊고 console 이 다시 연결하도록 한다.
+	eventBufferSize = 64
+	// proxy 가 idle 연결을 끊지 않도록 보내는 keepalive 주기
+	eventKeepaliveInterval = 30 * time.Second
+)
+
+// PhaseEvent 는 console 에 전달하는 resource 의 phase 변경
+type PhaseEvent struct {
+	Kind          string `json:"kind"`
+	Namespace     string `json:"namespace"`
+	Name          string `json:"name"`
+	Phase         string `json:"phase"`
+	PreviousPhase string `json:"previousPhase,omitempty"`
+	Reason        string `json:"reason,omitempty"`
+	// RFC3339 형식의 phase 가 변경된 시각
+	Time string `json:"time"`
+}
+
+// phase 변경을 전달하는 resource
+// 구독자는 list 권한이 있는 resource 의 event 만 받는다.
+var eventResources = []struct {
+	kind     string
+	group    string
+	resource string
+	object   client.Object
+}{
+	{"ClusterManager", clusterV1alpha1.GroupVersion.Group, "clustermanagers", &clusterV1alpha1.ClusterManager{}},
+	{"ClusterRegistration", clusterV1alpha1.GroupVersion.Group, "clusterregistrations", &clusterV1alpha1.ClusterRegistration{}},
+	{"ClusterClaim", claimV1alpha1.GroupVersion.Group, "clusterclaims", &claimV1alpha1.ClusterClaim{}},
+}
+
+// phaseBroadcaster 는 phase 변경을 namespace, kind 별 구독자에게 전달한다.
+type phaseBroadcaster struct {
+	mu          sync.Mutex
+	subscribers map[chan PhaseEvent]subscription
+}
+
+type subscription struct {
+	// 비어있으면 모든 namespace 의 event 를 받는다.
+	namespace string
+	kinds     sets.String
+}
+
+func newPhaseBroadcaster() *phaseBroadcaster {
+	return &phaseBroadcaster{
+		subscribers: map[chan PhaseEvent]subscription{},
+	}
+}
+
+// namespace 가 비어있으면 모든 namespace 의 event 를 받는다.
+func (b *phaseBroadcaster) subscribe(namespace string, kinds sets.String) chan PhaseEvent {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	ch := make(chan PhaseEvent, eventBufferSize)
+	b.subscribers[ch] = subscription{namespace: namespace, kinds: kinds}
+	return ch
+}
+
+func (b *phaseBroadcaster) unsubscribe(ch chan PhaseEvent) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if _, ok := b.subscribers[ch]; ok {
+		delete(b.subscribers, ch)
+		close(ch)
+	}
+}
+
+// informer 의 handler 를 막지 않도록 읽지 못하는 구독자는 제거한다.
+func (b *phaseBroadcaster) publish(event PhaseEvent) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for ch, sub := range b.subscribers {
+		if (sub.namespace != "" && sub.namespace != event.Namespace) || !sub.kinds.Has(event.Kind) {
+			continue
+		}
+		select {
+		case ch <- event:
+		default:
+			delete(b.subscribers, ch)
+			close(ch)
+		}
+	}
+}
+
+// phase 를 가진 resource 의 kind, phase, reason 을 반환한다.
+func phaseOf(obj interface{}) (kind, phase, reason string, ok bool) {
+	switch o := obj.(type) {
+	case *clusterV1alpha1.ClusterManager:
+		return "ClusterManager", string(o.Status.Phase), "", true
+	case *clusterV1alpha1.ClusterRegistration:
+		return "ClusterRegistration", string(o.Status.Phase), string(o.Status.Reason), true
+	case *claimV1alpha1.ClusterClaim:
+		return "ClusterClaim", string(o.Status.Phase), o.Status.Reason, true
+	}
+	return "", "", "", false
+}
+
+// cache 의 informer 에 handler 를 등록하여 phase 가 변경될 때마다 publish 한다.
+func (b *phaseBroadcaster) watch(ctx context.Context, informers cache.Informers) error {
+	handler := toolscache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			b.publishTransition(nil, obj)
+		},
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			b.publishTransition(oldObj, newObj)
+		},
+	}
+	for _, r := range eventResources {
+		informer, err := informers.GetInformer(ctx, r.object)
+		if err != nil {
+			return err
+		}
+		informer.AddEventHandler(handler)
+	}
+	return nil
+}
+
+func (b *phaseBroadcaster) publishTransition(oldObj, newObj interface{}) {
+	kind, phase, reason, ok := phaseOf(newObj)
+	if !ok || phase == "" {
+		return
+	}
+	previousPhase := ""
+	if oldObj != nil {
+		_, previousPhase, _, _ = phaseOf(oldObj)
+		if previousPhase == phase {
+			return
+		}
+	}
+	o := newObj.(client.Object)
+	b.publish(PhaseEvent{
+		Kind:          kind,
+		Namespace:     o.GetNamespace(),
+		Name:          o.GetName(),
+		Phase:         phase,
+		PreviousPhase: previousPhase,
+		Reason:        reason,
+		Time:          time.Now().UTC().Format(time.RFC3339),
+	})
+}
+
+// server-sent events 로 phase 변경을 전달한다. console 은 EventSource 로 연결한다.
+// 연결이 오래 유지되며 token 과 phase 변경이 오가므로 https 연결이 아니면 거부한다.
+func (s *Server) handleEvents(w http.ResponseWriter, req *http.Request) {
+	if req.TLS == nil {
+		http.Error(w, errTLSRequired.Error(), http.StatusForbidden)
+		return
+	}
+	if req.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming is not supported", http.StatusInternalServerError)
+		return
+	}
+
+	namespace := req.URL.Query().Get("namespace")
+	user, code, err := s.authenticate(req)
+	if err != nil {
+		s.Log.Info("Unauthorized events request", "reason", err.Error())
+		http.Error(w, err.Error(), code)
+		return
+	}
+	kinds, err := s.listableKinds(req.Context(), user, namespace)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if kinds.Len() == 0 {
+		s.Log.Info("Unauthorized events request", "reason", errForbidden.Error(), "user", user.Username)
+		http.Error(w, errForbidden.Error(), http.StatusForbidden)
+		return
+	}
+
+	ch := s.events.subscribe(namespace, kinds)
+	defer s.events.unsubscribe(ch)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	keepalive := time.NewTicker(eventKeepaliveInterval)
+	defer keepalive.Stop()
+
+	for {
+		select {
+		case <-req.Context().Done():
+			return
+		case <-keepalive.C:
+			if _, err := w.Write([]byte(": keepalive\n\n")); err != nil {
+				return
+			}
+			flusher.Flush()
+		case event, ok := <-ch:
+			// 읽는 속도가 느려 구독이 해제된 경우
+			if !ok {
+				return
+			}
+			data, err := json.Marshal(event)
+			if err != nil {
+				s.Log.Error(err, "Failed to marshal phase event")
+				continue
+			}
+			if _, err := w.Write([]byte("event: phase\ndata: " + string(data) + "\n\n")); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
+
+// 사용자가 namespace 에서 list 할 수 있는 resource 의 kind 를 반환한다.
+func (s *Server) listableKinds(ctx context.Context, user *authenticationV1.UserInfo, namespace string) (sets.String, error) {
+	kinds := sets.NewString()
+	for _, r := range eventResources {
+		allowed, err := s.canList(ctx, user, namespace, r.group, r.resource)
+		if err != nil {
+			return nil, err
+		}
+		if allowed {
+			kinds.Insert(r.kind)
+		}
+	}
+	return kinds, nil
+}
@@ -0,0 +1,106 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package inventory
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-logr/logr"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+func TestPhaseBroadcasterPublish(t *testing.T) {
+	tests := []struct {
+		name      string
+		namespace string
+		kinds     sets.String
+		event     PhaseEvent
+		want      bool
+	}{
+		{
+			name:  "all namespaces",
+			kinds: sets.NewString("ClusterManager"),
+			event: PhaseEvent{Kind: "ClusterManager", Namespace: "a"},
+			want:  true,
+		},
+		{
+			name:      "same namespace",
+			namespace: "a",
+			kinds:     sets.NewString("ClusterManager"),
+			event:     PhaseEvent{Kind: "ClusterManager", Namespace: "a"},
+			want:      true,
+		},
+		{
+			name:      "other namespace",
+			namespace: "a",
+			kinds:     sets.NewString("ClusterManager"),
+			event:     PhaseEvent{Kind: "ClusterManager", Namespace: "b"},
+		},
+		{
+			// list 권한이 없는 kind 의 event 는 전달하지 않는다.
+			name:  "kind not allowed",
+			kinds: sets.NewString("ClusterManager", "ClusterRegistration"),
+			event: PhaseEvent{Kind: "ClusterClaim", Namespace: "a"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := newPhaseBroadcaster()
+			ch := b.subscribe(tt.namespace, tt.kinds)
+			defer b.unsubscribe(ch)
+
+			b.publish(tt.event)
+			select {
+			case event := <-ch:
+				if !tt.want {
+					t.Errorf("event %v should not be delivered", event)
+				}
+			default:
+				if tt.want {
+					t.Error("event is not delivered")
+				}
+			}
+		})
+	}
+}
+
+func TestHandleEventsRequiresTLS(t *testing.T) {
+	tests := []struct {
+		name string
+		tls  bool
+		want int
+	}{
+		{name: "plaintext", tls: false, want: http.StatusForbidden},
+		// tls 연결이면 인증 단계로 넘어간다.
+		{name: "tls without token", tls: true, want: http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, EventsPath, nil)
+			if tt.tls {
+				req.TLS = &tls.ConnectionState{}
+			}
+			rec := httptest.NewRecorder()
+			(&Server{Log: logr.Discard()}).handleEvents(rec, req)
+			if rec.Code != tt.want {
+				t.Errorf("expected status %d, got %d", tt.want, rec.Code)
+			}
+		})
+	}
+}
@@ -0,0 +1,299 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package inventory
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/version"
+)
+
+const (
+	SortByName      = "name"
+	SortByVersion   = "version"
+	SortByHeartbeat = "heartbeat"
+
+	SortOrderAsc  = "asc"
+	SortOrderDesc = "desc"
+)
+
+// fieldSelector 로 조회할 수 있는 field, inventory 의 json field 이름과 같다.
+var inventoryFields = sets.NewString(
+	"name", "namespace", "type", "provider", "version", "distribution",
+	"phase", "ready", "owner", "creator", "team", "peer",
+)
+
+// InventoryQuery 는 console 이 inventory 를 server 에서 filter, 정렬, paging 하기 위한 조건
+type InventoryQuery struct {
+	// cluster manager 의 label 에 대한 selector. peer cluster 는 label 이 없으므로 selector 가 있으면 제외된다.
+	LabelSelector labels.Selector
+	FieldSelector fields.Selector
+	// 비어있으면 모든 phase 를 조회한다.
+	Phases sets.String
+	// version 범위 (양 끝 포함), 범위가 있으면 version 을 알 수 없는 cluster 는 제외된다.
+	MinVersion *version.Version
+	MaxVersion *version.Version
+	SortBy     string
+	SortOrder  string
+	// 0 이면 paging 하지 않는다.
+	Limit int64
+	// 이전 응답의 continue 에서 얻은 시작 위치
+	Offset int
+}
+
+// url query 로부터 InventoryQuery 를 만든다.
+// labelSelector, fieldSelector, phase, minVersion, maxVersion, sortBy, order, limit, continue 를 지원한다.
+func ParseInventoryQuery(values url.Values) (*InventoryQuery, error) {
+	query := &InventoryQuery{
+		LabelSelector: labels.Everything(),
+		FieldSelector: fields.Everything(),
+		Phases:        sets.NewString(),
+		SortBy:        SortByName,
+		SortOrder:     SortOrderAsc,
+	}
+
+	if s := values.Get("labelSelector"); s != "" {
+		selector, err := labels.Parse(s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid labelSelector: %w", err)
+		}
+		query.LabelSelector = selector
+	}
+	if s := values.Get("fieldSelector"); s != "" {
+		selector, err := fields.ParseSelector(s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid fieldSelector: %w", err)
+		}
+		for _, req := range selector.Requirements() {
+			if !inventoryFields.Has(req.Field) {
+				return nil, fmt.Errorf("invalid fieldSelector: unsupported field %q", req.Field)
+			}
+		}
+		query.FieldSelector = selector
+	}
+	// phase=Ready,Failed 또는 phase=Ready&phase=Failed 로 여러 phase 를 지정할 수 있다.
+	for _, v := range values["phase"] {
+		for _, phase := range strings.Split(v, ",") {
+			if phase = strings.TrimSpace(phase); phase != "" {
+				query.Phases.Insert(phase)
+			}
+		}
+	}
+	for key, target := range map[string]**version.Version{
+		"minVersion": &query.MinVersion,
+		"maxVersion": &query.MaxVersion,
+	} {
+		if s := values.Get(key); s != "" {
+			v, err := version.ParseGeneric(s)
+			if err != nil {
+				return nil, fmt.Errorf("invalid %s: %w", key, err)
+			}
+			*target = v
+		}
+	}
+	if query.MinVersion != nil && query.MaxVersion != nil && query.MaxVersion.LessThan(query.MinVersion) {
+		return nil, fmt.Errorf("maxVersion %s is less than minVersion %s", query.MaxVersion, query.MinVersion)
+	}
+
+	if s := values.Get("sortBy"); s != "" {
+		switch s {
+		case SortByName, SortByVersion, SortByHeartbeat:
+			query.SortBy = s
+		default:
+			return nil, fmt.Errorf("invalid sortBy %q: must be one of %s, %s, %s", s, SortByName, SortByVersion, SortByHeartbeat)
+		}
+	}
+	if s := values.Get("order"); s != "" {
+		if s != SortOrderAsc && s != SortOrderDesc {
+			return nil, fmt.Errorf("invalid order %q: must be %s or %s", s, SortOrderAsc, SortOrderDesc)
+		}
+		query.SortOrder = s
+	}
+
+	if s := values.Get("limit"); s != "" {
+		limit, err := strconv.ParseInt(s, 10, 64)
+		if err != nil || limit < 0 {
+			return nil, fmt.Errorf("invalid limit %q", s)
+		}
+		query.Limit = limit
+	}
+	if s := values.Get("continue"); s != "" {
+		offset, err := decodeContinue(s)
+		if err != nil {
+			return nil, err
+		}
+		query.Offset = offset
+	}
+	return query, nil
+}
+
+// query 의 filter 를 만족하는 cluster 인지 확인한다.
+func (q *InventoryQuery) Matches(cluster ClusterInventory) bool {
+	if q.LabelSelector != nil && !q.LabelSelector.Empty() && !q.LabelSelector.Matches(labels.Set(cluster.Labels)) {
+		return false
+	}
+	if q.FieldSelector != nil && !q.FieldSelector.Empty() && !q.FieldSelector.Matches(inventoryFieldSet(cluster)) {
+		return false
+	}
+	if q.Phases.Len() > 0 && !q.Phases.Has(string(cluster.Phase)) {
+		return false
+	}
+	if q.MinVersion == nil && q.MaxVersion == nil {
+		return true
+	}
+	v, err := version.ParseGeneric(cluster.Version)
+	if err != nil {
+		return false
+	}
+	if q.MinVersion != nil && v.LessThan(q.MinVersion) {
+		return false
+	}
+	if q.MaxVersion != nil && q.MaxVersion.LessThan(v) {
+		return false
+	}
+	return true
+}
+
+// filter 를 만족하는 cluster 만 남기고 정렬한 뒤 limit 만큼 잘라낸다.
+// total, readyCount 는 paging 전의 cluster 수이고, 다음 page 가 있으면 continue 를 설정한다.
+func (q *InventoryQuery) Apply(inventory *Inventory) error {
+	clusters := []ClusterInventory{}
+	readyCount := 0
+	for _, cluster := range inventory.Clusters {
+		if !q.Matches(cluster) {
+			continue
+		}
+		clusters = append(clusters, cluster)
+		if cluster.Ready {
+			readyCount++
+		}
+	}
+	q.sort(clusters)
+
+	inventory.Total = len(clusters)
+	inventory.ReadyCount = readyCount
+	inventory.Continue = ""
+	if q.Offset > len(clusters) {
+		return fmt.Errorf("%w: offset %d is out of range", errInvalidContinue, q.Offset)
+	}
+	clusters = clusters[q.Offset:]
+	if q.Limit > 0 && int64(len(clusters)) > q.Limit {
+		clusters = clusters[:q.Limit]
+		inventory.Continue = encodeContinue(q.Offset + len(clusters))
+	}
+	inventory.Clusters = clusters
+	return nil
+}
+
+// 정렬 기준이 같으면 namespace, name 순으로 정렬하여 page 사이의 순서가 바뀌지 않도록 한다.
+func (q *InventoryQuery) sort(clusters []ClusterInventory) {
+	byName := func(a, b ClusterInventory) bool {
+		if a.Namespace != b.Namespace {
+			return a.Namespace < b.Namespace
+		}
+		return a.Name < b.Name
+	}
+	desc := q.SortOrder == SortOrderDesc
+
+	sort.SliceStable(clusters, func(i, j int) bool {
+		a, b := clusters[i], clusters[j]
+		switch q.SortBy {
+		case SortByVersion:
+			if c := compareVersion(a.Version, b.Version); c != 0 {
+				return (c < 0) != desc
+			}
+		case SortByHeartbeat:
+			// heartbeat 가 없는 cluster 는 가장 오래된 것으로 본다.
+			at, bt := a.LastHeartbeatTime, b.LastHeartbeatTime
+			if at == nil && bt != nil {
+				return !desc
+			}
+			if at != nil && bt == nil {
+				return desc
+			}
+			if at != nil && bt != nil && !at.Equal(bt) {
+				return at.Before(bt) != desc
+			}
+		default:
+			if a.Namespace != b.Namespace || a.Name != b.Name {
+				return byName(a, b) != desc
+			}
+		}
+		return byName(a, b)
+	})
+}
+
+// parse 할 수 없는 version 은 가장 낮은 version 으로 본다.
+func compareVersion(a, b string) int {
+	av, aerr := version.ParseGeneric(a)
+	bv, berr := version.ParseGeneric(b)
+	switch {
+	case aerr != nil && berr != nil:
+		return strings.Compare(a, b)
+	case aerr != nil:
+		return -1
+	case berr != nil:
+		return 1
+	}
+	if av.LessThan(bv) {
+		return -1
+	}
+	if bv.LessThan(av) {
+		return 1
+	}
+	return 0
+}
+
+func inventoryFieldSet(cluster ClusterInventory) fields.Set {
+	return fields.Set{
+		"name":         cluster.Name,
+		"namespace":    cluster.Namespace,
+		"type":         cluster.Type,
+		"provider":     cluster.Provider,
+		"version":      cluster.Version,
+		"distribution": cluster.Distribution,
+		"phase":        string(cluster.Phase),
+		"ready":        strconv.FormatBool(cluster.Ready),
+		"owner":        cluster.Owner,
+		"creator":      cluster.Creator,
+		"team":         cluster.Team,
+		"peer":         cluster.Peer,
+	}
+}
+
+// continue token 은 다음 page 의 시작 위치이다. 조회 사이에 cluster 가 추가, 삭제되면 page 의 경계가 밀릴 수 있다.
+func encodeContinue(offset int) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.Itoa(offset)))
+}
+
+func decodeContinue(token string) (int, error) {
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %s", errInvalidContinue, err)
+	}
+	offset, err := strconv.Atoi(string(data))
+	if err != nil || offset < 0 {
+		return 0, fmt.Errorf("%w %q", errInvalidContinue, token)
+	}
+	return offset, nil
+}
@@ -0,0 +1,257 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package inventory
+
+import (
+	"errors"
+	"net/url"
+	"reflect"
+	"testing"
+	"time"
+
+	clusterV1alpha1 "github.com/tmax-cloud/hypercloud-multi-operator/apis/cluster/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestParseInventoryQuery(t *testing.T) {
+	tests := []struct {
+		name    string
+		query   string
+		check   func(*testing.T, *InventoryQuery)
+		wantErr bool
+	}{
+		{
+			name:  "defaults",
+			query: "",
+			check: func(t *testing.T, q *InventoryQuery) {
+				if !q.LabelSelector.Empty() || !q.FieldSelector.Empty() || q.Phases.Len() != 0 {
+					t.Error("selectors should be empty")
+				}
+				if q.SortBy != SortByName || q.SortOrder != SortOrderAsc || q.Limit != 0 || q.Offset != 0 {
+					t.Errorf("query = %+v, want sort by name asc without paging", q)
+				}
+			},
+		},
+		{
+			name:  "phases from comma separated and repeated values",
+			query: "phase=Ready,Failed&phase=Provisioning&phase=",
+			check: func(t *testing.T, q *InventoryQuery) {
+				if want := []string{"Failed", "Provisioning", "Ready"}; !reflect.DeepEqual(q.Phases.List(), want) {
+					t.Errorf("Phases = %v, want %v", q.Phases.List(), want)
+				}
+			},
+		},
+		{
+			name:  "sort and paging",
+			query: "sortBy=heartbeat&order=desc&limit=10&continue=" + encodeContinue(20),
+			check: func(t *testing.T, q *InventoryQuery) {
+				if q.SortBy != SortByHeartbeat || q.SortOrder != SortOrderDesc || q.Limit != 10 || q.Offset != 20 {
+					t.Errorf("query = %+v", q)
+				}
+			},
+		},
+		{
+			name:  "version range",
+			query: "minVersion=v1.22&maxVersion=1.24.3",
+			check: func(t *testing.T, q *InventoryQuery) {
+				if q.MinVersion.String() != "1.22" || q.MaxVersion.String() != "1.24.3" {
+					t.Errorf("version range = %s ~ %s", q.MinVersion, q.MaxVersion)
+				}
+			},
+		},
+		{name: "invalid labelSelector", query: "labelSelector=a+in+(", wantErr: true},
+		{name: "unsupported field", query: "fieldSelector=spec.foo%3Dbar", wantErr: true},
+		{name: "invalid version", query: "minVersion=latest", wantErr: true},
+		{name: "reversed version range", query: "minVersion=1.24&maxVersion=1.22", wantErr: true},
+		{name: "invalid sortBy", query: "sortBy=age", wantErr: true},
+		{name: "invalid order", query: "order=random", wantErr: true},
+		{name: "negative limit", query: "limit=-1", wantErr: true},
+		{name: "invalid continue", query: "continue=%21%21", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			values, err := url.ParseQuery(tt.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+			q, err := ParseInventoryQuery(values)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseInventoryQuery() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.check != nil {
+				tt.check(t, q)
+			}
+		})
+	}
+}
+
+func newTestInventory() *Inventory {
+	heartbeat := func(minutes int) *metav1.Time {
+		t := metav1.NewTime(time.Date(2026, 1, 1, 0, minutes, 0, 0, time.UTC))
+		return &t
+	}
+	return &Inventory{
+		Clusters: []ClusterInventory{
+			{
+				Name: "c", Namespace: "a", Version: "v1.24.3", Phase: clusterV1alpha1.ClusterManagerPhaseReady, Ready: true,
+				Provider: "AWS", LastHeartbeatTime: heartbeat(3), Labels: map[string]string{"env": "prod"},
+			},
+			{
+				Name: "a", Namespace: "b", Version: "v1.22.1", Phase: clusterV1alpha1.ClusterManagerPhaseReady, Ready: true,
+				Provider: "vSphere", LastHeartbeatTime: heartbeat(1), Labels: map[string]string{"env": "dev"},
+			},
+			{
+				Name: "b", Namespace: "a", Version: "unknown", Phase: clusterV1alpha1.ClusterManagerPhaseProcessing,
+				Provider: "AWS",
+			},
+			{
+				Name: "peer", Namespace: "a", Version: "v1.23.0", Phase: clusterV1alpha1.ClusterManagerPhaseReady, Ready: true,
+				Peer: "hub", LastHeartbeatTime: heartbeat(2),
+			},
+		},
+	}
+}
+
+func inventoryNames(inventory *Inventory) []string {
+	names := []string{}
+	for _, cluster := range inventory.Clusters {
+		names = append(names, cluster.Namespace+"/"+cluster.Name)
+	}
+	return names
+}
+
+func TestInventoryQueryApply(t *testing.T) {
+	tests := []struct {
+		name           string
+		query          string
+		want           []string
+		wantTotal      int
+		wantReadyCount int
+		wantContinue   string
+	}{
+		{
+			name:           "sort by name",
+			want:           []string{"a/b", "a/c", "a/peer", "b/a"},
+			wantTotal:      4,
+			wantReadyCount: 3,
+		},
+		{
+			name:           "sort by name desc",
+			query:          "order=desc",
+			want:           []string{"b/a", "a/peer", "a/c", "a/b"},
+			wantTotal:      4,
+			wantReadyCount: 3,
+		},
+		{
+			// parse 할 수 없는 version 은 가장 낮은 version 으로 본다.
+			name:           "sort by version",
+			query:          "sortBy=version",
+			want:           []string{"a/b", "b/a", "a/peer", "a/c"},
+			wantTotal:      4,
+			wantReadyCount: 3,
+		},
+		{
+			// heartbeat 가 없는 cluster 는 가장 오래된 것으로 본다.
+			name:           "sort by heartbeat desc",
+			query:          "sortBy=heartbeat&order=desc",
+			want:           []string{"a/c", "a/peer", "b/a", "a/b"},
+			wantTotal:      4,
+			wantReadyCount: 3,
+		},
+		{
+			// peer cluster 는 label 이 없으므로 제외된다.
+			name:           "label selector",
+			query:          "labelSelector=env%20in%20(prod,dev)",
+			want:           []string{"a/c", "b/a"},
+			wantTotal:      2,
+			wantReadyCount: 2,
+		},
+		{
+			name:           "field selector",
+			query:          "fieldSelector=provider%3DAWS,ready%3Dtrue",
+			want:           []string{"a/c"},
+			wantTotal:      1,
+			wantReadyCount: 1,
+		},
+		{
+			name:           "phase",
+			query:          "phase=Processing",
+			want:           []string{"a/b"},
+			wantTotal:      1,
+			wantReadyCount: 0,
+		},
+		{
+			// version 을 알 수 없는 cluster 는 제외된다.
+			name:           "version range",
+			query:          "minVersion=1.23&maxVersion=1.24.3",
+			want:           []string{"a/c", "a/peer"},
+			wantTotal:      2,
+			wantReadyCount: 2,
+		},
+		{
+			name:           "first page",
+			query:          "limit=3",
+			want:           []string{"a/b", "a/c", "a/peer"},
+			wantTotal:      4,
+			wantReadyCount: 3,
+			wantContinue:   encodeContinue(3),
+		},
+		{
+			name:           "last page",
+			query:          "limit=3&continue=" + encodeContinue(3),
+			want:           []string{"b/a"},
+			wantTotal:      4,
+			wantReadyCount: 3,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			values, err := url.ParseQuery(tt.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+			q, err := ParseInventoryQuery(values)
+			if err != nil {
+				t.Fatalf("ParseInventoryQuery() error = %v", err)
+			}
+			inventory := newTestInventory()
+			if err := q.Apply(inventory); err != nil {
+				t.Fatalf("Apply() error = %v", err)
+			}
+			if got := inventoryNames(inventory); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("clusters = %v, want %v", got, tt.want)
+			}
+			if inventory.Total != tt.wantTotal || inventory.ReadyCount != tt.wantReadyCount {
+				t.Errorf("total, readyCount = %d, %d, want %d, %d", inventory.Total, inventory.ReadyCount, tt.wantTotal, tt.wantReadyCount)
+			}
+			if inventory.Continue != tt.wantContinue {
+				t.Errorf("continue = %q, want %q", inventory.Continue, tt.wantContinue)
+			}
+		})
+	}
+}
+
+func TestInventoryQueryApplyOutOfRange(t *testing.T) {
+	values := url.Values{"continue": []string{encodeContinue(10)}}
+	q, err := ParseInventoryQuery(values)
+	if err != nil {
+		t.Fatalf("ParseInventoryQuery() error = %v", err)
+	}
+	if err := q.Apply(newTestInventory()); !errors.Is(err, errInvalidContinue) {
+		t.Errorf("Apply() error = %v, want errInvalidContinue", err)
+	}
+}
@@ -0,0 +1,379 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package inventory
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	clusterV1alpha1 "github.com/tmax-cloud/hypercloud-multi-operator/apis/cluster/v1alpha1"
+	util "github.com/tmax-cloud/hypercloud-multi-operator/controllers/util"
+
+	authenticationV1 "k8s.io/api/authentication/v1"
+	authorizationV1 "k8s.io/api/authorization/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	InventoryPath = "/inventory"
+
+	FormatBackstage = "backstage"
+)
+
+var (
+	errUnauthorized    = errors.New("unauthorized")
+	errForbidden       = errors.New("forbidden")
+	errReviewFailed    = errors.New("failed to review the request")
+	errInvalidContinue = errors.New("invalid continue")
+	errTLSRequired     = errors.New("https is required")
+)
+
+// ClusterInventory 는 console 에서 보여줄 cluster 하나의 요약 정보
+type ClusterInventory struct {
+	Name                 string                              `json:"name"`
+	Namespace            string                              `json:"namespace"`
+	Type                 string                              `json:"type,omitempty"`
+	Provider             string                              `json:"provider,omitempty"`
+	Version              string                              `json:"version,omitempty"`
+	Distribution         string                              `json:"distribution,omitempty"`
+	Phase                clusterV1alpha1.ClusterManagerPhase `json:"phase,omitempty"`
+	Ready                bool                                `json:"ready"`
+	MasterNum            int                                 `json:"masterNum"`
+	MasterRun            int                                 `json:"masterRun"`
+	WorkerNum            int                                 `json:"workerNum"`
+	WorkerRun            int                                 `json:"workerRun"`
+	Owner                string                              `json:"owner,omitempty"`
+	Creator              string                              `json:"creator,omitempty"`
+	Team                 string                              `json:"team,omitempty"`
+	ControlPlaneEndpoint string                              `json:"controlPlaneEndpoint,omitempty"`
+	LastHeartbeatTime    *metav1.Time                        `json:"lastHeartbeatTime,omitempty"`
+	Labels               map[string]string                   `json:"labels,omitempty"`
+	// peer management cluster 에서 가져온 cluster 인 경우 ManagementPeer 의 이름, 이 cluster 는 read-only 이다.
+	Peer string `json:"peer,omitempty"`
+}
+
+// Inventory 는 fleet 전체의 요약 정보
+// query 로 조회한 경우 total, readyCount 는 조건을 만족하는 cluster 의 수이다.
+type Inventory struct {
+	Total      int                `json:"total"`
+	ReadyCount int                `json:"readyCount"`
+	Clusters   []ClusterInventory `json:"clusters"`
+	// 다음 page 가 있으면 다음 요청의 continue query 에 넣을 token
+	Continue string `json:"continue,omitempty"`
+}
+
+// Server 는 console 이 cluster 마다 watch 를 맺지 않도록 cluster manager 들의 요약 정보를 제공하는 http server
+// manager 에 runnable 로 등록되어 manager 와 함께 시작되고 종료된다.
+// Cache 가 설정되면 EventsPath 로 cluster 의 phase 변경을 streaming 한다.
+type Server struct {
+	Client      client.Client
+	Cache       cache.Informers
+	Log         logr.Logger
+	BindAddress string
+	// 사용자의 token 을 받으므로 https 로만 요청을 받는다. tls.crt, tls.key 가 있는 directory
+	CertDir string
+
+	events *phaseBroadcaster
+}
+
+// +kubebuilder:rbac:groups=authentication.k8s.io,resources=tokenreviews,verbs=create
+// +kubebuilder:rbac:groups=authorization.k8s.io,resources=subjectaccessreviews,verbs=create
+
+// leader 가 아닌 replica 에서도 요청을 처리할 수 있도록 leader election 과 무관하게 동작한다.
+func (s *Server) NeedLeaderElection() bool {
+	return false
+}
+
+func (s *Server) Start(ctx context.Context) error {
+	mux := http.NewServeMux()
+	mux.HandleFunc(InventoryPath, s.handleInventory)
+	if s.Cache != nil {
+		s.events = newPhaseBroadcaster()
+		if err := s.events.watch(ctx, s.Cache); err != nil {
+			return err
+		}
+		mux.HandleFunc(EventsPath, s.handleEvents)
+	}
+
+	server := &http.Server{
+		Addr:              s.BindAddress,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	if err := util.ConfigureServingCert(ctx, server, s.CertDir, s.Log); err != nil {
+		return err
+	}
+
+	errCh := make(chan error, 1)
+	go func() {
+		s.Log.Info("Starting inventory server", "address", s.BindAddress, "certDir", s.CertDir)
+		if err := server.ListenAndServeTLS("", ""); err != nil && err != http.ErrServerClosed {
+			errCh <- err
+		}
+		close(errCh)
+	}()
+
+	select {
+	case <-ctx.Done():
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		return server.Shutdown(shutdownCtx)
+	case err := <-errCh:
+		return err
+	}
+}
+
+func (s *Server) handleInventory(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// namespace 가 없으면 모든 namespace 의 cluster 를 조회한다.
+	namespace := req.URL.Query().Get("namespace")
+	if code, err := s.authorize(req, namespace); err != nil {
+		s.Log.Info("Unauthorized inventory request", "reason", err.Error())
+		http.Error(w, err.Error(), code)
+		return
+	}
+
+	// labelSelector, fieldSelector, phase, version 범위로 filter 하고 sortBy, limit 으로 정렬, paging 한다.
+	query, err := ParseInventoryQuery(req.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	inventory, err := QueryInventory(req.Context(), s.Client, namespace, query)
+	if errors.Is(err, errInvalidContinue) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	} else if err != nil {
+		s.Log.Error(err, "Failed to get inventory")
+		http.Error(w, "failed to get inventory", http.StatusInternalServerError)
+		return
+	}
+
+	// format=backstage 인 경우 backstage 의 catalog entity 로 응답한다.
+	if req.URL.Query().Get("format") == FormatBackstage {
+		catalog, err := RenderBackstageCatalog(inventory)
+		if err != nil {
+			s.Log.Error(err, "Failed to render backstage catalog")
+			http.Error(w, "failed to render catalog", http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/yaml")
+		if _, err := w.Write(catalog); err != nil {
+			s.Log.Error(err, "Failed to write inventory response")
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(inventory); err != nil {
+		s.Log.Error(err, "Failed to write inventory response")
+	}
+}
+
+// bearer token 을 token review 로 인증하고, cluster manager 를 list 할 수 있는 사용자인지 subject access review 로 확인한다.
+func (s *Server) authorize(req *http.Request, namespace string) (int, error) {
+	user, code, err := s.authenticate(req)
+	if err != nil {
+		return code, err
+	}
+	allowed, err := s.canList(req.Context(), user, namespace, clusterV1alpha1.GroupVersion.Group, "clustermanagers")
+	if err != nil {
+		return http.StatusInternalServerError, err
+	}
+	if !allowed {
+		return http.StatusForbidden, errForbidden
+	}
+	return http.StatusOK, nil
+}
+
+// bearer token 을 token review 로 인증하여 사용자 정보를 반환한다.
+func (s *Server) authenticate(req *http.Request) (*authenticationV1.UserInfo, int, error) {
+	token := strings.TrimPrefix(req.Header.Get("Authorization"), "Bearer ")
+	if token == "" || token == req.Header.Get("Authorization") {
+		return nil, http.StatusUnauthorized, errUnauthorized
+	}
+
+	tokenReview := &authenticationV1.TokenReview{
+		Spec: authenticationV1.TokenReviewSpec{
+			Token: token,
+		},
+	}
+	if err := s.Client.Create(req.Context(), tokenReview); err != nil {
+		s.Log.Error(err, "Failed to create TokenReview")
+		return nil, http.StatusInternalServerError, errReviewFailed
+	}
+	if !tokenReview.Status.Authenticated {
+		return nil, http.StatusUnauthorized, errUnauthorized
+	}
+	return &tokenReview.Status.User, http.StatusOK, nil
+}
+
+// 사용자가 namespace 의 resource 를 list 할 수 있는지 subject access review 로 확인한다.
+func (s *Server) canList(ctx context.Context, user *authenticationV1.UserInfo, namespace, group, resource string) (bool, error) {
+	extra := map[string]authorizationV1.ExtraValue{}
+	for k, v := range user.Extra {
+		extra[k] = authorizationV1.ExtraValue(v)
+	}
+	sar := &authorizationV1.SubjectAccessReview{
+		Spec: authorizationV1.SubjectAccessReviewSpec{
+			User:   user.Username,
+			Groups: user.Groups,
+			UID:    user.UID,
+			Extra:  extra,
+			ResourceAttributes: &authorizationV1.ResourceAttributes{
+				Namespace: namespace,
+				Verb:      "list",
+				Group:     group,
+				Resource:  resource,
+			},
+		},
+	}
+	if err := s.Client.Create(ctx, sar); err != nil {
+		s.Log.Error(err, "Failed to create SubjectAccessReview", "resource", resource)
+		return false, errReviewFailed
+	}
+	return sar.Status.Allowed, nil
+}
+
+// namespace 가 비어있으면 모든 namespace 의 cluster 를 조회한다.
+func ListInventory(ctx context.Context, c client.Reader, namespace string) (*Inventory, error) {
+	return listInventory(ctx, c, namespace)
+}
+
+// query 의 조건으로 cluster 를 filter, 정렬하고 paging 한다.
+func QueryInventory(ctx context.Context, c client.Reader, namespace string, query *InventoryQuery) (*Inventory, error) {
+	opts := []client.ListOption{}
+	// label selector 는 cluster manager 를 조회할 때 cache 에서 먼저 거른다.
+	if query.LabelSelector != nil && !query.LabelSelector.Empty() {
+		opts = append(opts, client.MatchingLabelsSelector{Selector: query.LabelSelector})
+	}
+	inventory, err := listInventory(ctx, c, namespace, opts...)
+	if err != nil {
+		return nil, err
+	}
+	if err := query.Apply(inventory); err != nil {
+		return nil, err
+	}
+	return inventory, nil
+}
+
+func listInventory(ctx context.Context, c client.Reader, namespace string, opts ...client.ListOption) (*Inventory, error) {
+	clmList := &clusterV1alpha1.ClusterManagerList{}
+	if namespace != "" {
+		opts = append(opts, client.InNamespace(namespace))
+	}
+	if err := c.List(ctx, clmList, opts...); err != nil {
+		return nil, err
+	}
+
+	inventory := &Inventory{
+		Clusters: []ClusterInventory{},
+	}
+	for _, clm := range clmList.Items {
+		// 등록된 cluster 는 spec 에 version 이 없으므로 status 의 version 을 우선한다.
+		version := clm.Status.Version
+		if version == "" {
+			version = clm.Spec.Version
+		}
+		inventory.Clusters = append(inventory.Clusters, ClusterInventory{
+			Name:                 clm.Name,
+			Namespace:            clm.Namespace,
+			Type:                 clm.GetClusterType(),
+			Provider:             clm.Spec.Provider,
+			Version:              version,
+			Distribution:         clm.Status.Distribution,
+			Phase:                clm.Status.Phase,
+			Ready:                clm.Status.Ready,
+			MasterNum:            clm.Spec.MasterNum,
+			MasterRun:            clm.Status.MasterRun,
+			WorkerNum:            clm.Spec.WorkerNum,
+			WorkerRun:            clm.Status.WorkerRun,
+			Owner:                clm.Annotations[util.AnnotationKeyOwner],
+			Creator:              clm.Annotations[util.AnnotationKeyCreator],
+			Team:                 clm.Annotations[util.AnnotationKeyTeam],
+			ControlPlaneEndpoint: clm.Status.ControlPlaneEndpoint,
+			LastHeartbeatTime:    clm.Status.LastHeartbeatTime,
+			Labels:               clm.Labels,
+		})
+		if clm.Status.Ready {
+			inventory.ReadyCount++
+		}
+	}
+	if err := appendPeerClusters(ctx, c, namespace, inventory); err != nil {
+		return nil, err
+	}
+	sort.Slice(inventory.Clusters, func(i, j int) bool {
+		if inventory.Clusters[i].Namespace != inventory.Clusters[j].Namespace {
+			return inventory.Clusters[i].Namespace < inventory.Clusters[j].Namespace
+		}
+		return inventory.Clusters[i].Name < inventory.Clusters[j].Name
+	})
+	inventory.Total = len(inventory.Clusters)
+
+	return inventory, nil
+}
+
+// ManagementPeer 가 peer management cluster 에서 가져온 cluster 를 read-only 로 추가한다.
+// DR 구성에서 양쪽에 같은 cluster 가 등록되어 있으면 이 management cluster 의 cluster 만 표시한다.
+// namespace 가 비어있지 않으면 peer 에서도 같은 namespace 의 cluster 만 추가한다.
+func appendPeerClusters(ctx context.Context, c client.Reader, namespace string, inventory *Inventory) error {
+	peerList := &clusterV1alpha1.ManagementPeerList{}
+	if err := c.List(ctx, peerList); err != nil {
+		return err
+	}
+
+	listed := map[string]bool{}
+	for _, cluster := range inventory.Clusters {
+		listed[cluster.Namespace+"/"+cluster.Name] = true
+	}
+	for _, peer := range peerList.Items {
+		for _, cluster := range peer.Status.Clusters {
+			key := cluster.Namespace + "/" + cluster.Name
+			if (namespace != "" && cluster.Namespace != namespace) || listed[key] {
+				continue
+			}
+			listed[key] = true
+			inventory.Clusters = append(inventory.Clusters, ClusterInventory{
+				Name:                 cluster.Name,
+				Namespace:            cluster.Namespace,
+				Type:                 cluster.Type,
+				Provider:             cluster.Provider,
+				Version:              cluster.Version,
+				Phase:                cluster.Phase,
+				Ready:                cluster.Ready,
+				ControlPlaneEndpoint: cluster.ControlPlaneEndpoint,
+				Peer:                 peer.Namespace + "/" + peer.Name,
+			})
+			if cluster.Ready {
+				inventory.ReadyCount++
+			}
+		}
+	}
+	return nil
+}
@@ -16,17 +16,19 @@ package controllers
 
 import (
 	"context"
+	"reflect"
 	"strings"
 
 	"github.com/go-logr/logr"
 	clusterV1alpha1 "github.com/tmax-cloud/hypercloud-multi-operator/apis/cluster/v1alpha1"
+	"github.com/tmax-cloud/hypercloud-multi-operator/controllers/pipeline"
 	"github.com/tmax-cloud/hypercloud-multi-operator/controllers/util"
 
 	coreV1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
-	kerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/client-go/tools/record"
 
 	"sigs.k8s.io/cluster-api/util/patch"
 	ctrl "sigs.k8s.io/controller-runtime"
@@ -42,11 +44,19 @@ import (
 // ClusterReconciler reconciles a Memcached object
 type SecretReconciler struct {
 	client.Client
-	Log    logr.Logger
-	Scheme *runtime.Scheme
+	Log      logr.Logger
+	Scheme   *runtime.Scheme
+	Recorder record.EventRecorder
+	// member cluster 의 clientset 을 생성한다. nil 이면 util.DefaultRemoteClientFactory 를 사용한다.
+	RemoteClients util.RemoteClientFactory
+}
+
+func (r *SecretReconciler) remoteClients() util.RemoteClientFactory {
+	return util.RemoteClientFactoryOrDefault(r.RemoteClients)
 }
 
 // +kubebuilder:rbac:groups="",resources=secrets;namespaces;serviceaccounts,verbs=create;delete;get;list;patch;post;update;watch;
+// +kubebuilder:rbac:groups=cluster.tmax.io,resources=clusterroletemplates,verbs=get;list;watch
 
 func (r *SecretReconciler) Reconcile(ctx context.Context, req ctrl.Request) (_ ctrl.Result, reterr error) {
 	_ = context.Background()
@@ -68,6 +78,7 @@ func (r *SecretReconciler) Reconcile(ctx context.Context, req ctrl.Request) (_ c
 	secret := &coreV1.Secret{}
 	if err := r.Client.Get(context.TODO(), key, secret); errors.IsNotFound(err) {
 		log.Info("Secret resource not found. Ignoring since object must be deleted")
+		pipeline.Forget(secret, key)
 		return ctrl.Result{}, nil
 	} else if err != nil {
 		log.Error(err, "Failed to get secret")
@@ -114,36 +125,36 @@ func (r *SecretReconciler) Reconcile(ctx context.Context, req ctrl.Request) (_ c
 
 // reconcile handles cluster reconciliation.
 func (r *SecretReconciler) reconcile(ctx context.Context, secret *coreV1.Secret) (ctrl.Result, error) {
-	phases := []func(context.Context, *coreV1.Secret) (ctrl.Result, error){
+	// kubeconfig 는 여기서 한 번만 parse 하고 모든 phase 가 context 로 공유한다.
+	kubeconfig, err := util.ParseKubeconfig(secret.Data["value"])
+	if err != nil {
+		r.Log.WithValues("secret", client.ObjectKeyFromObject(secret)).
+			Error(err, "Failed to parse kubeconfig data from secret")
+		return ctrl.Result{}, err
+	}
+	ctx = util.WithKubeconfig(ctx, kubeconfig)
+
+	phases := []pipeline.Phase[*coreV1.Secret]{
 		// cluster manager 가 바라봐야 할 single cluster 의 api-server 를 설정해주는 작업을 진행한다.
 		// 해당 secret 으로 부터 kubeconfig data 를 가져와 kubeconfig 의 server 를 cluster manager 의 control plane endpoint 로 설정해준다.
-		r.UpdateClusterManagerControlPlaneEndpoint,
-		// single cluster 에 admin/developer/guest 에 따른 cluster role 을 생성하고,
+		{Name: "UpdateClusterManagerControlPlaneEndpoint", Run: r.UpdateClusterManagerControlPlaneEndpoint},
+		// single cluster 에 ClusterRoleTemplate 으로 정의된 cluster role 을 생성하고,
 		// cluster owner 에 대해 admin role 을 가지는 cluster rolebinding 을 생성한다.
-		r.DeployRBACResources,
+		{Name: "DeployRBACResources", Run: r.DeployRBACResources},
 		// single cluster 에 Argocd 연동을 위한 리소스 배포작업을 진행한다.
 		// Argocd 용 service account 를 생성하고,
 		// cluster role 과 cluster rolebinding 을 생성한다.
-		r.DeployArgocdResources,
-		// r.DeployOpensearchResources,
+		{Name: "DeployArgocdResources", Run: r.DeployArgocdResources},
+		// hypercloud5-system 의 image pull secret 을 single cluster 의 namespace 들에 복제한다.
+		{Name: "DeployImagePullSecrets", Run: r.DeployImagePullSecrets},
+		// DEFAULT_NETWORK_POLICY_NAMESPACES 의 namespace 들에 default-deny 와 platform-allow network policy 를 배포한다.
+		{Name: "DeployDefaultNetworkPolicies", Run: r.DeployDefaultNetworkPolicies},
+		// 상태 확인 controller 들이 사용할 읽기 전용 kubeconfig 를 생성한다.
+		{Name: "DeployLimitedKubeconfig", Run: r.DeployLimitedKubeconfig},
+		// {Name: "DeployOpensearchResources", Run: r.DeployOpensearchResources},
 	}
 
-	res := ctrl.Result{}
-	errs := []error{}
-	for _, phase := range phases {
-		// Call the inner reconciliation methods.
-		phaseResult, err := phase(ctx, secret)
-		if err != nil {
-			errs = append(errs, err)
-		}
-		if len(errs) > 0 {
-			continue
-		}
-
-		// Aggregate phases which requeued without err
-		res = util.LowestNonZeroResult(res, phaseResult)
-	}
-	return res, kerrors.NewAggregate(errs)
+	return pipeline.NewRunner[*coreV1.Secret](r.Log, r.Recorder).Run(ctx, secret, phases)
 }
 
 func (r *SecretReconciler) reconcileDelete(ctx context.Context, secret *coreV1.Secret) (reconcile.Result, error) {
@@ -169,45 +180,59 @@ func (r *SecretReconciler) reconcileDelete(ctx context.Context, secret *coreV1.S
 
 	// cluster registration의 경우, clr status를 update한다.
 	if clm.GetClusterType() == clusterV1alpha1.ClusterTypeRegistered {
-		key = types.NamespacedName{
-			Name:      clm.Labels[clusterV1alpha1.LabelKeyClrName],
-			Namespace: secret.Labels[clusterV1alpha1.LabelKeyClmNamespace],
-		}
-		clr := &clusterV1alpha1.ClusterRegistration{}
-		if err := r.Client.Get(context.TODO(), key, clr); err != nil {
-			log.Error(err, "Failed to get ClusterRegistration")
+		// 같은 cluster 이름으로 거절된 clusterRegistration 이 있을 수 있으므로, 검증된 clusterRegistration 만 update 한다.
+		clrs := &clusterV1alpha1.ClusterRegistrationList{}
+		if err := r.Client.List(context.TODO(), clrs,
+			client.InNamespace(clm.Namespace),
+			client.MatchingFields{util.IndexKeyClusterName: clm.Name},
+		); err != nil {
+			log.Error(err, "Failed to list ClusterRegistrations")
 			return ctrl.Result{}, err
 		}
 
-		helper, _ := patch.NewHelper(clr, r.Client)
-		defer func() {
+		for i := range clrs.Items {
+			clr := &clrs.Items[i]
+			if !clr.Status.ClusterValidated {
+				continue
+			}
+			helper, err := patch.NewHelper(clr, r.Client)
+			if err != nil {
+				return ctrl.Result{}, err
+			}
+			clr.Status.Reason = "kubeconfig secret is deleted"
+			clr.Status.ClusterValidated = false
+			clr.Status.Ready = false
 			if err := helper.Patch(context.TODO(), clr); err != nil {
-				r.Log.Error(err, "ClusterRegistration patch error")
+				log.Error(err, "ClusterRegistration patch error", "clusterRegistration", clr.Name)
+				return ctrl.Result{}, err
 			}
-		}()
-		clr.Status.Reason = "kubeconfig secret is deleted"
-		clr.Status.ClusterValidated = false
-		clr.Status.Ready = false
+		}
 	}
 
 	// 다른 secret을 처리 후, 이후부터는 kubeconfig secret에 대해서만 처리하도록 한다.
 	// capi가 생성한 kubeconfig secret이 들어오는 경우, single cluster에는 접근할 수 없다.
 	if secret.Labels[util.LabelKeyClmSecretType] == util.ClmSecretTypeArgo ||
-		secret.Labels[util.LabelKeyClmSecretType] == util.ClmSecretTypeSAToken {
+		secret.Labels[util.LabelKeyClmSecretType] == util.ClmSecretTypeSAToken ||
+		secret.Labels[util.LabelKeyClmSecretType] == util.ClmSecretTypeHyperAuth ||
+		secret.Labels[util.LabelKeyClmSecretType] == util.ClmSecretTypeAuthnWebhook ||
+		secret.Labels[util.LabelKeyClmSecretType] == util.ClmSecretTypeLimitedKubeconfig ||
+		secret.Labels[util.LabelKeyClmSecretType] == util.ClmSecretTypeRegistryConfig ||
+		secret.Labels[util.LabelKeyClmSecretType] == util.ClmSecretTypeAuditPolicy {
 		controllerutil.RemoveFinalizer(secret, clusterV1alpha1.ClusterManagerFinalizer)
 		return ctrl.Result{}, nil
 	}
 
 	// remote cluster 리소스 삭제
-	remoteClientset, err := util.GetRemoteK8sClient(secret)
-	if err != nil {
+	// member cluster 에 접근할 수 없어 삭제가 멈춘 경우, deadline 이 지나거나 force-delete annotation 이 있으면 remote 리소스 삭제를 건너뛴다.
+	remoteClientset, err := r.remoteClients().ClientsetForSecret(secret)
+	if err := util.IgnoreStuckFinalizerError(log, clm, "GetRemoteClient", err); err != nil {
 		log.Error(err, "Failed to get remoteK8sClient")
 		return ctrl.Result{}, err
 	}
 
 	adminSAName := GetAdminServiceAccountName(*clm)
 
-	if util.IsClusterHealthy(remoteClientset) {
+	if remoteClientset != nil && util.IsClusterHealthy(remoteClientset) {
 		saList := SADeleteList(adminSAName)
 
 		if DeleteSAList(remoteClientset, saList); errors.IsNotFound(err) {
@@ -230,7 +255,7 @@ func (r *SecretReconciler) reconcileDelete(ctx context.Context, secret *coreV1.S
 		}
 
 		memberList, err := FetchMemberList(*clm)
-		if err != nil {
+		if err := util.IgnoreStuckFinalizerError(log, clm, "FetchMemberList", err); err != nil {
 			return ctrl.Result{}, err
 		}
 
@@ -259,7 +284,7 @@ func (r *SecretReconciler) reconcileDelete(ctx context.Context, secret *coreV1.S
 	// master cluster에 있는 리소스 삭제
 
 	// db 에서 member 삭제
-	if err := util.Delete(clm.Namespace, clm.Name); err != nil {
+	if err := util.IgnoreStuckFinalizerError(log, clm, "DeleteClusterMember", util.DeleteOrQueue(context.TODO(), r.Client, clm.Namespace, clm.Name)); err != nil {
 		log.Error(err, "Failed to delete cluster info from cluster_member table")
 		return ctrl.Result{}, err
 	}
@@ -271,17 +296,17 @@ func (r *SecretReconciler) reconcileDelete(ctx context.Context, secret *coreV1.S
 	}
 	argoClusterSecret := &coreV1.Secret{}
 	if err := r.Client.Get(context.TODO(), key, argoClusterSecret); errors.IsNotFound(err) {
-		log.Info("Cannot find Secret for argocd external cluster [" + argoClusterSecret.Name + "]. Maybe already deleted")
+		log.Info("Cannot find Secret for argocd external cluster. Maybe already deleted", "secret", argoClusterSecret.Name)
 	} else if err != nil {
-		log.Error(err, "Failed to get Secret for argocd external cluster ["+argoClusterSecret.Name+"]")
+		log.Error(err, "Failed to get Secret for argocd external cluster", "secret", argoClusterSecret.Name)
 		return ctrl.Result{}, err
 	} else {
 		if !argoClusterSecret.DeletionTimestamp.IsZero() {
 			controllerutil.RemoveFinalizer(argoClusterSecret, clusterV1alpha1.ClusterManagerFinalizer)
-			log.Info("Deleted Secret for argocd external cluster [" + argoClusterSecret.Name + "] successfully")
+			log.Info("Deleted Secret for argocd external cluster successfully", "secret", argoClusterSecret.Name)
 			return ctrl.Result{Requeue: true}, nil
 		} else if err := r.Delete(context.TODO(), argoClusterSecret); err != nil {
-			log.Error(err, "Cannot delete Secret for argocd external cluster ["+argoClusterSecret.Name+"]")
+			log.Error(err, "Cannot delete Secret for argocd external cluster", "secret", argoClusterSecret.Name)
 			return ctrl.Result{}, err
 		}
 	}
@@ -293,40 +318,79 @@ func (r *SecretReconciler) reconcileDelete(ctx context.Context, secret *coreV1.S
 	}
 	saTokenSecret := &coreV1.Secret{}
 	if err := r.Client.Get(context.TODO(), key, saTokenSecret); errors.IsNotFound(err) {
-		log.Info("Cannot find Secret for ServiceAccount [" + saTokenSecret.Name + "]. Maybe already deleted")
+		log.Info("Cannot find Secret for ServiceAccount. Maybe already deleted", "secret", saTokenSecret.Name)
 	} else if err != nil {
-		log.Error(err, "Failed to get Secret for ServiceAccount ["+saTokenSecret.Name+"]")
+		log.Error(err, "Failed to get Secret for ServiceAccount", "secret", saTokenSecret.Name)
 		return ctrl.Result{}, err
 	} else {
 		if err := r.Delete(context.TODO(), saTokenSecret); err != nil {
-			log.Error(err, "Cannot delete Secret for ServiceAccount ["+saTokenSecret.Name+"]")
+			log.Error(err, "Cannot delete Secret for ServiceAccount", "secret", saTokenSecret.Name)
 			return ctrl.Result{}, err
 		}
-		log.Info("Deleted Secret for ServiceAccount [" + saTokenSecret.Name + "] successfully")
+		log.Info("Deleted Secret for ServiceAccount successfully", "secret", saTokenSecret.Name)
 	}
 
 	// kubeconfig finalizer 제거
-	key = types.NamespacedName{
-		Name:      clm.Name + util.KubeconfigSuffix,
-		Namespace: clm.Namespace,
-	}
-	kubeconfigSecret := &coreV1.Secret{}
-	if err := r.Client.Get(context.TODO(), key, kubeconfigSecret); errors.IsNotFound(err) {
-		log.Info("Cannot find secret for secret [" + kubeconfigSecret.Name + "]. Maybe already deleted")
+	kubeconfigSecret, err := util.GetKubeconfigSecret(context.TODO(), r.Client, clm.Namespace, clm.Name)
+	if errors.IsNotFound(err) {
+		log.Info("Cannot find secret for secret. Maybe already deleted", "clusterManager", clm.Name)
 	} else if err != nil {
-		log.Error(err, "Failed to get Secret for secret ["+kubeconfigSecret.Name+"]")
+		log.Error(err, "Failed to get Secret for secret", "clusterManager", clm.Name)
 		return ctrl.Result{}, err
 	} else {
 		controllerutil.RemoveFinalizer(secret, clusterV1alpha1.ClusterManagerFinalizer)
-		log.Info("Deleted Secret for secret [" + kubeconfigSecret.Name + "] successfully")
+		// kubeconfig secret 이 삭제되면 더이상 member cluster 에 접근하지 않으므로 생성해 둔 client 를 제거한다.
+		util.RemoveRemoteCluster(clm.Namespace, clm.Name)
+		log.Info("Deleted Secret for secret successfully", "secret", kubeconfigSecret.Name)
 	}
 
 	return ctrl.Result{}, nil
 }
 
+func (r *SecretReconciler) requeueClusterManagersForClusterRoleTemplate(o client.Object) []ctrl.Request {
+	log := r.Log.WithValues("Secret-ObjectMapper", "clusterRoleTemplateToClusterManagers", "ClusterRoleTemplate", o.GetName())
+	return r.requeueReadyClusterManagers(log)
+}
+
+func (r *SecretReconciler) requeueClusterManagersForImagePullSecret(o client.Object) []ctrl.Request {
+	log := r.Log.WithValues("Secret-ObjectMapper", "imagePullSecretToClusterManagers", "Secret", o.GetName())
+	return r.requeueReadyClusterManagers(log)
+}
+
+// cluster manager 이름으로 요청하면 kubeconfig secret 으로 변환되어 처리된다.
+func (r *SecretReconciler) requeueReadyClusterManagers(log logr.Logger) []ctrl.Request {
+	clmList := &clusterV1alpha1.ClusterManagerList{}
+	if err := r.Client.List(context.TODO(), clmList); err != nil {
+		log.Error(err, "Failed to list ClusterManager")
+		return nil
+	}
+
+	reqs := []ctrl.Request{}
+	for _, clm := range clmList.Items {
+		if !clm.Status.ControlPlaneReady || !clm.DeletionTimestamp.IsZero() {
+			continue
+		}
+		reqs = append(reqs, ctrl.Request{NamespacedName: clm.GetNamespacedName()})
+	}
+	return reqs
+}
+
+func isImagePullSecret(o client.Object) bool {
+	if o.GetNamespace() != util.HypercloudNamespace {
+		return false
+	}
+	for _, name := range GetImagePullSecretNames() {
+		if o.GetName() == name {
+			return true
+		}
+	}
+	return false
+}
+
 func (r *SecretReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	controller, err := ctrl.NewControllerManagedBy(mgr).
 		For(&coreV1.Secret{}).
+		WithOptions(util.DefaultControllerOptions()).
 		WithEventFilter(
 			predicate.Funcs{
 				CreateFunc: func(e event.CreateEvent) bool {
@@ -354,8 +418,56 @@ func (r *SecretReconciler) SetupWithManager(mgr ctrl.Manager) error {
 				},
 			},
 		).
-		Build(r)
+		Build(util.ShardReconciler(mgr.GetClient(), r))
+
+	if err != nil {
+		return err
+	}
 
+	// template 이 변경되면 모든 cluster 에 다시 적용한다.
+	err = controller.Watch(
+		&source.Kind{Type: &clusterV1alpha1.ClusterRoleTemplate{}},
+		handler.EnqueueRequestsFromMapFunc(r.requeueClusterManagersForClusterRoleTemplate),
+		predicate.Funcs{
+			UpdateFunc: func(e event.UpdateEvent) bool {
+				return e.ObjectOld.GetGeneration() != e.ObjectNew.GetGeneration()
+			},
+			CreateFunc: func(e event.CreateEvent) bool {
+				return true
+			},
+			DeleteFunc: func(e event.DeleteEvent) bool {
+				return true
+			},
+			GenericFunc: func(e event.GenericEvent) bool {
+				return false
+			},
+		},
+	)
+	if err != nil {
+		return err
+	}
+
+	// image pull secret 이 rotate 되면 모든 cluster 에 다시 배포한다.
+	err = controller.Watch(
+		&source.Kind{Type: &coreV1.Secret{}},
+		handler.EnqueueRequestsFromMapFunc(r.requeueClusterManagersForImagePullSecret),
+		predicate.Funcs{
+			UpdateFunc: func(e event.UpdateEvent) bool {
+				oldSecret := e.ObjectOld.(*coreV1.Secret)
+				newSecret := e.ObjectNew.(*coreV1.Secret)
+				return isImagePullSecret(newSecret) && !reflect.DeepEqual(oldSecret.Data, newSecret.Data)
+			},
+			CreateFunc: func(e event.CreateEvent) bool {
+				return isImagePullSecret(e.Object)
+			},
+			DeleteFunc: func(e event.DeleteEvent) bool {
+				return isImagePullSecret(e.Object)
+			},
+			GenericFunc: func(e event.GenericEvent) bool {
+				return false
+			},
+		},
+	)
 	if err != nil {
 		return err
 	}
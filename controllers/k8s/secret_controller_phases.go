@@ -18,6 +18,7 @@ import (
 	"context"
 	"regexp"
 	"strings"
+	"time"
 
 	clusterV1alpha1 "github.com/tmax-cloud/hypercloud-multi-operator/apis/cluster/v1alpha1"
 	"github.com/tmax-cloud/hypercloud-multi-operator/controllers/util"
@@ -27,10 +28,10 @@ import (
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
-	"k8s.io/client-go/tools/clientcmd"
 
 	"sigs.k8s.io/cluster-api/util/patch"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 )
 
 func (r *SecretReconciler) UpdateClusterManagerControlPlaneEndpoint(ctx context.Context, secret *coreV1.Secret) (ctrl.Result, error) {
@@ -41,14 +42,13 @@ func (r *SecretReconciler) UpdateClusterManagerControlPlaneEndpoint(ctx context.
 	log := r.Log.WithValues("secret", key)
 	log.Info("Start to reconcile phase for UpdateClusterManagerControlPlaneEndpoint... ")
 
-	kubeConfig, err := clientcmd.Load(secret.Data["value"])
+	kubeconfig, err := util.KubeconfigFrom(ctx)
 	if err != nil {
-		log.Error(err, "Failed to get kubeconfig data from secret")
 		return ctrl.Result{}, err
 	}
 
 	key = types.NamespacedName{
-		Name:      strings.Split(secret.Name, util.KubeconfigSuffix)[0],
+		Name:      util.KubeconfigClusterName(secret),
 		Namespace: secret.Namespace,
 	}
 	clm := &clusterV1alpha1.ClusterManager{}
@@ -57,10 +57,10 @@ func (r *SecretReconciler) UpdateClusterManagerControlPlaneEndpoint(ctx context.
 		log.Info("Cannot find clusterManager")
 		// return ctrl.Result{RequeueAfter: requeueAfter5Sec}, nil
 	} else if err != nil {
-		log.Error(err, "Failed to get clusterManager + ["+clm.Name+"]")
+		log.Error(err, "Failed to get clusterManager", "clusterManager", clm.Name)
 		return ctrl.Result{}, err
 	} else {
-		server := kubeConfig.Clusters[kubeConfig.Contexts[kubeConfig.CurrentContext].Cluster].Server
+		server := kubeconfig.Server
 		if !strings.EqualFold(clm.Status.ControlPlaneEndpoint, server) {
 			log.Info("Update clustermanager status. add ControlPlane endpoint")
 			helper, _ := patch.NewHelper(clm, r.Client)
@@ -76,6 +76,54 @@ func (r *SecretReconciler) UpdateClusterManagerControlPlaneEndpoint(ctx context.
 	return ctrl.Result{}, nil
 }
 
+// DeployDefaultNetworkPolicies 는 default-deny 와 platform-allow network policy 를 member cluster 에 배포한다.
+func (r *SecretReconciler) DeployDefaultNetworkPolicies(ctx context.Context, secret *coreV1.Secret) (ctrl.Result, error) {
+	log := r.Log.WithValues(
+		"secret",
+		types.NamespacedName{
+			Name:      secret.Name,
+			Namespace: secret.Namespace,
+		},
+	)
+	log.Info("Start to reconcile phase for DeployDefaultNetworkPolicies")
+
+	remoteClientset, err := r.remoteClients().ClientsetForSecret(secret)
+	if err != nil {
+		log.Error(err, "Failed to get remoteK8sClient")
+		return ctrl.Result{}, err
+	}
+
+	if err := r.applyDefaultNetworkPolicies(remoteClientset); err != nil {
+		log.Error(err, "Failed to apply default network policies to remote cluster")
+		return ctrl.Result{}, err
+	}
+	return ctrl.Result{}, nil
+}
+
+// DeployImagePullSecrets 는 platform 의 image pull secret 을 member cluster 에 배포한다.
+func (r *SecretReconciler) DeployImagePullSecrets(ctx context.Context, secret *coreV1.Secret) (ctrl.Result, error) {
+	log := r.Log.WithValues(
+		"secret",
+		types.NamespacedName{
+			Name:      secret.Name,
+			Namespace: secret.Namespace,
+		},
+	)
+	log.Info("Start to reconcile phase for DeployImagePullSecrets")
+
+	remoteClientset, err := r.remoteClients().ClientsetForSecret(secret)
+	if err != nil {
+		log.Error(err, "Failed to get remoteK8sClient")
+		return ctrl.Result{}, err
+	}
+
+	if err := r.applyImagePullSecrets(remoteClientset); err != nil {
+		log.Error(err, "Failed to apply image pull secrets to remote cluster")
+		return ctrl.Result{}, err
+	}
+	return ctrl.Result{}, nil
+}
+
 func (r *SecretReconciler) DeployRBACResources(ctx context.Context, secret *coreV1.Secret) (ctrl.Result, error) {
 	log := r.Log.WithValues(
 		"secret",
@@ -88,7 +136,7 @@ func (r *SecretReconciler) DeployRBACResources(ctx context.Context, secret *core
 
 	clm := &clusterV1alpha1.ClusterManager{}
 	key := types.NamespacedName{
-		Name:      strings.Split(secret.Name, util.KubeconfigSuffix)[0],
+		Name:      util.KubeconfigClusterName(secret),
 		Namespace: secret.Namespace,
 	}
 	if err := r.Client.Get(context.TODO(), key, clm); err != nil {
@@ -96,7 +144,7 @@ func (r *SecretReconciler) DeployRBACResources(ctx context.Context, secret *core
 		return ctrl.Result{}, err
 	}
 
-	remoteClientset, err := util.GetRemoteK8sClient(secret)
+	remoteClientset, err := r.remoteClients().ClientsetForSecret(secret)
 	if err != nil {
 		log.Error(err, "Failed to get remoteK8sClient")
 		return ctrl.Result{}, err
@@ -134,47 +182,28 @@ func (r *SecretReconciler) DeployRBACResources(ctx context.Context, secret *core
 			return ctrl.Result{}, err
 		}
 		log.Info("Create ClusterRoleBinding for cluster-admin to remote cluster successfully")
+
+		// secret 에 기록된 owner 와 다른 owner 에 대해 cluster-admin 을 부여한 경우, owner 가 변경된 것으로 기록한다.
+		owner := clm.Annotations[util.AnnotationKeyOwner]
+		prevOwner := secret.Annotations[util.AnnotationKeyOwner]
+		action := clusterV1alpha1.ClusterAuditActionRBACDeployed
+		message := "ClusterRoleBinding [" + clusterAdminCRB.Name + "] is created"
+		if prevOwner != "" && prevOwner != owner {
+			action = clusterV1alpha1.ClusterAuditActionOwnerChanged
+			message = "Owner is changed from [" + prevOwner + "] to [" + owner + "]"
+		}
+		if err := util.RecordAudit(r.Client, clm.Namespace, clm.Name, action, "", message); err != nil {
+			log.Error(err, "Failed to record audit for ClusterManager", "clusterManager", clm.Name)
+		}
 	} else if err != nil {
 		log.Error(err, "Failed to get ClusterRoleBinding for cluster-admin from remote cluster")
 		return ctrl.Result{}, err
 	}
 
-	targetGroup := []string{
-		"",
-		"apps",
-		"autoscaling",
-		"batch",
-		"extensions",
-		"policy",
-		"networking.k8s.io",
-		"snapshot.storage.k8s.io",
-		"storage.k8s.io",
-		"apiextensions.k8s.io",
-		"metrics.k8s.io",
-	}
-	crList := []*rbacv1.ClusterRole{
-		CreateClusterRole("developer", targetGroup, []string{rbacv1.VerbAll}),
-		CreateClusterRole("guest", targetGroup, []string{"get", "list", "watch"}),
-	}
-	for _, targetCr := range crList {
-		_, err := remoteClientset.
-			RbacV1().
-			ClusterRoles().
-			Get(context.TODO(), targetCr.Name, metav1.GetOptions{})
-		if errors.IsNotFound(err) {
-			_, err := remoteClientset.
-				RbacV1().
-				ClusterRoles().
-				Create(context.TODO(), targetCr, metav1.CreateOptions{})
-			if err != nil {
-				log.Error(err, "Cannot create ClusterRole ["+targetCr.Name+"] to remote cluster")
-				return ctrl.Result{}, err
-			}
-			log.Info("Create ClusterRole [" + targetCr.Name + "] to remote cluster successfully")
-		} else if err != nil {
-			log.Error(err, "Failed to get ClusterRole ["+targetCr.Name+"] from remote cluster")
-			return ctrl.Result{}, err
-		}
+	// ClusterRoleTemplate 으로 정의된 cluster role 을 생성하고, 삭제된 template 의 cluster role 은 삭제한다.
+	if err := r.applyClusterRoleTemplates(remoteClientset); err != nil {
+		log.Error(err, "Failed to apply ClusterRoleTemplates to remote cluster")
+		return ctrl.Result{}, err
 	}
 
 	re, _ := regexp.Compile("[" + regexp.QuoteMeta(`!#$%&'"*+-/=?^_{|}~().,:;<>[]\`) + "`\\s" + "]")
@@ -195,12 +224,12 @@ func (r *SecretReconciler) DeployRBACResources(ctx context.Context, secret *core
 			ServiceAccounts(util.KubeNamespace).
 			Create(context.TODO(), adminServiceAccount, metav1.CreateOptions{})
 		if err != nil {
-			log.Error(err, "Cannot create ServiceAccount ["+adminServiceAccount.Name+"] to remote cluster")
+			log.Error(err, "Cannot create ServiceAccount to remote cluster", "serviceAccount", adminServiceAccount.Name)
 			return ctrl.Result{}, err
 		}
-		log.Info("Create ServiceAccount [" + adminServiceAccount.Name + "] to remote cluster successfully")
+		log.Info("Create ServiceAccount to remote cluster successfully", "serviceAccount", adminServiceAccount.Name)
 	} else if err != nil {
-		log.Error(err, "Failed to get ServiceAccount ["+adminServiceAccount.Name+"] from remote cluster")
+		log.Error(err, "Failed to get ServiceAccount from remote cluster", "serviceAccount", adminServiceAccount.Name)
 		return ctrl.Result{}, err
 	}
 
@@ -223,12 +252,12 @@ func (r *SecretReconciler) DeployRBACResources(ctx context.Context, secret *core
 			Secrets(util.KubeNamespace).
 			Create(context.TODO(), adminServiceAccountTokenSecret, metav1.CreateOptions{})
 		if err != nil {
-			log.Error(err, "Cannot create ServiceAccount token secret ["+adminServiceAccount.Name+"] to remote cluster")
+			log.Error(err, "Cannot create ServiceAccount token secret to remote cluster", "secret", adminServiceAccount.Name)
 			return ctrl.Result{}, err
 		}
-		log.Info("Create ServiceAccount token secret [" + adminServiceAccount.Name + "] to remote cluster successfully")
+		log.Info("Create ServiceAccount token secret to remote cluster successfully", "secret", adminServiceAccount.Name)
 	} else if err != nil {
-		log.Error(err, "Failed to get ServiceAccount token secret ["+adminServiceAccount.Name+"] from remote cluster")
+		log.Error(err, "Failed to get ServiceAccount token secret from remote cluster", "secret", adminServiceAccount.Name)
 		return ctrl.Result{}, err
 	}
 
@@ -275,24 +304,40 @@ func (r *SecretReconciler) DeployArgocdResources(ctx context.Context, secret *co
 	log := r.Log.WithValues("secret", types.NamespacedName{Name: secret.Name, Namespace: secret.Namespace})
 	log.Info("Start to reconcile phase for Deploy argocd resources to remote")
 
-	kubeConfig, err := clientcmd.Load(secret.Data["value"])
+	kubeconfig, err := util.KubeconfigFrom(ctx)
 	if err != nil {
-		log.Error(err, "Failed to get secret")
 		return ctrl.Result{}, err
 	}
 
-	serverURI := kubeConfig.Clusters[kubeConfig.Contexts[kubeConfig.CurrentContext].Cluster].Server
+	serverURI := kubeconfig.Server
 	argoSecretName, err := util.URIToSecretName("cluster", serverURI)
 	if err != nil {
 		log.Error(err, "Failed to parse server uri")
 		return ctrl.Result{}, err
 	}
 
+	// 이전 버전에서 생성된 argocd cluster secret 이 남아있는 경우, 기존 이름을 그대로 사용한다.
 	if _, ok := secret.Annotations[util.AnnotationKeyArgoClusterSecret]; !ok {
+		legacySecretName, err := util.LegacyURIToSecretName("cluster", serverURI)
+		if err != nil {
+			log.Error(err, "Failed to parse server uri")
+			return ctrl.Result{}, err
+		}
+		key := types.NamespacedName{
+			Name:      legacySecretName,
+			Namespace: util.ArgoNamespace,
+		}
+		if err := r.Client.Get(context.TODO(), key, &coreV1.Secret{}); err == nil {
+			log.Info("Use legacy name for argocd cluster secret", "secret", legacySecretName)
+			argoSecretName = legacySecretName
+		} else if !errors.IsNotFound(err) {
+			log.Error(err, "Failed to get argocd cluster secret", "secret", legacySecretName)
+			return ctrl.Result{}, err
+		}
 		secret.Annotations[util.AnnotationKeyArgoClusterSecret] = argoSecretName
 	}
 
-	remoteClientset, err := util.GetRemoteK8sClient(secret)
+	remoteClientset, err := r.remoteClients().ClientsetForSecret(secret)
 	if err != nil {
 		log.Error(err, "Failed to get remoteK8sClient")
 		return ctrl.Result{}, err
@@ -313,12 +358,12 @@ func (r *SecretReconciler) DeployArgocdResources(ctx context.Context, secret *co
 			ServiceAccounts(util.KubeNamespace).
 			Create(context.TODO(), argocdManagerSA, metav1.CreateOptions{})
 		if err != nil {
-			log.Error(err, "Cannot create ServiceAccount for argocd ["+argocdManagerSA.Name+"] to remote cluster")
+			log.Error(err, "Cannot create ServiceAccount for argocd to remote cluster", "serviceAccount", argocdManagerSA.Name)
 			return ctrl.Result{}, err
 		}
-		log.Info("Create ServiceAccount for argocd [" + argocdManagerSA.Name + "] to remote cluster successfully")
+		log.Info("Create ServiceAccount for argocd to remote cluster successfully", "serviceAccount", argocdManagerSA.Name)
 	} else if err != nil {
-		log.Error(err, "Failed to get ServiceAccount for argocd ["+argocdManagerSA.Name+"] from remote cluster")
+		log.Error(err, "Failed to get ServiceAccount for argocd from remote cluster", "serviceAccount", argocdManagerSA.Name)
 		return ctrl.Result{}, err
 	}
 
@@ -343,12 +388,12 @@ func (r *SecretReconciler) DeployArgocdResources(ctx context.Context, secret *co
 			Secrets(util.KubeNamespace).
 			Create(context.TODO(), argocdManagerTokenSecret, metav1.CreateOptions{})
 		if err != nil {
-			log.Error(err, "Cannot create ServiceAccount token secret for argocd ["+argocdManagerTokenSecret.Name+"] to remote cluster")
+			log.Error(err, "Cannot create ServiceAccount token secret for argocd to remote cluster", "secret", argocdManagerTokenSecret.Name)
 			return ctrl.Result{}, err
 		}
-		log.Info("Create ServiceAccount token secret for argocd [" + argocdManagerTokenSecret.Name + "] to remote cluster successfully")
+		log.Info("Create ServiceAccount token secret for argocd to remote cluster successfully", "secret", argocdManagerTokenSecret.Name)
 	} else if err != nil {
-		log.Error(err, "Failed to get ServiceAccount token secret for argocd ["+argocdManagerTokenSecret.Name+"] from remote cluster")
+		log.Error(err, "Failed to get ServiceAccount token secret for argocd from remote cluster", "secret", argocdManagerTokenSecret.Name)
 		return ctrl.Result{}, err
 	}
 
@@ -378,12 +423,12 @@ func (r *SecretReconciler) DeployArgocdResources(ctx context.Context, secret *co
 			ClusterRoles().
 			Create(context.TODO(), argocdManagerRole, metav1.CreateOptions{})
 		if err != nil {
-			log.Error(err, "Cannot create ClusterRole for argocd ["+argocdManagerRole.Name+"] to remote cluster")
+			log.Error(err, "Cannot create ClusterRole for argocd to remote cluster", "clusterRole", argocdManagerRole.Name)
 			return ctrl.Result{}, err
 		}
-		log.Info("Create ClusterRole for argocd [" + argocdManagerRole.Name + "] to remote cluster successfully")
+		log.Info("Create ClusterRole for argocd to remote cluster successfully", "clusterRole", argocdManagerRole.Name)
 	} else if err != nil {
-		log.Error(err, "Failed to get ClusterRole for argocd ["+argocdManagerRole.Name+"] from remote cluster")
+		log.Error(err, "Failed to get ClusterRole for argocd from remote cluster", "clusterRole", argocdManagerRole.Name)
 		return ctrl.Result{}, err
 	}
 
@@ -414,14 +459,74 @@ func (r *SecretReconciler) DeployArgocdResources(ctx context.Context, secret *co
 			ClusterRoleBindings().
 			Create(context.TODO(), argocdManagerRoleBinding, metav1.CreateOptions{})
 		if err != nil {
-			log.Error(err, "Cannot create ClusterRoleBinding for argocd ["+argocdManagerRoleBinding.Name+"] to remote cluster")
+			log.Error(err, "Cannot create ClusterRoleBinding for argocd to remote cluster", "clusterRoleBinding", argocdManagerRoleBinding.Name)
 			return ctrl.Result{}, err
 		}
-		log.Info("Create ClusterRoleBinding for argocd [" + argocdManagerRoleBinding.Name + "] to remote cluster successfully")
+		log.Info("Create ClusterRoleBinding for argocd to remote cluster successfully", "clusterRoleBinding", argocdManagerRoleBinding.Name)
 	} else if err != nil {
-		log.Error(err, "Failed to get ClusterRoleBinding for argocd ["+argocdManagerRoleBinding.Name+"] from remote cluster")
+		log.Error(err, "Failed to get ClusterRoleBinding for argocd from remote cluster", "clusterRoleBinding", argocdManagerRoleBinding.Name)
 		return ctrl.Result{}, err
 	}
 
 	return ctrl.Result{}, nil
 }
+
+// DeployLimitedKubeconfig 는 member cluster 에 읽기 전용 service account 를 생성하고,
+// 그 token 으로 만든 limited kubeconfig 를 <cluster>-limited-kubeconfig secret 으로 저장한다.
+// admin kubeconfig 는 lifecycle 작업에만 사용하고, 주기적인 상태 확인은 limited kubeconfig 로 수행한다.
+func (r *SecretReconciler) DeployLimitedKubeconfig(ctx context.Context, secret *coreV1.Secret) (ctrl.Result, error) {
+	log := r.Log.WithValues("secret", types.NamespacedName{Name: secret.Name, Namespace: secret.Namespace})
+	log.Info("Start to reconcile phase for DeployLimitedKubeconfig")
+
+	kubeconfig, err := util.KubeconfigFrom(ctx)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	remoteClientset, err := r.remoteClients().ClientsetForSecret(secret)
+	if err != nil {
+		log.Error(err, "Failed to get remoteK8sClient")
+		return ctrl.Result{}, err
+	}
+
+	token, err := r.applyLimitedCredential(remoteClientset)
+	if err != nil {
+		log.Error(err, "Failed to create limited credential to remote cluster")
+		return ctrl.Result{}, err
+	}
+	if token == "" {
+		log.Info("Wait for token of limited service account to be issued")
+		return util.RequeueAfterWithJitter(10 * time.Second), nil
+	}
+
+	clusterName := util.KubeconfigClusterName(secret)
+	value, err := util.NewTokenKubeconfig(kubeconfig, clusterName, token)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	limitedSecret := &coreV1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      clusterName + util.LimitedKubeconfigSuffix,
+			Namespace: secret.Namespace,
+		},
+	}
+	result, err := controllerutil.CreateOrUpdate(context.TODO(), r.Client, limitedSecret, func() error {
+		if limitedSecret.Labels == nil {
+			limitedSecret.Labels = map[string]string{}
+		}
+		limitedSecret.Labels[util.LabelKeyClmSecretType] = util.ClmSecretTypeLimitedKubeconfig
+		limitedSecret.Labels[clusterV1alpha1.LabelKeyClmName] = clusterName
+		limitedSecret.Labels[clusterV1alpha1.LabelKeyClmNamespace] = secret.Namespace
+		limitedSecret.Data = map[string][]byte{"value": value}
+		// admin kubeconfig secret 이 삭제되면 함께 삭제된다.
+		return controllerutil.SetOwnerReference(secret, limitedSecret, r.Scheme)
+	})
+	if err != nil {
+		log.Error(err, "Failed to create limited kubeconfig secret")
+		return ctrl.Result{}, err
+	}
+	if result != controllerutil.OperationResultNone {
+		log.Info("Limited kubeconfig secret is "+string(result), "secret", limitedSecret.Name)
+	}
+	return ctrl.Result{}, nil
+}
@@ -17,6 +17,8 @@ package controllers
 import (
 	"context"
 	"encoding/json"
+	"os"
+	"reflect"
 	"regexp"
 	"strings"
 	"time"
@@ -24,6 +26,8 @@ import (
 	clusterV1alpha1 "github.com/tmax-cloud/hypercloud-multi-operator/apis/cluster/v1alpha1"
 
 	"github.com/tmax-cloud/hypercloud-multi-operator/controllers/util"
+	coreV1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
 	rbacv1 "k8s.io/api/rbac/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -31,28 +35,290 @@ import (
 	"k8s.io/client-go/kubernetes"
 )
 
-func CreateClusterRole(name string, targetGroup []string, verbList []string) *rbacv1.ClusterRole {
+// ClusterRoleTemplate 으로부터 remote cluster 에 생성할 cluster role 을 만든다.
+func RenderClusterRole(template *clusterV1alpha1.ClusterRoleTemplate) *rbacv1.ClusterRole {
 	clusterRole := &rbacv1.ClusterRole{
 		ObjectMeta: metav1.ObjectMeta{
-			Name: name,
-		},
-		Rules: []rbacv1.PolicyRule{
-			{
-				APIGroups: targetGroup,
-				Resources: []string{rbacv1.ResourceAll},
-				Verbs:     verbList,
-			},
-			{
-				APIGroups: []string{"apiregistration.k8s.io"},
-				Resources: []string{rbacv1.ResourceAll},
-				Verbs:     []string{"get", "list", "watch"},
+			Name: template.GetClusterRoleName(),
+			Labels: map[string]string{
+				clusterV1alpha1.LabelKeyClusterRoleTemplate: template.Name,
 			},
 		},
+		Rules: template.Spec.Rules,
 	}
 
 	return clusterRole
 }
 
+func (r *SecretReconciler) applyClusterRoleTemplates(remoteClientset kubernetes.Interface) error {
+	templateList := &clusterV1alpha1.ClusterRoleTemplateList{}
+	if err := r.Client.List(context.TODO(), templateList); err != nil {
+		return err
+	}
+
+	applied := map[string]bool{}
+	for _, template := range templateList.Items {
+		targetCr := RenderClusterRole(&template)
+		applied[targetCr.Name] = true
+
+		existCr, err := remoteClientset.
+			RbacV1().
+			ClusterRoles().
+			Get(context.TODO(), targetCr.Name, metav1.GetOptions{})
+		if errors.IsNotFound(err) {
+			_, err := remoteClientset.
+				RbacV1().
+				ClusterRoles().
+				Create(context.TODO(), targetCr, metav1.CreateOptions{})
+			if err != nil {
+				return err
+			}
+			r.Log.Info("Create ClusterRole to remote cluster successfully", "clusterRole", targetCr.Name)
+			continue
+		} else if err != nil {
+			return err
+		}
+
+		// template 이 변경된 경우에만 갱신한다.
+		if reflect.DeepEqual(existCr.Rules, targetCr.Rules) &&
+			existCr.Labels[clusterV1alpha1.LabelKeyClusterRoleTemplate] == template.Name {
+			continue
+		}
+		if existCr.Labels == nil {
+			existCr.Labels = map[string]string{}
+		}
+		existCr.Labels[clusterV1alpha1.LabelKeyClusterRoleTemplate] = template.Name
+		existCr.Rules = targetCr.Rules
+		if _, err := remoteClientset.
+			RbacV1().
+			ClusterRoles().
+			Update(context.TODO(), existCr, metav1.UpdateOptions{}); err != nil {
+			return err
+		}
+		r.Log.Info("Update ClusterRole to remote cluster successfully", "clusterRole", targetCr.Name)
+	}
+
+	// template 으로 생성했지만 template 이 삭제된 cluster role 을 삭제한다.
+	crList, err := remoteClientset.
+		RbacV1().
+		ClusterRoles().
+		List(context.TODO(), metav1.ListOptions{LabelSelector: clusterV1alpha1.LabelKeyClusterRoleTemplate})
+	if err != nil {
+		return err
+	}
+	for _, cr := range crList.Items {
+		if applied[cr.Name] {
+			continue
+		}
+		err := remoteClientset.
+			RbacV1().
+			ClusterRoles().
+			Delete(context.TODO(), cr.Name, metav1.DeleteOptions{})
+		if err != nil && !errors.IsNotFound(err) {
+			return err
+		}
+		r.Log.Info("Delete ClusterRole from remote cluster successfully", "clusterRole", cr.Name)
+	}
+
+	return nil
+}
+
+// 콤마로 구분된 환경 변수 값을 목록으로 반환한다.
+func getEnvList(env string) []string {
+	list := []string{}
+	for _, item := range strings.Split(os.Getenv(env), ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
+func GetImagePullSecretNames() []string {
+	return getEnvList(util.IMAGE_PULL_SECRETS)
+}
+
+func GetImagePullSecretNamespaces() []string {
+	namespaces := getEnvList(util.IMAGE_PULL_SECRET_NAMESPACES)
+	if len(namespaces) == 0 {
+		return []string{util.ImagePullSecretNamespaceDefault}
+	}
+	return namespaces
+}
+
+// hypercloud5-system 의 image pull secret 을 member cluster 의 namespace 들에 복제한다.
+// source secret 이 변경된 경우 갱신하고, 더 이상 배포 대상이 아닌 secret 은 삭제한다.
+func (r *SecretReconciler) applyImagePullSecrets(remoteClientset kubernetes.Interface) error {
+	names := GetImagePullSecretNames()
+	namespaces := GetImagePullSecretNamespaces()
+
+	applied := map[types.NamespacedName]bool{}
+	for _, name := range names {
+		source := &coreV1.Secret{}
+		key := types.NamespacedName{
+			Name:      name,
+			Namespace: util.HypercloudNamespace,
+		}
+		if err := r.Client.Get(context.TODO(), key, source); errors.IsNotFound(err) {
+			r.Log.Info("Cannot find image pull secret. Skip to deploy", "secret", name)
+			continue
+		} else if err != nil {
+			return err
+		}
+
+		for _, namespace := range namespaces {
+			applied[types.NamespacedName{Name: name, Namespace: namespace}] = true
+			if err := r.applyImagePullSecret(remoteClientset, source, namespace); err != nil {
+				return err
+			}
+		}
+	}
+
+	secretList, err := remoteClientset.
+		CoreV1().
+		Secrets("").
+		List(context.TODO(), metav1.ListOptions{LabelSelector: util.LabelKeyImagePullSecret})
+	if err != nil {
+		return err
+	}
+	for _, secret := range secretList.Items {
+		if applied[types.NamespacedName{Name: secret.Name, Namespace: secret.Namespace}] {
+			continue
+		}
+		err := remoteClientset.
+			CoreV1().
+			Secrets(secret.Namespace).
+			Delete(context.TODO(), secret.Name, metav1.DeleteOptions{})
+		if err != nil && !errors.IsNotFound(err) {
+			return err
+		}
+		r.Log.Info("Delete image pull secret from remote cluster successfully", "namespace", secret.Namespace, "secret", secret.Name)
+	}
+
+	return nil
+}
+
+func (r *SecretReconciler) applyImagePullSecret(remoteClientset kubernetes.Interface, source *coreV1.Secret, namespace string) error {
+	if err := util.EnsureRemoteNamespace(remoteClientset, namespace); err != nil {
+		return err
+	}
+
+	targetSecret := &coreV1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      source.Name,
+			Namespace: namespace,
+			Labels: map[string]string{
+				util.LabelKeyImagePullSecret: "true",
+			},
+		},
+		Type: source.Type,
+		Data: source.Data,
+	}
+
+	existSecret, err := remoteClientset.
+		CoreV1().
+		Secrets(namespace).
+		Get(context.TODO(), targetSecret.Name, metav1.GetOptions{})
+	if errors.IsNotFound(err) {
+		if _, err := remoteClientset.CoreV1().Secrets(namespace).Create(context.TODO(), targetSecret, metav1.CreateOptions{}); err != nil {
+			return err
+		}
+		r.Log.Info("Create image pull secret to remote cluster successfully", "namespace", namespace, "secret", targetSecret.Name)
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	// source secret 이 rotate 된 경우에만 갱신한다.
+	if reflect.DeepEqual(existSecret.Data, targetSecret.Data) &&
+		existSecret.Labels[util.LabelKeyImagePullSecret] == "true" {
+		return nil
+	}
+	if existSecret.Labels == nil {
+		existSecret.Labels = map[string]string{}
+	}
+	existSecret.Labels[util.LabelKeyImagePullSecret] = "true"
+	existSecret.Data = targetSecret.Data
+	if _, err := remoteClientset.CoreV1().Secrets(namespace).Update(context.TODO(), existSecret, metav1.UpdateOptions{}); err != nil {
+		return err
+	}
+	r.Log.Info("Update image pull secret to remote cluster successfully", "namespace", namespace, "secret", targetSecret.Name)
+	return nil
+}
+
+// member cluster 의 namespace 들에 default network policy 를 배포한다.
+// 더 이상 배포 대상이 아닌 namespace 의 default network policy 는 삭제한다.
+func (r *SecretReconciler) applyDefaultNetworkPolicies(remoteClientset kubernetes.Interface) error {
+	platformNamespaces := util.GetDefaultNetworkPolicyPlatformNamespaces()
+
+	applied := map[types.NamespacedName]bool{}
+	for _, namespace := range util.GetDefaultNetworkPolicyNamespaces() {
+		if err := util.EnsureRemoteNamespace(remoteClientset, namespace); err != nil {
+			return err
+		}
+		for _, policy := range util.DefaultNetworkPolicies(namespace, platformNamespaces, nil) {
+			applied[types.NamespacedName{Name: policy.Name, Namespace: namespace}] = true
+			if err := r.applyDefaultNetworkPolicy(remoteClientset, policy); err != nil {
+				return err
+			}
+		}
+	}
+
+	policyList, err := remoteClientset.
+		NetworkingV1().
+		NetworkPolicies("").
+		List(context.TODO(), metav1.ListOptions{LabelSelector: util.LabelKeyDefaultNetworkPolicy})
+	if err != nil {
+		return err
+	}
+	for _, policy := range policyList.Items {
+		if applied[types.NamespacedName{Name: policy.Name, Namespace: policy.Namespace}] {
+			continue
+		}
+		err := remoteClientset.
+			NetworkingV1().
+			NetworkPolicies(policy.Namespace).
+			Delete(context.TODO(), policy.Name, metav1.DeleteOptions{})
+		if err != nil && !errors.IsNotFound(err) {
+			return err
+		}
+		r.Log.Info("Delete default network policy from remote cluster successfully", "namespace", policy.Namespace, "networkpolicy", policy.Name)
+	}
+
+	return nil
+}
+
+func (r *SecretReconciler) applyDefaultNetworkPolicy(remoteClientset kubernetes.Interface, policy *networkingv1.NetworkPolicy) error {
+	existPolicy, err := remoteClientset.
+		NetworkingV1().
+		NetworkPolicies(policy.Namespace).
+		Get(context.TODO(), policy.Name, metav1.GetOptions{})
+	if errors.IsNotFound(err) {
+		if _, err := remoteClientset.NetworkingV1().NetworkPolicies(policy.Namespace).Create(context.TODO(), policy, metav1.CreateOptions{}); err != nil {
+			return err
+		}
+		r.Log.Info("Create default network policy to remote cluster successfully", "namespace", policy.Namespace, "networkpolicy", policy.Name)
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	// 사용자가 같은 이름으로 만든 network policy 는 덮어쓰지 않는다.
+	if existPolicy.Labels[util.LabelKeyDefaultNetworkPolicy] != "true" {
+		r.Log.Info("Network policy is not managed by operator. Skip to update", "namespace", policy.Namespace, "networkpolicy", policy.Name)
+		return nil
+	}
+	if reflect.DeepEqual(existPolicy.Spec, policy.Spec) {
+		return nil
+	}
+	existPolicy.Spec = policy.Spec
+	if _, err := remoteClientset.NetworkingV1().NetworkPolicies(policy.Namespace).Update(context.TODO(), existPolicy, metav1.UpdateOptions{}); err != nil {
+		return err
+	}
+	r.Log.Info("Update default network policy to remote cluster successfully", "namespace", policy.Namespace, "networkpolicy", policy.Name)
+	return nil
+}
+
 func SADeleteList(adminSAName string) []types.NamespacedName {
 	return []types.NamespacedName{
 		{
@@ -63,6 +329,10 @@ func SADeleteList(adminSAName string) []types.NamespacedName {
 			Name:      adminSAName,
 			Namespace: util.KubeNamespace,
 		},
+		{
+			Name:      util.LimitedServiceAccount,
+			Namespace: util.KubeNamespace,
+		},
 	}
 }
 
@@ -76,6 +346,10 @@ func SecretDeleteList(adminSAName string) []types.NamespacedName {
 			Name:      adminSAName + "-token",
 			Namespace: util.KubeNamespace,
 		},
+		{
+			Name:      util.LimitedServiceAccountTokenSecret,
+			Namespace: util.KubeNamespace,
+		},
 	}
 }
 
@@ -84,6 +358,7 @@ func CRBDeleteList(owner string, memberList []ClusterMemberInfo) []string {
 		"cluster-owner-crb-" + owner,
 		"cluster-owner-sa-crb-" + owner,
 		util.ArgoClusterRoleBinding,
+		util.LimitedClusterRoleBinding,
 	}
 	for _, member := range memberList {
 		if member.Status == "invited" && member.Attribute == "user" {
@@ -102,17 +377,18 @@ func CRDeleteList() []string {
 		"developer",
 		"guest",
 		util.ArgoClusterRole,
+		util.LimitedClusterRole,
 	}
 }
 
-func DeleteSAList(clientSet *kubernetes.Clientset, saList []types.NamespacedName) error {
+func DeleteSAList(clientSet kubernetes.Interface, saList []types.NamespacedName) error {
 	for _, targetSa := range saList {
 		_, err := clientSet.
 			CoreV1().
 			ServiceAccounts(targetSa.Namespace).
 			Get(context.TODO(), targetSa.Name, metav1.GetOptions{})
 		if errors.IsNotFound(err) {
-			return nil
+			continue
 		} else if err != nil {
 			return err
 		} else {
@@ -128,7 +404,7 @@ func DeleteSAList(clientSet *kubernetes.Clientset, saList []types.NamespacedName
 	return nil
 }
 
-func DeleteSecretList(clientSet *kubernetes.Clientset, secretList []types.NamespacedName) error {
+func DeleteSecretList(clientSet kubernetes.Interface, secretList []types.NamespacedName) error {
 	for _, targetSecret := range secretList {
 		_, err := clientSet.
 			CoreV1().
@@ -150,7 +426,7 @@ func DeleteSecretList(clientSet *kubernetes.Clientset, secretList []types.Namesp
 	return nil
 }
 
-func DeleteCRBList(clientSet *kubernetes.Clientset, crbList []string) error {
+func DeleteCRBList(clientSet kubernetes.Interface, crbList []string) error {
 	for _, targetCrb := range crbList {
 		_, err := clientSet.
 			RbacV1().
@@ -172,7 +448,7 @@ func DeleteCRBList(clientSet *kubernetes.Clientset, crbList []string) error {
 	return nil
 }
 
-func DeleteCRList(clientSet *kubernetes.Clientset, crList []string) error {
+func DeleteCRList(clientSet kubernetes.Interface, crList []string) error {
 	for _, targetCr := range crList {
 		_, err := clientSet.
 			RbacV1().
@@ -218,10 +494,89 @@ type ClusterMemberInfo struct {
 
 // db 로 부터 클러스터에 초대 된 member 들의 info 가져오기
 func FetchMemberList(clusterManager clusterV1alpha1.ClusterManager) ([]ClusterMemberInfo, error) {
-	jsonData, _ := util.List(clusterManager.Namespace, clusterManager.Name)
+	jsonData, err := util.List(clusterManager.Namespace, clusterManager.Name)
+	if err != nil {
+		return []ClusterMemberInfo{}, err
+	}
 	memberList := []ClusterMemberInfo{}
 	if err := json.Unmarshal(jsonData, &memberList); err != nil {
 		return []ClusterMemberInfo{}, err
 	}
 	return memberList, nil
 }
+
+// member cluster 에 limited kubeconfig 용 service account 와 읽기 전용 cluster role 을 생성하고,
+// service account 의 token 을 반환한다. token 이 아직 발급되지 않았으면 빈 문자열을 반환한다.
+func (r *SecretReconciler) applyLimitedCredential(remoteClientset kubernetes.Interface) (string, error) {
+	serviceAccount := &coreV1.ServiceAccount{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: util.LimitedServiceAccount,
+		},
+	}
+	if _, err := remoteClientset.CoreV1().ServiceAccounts(util.KubeNamespace).Create(context.TODO(), serviceAccount, metav1.CreateOptions{}); err != nil && !errors.IsAlreadyExists(err) {
+		return "", err
+	}
+
+	clusterRole := &rbacv1.ClusterRole{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: util.LimitedClusterRole,
+		},
+		Rules: util.LimitedClusterRoleRules(),
+	}
+	existRole, err := remoteClientset.RbacV1().ClusterRoles().Get(context.TODO(), clusterRole.Name, metav1.GetOptions{})
+	if errors.IsNotFound(err) {
+		if _, err := remoteClientset.RbacV1().ClusterRoles().Create(context.TODO(), clusterRole, metav1.CreateOptions{}); err != nil {
+			return "", err
+		}
+	} else if err != nil {
+		return "", err
+	} else if !reflect.DeepEqual(existRole.Rules, clusterRole.Rules) {
+		// operator 가 upgrade 되어 권한이 바뀐 경우 갱신한다.
+		existRole.Rules = clusterRole.Rules
+		if _, err := remoteClientset.RbacV1().ClusterRoles().Update(context.TODO(), existRole, metav1.UpdateOptions{}); err != nil {
+			return "", err
+		}
+	}
+
+	clusterRoleBinding := &rbacv1.ClusterRoleBinding{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: util.LimitedClusterRoleBinding,
+		},
+		RoleRef: rbacv1.RoleRef{
+			APIGroup: rbacv1.GroupName,
+			Kind:     "ClusterRole",
+			Name:     util.LimitedClusterRole,
+		},
+		Subjects: []rbacv1.Subject{
+			{
+				Kind:      rbacv1.ServiceAccountKind,
+				Name:      util.LimitedServiceAccount,
+				Namespace: util.KubeNamespace,
+			},
+		},
+	}
+	if _, err := remoteClientset.RbacV1().ClusterRoleBindings().Create(context.TODO(), clusterRoleBinding, metav1.CreateOptions{}); err != nil && !errors.IsAlreadyExists(err) {
+		return "", err
+	}
+
+	// argocd 와 같이 이름을 알 수 있도록 token secret 을 직접 생성한다.
+	tokenSecret := &coreV1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: util.LimitedServiceAccountTokenSecret,
+			Annotations: map[string]string{
+				coreV1.ServiceAccountNameKey: util.LimitedServiceAccount,
+			},
+		},
+		Type: coreV1.SecretTypeServiceAccountToken,
+	}
+	existSecret, err := remoteClientset.CoreV1().Secrets(util.KubeNamespace).Get(context.TODO(), tokenSecret.Name, metav1.GetOptions{})
+	if errors.IsNotFound(err) {
+		if _, err := remoteClientset.CoreV1().Secrets(util.KubeNamespace).Create(context.TODO(), tokenSecret, metav1.CreateOptions{}); err != nil {
+			return "", err
+		}
+		return "", nil
+	} else if err != nil {
+		return "", err
+	}
+	return string(existSecret.Data[coreV1.ServiceAccountTokenKey]), nil
+}
@@ -0,0 +1,607 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// metrics 패키지는 operator 의 custom metric 을 정의한다.
+// 모든 metric 은 controller-runtime 의 registry 에 등록되어,
+// controller-runtime 기본 metric (controller_runtime_reconcile_total 등) 과 함께 manager 의 metrics endpoint (/metrics) 로 노출된다.
+//
+// 제공하는 metric 목록
+//   - hypercloud_reconcile_total{kind, result}
+//     kind 별 reconcile 수행 횟수. result 는 success, error 중 하나이다.
+//   - hypercloud_reconcile_failing_objects{kind}
+//     마지막 reconcile 이 실패한 object 의 수
+//   - hypercloud_operator_degraded
+//     마지막 reconcile 이 실패한 object 의 비율이 degradedFailureRatio 이상인 kind 가 있으면 1
+//   - hypercloud_reconcile_phase_total{kind, phase, result}
+//     reconcile phase 의 수행 횟수. result 는 success, error, requeue 중 하나이다.
+//   - hypercloud_phase_transitions_total{kind, from, to}
+//     status.phase 가 변경된 횟수
+//   - hypercloud_remote_request_duration_seconds{host, method, code}
+//     remote cluster 의 api server 에 대한 요청의 latency. 응답을 받지 못한 경우 code 는 "error" 이다.
+//   - hypercloud_db_write_failures_total{operation}
+//     hypercloud api server 를 통한 db 쓰기 실패 횟수
+//   - hypercloud_db_circuit_open
+//     db 요청의 circuit breaker 가 열려 db 요청을 보내지 않고 있으면 1
+//   - hypercloud_webhook_rejections_total{kind, operation}
+//     validating webhook 에서 거절한 요청의 수
+//   - hypercloud_claim_reviews_total{kind, decision}
+//     claim 이 승인, 거절된 횟수
+//   - hypercloud_cluster_seconds_since_heartbeat{namespace, name}
+//     cluster 의 api server 가 마지막으로 health check 에 응답한 이후 지난 시간
+//   - hypercloud_webhook_cert_expiry_timestamp_seconds
+//     webhook serving cert 의 만료 시각 (unix time)
+//   - hypercloud_remote_cluster_clients
+//     재사용하기 위해 생성해 둔 member cluster client 의 수
+//   - hypercloud_cluster_cost_month_to_date{namespace, name, team}
+//     cluster 의 이번 달 누적 비용
+//   - hypercloud_cluster_cost_hourly_rate{namespace, name, team}
+//     cluster 에서 실행 중인 node 의 시간당 가격의 합
+//   - hypercloud_team_cost_month_to_date{team}, hypercloud_namespace_cost_month_to_date{namespace}
+//     team, namespace 별로 합산한 이번 달 누적 비용
+//   - hypercloud_cluster_deprecated_apis{namespace, name}
+//     cluster 에서 사용 중인 다음 minor version 에서 제거되는 api 의 수
+//   - hypercloud_remote_gc_deleted_total{kind}
+//     remote garbage collector 가 member cluster 에서 삭제한 object 의 수. kind 는 object 를 생성한 owner 의 종류이다.
+//   - hypercloud_cluster_certificate_expiry_timestamp_seconds{namespace, name, type, certificate}
+//     cluster 의 인증서의 만료 시각 (unix time). certificate 는 인증서를 읽은 주소 또는 secret 이다.
+//   - hypercloud_cluster_addon_healthy{namespace, name, addon}
+//     cluster 에 설치된 addon 이 정상이면 1, 아니면 0
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+const (
+	namespace = "hypercloud"
+
+	PhaseResultSuccess = "success"
+	PhaseResultError   = "error"
+	PhaseResultRequeue = "requeue"
+	PhaseResultSkipped = "skipped"
+)
+
+var (
+	ReconcileTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "reconcile_total",
+			Help:      "Total number of reconciles per kind and result",
+		},
+		[]string{"kind", "result"},
+	)
+
+	ReconcileHealth = newReconcileHealthCollector()
+
+	ReconcilePhaseTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "reconcile_phase_total",
+			Help:      "Total number of reconcile phase runs per kind, phase and result",
+		},
+		[]string{"kind", "phase", "result"},
+	)
+
+	ReconcilePhaseDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "reconcile_phase_duration_seconds",
+			Help:      "Duration of reconcile phase runs per kind and phase",
+			Buckets:   []float64{0.005, 0.01, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30},
+		},
+		[]string{"kind", "phase"},
+	)
+
+	PhaseTransitionsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "phase_transitions_total",
+			Help:      "Total number of status phase transitions per kind",
+		},
+		[]string{"kind", "from", "to"},
+	)
+
+	RemoteRequestDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "remote_request_duration_seconds",
+			Help:      "Latency of requests to the api server of member clusters",
+			Buckets:   []float64{0.01, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30},
+		},
+		[]string{"host", "method", "code"},
+	)
+
+	DBWriteFailuresTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "db_write_failures_total",
+			Help:      "Total number of failed db writes through the hypercloud api server",
+		},
+		[]string{"operation"},
+	)
+
+	ClusterEventsDroppedTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "cluster_events_dropped_total",
+			Help:      "Total number of cluster lifecycle events not delivered to the hypercloud api server",
+		},
+		[]string{"reason"},
+	)
+
+	WebhookRejectionsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "webhook_rejections_total",
+			Help:      "Total number of requests rejected by validating webhooks",
+		},
+		[]string{"kind", "operation"},
+	)
+
+	ClaimReviewsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "claim_reviews_total",
+			Help:      "Total number of approved or rejected claims per kind",
+		},
+		[]string{"kind", "decision"},
+	)
+
+	WebhookCertExpiry = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "webhook_cert_expiry_timestamp_seconds",
+			Help:      "Expiry time of the webhook serving certificate in unix seconds",
+		},
+	)
+
+	ClusterHeartbeat = newHeartbeatCollector()
+
+	DBCircuitOpen = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "db_circuit_open",
+			Help:      "Whether the circuit breaker of db requests is open",
+		},
+	)
+
+	DBAvailable = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "db_available",
+			Help:      "Whether the db is reachable through the hypercloud api server at the last health check",
+		},
+	)
+
+	RemoteClusterClients = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "remote_cluster_clients",
+			Help:      "Number of member clusters which have cached clients",
+		},
+	)
+
+	ClusterCost = newCostCollector()
+
+	ClusterDeprecatedAPIs = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "cluster_deprecated_apis",
+			Help:      "Number of apis removed in the next minor version and still used in the cluster",
+		},
+		[]string{"namespace", "name"},
+	)
+
+	RemoteGCDeletedTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "remote_gc_deleted_total",
+			Help:      "Total number of objects deleted from member clusters by the remote garbage collector per owner kind",
+		},
+		[]string{"kind"},
+	)
+
+	ClusterCertificateExpiry = newCertificateCollector()
+
+	ClusterAddonHealthy = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "cluster_addon_healthy",
+			Help:      "Whether the addon installed in the cluster is healthy (1) or not (0)",
+		},
+		[]string{"namespace", "name", "addon"},
+	)
+)
+
+func init() {
+	metrics.Registry.MustRegister(
+		ReconcileTotal,
+		ReconcileHealth,
+		ReconcilePhaseTotal,
+		ReconcilePhaseDuration,
+		PhaseTransitionsTotal,
+		RemoteRequestDuration,
+		DBWriteFailuresTotal,
+		DBCircuitOpen,
+		DBAvailable,
+		ClusterEventsDroppedTotal,
+		WebhookRejectionsTotal,
+		ClaimReviewsTotal,
+		WebhookCertExpiry,
+		ClusterHeartbeat,
+		RemoteClusterClients,
+		ClusterCost,
+		ClusterDeprecatedAPIs,
+		RemoteGCDeletedTotal,
+		ClusterCertificateExpiry,
+		ClusterAddonHealthy,
+	)
+}
+
+// reconcile 결과를 기록한다. err 가 nil 이 아니면 실패로 기록한다.
+func RecordReconcile(kind, namespace, name string, err error) {
+	result := PhaseResultSuccess
+	if err != nil {
+		result = PhaseResultError
+	}
+	ReconcileTotal.WithLabelValues(kind, result).Inc()
+	ReconcileHealth.Set(kind, namespace+"/"+name, err != nil)
+}
+
+func ForgetReconcile(kind, namespace, name string) {
+	ReconcileHealth.Delete(kind, namespace+"/"+name)
+}
+
+func RecordPhase(kind, phase, result string) {
+	ReconcilePhaseTotal.WithLabelValues(kind, phase, result).Inc()
+}
+
+func RecordPhaseDuration(kind, phase string, duration time.Duration) {
+	ReconcilePhaseDuration.WithLabelValues(kind, phase).Observe(duration.Seconds())
+}
+
+// from 과 to 가 같으면 기록하지 않는다.
+func RecordPhaseTransition(kind, from, to string) {
+	if from == to {
+		return
+	}
+	PhaseTransitionsTotal.WithLabelValues(kind, from, to).Inc()
+}
+
+func RecordDBWriteFailure(operation string) {
+	DBWriteFailuresTotal.WithLabelValues(operation).Inc()
+}
+
+// err 가 nil 이 아닌 경우에만 기록하고, err 를 그대로 반환한다.
+func RecordWebhookRejection(kind, operation string, err error) error {
+	if err != nil {
+		WebhookRejectionsTotal.WithLabelValues(kind, operation).Inc()
+	}
+	return err
+}
+
+func RecordClaimReview(kind, decision string) {
+	ClaimReviewsTotal.WithLabelValues(kind, decision).Inc()
+}
+
+// remote cluster 에 대한 요청의 latency 를 기록하는 RoundTripper
+// rest.Config 의 WrapTransport 로 사용한다.
+func InstrumentRoundTripper(rt http.RoundTripper) http.RoundTripper {
+	return roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		start := time.Now()
+		resp, err := rt.RoundTrip(req)
+		code := "error"
+		if err == nil {
+			code = strconv.Itoa(resp.StatusCode)
+		}
+		RemoteRequestDuration.WithLabelValues(req.URL.Host, req.Method, code).Observe(time.Since(start).Seconds())
+		return resp, err
+	})
+}
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// heartbeatCollector 는 scrape 시점을 기준으로 마지막 heartbeat 이후 지난 시간을 계산한다.
+// reconcile 주기와 관계없이 정확한 값을 노출하기 위해 gauge 대신 사용한다.
+type heartbeatCollector struct {
+	desc  *prometheus.Desc
+	mutex sync.RWMutex
+	last  map[[2]string]time.Time
+}
+
+func newHeartbeatCollector() *heartbeatCollector {
+	return &heartbeatCollector{
+		desc: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "", "cluster_seconds_since_heartbeat"),
+			"Seconds since the api server of the cluster last responded to the health check",
+			[]string{"namespace", "name"},
+			nil,
+		),
+		last: map[[2]string]time.Time{},
+	}
+}
+
+func (c *heartbeatCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.desc
+}
+
+func (c *heartbeatCollector) Collect(ch chan<- prometheus.Metric) {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	now := time.Now()
+	for key, t := range c.last {
+		ch <- prometheus.MustNewConstMetric(c.desc, prometheus.GaugeValue, now.Sub(t).Seconds(), key[0], key[1])
+	}
+}
+
+func (c *heartbeatCollector) Set(namespace, name string, t time.Time) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.last[[2]string{namespace, name}] = t
+}
+
+func (c *heartbeatCollector) Delete(namespace, name string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	delete(c.last, [2]string{namespace, name})
+}
+
+type costEntry struct {
+	team        string
+	monthToDate float64
+	hourlyRate  float64
+}
+
+// costCollector 는 cluster 별 비용과 함께 team, namespace 별로 합산한 비용을 scrape 시점에 계산한다.
+// cluster 의 team 이 바뀌거나 cluster 가 삭제되어도 합계에 남지 않도록 gauge 대신 사용한다.
+type costCollector struct {
+	clusterDesc   *prometheus.Desc
+	rateDesc      *prometheus.Desc
+	teamDesc      *prometheus.Desc
+	namespaceDesc *prometheus.Desc
+	mutex         sync.RWMutex
+	entries       map[[2]string]costEntry
+}
+
+func newCostCollector() *costCollector {
+	return &costCollector{
+		clusterDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "cluster", "cost_month_to_date"),
+			"Accumulated cost of the cluster in the current month",
+			[]string{"namespace", "name", "team"},
+			nil,
+		),
+		rateDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "cluster", "cost_hourly_rate"),
+			"Sum of the hourly price of the running nodes of the cluster",
+			[]string{"namespace", "name", "team"},
+			nil,
+		),
+		teamDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "team", "cost_month_to_date"),
+			"Accumulated cost of the clusters of the team in the current month",
+			[]string{"team"},
+			nil,
+		),
+		namespaceDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "namespace", "cost_month_to_date"),
+			"Accumulated cost of the clusters in the namespace in the current month",
+			[]string{"namespace"},
+			nil,
+		),
+		entries: map[[2]string]costEntry{},
+	}
+}
+
+func (c *costCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.clusterDesc
+	ch <- c.rateDesc
+	ch <- c.teamDesc
+	ch <- c.namespaceDesc
+}
+
+func (c *costCollector) Collect(ch chan<- prometheus.Metric) {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	teams := map[string]float64{}
+	namespaces := map[string]float64{}
+	for key, entry := range c.entries {
+		ch <- prometheus.MustNewConstMetric(c.clusterDesc, prometheus.GaugeValue, entry.monthToDate, key[0], key[1], entry.team)
+		ch <- prometheus.MustNewConstMetric(c.rateDesc, prometheus.GaugeValue, entry.hourlyRate, key[0], key[1], entry.team)
+		teams[entry.team] += entry.monthToDate
+		namespaces[key[0]] += entry.monthToDate
+	}
+	for team, cost := range teams {
+		ch <- prometheus.MustNewConstMetric(c.teamDesc, prometheus.GaugeValue, cost, team)
+	}
+	for ns, cost := range namespaces {
+		ch <- prometheus.MustNewConstMetric(c.namespaceDesc, prometheus.GaugeValue, cost, ns)
+	}
+}
+
+// team annotation 이 없는 cluster 는 team 이 빈 문자열로 집계된다.
+func (c *costCollector) Set(namespace, name, team string, monthToDate, hourlyRate float64) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.entries[[2]string{namespace, name}] = costEntry{
+		team:        team,
+		monthToDate: monthToDate,
+		hourlyRate:  hourlyRate,
+	}
+}
+
+func (c *costCollector) Delete(namespace, name string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	delete(c.entries, [2]string{namespace, name})
+}
+
+// CertificateExpiry 는 cluster 의 인증서 하나의 만료 시각이다.
+type CertificateExpiry struct {
+	Type        string
+	Certificate string
+	NotAfter    time.Time
+}
+
+// certificateCollector 는 cluster 별로 마지막 scan 에서 찾은 인증서만 노출한다.
+// 인증서가 교체되거나 ingress 가 삭제되어도 이전 series 가 남지 않도록 gauge 대신 사용한다.
+type certificateCollector struct {
+	desc    *prometheus.Desc
+	mutex   sync.RWMutex
+	entries map[[2]string][]CertificateExpiry
+}
+
+func newCertificateCollector() *certificateCollector {
+	return &certificateCollector{
+		desc: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "cluster", "certificate_expiry_timestamp_seconds"),
+			"Expiry time of the TLS certificates of the cluster in unix seconds",
+			[]string{"namespace", "name", "type", "certificate"},
+			nil,
+		),
+		entries: map[[2]string][]CertificateExpiry{},
+	}
+}
+
+func (c *certificateCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.desc
+}
+
+func (c *certificateCollector) Collect(ch chan<- prometheus.Metric) {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	for key, certs := range c.entries {
+		for _, cert := range certs {
+			ch <- prometheus.MustNewConstMetric(c.desc, prometheus.GaugeValue, float64(cert.NotAfter.Unix()), key[0], key[1], cert.Type, cert.Certificate)
+		}
+	}
+}
+
+// cluster 의 인증서 목록을 교체한다.
+func (c *certificateCollector) Set(namespace, name string, certs []CertificateExpiry) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.entries[[2]string{namespace, name}] = certs
+}
+
+func (c *certificateCollector) Delete(namespace, name string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	delete(c.entries, [2]string{namespace, name})
+}
+
+const (
+	// kind 별로 마지막 reconcile 이 실패한 object 의 비율이 이 값 이상이면 degraded 로 판단한다.
+	degradedFailureRatio = 0.5
+	// 삭제된 object 는 controller 가 ForgetReconcile 로 제외한다.
+	// 그래도 남아있는 실패한 object 는 backoff 에 따라 계속 requeue 되므로,
+	// 이 시간 동안 다시 reconcile 되지 않았다면 삭제된 것으로 보고 집계에서 제외한다.
+	// 성공한 object 는 resync 없이 오래 reconcile 되지 않을 수 있으므로 시간으로 제외하지 않는다.
+	failingObjectTTL = 30 * time.Minute
+)
+
+type reconcileHealthEntry struct {
+	failed  bool
+	updated time.Time
+}
+
+// reconcileHealthCollector 는 object 별 마지막 reconcile 결과를 가지고
+// 실패한 object 의 수와 operator 의 degraded 여부를 scrape 시점에 계산한다.
+type reconcileHealthCollector struct {
+	failingDesc  *prometheus.Desc
+	degradedDesc *prometheus.Desc
+	mutex        sync.Mutex
+	entries      map[string]map[string]reconcileHealthEntry
+}
+
+func newReconcileHealthCollector() *reconcileHealthCollector {
+	return &reconcileHealthCollector{
+		failingDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "", "reconcile_failing_objects"),
+			"Number of objects whose last reconcile failed per kind",
+			[]string{"kind"},
+			nil,
+		),
+		degradedDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "operator", "degraded"),
+			"1 if the last reconcile failed for too many objects of any kind",
+			nil,
+			nil,
+		),
+		entries: map[string]map[string]reconcileHealthEntry{},
+	}
+}
+
+func (c *reconcileHealthCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.failingDesc
+	ch <- c.degradedDesc
+}
+
+func (c *reconcileHealthCollector) Collect(ch chan<- prometheus.Metric) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	now := time.Now()
+	degraded := 0.0
+	for kind, objects := range c.entries {
+		failing := 0
+		for key, entry := range objects {
+			if !entry.failed {
+				continue
+			}
+			if now.Sub(entry.updated) > failingObjectTTL {
+				delete(objects, key)
+				continue
+			}
+			failing++
+		}
+		if len(objects) == 0 {
+			delete(c.entries, kind)
+			continue
+		}
+		if float64(failing)/float64(len(objects)) >= degradedFailureRatio {
+			degraded = 1
+		}
+		ch <- prometheus.MustNewConstMetric(c.failingDesc, prometheus.GaugeValue, float64(failing), kind)
+	}
+	ch <- prometheus.MustNewConstMetric(c.degradedDesc, prometheus.GaugeValue, degraded)
+}
+
+// 삭제가 완료된 object 를 집계에서 제외한다.
+func (c *reconcileHealthCollector) Delete(kind, key string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	delete(c.entries[kind], key)
+}
+
+func (c *reconcileHealthCollector) Set(kind, key string, failed bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if _, ok := c.entries[kind]; !ok {
+		c.entries[kind] = map[string]reconcileHealthEntry{}
+	}
+	c.entries[kind][key] = reconcileHealthEntry{
+		failed:  failed,
+		updated: time.Now(),
+	}
+}
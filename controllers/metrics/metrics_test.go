@@ -0,0 +1,138 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metrics
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// collector 가 내보낸 kind 별 실패 object 수와 degraded 값을 반환한다.
+func collectReconcileHealth(t *testing.T, c *reconcileHealthCollector) (map[string]float64, float64) {
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(c)
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	failing := map[string]float64{}
+	degraded := -1.0
+	for _, family := range families {
+		for _, m := range family.Metric {
+			if len(m.Label) == 0 {
+				degraded = m.Gauge.GetValue()
+				continue
+			}
+			failing[m.Label[0].GetValue()] = m.Gauge.GetValue()
+		}
+	}
+	return failing, degraded
+}
+
+func TestReconcileHealthCollector(t *testing.T) {
+	expired := time.Now().Add(-failingObjectTTL - time.Minute)
+
+	tests := []struct {
+		name    string
+		entries map[string]reconcileHealthEntry
+		// 집계에서 제외할 object
+		forget       []string
+		wantFailing  map[string]float64
+		wantDegraded float64
+	}{
+		{
+			name: "failing below ratio",
+			entries: map[string]reconcileHealthEntry{
+				"a/1": {failed: true, updated: time.Now()},
+				"a/2": {updated: time.Now()},
+				"a/3": {updated: time.Now()},
+			},
+			wantFailing: map[string]float64{"Kind": 1},
+		},
+		{
+			name: "degraded",
+			entries: map[string]reconcileHealthEntry{
+				"a/1": {failed: true, updated: time.Now()},
+				"a/2": {updated: time.Now()},
+			},
+			wantFailing:  map[string]float64{"Kind": 1},
+			wantDegraded: 1,
+		},
+		{
+			// 삭제된 object 가 집계에 남아 실패율을 낮추지 않도록 제외한다.
+			name: "forget deleted objects",
+			entries: map[string]reconcileHealthEntry{
+				"a/1": {failed: true, updated: time.Now()},
+				"a/2": {updated: time.Now()},
+				"a/3": {updated: time.Now()},
+			},
+			forget:       []string{"a/3"},
+			wantFailing:  map[string]float64{"Kind": 1},
+			wantDegraded: 1,
+		},
+		{
+			name: "expire failing object",
+			entries: map[string]reconcileHealthEntry{
+				"a/1": {failed: true, updated: expired},
+				"a/2": {updated: time.Now()},
+			},
+			wantFailing: map[string]float64{"Kind": 0},
+		},
+		{
+			// 성공한 object 는 오래 reconcile 되지 않아도 제외하지 않는다.
+			name: "keep idle healthy object",
+			entries: map[string]reconcileHealthEntry{
+				"a/1": {failed: true, updated: time.Now()},
+				"a/2": {updated: expired},
+				"a/3": {updated: expired},
+			},
+			wantFailing: map[string]float64{"Kind": 1},
+		},
+		{
+			name: "drop kind without objects",
+			entries: map[string]reconcileHealthEntry{
+				"a/1": {updated: time.Now()},
+			},
+			forget:      []string{"a/1"},
+			wantFailing: map[string]float64{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newReconcileHealthCollector()
+			c.entries["Kind"] = tt.entries
+			for _, key := range tt.forget {
+				c.Delete("Kind", key)
+			}
+
+			failing, degraded := collectReconcileHealth(t, c)
+			if len(failing) != len(tt.wantFailing) {
+				t.Fatalf("failing = %v, want %v", failing, tt.wantFailing)
+			}
+			for kind, want := range tt.wantFailing {
+				if failing[kind] != want {
+					t.Errorf("failing[%s] = %v, want %v", kind, failing[kind], want)
+				}
+			}
+			if degraded != tt.wantDegraded {
+				t.Errorf("degraded = %v, want %v", degraded, tt.wantDegraded)
+			}
+		})
+	}
+}
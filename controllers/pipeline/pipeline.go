@@ -0,0 +1,289 @@
+// pipeline 은 reconcile 을 phase 단위로 나누어 수행하고,
+// phase 별 metric, event, condition 기록과 skip, 순서 지정을 공통으로 처리한다.
+package pipeline
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/tmax-cloud/hypercloud-multi-operator/controllers/metrics"
+	"github.com/tmax-cloud/hypercloud-multi-operator/controllers/util"
+	coreV1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// last-reconcile-error annotation 에 기록할 error message 의 최대 길이
+const maxLastReconcileErrorLength = 1024
+
+// ConditionType 이 지정된 phase 의 condition reason
+const (
+	ReasonSucceeded  = "Succeeded"
+	ReasonInProgress = "InProgress"
+	ReasonFailed     = "Failed"
+)
+
+// phase 에서 error 가 발생했을 때의 requeue 정책
+type RequeuePolicy struct {
+	// 재시도 불가능한 error (IsRetryable 이 false) 가 발생한 경우 다시 확인할 간격
+	// 0 이면 error 를 그대로 반환하여 controller-runtime 의 exponential backoff 를 따른다.
+	NonRetryableAfter time.Duration
+}
+
+// Phase는 reconcile 과정의 한 단계를 나타낸다.
+type Phase[T client.Object] struct {
+	// event, log, metric 에 사용되는 phase 의 이름
+	Name string
+	// phase 에서 수행할 함수
+	Run func(context.Context, T) (ctrl.Result, error)
+	// error 발생 시의 requeue 정책
+	Requeue RequeuePolicy
+	// phase 수행 후 결과에 따라 condition 을 설정하는 함수 (optional)
+	// 성공한 경우 err 는 nil 이다.
+	SetCondition func(T, error)
+	// phase 의 결과를 기록할 condition type (optional)
+	// object 가 Conditioned 를 구현하는 경우에만 기록한다.
+	ConditionType string
+	// false 를 반환하면 phase 를 수행하지 않는다. (optional)
+	When func(T) bool
+	// 먼저 수행되어야 하는 phase 들의 이름 (optional)
+	// 선언 순서와 관계없이 해당 phase 들 뒤에 수행되며, 해당 phase 들이 error 나 requeue 로 끝나면 수행하지 않는다.
+	DependsOn []string
+}
+
+// Conditioned 는 phase 의 결과를 condition 으로 기록할 수 있는 object 이다.
+type Conditioned interface {
+	GetConditions() []metav1.Condition
+	SetConditions([]metav1.Condition)
+}
+
+// Runner는 phase 들을 순서대로 수행하고 결과를 aggregate 한다.
+type Runner[T client.Object] struct {
+	Log logr.Logger
+	// phase 가 실패한 경우 event 를 기록한다. nil 이면 기록하지 않는다.
+	Recorder record.EventRecorder
+}
+
+func NewRunner[T client.Object](log logr.Logger, recorder record.EventRecorder) *Runner[T] {
+	return &Runner[T]{
+		Log:      log,
+		Recorder: recorder,
+	}
+}
+
+// phases 를 DependsOn 에 맞게 정렬한 뒤 순차적으로 수행하고,
+// error 가 발생하면 이후 phase 의 결과는 무시하고 모든 error 를 aggregate 하여 반환한다.
+// error 는 없지만 requeue 가 필요한 phase 들이 있는 경우 requeueAfter time 이 가장 짧은 결과를 반환한다.
+func (p *Runner[T]) Run(ctx context.Context, obj T, phases []Phase[T]) (ctrl.Result, error) {
+	ordered, err := order(phases)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	res := ctrl.Result{}
+	errs := []error{}
+	// requeue 정책과 관계없이 실패한 모든 phase 의 error
+	failures := []error{}
+	failed := false
+	// error 나 requeue 로 끝나 dependent phase 를 막는 phase 들
+	incomplete := map[string]bool{}
+	kind := objectKind(obj)
+	for _, phase := range ordered {
+		if blocked := blockedBy(phase, incomplete); blocked != "" || (phase.When != nil && !phase.When(obj)) {
+			if blocked != "" {
+				incomplete[phase.Name] = true
+			}
+			metrics.RecordPhase(kind, phase.Name, metrics.PhaseResultSkipped)
+			continue
+		}
+
+		// Call the inner reconciliation methods.
+		start := time.Now()
+		phaseResult, err := phase.Run(ctx, obj)
+		metrics.RecordPhaseDuration(kind, phase.Name, time.Since(start))
+		if phase.SetCondition != nil {
+			phase.SetCondition(obj, err)
+		}
+		setPhaseCondition(obj, phase, phaseResult, err)
+		recordPhaseResult(kind, phase.Name, phaseResult, err)
+		if err != nil || !phaseResult.IsZero() {
+			incomplete[phase.Name] = true
+		}
+		if err != nil {
+			failed = true
+			failures = append(failures, fmt.Errorf("%s: %w", phase.Name, err))
+			p.recordFailure(obj, phase.Name, err)
+			if !util.IsRetryable(err) && phase.Requeue.NonRetryableAfter > 0 {
+				p.Log.WithValues("object", client.ObjectKeyFromObject(obj)).
+					Info("Phase failed with non-retryable error", "phase", phase.Name, "reason", err.Error())
+				res = util.LowestNonZeroResult(res, ctrl.Result{RequeueAfter: phase.Requeue.NonRetryableAfter})
+				continue
+			}
+			errs = append(errs, err)
+		}
+		if failed {
+			continue
+		}
+
+		// Aggregate phases which requeued without err
+		res = util.LowestNonZeroResult(res, phaseResult)
+	}
+
+	recordReconcileResult(kind, obj, kerrors.NewAggregate(failures))
+	return res, kerrors.NewAggregate(errs)
+}
+
+// 선언 순서를 유지하되, DependsOn 에 지정된 phase 가 먼저 수행되도록 정렬한다.
+func order[T client.Object](phases []Phase[T]) ([]Phase[T], error) {
+	byName := map[string]int{}
+	for i, phase := range phases {
+		if _, ok := byName[phase.Name]; ok {
+			return nil, fmt.Errorf("duplicated phase %s", phase.Name)
+		}
+		byName[phase.Name] = i
+	}
+
+	ordered := make([]Phase[T], 0, len(phases))
+	// 0: 방문 전, 1: 방문 중, 2: 방문 완료
+	state := make([]int, len(phases))
+	var visit func(i int) error
+	visit = func(i int) error {
+		switch state[i] {
+		case 1:
+			return fmt.Errorf("circular dependency on phase %s", phases[i].Name)
+		case 2:
+			return nil
+		}
+		state[i] = 1
+		for _, dep := range phases[i].DependsOn {
+			j, ok := byName[dep]
+			if !ok {
+				return fmt.Errorf("phase %s depends on unknown phase %s", phases[i].Name, dep)
+			}
+			if err := visit(j); err != nil {
+				return err
+			}
+		}
+		state[i] = 2
+		ordered = append(ordered, phases[i])
+		return nil
+	}
+	for i := range phases {
+		if err := visit(i); err != nil {
+			return nil, err
+		}
+	}
+	return ordered, nil
+}
+
+// phase 가 의존하는 phase 중 완료되지 않은 phase 의 이름을 반환한다.
+func blockedBy[T client.Object](phase Phase[T], incomplete map[string]bool) string {
+	for _, dep := range phase.DependsOn {
+		if incomplete[dep] {
+			return dep
+		}
+	}
+	return ""
+}
+
+// ConditionType 이 지정된 phase 의 결과를 condition 으로 기록한다.
+func setPhaseCondition[T client.Object](obj T, phase Phase[T], res ctrl.Result, err error) {
+	if phase.ConditionType == "" {
+		return
+	}
+	conditioned, ok := any(obj).(Conditioned)
+	if !ok {
+		return
+	}
+
+	condition := metav1.Condition{
+		Type:               phase.ConditionType,
+		Status:             metav1.ConditionTrue,
+		Reason:             ReasonSucceeded,
+		ObservedGeneration: obj.GetGeneration(),
+	}
+	switch {
+	case err != nil:
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = ReasonFailed
+		condition.Message = err.Error()
+	case !res.IsZero():
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = ReasonInProgress
+	}
+	conditions := conditioned.GetConditions()
+	meta.SetStatusCondition(&conditions, condition)
+	conditioned.SetConditions(conditions)
+}
+
+// reconcile 결과를 metric 으로 기록하고, 실패한 경우 마지막 error 를 annotation 으로 남긴다.
+// annotation 은 호출한 controller 의 patch 로 반영된다.
+func recordReconcileResult(kind string, obj client.Object, err error) {
+	if err == nil && !obj.GetDeletionTimestamp().IsZero() {
+		metrics.ForgetReconcile(kind, obj.GetNamespace(), obj.GetName())
+	} else {
+		metrics.RecordReconcile(kind, obj.GetNamespace(), obj.GetName(), err)
+	}
+
+	annotations := obj.GetAnnotations()
+	if err == nil {
+		if _, ok := annotations[util.AnnotationKeyLastReconcileError]; ok {
+			delete(annotations, util.AnnotationKeyLastReconcileError)
+			obj.SetAnnotations(annotations)
+		}
+		return
+	}
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	message := err.Error()
+	if len(message) > maxLastReconcileErrorLength {
+		message = message[:maxLastReconcileErrorLength] + "..."
+	}
+	annotations[util.AnnotationKeyLastReconcileError] = message
+	obj.SetAnnotations(annotations)
+}
+
+// object 를 찾을 수 없는 경우 마지막 reconcile 결과를 집계에서 제외한다.
+// finalizer 없이 삭제되거나 삭제 중 reconcile 이 실패한 채 사라진 object 는 Run 에서 제외되지 않으므로
+// controller 가 object 를 not found 로 확인했을 때 호출해야 한다.
+func Forget(obj client.Object, key client.ObjectKey) {
+	metrics.ForgetReconcile(objectKind(obj), key.Namespace, key.Name)
+}
+
+func (p *Runner[T]) recordFailure(obj T, name string, err error) {
+	if p.Recorder == nil {
+		return
+	}
+	p.Recorder.Event(obj, coreV1.EventTypeWarning, name+"Failed", err.Error())
+}
+
+func recordPhaseResult(kind, name string, res ctrl.Result, err error) {
+	switch {
+	case err != nil:
+		metrics.RecordPhase(kind, name, metrics.PhaseResultError)
+	case !res.IsZero():
+		metrics.RecordPhase(kind, name, metrics.PhaseResultRequeue)
+	default:
+		metrics.RecordPhase(kind, name, metrics.PhaseResultSuccess)
+	}
+}
+
+// client.Get 으로 가져온 object 는 TypeMeta 가 비어있을 수 있으므로 go type 의 이름을 사용한다.
+func objectKind(obj client.Object) string {
+	if kind := obj.GetObjectKind().GroupVersionKind().Kind; kind != "" {
+		return kind
+	}
+	t := reflect.TypeOf(obj)
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t.Name()
+}
@@ -0,0 +1,409 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/prometheus/client_golang/prometheus"
+	clusterV1alpha1 "github.com/tmax-cloud/hypercloud-multi-operator/apis/cluster/v1alpha1"
+	"github.com/tmax-cloud/hypercloud-multi-operator/controllers/metrics"
+	"github.com/tmax-cloud/hypercloud-multi-operator/controllers/util"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+type testObject = clusterV1alpha1.ClusterRegistration
+
+// 수행된 phase 의 이름을 순서대로 기록하는 phase 를 만든다.
+func recordingPhase(name string, ran *[]string, res ctrl.Result, err error, dependsOn ...string) Phase[*testObject] {
+	return Phase[*testObject]{
+		Name: name,
+		Run: func(context.Context, *testObject) (ctrl.Result, error) {
+			*ran = append(*ran, name)
+			return res, err
+		},
+		DependsOn: dependsOn,
+	}
+}
+
+func newTestObject() *testObject {
+	return &testObject{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:       "test",
+			Namespace:  "test",
+			Generation: 2,
+		},
+	}
+}
+
+func TestRunOrder(t *testing.T) {
+	errFailed := errors.New("failed")
+	requeue := ctrl.Result{RequeueAfter: time.Minute}
+
+	tests := []struct {
+		name   string
+		phases func(ran *[]string) []Phase[*testObject]
+		want   []string
+		// 정렬에 실패하여 아무 phase 도 수행하지 않아야 하는 경우
+		wantOrderErr string
+	}{
+		{
+			name: "declaration order",
+			phases: func(ran *[]string) []Phase[*testObject] {
+				return []Phase[*testObject]{
+					recordingPhase("a", ran, ctrl.Result{}, nil),
+					recordingPhase("b", ran, ctrl.Result{}, nil),
+					recordingPhase("c", ran, ctrl.Result{}, nil),
+				}
+			},
+			want: []string{"a", "b", "c"},
+		},
+		{
+			name: "dependency runs first",
+			phases: func(ran *[]string) []Phase[*testObject] {
+				return []Phase[*testObject]{
+					recordingPhase("a", ran, ctrl.Result{}, nil, "c"),
+					recordingPhase("b", ran, ctrl.Result{}, nil),
+					recordingPhase("c", ran, ctrl.Result{}, nil, "b"),
+				}
+			},
+			want: []string{"b", "c", "a"},
+		},
+		{
+			name: "dependent of failed phase is skipped",
+			phases: func(ran *[]string) []Phase[*testObject] {
+				return []Phase[*testObject]{
+					recordingPhase("a", ran, ctrl.Result{}, errFailed),
+					recordingPhase("b", ran, ctrl.Result{}, nil, "a"),
+					recordingPhase("c", ran, ctrl.Result{}, nil, "b"),
+					recordingPhase("d", ran, ctrl.Result{}, nil),
+				}
+			},
+			want: []string{"a", "d"},
+		},
+		{
+			name: "dependent of requeued phase is skipped",
+			phases: func(ran *[]string) []Phase[*testObject] {
+				return []Phase[*testObject]{
+					recordingPhase("a", ran, requeue, nil),
+					recordingPhase("b", ran, ctrl.Result{}, nil, "a"),
+				}
+			},
+			want: []string{"a"},
+		},
+		{
+			name: "circular dependency",
+			phases: func(ran *[]string) []Phase[*testObject] {
+				return []Phase[*testObject]{
+					recordingPhase("a", ran, ctrl.Result{}, nil, "b"),
+					recordingPhase("b", ran, ctrl.Result{}, nil, "a"),
+				}
+			},
+			wantOrderErr: "circular dependency",
+		},
+		{
+			name: "self dependency",
+			phases: func(ran *[]string) []Phase[*testObject] {
+				return []Phase[*testObject]{
+					recordingPhase("a", ran, ctrl.Result{}, nil, "a"),
+				}
+			},
+			wantOrderErr: "circular dependency",
+		},
+		{
+			name: "unknown dependency",
+			phases: func(ran *[]string) []Phase[*testObject] {
+				return []Phase[*testObject]{
+					recordingPhase("a", ran, ctrl.Result{}, nil, "b"),
+				}
+			},
+			wantOrderErr: "unknown phase",
+		},
+		{
+			name: "duplicated phase",
+			phases: func(ran *[]string) []Phase[*testObject] {
+				return []Phase[*testObject]{
+					recordingPhase("a", ran, ctrl.Result{}, nil),
+					recordingPhase("a", ran, ctrl.Result{}, nil),
+				}
+			},
+			wantOrderErr: "duplicated phase",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ran := []string{}
+			runner := NewRunner[*testObject](logr.Discard(), nil)
+			_, err := runner.Run(context.TODO(), newTestObject(), tt.phases(&ran))
+			if tt.wantOrderErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantOrderErr) {
+					t.Fatalf("Run() error = %v, want %q", err, tt.wantOrderErr)
+				}
+				if len(ran) > 0 {
+					t.Errorf("phases %v should not run", ran)
+				}
+				return
+			}
+			if !reflect.DeepEqual(ran, tt.want) {
+				t.Errorf("ran = %v, want %v", ran, tt.want)
+			}
+		})
+	}
+}
+
+func TestRunWhen(t *testing.T) {
+	tests := []struct {
+		name string
+		when func(*testObject) bool
+		// 수행되어야 하는 phase
+		want []string
+	}{
+		{
+			name: "no when",
+			want: []string{"a", "b"},
+		},
+		{
+			name: "when true",
+			when: func(*testObject) bool { return true },
+			want: []string{"a", "b"},
+		},
+		{
+			// When 으로 skip 된 phase 는 완료된 것으로 보므로 dependent phase 는 수행된다.
+			name: "when false does not block dependent",
+			when: func(*testObject) bool { return false },
+			want: []string{"b"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ran := []string{}
+			a := recordingPhase("a", &ran, ctrl.Result{}, nil)
+			a.When = tt.when
+			a.ConditionType = "A"
+			obj := newTestObject()
+
+			runner := NewRunner[*testObject](logr.Discard(), nil)
+			if _, err := runner.Run(context.TODO(), obj, []Phase[*testObject]{a, recordingPhase("b", &ran, ctrl.Result{}, nil, "a")}); err != nil {
+				t.Fatalf("Run() error = %v", err)
+			}
+			if !reflect.DeepEqual(ran, tt.want) {
+				t.Errorf("ran = %v, want %v", ran, tt.want)
+			}
+			// skip 된 phase 는 condition 을 기록하지 않는다.
+			skipped := tt.when != nil && !tt.when(obj)
+			if condition := meta.FindStatusCondition(obj.GetConditions(), "A"); (condition == nil) != skipped {
+				t.Errorf("condition = %v, skipped %v", condition, skipped)
+			}
+		})
+	}
+}
+
+func TestRunResult(t *testing.T) {
+	errRetryable := util.NewError(util.ErrRemoteUnreachable, errors.New("unreachable"))
+	errNonRetryable := util.NewError(util.ErrPermissionDenied, errors.New("forbidden"))
+
+	tests := []struct {
+		name    string
+		results []ctrl.Result
+		errs    []error
+		policy  RequeuePolicy
+		want    ctrl.Result
+		wantErr bool
+	}{
+		{
+			name:    "lowest requeue after",
+			results: []ctrl.Result{{RequeueAfter: time.Minute}, {RequeueAfter: time.Second}, {}},
+			errs:    []error{nil, nil, nil},
+			want:    ctrl.Result{RequeueAfter: time.Second},
+		},
+		{
+			name:    "error ignores requeue of later phases",
+			results: []ctrl.Result{{}, {RequeueAfter: time.Second}},
+			errs:    []error{errRetryable, nil},
+			wantErr: true,
+		},
+		{
+			name:    "non-retryable error is requeued by policy",
+			results: []ctrl.Result{{}},
+			errs:    []error{errNonRetryable},
+			policy:  RequeuePolicy{NonRetryableAfter: time.Hour},
+			want:    ctrl.Result{RequeueAfter: time.Hour},
+		},
+		{
+			name:    "retryable error is returned regardless of policy",
+			results: []ctrl.Result{{}},
+			errs:    []error{errRetryable},
+			policy:  RequeuePolicy{NonRetryableAfter: time.Hour},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ran := []string{}
+			phases := []Phase[*testObject]{}
+			for i := range tt.results {
+				phase := recordingPhase(string(rune('a'+i)), &ran, tt.results[i], tt.errs[i])
+				phase.Requeue = tt.policy
+				phases = append(phases, phase)
+			}
+
+			runner := NewRunner[*testObject](logr.Discard(), nil)
+			res, err := runner.Run(context.TODO(), newTestObject(), phases)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Run() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if res != tt.want {
+				t.Errorf("Run() result = %v, want %v", res, tt.want)
+			}
+		})
+	}
+}
+
+func TestRunCondition(t *testing.T) {
+	tests := []struct {
+		name       string
+		res        ctrl.Result
+		err        error
+		wantStatus metav1.ConditionStatus
+		wantReason string
+	}{
+		{
+			name:       "succeeded",
+			wantStatus: metav1.ConditionTrue,
+			wantReason: ReasonSucceeded,
+		},
+		{
+			name:       "in progress",
+			res:        ctrl.Result{RequeueAfter: time.Second},
+			wantStatus: metav1.ConditionFalse,
+			wantReason: ReasonInProgress,
+		},
+		{
+			name:       "failed",
+			err:        errors.New("failed"),
+			wantStatus: metav1.ConditionFalse,
+			wantReason: ReasonFailed,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ran := []string{}
+			phase := recordingPhase("a", &ran, tt.res, tt.err)
+			phase.ConditionType = "A"
+			obj := newTestObject()
+
+			runner := NewRunner[*testObject](logr.Discard(), nil)
+			_, _ = runner.Run(context.TODO(), obj, []Phase[*testObject]{phase})
+
+			condition := meta.FindStatusCondition(obj.GetConditions(), "A")
+			if condition == nil {
+				t.Fatal("condition is not set")
+			}
+			if condition.Status != tt.wantStatus || condition.Reason != tt.wantReason {
+				t.Errorf("condition = %s/%s, want %s/%s", condition.Status, condition.Reason, tt.wantStatus, tt.wantReason)
+			}
+			if condition.ObservedGeneration != obj.Generation {
+				t.Errorf("ObservedGeneration = %d, want %d", condition.ObservedGeneration, obj.Generation)
+			}
+		})
+	}
+}
+
+func TestRunLastReconcileErrorAnnotation(t *testing.T) {
+	longMessage := strings.Repeat("x", maxLastReconcileErrorLength+10)
+
+	tests := []struct {
+		name        string
+		annotations map[string]string
+		err         error
+		// 빈 값이면 annotation 이 없어야 한다.
+		want string
+	}{
+		{
+			name: "record error",
+			err:  errors.New("failed"),
+			want: "a: failed",
+		},
+		{
+			name: "truncate long error",
+			err:  errors.New(longMessage),
+			want: ("a: " + longMessage)[:maxLastReconcileErrorLength] + "...",
+		},
+		{
+			name:        "remove annotation on success",
+			annotations: map[string]string{util.AnnotationKeyLastReconcileError: "a: failed", "other": "value"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ran := []string{}
+			obj := newTestObject()
+			obj.SetAnnotations(tt.annotations)
+
+			runner := NewRunner[*testObject](logr.Discard(), nil)
+			_, _ = runner.Run(context.TODO(), obj, []Phase[*testObject]{recordingPhase("a", &ran, ctrl.Result{}, tt.err)})
+
+			got, ok := obj.GetAnnotations()[util.AnnotationKeyLastReconcileError]
+			if tt.want == "" {
+				if ok {
+					t.Errorf("annotation = %q, want removed", got)
+				}
+				if tt.annotations != nil && obj.GetAnnotations()["other"] != "value" {
+					t.Error("other annotations should be kept")
+				}
+				return
+			}
+			if got != tt.want {
+				t.Errorf("annotation = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestForget(t *testing.T) {
+	ran := []string{}
+	obj := newTestObject()
+	runner := NewRunner[*testObject](logr.Discard(), nil)
+	_, _ = runner.Run(context.TODO(), obj, []Phase[*testObject]{recordingPhase("a", &ran, ctrl.Result{}, errors.New("failed"))})
+
+	failing := func() float64 {
+		registry := prometheus.NewRegistry()
+		registry.MustRegister(metrics.ReconcileHealth)
+		families, err := registry.Gather()
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, family := range families {
+			if family.GetName() != "hypercloud_reconcile_failing_objects" {
+				continue
+			}
+			for _, m := range family.Metric {
+				if m.Label[0].GetValue() == "ClusterRegistration" {
+					return m.Gauge.GetValue()
+				}
+			}
+		}
+		return 0
+	}
+	if got := failing(); got != 1 {
+		t.Fatalf("failing objects = %v, want 1", got)
+	}
+
+	// not found 로 확인한 object 는 비어있으므로 go type 으로 kind 를 찾는다.
+	Forget(&testObject{}, client.ObjectKeyFromObject(obj))
+	if got := failing(); got != 0 {
+		t.Errorf("failing objects after Forget = %v, want 0", got)
+	}
+}
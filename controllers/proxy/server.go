@@ -0,0 +1,280 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proxy
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	clusterV1alpha1 "github.com/tmax-cloud/hypercloud-multi-operator/apis/cluster/v1alpha1"
+	util "github.com/tmax-cloud/hypercloud-multi-operator/controllers/util"
+
+	authenticationV1 "k8s.io/api/authentication/v1"
+	authorizationV1 "k8s.io/api/authorization/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/transport"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// /clusters/<namespace>/<name>/proxy/<path> 로 요청하면 member cluster 의 api server 의 /<path> 로 전달한다.
+	// cluster manager 는 namespace 에 속하므로 이름과 함께 namespace 를 지정한다.
+	ClustersPath = "/clusters/"
+
+	proxySubresource = "proxy"
+	// kubernetes 가 예약한 사용자와 group 의 prefix
+	systemPrefix = "system:"
+)
+
+var (
+	errUnauthorized = errors.New("unauthorized")
+	errForbidden    = errors.New("forbidden")
+	errReviewFailed = errors.New("failed to review the request")
+	errSystemUser   = errors.New("system users cannot be proxied to member clusters")
+	errInvalidPath  = errors.New("path must be " + ClustersPath + "<namespace>/<name>/" + proxySubresource + "/<path>")
+)
+
+// Server 는 console 과 cli 가 management cluster 의 endpoint 하나로 member cluster 의 api server 에 요청할 수 있도록
+// kubeconfig secret 의 인증 정보로 요청을 전달하는 http server
+// 요청한 사용자를 impersonate 하여 전달하므로 member cluster 에서도 사용자의 권한으로 처리된다.
+// manager 에 runnable 로 등록되어 manager 와 함께 시작되고 종료된다.
+type Server struct {
+	Client      client.Client
+	Log         logr.Logger
+	BindAddress string
+	// 사용자의 token 을 받으므로 https 로만 요청을 받는다. tls.crt, tls.key 가 있는 directory
+	CertDir string
+}
+
+// +kubebuilder:rbac:groups=authentication.k8s.io,resources=tokenreviews,verbs=create
+// +kubebuilder:rbac:groups=authorization.k8s.io,resources=subjectaccessreviews,verbs=create
+
+// leader 가 아닌 replica 에서도 요청을 처리할 수 있도록 leader election 과 무관하게 동작한다.
+func (s *Server) NeedLeaderElection() bool {
+	return false
+}
+
+func (s *Server) Start(ctx context.Context) error {
+	mux := http.NewServeMux()
+	mux.HandleFunc(ClustersPath, s.handleProxy)
+
+	// watch, exec 처럼 오래 유지되는 요청이 있으므로 write timeout 은 설정하지 않는다.
+	server := &http.Server{
+		Addr:              s.BindAddress,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	if err := util.ConfigureServingCert(ctx, server, s.CertDir, s.Log); err != nil {
+		return err
+	}
+
+	errCh := make(chan error, 1)
+	go func() {
+		s.Log.Info("Starting proxy server", "address", s.BindAddress, "certDir", s.CertDir)
+		if err := server.ListenAndServeTLS("", ""); err != nil && err != http.ErrServerClosed {
+			errCh <- err
+		}
+		close(errCh)
+	}()
+
+	select {
+	case <-ctx.Done():
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		return server.Shutdown(shutdownCtx)
+	case err := <-errCh:
+		return err
+	}
+}
+
+func (s *Server) handleProxy(w http.ResponseWriter, req *http.Request) {
+	key, path, err := parseProxyPath(req.URL.Path)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	log := s.Log.WithValues("cluster", key.String(), "method", req.Method, "path", path)
+
+	user, code, err := s.authorize(req, key)
+	if err != nil {
+		log.Info("Unauthorized proxy request", "reason", err.Error())
+		http.Error(w, err.Error(), code)
+		return
+	}
+	impersonate, err := impersonationConfig(user)
+	if err != nil {
+		log.Info("Unauthorized proxy request", "reason", err.Error(), "user", user.Username)
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+
+	clm := &clusterV1alpha1.ClusterManager{}
+	if err := s.Client.Get(req.Context(), key, clm); apierrors.IsNotFound(err) {
+		http.Error(w, "cluster not found", http.StatusNotFound)
+		return
+	} else if err != nil {
+		log.Error(err, "Failed to get ClusterManager")
+		http.Error(w, "failed to get cluster", http.StatusInternalServerError)
+		return
+	}
+
+	kubeconfigSecret, err := util.GetKubeconfigSecret(req.Context(), s.Client, clm.Namespace, clm.Name)
+	if apierrors.IsNotFound(err) {
+		http.Error(w, "cluster is not ready", http.StatusServiceUnavailable)
+		return
+	} else if err != nil {
+		log.Error(err, "Failed to get kubeconfig secret")
+		http.Error(w, "failed to get kubeconfig", http.StatusInternalServerError)
+		return
+	}
+
+	host, rt, err := util.GetRemoteK8sTransport(kubeconfigSecret)
+	if err != nil {
+		log.Error(err, "Failed to get transport for the cluster")
+		http.Error(w, "failed to connect to the cluster", http.StatusBadGateway)
+		return
+	}
+	target, err := url.Parse(host)
+	if err != nil {
+		log.Error(err, "Invalid api server address of the cluster", "host", host)
+		http.Error(w, "failed to connect to the cluster", http.StatusBadGateway)
+		return
+	}
+
+	log.V(4).Info("Proxy request", "user", user.Username)
+	reverseProxy := &httputil.ReverseProxy{
+		Director: func(out *http.Request) {
+			out.URL.Scheme = target.Scheme
+			out.URL.Host = target.Host
+			out.URL.Path = strings.TrimSuffix(target.Path, "/") + path
+			out.URL.RawPath = ""
+			out.Host = target.Host
+			// 사용자의 token 과 impersonate header 는 member cluster 로 전달하지 않는다.
+			out.Header.Del("Authorization")
+			for header := range out.Header {
+				if strings.HasPrefix(header, "Impersonate-") {
+					out.Header.Del(header)
+				}
+			}
+		},
+		Transport: transport.NewImpersonatingRoundTripper(impersonate, rt),
+		// watch 응답을 바로 전달한다.
+		FlushInterval: -1,
+		ErrorHandler: func(w http.ResponseWriter, req *http.Request, err error) {
+			log.Error(err, "Failed to proxy request")
+			http.Error(w, "failed to proxy request to the cluster", http.StatusBadGateway)
+		},
+	}
+	reverseProxy.ServeHTTP(w, req)
+}
+
+// member cluster 에서 impersonate 할 사용자 정보를 만든다.
+// management cluster 의 service account, node 같은 system 사용자는 member cluster 에서 다른 주체를 가리키므로 거부하고,
+// system:masters 처럼 member cluster 에서 권한을 부여하는 system group 은 전달하지 않는다.
+// system:authenticated 는 impersonate 된 사용자에게 member cluster 가 다시 추가한다.
+func impersonationConfig(user *authenticationV1.UserInfo) (transport.ImpersonationConfig, error) {
+	if strings.HasPrefix(user.Username, systemPrefix) {
+		return transport.ImpersonationConfig{}, errSystemUser
+	}
+	groups := []string{}
+	for _, group := range user.Groups {
+		if !strings.HasPrefix(group, systemPrefix) {
+			groups = append(groups, group)
+		}
+	}
+	extra := map[string][]string{}
+	for k, v := range user.Extra {
+		extra[k] = v
+	}
+	return transport.ImpersonationConfig{
+		UserName: user.Username,
+		UID:      user.UID,
+		Groups:   groups,
+		Extra:    extra,
+	}, nil
+}
+
+// /clusters/<namespace>/<name>/proxy/<path> 에서 cluster 와 member cluster 로 전달할 path 를 구한다.
+func parseProxyPath(path string) (types.NamespacedName, string, error) {
+	parts := strings.SplitN(strings.TrimPrefix(path, ClustersPath), "/", 4)
+	if len(parts) < 3 || parts[0] == "" || parts[1] == "" || parts[2] != proxySubresource {
+		return types.NamespacedName{}, "", errInvalidPath
+	}
+	key := types.NamespacedName{Namespace: parts[0], Name: parts[1]}
+	if len(parts) == 3 {
+		return key, "/", nil
+	}
+	return key, "/" + parts[3], nil
+}
+
+// bearer token 을 token review 로 인증하고, cluster manager 의 proxy 권한이 있는 사용자인지 subject access review 로 확인한다.
+// member cluster 의 리소스에 대한 권한은 impersonate 된 요청을 받은 member cluster 에서 확인한다.
+func (s *Server) authorize(req *http.Request, key types.NamespacedName) (*authenticationV1.UserInfo, int, error) {
+	token := strings.TrimPrefix(req.Header.Get("Authorization"), "Bearer ")
+	if token == "" || token == req.Header.Get("Authorization") {
+		return nil, http.StatusUnauthorized, errUnauthorized
+	}
+
+	tokenReview := &authenticationV1.TokenReview{
+		Spec: authenticationV1.TokenReviewSpec{
+			Token: token,
+		},
+	}
+	if err := s.Client.Create(req.Context(), tokenReview); err != nil {
+		s.Log.Error(err, "Failed to create TokenReview")
+		return nil, http.StatusInternalServerError, errReviewFailed
+	}
+	if !tokenReview.Status.Authenticated {
+		return nil, http.StatusUnauthorized, errUnauthorized
+	}
+
+	user := tokenReview.Status.User
+	extra := map[string]authorizationV1.ExtraValue{}
+	for k, v := range user.Extra {
+		extra[k] = authorizationV1.ExtraValue(v)
+	}
+	sar := &authorizationV1.SubjectAccessReview{
+		Spec: authorizationV1.SubjectAccessReviewSpec{
+			User:   user.Username,
+			Groups: user.Groups,
+			UID:    user.UID,
+			Extra:  extra,
+			ResourceAttributes: &authorizationV1.ResourceAttributes{
+				Namespace:   key.Namespace,
+				Name:        key.Name,
+				Verb:        "get",
+				Group:       clusterV1alpha1.GroupVersion.Group,
+				Resource:    "clustermanagers",
+				Subresource: proxySubresource,
+			},
+		},
+	}
+	if err := s.Client.Create(req.Context(), sar); err != nil {
+		s.Log.Error(err, "Failed to create SubjectAccessReview")
+		return nil, http.StatusInternalServerError, errReviewFailed
+	}
+	if !sar.Status.Allowed {
+		return nil, http.StatusForbidden, errForbidden
+	}
+	return &user, http.StatusOK, nil
+}
@@ -0,0 +1,69 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proxy
+
+import (
+	"reflect"
+	"testing"
+
+	authenticationV1 "k8s.io/api/authentication/v1"
+)
+
+func TestImpersonationConfig(t *testing.T) {
+	tests := []struct {
+		name       string
+		user       authenticationV1.UserInfo
+		wantErr    bool
+		wantGroups []string
+	}{
+		{
+			name:       "oidc user",
+			user:       authenticationV1.UserInfo{Username: "alice@tmax.co.kr", Groups: []string{"dev", "system:authenticated"}},
+			wantGroups: []string{"dev"},
+		},
+		{
+			name:       "system groups are dropped",
+			user:       authenticationV1.UserInfo{Username: "bob@tmax.co.kr", Groups: []string{"system:masters", "system:nodes", "ops"}},
+			wantGroups: []string{"ops"},
+		},
+		{
+			name:    "service account",
+			user:    authenticationV1.UserInfo{Username: "system:serviceaccount:kube-system:default"},
+			wantErr: true,
+		},
+		{
+			name:    "node",
+			user:    authenticationV1.UserInfo{Username: "system:node:worker-1", Groups: []string{"system:nodes"}},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config, err := impersonationConfig(&tt.user)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("expected error %v, got %v", tt.wantErr, err)
+			}
+			if tt.wantErr {
+				return
+			}
+			if config.UserName != tt.user.Username {
+				t.Errorf("expected user %s, got %s", tt.user.Username, config.UserName)
+			}
+			if !reflect.DeepEqual(config.Groups, tt.wantGroups) {
+				t.Errorf("expected groups %v, got %v", tt.wantGroups, config.Groups)
+			}
+		})
+	}
+}
@@ -0,0 +1,121 @@
+package util
+
+import (
+	"context"
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	// gateway bootstrap 으로 설치되는 monitoring addon
+	MonitoringNamespace    = "monitoring"
+	PrometheusName         = "prometheus-k8s"
+	PrometheusOperatorName = "prometheus-operator"
+	MonitoringGroupVersion = "monitoring.coreos.com/v1"
+
+	// ingress-nginx 가 처리하는 IngressClass 의 controller
+	IngressNginxController = "k8s.io/ingress-nginx"
+
+	CalicoNodeName     = "calico-node"
+	CalicoGroupVersion = "crd.projectcalico.org/v1"
+	CiliumName         = "cilium"
+	CiliumGroupVersion = "cilium.io/v2"
+)
+
+// remote cluster 에 addon 의 리소스는 있지만 준비되지 않은 상태
+type AddonNotReadyError struct {
+	Message string
+}
+
+func (e *AddonNotReadyError) Error() string {
+	return e.Message
+}
+
+func IsAddonNotReady(err error) bool {
+	_, ok := err.(*AddonNotReadyError)
+	return ok
+}
+
+func addonNotReady(format string, args ...interface{}) error {
+	return &AddonNotReadyError{Message: fmt.Sprintf(format, args...)}
+}
+
+// deployment 의 모든 replica 가 갱신되고 available 인지 확인한다.
+func CheckRemoteDeploymentReady(ctx context.Context, c kubernetes.Interface, namespace, name string) error {
+	deployment, err := c.AppsV1().Deployments(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	replicas := int32(1)
+	if deployment.Spec.Replicas != nil {
+		replicas = *deployment.Spec.Replicas
+	}
+	if deployment.Status.ObservedGeneration < deployment.Generation ||
+		deployment.Status.UpdatedReplicas < replicas ||
+		deployment.Status.AvailableReplicas < replicas {
+		return addonNotReady("deployment %s/%s has %d/%d available replicas",
+			namespace, name, deployment.Status.AvailableReplicas, replicas)
+	}
+	return nil
+}
+
+// statefulset 의 모든 replica 가 ready 인지 확인한다.
+func CheckRemoteStatefulSetReady(ctx context.Context, c kubernetes.Interface, namespace, name string) error {
+	statefulSet, err := c.AppsV1().StatefulSets(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	replicas := int32(1)
+	if statefulSet.Spec.Replicas != nil {
+		replicas = *statefulSet.Spec.Replicas
+	}
+	if statefulSet.Status.ObservedGeneration < statefulSet.Generation ||
+		statefulSet.Status.ReadyReplicas < replicas {
+		return addonNotReady("statefulset %s/%s has %d/%d ready replicas",
+			namespace, name, statefulSet.Status.ReadyReplicas, replicas)
+	}
+	return nil
+}
+
+// daemonset 이 스케줄된 모든 node 에서 ready 인지 확인한다.
+func CheckRemoteDaemonSetReady(ctx context.Context, c kubernetes.Interface, namespace, name string) error {
+	daemonSet, err := c.AppsV1().DaemonSets(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	desired := daemonSet.Status.DesiredNumberScheduled
+	if daemonSet.Status.ObservedGeneration < daemonSet.Generation ||
+		desired == 0 ||
+		daemonSet.Status.NumberReady < desired {
+		return addonNotReady("daemonset %s/%s has %d/%d ready pods",
+			namespace, name, daemonSet.Status.NumberReady, desired)
+	}
+	return nil
+}
+
+// addon 의 CRD 가 remote cluster 의 api server 에 등록되어 있는지 discovery 로 확인한다.
+// CRD 를 직접 조회하지 않으므로 remote cluster 에 apiextensions 권한이 필요 없다.
+func CheckRemoteAPIResources(c kubernetes.Interface, groupVersion string, resources ...string) error {
+	resourceList, err := c.Discovery().ServerResourcesForGroupVersion(groupVersion)
+	if err != nil {
+		return err
+	}
+	served := map[string]bool{}
+	for _, resource := range resourceList.APIResources {
+		served[resource.Name] = true
+	}
+	gv, err := schema.ParseGroupVersion(groupVersion)
+	if err != nil {
+		return err
+	}
+	for _, resource := range resources {
+		if !served[resource] {
+			return errors.NewNotFound(schema.GroupResource{Group: gv.Group, Resource: resource}, "")
+		}
+	}
+	return nil
+}
@@ -0,0 +1,128 @@
+package util
+
+import (
+	"context"
+	b64 "encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	clusterV1alpha1 "github.com/tmax-cloud/hypercloud-multi-operator/apis/cluster/v1alpha1"
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/clientcredentials"
+	coreV1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// AKS 의 credential secret 에서 service principal 정보를 가지고 있는 key
+const (
+	AKSTenantIDKey     = "tenantId"
+	AKSClientIDKey     = "clientId"
+	AKSClientSecretKey = "clientSecret"
+)
+
+const (
+	// operator 가 아닌 사용자가 kubeconfig 를 사용하는 경우 kubelogin 으로 token 을 발급받는다.
+	aksExecCommand = "kubelogin"
+	// AKS-managed Azure AD 의 api server application id 로, 모든 AKS cluster 가 같은 값을 사용한다.
+	aksServerID        = "6dae42f8-4368-4678-94ff-3960e28e3630"
+	azureManagementURL = "https://management.azure.com"
+	aksAPIVersion      = "2022-09-01"
+)
+
+// Azure Resource Manager api 로 api server 주소와 CA 를 조회하여 exec plugin 을 사용하는 kubeconfig 를 생성한다.
+// operator 의 remote client 는 credential secret 의 service principal 로 Azure AD token 을 발급하여 갱신한다.
+func NewAKSKubeconfig(ctx context.Context, c client.Reader, namespace string, aks *clusterV1alpha1.AKSRegistration) ([]byte, error) {
+	secret, err := getCredentialsSecret(ctx, c, namespace, aks.CredentialsSecret)
+	if err != nil {
+		return nil, err
+	}
+	config, err := aksClientCredentials(secret, azureManagementURL+"/.default")
+	if err != nil {
+		return nil, err
+	}
+
+	// user credential 의 kubeconfig 에서 api server 주소와 CA 만 사용한다.
+	endpoint := fmt.Sprintf("%s/subscriptions/%s/resourceGroups/%s/providers/Microsoft.ContainerService/managedClusters/%s/listClusterUserCredential?api-version=%s",
+		azureManagementURL, url.PathEscape(aks.SubscriptionID), url.PathEscape(aks.ResourceGroup), url.PathEscape(aks.Cluster), aksAPIVersion)
+	body, err := doCloudRequest(ctx, config.TokenSource(context.Background()), http.MethodPost, endpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	credentials := struct {
+		Kubeconfigs []struct {
+			Value string `json:"value"`
+		} `json:"kubeconfigs"`
+	}{}
+	if err := json.Unmarshal(body, &credentials); err != nil {
+		return nil, err
+	}
+	if len(credentials.Kubeconfigs) == 0 {
+		return nil, fmt.Errorf("AKS cluster %q returned no kubeconfig", aks.Cluster)
+	}
+	raw, err := b64.StdEncoding.DecodeString(credentials.Kubeconfigs[0].Value)
+	if err != nil {
+		return nil, err
+	}
+	userKubeconfig, err := clientcmd.Load(raw)
+	if err != nil {
+		return nil, err
+	}
+	kubeContext, ok := userKubeconfig.Contexts[userKubeconfig.CurrentContext]
+	if !ok {
+		return nil, fmt.Errorf("kubeconfig of AKS cluster %q has no current context", aks.Cluster)
+	}
+	cluster, ok := userKubeconfig.Clusters[kubeContext.Cluster]
+	if !ok {
+		return nil, fmt.Errorf("kubeconfig of AKS cluster %q has no cluster", aks.Cluster)
+	}
+
+	tenantID, _ := secretValue(secret, AKSTenantIDKey)
+	clientID, _ := secretValue(secret, AKSClientIDKey)
+	return newExecKubeconfig(aks.Cluster, cluster.Server, cluster.CertificateAuthorityData, &clientcmdapi.ExecConfig{
+		APIVersion: execAPIVersion,
+		Command:    aksExecCommand,
+		Args: []string{
+			"get-token",
+			"--login", "spn",
+			"--environment", "AzurePublicCloud",
+			"--server-id", aksServerID,
+			"--tenant-id", tenantID,
+			"--client-id", clientID,
+		},
+		InteractiveMode: clientcmdapi.NeverExecInteractiveMode,
+	})
+}
+
+func newAKSTokenSource(secret *coreV1.Secret) (oauth2.TokenSource, error) {
+	config, err := aksClientCredentials(secret, aksServerID+"/.default")
+	if err != nil {
+		return nil, err
+	}
+	return config.TokenSource(context.Background()), nil
+}
+
+func aksClientCredentials(secret *coreV1.Secret, scope string) (*clientcredentials.Config, error) {
+	tenantID, err := secretValue(secret, AKSTenantIDKey)
+	if err != nil {
+		return nil, err
+	}
+	clientID, err := secretValue(secret, AKSClientIDKey)
+	if err != nil {
+		return nil, err
+	}
+	clientSecret, err := secretValue(secret, AKSClientSecretKey)
+	if err != nil {
+		return nil, err
+	}
+	return &clientcredentials.Config{
+		ClientID:     clientID,
+		ClientSecret: clientSecret,
+		TokenURL:     "https://login.microsoftonline.com/" + url.PathEscape(tenantID) + "/oauth2/v2.0/token",
+		Scopes:       []string{scope},
+	}, nil
+}
@@ -0,0 +1,33 @@
+package util
+
+import (
+	"context"
+	"strings"
+
+	clusterV1alpha1 "github.com/tmax-cloud/hypercloud-multi-operator/apis/cluster/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// operator 가 수행한 주요 동작을 ClusterAudit 으로 기록한다.
+// 콘솔에서는 clm-name label 로 cluster 별 audit 을 조회할 수 있다.
+func RecordAudit(c client.Client, namespace, clusterName string, action clusterV1alpha1.ClusterAuditAction, actor, message string) error {
+	audit := &clusterV1alpha1.ClusterAudit{
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName: clusterName + "-" + strings.ToLower(string(action)) + "-",
+			Namespace:    namespace,
+			Labels: map[string]string{
+				clusterV1alpha1.LabelKeyClmName:            clusterName,
+				clusterV1alpha1.LabelKeyClusterAuditAction: string(action),
+			},
+		},
+		Spec: clusterV1alpha1.ClusterAuditSpec{
+			ClusterName: clusterName,
+			Action:      action,
+			Actor:       actor,
+			Timestamp:   metav1.Now(),
+			Message:     message,
+		},
+	}
+	return c.Create(context.TODO(), audit)
+}
@@ -0,0 +1,255 @@
+package util
+
+import (
+	"sort"
+	"strconv"
+
+	clusterV1alpha1 "github.com/tmax-cloud/hypercloud-multi-operator/apis/cluster/v1alpha1"
+	coreV1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+	bootstrapv1 "sigs.k8s.io/cluster-api/bootstrap/kubeadm/api/v1beta1"
+)
+
+const (
+	// cluster 별 audit 설정 secret 의 이름 suffix 와 key
+	// kubeadm control plane 의 kubeadm config 가 이 secret 을 file 로 control plane node 에 내려받는다.
+	AuditPolicySecretSuffix = "-audit-policy"
+	AuditPolicyKey          = "policy.yaml"
+	AuditWebhookConfigKey   = "webhook.yaml"
+
+	// spec.addons.auditPolicy.webhook 의 secret key
+	AuditWebhookURLKey = "url"
+	AuditWebhookCAKey  = "ca.crt"
+
+	// 적용한 audit 설정의 hash 를 기록하는 kubeadm control plane 의 annotation
+	// 설정 파일의 내용만 바뀐 경우 kubeadm config spec 이 그대로이므로, hash 를 비교하여 control plane 을 rollout 한다.
+	AnnotationKeyAuditPolicyHash = "clustermanager.cluster.tmax.io/audit-policy-hash"
+
+	auditPolicyDir    = "/etc/kubernetes/audit"
+	auditLogDir       = "/var/log/kubernetes/audit"
+	auditPolicyVolume = "audit-policy"
+	auditLogVolume    = "audit-log"
+	auditWebhookName  = "audit-webhook"
+
+	auditLogMaxAgeDefault    = 30
+	auditLogMaxBackupDefault = 10
+	auditLogMaxSizeDefault   = 100
+)
+
+// 모든 cluster 에 동일하게 적용하는 기본 audit policy
+// health check, event 등 양이 많고 의미가 적은 요청은 제외하고, secret 등 민감한 resource 는 body 를 남기지 않는다.
+const StandardAuditPolicy = `apiVersion: audit.k8s.io/v1
+kind: Policy
+omitStages:
+- RequestReceived
+rules:
+- level: None
+  users:
+  - system:kube-proxy
+  verbs:
+  - watch
+  resources:
+  - group: ""
+    resources:
+    - endpoints
+    - services
+    - services/status
+- level: None
+  userGroups:
+  - system:nodes
+  verbs:
+  - get
+  resources:
+  - group: ""
+    resources:
+    - nodes
+    - nodes/status
+- level: None
+  users:
+  - system:kube-controller-manager
+  - system:kube-scheduler
+  - system:serviceaccount:kube-system:endpoint-controller
+  verbs:
+  - get
+  - update
+  namespaces:
+  - kube-system
+  resources:
+  - group: ""
+    resources:
+    - endpoints
+  - group: coordination.k8s.io
+    resources:
+    - leases
+- level: None
+  nonResourceURLs:
+  - /healthz*
+  - /livez*
+  - /readyz*
+  - /version
+  - /metrics
+- level: None
+  resources:
+  - group: ""
+    resources:
+    - events
+  - group: events.k8s.io
+    resources:
+    - events
+- level: Metadata
+  resources:
+  - group: ""
+    resources:
+    - secrets
+    - configmaps
+    - serviceaccounts/token
+  - group: authentication.k8s.io
+    resources:
+    - tokenreviews
+- level: Metadata
+  verbs:
+  - get
+  - list
+  - watch
+- level: Request
+  verbs:
+  - create
+  - update
+  - patch
+  - delete
+  - deletecollection
+- level: Metadata
+`
+
+// audit event 를 log collector 로 전송하는 webhook backend 설정 (kubeconfig 형식) 을 생성한다.
+func NewAuditWebhookConfig(url string, ca []byte) ([]byte, error) {
+	config := clientcmdapi.NewConfig()
+	config.Clusters[auditWebhookName] = &clientcmdapi.Cluster{
+		Server:                   url,
+		CertificateAuthorityData: ca,
+	}
+	config.AuthInfos[auditWebhookName] = &clientcmdapi.AuthInfo{}
+	config.Contexts[auditWebhookName] = &clientcmdapi.Context{
+		Cluster:  auditWebhookName,
+		AuthInfo: auditWebhookName,
+	}
+	config.CurrentContext = auditWebhookName
+	return clientcmd.Write(*config)
+}
+
+// audit 설정 secret 의 내용에 대한 hash 를 반환한다.
+func AuditPolicyHash(data map[string][]byte) string {
+	keys := make([]string, 0, len(data))
+	for key := range data {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	content := []byte{}
+	for _, key := range keys {
+		content = append(content, key...)
+		content = append(content, 0)
+		content = append(content, data[key]...)
+		content = append(content, 0)
+	}
+	return sha256Hex(content)[:16]
+}
+
+// kubeadm config spec 에 audit 설정 file, kube-apiserver 의 audit flag 와 volume 을 추가한다.
+// 이미 추가된 항목은 spec.addons.auditPolicy 에 맞게 갱신한다.
+func SetAuditPolicy(spec *bootstrapv1.KubeadmConfigSpec, secretName string, addon *clusterV1alpha1.AuditPolicyAddon) {
+	RemoveAuditPolicy(spec)
+
+	files := []string{AuditPolicyKey}
+	if addon.Webhook != nil {
+		files = append(files, AuditWebhookConfigKey)
+	}
+	for _, key := range files {
+		spec.Files = append(spec.Files, bootstrapv1.File{
+			Path:        auditPolicyDir + "/" + key,
+			Owner:       "root:root",
+			Permissions: "0600",
+			ContentFrom: &bootstrapv1.FileSource{
+				Secret: bootstrapv1.SecretFileSource{Name: secretName, Key: key},
+			},
+		})
+	}
+
+	if spec.ClusterConfiguration == nil {
+		spec.ClusterConfiguration = &bootstrapv1.ClusterConfiguration{}
+	}
+	apiServer := &spec.ClusterConfiguration.APIServer
+	if apiServer.ExtraArgs == nil {
+		apiServer.ExtraArgs = map[string]string{}
+	}
+	apiServer.ExtraArgs["audit-policy-file"] = auditPolicyDir + "/" + AuditPolicyKey
+	apiServer.ExtraArgs["audit-log-path"] = auditLogDir + "/audit.log"
+	apiServer.ExtraArgs["audit-log-maxage"] = strconv.Itoa(valueOrDefault(addon.MaxAge, auditLogMaxAgeDefault))
+	apiServer.ExtraArgs["audit-log-maxbackup"] = strconv.Itoa(valueOrDefault(addon.MaxBackup, auditLogMaxBackupDefault))
+	apiServer.ExtraArgs["audit-log-maxsize"] = strconv.Itoa(valueOrDefault(addon.MaxSize, auditLogMaxSizeDefault))
+	if addon.Webhook != nil {
+		apiServer.ExtraArgs["audit-webhook-config-file"] = auditPolicyDir + "/" + AuditWebhookConfigKey
+	}
+
+	apiServer.ExtraVolumes = append(apiServer.ExtraVolumes,
+		bootstrapv1.HostPathMount{
+			Name:      auditPolicyVolume,
+			HostPath:  auditPolicyDir,
+			MountPath: auditPolicyDir,
+			ReadOnly:  true,
+			PathType:  coreV1.HostPathDirectoryOrCreate,
+		},
+		bootstrapv1.HostPathMount{
+			Name:      auditLogVolume,
+			HostPath:  auditLogDir,
+			MountPath: auditLogDir,
+			PathType:  coreV1.HostPathDirectoryOrCreate,
+		},
+	)
+}
+
+// kubeadm config spec 에서 SetAuditPolicy 로 추가한 항목을 제거한다.
+func RemoveAuditPolicy(spec *bootstrapv1.KubeadmConfigSpec) {
+	files := []bootstrapv1.File{}
+	for _, file := range spec.Files {
+		if file.Path != auditPolicyDir+"/"+AuditPolicyKey && file.Path != auditPolicyDir+"/"+AuditWebhookConfigKey {
+			files = append(files, file)
+		}
+	}
+	if len(files) != len(spec.Files) {
+		spec.Files = files
+	}
+
+	if spec.ClusterConfiguration == nil {
+		return
+	}
+	apiServer := &spec.ClusterConfiguration.APIServer
+	for _, arg := range []string{
+		"audit-policy-file",
+		"audit-log-path",
+		"audit-log-maxage",
+		"audit-log-maxbackup",
+		"audit-log-maxsize",
+		"audit-webhook-config-file",
+	} {
+		delete(apiServer.ExtraArgs, arg)
+	}
+
+	volumes := []bootstrapv1.HostPathMount{}
+	for _, volume := range apiServer.ExtraVolumes {
+		if volume.Name != auditPolicyVolume && volume.Name != auditLogVolume {
+			volumes = append(volumes, volume)
+		}
+	}
+	if len(volumes) != len(apiServer.ExtraVolumes) {
+		apiServer.ExtraVolumes = volumes
+	}
+}
+
+func valueOrDefault(value, defaultValue int) int {
+	if value == 0 {
+		return defaultValue
+	}
+	return value
+}
@@ -0,0 +1,69 @@
+package util
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+
+	coreV1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// webhook 설정 secret 에서 api server 의 --authentication-token-webhook-config-file 로 사용할 key
+	AuthnWebhookConfigKey = "config"
+	// webhook 설정 secret 에서 member cluster 가 authn broker 에 인증할 때 사용하는 token 의 key
+	AuthnWebhookTokenKey = "token"
+
+	authnWebhookName = "hyperauth-authn-broker"
+)
+
+// member cluster 의 api server 가 authn broker 로 TokenReview 를 요청하도록 하는 webhook 설정 (kubeconfig 형식) 을 생성한다.
+// broker 는 AUTHN_WEBHOOK_URL 아래의 /authenticate/<namespace>/<name> 에서 cluster 별 token 으로 요청을 받는다.
+// token 과 사용자의 hyperauth token 이 전달되므로 https 만 허용하고, ca 로 broker 의 serving cert 를 검증하도록 한다.
+func NewAuthnWebhookConfig(namespace, name, token string, ca []byte) ([]byte, error) {
+	server := strings.TrimSuffix(os.Getenv(AUTHN_WEBHOOK_URL), "/")
+	if u, err := url.Parse(server); err != nil || u.Scheme != "https" || u.Host == "" {
+		return nil, fmt.Errorf("%s must be a https url", AUTHN_WEBHOOK_URL)
+	}
+	if len(ca) == 0 {
+		return nil, fmt.Errorf("CA of authn broker is empty")
+	}
+
+	config := clientcmdapi.NewConfig()
+	config.Clusters[authnWebhookName] = &clientcmdapi.Cluster{
+		Server:                   server + "/authenticate/" + namespace + "/" + name,
+		CertificateAuthorityData: ca,
+	}
+	config.AuthInfos[authnWebhookName] = &clientcmdapi.AuthInfo{
+		Token: token,
+	}
+	config.Contexts[authnWebhookName] = &clientcmdapi.Context{
+		Cluster:  authnWebhookName,
+		AuthInfo: authnWebhookName,
+	}
+	config.CurrentContext = authnWebhookName
+	return clientcmd.Write(*config)
+}
+
+// authn broker 의 serving cert 를 발급한 CA 를 반환한다.
+// cert-manager 가 secret 에 저장한 ca.crt 를 사용하고, 없으면 self-signed cert 로 보고 tls.crt 를 사용한다.
+func GetAuthnServingCA(ctx context.Context, c client.Reader) ([]byte, error) {
+	secret := &coreV1.Secret{}
+	key := types.NamespacedName{Name: AuthnServerCertSecret, Namespace: HypercloudNamespace}
+	if err := c.Get(ctx, key, secret); err != nil {
+		return nil, err
+	}
+	if ca := secret.Data[ServingCAName]; len(ca) != 0 {
+		return ca, nil
+	}
+	if cert := secret.Data[ServingCertName]; len(cert) != 0 {
+		return cert, nil
+	}
+	return nil, fmt.Errorf("secret %s has neither %s nor %s", AuthnServerCertSecret, ServingCAName, ServingCertName)
+}
@@ -0,0 +1,219 @@
+package util
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+
+	coreV1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// bundle 의 형식이 바뀌면 올린다.
+	BackupBundleVersion = 1
+	// bundle 을 암호화하는 key 를 가지고 있는 secret 의 key
+	BackupEncryptionKey = "key"
+)
+
+// BackupBundle 은 operator 의 상태를 다른 management cluster 로 옮기기 위한 snapshot 이다.
+// object store 에는 gzip 으로 압축한 json 으로 저장한다.
+type BackupBundle struct {
+	Version     int         `json:"version"`
+	CreatedTime metav1.Time `json:"createdTime"`
+	// cluster.tmax.io, claim.tmax.io 의 custom resource, status 를 포함한다.
+	Resources []*unstructured.Unstructured `json:"resources"`
+	// cluster 의 kubeconfig secret, data 는 암호화되어 있다.
+	KubeconfigSecrets []BackupSecret `json:"kubeconfigSecrets"`
+	// db 의 cluster member 정보
+	Members []DBMember `json:"members"`
+}
+
+type BackupSecret struct {
+	Name        string            `json:"name"`
+	Namespace   string            `json:"namespace"`
+	Labels      map[string]string `json:"labels,omitempty"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+	Type        coreV1.SecretType `json:"type,omitempty"`
+	// secret 의 data 를 json 으로 만들어 암호화한 값
+	EncryptedData []byte `json:"encryptedData"`
+	// namespace 에 tenant key 가 설정된 경우 bundle 의 key 대신 tenant key 로 암호화한다.
+	TenantKey *TenantKey `json:"tenantKey,omitempty"`
+}
+
+// DBMember 는 hypercloud api server 가 반환하는 cluster member 의 형식이다.
+type DBMember struct {
+	Namespace  string   `json:"Namespace"`
+	Cluster    string   `json:"Cluster"`
+	MemberId   string   `json:"MemberId"`
+	Groups     []string `json:"Groups,omitempty"`
+	MemberName string   `json:"MemberName"`
+	Attribute  string   `json:"Attribute"`
+	Role       string   `json:"Role"`
+	Status     string   `json:"Status"`
+}
+
+// db 에서 cluster 의 member 목록을 가져온다.
+func ListDBMembers(namespace, cluster string) ([]DBMember, error) {
+	data, err := List(namespace, cluster)
+	if err != nil {
+		return nil, err
+	}
+	members := []DBMember{}
+	if err := json.Unmarshal(data, &members); err != nil {
+		return nil, err
+	}
+	return members, nil
+}
+
+// secret 의 key 로 bundle 을 암호화하는 aes-256 key 를 만든다.
+// 임의 길이의 passphrase 를 사용할 수 있도록 sha256 으로 key 를 만든다.
+func GetBackupEncryptionKey(ctx context.Context, c client.Reader, namespace, name string) ([]byte, error) {
+	secret := &coreV1.Secret{}
+	if err := c.Get(ctx, types.NamespacedName{Name: name, Namespace: namespace}, secret); err != nil {
+		return nil, err
+	}
+	value := secret.Data[BackupEncryptionKey]
+	if len(value) == 0 {
+		return nil, fmt.Errorf("secret %s does not have %s", name, BackupEncryptionKey)
+	}
+	sum := sha256.Sum256(value)
+	return sum[:], nil
+}
+
+// tenantKey 가 nil 이 아니면 bundle 의 key 가 노출되어도 복호화할 수 없도록 tenant key 로 암호화한다.
+func NewBackupSecret(ctx context.Context, secret *coreV1.Secret, key []byte, tenantKey *TenantKey) (BackupSecret, error) {
+	data, err := json.Marshal(secret.Data)
+	if err != nil {
+		return BackupSecret{}, err
+	}
+	var encrypted []byte
+	if tenantKey != nil {
+		encrypted, err = EncryptWithTenantKey(ctx, tenantKey, data)
+	} else {
+		encrypted, err = encryptBackupData(key, data)
+	}
+	if err != nil {
+		return BackupSecret{}, err
+	}
+	return BackupSecret{
+		Name:          secret.Name,
+		Namespace:     secret.Namespace,
+		Labels:        secret.Labels,
+		Annotations:   secret.Annotations,
+		Type:          secret.Type,
+		EncryptedData: encrypted,
+		TenantKey:     tenantKey,
+	}, nil
+}
+
+// bundle 의 secret 을 복호화하여 생성할 secret 을 만든다.
+func (s BackupSecret) ToSecret(ctx context.Context, key []byte) (*coreV1.Secret, error) {
+	var data []byte
+	var err error
+	if s.TenantKey != nil {
+		data, err = DecryptWithTenantKey(ctx, s.TenantKey, s.EncryptedData)
+		if errors.Is(err, ErrRemoteUnreachable) {
+			return nil, err
+		}
+	} else {
+		data, err = decryptBackupData(key, s.EncryptedData)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt secret %s/%s, the encryption key may be different: %w", s.Namespace, s.Name, err)
+	}
+	secret := &coreV1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        s.Name,
+			Namespace:   s.Namespace,
+			Labels:      s.Labels,
+			Annotations: s.Annotations,
+		},
+		Type: s.Type,
+	}
+	if err := json.Unmarshal(data, &secret.Data); err != nil {
+		return nil, err
+	}
+	return secret, nil
+}
+
+// nonce | aes-gcm 으로 암호화한 data 형식이다.
+func encryptBackupData(key, plaintext []byte) ([]byte, error) {
+	gcm, err := newBackupCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+	return gcm.Seal(nonce, nonce, plaintext, nil), nil
+}
+
+func decryptBackupData(key, data []byte) ([]byte, error) {
+	gcm, err := newBackupCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < gcm.NonceSize() {
+		return nil, fmt.Errorf("encrypted data is too short")
+	}
+	return gcm.Open(nil, data[:gcm.NonceSize()], data[gcm.NonceSize():], nil)
+}
+
+func newBackupCipher(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func MarshalBackupBundle(bundle *BackupBundle) ([]byte, error) {
+	data, err := json.Marshal(bundle)
+	if err != nil {
+		return nil, err
+	}
+	buf := &bytes.Buffer{}
+	w := gzip.NewWriter(buf)
+	if _, err := w.Write(data); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func UnmarshalBackupBundle(data []byte) (*BackupBundle, error) {
+	r, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	raw, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	bundle := &BackupBundle{}
+	if err := json.Unmarshal(raw, bundle); err != nil {
+		return nil, err
+	}
+	if bundle.Version != BackupBundleVersion {
+		return nil, fmt.Errorf("unsupported bundle version %d", bundle.Version)
+	}
+	return bundle, nil
+}
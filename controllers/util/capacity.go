@@ -0,0 +1,103 @@
+package util
+
+import (
+	"context"
+
+	clusterV1alpha1 "github.com/tmax-cloud/hypercloud-multi-operator/apis/cluster/v1alpha1"
+	coreV1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/client-go/kubernetes"
+)
+
+// capacity 로 집계하는 resource
+var capacityResources = []coreV1.ResourceName{coreV1.ResourceCPU, coreV1.ResourceMemory}
+
+// remote cluster 의 ready 이고 scheduling 가능한 worker node 의 allocatable 과
+// 그 node 에서 실행 중인 pod 의 request 를 합산한다.
+func AggregateClusterCapacity(ctx context.Context, clientset kubernetes.Interface) (*clusterV1alpha1.ClusterCapacity, error) {
+	nodeList, err := clientset.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	capacity := &clusterV1alpha1.ClusterCapacity{
+		Allocatable: coreV1.ResourceList{},
+		Requested:   coreV1.ResourceList{},
+	}
+	workers := map[string]bool{}
+	for i := range nodeList.Items {
+		node := &nodeList.Items[i]
+		if IsControlPlaneNode(node) || !IsNodeReady(node) || node.Spec.Unschedulable {
+			continue
+		}
+		workers[node.Name] = true
+		addResources(capacity.Allocatable, node.Status.Allocatable)
+	}
+	capacity.Workers = len(workers)
+
+	selector := fields.ParseSelectorOrDie("status.phase!=" + string(coreV1.PodSucceeded) + ",status.phase!=" + string(coreV1.PodFailed))
+	podList, err := clientset.CoreV1().Pods(metav1.NamespaceAll).List(ctx, metav1.ListOptions{FieldSelector: selector.String()})
+	if err != nil {
+		return nil, err
+	}
+	for i := range podList.Items {
+		pod := &podList.Items[i]
+		if workers[pod.Spec.NodeName] {
+			addResources(capacity.Requested, PodRequests(pod))
+		}
+	}
+
+	now := metav1.Now()
+	capacity.LastUpdateTime = &now
+	return capacity, nil
+}
+
+// capacity 로 집계하는 resource 만 더한다.
+func addResources(list, add coreV1.ResourceList) {
+	for _, name := range capacityResources {
+		if quantity, ok := add[name]; ok {
+			AddResourceList(list, coreV1.ResourceList{name: quantity})
+		}
+	}
+}
+
+func IsDaemonSetPod(pod *coreV1.Pod) bool {
+	owner := metav1.GetControllerOf(pod)
+	return owner != nil && owner.Kind == "DaemonSet"
+}
+
+// init container 는 순서대로 실행되므로 container 의 합과 가장 큰 init container 중 큰 값을 사용한다.
+func PodRequests(pod *coreV1.Pod) coreV1.ResourceList {
+	requests := coreV1.ResourceList{}
+	for _, container := range pod.Spec.Containers {
+		AddResourceList(requests, container.Resources.Requests)
+	}
+	for _, container := range pod.Spec.InitContainers {
+		for name, quantity := range container.Resources.Requests {
+			if value, ok := requests[name]; !ok || quantity.Cmp(value) > 0 {
+				requests[name] = quantity.DeepCopy()
+			}
+		}
+	}
+	return requests
+}
+
+func AddResourceList(list, add coreV1.ResourceList) {
+	for name, quantity := range add {
+		value := list[name]
+		value.Add(quantity)
+		list[name] = value
+	}
+}
+
+func SubtractResourceList(list, sub coreV1.ResourceList) {
+	for name, quantity := range sub {
+		value, ok := list[name]
+		if !ok {
+			value = resource.Quantity{}
+		}
+		value.Sub(quantity)
+		list[name] = value
+	}
+}
@@ -0,0 +1,71 @@
+package util
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"net"
+	"time"
+)
+
+const (
+	// 설정하지 않으면 30일 안에 만료되는 인증서를 경고한다.
+	certExpiryWarningThresholdDefault = 30 * 24 * time.Hour
+	// serving 인증서를 읽기 위한 tls handshake 의 timeout
+	servingCertificateTimeout = 5 * time.Second
+
+	// etcd 의 client port
+	EtcdClientPort = "2379"
+)
+
+// CERT_EXPIRY_WARNING_THRESHOLD 가 설정되지 않았거나 잘못된 경우 30일을 사용한다.
+func GetCertExpiryWarningThreshold() time.Duration {
+	if threshold, err := GetDurationEnv(CERT_EXPIRY_WARNING_THRESHOLD); err == nil && threshold > 0 {
+		return threshold
+	}
+	return certExpiryWarningThresholdDefault
+}
+
+// address 로 tls 연결을 맺어 server 의 leaf 인증서를 반환한다.
+// etcd 처럼 client 인증서를 요구하는 server 는 handshake 가 실패하지만, 그 전에 받은 server 인증서를 반환한다.
+func GetServingCertificate(address string) (*x509.Certificate, error) {
+	var leaf *x509.Certificate
+	config := &tls.Config{
+		// 인증서의 만료 시각만 확인하므로 검증하지 않는다.
+		InsecureSkipVerify: true,
+		VerifyPeerCertificate: func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+			if len(rawCerts) == 0 {
+				return nil
+			}
+			cert, err := x509.ParseCertificate(rawCerts[0])
+			if err != nil {
+				return err
+			}
+			leaf = cert
+			return nil
+		},
+	}
+
+	dialer := &net.Dialer{Timeout: servingCertificateTimeout}
+	conn, err := tls.DialWithDialer(dialer, "tcp", address, config)
+	if conn != nil {
+		conn.Close()
+	}
+	if leaf != nil {
+		return leaf, nil
+	}
+	if err == nil {
+		err = fmt.Errorf("%s did not present a certificate", address)
+	}
+	return nil, err
+}
+
+// PEM 형식의 인증서 중 첫 번째 인증서를 반환한다.
+func ParseCertificatePEM(data []byte) (*x509.Certificate, error) {
+	block, _ := pem.Decode(data)
+	if block == nil || block.Type != "CERTIFICATE" {
+		return nil, fmt.Errorf("no PEM encoded certificate")
+	}
+	return x509.ParseCertificate(block.Bytes)
+}
@@ -0,0 +1,230 @@
+package util
+
+import (
+	"context"
+	"encoding/json"
+	"regexp"
+	"sort"
+	"strings"
+
+	clusterV1alpha1 "github.com/tmax-cloud/hypercloud-multi-operator/apis/cluster/v1alpha1"
+	coreV1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"sigs.k8s.io/yaml"
+)
+
+// 등록된 cluster 의 kubernetes distribution
+const (
+	DistributionKubeadm = "kubeadm"
+	DistributionRKE2    = "rke2"
+	DistributionK3s     = "k3s"
+	DistributionEKS     = "eks"
+	DistributionGKE     = "gke"
+	DistributionAKS     = "aks"
+)
+
+// 등록된 cluster 를 관리하는 platform
+const ManagedByRancher = "rancher"
+
+const (
+	NodeRoleMaster = "master"
+	NodeRoleWorker = "worker"
+)
+
+const (
+	// rancher 의 cluster agent 가 설치되는 namespace
+	rancherNamespace = "cattle-system"
+	// capi 가 생성한 node 에 설정되는 machine 의 owner annotation
+	annotationKeyCapiOwnerKind = "cluster.x-k8s.io/owner-kind"
+	annotationKeyCapiOwnerName = "cluster.x-k8s.io/owner-name"
+)
+
+// managed cluster 가 node 에 설정하는 node group label
+var nodePoolLabelKeys = []string{
+	"eks.amazonaws.com/nodegroup",
+	"cloud.google.com/gke-nodepool",
+	"kubernetes.azure.com/agentpool",
+	"agentpool",
+}
+
+// 등록된 cluster 에서 조회한 metadata
+// kubeadm, rancher 등이 관리하는 cluster 를 등록하는 경우에도 생성한 cluster 와 같은 정보를 ClusterManager 에 채운다.
+type ClusterMetadata struct {
+	Version      string
+	Distribution string
+	ManagedBy    string
+	// node 의 providerID 나 kubeadm 의 cloud-provider 로 확인한 cloud provider
+	Provider  string
+	NodePools []clusterV1alpha1.NodePool
+
+	MasterNum int
+	MasterRun int
+	WorkerNum int
+	WorkerRun int
+}
+
+// remote cluster 의 kubeadm-config, version, node 로부터 metadata 를 조회한다.
+// kubeadm-config 가 없는 cluster (rke2, k3s, managed cluster 등) 는 api server 의 version 으로 distribution 을 판단한다.
+func ImportClusterMetadata(ctx context.Context, clientset kubernetes.Interface) (*ClusterMetadata, error) {
+	metadata := &ClusterMetadata{Provider: ProviderUnknown}
+
+	// kubeadm 으로 생성한 cluster 는 kube-system 의 kubeadm-config ConfigMap 이 있다.
+	kubeadmConfig, err := clientset.CoreV1().
+		ConfigMaps(KubeNamespace).
+		Get(ctx, "kubeadm-config", metav1.GetOptions{})
+	if err != nil && !errors.IsNotFound(err) {
+		return nil, err
+	} else if err == nil {
+		clusterConfiguration := kubeadmConfig.Data["ClusterConfiguration"]
+		jsonData, err := yaml.YAMLToJSON([]byte(clusterConfiguration))
+		if err != nil {
+			return nil, err
+		}
+		data := make(map[string]interface{})
+		if err := json.Unmarshal(jsonData, &data); err != nil {
+			return nil, err
+		}
+		metadata.Distribution = DistributionKubeadm
+		if version, ok := data["kubernetesVersion"].(string); ok {
+			metadata.Version = version
+		}
+
+		reg, _ := regexp.Compile(`cloud-provider: [a-zA-Z-_ ]+`)
+		if matchString := reg.FindString(clusterConfiguration); matchString != "" {
+			metadata.Provider, _ = GetProviderName(matchString[len("cloud-provider: "):])
+		}
+	}
+
+	serverVersion, err := clientset.Discovery().ServerVersion()
+	if err != nil {
+		return nil, err
+	}
+	if metadata.Version == "" {
+		metadata.Version = serverVersion.GitVersion
+	}
+	if metadata.Distribution == "" {
+		metadata.Distribution = distributionFromVersion(serverVersion.GitVersion)
+	}
+
+	if _, err := clientset.CoreV1().Namespaces().Get(ctx, rancherNamespace, metav1.GetOptions{}); err == nil {
+		metadata.ManagedBy = ManagedByRancher
+	} else if !errors.IsNotFound(err) {
+		return nil, err
+	}
+
+	nodeList, err := clientset.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	metadata.importNodes(nodeList.Items)
+	return metadata, nil
+}
+
+// version 에 distribution 별 suffix 가 붙는다. (예: v1.24.4+rke2r1, v1.24.4+k3s1, v1.24.7-eks-fb459a0, v1.24.5-gke.600)
+func distributionFromVersion(version string) string {
+	switch {
+	case strings.Contains(version, "+rke2"):
+		return DistributionRKE2
+	case strings.Contains(version, "+k3s"):
+		return DistributionK3s
+	case strings.Contains(version, "-eks-"):
+		return DistributionEKS
+	case strings.Contains(version, "-gke."):
+		return DistributionGKE
+	}
+	return ""
+}
+
+func (m *ClusterMetadata) importNodes(nodes []coreV1.Node) {
+	pools := map[string]*clusterV1alpha1.NodePool{}
+	for _, node := range nodes {
+		role := NodeRoleWorker
+		if IsControlPlaneNode(&node) {
+			role = NodeRoleMaster
+		}
+		ready := IsNodeReady(&node)
+		switch {
+		case role == NodeRoleMaster:
+			m.MasterNum++
+			if ready {
+				m.MasterRun++
+			}
+		default:
+			m.WorkerNum++
+			if ready {
+				m.WorkerRun++
+			}
+		}
+
+		if m.Provider == ProviderUnknown && node.Spec.ProviderID != "" {
+			m.Provider, _ = GetProviderName(strings.Split(node.Spec.ProviderID, "://")[0])
+		}
+		if m.Distribution == "" {
+			if _, ok := node.Labels["kubernetes.azure.com/cluster"]; ok {
+				m.Distribution = DistributionAKS
+			}
+		}
+
+		name := nodePoolName(&node, role)
+		pool, ok := pools[name]
+		if !ok {
+			pool = &clusterV1alpha1.NodePool{
+				Name:         name,
+				Role:         role,
+				InstanceType: node.Labels[coreV1.LabelInstanceTypeStable],
+			}
+			pools[name] = pool
+		}
+		pool.Replicas++
+		if ready {
+			pool.Ready++
+		}
+	}
+
+	m.NodePools = make([]clusterV1alpha1.NodePool, 0, len(pools))
+	for _, pool := range pools {
+		m.NodePools = append(m.NodePools, *pool)
+	}
+	sort.Slice(m.NodePools, func(i, j int) bool {
+		return m.NodePools[i].Name < m.NodePools[j].Name
+	})
+}
+
+// capi (rancher 포함) 가 생성한 node 는 machine 을 소유한 MachineSet, control plane 의 이름으로,
+// managed cluster 의 node 는 node group label 로 pool 을 구분한다.
+// 둘 다 없으면 role 이름을 pool 이름으로 사용한다.
+func nodePoolName(node *coreV1.Node, role string) string {
+	if owner := node.Annotations[annotationKeyCapiOwnerName]; owner != "" {
+		// MachineSet 의 이름은 <MachineDeployment>-<hash> 형식이다.
+		if node.Annotations[annotationKeyCapiOwnerKind] == "MachineSet" {
+			if i := strings.LastIndex(owner, "-"); i > 0 {
+				return owner[:i]
+			}
+		}
+		return owner
+	}
+	for _, key := range nodePoolLabelKeys {
+		if name := node.Labels[key]; name != "" {
+			return name
+		}
+	}
+	return role
+}
+
+// kubernetes 1.20 부터 master 대신 control-plane label 을 사용한다.
+func IsControlPlaneNode(node *coreV1.Node) bool {
+	_, isMaster := node.Labels["node-role.kubernetes.io/master"]
+	_, isControlPlane := node.Labels["node-role.kubernetes.io/control-plane"]
+	return isMaster || isControlPlane
+}
+
+func IsNodeReady(node *coreV1.Node) bool {
+	for _, condition := range node.Status.Conditions {
+		if condition.Type == coreV1.NodeReady {
+			return condition.Status == coreV1.ConditionTrue
+		}
+	}
+	return false
+}
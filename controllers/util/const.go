@@ -15,12 +15,14 @@ limitations under the License.
 package util
 
 const (
+	HypercloudNamespace    = "hypercloud5-system"
 	KubeNamespace          = "kube-system"
 	ApiGatewayNamespace    = "api-gateway-system"
 	IngressNginxNamespace  = "ingress-nginx"
 	ArgoNamespace          = "argocd"
 	HyperregistryNamespace = "hyperregistry"
 	OpenSearchNamespace    = "kube-logging"
+	CertManagerNamespace   = "cert-manager"
 )
 
 const (
@@ -32,7 +34,15 @@ const (
 )
 
 const (
-	KubeconfigSuffix = "-kubeconfig"
+	// cert-manager 가 발급한 webhook serving cert 를 가지고 있는 secret
+	WebhookServerCertSecret = "hypercloud-multi-operator-webhook-server-cert"
+	// cert-manager 가 발급한 authn broker serving cert 를 가지고 있는 secret
+	AuthnServerCertSecret = "hypercloud-multi-operator-authn-server-cert"
+)
+
+const (
+	KubeconfigSuffix        = "-kubeconfig"
+	LimitedKubeconfigSuffix = "-limited-kubeconfig"
 	// HypercloudIngressClass          = "tmax-cloud"
 	// HypercloudMultiIngressClass     = "multicluster"
 	// HypercloudMultiIngressSubdomain = "multicluster"
@@ -52,6 +62,36 @@ const (
 	ClmSecretTypeKubeconfig = "kubeconfig"
 	ClmSecretTypeArgo       = "argocd"
 	ClmSecretTypeSAToken    = "token"
+	// cluster 별 hyperauth client 의 secret
+	ClmSecretTypeHyperAuth = "hyperauth"
+	// member cluster 의 webhook token authentication 설정
+	ClmSecretTypeAuthnWebhook = "authn-webhook"
+	// 상태 확인용 읽기 전용 kubeconfig
+	ClmSecretTypeLimitedKubeconfig = "limited-kubeconfig"
+	// 생성하는 cluster 의 node 가 bootstrap 할 때 사용하는 containerd registry 설정
+	ClmSecretTypeRegistryConfig = "registry-config"
+	// 생성하는 cluster 의 kube-apiserver 가 사용하는 audit policy 와 webhook backend 설정
+	ClmSecretTypeAuditPolicy = "audit-policy"
+)
+
+const (
+	HubAttachModeKarmada = "karmada"
+	HubAttachModeOCM     = "ocm"
+
+	HubKubeconfigSecretDefault = "hub-kubeconfig"
+)
+
+const (
+	ImagePullSecretNamespaceDefault = "default"
+)
+
+const (
+	CertManagerACMEServerDefault = "https://acme-v02.api.letsencrypt.org/directory"
+	// cert-manager addon 이 member cluster 에 생성하는 ClusterIssuer 와 DNS01 credential secret
+	CertManagerClusterIssuer       = "hypercloud-dns01"
+	CertManagerCredentialsSecret   = "hypercloud-dns01-credentials"
+	CertManagerAccountKeySecret    = "hypercloud-dns01-account-key"
+	ArgoParameterCertManagerEnable = "modules.certManager.enabled"
 )
 
 const (
@@ -69,10 +109,32 @@ const (
 const (
 	AnnotationKeyOwner   = "owner"
 	AnnotationKeyCreator = "creator"
+	// cluster 를 소유한 team, argocd AppProject 의 group 으로 사용
+	AnnotationKeyTeam = "team"
 
 	AnnotationKeyArgoClusterSecret = "argocd.argoproj.io/cluster.secret"
 	AnnotationKeyArgoManagedBy     = "managed-by"
 	AnnotationKeyArgoSyncWave      = "argocd.argoproj.io/sync-wave"
+	// argocd cluster secret 에 동기화한 cluster manager 의 label key 목록
+	AnnotationKeyArgoSyncedLabels = "cluster.tmax.io/argocd-synced-labels"
+
+	// pipeline.Runner 가 마지막으로 실패한 reconcile 의 error 를 기록한다. 성공하면 삭제된다.
+	AnnotationKeyLastReconcileError = "cluster.tmax.io/last-reconcile-error"
+
+	// namespace 에 제출하는 cluster credential 과 backup 을 암호화하는 tenant key, 둘 중 하나만 설정할 수 있다.
+	AnnotationKeyTenantKMSKeyARN       = "cluster.tmax.io/kms-key-arn"
+	AnnotationKeyTenantVaultTransitKey = "cluster.tmax.io/vault-transit-key"
+
+	// ClusterImportSession 이 생성한 ClusterRegistration 에 session 이름을 기록한다.
+	AnnotationKeyImportSession = "cluster.tmax.io/import-session"
+
+	// operator 가 cloud api 로 생성한 managed cluster 의 kubeconfig secret 에 기록하는 인증 정보
+	// 이 annotation 이 없는 kubeconfig 의 exec plugin 은 사용하지 않는다.
+	AnnotationKeyManagedClusterAuth = "cluster.tmax.io/managed-cluster-auth"
+	AnnotationKeyEKSClusterARN      = "cluster.tmax.io/eks-cluster-arn"
+	AnnotationKeyEKSRoleARN         = "cluster.tmax.io/eks-role-arn"
+	// GKE, AKS 의 token 을 발급하는 credential secret 의 이름, kubeconfig secret 과 같은 namespace 에서만 찾는다.
+	AnnotationKeyCredentialsSecret = "cluster.tmax.io/credentials-secret"
 
 	AnnotationKeyTraefikServerTransport = "traefik.ingress.kubernetes.io/service.serverstransport"
 	AnnotationKeyTraefikEntrypoints     = "traefik.ingress.kubernetes.io/router.entrypoints"
@@ -84,16 +146,33 @@ const (
 	LabelKeyHypercloudIngress = "ingress.tmaxcloud.org/name"
 
 	LabelKeyClmSecretType = "cluster.tmax.io/clm-secret-type"
+	// member cluster 에 배포한 image pull secret 에 다는 label
+	LabelKeyImagePullSecret = "cluster.tmax.io/image-pull-secret"
+	// member cluster 에 배포한 default network policy 에 다는 label
+	LabelKeyDefaultNetworkPolicy = "cluster.tmax.io/default-network-policy"
 
 	LabelKeyArgoSecretType  = "argocd.argoproj.io/secret-type"
 	LabelKeyCapiClusterName = "cluster.x-k8s.io/cluster-name"
 
+	// namespace 를 처리할 operator shard 를 직접 지정할 때 namespace 에 다는 label
+	LabelKeyShard = "cluster.tmax.io/shard"
+
+	// member cluster 에 생성한 object 에 다는 label, remote garbage collector 가 삭제할 object 를 찾는 데 사용한다.
+	LabelKeyManagedBy      = "app.kubernetes.io/managed-by"
+	LabelKeyOwnerKind      = "cluster.tmax.io/owner-kind"
+	LabelKeyOwnerNamespace = "cluster.tmax.io/owner-namespace"
+	LabelKeyOwnerName      = "cluster.tmax.io/owner-name"
+
 	// LabelKeyArgoTargetCluster = "cluster.tmax.io/cluster"
 	LabelKeyArgoTargetCluster = "cluster"
 	LabelKeyArgoAppType       = "appType"
 	LabelKeyArgoAppInstance   = "app.kubernetes.io/instance"
 )
 
+const (
+	ManagedByOperator = "hypercloud-multi-operator"
+)
+
 const (
 	ArgoResourceFinalizers = "resources-finalizer.argocd.argoproj.io"
 )
@@ -134,6 +213,85 @@ const (
 	ARGO_APP_DELETE = "ARGO_APP_DELETE"
 	OIDC_CLIENT_SET = "OIDC_CLIENT_SET"
 	DEV_MODE        = "DEV_MODE"
+	HC_EVENT_PUSH   = "HC_EVENT_PUSH"
+	// cluster event 를 보낼 때 hypercloud api server 의 serving cert 를 검증하는 CA 파일 (설정하지 않으면 service account 의 ca.crt)
+	HC_API_SERVER_CA_FILE = "HC_API_SERVER_CA_FILE"
+	// member cluster 의 warning event 와 node 상태 변경을 management cluster 로 mirror 할지 여부
+	REMOTE_EVENT_MIRROR = "REMOTE_EVENT_MIRROR"
+	// member cluster 를 등록할 hub 의 종류 (karmada, ocm), 비어있거나 none 이면 등록하지 않는다.
+	HUB_ATTACH_MODE = "HUB_ATTACH_MODE"
+	// hub 의 kubeconfig 를 가지고 있는 secret 이름
+	HUB_KUBECONFIG_SECRET = "HUB_KUBECONFIG_SECRET"
+	// member cluster 에 배포할 image pull secret 이름 목록 (hypercloud5-system namespace, 콤마로 구분)
+	IMAGE_PULL_SECRETS = "IMAGE_PULL_SECRETS"
+	// image pull secret 을 배포할 member cluster 의 namespace 목록 (콤마로 구분)
+	IMAGE_PULL_SECRET_NAMESPACES = "IMAGE_PULL_SECRET_NAMESPACES"
+	// heartbeat 가 갱신되지 않은 채 이 시간이 지나면 cluster 를 Unreachable 로 판단한다. (예: 5m)
+	HEARTBEAT_STALE_THRESHOLD = "HEARTBEAT_STALE_THRESHOLD"
+	// webhook serving cert 의 만료까지 남은 기간이 이 일수보다 적으면 warning event 를 기록한다.
+	WEBHOOK_CERT_EXPIRY_WARNING_DAYS = "WEBHOOK_CERT_EXPIRY_WARNING_DAYS"
+	// Insert 요청을 모아서 db 에 쓰는 간격 (예: 1s)
+	DB_FLUSH_INTERVAL = "DB_FLUSH_INTERVAL"
+	// fleet inventory 를 backstage catalog 로 ConfigMap 에 export 하는 간격 (예: 5m, 설정하지 않으면 export 하지 않음)
+	CATALOG_EXPORT_INTERVAL = "CATALOG_EXPORT_INTERVAL"
+	// 멤버용 kubeconfig 가 hyperauth 에서 token 을 발급받을 때 사용하는 client id (설정하지 않으면 hypercloud5)
+	KUBECONFIG_OIDC_CLIENT_ID = "KUBECONFIG_OIDC_CLIENT_ID"
+	// default network policy 를 배포할 member cluster 의 namespace 목록 (콤마로 구분, 설정하지 않으면 배포하지 않음)
+	DEFAULT_NETWORK_POLICY_NAMESPACES = "DEFAULT_NETWORK_POLICY_NAMESPACES"
+	// default network policy 에서 ingress 를 허용할 platform namespace 목록 (콤마로 구분)
+	DEFAULT_NETWORK_POLICY_PLATFORM_NAMESPACES = "DEFAULT_NETWORK_POLICY_PLATFORM_NAMESPACES"
+	// cert-manager addon 의 ClusterIssuer 가 사용하는 ACME server (설정하지 않으면 letsencrypt production)
+	CERT_MANAGER_ACME_SERVER = "CERT_MANAGER_ACME_SERVER"
+	// License 의 token 서명을 검증하는 ed25519 public key (base64)
+	LICENSE_PUBLIC_KEY = "LICENSE_PUBLIC_KEY"
+	// controller 가 주기적으로 다시 reconcile 하는 간격 (예: 1m, 설정하지 않으면 1m)
+	RESYNC_PERIOD = "RESYNC_PERIOD"
+	// member cluster 의 api server 가 token 인증을 요청할 authn broker 의 외부 https 주소 (설정하지 않으면 webhook 설정을 생성하지 않음)
+	// broker 의 serving cert 가 이 주소의 host 를 포함해야 한다.
+	AUTHN_WEBHOOK_URL = "AUTHN_WEBHOOK_URL"
+	// instance type 별 시간당 가격을 가지고 있는 configmap 이름 (hypercloud5-system namespace, 설정하지 않으면 비용을 집계하지 않음)
+	COST_PRICING_CONFIGMAP = "COST_PRICING_CONFIGMAP"
+	// argocd 에 직접 등록된 cluster 의 ClusterRegistration 을 생성할 namespace (설정하지 않으면 import 하지 않음)
+	ARGOCD_IMPORT_NAMESPACE = "ARGOCD_IMPORT_NAMESPACE"
+	// argocd cluster secret 에 owner annotation 이 없을 때 import 한 cluster 의 owner 로 기록할 사용자
+	ARGOCD_IMPORT_OWNER = "ARGOCD_IMPORT_OWNER"
+	// 사용자별로 REGISTRATION_FAILURE_WINDOW 동안 검증에 실패한 ClusterRegistration 이 이 수 이상이면 생성을 거부한다. (설정하지 않으면 5, 음수이면 제한하지 않음)
+	REGISTRATION_FAILURE_LIMIT = "REGISTRATION_FAILURE_LIMIT"
+	// 실패한 ClusterRegistration 을 집계하는 기간 (예: 10m, 설정하지 않으면 10m)
+	REGISTRATION_FAILURE_WINDOW = "REGISTRATION_FAILURE_WINDOW"
+	// ClusterClaim 의 target namespace 가 없으면 승인할 때 namespace 를 생성할지 여부
+	CLAIM_NAMESPACE_PROVISIONING = "CLAIM_NAMESPACE_PROVISIONING"
+	// 생성한 namespace 에 복사할 ResourceQuota 이름 (hypercloud5-system namespace, 설정하지 않으면 quota 를 생성하지 않음)
+	CLAIM_NAMESPACE_RESOURCE_QUOTA = "CLAIM_NAMESPACE_RESOURCE_QUOTA"
+	// 지원하는 kubernetes minor version 목록 (콤마로 구분, 예: 1.25,1.26, 설정하지 않으면 version 을 검사하지 않음)
+	SUPPORTED_KUBERNETES_VERSIONS = "SUPPORTED_KUBERNETES_VERSIONS"
+	// 삭제가 시작된 후 실패한 finalizer 단계를 건너뛰기까지 기다리는 시간 (예: 1h, 설정하지 않으면 건너뛰지 않음)
+	FINALIZER_TIMEOUT = "FINALIZER_TIMEOUT"
+	// 생성하는 cluster 의 containerd 에 적용할 registry mirror, 인증, CA 설정을 가지고 있는 secret 이름 (hypercloud5-system namespace, 설정하지 않으면 적용하지 않음)
+	REGISTRY_CONFIG_SECRET = "REGISTRY_CONFIG_SECRET"
+	// 인터넷에 접근할 수 없는 환경에서 cluster 를 생성할지 여부, 설정하면 내부 registry, mirror, repo 만 사용한다.
+	AIR_GAPPED = "AIR_GAPPED"
+	// air-gapped 환경에서 kubernetes image 를 받을 내부 registry (예: harbor.example.com/k8s)
+	AIR_GAPPED_IMAGE_REPOSITORY = "AIR_GAPPED_IMAGE_REPOSITORY"
+	// air-gapped 환경에서 node 가 cni manifest 등의 file 을 받을 내부 mirror, upstream 과 같은 경로로 file 을 제공해야 한다. (예: https://mirror.example.com)
+	AIR_GAPPED_ARTIFACT_MIRROR = "AIR_GAPPED_ARTIFACT_MIRROR"
+	// air-gapped 환경에서 addon 을 설치하는 argocd application 이 chart 를 받을 내부 git repo
+	AIR_GAPPED_CHART_REPO = "AIR_GAPPED_CHART_REPO"
+	// 이 기간 안에 만료되는 cluster 의 인증서를 경고한다. (예: 720h, 설정하지 않으면 720h)
+	CERT_EXPIRY_WARNING_THRESHOLD = "CERT_EXPIRY_WARNING_THRESHOLD"
+	// fleet 전체에 적용하는 변경 (FederatedRoleBinding, ClusterPolicy, WorkloadDistribution) 을 member cluster 에 적용하는
+	// 작업의 동시 실행 수, 초당 시작 수, reconcile 한 번에 처리하는 cluster 수 (설정하지 않으면 10, 5, 50)
+	FANOUT_CONCURRENCY = "FANOUT_CONCURRENCY"
+	FANOUT_QPS         = "FANOUT_QPS"
+	FANOUT_BATCH_SIZE  = "FANOUT_BATCH_SIZE"
+	// namespace 에 vault transit key 를 tenant key 로 설정한 경우 사용하는 vault 의 주소 (예: https://vault.example.com:8200)
+	VAULT_ADDR = "VAULT_ADDR"
+	// vault 의 kubernetes auth 로 로그인할 때 사용하는 role
+	VAULT_AUTH_ROLE = "VAULT_AUTH_ROLE"
+	// transit secrets engine 의 mount 경로 (설정하지 않으면 transit)
+	VAULT_TRANSIT_MOUNT = "VAULT_TRANSIT_MOUNT"
+	// EKS cluster 에 접근할 때 operator 가 assume 할 수 있는 IAM role ARN 목록 (콤마로 구분, 설정하지 않으면 EKS cluster 를 등록할 수 없음)
+	EKS_ALLOWED_ROLE_ARNS = "EKS_ALLOWED_ROLE_ARNS"
 )
 
 func GetRequiredEnvPreset() []string {
@@ -0,0 +1,88 @@
+package util
+
+import (
+	"context"
+
+	clusterV1alpha1 "github.com/tmax-cloud/hypercloud-multi-operator/apis/cluster/v1alpha1"
+	coreV1 "k8s.io/api/core/v1"
+	rbacV1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// cluster 마다 두 가지 kubeconfig 를 가진다.
+// admin 은 cluster 생성, 삭제, addon 배포 등 lifecycle 작업에만 사용하고,
+// 주기적인 상태 확인은 limited 로 수행한다.
+type CredentialLevel string
+
+const (
+	CredentialAdmin   = CredentialLevel("admin")
+	CredentialLimited = CredentialLevel("limited")
+)
+
+const (
+	// limited kubeconfig 에 사용하는 member cluster 의 service account, token secret, cluster role (kube-system namespace)
+	LimitedServiceAccount            = "hypercloud-multi-operator-limited"
+	LimitedServiceAccountTokenSecret = "hypercloud-multi-operator-limited-token"
+	LimitedClusterRole               = "hypercloud-multi-operator-limited"
+	LimitedClusterRoleBinding        = "hypercloud-multi-operator-limited"
+)
+
+// limited kubeconfig 에 부여하는 권한
+// health check 와 version 확인에 필요한 조회 권한만 가진다.
+func LimitedClusterRoleRules() []rbacV1.PolicyRule {
+	return []rbacV1.PolicyRule{
+		{
+			APIGroups: []string{""},
+			Resources: []string{"nodes", "namespaces"},
+			Verbs:     []string{"get", "list", "watch"},
+		},
+		{
+			NonResourceURLs: []string{"/version", "/healthz", "/livez", "/readyz"},
+			Verbs:           []string{"get"},
+		},
+	}
+}
+
+// admin kubeconfig 의 cluster 정보에 service account token 을 사용하는 kubeconfig 를 생성한다.
+func NewTokenKubeconfig(admin *Kubeconfig, name, token string) ([]byte, error) {
+	cluster := admin.Config.Clusters[admin.Config.Contexts[admin.Config.CurrentContext].Cluster]
+
+	config := clientcmdapi.NewConfig()
+	config.Clusters[name] = cluster.DeepCopy()
+	config.AuthInfos[name] = &clientcmdapi.AuthInfo{
+		Token: token,
+	}
+	config.Contexts[name] = &clientcmdapi.Context{
+		Cluster:  name,
+		AuthInfo: name,
+	}
+	config.CurrentContext = name
+	return clientcmd.Write(*config)
+}
+
+// level 에 맞는 cluster 의 kubeconfig secret 을 찾는다.
+// limited kubeconfig 가 아직 생성되지 않은 cluster 는 admin kubeconfig 를 사용한다.
+func GetCredentialSecret(ctx context.Context, c client.Reader, namespace, clusterName string, level CredentialLevel) (*coreV1.Secret, error) {
+	if level == CredentialLimited {
+		secret := &coreV1.Secret{}
+		key := types.NamespacedName{Name: clusterName + LimitedKubeconfigSuffix, Namespace: namespace}
+		if err := c.Get(ctx, key, secret); err == nil {
+			return secret, nil
+		} else if !errors.IsNotFound(err) {
+			return nil, err
+		}
+	}
+	return GetKubeconfigSecret(ctx, c, namespace, clusterName)
+}
+
+// kubeconfig secret 이 가리키는 cluster manager 의 이름 (limited kubeconfig 포함)
+func credentialClusterName(secret *coreV1.Secret) string {
+	if secret.Labels[LabelKeyClmSecretType] == ClmSecretTypeLimitedKubeconfig {
+		return secret.Labels[clusterV1alpha1.LabelKeyClmName]
+	}
+	return KubeconfigClusterName(secret)
+}
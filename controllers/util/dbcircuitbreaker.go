@@ -0,0 +1,121 @@
+package util
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/tmax-cloud/hypercloud-multi-operator/controllers/metrics"
+)
+
+const (
+	// 연속으로 실패하면 circuit 을 여는 db 요청의 수
+	dbCircuitFailureThreshold = 5
+	// circuit 을 연 뒤 다시 요청을 보내보기까지 기다리는 시간
+	dbCircuitOpenTimeout = 30 * time.Second
+)
+
+var errDBCircuitOpen = errors.New("db circuit is open")
+
+type circuitState int
+
+const (
+	circuitClosed circuitState = iota
+	circuitOpen
+	// open timeout 이 지나 요청 하나만 보내 db 가 복구되었는지 확인하는 상태
+	circuitHalfOpen
+)
+
+// DBCircuitBreaker 는 db 가 응답하지 않을 때 모든 reconciler 가 request timeout 만큼 기다리지 않도록
+// 연속된 실패가 threshold 를 넘으면 open timeout 동안 db 요청을 보내지 않고 바로 ErrDBUnavailable 을 반환한다.
+type DBCircuitBreaker struct {
+	FailureThreshold int
+	OpenTimeout      time.Duration
+
+	mu       sync.Mutex
+	state    circuitState
+	failures int
+	openedAt time.Time
+}
+
+// 모든 db 요청이 공유하는 circuit breaker
+var dbCircuitBreaker = &DBCircuitBreaker{
+	FailureThreshold: dbCircuitFailureThreshold,
+	OpenTimeout:      dbCircuitOpenTimeout,
+}
+
+// circuit 이 닫혀있으면 fn 을 수행하고 결과에 따라 circuit 의 상태를 바꾼다.
+// ErrDBUnavailable 이 아닌 error 는 db 가 응답한 것이므로 실패로 세지 않는다.
+func (b *DBCircuitBreaker) Do(fn func() error) error {
+	if err := b.allow(); err != nil {
+		return err
+	}
+	err := fn()
+	b.record(errors.Is(err, ErrDBUnavailable))
+	return err
+}
+
+func (b *DBCircuitBreaker) allow() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case circuitOpen:
+		if time.Since(b.openedAt) < b.OpenTimeout {
+			return NewError(ErrDBUnavailable, errDBCircuitOpen)
+		}
+		b.state = circuitHalfOpen
+		return nil
+	case circuitHalfOpen:
+		// 확인 중인 요청의 결과가 나올 때까지 다른 요청은 보내지 않는다.
+		return NewError(ErrDBUnavailable, fmt.Errorf("%w: probing db", errDBCircuitOpen))
+	}
+	return nil
+}
+
+func (b *DBCircuitBreaker) record(failed bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if !failed {
+		b.failures = 0
+		b.setState(circuitClosed)
+		return
+	}
+	b.failures++
+	if b.state == circuitHalfOpen || b.failures >= b.FailureThreshold {
+		b.openedAt = time.Now()
+		b.setState(circuitOpen)
+	}
+}
+
+func (b *DBCircuitBreaker) setState(state circuitState) {
+	b.state = state
+	if state == circuitClosed {
+		metrics.DBCircuitOpen.Set(0)
+	} else {
+		metrics.DBCircuitOpen.Set(1)
+	}
+}
+
+// circuit 이 열려있으면 다시 요청을 보낼 수 있을 때까지 남은 시간을 반환한다. 닫혀있으면 0 을 반환한다.
+func (b *DBCircuitBreaker) RetryAfter() time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case circuitOpen:
+		if remaining := b.OpenTimeout - time.Since(b.openedAt); remaining > 0 {
+			return remaining
+		}
+	case circuitHalfOpen:
+		return time.Second
+	}
+	return 0
+}
+
+// 공유 circuit breaker 가 db 요청을 막고 있으면 다시 시도할 수 있을 때까지 남은 시간을 반환한다.
+func DBRetryAfter() time.Duration {
+	return dbCircuitBreaker.RetryAfter()
+}
@@ -0,0 +1,137 @@
+package util
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestDBCircuitBreaker(t *testing.T) {
+	errUnavailable := NewError(ErrDBUnavailable, errors.New("connection refused"))
+	errRejected := errors.New("bad request")
+
+	// step 마다 요청을 보내고, 요청이 수행되었는지와 이후의 상태를 확인한다.
+	type step struct {
+		// 요청 전에 open timeout 이 지난 것으로 만든다.
+		expire bool
+		err    error
+		// 요청이 circuit 에 막혀 fn 이 수행되지 않아야 하는 경우
+		wantBlocked bool
+		wantState   circuitState
+	}
+	tests := []struct {
+		name  string
+		steps []step
+	}{
+		{
+			name: "open after threshold",
+			steps: []step{
+				{err: errUnavailable, wantState: circuitClosed},
+				{err: errUnavailable, wantState: circuitClosed},
+				{err: errUnavailable, wantState: circuitOpen},
+				{wantBlocked: true, wantState: circuitOpen},
+			},
+		},
+		{
+			name: "success resets failures",
+			steps: []step{
+				{err: errUnavailable, wantState: circuitClosed},
+				{err: errUnavailable, wantState: circuitClosed},
+				{wantState: circuitClosed},
+				{err: errUnavailable, wantState: circuitClosed},
+				{err: errUnavailable, wantState: circuitClosed},
+			},
+		},
+		{
+			name: "db response is not a failure",
+			steps: []step{
+				{err: errRejected, wantState: circuitClosed},
+				{err: errRejected, wantState: circuitClosed},
+				{err: errRejected, wantState: circuitClosed},
+			},
+		},
+		{
+			name: "close after successful probe",
+			steps: []step{
+				{err: errUnavailable},
+				{err: errUnavailable},
+				{err: errUnavailable, wantState: circuitOpen},
+				{expire: true, wantState: circuitClosed},
+				{wantState: circuitClosed},
+			},
+		},
+		{
+			name: "reopen after failed probe",
+			steps: []step{
+				{err: errUnavailable},
+				{err: errUnavailable},
+				{err: errUnavailable, wantState: circuitOpen},
+				{expire: true, err: errUnavailable, wantState: circuitOpen},
+				{wantBlocked: true, wantState: circuitOpen},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := &DBCircuitBreaker{
+				FailureThreshold: 3,
+				OpenTimeout:      time.Minute,
+			}
+			for i, s := range tt.steps {
+				if s.expire {
+					b.openedAt = time.Now().Add(-b.OpenTimeout)
+				}
+				called := false
+				err := b.Do(func() error {
+					called = true
+					return s.err
+				})
+				if called == s.wantBlocked {
+					t.Fatalf("step %d: called = %v, want blocked %v", i, called, s.wantBlocked)
+				}
+				if s.wantBlocked && !errors.Is(err, ErrDBUnavailable) {
+					t.Errorf("step %d: blocked error = %v, want ErrDBUnavailable", i, err)
+				}
+				if !s.wantBlocked && err != s.err {
+					t.Errorf("step %d: error = %v, want %v", i, err, s.err)
+				}
+				if b.state != s.wantState {
+					t.Errorf("step %d: state = %v, want %v", i, b.state, s.wantState)
+				}
+			}
+		})
+	}
+}
+
+func TestDBCircuitBreakerHalfOpen(t *testing.T) {
+	b := &DBCircuitBreaker{
+		FailureThreshold: 1,
+		OpenTimeout:      time.Minute,
+	}
+	_ = b.Do(func() error { return NewError(ErrDBUnavailable, errors.New("connection refused")) })
+	if got := b.RetryAfter(); got <= 0 || got > time.Minute {
+		t.Errorf("RetryAfter() = %v, want in (0, 1m]", got)
+	}
+
+	// 확인 중인 요청이 끝나기 전의 요청은 막는다.
+	b.openedAt = time.Now().Add(-b.OpenTimeout)
+	err := b.Do(func() error {
+		if b.state != circuitHalfOpen {
+			t.Errorf("state = %v, want half open", b.state)
+		}
+		if err := b.Do(func() error { return nil }); !errors.Is(err, ErrDBUnavailable) {
+			t.Errorf("request during probe error = %v, want ErrDBUnavailable", err)
+		}
+		if got := b.RetryAfter(); got != time.Second {
+			t.Errorf("RetryAfter() during probe = %v, want 1s", got)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("probe error = %v", err)
+	}
+	if got := b.RetryAfter(); got != 0 {
+		t.Errorf("RetryAfter() = %v, want 0", got)
+	}
+}
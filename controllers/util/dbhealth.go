@@ -0,0 +1,90 @@
+package util
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/tmax-cloud/hypercloud-multi-operator/controllers/metrics"
+)
+
+const (
+	dbHealthCheckInterval = 30 * time.Second
+	// metrics server 에서 db 의 readiness 를 제공하는 path
+	DBReadyzPath = "/readyz/db"
+)
+
+var errDBNotChecked = errors.New("db has not been checked yet")
+
+// DBHealthMonitor 는 주기적으로 db 에 접근 가능한지 확인하여 metric 과 DBReadyzPath 의 readiness 로 노출한다.
+// db 장애로 pod 가 unready 가 되면 webhook 의 endpoint 가 제거되어 모든 CR 의 생성, 수정이 거부되므로
+// pod 의 readiness probe 에는 포함하지 않는다. db 에 쓰지 못한 요청은 PendingDBWrite 로 저장되고 DBDegraded condition 으로 표시된다.
+type DBHealthMonitor struct {
+	Interval time.Duration
+	Log      logr.Logger
+
+	mu        sync.Mutex
+	available *bool
+	lastErr   error
+}
+
+func (m *DBHealthMonitor) Start(ctx context.Context) error {
+	if m.Interval <= 0 {
+		m.Interval = dbHealthCheckInterval
+	}
+
+	ticker := time.NewTicker(m.Interval)
+	defer ticker.Stop()
+
+	for {
+		m.check()
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// 각 replica 의 metric 으로 노출하므로 leader 가 아니어도 동작한다.
+func (m *DBHealthMonitor) NeedLeaderElection() bool {
+	return false
+}
+
+// 마지막으로 확인한 db 의 상태를 반환한다. 요청마다 db 에 접근하지 않도록 확인한 결과를 사용한다.
+func (m *DBHealthMonitor) Checker(_ *http.Request) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.available == nil {
+		return errDBNotChecked
+	}
+	return m.lastErr
+}
+
+func (m *DBHealthMonitor) check() {
+	err := CheckDBHealth()
+	available := err == nil
+	if available {
+		metrics.DBAvailable.Set(1)
+	} else {
+		metrics.DBAvailable.Set(0)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.lastErr = err
+	// 상태가 바뀐 경우에만 기록한다.
+	if m.available != nil && *m.available == available {
+		return
+	}
+	m.available = &available
+	if available {
+		m.Log.Info("DB is available")
+	} else {
+		m.Log.Info("DB is unavailable", "reason", err.Error())
+	}
+}
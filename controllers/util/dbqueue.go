@@ -0,0 +1,170 @@
+package util
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+
+	clusterV1alpha1 "github.com/tmax-cloud/hypercloud-multi-operator/apis/cluster/v1alpha1"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+// db 에 쓰지 못한 membership 요청은 PendingDBWrite 로 저장하고 reconcile 을 계속 진행한다.
+// 저장된 요청은 PendingDBWriteReconciler 가 db 가 복구되면 다시 쓴다.
+
+// cluster 정보를 db 에 쓰고, db 에 접근할 수 없으면 요청을 저장한다.
+func InsertOrQueue(ctx context.Context, c client.Client, clusterManager *clusterV1alpha1.ClusterManager) error {
+	write := newPendingDBWrite(clusterManager.Namespace, clusterManager.Name, clusterV1alpha1.PendingDBWriteOperationInsertCluster, nil)
+	return writeOrQueue(ctx, c, write, func() error {
+		return Insert(clusterManager)
+	})
+}
+
+// cluster 정보를 db 에서 삭제하고, db 에 접근할 수 없으면 요청을 저장한다.
+func DeleteOrQueue(ctx context.Context, c client.Client, namespace, cluster string) error {
+	write := newPendingDBWrite(namespace, cluster, clusterV1alpha1.PendingDBWriteOperationDeleteCluster, nil)
+	return writeOrQueue(ctx, c, write, func() error {
+		return Delete(namespace, cluster)
+	})
+}
+
+// member 정보를 db 에 쓰고, db 에 접근할 수 없으면 요청을 저장한다.
+func InsertMemberOrQueue(ctx context.Context, c client.Client, clusterMember *clusterV1alpha1.ClusterMember) error {
+	write := newPendingDBWrite(clusterMember.Namespace, clusterMember.Spec.ClusterName, clusterV1alpha1.PendingDBWriteOperationInsertMember, &clusterMember.Spec)
+	return writeOrQueue(ctx, c, write, func() error {
+		return InsertMember(clusterMember)
+	})
+}
+
+// role 이 변경된 member 정보를 db 에 다시 쓰고, db 에 접근할 수 없으면 요청을 저장한다.
+// 아직 쓰지 못한 insert 요청이 저장되어 있으면 변경된 role 로 insert 하도록 insert 요청을 유지한다.
+func UpdateMemberOrQueue(ctx context.Context, c client.Client, clusterMember *clusterV1alpha1.ClusterMember) error {
+	write := newPendingDBWrite(clusterMember.Namespace, clusterMember.Spec.ClusterName, clusterV1alpha1.PendingDBWriteOperationUpdateMember, &clusterMember.Spec)
+	queued := &clusterV1alpha1.PendingDBWrite{}
+	if err := c.Get(ctx, write.GetNamespacedName(), queued); err == nil &&
+		queued.Spec.Operation == clusterV1alpha1.PendingDBWriteOperationInsertMember {
+		write.Spec.Operation = clusterV1alpha1.PendingDBWriteOperationInsertMember
+	} else if err != nil && !k8sErrors.IsNotFound(err) {
+		return err
+	}
+	return writeOrQueue(ctx, c, write, func() error {
+		if write.Spec.Operation == clusterV1alpha1.PendingDBWriteOperationInsertMember {
+			return InsertMember(clusterMember)
+		}
+		return UpdateMember(clusterMember)
+	})
+}
+
+// member 정보를 db 에서 삭제하고, db 에 접근할 수 없으면 요청을 저장한다.
+func DeleteMemberOrQueue(ctx context.Context, c client.Client, clusterMember *clusterV1alpha1.ClusterMember) error {
+	write := newPendingDBWrite(clusterMember.Namespace, clusterMember.Spec.ClusterName, clusterV1alpha1.PendingDBWriteOperationDeleteMember, &clusterMember.Spec)
+	return writeOrQueue(ctx, c, write, func() error {
+		return DeleteMember(clusterMember.Namespace, clusterMember.Spec.ClusterName, clusterMember.Spec.MemberId, clusterMember.Spec.Attribute)
+	})
+}
+
+// 저장된 요청을 db 에 다시 쓴다. cluster 정보는 저장된 시점이 아닌 현재의 cluster manager 로 쓴다.
+// cluster manager 가 이미 삭제된 경우에는 삭제 요청이 따로 저장되므로 아무것도 하지 않는다.
+func ReplayPendingDBWrite(ctx context.Context, c client.Reader, write *clusterV1alpha1.PendingDBWrite) error {
+	switch write.Spec.Operation {
+	case clusterV1alpha1.PendingDBWriteOperationInsertCluster:
+		clm := &clusterV1alpha1.ClusterManager{}
+		key := client.ObjectKey{Name: write.Spec.ClusterName, Namespace: write.Namespace}
+		if err := c.Get(ctx, key, clm); k8sErrors.IsNotFound(err) {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		return Insert(clm)
+	case clusterV1alpha1.PendingDBWriteOperationDeleteCluster:
+		return Delete(write.Namespace, write.Spec.ClusterName)
+	case clusterV1alpha1.PendingDBWriteOperationInsertMember, clusterV1alpha1.PendingDBWriteOperationUpdateMember, clusterV1alpha1.PendingDBWriteOperationDeleteMember:
+		if write.Spec.Member == nil {
+			return errors.New("spec.member is required for member operations")
+		}
+		member := &clusterV1alpha1.ClusterMember{
+			ObjectMeta: metav1.ObjectMeta{Namespace: write.Namespace},
+			Spec:       *write.Spec.Member,
+		}
+		switch write.Spec.Operation {
+		case clusterV1alpha1.PendingDBWriteOperationInsertMember:
+			return InsertMember(member)
+		case clusterV1alpha1.PendingDBWriteOperationUpdateMember:
+			return UpdateMember(member)
+		}
+		return DeleteMember(member.Namespace, member.Spec.ClusterName, member.Spec.MemberId, member.Spec.Attribute)
+	}
+	return errors.New("unsupported operation: " + string(write.Spec.Operation))
+}
+
+// 같은 cluster 나 member 에 대한 요청은 하나의 PendingDBWrite 에 마지막 요청만 저장한다.
+func newPendingDBWrite(namespace, cluster string, operation clusterV1alpha1.PendingDBWriteOperation, member *clusterV1alpha1.ClusterMemberSpec) *clusterV1alpha1.PendingDBWrite {
+	name := "cluster-" + cluster
+	if member != nil {
+		// member id 는 email 일 수 있으므로 hash 로 이름을 만든다.
+		sum := sha256.Sum256([]byte(cluster + "/" + member.Attribute + "/" + member.MemberId))
+		name = "member-" + cluster + "-" + hex.EncodeToString(sum[:])[:10]
+		member = member.DeepCopy()
+	}
+	return &clusterV1alpha1.PendingDBWrite{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+			Labels: map[string]string{
+				clusterV1alpha1.LabelKeyClmName: cluster,
+			},
+		},
+		Spec: clusterV1alpha1.PendingDBWriteSpec{
+			Operation:   operation,
+			ClusterName: cluster,
+			Member:      member,
+		},
+	}
+}
+
+func writeOrQueue(ctx context.Context, c client.Client, write *clusterV1alpha1.PendingDBWrite, fn func() error) error {
+	err := fn()
+	if err == nil {
+		// 이전에 저장된 요청이 나중에 다시 쓰여 이번 요청을 덮어쓰지 않도록 삭제한다.
+		stale := &clusterV1alpha1.PendingDBWrite{}
+		if err := c.Get(ctx, write.GetNamespacedName(), stale); k8sErrors.IsNotFound(err) {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		if err := c.Delete(ctx, stale); err != nil && !k8sErrors.IsNotFound(err) {
+			return err
+		}
+		return nil
+	}
+	if !errors.Is(err, ErrDBUnavailable) {
+		return err
+	}
+
+	queued := &clusterV1alpha1.PendingDBWrite{ObjectMeta: write.ObjectMeta}
+	if _, qerr := controllerutil.CreateOrUpdate(ctx, c, queued, func() error {
+		queued.Labels = write.Labels
+		queued.Spec = write.Spec
+		return nil
+	}); qerr != nil {
+		return utilerrors.NewAggregate([]error{err, qerr})
+	}
+	return nil
+}
+
+// cluster 에 대해 저장된 요청의 목록을 반환한다.
+func ListPendingDBWrites(ctx context.Context, c client.Reader, namespace, cluster string) ([]clusterV1alpha1.PendingDBWrite, error) {
+	writes := &clusterV1alpha1.PendingDBWriteList{}
+	if err := c.List(ctx, writes,
+		client.InNamespace(namespace),
+		client.MatchingLabels{clusterV1alpha1.LabelKeyClmName: cluster},
+	); err != nil {
+		return nil, err
+	}
+	return writes.Items, nil
+}
@@ -0,0 +1,224 @@
+package util
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	clusterV1alpha1 "github.com/tmax-cloud/hypercloud-multi-operator/apis/cluster/v1alpha1"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// db 요청을 보내는 대신 method 와 path 를 기록하고 status 로 응답하도록 dbHTTPClient 를 바꾼다.
+// status 가 0 이면 db 에 접근할 수 없는 것처럼 transport error 를 반환한다.
+func fakeDB(t *testing.T, status int) *[]string {
+	requests := []string{}
+	transport := dbHTTPClient.Transport
+	dbHTTPClient.Transport = roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		requests = append(requests, req.Method+" "+req.URL.EscapedPath())
+		if status == 0 {
+			return nil, errors.New("connection refused")
+		}
+		return &http.Response{
+			StatusCode: status,
+			Status:     http.StatusText(status),
+			Body:       ioutil.NopCloser(strings.NewReader("")),
+		}, nil
+	})
+	t.Cleanup(func() {
+		dbHTTPClient.Transport = transport
+		// 다른 test 에 영향을 주지 않도록 공유 circuit breaker 를 닫는다.
+		dbCircuitBreaker.record(false)
+	})
+	return &requests
+}
+
+func newTestDBClient(t *testing.T, objects ...client.Object) client.Client {
+	scheme := runtime.NewScheme()
+	if err := clusterV1alpha1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	return fake.NewClientBuilder().WithScheme(scheme).WithObjects(objects...).Build()
+}
+
+func newTestMember() *clusterV1alpha1.ClusterMember {
+	return &clusterV1alpha1.ClusterMember{
+		ObjectMeta: metav1.ObjectMeta{Name: "member", Namespace: "test"},
+		Spec: clusterV1alpha1.ClusterMemberSpec{
+			ClusterName: "cluster",
+			MemberId:    "user@tmax.co.kr",
+			Attribute:   clusterV1alpha1.ClusterMemberAttributeUser,
+			Role:        clusterV1alpha1.ClusterMemberRoleDeveloper,
+		},
+	}
+}
+
+func TestReplayPendingDBWrite(t *testing.T) {
+	member := newTestMember()
+	memberPath := "/namespaces/test/clustermanagers/cluster/member/user@tmax.co.kr"
+
+	tests := []struct {
+		name    string
+		write   *clusterV1alpha1.PendingDBWrite
+		objects []client.Object
+		want    []string
+		wantErr bool
+	}{
+		{
+			name:  "delete cluster",
+			write: newPendingDBWrite("test", "cluster", clusterV1alpha1.PendingDBWriteOperationDeleteCluster, nil),
+			want:  []string{"DELETE /namespaces/test/clustermanagers/cluster"},
+		},
+		{
+			name:  "insert cluster",
+			write: newPendingDBWrite("test", "cluster", clusterV1alpha1.PendingDBWriteOperationInsertCluster, nil),
+			objects: []client.Object{
+				&clusterV1alpha1.ClusterManager{ObjectMeta: metav1.ObjectMeta{Name: "cluster", Namespace: "test"}},
+			},
+			want: []string{"POST /namespaces/test/clustermanagers/cluster"},
+		},
+		{
+			name:  "skip insert of deleted cluster",
+			write: newPendingDBWrite("test", "cluster", clusterV1alpha1.PendingDBWriteOperationInsertCluster, nil),
+			want:  []string{},
+		},
+		{
+			name:  "insert member",
+			write: newPendingDBWrite("test", "cluster", clusterV1alpha1.PendingDBWriteOperationInsertMember, &member.Spec),
+			want:  []string{"POST " + memberPath},
+		},
+		{
+			name:  "update member",
+			write: newPendingDBWrite("test", "cluster", clusterV1alpha1.PendingDBWriteOperationUpdateMember, &member.Spec),
+			want:  []string{"PUT " + memberPath},
+		},
+		{
+			name:  "delete member",
+			write: newPendingDBWrite("test", "cluster", clusterV1alpha1.PendingDBWriteOperationDeleteMember, &member.Spec),
+			want:  []string{"DELETE " + memberPath},
+		},
+		{
+			name:    "member operation without member",
+			write:   newPendingDBWrite("test", "cluster", clusterV1alpha1.PendingDBWriteOperationInsertMember, nil),
+			want:    []string{},
+			wantErr: true,
+		},
+		{
+			name:    "unsupported operation",
+			write:   newPendingDBWrite("test", "cluster", clusterV1alpha1.PendingDBWriteOperation("Unknown"), nil),
+			want:    []string{},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			requests := fakeDB(t, http.StatusOK)
+			err := ReplayPendingDBWrite(context.TODO(), newTestDBClient(t, tt.objects...), tt.write)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ReplayPendingDBWrite() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if strings.Join(*requests, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("requests = %v, want %v", *requests, tt.want)
+			}
+		})
+	}
+}
+
+func TestMemberOrQueue(t *testing.T) {
+	member := newTestMember()
+	insert := newPendingDBWrite(member.Namespace, member.Spec.ClusterName, clusterV1alpha1.PendingDBWriteOperationInsertMember, &member.Spec)
+
+	tests := []struct {
+		name string
+		// 0 이면 db 에 접근할 수 없다.
+		status int
+		// 이미 저장되어 있는 요청
+		queued *clusterV1alpha1.PendingDBWrite
+		write  func(context.Context, client.Client, *clusterV1alpha1.ClusterMember) error
+		// 빈 값이면 저장된 요청이 없어야 한다.
+		wantQueued clusterV1alpha1.PendingDBWriteOperation
+		wantErr    bool
+	}{
+		{
+			name:   "written to db",
+			status: http.StatusOK,
+			write:  InsertMemberOrQueue,
+		},
+		{
+			name:   "stale request is removed after write",
+			status: http.StatusOK,
+			queued: insert.DeepCopy(),
+			write:  DeleteMemberOrQueue,
+		},
+		{
+			name:       "queue when db is unavailable",
+			write:      InsertMemberOrQueue,
+			wantQueued: clusterV1alpha1.PendingDBWriteOperationInsertMember,
+		},
+		{
+			name:       "latest request overwrites queued request",
+			queued:     insert.DeepCopy(),
+			write:      DeleteMemberOrQueue,
+			wantQueued: clusterV1alpha1.PendingDBWriteOperationDeleteMember,
+		},
+		{
+			name:       "update keeps queued insert",
+			queued:     insert.DeepCopy(),
+			write:      UpdateMemberOrQueue,
+			wantQueued: clusterV1alpha1.PendingDBWriteOperationInsertMember,
+		},
+		{
+			name:    "rejected request is not queued",
+			status:  http.StatusBadRequest,
+			write:   InsertMemberOrQueue,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fakeDB(t, tt.status)
+			objects := []client.Object{}
+			if tt.queued != nil {
+				objects = append(objects, tt.queued)
+			}
+			c := newTestDBClient(t, objects...)
+
+			if err := tt.write(context.TODO(), c, member); (err != nil) != tt.wantErr {
+				t.Fatalf("write error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			queued := &clusterV1alpha1.PendingDBWrite{}
+			err := c.Get(context.TODO(), insert.GetNamespacedName(), queued)
+			if tt.wantQueued == "" {
+				if !k8sErrors.IsNotFound(err) {
+					t.Errorf("request should not be queued, got %v, error %v", queued.Spec.Operation, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("failed to get queued request: %v", err)
+			}
+			if queued.Spec.Operation != tt.wantQueued {
+				t.Errorf("queued operation = %v, want %v", queued.Spec.Operation, tt.wantQueued)
+			}
+			if queued.Spec.Member == nil || queued.Spec.Member.MemberId != member.Spec.MemberId {
+				t.Errorf("queued member = %v, want %q", queued.Spec.Member, member.Spec.MemberId)
+			}
+		})
+	}
+}
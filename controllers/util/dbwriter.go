@@ -0,0 +1,149 @@
+package util
+
+import (
+	"context"
+	"crypto/tls"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+	clusterV1alpha1 "github.com/tmax-cloud/hypercloud-multi-operator/apis/cluster/v1alpha1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+const (
+	// hypercloud api server 를 통해 db 에 요청할 때 동시에 사용하는 connection 의 상한
+	dbMaxConns       = 10
+	dbRequestTimeout = 30 * time.Second
+	// DB_FLUSH_INTERVAL 이 설정되지 않은 경우의 기본값
+	dbFlushIntervalDefault = time.Second
+)
+
+// db 요청은 모두 이 client 를 사용하여 connection 을 재사용한다.
+// 요청마다 transport 를 생성하면 cluster 등록이 몰릴 때 connection 이 계속 새로 열려 db 의 connection 이 고갈된다.
+var dbHTTPClient = &http.Client{
+	Transport: &http.Transport{
+		TLSClientConfig:     &tls.Config{InsecureSkipVerify: true},
+		MaxIdleConns:        dbMaxConns,
+		MaxIdleConnsPerHost: dbMaxConns,
+		MaxConnsPerHost:     dbMaxConns,
+		IdleConnTimeout:     90 * time.Second,
+	},
+	Timeout: dbRequestTimeout,
+}
+
+// DBWriter 는 Insert 로 요청된 cluster 정보를 모아두었다가 FlushInterval 마다 db 에 쓴다.
+// 같은 cluster 에 대한 요청이 여러 번 들어오면 마지막 요청만 한 번 쓰고, 기다리던 caller 모두에게 결과를 전달한다.
+// manager 가 시작되기 전이나 종료된 후에는 Insert 가 바로 db 에 쓴다.
+type DBWriter struct {
+	FlushInterval time.Duration
+	Log           logr.Logger
+
+	mu      sync.Mutex
+	running bool
+	pending map[types.NamespacedName]*dbWrite
+}
+
+type dbWrite struct {
+	clusterManager *clusterV1alpha1.ClusterManager
+	// flush 결과를 기다리는 caller 들
+	waiters []chan error
+}
+
+// Insert 가 사용하는 writer 로, NewDBWriter 로 설정한다.
+var dbWriter *DBWriter
+
+// writer 를 생성하고 Insert 가 사용하도록 설정한다. 반환된 writer 는 manager 에 등록해야 한다.
+func NewDBWriter(flushInterval time.Duration, log logr.Logger) *DBWriter {
+	if flushInterval <= 0 {
+		flushInterval = dbFlushIntervalDefault
+	}
+	dbWriter = &DBWriter{
+		FlushInterval: flushInterval,
+		Log:           log,
+		pending:       map[types.NamespacedName]*dbWrite{},
+	}
+	return dbWriter
+}
+
+func (w *DBWriter) Start(ctx context.Context) error {
+	w.mu.Lock()
+	w.running = true
+	w.mu.Unlock()
+
+	ticker := time.NewTicker(w.FlushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			w.mu.Lock()
+			w.running = false
+			w.mu.Unlock()
+			// 종료 전에 남아있는 요청을 모두 쓴다.
+			w.flush()
+			return nil
+		case <-ticker.C:
+			w.flush()
+		}
+	}
+}
+
+// reconciler 가 결과를 기다리므로 leader 가 아니어도 동작한다.
+func (w *DBWriter) NeedLeaderElection() bool {
+	return false
+}
+
+// writer 가 동작 중이 아니면 nil 을 반환하고, caller 는 바로 db 에 써야 한다.
+func (w *DBWriter) enqueue(clusterManager *clusterV1alpha1.ClusterManager) <-chan error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if !w.running {
+		return nil
+	}
+	key := types.NamespacedName{Name: clusterManager.Name, Namespace: clusterManager.Namespace}
+	write, ok := w.pending[key]
+	if !ok {
+		write = &dbWrite{}
+		w.pending[key] = write
+	}
+	write.clusterManager = clusterManager.DeepCopy()
+	ch := make(chan error, 1)
+	write.waiters = append(write.waiters, ch)
+	return ch
+}
+
+func (w *DBWriter) flush() {
+	w.mu.Lock()
+	pending := w.pending
+	w.pending = map[types.NamespacedName]*dbWrite{}
+	w.mu.Unlock()
+
+	if len(pending) == 0 {
+		return
+	}
+
+	// transport 의 connection 수와 같은 수의 요청만 동시에 보낸다.
+	sem := make(chan struct{}, dbMaxConns)
+	wg := sync.WaitGroup{}
+	for _, write := range pending {
+		sem <- struct{}{}
+		wg.Add(1)
+		go func(write *dbWrite) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			err := insertCluster(write.clusterManager)
+			if err != nil {
+				w.Log.Error(err, "Failed to insert cluster info", "clusterManager", write.clusterManager.Name)
+			}
+			for _, ch := range write.waiters {
+				ch <- err
+			}
+		}(write)
+	}
+	wg.Wait()
+}
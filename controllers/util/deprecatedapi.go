@@ -0,0 +1,175 @@
+package util
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"sort"
+	"strings"
+
+	clusterV1alpha1 "github.com/tmax-cloud/hypercloud-multi-operator/apis/cluster/v1alpha1"
+
+	"github.com/prometheus/common/expfmt"
+	coreV1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/version"
+	"k8s.io/client-go/kubernetes"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	DeprecatedAPISourceRequest  = "Request"
+	DeprecatedAPISourceManifest = "Manifest"
+
+	// member cluster 의 api server 가 deprecated api 요청을 기록하는 metric
+	deprecatedAPIMetricName = "apiserver_requested_deprecated_apis"
+	// finding 마다 기록하는 object 의 최대 수
+	deprecatedAPIMaxObjects = 10
+)
+
+// RemovedAPI 는 제거되는 api 와 같은 resource 를 제공하는 대체 api 이다.
+type RemovedAPI struct {
+	GroupVersionResource schema.GroupVersionResource
+	Kind                 string
+	RemovedRelease       string
+	// 대체 api 로 object 를 조회하여, 조회 자체가 deprecated api 요청으로 기록되지 않도록 한다.
+	Replacement schema.GroupVersion
+}
+
+// 대체 api 가 있는 removed api 목록
+// podsecuritypolicy 처럼 대체 api 가 없는 api 는 요청 기록으로만 확인한다.
+var RemovedAPIs = []RemovedAPI{
+	{schema.GroupVersionResource{Group: "extensions", Version: "v1beta1", Resource: "ingresses"}, "Ingress", "1.22", schema.GroupVersion{Group: "networking.k8s.io", Version: "v1"}},
+	{schema.GroupVersionResource{Group: "networking.k8s.io", Version: "v1beta1", Resource: "ingresses"}, "Ingress", "1.22", schema.GroupVersion{Group: "networking.k8s.io", Version: "v1"}},
+	{schema.GroupVersionResource{Group: "apiextensions.k8s.io", Version: "v1beta1", Resource: "customresourcedefinitions"}, "CustomResourceDefinition", "1.22", schema.GroupVersion{Group: "apiextensions.k8s.io", Version: "v1"}},
+	{schema.GroupVersionResource{Group: "admissionregistration.k8s.io", Version: "v1beta1", Resource: "mutatingwebhookconfigurations"}, "MutatingWebhookConfiguration", "1.22", schema.GroupVersion{Group: "admissionregistration.k8s.io", Version: "v1"}},
+	{schema.GroupVersionResource{Group: "admissionregistration.k8s.io", Version: "v1beta1", Resource: "validatingwebhookconfigurations"}, "ValidatingWebhookConfiguration", "1.22", schema.GroupVersion{Group: "admissionregistration.k8s.io", Version: "v1"}},
+	{schema.GroupVersionResource{Group: "rbac.authorization.k8s.io", Version: "v1beta1", Resource: "clusterroles"}, "ClusterRole", "1.22", schema.GroupVersion{Group: "rbac.authorization.k8s.io", Version: "v1"}},
+	{schema.GroupVersionResource{Group: "rbac.authorization.k8s.io", Version: "v1beta1", Resource: "clusterrolebindings"}, "ClusterRoleBinding", "1.22", schema.GroupVersion{Group: "rbac.authorization.k8s.io", Version: "v1"}},
+	{schema.GroupVersionResource{Group: "rbac.authorization.k8s.io", Version: "v1beta1", Resource: "roles"}, "Role", "1.22", schema.GroupVersion{Group: "rbac.authorization.k8s.io", Version: "v1"}},
+	{schema.GroupVersionResource{Group: "rbac.authorization.k8s.io", Version: "v1beta1", Resource: "rolebindings"}, "RoleBinding", "1.22", schema.GroupVersion{Group: "rbac.authorization.k8s.io", Version: "v1"}},
+	{schema.GroupVersionResource{Group: "scheduling.k8s.io", Version: "v1beta1", Resource: "priorityclasses"}, "PriorityClass", "1.22", schema.GroupVersion{Group: "scheduling.k8s.io", Version: "v1"}},
+	{schema.GroupVersionResource{Group: "storage.k8s.io", Version: "v1beta1", Resource: "storageclasses"}, "StorageClass", "1.22", schema.GroupVersion{Group: "storage.k8s.io", Version: "v1"}},
+	{schema.GroupVersionResource{Group: "batch", Version: "v1beta1", Resource: "cronjobs"}, "CronJob", "1.25", schema.GroupVersion{Group: "batch", Version: "v1"}},
+	{schema.GroupVersionResource{Group: "policy", Version: "v1beta1", Resource: "poddisruptionbudgets"}, "PodDisruptionBudget", "1.25", schema.GroupVersion{Group: "policy", Version: "v1"}},
+	{schema.GroupVersionResource{Group: "autoscaling", Version: "v2beta1", Resource: "horizontalpodautoscalers"}, "HorizontalPodAutoscaler", "1.25", schema.GroupVersion{Group: "autoscaling", Version: "v2"}},
+	{schema.GroupVersionResource{Group: "node.k8s.io", Version: "v1beta1", Resource: "runtimeclasses"}, "RuntimeClass", "1.25", schema.GroupVersion{Group: "node.k8s.io", Version: "v1"}},
+	{schema.GroupVersionResource{Group: "autoscaling", Version: "v2beta2", Resource: "horizontalpodautoscalers"}, "HorizontalPodAutoscaler", "1.26", schema.GroupVersion{Group: "autoscaling", Version: "v2"}},
+	{schema.GroupVersionResource{Group: "flowcontrol.apiserver.k8s.io", Version: "v1beta1", Resource: "flowschemas"}, "FlowSchema", "1.26", schema.GroupVersion{Group: "flowcontrol.apiserver.k8s.io", Version: "v1beta2"}},
+	{schema.GroupVersionResource{Group: "flowcontrol.apiserver.k8s.io", Version: "v1beta1", Resource: "prioritylevelconfigurations"}, "PriorityLevelConfiguration", "1.26", schema.GroupVersion{Group: "flowcontrol.apiserver.k8s.io", Version: "v1beta2"}},
+}
+
+// api server 가 시작된 이후 요청된 deprecated api 중 target version 까지 제거되는 api 를 찾는다.
+// api server 의 apiserver_requested_deprecated_apis metric 을 사용한다.
+func GetRequestedDeprecatedAPIs(ctx context.Context, remoteClientset *kubernetes.Clientset, target *version.Version) ([]clusterV1alpha1.DeprecatedAPIFinding, error) {
+	raw, err := remoteClientset.RESTClient().Get().AbsPath("/metrics").DoRaw(ctx)
+	if err != nil {
+		return nil, err
+	}
+	families, err := new(expfmt.TextParser).TextToMetricFamilies(bytes.NewReader(raw))
+	if err != nil {
+		return nil, err
+	}
+
+	findings := []clusterV1alpha1.DeprecatedAPIFinding{}
+	family, ok := families[deprecatedAPIMetricName]
+	if !ok {
+		return findings, nil
+	}
+	for _, metric := range family.GetMetric() {
+		labels := map[string]string{}
+		for _, label := range metric.GetLabel() {
+			labels[label.GetName()] = label.GetValue()
+		}
+		release, err := version.ParseGeneric(labels["removed_release"])
+		if err != nil || !target.AtLeast(release) {
+			continue
+		}
+		findings = append(findings, clusterV1alpha1.DeprecatedAPIFinding{
+			Group:          labels["group"],
+			Version:        labels["version"],
+			Resource:       labels["resource"],
+			RemovedRelease: labels["removed_release"],
+			Source:         DeprecatedAPISourceRequest,
+		})
+	}
+	sortDeprecatedAPIFindings(findings)
+	return findings, nil
+}
+
+// last-applied-configuration annotation 의 apiVersion 으로 target version 까지 제거되는 api 로 적용된 object 를 찾는다.
+// 요청 기록은 api server 가 재시작되면 사라지므로, 다시 적용될 manifest 를 확인하기 위해 함께 사용한다.
+func GetAppliedDeprecatedAPIs(ctx context.Context, remoteClient client.Client, target *version.Version) ([]clusterV1alpha1.DeprecatedAPIFinding, error) {
+	findings := []clusterV1alpha1.DeprecatedAPIFinding{}
+	for _, api := range RemovedAPIs {
+		release := version.MustParseGeneric(api.RemovedRelease)
+		if !target.AtLeast(release) {
+			continue
+		}
+
+		objects := []string{}
+		list := &metav1.PartialObjectMetadataList{}
+		list.SetGroupVersionKind(api.Replacement.WithKind(api.Kind + "List"))
+		err := ListPages(ctx, remoteClient, list, func() (bool, error) {
+			for _, item := range list.Items {
+				if lastAppliedAPIVersion(item.Annotations) != api.GroupVersionResource.GroupVersion().String() {
+					continue
+				}
+				objects = append(objects, strings.TrimPrefix(item.Namespace+"/"+item.Name, "/"))
+			}
+			return true, nil
+		})
+		// 대체 api 를 제공하지 않는 version 의 cluster 는 건너뛴다.
+		if meta.IsNoMatchError(err) {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		if len(objects) == 0 {
+			continue
+		}
+
+		sort.Strings(objects)
+		if len(objects) > deprecatedAPIMaxObjects {
+			objects = objects[:deprecatedAPIMaxObjects]
+		}
+		findings = append(findings, clusterV1alpha1.DeprecatedAPIFinding{
+			Group:          api.GroupVersionResource.Group,
+			Version:        api.GroupVersionResource.Version,
+			Resource:       api.GroupVersionResource.Resource,
+			RemovedRelease: api.RemovedRelease,
+			Source:         DeprecatedAPISourceManifest,
+			Objects:        objects,
+		})
+	}
+	sortDeprecatedAPIFindings(findings)
+	return findings, nil
+}
+
+func lastAppliedAPIVersion(annotations map[string]string) string {
+	lastApplied, ok := annotations[coreV1.LastAppliedConfigAnnotation]
+	if !ok {
+		return ""
+	}
+	applied := &metav1.TypeMeta{}
+	if err := json.Unmarshal([]byte(lastApplied), applied); err != nil {
+		return ""
+	}
+	return applied.APIVersion
+}
+
+func sortDeprecatedAPIFindings(findings []clusterV1alpha1.DeprecatedAPIFinding) {
+	sort.Slice(findings, func(i, j int) bool {
+		a, b := findings[i], findings[j]
+		if a.Group != b.Group {
+			return a.Group < b.Group
+		}
+		if a.Resource != b.Resource {
+			return a.Resource < b.Resource
+		}
+		return a.Version < b.Version
+	})
+}
@@ -0,0 +1,84 @@
+package util
+
+import (
+	"context"
+
+	clusterV1alpha1 "github.com/tmax-cloud/hypercloud-multi-operator/apis/cluster/v1alpha1"
+	coreV1 "k8s.io/api/core/v1"
+	policyV1 "k8s.io/api/policy/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/client-go/kubernetes"
+)
+
+// static pod 의 mirror pod 에 kubelet 이 다는 annotation
+const mirrorPodAnnotation = "kubernetes.io/config.mirror"
+
+// node 를 cordon 하고 drain 으로 cordon 했음을 annotation 으로 기록한다.
+// 사용자가 이미 cordon 한 node 는 uncordon 할 때 건드리지 않도록 annotation 을 달지 않는다.
+// node 가 변경되었으면 true 를 반환한다.
+func CordonNode(node *coreV1.Node) bool {
+	if node.Spec.Unschedulable {
+		return false
+	}
+	node.Spec.Unschedulable = true
+	if node.Annotations == nil {
+		node.Annotations = map[string]string{}
+	}
+	node.Annotations[clusterV1alpha1.AnnotationKeyClmDrainCordoned] = "true"
+	return true
+}
+
+// drain 으로 cordon 한 node 를 uncordon 한다.
+// node 가 변경되었으면 true 를 반환한다.
+func UncordonNode(node *coreV1.Node) bool {
+	if _, ok := node.Annotations[clusterV1alpha1.AnnotationKeyClmDrainCordoned]; !ok {
+		return false
+	}
+	node.Spec.Unschedulable = false
+	delete(node.Annotations, clusterV1alpha1.AnnotationKeyClmDrainCordoned)
+	return true
+}
+
+// node 에서 evict 해야 하는 pod 목록을 반환한다.
+// daemonset pod 는 다시 같은 node 에 생성되고, static pod 는 api 로 삭제할 수 없으며,
+// 종료된 pod 는 resource 를 사용하지 않으므로 제외한다.
+func ListEvictablePods(ctx context.Context, clientset kubernetes.Interface, nodeName string) ([]coreV1.Pod, error) {
+	podList, err := clientset.CoreV1().Pods(metav1.NamespaceAll).List(ctx, metav1.ListOptions{
+		FieldSelector: fields.OneTermEqualSelector("spec.nodeName", nodeName).String(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	pods := []coreV1.Pod{}
+	for _, pod := range podList.Items {
+		if pod.Status.Phase == coreV1.PodSucceeded || pod.Status.Phase == coreV1.PodFailed {
+			continue
+		}
+		if _, ok := pod.Annotations[mirrorPodAnnotation]; ok {
+			continue
+		}
+		if owner := metav1.GetControllerOf(&pod); owner != nil && owner.Kind == "DaemonSet" {
+			continue
+		}
+		pods = append(pods, pod)
+	}
+	return pods, nil
+}
+
+// eviction api 로 pod 를 evict 한다.
+// PodDisruptionBudget 에 의해 거부된 경우 TooManyRequests error 를 반환하며, 이미 삭제된 pod 는 무시한다.
+func EvictPod(ctx context.Context, clientset kubernetes.Interface, pod *coreV1.Pod) error {
+	eviction := &policyV1.Eviction{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      pod.Name,
+			Namespace: pod.Namespace,
+		},
+	}
+	if err := clientset.PolicyV1().Evictions(pod.Namespace).Evict(ctx, eviction); err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,487 @@
+package util
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	b64 "encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	restclient "k8s.io/client-go/rest"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+)
+
+// operator 가 AWS api 를 호출할 때 사용하는 credential 의 환경 변수
+// IRSA 를 사용하는 경우 AWS_ROLE_ARN 과 AWS_WEB_IDENTITY_TOKEN_FILE 이 설정된다.
+const (
+	awsAccessKeyID          = "AWS_ACCESS_KEY_ID"
+	awsSecretAccessKey      = "AWS_SECRET_ACCESS_KEY"
+	awsSessionToken         = "AWS_SESSION_TOKEN"
+	awsRoleARN              = "AWS_ROLE_ARN"
+	awsWebIdentityTokenFile = "AWS_WEB_IDENTITY_TOKEN_FILE"
+)
+
+const (
+	// aws-iam-authenticator 와 같은 형식의 token 을 생성한다.
+	eksTokenPrefix     = "k8s-aws-v1."
+	eksClusterIDHeader = "x-k8s-aws-id"
+	// EKS 의 token 은 15 분간 유효하므로 만료 전에 갱신한다.
+	eksTokenLifetime = 14 * time.Minute
+	// assume 한 role 의 credential 은 만료 5 분 전에 갱신한다.
+	awsCredentialsRefreshWindow = 5 * time.Minute
+	awsSTSVersion               = "2011-06-15"
+	awsRoleSessionName          = "hypercloud-multi-operator"
+)
+
+// server side 에서 생성하는 EKS kubeconfig 의 exec plugin
+// operator 가 아닌 사용자가 kubeconfig 를 사용하는 경우 aws cli 로 token 을 발급받는다. operator 는 실행하지 않는다.
+const eksExecCommand = "aws"
+
+// arn:aws:eks:<region>:<account>:cluster/<name> 형식의 EKS cluster ARN
+type EKSClusterARN struct {
+	Partition string
+	Region    string
+	Account   string
+	Name      string
+}
+
+func ParseEKSClusterARN(arn string) (*EKSClusterARN, error) {
+	parts := strings.SplitN(arn, ":", 6)
+	if len(parts) != 6 || parts[0] != "arn" || parts[2] != "eks" || !strings.HasPrefix(parts[5], "cluster/") {
+		return nil, fmt.Errorf("invalid EKS cluster ARN %q", arn)
+	}
+	name := strings.TrimPrefix(parts[5], "cluster/")
+	if parts[3] == "" || name == "" {
+		return nil, fmt.Errorf("invalid EKS cluster ARN %q", arn)
+	}
+	return &EKSClusterARN{
+		Partition: parts[1],
+		Region:    parts[3],
+		Account:   parts[4],
+		Name:      name,
+	}, nil
+}
+
+// EKS 의 DescribeCluster api 로 api server 주소와 CA 를 조회하여 exec plugin 을 사용하는 kubeconfig 를 생성한다.
+// operator 의 remote client 는 exec plugin 을 실행하지 않고, NewEKSClusterAuth 의 정보로 직접 token 을 발급하여 갱신한다.
+func NewEKSKubeconfig(ctx context.Context, clusterARN, roleARN string) ([]byte, error) {
+	arn, err := ParseEKSClusterARN(clusterARN)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkEKSRoleAllowed(roleARN); err != nil {
+		return nil, err
+	}
+	creds, err := newEKSCredentialProvider(arn.Partition, arn.Region, roleARN).get(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	endpoint := fmt.Sprintf("https://eks.%s.%s/clusters/%s", arn.Region, awsDomain(arn.Partition), url.PathEscape(arn.Name))
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	signAWSRequest(req, nil, creds, arn.Region, "eks", time.Now())
+	body, err := doCloudHTTPRequest(req)
+	if err != nil {
+		return nil, err
+	}
+
+	described := struct {
+		Cluster struct {
+			Endpoint             string `json:"endpoint"`
+			CertificateAuthority struct {
+				Data string `json:"data"`
+			} `json:"certificateAuthority"`
+		} `json:"cluster"`
+	}{}
+	if err := json.Unmarshal(body, &described); err != nil {
+		return nil, err
+	}
+	if described.Cluster.Endpoint == "" {
+		return nil, fmt.Errorf("endpoint of EKS cluster %q is not ready", arn.Name)
+	}
+	ca, err := b64.StdEncoding.DecodeString(described.Cluster.CertificateAuthority.Data)
+	if err != nil {
+		return nil, err
+	}
+
+	return newExecKubeconfig(clusterARN, described.Cluster.Endpoint, ca, &clientcmdapi.ExecConfig{
+		APIVersion: execAPIVersion,
+		Command:    eksExecCommand,
+		Args: []string{
+			"--region", arn.Region,
+			"eks", "get-token",
+			"--cluster-name", arn.Name,
+			"--role-arn", roleARN,
+		},
+		InteractiveMode: clientcmdapi.NeverExecInteractiveMode,
+	})
+}
+
+// operator 가 생성한 EKS kubeconfig 인 경우 exec plugin 대신 operator 가 직접 token 을 발급하도록 설정한다.
+// cluster 와 role 은 kubeconfig 의 exec args 가 아닌 secret 에 기록한 auth 에서 가져오고,
+// token 은 EKS 의 api server 로만 보낸다.
+// token 은 만료 전에 갱신되고, api server 가 401 을 응답하면 다음 요청에서 새로 발급한다.
+// rest config 로 transport 를 생성하기 전에 호출해야 한다.
+func configureEKSAuth(config *restclient.Config, auth *ManagedClusterAuth) error {
+	arn, err := ParseEKSClusterARN(auth.ClusterARN)
+	if err != nil {
+		return err
+	}
+	if err := checkEKSRoleAllowed(auth.RoleARN); err != nil {
+		return err
+	}
+	if !isEKSEndpoint(config.Host, arn) {
+		return fmt.Errorf("server %q is not an endpoint of EKS cluster %q", config.Host, auth.ClusterARN)
+	}
+
+	ts := &eksTokenSource{
+		clusterName: arn.Name,
+		partition:   arn.Partition,
+		region:      arn.Region,
+		creds:       newEKSCredentialProvider(arn.Partition, arn.Region, auth.RoleARN),
+	}
+	config.ExecProvider = nil
+	config.Wrap(func(rt http.RoundTripper) http.RoundTripper {
+		return &eksTokenRoundTripper{source: ts, base: rt}
+	})
+	return nil
+}
+
+// operator 는 EKS_ALLOWED_ROLE_ARNS 에 등록된 role 만 assume 한다.
+func checkEKSRoleAllowed(roleARN string) error {
+	for _, allowed := range strings.Split(os.Getenv(EKS_ALLOWED_ROLE_ARNS), ",") {
+		if allowed = strings.TrimSpace(allowed); allowed != "" && allowed == roleARN {
+			return nil
+		}
+	}
+	return NewError(ErrPermissionDenied, fmt.Errorf("role %q is not allowed for EKS clusters, it must be one of %s", roleARN, EKS_ALLOWED_ROLE_ARNS))
+}
+
+// EKS 의 api server 주소는 https://<id>.<zone>.<region>.eks.amazonaws.com 형식이다.
+func isEKSEndpoint(server string, arn *EKSClusterARN) bool {
+	host := EndpointHost(server)
+	return host != "" && strings.HasSuffix(host, "."+arn.Region+".eks."+awsDomain(arn.Partition))
+}
+
+type eksTokenSource struct {
+	clusterName string
+	partition   string
+	region      string
+	creds       *eksCredentialProvider
+
+	mu     sync.Mutex
+	token  string
+	expiry time.Time
+}
+
+func (s *eksTokenSource) get(ctx context.Context) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.token != "" && time.Now().Before(s.expiry) {
+		return s.token, nil
+	}
+	creds, err := s.creds.get(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now()
+	req, err := http.NewRequest(http.MethodGet, stsEndpoint(s.partition, s.region)+"?Action=GetCallerIdentity&Version="+awsSTSVersion, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set(eksClusterIDHeader, s.clusterName)
+	presigned := presignAWSRequest(req, creds, s.region, "sts", now, 60*time.Second)
+
+	s.token = eksTokenPrefix + b64.RawURLEncoding.EncodeToString([]byte(presigned))
+	s.expiry = now.Add(eksTokenLifetime)
+	return s.token, nil
+}
+
+// 다음 요청에서 token 을 새로 발급하도록 한다.
+func (s *eksTokenSource) reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.token = ""
+}
+
+type eksTokenRoundTripper struct {
+	source *eksTokenSource
+	base   http.RoundTripper
+}
+
+func (rt *eksTokenRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	token, err := rt.source.get(req.Context())
+	if err != nil {
+		return nil, err
+	}
+	req = req.Clone(req.Context())
+	req.Header.Set("Authorization", "Bearer "+token)
+	resp, err := rt.base.RoundTrip(req)
+	if err == nil && resp.StatusCode == http.StatusUnauthorized {
+		rt.source.reset()
+	}
+	return resp, err
+}
+
+type awsCredentials struct {
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+	Expiration      time.Time
+}
+
+// operator 의 credential 로 roleARN 의 role 을 assume 한다. operator 의 credential 을 그대로 사용하지는 않는다.
+// assume 한 credential 은 만료되기 전까지 재사용한다.
+type eksCredentialProvider struct {
+	partition string
+	region    string
+	roleARN   string
+
+	mu    sync.Mutex
+	creds *awsCredentials
+}
+
+func newEKSCredentialProvider(partition, region, roleARN string) *eksCredentialProvider {
+	return &eksCredentialProvider{partition: partition, region: region, roleARN: roleARN}
+}
+
+func (p *eksCredentialProvider) get(ctx context.Context) (*awsCredentials, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.creds != nil && time.Until(p.creds.Expiration) > awsCredentialsRefreshWindow {
+		return p.creds, nil
+	}
+	if err := checkEKSRoleAllowed(p.roleARN); err != nil {
+		return nil, err
+	}
+	base, err := operatorAWSCredentials(ctx, p.partition, p.region)
+	if err != nil {
+		return nil, err
+	}
+
+	form := url.Values{
+		"Action":          {"AssumeRole"},
+		"Version":         {awsSTSVersion},
+		"RoleArn":         {p.roleARN},
+		"RoleSessionName": {awsRoleSessionName},
+	}
+	creds, err := callSTS(ctx, p.partition, p.region, form, base)
+	if err != nil {
+		return nil, err
+	}
+	p.creds = creds
+	return creds, nil
+}
+
+// 환경 변수의 access key 를 사용하고, 없으면 IRSA 의 web identity token 으로 credential 을 발급받는다.
+func operatorAWSCredentials(ctx context.Context, partition, region string) (*awsCredentials, error) {
+	if id, secret := os.Getenv(awsAccessKeyID), os.Getenv(awsSecretAccessKey); id != "" && secret != "" {
+		return &awsCredentials{
+			AccessKeyID:     id,
+			SecretAccessKey: secret,
+			SessionToken:    os.Getenv(awsSessionToken),
+		}, nil
+	}
+
+	tokenFile, roleARN := os.Getenv(awsWebIdentityTokenFile), os.Getenv(awsRoleARN)
+	if tokenFile == "" || roleARN == "" {
+		return nil, NewError(ErrPermissionDenied, fmt.Errorf("aws credentials are not configured for the operator"))
+	}
+	token, err := ioutil.ReadFile(tokenFile)
+	if err != nil {
+		return nil, err
+	}
+	form := url.Values{
+		"Action":           {"AssumeRoleWithWebIdentity"},
+		"Version":          {awsSTSVersion},
+		"RoleArn":          {roleARN},
+		"RoleSessionName":  {awsRoleSessionName},
+		"WebIdentityToken": {strings.TrimSpace(string(token))},
+	}
+	return callSTS(ctx, partition, region, form, nil)
+}
+
+// sts 의 AssumeRole, AssumeRoleWithWebIdentity 를 호출한다.
+// creds 가 nil 이면 서명하지 않는다.
+func callSTS(ctx context.Context, partition, region string, form url.Values, creds *awsCredentials) (*awsCredentials, error) {
+	body := []byte(form.Encode())
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, stsEndpoint(partition, region), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded; charset=utf-8")
+	if creds != nil {
+		signAWSRequest(req, body, creds, region, "sts", time.Now())
+	}
+	respBody, err := doCloudHTTPRequest(req)
+	if err != nil {
+		return nil, err
+	}
+
+	// AssumeRole 과 AssumeRoleWithWebIdentity 의 응답은 Result element 의 이름만 다르다.
+	type stsCredentials struct {
+		AccessKeyID     string    `xml:"Credentials>AccessKeyId"`
+		SecretAccessKey string    `xml:"Credentials>SecretAccessKey"`
+		SessionToken    string    `xml:"Credentials>SessionToken"`
+		Expiration      time.Time `xml:"Credentials>Expiration"`
+	}
+	result := struct {
+		AssumeRole            stsCredentials `xml:"AssumeRoleResult"`
+		AssumeRoleWebIdentity stsCredentials `xml:"AssumeRoleWithWebIdentityResult"`
+	}{}
+	if err := xml.Unmarshal(respBody, &result); err != nil {
+		return nil, err
+	}
+	c := result.AssumeRole
+	if c.AccessKeyID == "" {
+		c = result.AssumeRoleWebIdentity
+	}
+	if c.AccessKeyID == "" {
+		return nil, fmt.Errorf("sts %s returned no credentials", form.Get("Action"))
+	}
+	return &awsCredentials{
+		AccessKeyID:     c.AccessKeyID,
+		SecretAccessKey: c.SecretAccessKey,
+		SessionToken:    c.SessionToken,
+		Expiration:      c.Expiration,
+	}, nil
+}
+
+// sts 의 regional endpoint 로, aws-cn 같은 partition 은 domain 이 다르다.
+func stsEndpoint(partition, region string) string {
+	return fmt.Sprintf("https://sts.%s.%s/", region, awsDomain(partition))
+}
+
+func awsDomain(partition string) string {
+	if partition == "aws-cn" {
+		return "amazonaws.com.cn"
+	}
+	return "amazonaws.com"
+}
+
+const (
+	awsSigningAlgorithm = "AWS4-HMAC-SHA256"
+	awsTimeFormat       = "20060102T150405Z"
+	awsDateFormat       = "20060102"
+)
+
+// Signature Version 4 로 request 의 header 에 서명한다.
+func signAWSRequest(req *http.Request, body []byte, creds *awsCredentials, region, service string, now time.Time) {
+	now = now.UTC()
+	req.Header.Set("X-Amz-Date", now.Format(awsTimeFormat))
+	if creds.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", creds.SessionToken)
+	}
+	payloadHash := sha256Hex(body)
+
+	scope := awsCredentialScope(now, region, service)
+	signedHeaders, canonicalHeaders := awsCanonicalHeaders(req)
+	signature := awsSignature(req, canonicalHeaders, signedHeaders, payloadHash, creds, scope, now, region, service)
+	req.Header.Set("Authorization", fmt.Sprintf("%s Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		awsSigningAlgorithm, creds.AccessKeyID, scope, signedHeaders, signature))
+}
+
+// Signature Version 4 로 서명한 url 을 반환한다. header 도 서명에 포함된다.
+func presignAWSRequest(req *http.Request, creds *awsCredentials, region, service string, now time.Time, expires time.Duration) string {
+	now = now.UTC()
+	scope := awsCredentialScope(now, region, service)
+	signedHeaders, canonicalHeaders := awsCanonicalHeaders(req)
+
+	query := req.URL.Query()
+	query.Set("X-Amz-Algorithm", awsSigningAlgorithm)
+	query.Set("X-Amz-Credential", creds.AccessKeyID+"/"+scope)
+	query.Set("X-Amz-Date", now.Format(awsTimeFormat))
+	query.Set("X-Amz-Expires", fmt.Sprintf("%d", int(expires.Seconds())))
+	query.Set("X-Amz-SignedHeaders", signedHeaders)
+	if creds.SessionToken != "" {
+		query.Set("X-Amz-Security-Token", creds.SessionToken)
+	}
+	req.URL.RawQuery = awsEncodeQuery(query)
+
+	signature := awsSignature(req, canonicalHeaders, signedHeaders, sha256Hex(nil), creds, scope, now, region, service)
+	return req.URL.String() + "&X-Amz-Signature=" + signature
+}
+
+func awsCredentialScope(now time.Time, region, service string) string {
+	return strings.Join([]string{now.Format(awsDateFormat), region, service, "aws4_request"}, "/")
+}
+
+// host 와 request 에 설정된 header 를 모두 서명한다.
+func awsCanonicalHeaders(req *http.Request) (string, string) {
+	headers := map[string]string{"host": req.URL.Host}
+	for k, v := range req.Header {
+		headers[strings.ToLower(k)] = strings.TrimSpace(strings.Join(v, ","))
+	}
+	keys := make([]string, 0, len(headers))
+	for k := range headers {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	canonical := strings.Builder{}
+	for _, k := range keys {
+		canonical.WriteString(k + ":" + headers[k] + "\n")
+	}
+	return strings.Join(keys, ";"), canonical.String()
+}
+
+func awsSignature(req *http.Request, canonicalHeaders, signedHeaders, payloadHash string, creds *awsCredentials, scope string, now time.Time, region, service string) string {
+	path := req.URL.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		path,
+		awsEncodeQuery(req.URL.Query()),
+		canonicalHeaders,
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+	stringToSign := strings.Join([]string{
+		awsSigningAlgorithm,
+		now.Format(awsTimeFormat),
+		scope,
+		sha256Hex([]byte(canonicalRequest)),
+	}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+creds.SecretAccessKey), now.Format(awsDateFormat))
+	key = hmacSHA256(key, region)
+	key = hmacSHA256(key, service)
+	key = hmacSHA256(key, "aws4_request")
+	return hex.EncodeToString(hmacSHA256(key, stringToSign))
+}
+
+// 서명에 사용하는 query 는 key 로 정렬하고 공백을 %20 으로 encode 한다.
+func awsEncodeQuery(query url.Values) string {
+	return strings.ReplaceAll(query.Encode(), "+", "%20")
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
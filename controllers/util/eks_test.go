@@ -0,0 +1,24 @@
+package util
+
+import "testing"
+
+func TestSTSEndpoint(t *testing.T) {
+	tests := []struct {
+		clusterARN string
+		want       string
+	}{
+		{clusterARN: "arn:aws:eks:ap-northeast-2:123456789012:cluster/dev", want: "https://sts.ap-northeast-2.amazonaws.com/"},
+		{clusterARN: "arn:aws-cn:eks:cn-north-1:123456789012:cluster/dev", want: "https://sts.cn-north-1.amazonaws.com.cn/"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.clusterARN, func(t *testing.T) {
+			arn, err := ParseEKSClusterARN(tt.clusterARN)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := stsEndpoint(arn.Partition, arn.Region); got != tt.want {
+				t.Errorf("expected %s, got %s", tt.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,54 @@
+package util
+
+import (
+	"net"
+	"net/url"
+	"strings"
+)
+
+// endpoint 는 scheme, port 를 포함한 url 이거나 host 만 저장되어 있을 수 있다.
+// IPv6 address 는 url 에서는 [fd00::1]:6443 처럼 bracket 으로 감싸지만, capi 의 control plane endpoint 나
+// kubeconfig 에서 얻은 host 는 bracket 없이 fd00::1 로 저장되므로 문자열 그대로 비교하거나 "https://" 를 붙여 parse 하면 안된다.
+
+// endpoint 에서 scheme, port, bracket 을 제외한 host 를 반환한다. 같은 host 인지 비교할 수 있도록 ip address 는 표준 표기로 변환한다.
+// 예) https://[FD00:0::1]:6443 -> fd00::1, fd00::1 -> fd00::1, https://API.example.com -> api.example.com
+func EndpointHost(endpoint string) string {
+	endpoint = strings.TrimSpace(endpoint)
+	if endpoint == "" {
+		return ""
+	}
+	if !strings.Contains(endpoint, "://") {
+		// bracket 이 없는 IPv6 address 는 port 와 구분할 수 없으므로 url 로 parse 하지 않는다.
+		if ip := net.ParseIP(strings.Trim(endpoint, "[]")); ip != nil {
+			return ip.String()
+		}
+		endpoint = "https://" + endpoint
+	}
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return ""
+	}
+	return normalizeHost(u.Hostname())
+}
+
+// host 로 api server 의 url 을 만든다. IPv6 address 는 bracket 으로 감싼다.
+// 예) fd00::1 -> https://[fd00::1], 10.0.0.1 -> https://10.0.0.1
+func ServerURL(host string) string {
+	if IsIPv6Host(host) {
+		return "https://[" + host + "]"
+	}
+	return "https://" + host
+}
+
+// host 가 bracket 이 없는 IPv6 address 인지 확인한다.
+func IsIPv6Host(host string) bool {
+	ip := net.ParseIP(host)
+	return ip != nil && ip.To4() == nil
+}
+
+func normalizeHost(host string) string {
+	if ip := net.ParseIP(host); ip != nil {
+		return ip.String()
+	}
+	return strings.ToLower(host)
+}
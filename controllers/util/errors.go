@@ -0,0 +1,115 @@
+package util
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"strings"
+
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+)
+
+// reconciler 에서 status reason 과 retry 여부를 일관되게 결정하기 위한 error 종류
+// errors.Is(err, util.ErrRemoteUnreachable) 와 같이 종류를 확인할 수 있다.
+var (
+	// remote cluster 의 api server 에 접근할 수 없는 경우
+	ErrRemoteUnreachable = errors.New("remote cluster is unreachable")
+	// remote cluster 또는 외부 api 에 대한 권한이 없는 경우
+	ErrPermissionDenied = errors.New("permission denied")
+	// 동일한 이름의 cluster 가 이미 존재하는 경우
+	ErrDuplicateCluster = errors.New("cluster already exists")
+	// hypercloud api server 를 통해 db 에 접근할 수 없는 경우
+	ErrDBUnavailable = errors.New("db is unavailable")
+)
+
+// error 종류에 따른 status reason
+const (
+	ReasonRemoteUnreachable = "RemoteUnreachable"
+	ReasonPermissionDenied  = "PermissionDenied"
+	ReasonDuplicateCluster  = "ClusterNameDuplicated"
+	ReasonDBUnavailable     = "DBUnavailable"
+	ReasonUnknown           = "Unknown"
+)
+
+// OperatorError는 error 종류(Kind)와 원인이 되는 error(Err)를 함께 가진다.
+type OperatorError struct {
+	Kind error
+	Err  error
+}
+
+func (e *OperatorError) Error() string {
+	if e.Err == nil {
+		return e.Kind.Error()
+	}
+	return e.Kind.Error() + ": " + e.Err.Error()
+}
+
+func (e *OperatorError) Unwrap() error {
+	return e.Err
+}
+
+func (e *OperatorError) Is(target error) bool {
+	return e.Kind == target
+}
+
+// kind 종류의 error 로 err 를 감싼다. err 가 nil 이면 nil 을 반환한다.
+func NewError(kind error, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &OperatorError{Kind: kind, Err: err}
+}
+
+// remote cluster 에 대한 요청에서 발생한 error 를 종류에 맞게 감싼다.
+func ClassifyRemoteError(err error) error {
+	var netErr net.Error
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, ErrRemoteUnreachable), errors.Is(err, ErrPermissionDenied):
+		return err
+	case k8sErrors.IsUnauthorized(err), k8sErrors.IsForbidden(err):
+		return NewError(ErrPermissionDenied, err)
+	case k8sErrors.IsServiceUnavailable(err), k8sErrors.IsTimeout(err), k8sErrors.IsServerTimeout(err),
+		errors.As(err, &netErr), strings.Contains(err.Error(), "connection refused"):
+		return NewError(ErrRemoteUnreachable, err)
+	}
+	return err
+}
+
+// hypercloud api server 의 응답 코드를 error 로 변환한다.
+func ClassifyStatusCode(statusCode int, err error) error {
+	switch {
+	case statusCode == http.StatusUnauthorized || statusCode == http.StatusForbidden:
+		return NewError(ErrPermissionDenied, err)
+	case statusCode >= http.StatusInternalServerError:
+		return NewError(ErrDBUnavailable, err)
+	}
+	return err
+}
+
+// error 종류에 해당하는 status reason 을 반환한다.
+func ErrorReason(err error) string {
+	switch {
+	case err == nil:
+		return ""
+	case errors.Is(err, ErrRemoteUnreachable):
+		return ReasonRemoteUnreachable
+	case errors.Is(err, ErrPermissionDenied):
+		return ReasonPermissionDenied
+	case errors.Is(err, ErrDuplicateCluster):
+		return ReasonDuplicateCluster
+	case errors.Is(err, ErrDBUnavailable):
+		return ReasonDBUnavailable
+	}
+	return ReasonUnknown
+}
+
+// 일시적인 error 인 경우 true 를 반환한다.
+// 권한 문제나 cluster 이름 중복은 사용자 조치가 필요하므로 재시도하지 않는다.
+func IsRetryable(err error) bool {
+	if err == nil {
+		return false
+	}
+	return !errors.Is(err, ErrPermissionDenied) && !errors.Is(err, ErrDuplicateCluster)
+}
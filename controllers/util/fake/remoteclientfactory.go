@@ -0,0 +1,90 @@
+// fake 는 실제 member cluster 없이 reconciler 를 test 하기 위한 fake 구현을 제공한다.
+package fake
+
+import (
+	"context"
+	"sync"
+
+	"github.com/tmax-cloud/hypercloud-multi-operator/controllers/util"
+	coreV1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+var _ util.RemoteClientFactory = &RemoteClientFactory{}
+
+// RemoteClientFactory 는 member cluster 마다 fake clientset 을 돌려주는 util.RemoteClientFactory 의 fake 구현이다.
+// kubeconfig secret 은 namespace/name 으로, kubeconfig 는 server 로,
+// cluster manager 는 namespace/name 과 credential level 로 member cluster 를 구분한다.
+type RemoteClientFactory struct {
+	// 등록되지 않은 member cluster 에 대해 반환하는 error, nil 이면 빈 fake clientset 을 생성한다.
+	Err error
+
+	lock       sync.Mutex
+	clientsets map[string]*fake.Clientset
+}
+
+func NewRemoteClientFactory() *RemoteClientFactory {
+	return &RemoteClientFactory{
+		clientsets: map[string]*fake.Clientset{},
+	}
+}
+
+// kubeconfig secret 에 대한 member cluster 를 objects 로 초기화한다.
+func (f *RemoteClientFactory) AddSecretCluster(secret types.NamespacedName, objects ...runtime.Object) *fake.Clientset {
+	return f.add(secret.String(), objects...)
+}
+
+// kubeconfig 의 server 에 대한 member cluster 를 objects 로 초기화한다.
+func (f *RemoteClientFactory) AddServerCluster(server string, objects ...runtime.Object) *fake.Clientset {
+	return f.add(server, objects...)
+}
+
+// cluster manager 의 credential level 에 대한 member cluster 를 objects 로 초기화한다.
+// controller 가 필요한 level 의 client 를 요청하는지 확인할 때 사용한다.
+func (f *RemoteClientFactory) AddCluster(cluster types.NamespacedName, level util.CredentialLevel, objects ...runtime.Object) *fake.Clientset {
+	return f.add(clusterKey(cluster, level), objects...)
+}
+
+func (f *RemoteClientFactory) ClientsetForSecret(secret *coreV1.Secret) (kubernetes.Interface, error) {
+	return f.get(types.NamespacedName{Name: secret.Name, Namespace: secret.Namespace}.String())
+}
+
+func (f *RemoteClientFactory) ClientsetForKubeconfig(kubeconfig *util.Kubeconfig) (kubernetes.Interface, error) {
+	return f.get(kubeconfig.Server)
+}
+
+func (f *RemoteClientFactory) ClientsetForCluster(ctx context.Context, c client.Reader, namespace, clusterName string, level util.CredentialLevel) (kubernetes.Interface, error) {
+	return f.get(clusterKey(types.NamespacedName{Name: clusterName, Namespace: namespace}, level))
+}
+
+func clusterKey(cluster types.NamespacedName, level util.CredentialLevel) string {
+	return cluster.String() + "@" + string(level)
+}
+
+func (f *RemoteClientFactory) add(key string, objects ...runtime.Object) *fake.Clientset {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	clientset := fake.NewSimpleClientset(objects...)
+	f.clientsets[key] = clientset
+	return clientset
+}
+
+func (f *RemoteClientFactory) get(key string) (kubernetes.Interface, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	if clientset, ok := f.clientsets[key]; ok {
+		return clientset, nil
+	}
+	if f.Err != nil {
+		return nil, f.Err
+	}
+	clientset := fake.NewSimpleClientset()
+	f.clientsets[key] = clientset
+	return clientset, nil
+}
@@ -0,0 +1,114 @@
+package util
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+const (
+	// FANOUT_CONCURRENCY, FANOUT_QPS, FANOUT_BATCH_SIZE 가 설정되지 않은 경우의 기본값
+	fanOutConcurrencyDefault = 10
+	fanOutQPSDefault         = 5
+	fanOutBatchSizeDefault   = 50
+
+	// 처리하지 못한 cluster 가 남은 경우 다음 batch 를 처리하기까지의 간격
+	FanOutBatchInterval = 5 * time.Second
+)
+
+// fleet 전체에 적용하는 변경을 각 cluster 에 적용하는 작업
+type FanOutTask struct {
+	// cluster manager 의 이름
+	Key string
+	// 클수록 먼저 처리한다.
+	Priority int
+	Run      func(ctx context.Context) error
+}
+
+// FanOutPool 은 모든 controller 가 공유하는 worker pool 이다.
+// 여러 object 의 변경이 동시에 수백 개의 cluster 에 적용되더라도
+// member cluster 의 api server 로 가는 작업의 동시 실행 수와 시작 속도를 제한한다.
+type FanOutPool struct {
+	slots     chan struct{}
+	limiter   *rate.Limiter
+	batchSize int
+}
+
+var (
+	fanOutPool     = NewFanOutPool(0, 0, 0)
+	fanOutPoolLock sync.RWMutex
+)
+
+// 0 이하의 값은 기본값을 사용한다.
+func NewFanOutPool(concurrency, qps, batchSize int) *FanOutPool {
+	if concurrency <= 0 {
+		concurrency = fanOutConcurrencyDefault
+	}
+	if qps <= 0 {
+		qps = fanOutQPSDefault
+	}
+	if batchSize <= 0 {
+		batchSize = fanOutBatchSizeDefault
+	}
+	return &FanOutPool{
+		slots:     make(chan struct{}, concurrency),
+		limiter:   rate.NewLimiter(rate.Limit(qps), concurrency),
+		batchSize: batchSize,
+	}
+}
+
+// manager 를 시작하기 전에 환경 변수로 설정한 pool 로 교체한다.
+func SetFanOutPool(pool *FanOutPool) {
+	fanOutPoolLock.Lock()
+	defer fanOutPoolLock.Unlock()
+	fanOutPool = pool
+}
+
+func GetFanOutPool() *FanOutPool {
+	fanOutPoolLock.RLock()
+	defer fanOutPoolLock.RUnlock()
+	return fanOutPool
+}
+
+// tasks 중 priority 가 높은 순서로 batch size 만큼 실행하고 실행한 task 의 결과를 key 별로 반환한다.
+// 실행하지 못하고 남은 task 의 수를 함께 반환하며, 남은 task 는 다음 reconcile 에서 다시 전달해야 한다.
+func (p *FanOutPool) Run(ctx context.Context, tasks []FanOutTask) (map[string]error, int) {
+	sort.SliceStable(tasks, func(i, j int) bool {
+		if tasks[i].Priority != tasks[j].Priority {
+			return tasks[i].Priority > tasks[j].Priority
+		}
+		return tasks[i].Key < tasks[j].Key
+	})
+	batch := tasks
+	if len(batch) > p.batchSize {
+		batch = batch[:p.batchSize]
+	}
+
+	errs := make([]error, len(batch))
+	wg := sync.WaitGroup{}
+	for i := range batch {
+		if err := p.limiter.Wait(ctx); err != nil {
+			errs[i] = err
+			continue
+		}
+		p.slots <- struct{}{}
+		wg.Add(1)
+		go func(i int) {
+			defer func() {
+				<-p.slots
+				wg.Done()
+			}()
+			errs[i] = batch[i].Run(ctx)
+		}(i)
+	}
+	wg.Wait()
+
+	result := make(map[string]error, len(batch))
+	for i, task := range batch {
+		result[task.Key] = errs[i]
+	}
+	return result, len(tasks) - len(batch)
+}
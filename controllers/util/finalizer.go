@@ -0,0 +1,26 @@
+package util
+
+import (
+	"time"
+
+	"github.com/go-logr/logr"
+	clusterV1alpha1 "github.com/tmax-cloud/hypercloud-multi-operator/apis/cluster/v1alpha1"
+)
+
+// 삭제 중인 cluster manager 의 finalizer 단계에서 발생한 error 를 처리한다.
+// force-delete annotation 이 있거나 삭제 deadline 이 지난 경우 error 를 log 로 남기고 nil 을 반환하여 다음 단계로 넘어간다.
+func IgnoreStuckFinalizerError(log logr.Logger, clm *clusterV1alpha1.ClusterManager, step string, err error) error {
+	if err == nil {
+		return nil
+	}
+	timeout, envErr := GetDurationEnv(FINALIZER_TIMEOUT)
+	if envErr != nil {
+		log.Error(envErr, "Invalid env", "env", FINALIZER_TIMEOUT)
+	}
+	reason, ok := clm.FinalizerSkipReason(timeout, time.Now())
+	if !ok {
+		return err
+	}
+	log.Error(err, "Skipped stuck finalizer step", "step", step, "reason", reason)
+	return nil
+}
@@ -0,0 +1,87 @@
+package util
+
+import (
+	"context"
+	b64 "encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	clusterV1alpha1 "github.com/tmax-cloud/hypercloud-multi-operator/apis/cluster/v1alpha1"
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/google"
+	coreV1 "k8s.io/api/core/v1"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// GKE 의 credential secret 에서 service account key 를 가지고 있는 key
+	GKECredentialsKey = "credentials.json"
+	// operator 가 아닌 사용자가 kubeconfig 를 사용하는 경우 gcloud 의 plugin 으로 token 을 발급받는다.
+	gkeExecCommand = "gke-gcloud-auth-plugin"
+)
+
+// GKE api 와 api server 에 모두 사용하는 scope
+var gkeScopes = []string{
+	"https://www.googleapis.com/auth/cloud-platform",
+	"https://www.googleapis.com/auth/userinfo.email",
+}
+
+// GKE api 로 api server 주소와 CA 를 조회하여 exec plugin 을 사용하는 kubeconfig 를 생성한다.
+// operator 의 remote client 는 credential secret 의 service account 로 token 을 발급하여 갱신한다.
+func NewGKEKubeconfig(ctx context.Context, c client.Reader, namespace string, gke *clusterV1alpha1.GKERegistration) ([]byte, error) {
+	secret, err := getCredentialsSecret(ctx, c, namespace, gke.CredentialsSecret)
+	if err != nil {
+		return nil, err
+	}
+	ts, err := newGKETokenSource(secret)
+	if err != nil {
+		return nil, err
+	}
+
+	endpoint := fmt.Sprintf("https://container.googleapis.com/v1/projects/%s/locations/%s/clusters/%s",
+		url.PathEscape(gke.Project), url.PathEscape(gke.Location), url.PathEscape(gke.Cluster))
+	body, err := doCloudRequest(ctx, ts, http.MethodGet, endpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	described := struct {
+		Endpoint   string `json:"endpoint"`
+		MasterAuth struct {
+			ClusterCaCertificate string `json:"clusterCaCertificate"`
+		} `json:"masterAuth"`
+	}{}
+	if err := json.Unmarshal(body, &described); err != nil {
+		return nil, err
+	}
+	if described.Endpoint == "" {
+		return nil, fmt.Errorf("endpoint of GKE cluster %q is not ready", gke.Cluster)
+	}
+	ca, err := b64.StdEncoding.DecodeString(described.MasterAuth.ClusterCaCertificate)
+	if err != nil {
+		return nil, err
+	}
+
+	name := fmt.Sprintf("gke_%s_%s_%s", gke.Project, gke.Location, gke.Cluster)
+	return newExecKubeconfig(name, ServerURL(described.Endpoint), ca, &clientcmdapi.ExecConfig{
+		APIVersion:         execAPIVersion,
+		Command:            gkeExecCommand,
+		ProvideClusterInfo: true,
+		InteractiveMode:    clientcmdapi.NeverExecInteractiveMode,
+	})
+}
+
+func newGKETokenSource(secret *coreV1.Secret) (oauth2.TokenSource, error) {
+	key, err := secretValue(secret, GKECredentialsKey)
+	if err != nil {
+		return nil, err
+	}
+	creds, err := google.CredentialsFromJSON(context.Background(), []byte(key), gkeScopes...)
+	if err != nil {
+		return nil, err
+	}
+	return creds.TokenSource, nil
+}
@@ -0,0 +1,70 @@
+package util
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/healthz"
+)
+
+const healthCheckTimeout = 5 * time.Second
+
+// webhook server 의 serving cert 를 읽어 유효한 cert 인지 확인한다.
+// cert 가 없거나, key 와 맞지 않거나, 만료된 경우 실패한다.
+func WebhookCertChecker(certDir, certName, keyName string) healthz.Checker {
+	return func(_ *http.Request) error {
+		cert, err := LoadWebhookCert(certDir, certName, keyName)
+		if err != nil {
+			return err
+		}
+		if time.Now().After(cert.NotAfter) {
+			return fmt.Errorf("webhook serving cert expired at %s", cert.NotAfter.Format(time.RFC3339))
+		}
+		return nil
+	}
+}
+
+// certDir 의 cert, key 로 keypair 를 만들고 leaf cert 를 반환한다.
+func LoadWebhookCert(certDir, certName, keyName string) (*x509.Certificate, error) {
+	keyPair, err := tls.LoadX509KeyPair(filepath.Join(certDir, certName), filepath.Join(certDir, keyName))
+	if err != nil {
+		return nil, err
+	}
+	return x509.ParseCertificate(keyPair.Certificate[0])
+}
+
+// webhook server 가 tls 연결을 맺을 수 있는지 확인한다.
+// webhook server 가 동작하지 않으면 CRD 에 대한 모든 요청이 실패하므로 liveness probe 에 등록한다.
+func WebhookServerChecker(host string, port int) healthz.Checker {
+	return func(_ *http.Request) error {
+		dialer := &net.Dialer{Timeout: healthCheckTimeout}
+		conn, err := tls.DialWithDialer(dialer, "tcp", net.JoinHostPort(host, strconv.Itoa(port)), &tls.Config{InsecureSkipVerify: true})
+		if err != nil {
+			return fmt.Errorf("webhook server is not serving: %w", err)
+		}
+		return conn.Close()
+	}
+}
+
+// 주어진 list 에 해당하는 resource 를 조회할 수 있는지 확인한다.
+// CRD 가 삭제되었거나 권한이 없는 경우 실패한다.
+func CRDListChecker(reader client.Reader, lists ...client.ObjectList) healthz.Checker {
+	return func(_ *http.Request) error {
+		ctx, cancel := context.WithTimeout(context.Background(), healthCheckTimeout)
+		defer cancel()
+		for _, list := range lists {
+			if err := reader.List(ctx, list, client.Limit(1)); err != nil {
+				return fmt.Errorf("failed to list %T: %w", list, err)
+			}
+		}
+		return nil
+	}
+}
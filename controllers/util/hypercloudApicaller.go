@@ -2,86 +2,259 @@ package util
 
 import (
 	"bytes"
-	"crypto/tls"
+	"context"
 	"encoding/json"
+	"fmt"
 	"io/ioutil"
-	"log"
 	"net/http"
+	neturl "net/url"
 	"strings"
+	"time"
 
 	clusterV1alpha1 "github.com/tmax-cloud/hypercloud-multi-operator/apis/cluster/v1alpha1"
+	"github.com/tmax-cloud/hypercloud-multi-operator/controllers/metrics"
 )
 
+const (
+	HypercloudApiServerUrl = "https://hypercloud5-api-server-service.hypercloud5-system.svc.cluster.local"
+)
+
+// cluster 와 cluster 의 member 정보를 db 에서 삭제한다.
 func Delete(namespace, cluster string) error {
+	return dbCircuitBreaker.Do(func() error {
+		return deleteCluster(namespace, cluster)
+	})
+}
+
+func deleteCluster(namespace, cluster string) error {
 	// hypercloud api call
-	url := "https://hypercloud5-api-server-service.hypercloud5-system.svc.cluster.local/namespaces/{namespace}/clustermanagers/{clustermanager}"
+	url := HypercloudApiServerUrl + "/namespaces/{namespace}/clustermanagers/{clustermanager}"
 
-	tr := &http.Transport{
-		TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
-	}
 	url = strings.Replace(url, "{namespace}", namespace, -1)
 	url = strings.Replace(url, "{clustermanager}", cluster, -1)
-	client := &http.Client{Transport: tr}
 	req, err := http.NewRequest("DELETE", url, nil)
 	if err != nil {
-		log.Fatalf("An Error Occurred %v", err)
 		return err
 	}
-	_, err = client.Do(req)
+	resp, err := dbHTTPClient.Do(req)
 
-	// _, err := client.Post(url, "application/json", nil)
+	// _, err := dbHTTPClient.Post(url, "application/json", nil)
 	if err != nil {
-		log.Fatalf("An Error Occurred %v", err)
-		return err
+		metrics.RecordDBWriteFailure("delete_cluster")
+		return NewError(ErrDBUnavailable, err)
+	}
+	defer resp.Body.Close()
+
+	if !IsOK(resp.StatusCode) && resp.StatusCode != http.StatusNotFound {
+		metrics.RecordDBWriteFailure("delete_cluster")
+		return ClassifyStatusCode(resp.StatusCode, fmt.Errorf("failed to delete cluster [%s]: %s", cluster, resp.Status))
 	}
 	return nil
 }
 
+// cluster 정보를 db 에 쓴다.
+// DBWriter 가 동작 중이면 다른 요청과 모아서 쓰고, 결과가 나올 때까지 기다린다.
 func Insert(clusterManager *clusterV1alpha1.ClusterManager) error {
+	if dbWriter != nil {
+		if result := dbWriter.enqueue(clusterManager); result != nil {
+			return <-result
+		}
+	}
+	return insertCluster(clusterManager)
+}
+
+func insertCluster(clusterManager *clusterV1alpha1.ClusterManager) error {
+	return dbCircuitBreaker.Do(func() error {
+		return postCluster(clusterManager)
+	})
+}
+
+func postCluster(clusterManager *clusterV1alpha1.ClusterManager) error {
 	// hypercloud api call
-	url := "https://hypercloud5-api-server-service.hypercloud5-system.svc.cluster.local/namespaces/{namespace}/clustermanagers/{clustermanager}"
+	url := HypercloudApiServerUrl + "/namespaces/{namespace}/clustermanagers/{clustermanager}"
 
-	tr := &http.Transport{
-		TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
-	}
-	// http.
 	url = strings.Replace(url, "{namespace}", clusterManager.Namespace, -1)
 	url = strings.Replace(url, "{clustermanager}", clusterManager.Name, -1)
-	client := &http.Client{Transport: tr}
 
 	// person := Person{"Alex", 10}
 	data, _ := json.Marshal(clusterManager)
 	buff := bytes.NewBuffer(data)
-	_, err := client.Post(url, "application/json", buff)
+	resp, err := dbHTTPClient.Post(url, "application/json", buff)
 
 	if err != nil {
-		log.Fatalf("An Error Occurred %v", err)
-		return err
+		metrics.RecordDBWriteFailure("insert_cluster")
+		return NewError(ErrDBUnavailable, err)
+	}
+	defer resp.Body.Close()
+
+	if !IsOK(resp.StatusCode) && resp.StatusCode != http.StatusConflict {
+		metrics.RecordDBWriteFailure("insert_cluster")
+		return ClassifyStatusCode(resp.StatusCode, fmt.Errorf("failed to insert cluster [%s]: %s", clusterManager.Name, resp.Status))
 	}
 	return nil
 }
 
 func List(namespace, cluster string) ([]byte, error) {
+	var members []byte
+	err := dbCircuitBreaker.Do(func() error {
+		var err error
+		members, err = listMembers(namespace, cluster)
+		return err
+	})
+	return members, err
+}
+
+func listMembers(namespace, cluster string) ([]byte, error) {
 	// hypercloud api call
-	url := "https://hypercloud5-api-server-service.hypercloud5-system.svc.cluster.local/namespaces/{namespace}/clustermanagers/{clustermanager}/member/{member}"
-	tr := &http.Transport{
-		TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
-	}
+	url := HypercloudApiServerUrl + "/namespaces/{namespace}/clustermanagers/{clustermanager}/member/{member}"
 	url = strings.Replace(url, "{namespace}", namespace, -1)
 	url = strings.Replace(url, "{clustermanager}", cluster, -1)
 	url = strings.Replace(url, "{member}", "all", -1)
-	client := &http.Client{Transport: tr}
 	req, err := http.NewRequest("GET", url, nil)
 	if err != nil {
-		log.Fatalf("An Error Occurred %v", err)
 		return nil, err
 	}
-	resp, err := client.Do(req)
+	resp, err := dbHTTPClient.Do(req)
 	if err != nil {
-		log.Fatalf("An Error Occurred %v", err)
-		return nil, err
+		return nil, NewError(ErrDBUnavailable, err)
 	}
 	defer resp.Body.Close()
-	bytes, _ := ioutil.ReadAll(resp.Body)
+
+	if !IsOK(resp.StatusCode) {
+		return nil, ClassifyStatusCode(resp.StatusCode, fmt.Errorf("failed to list members of cluster [%s]: %s", cluster, resp.Status))
+	}
+	bytes, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, NewError(ErrDBUnavailable, err)
+	}
 	return bytes, nil
 }
+
+func InsertMember(clusterMember *clusterV1alpha1.ClusterMember) error {
+	return dbCircuitBreaker.Do(func() error {
+		return postMember(clusterMember)
+	})
+}
+
+func postMember(clusterMember *clusterV1alpha1.ClusterMember) error {
+	// hypercloud api call
+	url := HypercloudApiServerUrl + "/namespaces/{namespace}/clustermanagers/{clustermanager}/member/{member}"
+
+	url = strings.Replace(url, "{namespace}", clusterMember.Namespace, -1)
+	url = strings.Replace(url, "{clustermanager}", clusterMember.Spec.ClusterName, -1)
+	// member id 는 email 이나 group 이름이므로 path 에 넣기 전에 escape 한다.
+	url = strings.Replace(url, "{member}", neturl.PathEscape(clusterMember.Spec.MemberId), -1)
+
+	data, err := json.Marshal(clusterMember)
+	if err != nil {
+		return err
+	}
+	resp, err := dbHTTPClient.Post(url, "application/json", bytes.NewBuffer(data))
+	if err != nil {
+		metrics.RecordDBWriteFailure("insert_member")
+		return NewError(ErrDBUnavailable, err)
+	}
+	defer resp.Body.Close()
+
+	if !IsOK(resp.StatusCode) && resp.StatusCode != http.StatusConflict {
+		metrics.RecordDBWriteFailure("insert_member")
+		return ClassifyStatusCode(resp.StatusCode, fmt.Errorf("failed to insert member [%s]: %s", clusterMember.Spec.MemberId, resp.Status))
+	}
+	return nil
+}
+
+// role 이 변경된 member 의 정보를 db 에 다시 쓴다.
+func UpdateMember(clusterMember *clusterV1alpha1.ClusterMember) error {
+	return dbCircuitBreaker.Do(func() error {
+		return putMember(clusterMember)
+	})
+}
+
+func putMember(clusterMember *clusterV1alpha1.ClusterMember) error {
+	// hypercloud api call
+	url := HypercloudApiServerUrl + "/namespaces/{namespace}/clustermanagers/{clustermanager}/member/{member}"
+
+	url = strings.Replace(url, "{namespace}", clusterMember.Namespace, -1)
+	url = strings.Replace(url, "{clustermanager}", clusterMember.Spec.ClusterName, -1)
+	url = strings.Replace(url, "{member}", neturl.PathEscape(clusterMember.Spec.MemberId), -1)
+
+	data, err := json.Marshal(clusterMember)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPut, url, bytes.NewBuffer(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := dbHTTPClient.Do(req)
+	if err != nil {
+		metrics.RecordDBWriteFailure("update_member")
+		return NewError(ErrDBUnavailable, err)
+	}
+	defer resp.Body.Close()
+
+	if !IsOK(resp.StatusCode) {
+		metrics.RecordDBWriteFailure("update_member")
+		return ClassifyStatusCode(resp.StatusCode, fmt.Errorf("failed to update member [%s]: %s", clusterMember.Spec.MemberId, resp.Status))
+	}
+	return nil
+}
+
+func DeleteMember(namespace, cluster, member, attribute string) error {
+	return dbCircuitBreaker.Do(func() error {
+		return deleteMember(namespace, cluster, member, attribute)
+	})
+}
+
+func deleteMember(namespace, cluster, member, attribute string) error {
+	// hypercloud api call
+	url := HypercloudApiServerUrl + "/namespaces/{namespace}/clustermanagers/{clustermanager}/member/{member}?attribute={attribute}"
+
+	url = strings.Replace(url, "{namespace}", namespace, -1)
+	url = strings.Replace(url, "{clustermanager}", cluster, -1)
+	url = strings.Replace(url, "{member}", neturl.PathEscape(member), -1)
+	url = strings.Replace(url, "{attribute}", neturl.QueryEscape(attribute), -1)
+	req, err := http.NewRequest("DELETE", url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := dbHTTPClient.Do(req)
+	if err != nil {
+		metrics.RecordDBWriteFailure("delete_member")
+		return NewError(ErrDBUnavailable, err)
+	}
+	defer resp.Body.Close()
+
+	if !IsOK(resp.StatusCode) && resp.StatusCode != http.StatusNotFound {
+		metrics.RecordDBWriteFailure("delete_member")
+		return ClassifyStatusCode(resp.StatusCode, fmt.Errorf("failed to delete member [%s]: %s", member, resp.Status))
+	}
+	return nil
+}
+
+// hypercloud api server 를 통해 db 에 접근 가능한지 확인한다.
+// 응답이 오지 않거나 5xx 응답이 오는 경우 ErrDBUnavailable 을 반환한다.
+func CheckDBHealth() error {
+	url := HypercloudApiServerUrl + "/namespaces/{namespace}/clustermanagers/{clustermanager}/member/{member}"
+	url = strings.Replace(url, "{namespace}", "default", -1)
+	url = strings.Replace(url, "{clustermanager}", "db-health-check", -1)
+	url = strings.Replace(url, "{member}", "all", -1)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := dbHTTPClient.Do(req)
+	if err != nil {
+		return NewError(ErrDBUnavailable, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusInternalServerError {
+		return NewError(ErrDBUnavailable, fmt.Errorf("hypercloud api server responded %s", resp.Status))
+	}
+	return nil
+}
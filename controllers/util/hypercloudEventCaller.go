@@ -0,0 +1,235 @@
+package util
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/tmax-cloud/hypercloud-multi-operator/controllers/metrics"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+const (
+	// cluster lifecycle event 를 전달받는 hypercloud api server 의 endpoint
+	HypercloudServiceClusterEvent = "/namespaces/{namespace}/clustermanagers/{clustermanager}/events"
+	// hypercloud api server 인증에 사용하는 operator 의 service account token
+	ServiceAccountTokenPath = "/var/run/secrets/kubernetes.io/serviceaccount/token"
+	// HC_API_SERVER_CA_FILE 이 설정되지 않은 경우 hypercloud api server 의 serving cert 검증에 사용하는 CA
+	ServiceAccountCAPath = "/var/run/secrets/kubernetes.io/serviceaccount/ca.crt"
+)
+
+type ClusterEventType string
+
+const (
+	ClusterEventTypeClusterCreated = ClusterEventType("ClusterCreated")
+	ClusterEventTypeClusterDeleted = ClusterEventType("ClusterDeleted")
+	ClusterEventTypeMemberAdded    = ClusterEventType("MemberAdded")
+	ClusterEventTypeMemberChanged  = ClusterEventType("MemberChanged")
+	ClusterEventTypeMemberRemoved  = ClusterEventType("MemberRemoved")
+)
+
+// hypercloud api server 로 전달하는 cluster lifecycle event
+type ClusterEvent struct {
+	Type        ClusterEventType `json:"type"`
+	Namespace   string           `json:"namespace"`
+	ClusterName string           `json:"clusterName"`
+	Owner       string           `json:"owner,omitempty"`
+	MemberId    string           `json:"memberId,omitempty"`
+	Attribute   string           `json:"attribute,omitempty"`
+	Role        string           `json:"role,omitempty"`
+	Timestamp   time.Time        `json:"timestamp"`
+}
+
+// event 전송 실패시 재시도 정책
+var clusterEventBackoff = wait.Backoff{
+	Duration: 500 * time.Millisecond,
+	Factor:   2.0,
+	Jitter:   0.1,
+	Steps:    4,
+}
+
+const (
+	// 전송을 기다리는 event 의 상한으로, 가득 차면 새 event 는 버린다.
+	clusterEventQueueSize = 1000
+
+	ClusterEventDropReasonQueueFull      = "queue_full"
+	ClusterEventDropReasonDeliveryFailed = "delivery_failed"
+)
+
+type clusterEventRequest struct {
+	url  string
+	data []byte
+	// log 에 남기기 위한 event 정보
+	event ClusterEvent
+}
+
+// ClusterEventPusher 는 PushClusterEvent 로 요청된 event 를 queue 에 쌓아두고 순서대로 hypercloud api server 로 전송한다.
+// reconcile 이 전송과 재시도를 기다리지 않도록, queue 가 가득 차면 event 를 버리고 error 를 반환한다.
+// manager 가 시작되기 전에 요청된 event 는 queue 에 남아있다가 시작된 후에 전송된다.
+type ClusterEventPusher struct {
+	Log logr.Logger
+
+	queue chan clusterEventRequest
+}
+
+// PushClusterEvent 가 사용하는 pusher 로, NewClusterEventPusher 로 설정한다.
+var clusterEventPusher *ClusterEventPusher
+
+// pusher 를 생성하고 PushClusterEvent 가 사용하도록 설정한다. 반환된 pusher 는 manager 에 등록해야 한다.
+func NewClusterEventPusher(log logr.Logger) *ClusterEventPusher {
+	clusterEventPusher = &ClusterEventPusher{
+		Log:   log,
+		queue: make(chan clusterEventRequest, clusterEventQueueSize),
+	}
+	return clusterEventPusher
+}
+
+func (p *ClusterEventPusher) Start(ctx context.Context) error {
+	for {
+		select {
+		case <-ctx.Done():
+			if n := len(p.queue); n > 0 {
+				p.Log.Info("Dropping undelivered cluster events on shutdown", "count", n)
+				metrics.ClusterEventsDroppedTotal.WithLabelValues(ClusterEventDropReasonDeliveryFailed).Add(float64(n))
+			}
+			return nil
+		case req := <-p.queue:
+			if err := deliverClusterEvent(ctx, req); err != nil {
+				p.Log.Error(err, "Failed to push cluster event",
+					"type", req.event.Type, "namespace", req.event.Namespace, "cluster", req.event.ClusterName)
+				metrics.ClusterEventsDroppedTotal.WithLabelValues(ClusterEventDropReasonDeliveryFailed).Inc()
+			}
+		}
+	}
+}
+
+// reconciler 는 leader 에서만 event 를 요청하므로 leader 가 아니어도 동작한다.
+func (p *ClusterEventPusher) NeedLeaderElection() bool {
+	return false
+}
+
+func (p *ClusterEventPusher) enqueue(req clusterEventRequest) error {
+	select {
+	case p.queue <- req:
+		return nil
+	default:
+		metrics.ClusterEventsDroppedTotal.WithLabelValues(ClusterEventDropReasonQueueFull).Inc()
+		return fmt.Errorf("cluster event queue is full, dropping %s event of %s/%s",
+			req.event.Type, req.event.Namespace, req.event.ClusterName)
+	}
+}
+
+// HC_EVENT_PUSH 가 true 인 경우, cluster lifecycle event 를 hypercloud api server 로 전송하도록 queue 에 넣는다.
+// pusher 가 설정되지 않은 경우에는 바로 전송한다.
+func PushClusterEvent(event ClusterEvent) error {
+	if !IsTrue(os.Getenv(HC_EVENT_PUSH)) {
+		return nil
+	}
+	if event.Timestamp.IsZero() {
+		event.Timestamp = time.Now()
+	}
+
+	url := HypercloudApiServerUrl + HypercloudServiceClusterEvent
+	url = strings.Replace(url, "{namespace}", event.Namespace, -1)
+	url = strings.Replace(url, "{clustermanager}", event.ClusterName, -1)
+
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	req := clusterEventRequest{url: url, data: data, event: event}
+	if clusterEventPusher == nil {
+		return deliverClusterEvent(context.Background(), req)
+	}
+	return clusterEventPusher.enqueue(req)
+}
+
+// 일시적인 error 에 대해서는 backoff 에 따라 재시도하고, 마지막 error 를 반환한다.
+func deliverClusterEvent(ctx context.Context, req clusterEventRequest) error {
+	var lastErr error
+	err := wait.ExponentialBackoffWithContext(ctx, clusterEventBackoff, func() (bool, error) {
+		lastErr = postClusterEvent(req.url, req.data)
+		if lastErr == nil {
+			return true, nil
+		}
+		if !IsRetryable(lastErr) {
+			return false, lastErr
+		}
+		return false, nil
+	})
+	if err == wait.ErrWaitTimeout && lastErr != nil {
+		return lastErr
+	}
+	return err
+}
+
+var (
+	clusterEventClientOnce sync.Once
+	clusterEventClient     *http.Client
+	clusterEventClientErr  error
+)
+
+// service account token 을 함께 보내므로 hypercloud api server 의 serving cert 를 CA 로 검증하는 client 를 사용한다.
+// CA 를 읽지 못하면 token 이 노출되지 않도록 event 를 보내지 않는다.
+func getClusterEventClient() (*http.Client, error) {
+	clusterEventClientOnce.Do(func() {
+		caFile := os.Getenv(HC_API_SERVER_CA_FILE)
+		if caFile == "" {
+			caFile = ServiceAccountCAPath
+		}
+		caPEM, err := ioutil.ReadFile(caFile)
+		if err != nil {
+			clusterEventClientErr = fmt.Errorf("failed to read hypercloud api server CA %s: %w", caFile, err)
+			return
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(caPEM) {
+			clusterEventClientErr = fmt.Errorf("no certificate found in hypercloud api server CA %s", caFile)
+			return
+		}
+		clusterEventClient = &http.Client{
+			Transport: &http.Transport{
+				TLSClientConfig: &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12},
+			},
+			Timeout: 10 * time.Second,
+		}
+	})
+	return clusterEventClient, clusterEventClientErr
+}
+
+func postClusterEvent(url string, data []byte) error {
+	client, err := getClusterEventClient()
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewBuffer(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Add("Content-Type", "application/json")
+	if token, err := ioutil.ReadFile(ServiceAccountTokenPath); err == nil {
+		req.Header.Add("Authorization", "Bearer "+strings.TrimSpace(string(token)))
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return NewError(ErrDBUnavailable, err)
+	}
+	defer resp.Body.Close()
+
+	if !IsOK(resp.StatusCode) {
+		return ClassifyStatusCode(resp.StatusCode, fmt.Errorf("failed to push cluster event: %s", resp.Status))
+	}
+	return nil
+}
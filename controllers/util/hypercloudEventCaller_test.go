@@ -0,0 +1,91 @@
+package util
+
+import (
+	"context"
+	"encoding/pem"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+func resetClusterEventClient() {
+	clusterEventClientOnce = sync.Once{}
+	clusterEventClient = nil
+	clusterEventClientErr = nil
+}
+
+func TestPushClusterEventQueueFull(t *testing.T) {
+	t.Setenv(HC_EVENT_PUSH, "true")
+	defer func() { clusterEventPusher = nil }()
+	clusterEventPusher = &ClusterEventPusher{queue: make(chan clusterEventRequest, 1)}
+
+	event := ClusterEvent{Type: ClusterEventTypeClusterCreated, Namespace: "default", ClusterName: "cluster"}
+	if err := PushClusterEvent(event); err != nil {
+		t.Fatalf("first event: unexpected error %v", err)
+	}
+	// queue 가 가득 차면 기다리지 않고 바로 error 를 반환해야 한다.
+	if err := PushClusterEvent(event); err == nil {
+		t.Fatalf("second event: expected queue full error")
+	}
+	if n := len(clusterEventPusher.queue); n != 1 {
+		t.Errorf("expected 1 queued event, got %d", n)
+	}
+}
+
+func TestDeliverClusterEvent(t *testing.T) {
+	var mu sync.Mutex
+	var authorization string
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		authorization = r.Header.Get("Authorization")
+		mu.Unlock()
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	trustedCA := filepath.Join(dir, "ca.crt")
+	caPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	if err := ioutil.WriteFile(trustedCA, caPEM, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	// 실패한 경우 재시도를 기다리지 않도록 한 번만 시도한다.
+	backoff := clusterEventBackoff
+	defer func() { clusterEventBackoff = backoff }()
+	clusterEventBackoff = wait.Backoff{Steps: 1}
+
+	cases := []struct {
+		name    string
+		caFile  string
+		wantErr bool
+	}{
+		{name: "trusted ca", caFile: trustedCA},
+		{name: "missing ca", caFile: filepath.Join(dir, "missing.crt"), wantErr: true},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Setenv(HC_API_SERVER_CA_FILE, tc.caFile)
+			resetClusterEventClient()
+			defer resetClusterEventClient()
+			mu.Lock()
+			authorization = ""
+			mu.Unlock()
+
+			err := deliverClusterEvent(context.Background(), clusterEventRequest{url: server.URL, data: []byte("{}")})
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("expected error %v, got %v", tc.wantErr, err)
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			if tc.wantErr && authorization != "" {
+				t.Errorf("token must not be sent without a trusted CA")
+			}
+		})
+	}
+}
@@ -0,0 +1,100 @@
+package util
+
+import (
+	"context"
+
+	claimV1alpha1 "github.com/tmax-cloud/hypercloud-multi-operator/apis/claim/v1alpha1"
+	clusterV1alpha1 "github.com/tmax-cloud/hypercloud-multi-operator/apis/cluster/v1alpha1"
+	coreV1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// ClusterRegistration, ClusterClaim, ClusterUpdateClaim 의 spec.clusterName
+	IndexKeyClusterName = "spec.clusterName"
+	// kubeconfig secret 이 가리키는 cluster manager 의 이름
+	IndexKeyKubeconfigCluster = "kubeconfig.clusterName"
+)
+
+// 다른 resource 를 조회할 때 사용하는 field index 를 manager cache 에 등록한다.
+// 이름을 조합해서 Get 하는 대신 index 로 List 하여, label 로 연결된 object 를 찾는다.
+// manager 가 시작되기 전에 호출해야 한다.
+func SetupIndexes(ctx context.Context, mgr ctrl.Manager) error {
+	indexer := mgr.GetFieldIndexer()
+
+	if err := indexer.IndexField(ctx, &clusterV1alpha1.ClusterRegistration{}, IndexKeyClusterName,
+		func(o client.Object) []string {
+			return indexValue(o.(*clusterV1alpha1.ClusterRegistration).Spec.ClusterName)
+		},
+	); err != nil {
+		return err
+	}
+	if err := indexer.IndexField(ctx, &claimV1alpha1.ClusterClaim{}, IndexKeyClusterName,
+		func(o client.Object) []string {
+			return indexValue(o.(*claimV1alpha1.ClusterClaim).Spec.ClusterName)
+		},
+	); err != nil {
+		return err
+	}
+	if err := indexer.IndexField(ctx, &claimV1alpha1.ClusterUpdateClaim{}, IndexKeyClusterName,
+		func(o client.Object) []string {
+			return indexValue(o.(*claimV1alpha1.ClusterUpdateClaim).Spec.ClusterName)
+		},
+	); err != nil {
+		return err
+	}
+	return indexer.IndexField(ctx, &coreV1.Secret{}, IndexKeyKubeconfigCluster,
+		func(o client.Object) []string {
+			return indexValue(KubeconfigClusterName(o.(*coreV1.Secret)))
+		},
+	)
+}
+
+func indexValue(value string) []string {
+	if value == "" {
+		return nil
+	}
+	return []string{value}
+}
+
+// kubeconfig secret 이 가리키는 cluster manager 의 이름을 반환한다.
+// kubeconfig secret 이 아니면 빈 문자열을 반환한다.
+// capi 가 생성한 kubeconfig secret 은 secret controller 가 label 을 달기 전까지 capi 의 cluster-name label 만 가지고 있다.
+func KubeconfigClusterName(secret *coreV1.Secret) string {
+	labels := secret.GetLabels()
+	if labels[LabelKeyClmSecretType] == ClmSecretTypeKubeconfig {
+		if name := labels[clusterV1alpha1.LabelKeyClmName]; name != "" {
+			return name
+		}
+	}
+	// capi 는 같은 label 로 ca, etcd 등의 secret 도 생성하므로 이름으로 kubeconfig secret 을 구분한다.
+	if name := labels[LabelKeyCapiClusterName]; name != "" && secret.Name == name+KubeconfigSuffix {
+		return name
+	}
+	return ""
+}
+
+// namespace 에서 clusterName 에 해당하는 kubeconfig secret 을 찾는다.
+// 없으면 NotFound error 를 반환한다.
+func GetKubeconfigSecret(ctx context.Context, c client.Reader, namespace, clusterName string) (*coreV1.Secret, error) {
+	secrets := &coreV1.SecretList{}
+	if err := c.List(ctx, secrets,
+		client.InNamespace(namespace),
+		client.MatchingFields{IndexKeyKubeconfigCluster: clusterName},
+	); err != nil {
+		return nil, err
+	}
+	if len(secrets.Items) == 0 {
+		return nil, errors.NewNotFound(coreV1.Resource("secrets"), clusterName+KubeconfigSuffix)
+	}
+
+	// 여러 개인 경우 기본 이름을 가진 secret 을 우선한다.
+	for i := range secrets.Items {
+		if secrets.Items[i].Name == clusterName+KubeconfigSuffix {
+			return &secrets.Items[i], nil
+		}
+	}
+	return &secrets.Items[0], nil
+}
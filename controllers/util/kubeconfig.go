@@ -0,0 +1,95 @@
+package util
+
+import (
+	"context"
+	b64 "encoding/base64"
+	"fmt"
+	"net/url"
+
+	restclient "k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+)
+
+// Kubeconfig 는 reconcile 한 번 동안 여러 phase 에서 공유하는 parse 된 kubeconfig 이다.
+// phase 마다 decode 와 clientcmd.Load 를 반복하지 않도록 reconcile 시작 시 한 번만 parse 하고 context 로 전달한다.
+type Kubeconfig struct {
+	// decode 된 kubeconfig 원본
+	Raw    []byte
+	Config *clientcmdapi.Config
+	// current-context 가 가리키는 cluster 의 api server 주소
+	Server string
+	// operator 가 managed cluster 에 대해 생성한 kubeconfig 인 경우에만 설정한다.
+	Auth *ManagedClusterAuth
+}
+
+type kubeconfigContextKey struct{}
+
+// kubeconfig 를 parse 하고 current-context 의 cluster 를 확인한다.
+func ParseKubeconfig(raw []byte) (*Kubeconfig, error) {
+	config, err := clientcmd.Load(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	kubeContext, ok := config.Contexts[config.CurrentContext]
+	if !ok {
+		return nil, fmt.Errorf("context %q is not found in kubeconfig", config.CurrentContext)
+	}
+	cluster, ok := config.Clusters[kubeContext.Cluster]
+	if !ok || cluster.Server == "" {
+		return nil, fmt.Errorf("server of cluster %q is not found in kubeconfig", kubeContext.Cluster)
+	}
+
+	return &Kubeconfig{
+		Raw:    raw,
+		Config: config,
+		Server: cluster.Server,
+	}, nil
+}
+
+// base64 로 encode 된 kubeconfig 를 decode 한 뒤 parse 한다.
+func ParseEncodedKubeconfig(encoded string) (*Kubeconfig, error) {
+	raw, err := b64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, err
+	}
+	return ParseKubeconfig(raw)
+}
+
+// api server 주소에서 scheme 과 port 를 제외한 host 를 반환한다.
+func (k *Kubeconfig) Host() (string, error) {
+	u, err := url.Parse(k.Server)
+	if err != nil {
+		return "", err
+	}
+	if u.Hostname() == "" {
+		return "", fmt.Errorf("endpoint is empty")
+	}
+	return u.Hostname(), nil
+}
+
+func (k *Kubeconfig) RESTConfig() (*restclient.Config, error) {
+	config, err := clientcmd.NewDefaultClientConfig(*k.Config, &clientcmd.ConfigOverrides{}).ClientConfig()
+	if err != nil {
+		return nil, err
+	}
+	if err := configureManagedClusterAuth(config, k.Auth); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+func WithKubeconfig(ctx context.Context, kubeconfig *Kubeconfig) context.Context {
+	return context.WithValue(ctx, kubeconfigContextKey{}, kubeconfig)
+}
+
+// reconcile 시작 시 context 에 저장한 kubeconfig 를 반환한다.
+// 저장되어 있지 않으면 error 를 반환한다.
+func KubeconfigFrom(ctx context.Context) (*Kubeconfig, error) {
+	kubeconfig, ok := ctx.Value(kubeconfigContextKey{}).(*Kubeconfig)
+	if !ok || kubeconfig == nil {
+		return nil, fmt.Errorf("kubeconfig is not parsed in this reconcile")
+	}
+	return kubeconfig, nil
+}
@@ -0,0 +1,219 @@
+package util
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	b64 "encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"time"
+
+	"github.com/go-logr/logr"
+	coreV1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// kubeconfig 를 복호화하는 private key 를 가지고 있는 secret (hypercloud5-system namespace)
+	KubeconfigEncryptionKeySecret = "hypercloud-multi-operator-kubeconfig-key"
+	// 사용자가 kubeconfig 를 암호화할 때 사용하는 public key 를 공개하는 configmap (hypercloud5-system namespace)
+	KubeconfigEncryptionPublicKeyConfigMap = "hypercloud-multi-operator-kubeconfig-public-key"
+
+	KubeconfigEncryptionPrivateKey = "private.pem"
+	KubeconfigEncryptionPublicKey  = "public.pem"
+
+	kubeconfigEncryptionKeyBits   = 3072
+	kubeconfigEncryptionNonceSize = 12
+)
+
+// key pair 를 준비하지 못한 경우 재시도 간격, 실패할 때마다 두 배로 늘린다.
+var (
+	kubeconfigKeyRetryInitial = time.Second
+	kubeconfigKeyRetryMax     = 5 * time.Minute
+)
+
+// KubeconfigKeyManager 는 operator 가 시작될 때 kubeconfig 암호화에 사용하는 rsa key pair 가 없으면 생성하고,
+// public key 를 configmap 으로 공개한다.
+type KubeconfigKeyManager struct {
+	Reader client.Reader
+	Client client.Client
+	Log    logr.Logger
+}
+
+// api server 에 일시적으로 접근할 수 없는 경우에도 key 가 준비되도록 성공할 때까지 backoff 로 재시도한다.
+func (m *KubeconfigKeyManager) Start(ctx context.Context) error {
+	delay := kubeconfigKeyRetryInitial
+	for {
+		err := m.ensureKeyPair(ctx)
+		if err == nil {
+			return nil
+		}
+		m.Log.Error(err, "Failed to ensure kubeconfig encryption key", "retryAfter", delay.String())
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(wait.Jitter(delay, 0.1)):
+		}
+		if delay *= 2; delay > kubeconfigKeyRetryMax {
+			delay = kubeconfigKeyRetryMax
+		}
+	}
+}
+
+// secret 과 configmap 을 생성하므로 leader 만 동작한다.
+func (m *KubeconfigKeyManager) NeedLeaderElection() bool {
+	return true
+}
+
+func (m *KubeconfigKeyManager) ensureKeyPair(ctx context.Context) error {
+	secret := &coreV1.Secret{}
+	key := types.NamespacedName{Name: KubeconfigEncryptionKeySecret, Namespace: HypercloudNamespace}
+	if err := m.Reader.Get(ctx, key, secret); errors.IsNotFound(err) {
+		privateKey, err := rsa.GenerateKey(rand.Reader, kubeconfigEncryptionKeyBits)
+		if err != nil {
+			return err
+		}
+		der, err := x509.MarshalPKCS8PrivateKey(privateKey)
+		if err != nil {
+			return err
+		}
+		secret = &coreV1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      KubeconfigEncryptionKeySecret,
+				Namespace: HypercloudNamespace,
+				Labels: map[string]string{
+					LabelKeyManagedBy: ManagedByOperator,
+				},
+			},
+			Data: map[string][]byte{
+				KubeconfigEncryptionPrivateKey: pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}),
+			},
+		}
+		if err := m.Client.Create(ctx, secret); err != nil {
+			return err
+		}
+		m.Log.Info("Created kubeconfig encryption key", "secret", KubeconfigEncryptionKeySecret)
+	} else if err != nil {
+		return err
+	}
+
+	privateKey, err := parseKubeconfigEncryptionKey(secret)
+	if err != nil {
+		return err
+	}
+	der, err := x509.MarshalPKIXPublicKey(&privateKey.PublicKey)
+	if err != nil {
+		return err
+	}
+	publicKey := string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+
+	configMap := &coreV1.ConfigMap{}
+	key = types.NamespacedName{Name: KubeconfigEncryptionPublicKeyConfigMap, Namespace: HypercloudNamespace}
+	if err := m.Reader.Get(ctx, key, configMap); errors.IsNotFound(err) {
+		configMap = &coreV1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      KubeconfigEncryptionPublicKeyConfigMap,
+				Namespace: HypercloudNamespace,
+				Labels: map[string]string{
+					LabelKeyManagedBy: ManagedByOperator,
+				},
+			},
+			Data: map[string]string{
+				KubeconfigEncryptionPublicKey: publicKey,
+			},
+		}
+		return m.Client.Create(ctx, configMap)
+	} else if err != nil {
+		return err
+	}
+	// key 를 교체한 경우 public key 를 갱신한다.
+	if configMap.Data[KubeconfigEncryptionPublicKey] == publicKey {
+		return nil
+	}
+	if configMap.Data == nil {
+		configMap.Data = map[string]string{}
+	}
+	configMap.Data[KubeconfigEncryptionPublicKey] = publicKey
+	return m.Client.Update(ctx, configMap)
+}
+
+func parseKubeconfigEncryptionKey(secret *coreV1.Secret) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode(secret.Data[KubeconfigEncryptionPrivateKey])
+	if block == nil {
+		return nil, fmt.Errorf("%s of secret %s is not a pem encoded key", KubeconfigEncryptionPrivateKey, secret.Name)
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	privateKey, ok := key.(*rsa.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("%s of secret %s is not a rsa key", KubeconfigEncryptionPrivateKey, secret.Name)
+	}
+	return privateKey, nil
+}
+
+// DecryptKubeconfig 는 operator 의 public key 로 암호화된 kubeconfig 를 복호화한다.
+// 암호문은 base64(rsa-oaep-sha256 으로 암호화한 aes-256 key | 12 byte nonce | aes-gcm 으로 암호화한 kubeconfig) 형식이다.
+// namespace 에 tenant key 가 설정된 경우에는 tenant key 로 암호화한 kubeconfig 를 복호화하고,
+// tenant key 를 설정하기 전에 operator 의 public key 로 암호화한 kubeconfig 도 계속 복호화한다.
+func DecryptKubeconfig(ctx context.Context, c client.Reader, namespace, encrypted string) ([]byte, error) {
+	data, err := b64.StdEncoding.DecodeString(encrypted)
+	if err != nil {
+		return nil, err
+	}
+	tenantKey, err := GetTenantKey(ctx, c, namespace)
+	if err != nil {
+		return nil, err
+	}
+	if tenantKey != nil {
+		// rsa-oaep 와 aes-gcm 의 검증을 모두 통과해야 하므로 tenant key 의 암호문이 operator key 로 복호화되지는 않는다.
+		if kubeconfig, err := decryptWithOperatorKey(ctx, c, data); err == nil {
+			return kubeconfig, nil
+		}
+		return DecryptWithTenantKey(ctx, tenantKey, data)
+	}
+	return decryptWithOperatorKey(ctx, c, data)
+}
+
+func decryptWithOperatorKey(ctx context.Context, c client.Reader, data []byte) ([]byte, error) {
+	secret := &coreV1.Secret{}
+	key := types.NamespacedName{Name: KubeconfigEncryptionKeySecret, Namespace: HypercloudNamespace}
+	if err := c.Get(ctx, key, secret); err != nil {
+		return nil, err
+	}
+	privateKey, err := parseKubeconfigEncryptionKey(secret)
+	if err != nil {
+		return nil, err
+	}
+
+	keySize := privateKey.Size()
+	if len(data) < keySize+kubeconfigEncryptionNonceSize {
+		return nil, fmt.Errorf("encrypted kubeconfig is too short")
+	}
+
+	aesKey, err := rsa.DecryptOAEP(sha256.New(), rand.Reader, privateKey, data[:keySize], nil)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(aesKey)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCMWithNonceSize(block, kubeconfigEncryptionNonceSize)
+	if err != nil {
+		return nil, err
+	}
+	nonce := data[keySize : keySize+kubeconfigEncryptionNonceSize]
+	return gcm.Open(nil, nonce, data[keySize+kubeconfigEncryptionNonceSize:], nil)
+}
@@ -0,0 +1,174 @@
+package util
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	b64 "encoding/base64"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	coreV1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+// 처음 failures 번의 Get 요청을 실패시키는 reader
+type flakyReader struct {
+	client.Reader
+	failures int
+	calls    int
+}
+
+func (r *flakyReader) Get(ctx context.Context, key client.ObjectKey, obj client.Object) error {
+	r.calls++
+	if r.calls <= r.failures {
+		return errors.New("connection refused")
+	}
+	return r.Reader.Get(ctx, key, obj)
+}
+
+func TestKubeconfigKeyManagerStart(t *testing.T) {
+	initial, max := kubeconfigKeyRetryInitial, kubeconfigKeyRetryMax
+	kubeconfigKeyRetryInitial, kubeconfigKeyRetryMax = time.Millisecond, 2*time.Millisecond
+	defer func() {
+		kubeconfigKeyRetryInitial, kubeconfigKeyRetryMax = initial, max
+	}()
+
+	scheme := runtime.NewScheme()
+	if err := clientgoscheme.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("retry until key pair is created", func(t *testing.T) {
+		c := fake.NewClientBuilder().WithScheme(scheme).Build()
+		reader := &flakyReader{Reader: c, failures: 3}
+		m := &KubeconfigKeyManager{Reader: reader, Client: c, Log: logr.Discard()}
+
+		ctx, cancel := context.WithTimeout(context.TODO(), 10*time.Second)
+		defer cancel()
+		if err := m.Start(ctx); err != nil {
+			t.Fatalf("Start() error = %v", err)
+		}
+		if ctx.Err() != nil {
+			t.Fatal("Start() should return after key pair is created")
+		}
+
+		secret := &coreV1.Secret{}
+		if err := c.Get(ctx, types.NamespacedName{Name: KubeconfigEncryptionKeySecret, Namespace: HypercloudNamespace}, secret); err != nil {
+			t.Fatalf("failed to get key secret: %v", err)
+		}
+		if _, err := parseKubeconfigEncryptionKey(secret); err != nil {
+			t.Errorf("invalid private key: %v", err)
+		}
+		configMap := &coreV1.ConfigMap{}
+		if err := c.Get(ctx, types.NamespacedName{Name: KubeconfigEncryptionPublicKeyConfigMap, Namespace: HypercloudNamespace}, configMap); err != nil {
+			t.Fatalf("failed to get public key configmap: %v", err)
+		}
+		if configMap.Data[KubeconfigEncryptionPublicKey] == "" {
+			t.Error("public key is not published")
+		}
+	})
+
+	t.Run("stop retrying when context is done", func(t *testing.T) {
+		c := fake.NewClientBuilder().WithScheme(scheme).Build()
+		reader := &flakyReader{Reader: c, failures: 1 << 30}
+		m := &KubeconfigKeyManager{Reader: reader, Client: c, Log: logr.Discard()}
+
+		ctx, cancel := context.WithTimeout(context.TODO(), 50*time.Millisecond)
+		defer cancel()
+		if err := m.Start(ctx); err != nil {
+			t.Fatalf("Start() error = %v", err)
+		}
+		if reader.calls < 2 {
+			t.Errorf("Get is called %d times, want retries", reader.calls)
+		}
+	})
+}
+
+// operator 의 public key 로 kubeconfig 를 암호화한다.
+func encryptKubeconfigWithOperatorKey(t *testing.T, publicKey *rsa.PublicKey, kubeconfig []byte) string {
+	aesKey := make([]byte, 32)
+	nonce := make([]byte, kubeconfigEncryptionNonceSize)
+	if _, err := rand.Read(aesKey); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := rand.Read(nonce); err != nil {
+		t.Fatal(err)
+	}
+	wrapped, err := rsa.EncryptOAEP(sha256.New(), rand.Reader, publicKey, aesKey, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	block, err := aes.NewCipher(aesKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gcm, err := cipher.NewGCMWithNonceSize(block, kubeconfigEncryptionNonceSize)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := append(append(wrapped, nonce...), gcm.Seal(nil, nonce, kubeconfig, nil)...)
+	return b64.StdEncoding.EncodeToString(data)
+}
+
+func TestDecryptKubeconfig(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := clientgoscheme.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.TODO()
+	kubeconfig := []byte("apiVersion: v1\nkind: Config\n")
+
+	plain := &coreV1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "plain"}}
+	tenant := &coreV1.Namespace{ObjectMeta: metav1.ObjectMeta{
+		Name:        "tenant",
+		Annotations: map[string]string{AnnotationKeyTenantVaultTransitKey: "tenant"},
+	}}
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(plain, tenant).Build()
+	m := &KubeconfigKeyManager{Reader: c, Client: c, Log: logr.Discard()}
+	if err := m.ensureKeyPair(ctx); err != nil {
+		t.Fatal(err)
+	}
+	secret := &coreV1.Secret{}
+	if err := c.Get(ctx, types.NamespacedName{Name: KubeconfigEncryptionKeySecret, Namespace: HypercloudNamespace}, secret); err != nil {
+		t.Fatal(err)
+	}
+	privateKey, err := parseKubeconfigEncryptionKey(secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	legacy := encryptKubeconfigWithOperatorKey(t, &privateKey.PublicKey, kubeconfig)
+
+	tests := []struct {
+		name      string
+		namespace string
+		encrypted string
+		wantErr   bool
+	}{
+		{name: "operator key", namespace: "plain", encrypted: legacy},
+		// tenant key 를 설정하기 전에 제출된 kubeconfig
+		{name: "operator key in tenant namespace", namespace: "tenant", encrypted: legacy},
+		{name: "invalid ciphertext in tenant namespace", namespace: "tenant", encrypted: b64.StdEncoding.EncodeToString([]byte("invalid")), wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := DecryptKubeconfig(ctx, c, tt.namespace, tt.encrypted)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("DecryptKubeconfig() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && string(got) != string(kubeconfig) {
+				t.Errorf("DecryptKubeconfig() = %q, want %q", got, kubeconfig)
+			}
+		})
+	}
+}
@@ -0,0 +1,65 @@
+package util
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// License token 의 payload
+type LicensePayload struct {
+	Customer    string     `json:"customer"`
+	MaxClusters int        `json:"maxClusters"`
+	ExpiresAt   *time.Time `json:"expiresAt,omitempty"`
+}
+
+// LICENSE_PUBLIC_KEY 의 ed25519 public key 를 반환한다.
+// 설정되지 않은 경우 nil 을 반환한다.
+func GetLicensePublicKey() (ed25519.PublicKey, error) {
+	encoded := os.Getenv(LICENSE_PUBLIC_KEY)
+	if encoded == "" {
+		return nil, nil
+	}
+	key, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, err
+	}
+	if len(key) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("public key must be %d bytes", ed25519.PublicKeySize)
+	}
+	return ed25519.PublicKey(key), nil
+}
+
+// "<payload>.<signature>" 형식의 token 의 서명을 검증하고 payload 를 반환한다.
+// payload 와 signature 는 base64url 로 인코딩되어 있다.
+func VerifyLicenseToken(token string, publicKey ed25519.PublicKey) (*LicensePayload, error) {
+	parts := strings.Split(strings.TrimSpace(token), ".")
+	if len(parts) != 2 {
+		return nil, errors.New("token must be in the form of <payload>.<signature>")
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode payload: %w", err)
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode signature: %w", err)
+	}
+	if !ed25519.Verify(publicKey, payload, signature) {
+		return nil, errors.New("signature does not match")
+	}
+
+	license := &LicensePayload{}
+	if err := json.Unmarshal(payload, license); err != nil {
+		return nil, fmt.Errorf("failed to parse payload: %w", err)
+	}
+	if license.MaxClusters <= 0 {
+		return nil, errors.New("maxClusters must be greater than 0")
+	}
+	return license, nil
+}
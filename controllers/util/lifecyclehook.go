@@ -0,0 +1,68 @@
+package util
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	clusterV1alpha1 "github.com/tmax-cloud/hypercloud-multi-operator/apis/cluster/v1alpha1"
+	coreV1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// lifecycle hook job 의 container 에 추가하는 env
+	LifecycleHookEnvClusterName      = "CLUSTER_NAME"
+	LifecycleHookEnvClusterNamespace = "CLUSTER_NAMESPACE"
+	LifecycleHookEnvPoint            = "HOOK_POINT"
+
+	lifecycleHookWebhookTimeout = 30 * time.Second
+)
+
+// lifecycle hook webhook 으로 전달하는 요청
+type LifecycleHookRequest struct {
+	Point       clusterV1alpha1.LifecycleHookPoint `json:"point"`
+	Hook        string                             `json:"hook"`
+	Namespace   string                             `json:"namespace"`
+	ClusterName string                             `json:"clusterName"`
+	Timestamp   time.Time                          `json:"timestamp"`
+}
+
+// hook 의 secret 에 있는 url 로 요청을 보낸다. 2xx 가 아닌 응답은 hook 의 실패로 간주한다.
+func CallLifecycleHookWebhook(ctx context.Context, c client.Reader, hook *clusterV1alpha1.LifecycleHook, request LifecycleHookRequest) error {
+	secret := &coreV1.Secret{}
+	key := types.NamespacedName{Name: hook.Spec.Webhook.SecretName, Namespace: hook.Namespace}
+	if err := c.Get(ctx, key, secret); err != nil {
+		return err
+	}
+	url := string(secret.Data[clusterV1alpha1.LifecycleHookSecretKeyURL])
+	if url == "" {
+		return fmt.Errorf("secret %s does not have %s", key.Name, clusterV1alpha1.LifecycleHookSecretKeyURL)
+	}
+
+	data, err := json.Marshal(request)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewBuffer(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Add("Content-Type", "application/json")
+
+	httpClient := &http.Client{Timeout: lifecycleHookWebhookTimeout}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if !IsOK(resp.StatusCode) {
+		return ClassifyStatusCode(resp.StatusCode, fmt.Errorf("lifecycle hook webhook responded %s", resp.Status))
+	}
+	return nil
+}
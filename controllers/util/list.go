@@ -0,0 +1,50 @@
+package util
+
+import (
+	"context"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/client-go/tools/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// remote cluster 에 list 요청을 보낼 때 한 번에 가져오는 object 의 수
+const ListPageSize = 500
+
+// list 를 ListPageSize 단위로 나누어 조회하고, page 를 가져올 때마다 fn 을 호출한다.
+// fn 이 false 를 반환하면 다음 page 를 조회하지 않는다.
+// node, event 처럼 member cluster 의 규모에 따라 커지는 resource 를 한 번에 메모리에 올리지 않도록 한다.
+// manager 의 cache 는 continue 를 지원하지 않으므로 remote cluster 의 client 나 api reader 에만 사용한다.
+func ListPages(ctx context.Context, c client.Reader, list client.ObjectList, fn func() (bool, error), opts ...client.ListOption) error {
+	continueToken := ""
+	for {
+		pageOpts := append([]client.ListOption{}, opts...)
+		pageOpts = append(pageOpts, client.Limit(ListPageSize), client.Continue(continueToken))
+		if err := c.List(ctx, list, pageOpts...); err != nil {
+			return err
+		}
+		if next, err := fn(); err != nil || !next {
+			return err
+		}
+
+		continueToken = list.GetContinue()
+		if continueToken == "" {
+			return nil
+		}
+	}
+}
+
+// cache 에 저장하는 object 의 managedFields 를 제거하여 메모리 사용량을 줄인다.
+// managedFields 가 없는 object 로 update 하더라도 api server 는 기존 managedFields 를 유지한다.
+func StripManagedFields(obj interface{}) (interface{}, error) {
+	// informer 가 삭제 event 로 전달하는 tombstone 은 그대로 둔다.
+	if _, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		return obj, nil
+	}
+	accessor, err := meta.Accessor(obj)
+	if err != nil {
+		return obj, nil
+	}
+	accessor.SetManagedFields(nil)
+	return obj, nil
+}
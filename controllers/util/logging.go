@@ -0,0 +1,203 @@
+package util
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/go-logr/zapr"
+	uberzap "go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	coreV1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+)
+
+const (
+	LogFormatJSON    = "json"
+	LogFormatConsole = "console"
+
+	// log 설정 configmap 의 key
+	LogConfigKeyLevel  = "level"
+	LogConfigKeyFormat = "format"
+
+	logConfigSyncInterval = 30 * time.Second
+)
+
+// LogSettings 는 실행 중에 log level 과 format 을 변경할 수 있도록 한다.
+type LogSettings struct {
+	level   uberzap.AtomicLevel
+	console *atomic.Bool
+}
+
+// opts 와 format 으로 logger 를 생성한다.
+// format 이 비어있으면 opts.Development 에 따라 console 또는 json 을 사용한다.
+func NewLogger(opts *zap.Options, format string) (logr.Logger, *LogSettings, error) {
+	settings := &LogSettings{
+		console: &atomic.Bool{},
+	}
+
+	// --zap-log-level flag 로 설정된 level 을 초기값으로 사용한다.
+	switch lvl := opts.Level.(type) {
+	case uberzap.AtomicLevel:
+		settings.level = lvl
+	case zapcore.Level:
+		settings.level = uberzap.NewAtomicLevelAt(lvl)
+	default:
+		settings.level = uberzap.NewAtomicLevelAt(zapcore.InfoLevel)
+		if opts.Development {
+			settings.level.SetLevel(zapcore.DebugLevel)
+		}
+	}
+
+	if format == "" {
+		format = LogFormatJSON
+		if opts.Development {
+			format = LogFormatConsole
+		}
+	}
+	if err := settings.SetFormat(format); err != nil {
+		return logr.Logger{}, nil, err
+	}
+
+	consoleLogger := zap.NewRaw(zap.UseFlagOptions(opts), zap.ConsoleEncoder(), zap.Level(settings.level))
+	jsonLogger := zap.NewRaw(zap.UseFlagOptions(opts), zap.JSONEncoder(), zap.Level(settings.level))
+	logger := jsonLogger.WithOptions(
+		uberzap.WrapCore(func(core zapcore.Core) zapcore.Core {
+			return &formatCore{
+				json:       core,
+				console:    consoleLogger.Core(),
+				useConsole: settings.console,
+			}
+		}),
+	)
+	return zapr.NewLogger(logger), settings, nil
+}
+
+// debug, info, error 또는 verbosity 를 나타내는 양의 정수를 받는다.
+func (s *LogSettings) SetLevel(level string) error {
+	switch level {
+	case "debug":
+		s.level.SetLevel(zapcore.DebugLevel)
+	case "info":
+		s.level.SetLevel(zapcore.InfoLevel)
+	case "error":
+		s.level.SetLevel(zapcore.ErrorLevel)
+	default:
+		v, err := strconv.Atoi(level)
+		if err != nil || v <= 0 {
+			return fmt.Errorf("invalid log level %q", level)
+		}
+		s.level.SetLevel(zapcore.Level(int8(-v)))
+	}
+	return nil
+}
+
+func (s *LogSettings) SetFormat(format string) error {
+	switch format {
+	case LogFormatJSON:
+		s.console.Store(false)
+	case LogFormatConsole:
+		s.console.Store(true)
+	default:
+		return fmt.Errorf("invalid log format %q", format)
+	}
+	return nil
+}
+
+// formatCore 는 json, console core 를 모두 가지고 있다가 현재 설정된 format 의 core 로 기록한다.
+type formatCore struct {
+	json       zapcore.Core
+	console    zapcore.Core
+	useConsole *atomic.Bool
+}
+
+func (c *formatCore) current() zapcore.Core {
+	if c.useConsole.Load() {
+		return c.console
+	}
+	return c.json
+}
+
+func (c *formatCore) Enabled(level zapcore.Level) bool {
+	return c.current().Enabled(level)
+}
+
+func (c *formatCore) With(fields []zapcore.Field) zapcore.Core {
+	return &formatCore{
+		json:       c.json.With(fields),
+		console:    c.console.With(fields),
+		useConsole: c.useConsole,
+	}
+}
+
+func (c *formatCore) Check(entry zapcore.Entry, checked *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	return c.current().Check(entry, checked)
+}
+
+func (c *formatCore) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	return c.current().Write(entry, fields)
+}
+
+func (c *formatCore) Sync() error {
+	if err := c.json.Sync(); err != nil {
+		return err
+	}
+	return c.console.Sync()
+}
+
+// LogConfigWatcher 는 주기적으로 configmap 을 읽어 log level 과 format 을 반영한다.
+// configmap 이 없거나 key 가 비어있으면 현재 설정을 유지한다.
+// +kubebuilder:rbac:groups="",resources=configmaps,verbs=get
+type LogConfigWatcher struct {
+	// cache 를 사용하면 모든 configmap 을 watch 하게 되므로 api reader 를 사용한다.
+	Reader   client.Reader
+	Log      logr.Logger
+	Settings *LogSettings
+	Key      types.NamespacedName
+}
+
+func (w *LogConfigWatcher) Start(ctx context.Context) error {
+	ticker := time.NewTicker(logConfigSyncInterval)
+	defer ticker.Stop()
+
+	for {
+		w.sync(ctx)
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// leader 가 아닌 replica 도 log 설정을 반영해야 한다.
+func (w *LogConfigWatcher) NeedLeaderElection() bool {
+	return false
+}
+
+func (w *LogConfigWatcher) sync(ctx context.Context) {
+	cm := &coreV1.ConfigMap{}
+	if err := w.Reader.Get(ctx, w.Key, cm); errors.IsNotFound(err) {
+		return
+	} else if err != nil {
+		w.Log.Error(err, "Failed to get log config", "configMap", w.Key)
+		return
+	}
+
+	if level := cm.Data[LogConfigKeyLevel]; level != "" {
+		if err := w.Settings.SetLevel(level); err != nil {
+			w.Log.Error(err, "Failed to set log level", "configMap", w.Key)
+		}
+	}
+	if format := cm.Data[LogConfigKeyFormat]; format != "" {
+		if err := w.Settings.SetFormat(format); err != nil {
+			w.Log.Error(err, "Failed to set log format", "configMap", w.Key)
+		}
+	}
+}
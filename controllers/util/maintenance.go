@@ -0,0 +1,30 @@
+package util
+
+import (
+	"time"
+
+	"github.com/robfig/cron"
+	clusterV1alpha1 "github.com/tmax-cloud/hypercloud-multi-operator/apis/cluster/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// now 시점의 유지보수 시간 상태를 계산한다.
+// 현재 유지보수 시간 안이면 그 종료 시간을, 아니면 다음 유지보수 시간의 시작 시간을 함께 반환한다.
+func GetMaintenanceWindowStatus(window *clusterV1alpha1.MaintenanceWindow, now time.Time) (*clusterV1alpha1.MaintenanceWindowStatus, error) {
+	schedule, err := cron.ParseStandard(window.Schedule)
+	if err != nil {
+		return nil, err
+	}
+	now = now.UTC()
+
+	status := &clusterV1alpha1.MaintenanceWindowStatus{}
+	// now - duration 이후의 첫 시작 시간이 now 보다 이르면 그 유지보수 시간 안에 있다.
+	if start := schedule.Next(now.Add(-window.Duration.Duration)); !start.IsZero() && !start.After(now) {
+		status.Active = true
+		status.CurrentWindowEnd = &metav1.Time{Time: start.Add(window.Duration.Duration)}
+	}
+	if next := schedule.Next(now); !next.IsZero() {
+		status.NextWindowStart = &metav1.Time{Time: next}
+	}
+	return status, nil
+}
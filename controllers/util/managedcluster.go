@@ -0,0 +1,252 @@
+package util
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	clusterV1alpha1 "github.com/tmax-cloud/hypercloud-multi-operator/apis/cluster/v1alpha1"
+	"golang.org/x/oauth2"
+	coreV1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	restclient "k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+	"k8s.io/client-go/transport"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// EKS, GKE, AKS 처럼 cloud 의 IAM 으로 인증하는 managed cluster 의 kubeconfig 는 exec plugin 을 사용한다.
+// operator 는 exec plugin 을 실행하지 않고, kubeconfig secret 에 기록한 ManagedClusterAuth 에 따라 직접 token 을 발급하고 만료 전에 갱신한다.
+// 사용자가 입력한 kubeconfig 의 exec 설정은 신뢰할 수 없으므로 token 발급에 사용하지 않는다.
+
+const (
+	ManagedClusterAuthEKS = "eks"
+	ManagedClusterAuthGKE = "gke"
+	ManagedClusterAuthAKS = "aks"
+)
+
+// ManagedClusterAuth 는 operator 가 managed cluster 의 token 을 발급하기 위한 정보로,
+// ClusterRegistration 의 spec 으로 만들어 operator 가 생성하는 kubeconfig secret 의 annotation 에 기록한다.
+type ManagedClusterAuth struct {
+	Provider string
+	// kubeconfig secret 의 namespace
+	Namespace string
+	// EKS cluster 의 ARN 과 operator 가 assume 할 role 의 ARN
+	ClusterARN string
+	RoleARN    string
+	// GKE, AKS 의 credential secret 이름, 다른 tenant 의 credential 을 사용하지 않도록 Namespace 에서만 찾는다.
+	CredentialsSecret string
+}
+
+func NewEKSClusterAuth(namespace string, eks *clusterV1alpha1.EKSRegistration) *ManagedClusterAuth {
+	return &ManagedClusterAuth{
+		Provider:   ManagedClusterAuthEKS,
+		Namespace:  namespace,
+		ClusterARN: eks.ClusterARN,
+		RoleARN:    eks.RoleARN,
+	}
+}
+
+func NewGKEClusterAuth(namespace string, gke *clusterV1alpha1.GKERegistration) *ManagedClusterAuth {
+	return &ManagedClusterAuth{
+		Provider:          ManagedClusterAuthGKE,
+		Namespace:         namespace,
+		CredentialsSecret: gke.CredentialsSecret,
+	}
+}
+
+func NewAKSClusterAuth(namespace string, aks *clusterV1alpha1.AKSRegistration) *ManagedClusterAuth {
+	return &ManagedClusterAuth{
+		Provider:          ManagedClusterAuthAKS,
+		Namespace:         namespace,
+		CredentialsSecret: aks.CredentialsSecret,
+	}
+}
+
+// kubeconfig secret 에 기록할 annotation
+func (a *ManagedClusterAuth) Annotations() map[string]string {
+	annotations := map[string]string{
+		AnnotationKeyManagedClusterAuth: a.Provider,
+	}
+	switch a.Provider {
+	case ManagedClusterAuthEKS:
+		annotations[AnnotationKeyEKSClusterARN] = a.ClusterARN
+		annotations[AnnotationKeyEKSRoleARN] = a.RoleARN
+	case ManagedClusterAuthGKE, ManagedClusterAuthAKS:
+		annotations[AnnotationKeyCredentialsSecret] = a.CredentialsSecret
+	}
+	return annotations
+}
+
+// kubeconfig secret 의 annotation 으로 ManagedClusterAuth 를 만든다. operator 가 생성한 secret 이 아니면 nil 을 반환한다.
+func ManagedClusterAuthFromSecret(secret *coreV1.Secret) *ManagedClusterAuth {
+	annotations := secret.GetAnnotations()
+	provider := annotations[AnnotationKeyManagedClusterAuth]
+	if provider == "" {
+		return nil
+	}
+	return &ManagedClusterAuth{
+		Provider:          provider,
+		Namespace:         secret.Namespace,
+		ClusterARN:        annotations[AnnotationKeyEKSClusterARN],
+		RoleARN:           annotations[AnnotationKeyEKSRoleARN],
+		CredentialsSecret: annotations[AnnotationKeyCredentialsSecret],
+	}
+}
+
+// annotation 이 변경되면 remote client 를 새로 생성하도록 hash 에 포함한다.
+func (a *ManagedClusterAuth) String() string {
+	if a == nil {
+		return ""
+	}
+	return strings.Join([]string{a.Provider, a.Namespace, a.ClusterARN, a.RoleARN, a.CredentialsSecret}, "/")
+}
+
+// managed cluster 의 kubeconfig 에서 사용하는 exec plugin 의 api version
+const execAPIVersion = "client.authentication.k8s.io/v1beta1"
+
+const cloudRequestTimeout = 30 * time.Second
+
+// cloud 의 api 를 호출할 때 사용하는 client
+var cloudHTTPClient = &http.Client{Timeout: cloudRequestTimeout}
+
+// GKE, AKS 의 token 을 갱신할 때 credential secret 을 조회하는 reader 로, SetCredentialsReader 로 설정한다.
+var credentialsReader client.Reader
+
+// manager 가 생성된 후 호출해야 한다.
+func SetCredentialsReader(reader client.Reader) {
+	credentialsReader = reader
+}
+
+// rest config 로 transport 를 생성하기 전에 호출해야 한다.
+// 사용자가 입력한 kubeconfig 의 exec plugin 은 operator 의 pod 에서 임의의 command 를 실행하게 되므로 거부한다.
+func configureManagedClusterAuth(config *restclient.Config, auth *ManagedClusterAuth) error {
+	if config.ExecProvider == nil {
+		return nil
+	}
+	if auth == nil {
+		return fmt.Errorf("exec plugin %q is not allowed in kubeconfig", config.ExecProvider.Command)
+	}
+	switch auth.Provider {
+	case ManagedClusterAuthEKS:
+		return configureEKSAuth(config, auth)
+	case ManagedClusterAuthGKE:
+		return configureSecretTokenAuth(config, auth, newGKETokenSource)
+	case ManagedClusterAuthAKS:
+		return configureSecretTokenAuth(config, auth, newAKSTokenSource)
+	}
+	return fmt.Errorf("unknown managed cluster auth %q", auth.Provider)
+}
+
+// kubeconfig secret 과 같은 namespace 의 credential secret 으로 token 을 발급하도록 설정한다.
+// oauth2 의 token source 가 만료 전에 token 을 갱신하고, api server 가 401 을 응답하면 다음 요청에서 새로 발급한다.
+func configureSecretTokenAuth(config *restclient.Config, auth *ManagedClusterAuth, newTokenSource func(*coreV1.Secret) (oauth2.TokenSource, error)) error {
+	if auth.Namespace == "" || auth.CredentialsSecret == "" {
+		return fmt.Errorf("credentials secret of %s cluster is not set", auth.Provider)
+	}
+	key := types.NamespacedName{Namespace: auth.Namespace, Name: auth.CredentialsSecret}
+	ts := transport.NewCachedTokenSource(&secretTokenSource{key: key, newTokenSource: newTokenSource})
+	config.ExecProvider = nil
+	config.Wrap(transport.ResettableTokenSourceWrapTransport(ts))
+	return nil
+}
+
+// token 이 만료될 때마다 credential secret 을 다시 읽으므로, secret 의 credential 이 교체되어도 반영된다.
+type secretTokenSource struct {
+	key            types.NamespacedName
+	newTokenSource func(*coreV1.Secret) (oauth2.TokenSource, error)
+}
+
+func (s *secretTokenSource) Token() (*oauth2.Token, error) {
+	secret, err := getCredentialsSecret(context.Background(), credentialsReader, s.key.Namespace, s.key.Name)
+	if err != nil {
+		return nil, err
+	}
+	ts, err := s.newTokenSource(secret)
+	if err != nil {
+		return nil, err
+	}
+	return ts.Token()
+}
+
+func getCredentialsSecret(ctx context.Context, c client.Reader, namespace, name string) (*coreV1.Secret, error) {
+	if c == nil {
+		return nil, fmt.Errorf("credentials reader is not set")
+	}
+	secret := &coreV1.Secret{}
+	if err := c.Get(ctx, types.NamespacedName{Namespace: namespace, Name: name}, secret); err != nil {
+		return nil, err
+	}
+	return secret, nil
+}
+
+func secretValue(secret *coreV1.Secret, key string) (string, error) {
+	value := strings.TrimSpace(string(secret.Data[key]))
+	if value == "" {
+		return "", fmt.Errorf("secret [%s] does not have %s", secret.Name, key)
+	}
+	return value, nil
+}
+
+// 하나의 cluster 와 exec plugin 으로 구성된 kubeconfig 를 생성한다.
+func newExecKubeconfig(name, server string, ca []byte, exec *clientcmdapi.ExecConfig) ([]byte, error) {
+	config := clientcmdapi.NewConfig()
+	config.Clusters[name] = &clientcmdapi.Cluster{
+		Server:                   server,
+		CertificateAuthorityData: ca,
+	}
+	config.AuthInfos[name] = &clientcmdapi.AuthInfo{
+		Exec: exec,
+	}
+	config.Contexts[name] = &clientcmdapi.Context{
+		Cluster:  name,
+		AuthInfo: name,
+	}
+	config.CurrentContext = name
+	return clientcmd.Write(*config)
+}
+
+// cloud 의 api 를 oauth2 token 으로 호출한다.
+func doCloudRequest(ctx context.Context, ts oauth2.TokenSource, method, endpoint string) ([]byte, error) {
+	token, err := ts.Token()
+	if err != nil {
+		// credential 이 잘못된 경우 token endpoint 는 4xx 를 응답한다.
+		var retrieveErr *oauth2.RetrieveError
+		if errors.As(err, &retrieveErr) && retrieveErr.Response != nil && retrieveErr.Response.StatusCode < http.StatusInternalServerError {
+			return nil, NewError(ErrPermissionDenied, err)
+		}
+		return nil, ClassifyRemoteError(err)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	token.SetAuthHeader(req)
+	return doCloudHTTPRequest(req)
+}
+
+func doCloudHTTPRequest(req *http.Request) ([]byte, error) {
+	resp, err := cloudHTTPClient.Do(req)
+	if err != nil {
+		return nil, ClassifyRemoteError(err)
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, NewError(ErrRemoteUnreachable, err)
+	}
+	if !IsOK(resp.StatusCode) {
+		err := fmt.Errorf("request to %s failed: %s: %s", req.URL.Host, resp.Status, strings.TrimSpace(string(body)))
+		if resp.StatusCode >= http.StatusInternalServerError {
+			return nil, NewError(ErrRemoteUnreachable, err)
+		}
+		return nil, ClassifyStatusCode(resp.StatusCode, err)
+	}
+	return body, nil
+}
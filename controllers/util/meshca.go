@@ -0,0 +1,108 @@
+package util
+
+import (
+	cryptorand "crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"time"
+)
+
+const (
+	meshRootCAValidity         = 10 * 365 * 24 * time.Hour
+	meshIntermediateCAValidity = 2 * 365 * 24 * time.Hour
+	meshCAKeySize              = 4096
+)
+
+// mesh 의 모든 cluster 가 공유하는 self-signed root CA 의 인증서와 key 를 pem 으로 반환한다.
+func NewMeshRootCA(meshID string) ([]byte, []byte, error) {
+	key, err := rsa.GenerateKey(cryptorand.Reader, meshCAKeySize)
+	if err != nil {
+		return nil, nil, err
+	}
+	template, err := newMeshCATemplate(pkix.Name{Organization: []string{meshID}, CommonName: "Root CA"}, meshRootCAValidity)
+	if err != nil {
+		return nil, nil, err
+	}
+	template.MaxPathLen = 1
+
+	cert, err := x509.CreateCertificate(cryptorand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return nil, nil, err
+	}
+	return encodeCertificate(cert), encodePrivateKey(key), nil
+}
+
+// root CA 로 서명한 cluster 별 intermediate CA 의 인증서와 key 를 pem 으로 반환한다.
+// istiod 는 intermediate CA 로 workload 인증서를 발급하므로 cluster 사이에 mTLS 가 가능해진다.
+func NewMeshIntermediateCA(rootCertPEM, rootKeyPEM []byte, clusterName string) ([]byte, []byte, error) {
+	rootCert, rootKey, err := parseMeshCA(rootCertPEM, rootKeyPEM)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	key, err := rsa.GenerateKey(cryptorand.Reader, meshCAKeySize)
+	if err != nil {
+		return nil, nil, err
+	}
+	template, err := newMeshCATemplate(pkix.Name{Organization: rootCert.Subject.Organization, CommonName: "Intermediate CA", Locality: []string{clusterName}}, meshIntermediateCAValidity)
+	if err != nil {
+		return nil, nil, err
+	}
+	template.MaxPathLenZero = true
+	template.DNSNames = []string{"istiod.istio-system.svc"}
+
+	cert, err := x509.CreateCertificate(cryptorand.Reader, template, rootCert, &key.PublicKey, rootKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	return encodeCertificate(cert), encodePrivateKey(key), nil
+}
+
+func newMeshCATemplate(subject pkix.Name, validity time.Duration) (*x509.Certificate, error) {
+	serial, err := cryptorand.Int(cryptorand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	return &x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               subject,
+		NotBefore:             now.Add(-5 * time.Minute),
+		NotAfter:              now.Add(validity),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}, nil
+}
+
+func parseMeshCA(certPEM, keyPEM []byte) (*x509.Certificate, *rsa.PrivateKey, error) {
+	certBlock, _ := pem.Decode(certPEM)
+	if certBlock == nil {
+		return nil, nil, errors.New("failed to decode root certificate")
+	}
+	cert, err := x509.ParseCertificate(certBlock.Bytes)
+	if err != nil {
+		return nil, nil, err
+	}
+	keyBlock, _ := pem.Decode(keyPEM)
+	if keyBlock == nil {
+		return nil, nil, errors.New("failed to decode root key")
+	}
+	key, err := x509.ParsePKCS1PrivateKey(keyBlock.Bytes)
+	if err != nil {
+		return nil, nil, err
+	}
+	return cert, key, nil
+}
+
+func encodeCertificate(der []byte) []byte {
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+}
+
+func encodePrivateKey(key *rsa.PrivateKey) []byte {
+	return pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+}
@@ -0,0 +1,97 @@
+package util
+
+import (
+	"os"
+	"strings"
+
+	coreV1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	NetworkPolicyDefaultDenyIngress     = "default-deny-ingress"
+	NetworkPolicyAllowSameNamespace     = "allow-same-namespace"
+	NetworkPolicyAllowPlatformNamespace = "allow-platform-namespaces"
+)
+
+func getEnvList(env string) []string {
+	list := []string{}
+	for _, item := range strings.Split(os.Getenv(env), ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
+// default network policy 를 배포할 member cluster 의 namespace 목록
+func GetDefaultNetworkPolicyNamespaces() []string {
+	return getEnvList(DEFAULT_NETWORK_POLICY_NAMESPACES)
+}
+
+// default network policy 에서 ingress 를 허용할 platform namespace 목록
+func GetDefaultNetworkPolicyPlatformNamespaces() []string {
+	return getEnvList(DEFAULT_NETWORK_POLICY_PLATFORM_NAMESPACES)
+}
+
+// namespace 에 배포할 default network policy 목록을 반환한다.
+// 모든 ingress 를 막고, 같은 namespace 와 platform namespace 로부터의 ingress 만 허용한다.
+// egress 는 제한하지 않는다.
+func DefaultNetworkPolicies(namespace string, platformNamespaces []string, labels map[string]string) []*networkingv1.NetworkPolicy {
+	newPolicy := func(name string, ingress []networkingv1.NetworkPolicyIngressRule) *networkingv1.NetworkPolicy {
+		policyLabels := map[string]string{
+			LabelKeyDefaultNetworkPolicy: "true",
+		}
+		for k, v := range labels {
+			policyLabels[k] = v
+		}
+		return &networkingv1.NetworkPolicy{
+			TypeMeta: metav1.TypeMeta{
+				APIVersion: networkingv1.SchemeGroupVersion.String(),
+				Kind:       "NetworkPolicy",
+			},
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: namespace,
+				Labels:    policyLabels,
+			},
+			Spec: networkingv1.NetworkPolicySpec{
+				PodSelector: metav1.LabelSelector{},
+				Ingress:     ingress,
+				PolicyTypes: []networkingv1.PolicyType{networkingv1.PolicyTypeIngress},
+			},
+		}
+	}
+
+	policies := []*networkingv1.NetworkPolicy{
+		newPolicy(NetworkPolicyDefaultDenyIngress, nil),
+		newPolicy(NetworkPolicyAllowSameNamespace, []networkingv1.NetworkPolicyIngressRule{
+			{
+				From: []networkingv1.NetworkPolicyPeer{
+					{PodSelector: &metav1.LabelSelector{}},
+				},
+			},
+		}),
+	}
+	if len(platformNamespaces) != 0 {
+		policies = append(policies, newPolicy(NetworkPolicyAllowPlatformNamespace, []networkingv1.NetworkPolicyIngressRule{
+			{
+				From: []networkingv1.NetworkPolicyPeer{
+					{
+						NamespaceSelector: &metav1.LabelSelector{
+							MatchExpressions: []metav1.LabelSelectorRequirement{
+								{
+									Key:      coreV1.LabelMetadataName,
+									Operator: metav1.LabelSelectorOpIn,
+									Values:   platformNamespaces,
+								},
+							},
+						},
+					},
+				},
+			},
+		}))
+	}
+	return policies
+}
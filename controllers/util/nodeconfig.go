@@ -0,0 +1,110 @@
+package util
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+
+	clusterV1alpha1 "github.com/tmax-cloud/hypercloud-multi-operator/apis/cluster/v1alpha1"
+	coreV1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// node 에 적용한 label key 와 taint (key:effect) 목록
+// spec 에서 제거된 label, taint 만 node 에서 삭제하고, 사용자가 직접 단 label, taint 는 건드리지 않기 위해 기록한다.
+type appliedNodeConfig struct {
+	Labels []string `json:"labels,omitempty"`
+	Taints []string `json:"taints,omitempty"`
+}
+
+func taintID(taint *coreV1.Taint) string {
+	return taint.Key + ":" + string(taint.Effect)
+}
+
+// selector 에 맞는 node 에 nodeConfigs 의 label, taint 를 적용한다.
+// 이전에 적용했지만 더 이상 선택되지 않거나 spec 에서 제거된 label, taint 는 삭제한다.
+// node 가 변경되었으면 true 를 반환한다.
+func ApplyNodeConfigs(node *coreV1.Node, nodeConfigs []clusterV1alpha1.NodeConfig) bool {
+	original := node.DeepCopy()
+
+	desiredLabels := map[string]string{}
+	desiredTaints := map[string]coreV1.Taint{}
+	for _, nodeConfig := range nodeConfigs {
+		if !labels.SelectorFromSet(nodeConfig.Selector).Matches(labels.Set(original.Labels)) {
+			continue
+		}
+		for key, value := range nodeConfig.Labels {
+			desiredLabels[key] = value
+		}
+		for _, taint := range nodeConfig.Taints {
+			desiredTaints[taintID(&taint)] = taint
+		}
+	}
+
+	previous := appliedNodeConfig{}
+	if value, ok := node.Annotations[clusterV1alpha1.AnnotationKeyClmNodeConfig]; ok {
+		// annotation 이 깨진 경우에는 이전에 적용한 것이 없다고 본다.
+		_ = json.Unmarshal([]byte(value), &previous)
+	}
+
+	// label
+	for _, key := range previous.Labels {
+		if _, ok := desiredLabels[key]; !ok {
+			delete(node.Labels, key)
+		}
+	}
+	applied := appliedNodeConfig{}
+	for key, value := range desiredLabels {
+		if node.Labels == nil {
+			node.Labels = map[string]string{}
+		}
+		node.Labels[key] = value
+		applied.Labels = append(applied.Labels, key)
+	}
+
+	// taint
+	removed := map[string]bool{}
+	for _, id := range previous.Taints {
+		if _, ok := desiredTaints[id]; !ok {
+			removed[id] = true
+		}
+	}
+	for id := range desiredTaints {
+		applied.Taints = append(applied.Taints, id)
+	}
+	taints := []coreV1.Taint{}
+	for _, taint := range node.Spec.Taints {
+		id := taintID(&taint)
+		if removed[id] {
+			continue
+		}
+		if desired, ok := desiredTaints[id]; ok {
+			taint.Value = desired.Value
+			delete(desiredTaints, id)
+		}
+		taints = append(taints, taint)
+	}
+	for _, taint := range desiredTaints {
+		taints = append(taints, taint)
+	}
+	if len(taints) != 0 || original.Spec.Taints != nil {
+		node.Spec.Taints = taints
+	}
+
+	// annotation
+	sort.Strings(applied.Labels)
+	sort.Strings(applied.Taints)
+	if len(applied.Labels) == 0 && len(applied.Taints) == 0 {
+		delete(node.Annotations, clusterV1alpha1.AnnotationKeyClmNodeConfig)
+	} else {
+		value, _ := json.Marshal(applied)
+		if node.Annotations == nil {
+			node.Annotations = map[string]string{}
+		}
+		node.Annotations[clusterV1alpha1.AnnotationKeyClmNodeConfig] = string(value)
+	}
+
+	return !reflect.DeepEqual(original.Labels, node.Labels) ||
+		!reflect.DeepEqual(original.Spec.Taints, node.Spec.Taints) ||
+		!reflect.DeepEqual(original.Annotations, node.Annotations)
+}
@@ -0,0 +1,239 @@
+package util
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	clusterV1alpha1 "github.com/tmax-cloud/hypercloud-multi-operator/apis/cluster/v1alpha1"
+	coreV1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/uuid"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	DefaultNotificationTemplate = "[{{.Event}}] {{.Namespace}}/{{.ClusterName}}: {{.Message}}"
+)
+
+// webhook sink 로 전송하는 request 의 header
+const (
+	NotificationHeaderEvent     = "X-Hypercloud-Event"
+	NotificationHeaderDelivery  = "X-Hypercloud-Delivery"
+	NotificationHeaderTimestamp = "X-Hypercloud-Timestamp"
+	// sha256=<hex(HMAC-SHA256(hmacKey, timestamp + "." + body))>
+	NotificationHeaderSignature = "X-Hypercloud-Signature"
+)
+
+// sink 로 전달하는 lifecycle notification
+type Notification struct {
+	Event       clusterV1alpha1.NotificationEvent `json:"event"`
+	Namespace   string                            `json:"namespace"`
+	ClusterName string                            `json:"clusterName"`
+	Message     string                            `json:"message,omitempty"`
+	Timestamp   time.Time                         `json:"timestamp"`
+	// CMDB, ITSM 과 같은 외부 시스템이 api 를 조회하지 않고 cluster 정보를 동기화할 수 있도록 전달한다.
+	Cluster *NotificationCluster `json:"cluster,omitempty"`
+}
+
+// notification 시점의 cluster manager 정보
+type NotificationCluster struct {
+	Type      string            `json:"type,omitempty"`
+	Provider  string            `json:"provider,omitempty"`
+	Version   string            `json:"version,omitempty"`
+	Phase     string            `json:"phase,omitempty"`
+	Ready     bool              `json:"ready"`
+	APIServer string            `json:"apiServer,omitempty"`
+	Owner     string            `json:"owner,omitempty"`
+	Labels    map[string]string `json:"labels,omitempty"`
+}
+
+// cluster manager 의 현재 상태를 포함한 notification 을 생성한다.
+func NewClusterNotification(clm *clusterV1alpha1.ClusterManager, event clusterV1alpha1.NotificationEvent, message string) Notification {
+	provider := clm.Status.Provider
+	if provider == "" {
+		provider = clm.Spec.Provider
+	}
+	return Notification{
+		Event:       event,
+		Namespace:   clm.Namespace,
+		ClusterName: clm.Name,
+		Message:     message,
+		Cluster: &NotificationCluster{
+			Type:      clm.GetClusterType(),
+			Provider:  provider,
+			Version:   clm.Status.Version,
+			Phase:     string(clm.Status.Phase),
+			Ready:     clm.Status.Ready,
+			APIServer: clm.GetAPIServerEndpoint(),
+			Owner:     clm.Annotations[AnnotationKeyOwner],
+			Labels:    clm.Labels,
+		},
+	}
+}
+
+// notification 의 namespace 와 hypercloud5-system namespace 의 NotificationConfig 중
+// 해당 event 를 받는 config 의 모든 sink 로 notification 을 전송한다.
+// 일부 sink 로의 전송이 실패해도 나머지 sink 로는 전송하고, 실패한 error 들을 모아서 반환한다.
+func Notify(c client.Client, notification Notification) error {
+	if notification.Timestamp.IsZero() {
+		notification.Timestamp = time.Now()
+	}
+
+	namespaces := []string{notification.Namespace}
+	if notification.Namespace != HypercloudNamespace {
+		namespaces = append(namespaces, HypercloudNamespace)
+	}
+
+	errs := []error{}
+	for _, namespace := range namespaces {
+		configList := &clusterV1alpha1.NotificationConfigList{}
+		if err := c.List(context.TODO(), configList, client.InNamespace(namespace)); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		for _, config := range configList.Items {
+			if !config.Accepts(notification.Event) {
+				continue
+			}
+			message, err := RenderNotification(config.Spec.Template, notification)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("notificationconfig [%s/%s]: %w", config.Namespace, config.Name, err))
+				continue
+			}
+			for _, sink := range config.Spec.Sinks {
+				if err := sendNotification(c, config.Namespace, sink, notification, message); err != nil {
+					errs = append(errs, fmt.Errorf("notificationconfig [%s/%s] sink [%s]: %w", config.Namespace, config.Name, sink.Name, err))
+				}
+			}
+		}
+	}
+	return utilerrors.NewAggregate(errs)
+}
+
+func RenderNotification(text string, notification Notification) (string, error) {
+	if text == "" {
+		text = DefaultNotificationTemplate
+	}
+	tmpl, err := template.New("notification").Parse(text)
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, notification); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+func sendNotification(c client.Client, namespace string, sink clusterV1alpha1.NotificationSink, notification Notification, message string) error {
+	secret := &coreV1.Secret{}
+	if sink.SecretName != "" {
+		key := types.NamespacedName{
+			Name:      sink.SecretName,
+			Namespace: namespace,
+		}
+		if err := c.Get(context.TODO(), key, secret); err != nil {
+			return err
+		}
+	}
+
+	switch sink.Type {
+	case clusterV1alpha1.NotificationSinkTypeSlack:
+		data, err := json.Marshal(map[string]string{"text": message})
+		if err != nil {
+			return err
+		}
+		return postNotification(string(secret.Data[clusterV1alpha1.NotificationSecretKeyURL]), data, nil)
+	case clusterV1alpha1.NotificationSinkTypeWebhook:
+		// generic webhook 은 렌더링된 message 와 함께 notification 원본을 전달한다.
+		notification.Message = message
+		data, err := json.Marshal(notification)
+		if err != nil {
+			return err
+		}
+		headers := map[string]string{
+			NotificationHeaderEvent:     string(notification.Event),
+			NotificationHeaderDelivery:  string(uuid.NewUUID()),
+			NotificationHeaderTimestamp: strconv.FormatInt(notification.Timestamp.Unix(), 10),
+		}
+		// hmac key 가 있으면 수신측이 출처와 변조 여부를 확인할 수 있도록 서명한다.
+		if key := secret.Data[clusterV1alpha1.NotificationSecretKeyHMACKey]; len(key) > 0 {
+			headers[NotificationHeaderSignature] = SignNotification(key, headers[NotificationHeaderTimestamp], data)
+		}
+		return postNotification(string(secret.Data[clusterV1alpha1.NotificationSecretKeyURL]), data, headers)
+	case clusterV1alpha1.NotificationSinkTypeSMTP:
+		return sendMail(sink.SMTP, secret, notification, message)
+	}
+	return fmt.Errorf("unsupported sink type: %s", sink.Type)
+}
+
+// timestamp 를 함께 서명하여 수신측이 오래된 request 의 재전송을 거부할 수 있게 한다.
+func SignNotification(key []byte, timestamp string, data []byte) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(data)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func postNotification(url string, data []byte, headers map[string]string) error {
+	if url == "" {
+		return fmt.Errorf("url is not found in secret")
+	}
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewBuffer(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Add("Content-Type", "application/json")
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if !IsOK(resp.StatusCode) {
+		return ClassifyStatusCode(resp.StatusCode, fmt.Errorf("failed to send notification: %s", resp.Status))
+	}
+	return nil
+}
+
+func sendMail(config *clusterV1alpha1.NotificationSMTP, secret *coreV1.Secret, notification Notification, message string) error {
+	if config == nil {
+		return fmt.Errorf("smtp config is required for SMTP sink")
+	}
+
+	var auth smtp.Auth
+	if username := string(secret.Data[clusterV1alpha1.NotificationSecretKeyUsername]); username != "" {
+		host, _, err := net.SplitHostPort(config.Address)
+		if err != nil {
+			return err
+		}
+		auth = smtp.PlainAuth("", username, string(secret.Data[clusterV1alpha1.NotificationSecretKeyPassword]), host)
+	}
+
+	subject := fmt.Sprintf("[%s] %s/%s", notification.Event, notification.Namespace, notification.ClusterName)
+	mail := "From: " + config.From + "\r\n" +
+		"To: " + strings.Join(config.To, ", ") + "\r\n" +
+		"Subject: " + subject + "\r\n" +
+		"Content-Type: text/plain; charset=UTF-8\r\n" +
+		"\r\n" + message + "\r\n"
+	return smtp.SendMail(config.Address, auth, config.From, config.To, []byte(mail))
+}
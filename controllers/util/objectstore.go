@@ -0,0 +1,104 @@
+package util
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+
+	clusterV1alpha1 "github.com/tmax-cloud/hypercloud-multi-operator/apis/cluster/v1alpha1"
+	coreV1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// object store 의 credential secret 의 key
+	ObjectStoreAccessKey = "accessKey"
+	ObjectStoreSecretKey = "secretKey"
+
+	objectStoreDefaultRegion = "us-east-1"
+)
+
+// ObjectStore 는 S3 호환 object store 에 Signature Version 4 로 서명한 요청을 보낸다.
+// minio 등 virtual host 를 지원하지 않는 object store 도 사용할 수 있도록 path style 로 요청한다.
+type ObjectStore struct {
+	endpoint *url.URL
+	bucket   string
+	region   string
+	creds    *awsCredentials
+}
+
+// storage 의 credential secret 을 조회하여 ObjectStore 를 생성한다.
+func NewObjectStore(ctx context.Context, c client.Reader, namespace string, storage clusterV1alpha1.BackupStorage) (*ObjectStore, error) {
+	endpoint, err := url.Parse(storage.Endpoint)
+	if err != nil || endpoint.Host == "" {
+		return nil, fmt.Errorf("invalid object store endpoint %q", storage.Endpoint)
+	}
+
+	secret := &coreV1.Secret{}
+	key := types.NamespacedName{Name: storage.CredentialsSecret, Namespace: namespace}
+	if err := c.Get(ctx, key, secret); err != nil {
+		return nil, err
+	}
+	accessKey, secretKey := string(secret.Data[ObjectStoreAccessKey]), string(secret.Data[ObjectStoreSecretKey])
+	if accessKey == "" || secretKey == "" {
+		return nil, fmt.Errorf("secret %s must have %s and %s", storage.CredentialsSecret, ObjectStoreAccessKey, ObjectStoreSecretKey)
+	}
+
+	region := storage.Region
+	if region == "" {
+		region = objectStoreDefaultRegion
+	}
+	return &ObjectStore{
+		endpoint: endpoint,
+		bucket:   storage.Bucket,
+		region:   region,
+		creds: &awsCredentials{
+			AccessKeyID:     accessKey,
+			SecretAccessKey: secretKey,
+		},
+	}, nil
+}
+
+func (s *ObjectStore) Put(ctx context.Context, key string, data []byte) error {
+	req, err := s.newRequest(ctx, http.MethodPut, key, data)
+	if err != nil {
+		return err
+	}
+	_, err = doCloudHTTPRequest(req)
+	return err
+}
+
+func (s *ObjectStore) Get(ctx context.Context, key string) ([]byte, error) {
+	req, err := s.newRequest(ctx, http.MethodGet, key, nil)
+	if err != nil {
+		return nil, err
+	}
+	return doCloudHTTPRequest(req)
+}
+
+func (s *ObjectStore) newRequest(ctx context.Context, method, key string, body []byte) (*http.Request, error) {
+	u := *s.endpoint
+	u.Path = path.Join("/", u.Path, s.bucket, strings.TrimPrefix(key, "/"))
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	// S3 는 payload 의 hash 를 header 로 요구한다.
+	req.Header.Set("X-Amz-Content-Sha256", sha256Hex(body))
+	signAWSRequest(req, body, s.creds, s.region, "s3", time.Now())
+	return req, nil
+}
+
+// prefix 와 이름으로 object key 를 만든다.
+func ObjectKey(prefix, name string) string {
+	if prefix == "" {
+		return name
+	}
+	return strings.TrimSuffix(prefix, "/") + "/" + name
+}
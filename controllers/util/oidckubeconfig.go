@@ -0,0 +1,40 @@
+package util
+
+import (
+	"os"
+
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+)
+
+const (
+	// KUBECONFIG_OIDC_CLIENT_ID 가 설정되지 않은 경우의 기본값
+	oidcClientIDDefault = "hypercloud5"
+	// 사용자는 kubelogin (kubectl oidc-login) plugin 으로 hyperauth 에 로그인하여 token 을 발급받는다.
+	oidcExecCommand = "kubectl"
+)
+
+// hyperauth 의 tmax realm 주소
+func HyperAuthIssuerURL() string {
+	return "https://" + os.Getenv(AUTH_SUBDOMAIN) + "." + os.Getenv(HC_DOMAIN) + "/auth/realms/tmax"
+}
+
+// cluster 의 kubeconfig 에서 api server 주소와 CA 만 가져오고, 인증은 hyperauth 의 oidc token 으로 하는 kubeconfig 를 생성한다.
+// admin 인증서나 token 을 포함하지 않으므로 사용자는 remote cluster 에 바인딩된 자신의 권한으로만 접근한다.
+func NewOIDCKubeconfig(name string, kubeconfig *Kubeconfig) ([]byte, error) {
+	cluster := kubeconfig.Config.Clusters[kubeconfig.Config.Contexts[kubeconfig.Config.CurrentContext].Cluster]
+	clientID := os.Getenv(KUBECONFIG_OIDC_CLIENT_ID)
+	if clientID == "" {
+		clientID = oidcClientIDDefault
+	}
+	return newExecKubeconfig(name, kubeconfig.Server, cluster.CertificateAuthorityData, &clientcmdapi.ExecConfig{
+		APIVersion: execAPIVersion,
+		Command:    oidcExecCommand,
+		Args: []string{
+			"oidc-login",
+			"get-token",
+			"--oidc-issuer-url=" + HyperAuthIssuerURL(),
+			"--oidc-client-id=" + clientID,
+		},
+		InteractiveMode: clientcmdapi.IfAvailableExecInteractiveMode,
+	})
+}
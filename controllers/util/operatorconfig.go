@@ -0,0 +1,243 @@
+package util
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	clusterV1alpha1 "github.com/tmax-cloud/hypercloud-multi-operator/apis/cluster/v1alpha1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	operatorConfigSyncInterval = 30 * time.Second
+	// controller 가 너무 자주 reconcile 하지 않도록 resync 주기의 하한을 둔다.
+	resyncPeriodMin = 10 * time.Second
+)
+
+// HyperCloudOperatorConfig 로 변경할 수 있는 환경 변수
+// 모두 사용하는 시점에 환경 변수를 읽으므로 재시작 없이 반영된다.
+var operatorConfigEnvs = []string{
+	HC_DOMAIN,
+	AUTH_SUBDOMAIN,
+	ARGO_APP_DELETE,
+	HC_EVENT_PUSH,
+	KUBECONFIG_OIDC_CLIENT_ID,
+	CERT_MANAGER_ACME_SERVER,
+	IMAGE_PULL_SECRETS,
+	IMAGE_PULL_SECRET_NAMESPACES,
+	DEFAULT_NETWORK_POLICY_NAMESPACES,
+	DEFAULT_NETWORK_POLICY_PLATFORM_NAMESPACES,
+	RESYNC_PERIOD,
+	SUPPORTED_KUBERNETES_VERSIONS,
+	REGISTRY_CONFIG_SECRET,
+	AIR_GAPPED,
+	AIR_GAPPED_IMAGE_REPOSITORY,
+	AIR_GAPPED_ARTIFACT_MIRROR,
+	AIR_GAPPED_CHART_REPO,
+}
+
+// spec 을 검증하고, 설정된 값을 환경 변수 이름과 값으로 변환한다.
+func OperatorConfigToEnv(spec *clusterV1alpha1.HyperCloudOperatorConfigSpec) (map[string]string, error) {
+	errs := []error{}
+	envs := map[string]string{}
+
+	setString := func(env, value string) {
+		if value != "" {
+			envs[env] = value
+		}
+	}
+	setBool := func(env string, value *bool) {
+		if value != nil {
+			envs[env] = strconv.FormatBool(*value)
+		}
+	}
+	setList := func(env string, values []string, validate func(string) []string) {
+		for _, value := range values {
+			if msgs := validate(value); len(msgs) > 0 {
+				errs = append(errs, fmt.Errorf("%s: invalid value [%s]: %s", env, value, strings.Join(msgs, ", ")))
+			}
+		}
+		if len(values) > 0 {
+			envs[env] = strings.Join(values, ",")
+		}
+	}
+
+	setString(HC_DOMAIN, spec.HCDomain)
+	setString(AUTH_SUBDOMAIN, spec.AuthSubdomain)
+	setBool(ARGO_APP_DELETE, spec.ArgoAppDelete)
+	setBool(HC_EVENT_PUSH, spec.HCEventPush)
+	setString(KUBECONFIG_OIDC_CLIENT_ID, spec.KubeconfigOIDCClientID)
+	if spec.CertManagerACMEServer != "" {
+		if u, err := url.Parse(spec.CertManagerACMEServer); err != nil || u.Scheme != "https" || u.Host == "" {
+			errs = append(errs, fmt.Errorf("%s: must be a https url", CERT_MANAGER_ACME_SERVER))
+		}
+		setString(CERT_MANAGER_ACME_SERVER, spec.CertManagerACMEServer)
+	}
+	setList(IMAGE_PULL_SECRETS, spec.ImagePullSecrets, validation.IsDNS1123Subdomain)
+	setList(IMAGE_PULL_SECRET_NAMESPACES, spec.ImagePullSecretNamespaces, validation.IsDNS1123Label)
+	setList(DEFAULT_NETWORK_POLICY_NAMESPACES, spec.DefaultNetworkPolicyNamespaces, validation.IsDNS1123Label)
+	setList(DEFAULT_NETWORK_POLICY_PLATFORM_NAMESPACES, spec.DefaultNetworkPolicyPlatformNamespaces, validation.IsDNS1123Label)
+	if spec.ResyncPeriod != nil {
+		if spec.ResyncPeriod.Duration < resyncPeriodMin {
+			errs = append(errs, fmt.Errorf("%s: must be at least %s", RESYNC_PERIOD, resyncPeriodMin))
+		}
+		envs[RESYNC_PERIOD] = spec.ResyncPeriod.Duration.String()
+	}
+	setList(SUPPORTED_KUBERNETES_VERSIONS, spec.SupportedKubernetesVersions, validateMinorVersion)
+	if spec.RegistryConfigSecret != "" {
+		if msgs := validation.IsDNS1123Subdomain(spec.RegistryConfigSecret); len(msgs) > 0 {
+			errs = append(errs, fmt.Errorf("%s: %s", REGISTRY_CONFIG_SECRET, strings.Join(msgs, ", ")))
+		}
+		setString(REGISTRY_CONFIG_SECRET, spec.RegistryConfigSecret)
+	}
+	setBool(AIR_GAPPED, spec.AirGapped)
+	setString(AIR_GAPPED_IMAGE_REPOSITORY, spec.AirGappedImageRepository)
+	setURL := func(env, value string) {
+		if value == "" {
+			return
+		}
+		if u, err := url.Parse(value); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			errs = append(errs, fmt.Errorf("%s: must be a http or https url", env))
+		}
+		setString(env, value)
+	}
+	setURL(AIR_GAPPED_ARTIFACT_MIRROR, spec.AirGappedArtifactMirror)
+	setURL(AIR_GAPPED_CHART_REPO, spec.AirGappedChartRepo)
+
+	if len(errs) > 0 {
+		return nil, utilerrors.NewAggregate(errs)
+	}
+	return envs, nil
+}
+
+// OperatorConfigWatcher 는 주기적으로 HyperCloudOperatorConfig 를 읽어 환경 변수에 반영하고,
+// 적용된 값을 status 에 기록한다.
+// spec 에서 제거된 값은 operator 가 시작될 때의 환경 변수로 되돌린다.
+// spec 이 잘못된 경우 이전에 적용된 값을 유지한다.
+// +kubebuilder:rbac:groups=cluster.tmax.io,resources=hypercloudoperatorconfigs,verbs=get;list;watch
+// +kubebuilder:rbac:groups=cluster.tmax.io,resources=hypercloudoperatorconfigs/status,verbs=get;patch;update
+type OperatorConfigWatcher struct {
+	// 설정이 하나뿐이므로 cache 대신 api reader 를 사용한다.
+	Reader client.Reader
+	Client client.Client
+	Log    logr.Logger
+
+	// operator 가 시작될 때의 환경 변수
+	defaults map[string]*string
+	// spec 에서 적용한 환경 변수
+	applied map[string]string
+}
+
+func (w *OperatorConfigWatcher) Start(ctx context.Context) error {
+	w.defaults = map[string]*string{}
+	for _, env := range operatorConfigEnvs {
+		if value, ok := os.LookupEnv(env); ok {
+			w.defaults[env] = &value
+		} else {
+			w.defaults[env] = nil
+		}
+	}
+
+	ticker := time.NewTicker(operatorConfigSyncInterval)
+	defer ticker.Stop()
+
+	for {
+		w.sync(ctx)
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// leader 가 아닌 replica 도 설정을 반영해야 한다.
+func (w *OperatorConfigWatcher) NeedLeaderElection() bool {
+	return false
+}
+
+func (w *OperatorConfigWatcher) sync(ctx context.Context) {
+	key := types.NamespacedName{Name: clusterV1alpha1.HyperCloudOperatorConfigName}
+	config := &clusterV1alpha1.HyperCloudOperatorConfig{}
+	if err := w.Reader.Get(ctx, key, config); errors.IsNotFound(err) {
+		w.apply(map[string]string{})
+		return
+	} else if err != nil {
+		w.Log.Error(err, "Failed to get operator config", "name", key.Name)
+		return
+	}
+
+	original := config.DeepCopy()
+	condition := metav1.Condition{
+		Type:   clusterV1alpha1.HyperCloudOperatorConfigConditionValid,
+		Status: metav1.ConditionTrue,
+		Reason: "Applied",
+	}
+	envs, err := OperatorConfigToEnv(&config.Spec)
+	if err != nil {
+		w.Log.Error(err, "Invalid operator config", "name", key.Name)
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = "InvalidConfig"
+		condition.Message = err.Error()
+	} else {
+		w.apply(envs)
+		config.Status.ObservedGeneration = config.Generation
+	}
+	meta.SetStatusCondition(&config.Status.Conditions, condition)
+	config.Status.Effective = w.effective()
+
+	if reflect.DeepEqual(original.Status, config.Status) {
+		return
+	}
+	if err := w.Client.Status().Patch(ctx, config, client.MergeFrom(original)); err != nil {
+		w.Log.Error(err, "Failed to update operator config status", "name", key.Name)
+	}
+}
+
+// spec 에 없는 값은 시작할 때의 환경 변수로 되돌린다.
+func (w *OperatorConfigWatcher) apply(envs map[string]string) {
+	w.applied = envs
+	for _, env := range operatorConfigEnvs {
+		value, ok := envs[env]
+		if !ok {
+			if w.defaults[env] == nil {
+				os.Unsetenv(env)
+				continue
+			}
+			value = *w.defaults[env]
+		}
+		if os.Getenv(env) != value {
+			w.Log.Info("Apply operator config", "env", env, "value", value)
+			os.Setenv(env, value)
+		}
+	}
+}
+
+func (w *OperatorConfigWatcher) effective() []clusterV1alpha1.OperatorConfigValue {
+	values := []clusterV1alpha1.OperatorConfigValue{}
+	for _, env := range operatorConfigEnvs {
+		value := clusterV1alpha1.OperatorConfigValue{
+			Name:   env,
+			Value:  os.Getenv(env),
+			Source: clusterV1alpha1.OperatorConfigSourceEnv,
+		}
+		if _, ok := w.applied[env]; ok {
+			value.Source = clusterV1alpha1.OperatorConfigSourceConfig
+		}
+		values = append(values, value)
+	}
+	return values
+}
@@ -0,0 +1,61 @@
+package util
+
+import (
+	"reflect"
+
+	"k8s.io/apimachinery/pkg/labels"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+)
+
+// operator 가 reconcile 결과를 기록하기 위해 변경하는 annotation 으로, 변경되어도 다시 reconcile 할 필요가 없다.
+var ignoredAnnotationKeys = []string{
+	AnnotationKeyLastReconcileError,
+}
+
+// spec, label, annotation, finalizer 가 변경되었거나 삭제가 시작된 update 만 통과시킨다.
+// status 만 변경된 update 와 resync 로 인한 update 는 무시하므로,
+// phase pipeline 이 kubeconfig 를 다시 읽고 remote cluster 에 요청하는 일을 줄인다.
+// create 는 모두 통과시키고, delete 와 generic 은 무시한다.
+func SpecChangedPredicate() predicate.Funcs {
+	return predicate.Funcs{
+		CreateFunc: func(e event.CreateEvent) bool {
+			return true
+		},
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			return IsSpecChanged(e.ObjectOld, e.ObjectNew)
+		},
+		DeleteFunc: func(e event.DeleteEvent) bool {
+			return false
+		},
+		GenericFunc: func(e event.GenericEvent) bool {
+			return false
+		},
+	}
+}
+
+// status 이외의 변경이 있는지 확인한다.
+// status subresource 를 사용하는 resource 는 spec 이 변경된 경우에만 generation 이 증가한다.
+func IsSpecChanged(oldObj, newObj client.Object) bool {
+	if oldObj == nil || newObj == nil {
+		return true
+	}
+	isDeleted := oldObj.GetDeletionTimestamp().IsZero() && !newObj.GetDeletionTimestamp().IsZero()
+	return isDeleted ||
+		oldObj.GetGeneration() != newObj.GetGeneration() ||
+		!labels.Equals(oldObj.GetLabels(), newObj.GetLabels()) ||
+		!reflect.DeepEqual(oldObj.GetFinalizers(), newObj.GetFinalizers()) ||
+		!reflect.DeepEqual(filterAnnotations(oldObj.GetAnnotations()), filterAnnotations(newObj.GetAnnotations()))
+}
+
+func filterAnnotations(annotations map[string]string) map[string]string {
+	filtered := map[string]string{}
+	for k, v := range annotations {
+		filtered[k] = v
+	}
+	for _, k := range ignoredAnnotationKeys {
+		delete(filtered, k)
+	}
+	return filtered
+}
@@ -0,0 +1,106 @@
+package util
+
+import (
+	"context"
+	"regexp"
+	"strings"
+
+	clusterV1alpha1 "github.com/tmax-cloud/hypercloud-multi-operator/apis/cluster/v1alpha1"
+	coreV1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// node 의 providerID 의 scheme
+const (
+	ProviderIDPrefixAWS     = "aws"
+	ProviderIDPrefixGCE     = "gce"
+	ProviderIDPrefixAzure   = "azure"
+	ProviderIDPrefixVSphere = "vsphere"
+)
+
+var (
+	// aws:///<zone>/<instance id>
+	awsProviderIDRegexp = regexp.MustCompile(`^aws:///([^/]+)/`)
+	// gce://<project>/<zone>/<instance name>
+	gceProviderIDRegexp = regexp.MustCompile(`^gce://([^/]+)/([^/]+)/`)
+	// azure:///subscriptions/<subscription id>/resourceGroups/...
+	azureProviderIDRegexp = regexp.MustCompile(`(?i)^azure:///subscriptions/([^/]+)/`)
+	// aws-auth 의 node role arn (arn:aws:iam::<account id>:role/...)
+	awsAccountRegexp = regexp.MustCompile(`arn:aws[a-z-]*:iam::([0-9]{12}):`)
+)
+
+// remote cluster 의 node 로부터 provider identity 를 조회한다.
+// aws 는 node 에 account 가 없으므로 eks 의 aws-auth configmap 의 node role arn 에서 account 를 조회한다.
+func DetectProviderIdentity(ctx context.Context, clientset kubernetes.Interface) (*clusterV1alpha1.ProviderIdentity, error) {
+	nodeList, err := clientset.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	identity := ProviderIdentityFromNodes(nodeList.Items)
+
+	if identity.ProviderIDPrefix == ProviderIDPrefixAWS && identity.Account == "" {
+		awsAuth, err := clientset.CoreV1().ConfigMaps(KubeNamespace).Get(ctx, "aws-auth", metav1.GetOptions{})
+		if err != nil && !errors.IsNotFound(err) {
+			return nil, err
+		} else if err == nil {
+			if match := awsAccountRegexp.FindStringSubmatch(awsAuth.Data["mapRoles"]); match != nil {
+				identity.Account = match[1]
+			}
+		}
+	}
+	return identity, nil
+}
+
+// providerID 가 있는 첫 번째 node 로 provider 와 account 를 판단한다.
+// region 은 cloud provider 가 설정하는 topology label 을 우선하고, 없으면 providerID 의 zone 에서 구한다.
+func ProviderIdentityFromNodes(nodes []coreV1.Node) *clusterV1alpha1.ProviderIdentity {
+	identity := &clusterV1alpha1.ProviderIdentity{}
+	for _, node := range nodes {
+		providerID := node.Spec.ProviderID
+		if providerID == "" {
+			continue
+		}
+		identity.ProviderIDPrefix = strings.ToLower(strings.Split(providerID, "://")[0])
+
+		zone := ""
+		switch identity.ProviderIDPrefix {
+		case ProviderIDPrefixAWS:
+			if match := awsProviderIDRegexp.FindStringSubmatch(providerID); match != nil {
+				zone = match[1]
+			}
+		case ProviderIDPrefixGCE:
+			if match := gceProviderIDRegexp.FindStringSubmatch(providerID); match != nil {
+				identity.Account, zone = match[1], match[2]
+			}
+		case ProviderIDPrefixAzure:
+			if match := azureProviderIDRegexp.FindStringSubmatch(providerID); match != nil {
+				identity.Account = match[1]
+			}
+		}
+
+		identity.Region = node.Labels[coreV1.LabelTopologyRegion]
+		if identity.Region == "" {
+			identity.Region = regionFromZone(identity.ProviderIDPrefix, zone)
+		}
+		break
+	}
+	return identity
+}
+
+// aws 의 zone 은 <region><letter> (예: ap-northeast-2a), gce 의 zone 은 <region>-<letter> (예: asia-northeast3-a) 형식이다.
+func regionFromZone(prefix, zone string) string {
+	if zone == "" {
+		return ""
+	}
+	switch prefix {
+	case ProviderIDPrefixAWS:
+		return strings.TrimRight(zone, "abcdefghijklmnopqrstuvwxyz")
+	case ProviderIDPrefixGCE:
+		if i := strings.LastIndex(zone, "-"); i > 0 {
+			return zone[:i]
+		}
+	}
+	return zone
+}
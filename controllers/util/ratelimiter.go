@@ -0,0 +1,60 @@
+package util
+
+import (
+	"time"
+
+	"golang.org/x/time/rate"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/util/workqueue"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+)
+
+const (
+	// 실패하거나 Requeue 를 반환한 object 를 처음 다시 reconcile 하기까지의 시간
+	RateLimiterBaseDelay = 2 * time.Second
+	// 연속으로 실패한 object 를 다시 reconcile 하기까지의 최대 시간
+	RateLimiterMaxDelay = 5 * time.Minute
+	// backoff 와 주기적인 requeue 에 더하는 jitter 의 비율
+	requeueJitterFactor = 0.2
+)
+
+// controller 마다 사용하는 workqueue rate limiter 를 생성한다.
+// object 별로 baseDelay 부터 maxDelay 까지 exponential backoff 하고, 같은 시각에 requeue 가 몰리지 않도록 jitter 를 더한다.
+// reconcile 이 error 를 반환하거나 Result.Requeue 가 true 인 경우에 적용되며, 성공하면 backoff 가 초기화된다.
+func NewRateLimiter(baseDelay, maxDelay time.Duration) workqueue.RateLimiter {
+	return workqueue.NewMaxOfRateLimiter(
+		&jitterRateLimiter{
+			RateLimiter: workqueue.NewItemExponentialFailureRateLimiter(baseDelay, maxDelay),
+			maxDelay:    maxDelay,
+		},
+		// 전체 workqueue 에 대한 overall rate limit (controller-runtime 의 기본값과 동일)
+		&workqueue.BucketRateLimiter{Limiter: rate.NewLimiter(rate.Limit(10), 100)},
+	)
+}
+
+// 기본 delay 를 사용하는 controller option
+func DefaultControllerOptions() controller.Options {
+	return controller.Options{
+		RateLimiter: NewRateLimiter(RateLimiterBaseDelay, RateLimiterMaxDelay),
+	}
+}
+
+// 주기적으로 상태를 확인하는 경우에 사용한다.
+// 여러 object 가 같은 주기로 동시에 requeue 되지 않도록 jitter 를 더한다.
+func RequeueAfterWithJitter(d time.Duration) ctrl.Result {
+	return ctrl.Result{RequeueAfter: wait.Jitter(d, requeueJitterFactor)}
+}
+
+type jitterRateLimiter struct {
+	workqueue.RateLimiter
+	maxDelay time.Duration
+}
+
+func (r *jitterRateLimiter) When(item interface{}) time.Duration {
+	delay := wait.Jitter(r.RateLimiter.When(item), requeueJitterFactor)
+	if delay > r.maxDelay {
+		return r.maxDelay
+	}
+	return delay
+}
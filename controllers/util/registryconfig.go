@@ -0,0 +1,153 @@
+package util
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+
+	coreV1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// REGISTRY_CONFIG_SECRET 의 registry 설정 key
+	RegistryConfigKey = "registries.yaml"
+
+	// cluster 별 registry 설정 secret 의 이름 suffix 와 key
+	// capi template 의 kubeadm config 가 이 secret 을 file 로 node 에 내려받아 containerd 설정에 추가한다.
+	RegistryConfigSecretSuffix = "-registry-config"
+	RegistryConfigTomlKey      = "registry.toml"
+	RegistryConfigCAKey        = "ca.crt"
+
+	// node 에 내려받은 CA 인증서의 경로
+	registryCAPath = "/etc/containerd/certs/hypercloud-registry-ca.crt"
+	// containerd 1.x 의 cri plugin 설정
+	containerdRegistryPlugin = `plugins."io.containerd.grpc.v1.cri".registry`
+)
+
+// RegistryConfig 는 생성하는 cluster 의 containerd 에 적용할 registry 설정이다.
+type RegistryConfig struct {
+	Registries []RegistryHost `json:"registries"`
+}
+
+// RegistryHost 는 registry host 하나의 mirror, 인증, tls 설정이다.
+type RegistryHost struct {
+	// image 이름에 사용되는 registry host (예: docker.io, harbor.example.com:5000)
+	Host string `json:"host"`
+	// host 대신 image 를 받을 mirror 의 주소 목록 (예: https://mirror.example.com)
+	Mirrors  []string `json:"mirrors,omitempty"`
+	Username string   `json:"username,omitempty"`
+	Password string   `json:"password,omitempty"`
+	// registry 와 mirror 의 인증서를 검증할 PEM 형식의 CA 인증서
+	CA string `json:"ca,omitempty"`
+	// 인증서를 검증하지 않는다.
+	InsecureSkipVerify bool `json:"insecureSkipVerify,omitempty"`
+}
+
+// REGISTRY_CONFIG_SECRET 의 registry 설정을 가져온다.
+// 설정되지 않은 경우 빈 설정을 반환한다.
+func GetRegistryConfig(ctx context.Context, c client.Reader) (*RegistryConfig, error) {
+	name := os.Getenv(REGISTRY_CONFIG_SECRET)
+	if name == "" {
+		return &RegistryConfig{}, nil
+	}
+	secret := &coreV1.Secret{}
+	if err := c.Get(ctx, types.NamespacedName{Name: name, Namespace: HypercloudNamespace}, secret); err != nil {
+		return nil, err
+	}
+	return ParseRegistryConfig(secret.Data[RegistryConfigKey])
+}
+
+func ParseRegistryConfig(data []byte) (*RegistryConfig, error) {
+	config := &RegistryConfig{}
+	if err := yaml.UnmarshalStrict(data, config); err != nil {
+		return nil, err
+	}
+
+	errs := []error{}
+	hosts := map[string]bool{}
+	for _, registry := range config.Registries {
+		if registry.Host == "" || strings.Contains(registry.Host, "/") {
+			errs = append(errs, fmt.Errorf("invalid registry host [%s]", registry.Host))
+		} else if hosts[registry.Host] {
+			errs = append(errs, fmt.Errorf("duplicated registry host [%s]", registry.Host))
+		}
+		hosts[registry.Host] = true
+		for _, mirror := range registry.Mirrors {
+			if u, err := url.Parse(mirror); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+				errs = append(errs, fmt.Errorf("%s: mirror [%s] must be a http or https url", registry.Host, mirror))
+			}
+		}
+		if registry.CA != "" {
+			if block, _ := pem.Decode([]byte(registry.CA)); block == nil {
+				errs = append(errs, fmt.Errorf("%s: ca is not a pem encoded certificate", registry.Host))
+			} else if _, err := x509.ParseCertificate(block.Bytes); err != nil {
+				errs = append(errs, fmt.Errorf("%s: %s", registry.Host, err.Error()))
+			}
+		}
+	}
+	if len(errs) > 0 {
+		return nil, utilerrors.NewAggregate(errs)
+	}
+	return config, nil
+}
+
+// containerd 의 config.toml 에 추가할 설정과 모든 registry 의 CA 인증서를 합친 bundle 을 생성한다.
+// registry 가 없으면 빈 값을 반환하므로, node 의 containerd 설정은 바뀌지 않는다.
+func (c *RegistryConfig) Render() (toml string, ca string) {
+	var tomlBuilder, caBuilder strings.Builder
+	// 같은 table 이 두 번 정의되면 containerd 가 시작되지 않으므로 host 별로 한 번만 설정한다.
+	configured := map[string]bool{}
+	for _, registry := range c.Registries {
+		if len(registry.Mirrors) > 0 {
+			endpoints := make([]string, 0, len(registry.Mirrors))
+			for _, mirror := range registry.Mirrors {
+				endpoints = append(endpoints, strconv.Quote(mirror))
+			}
+			fmt.Fprintf(&tomlBuilder, "[%s.mirrors.%s]\n", containerdRegistryPlugin, strconv.Quote(registry.Host))
+			fmt.Fprintf(&tomlBuilder, "  endpoint = [%s]\n", strings.Join(endpoints, ", "))
+		}
+
+		// containerd 는 mirror 에 접속할 때 mirror host 의 설정을 사용하므로, mirror 에도 같은 인증, tls 설정을 적용한다.
+		hosts := []string{registry.Host}
+		for _, mirror := range registry.Mirrors {
+			if u, err := url.Parse(mirror); err == nil {
+				hosts = append(hosts, u.Host)
+			}
+		}
+		for _, host := range hosts {
+			if configured[host] {
+				continue
+			}
+			configured[host] = true
+			if registry.Username != "" || registry.Password != "" {
+				fmt.Fprintf(&tomlBuilder, "[%s.configs.%s.auth]\n", containerdRegistryPlugin, strconv.Quote(host))
+				fmt.Fprintf(&tomlBuilder, "  username = %s\n", strconv.Quote(registry.Username))
+				fmt.Fprintf(&tomlBuilder, "  password = %s\n", strconv.Quote(registry.Password))
+			}
+			if registry.CA != "" || registry.InsecureSkipVerify {
+				fmt.Fprintf(&tomlBuilder, "[%s.configs.%s.tls]\n", containerdRegistryPlugin, strconv.Quote(host))
+				if registry.CA != "" {
+					fmt.Fprintf(&tomlBuilder, "  ca_file = %s\n", strconv.Quote(registryCAPath))
+				}
+				if registry.InsecureSkipVerify {
+					fmt.Fprintf(&tomlBuilder, "  insecure_skip_verify = true\n")
+				}
+			}
+		}
+
+		if registry.CA != "" {
+			caBuilder.WriteString(strings.TrimSpace(registry.CA))
+			caBuilder.WriteString("\n")
+		}
+	}
+	return tomlBuilder.String(), caBuilder.String()
+}
@@ -0,0 +1,59 @@
+package util
+
+import (
+	"context"
+
+	coreV1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// RemoteClientFactory 는 member cluster 의 clientset 을 생성한다.
+// reconciler 에 주입하여, test 에서는 실제 cluster 없이 fake clientset 을 사용할 수 있다.
+type RemoteClientFactory interface {
+	// kubeconfig secret 으로 member cluster 의 clientset 을 가져온다.
+	ClientsetForSecret(secret *coreV1.Secret) (kubernetes.Interface, error)
+	// kubeconfig 로 member cluster 의 clientset 을 생성한다.
+	ClientsetForKubeconfig(kubeconfig *Kubeconfig) (kubernetes.Interface, error)
+	// cluster 의 kubeconfig secret 중 level 에 맞는 secret 으로 clientset 을 가져온다.
+	// 조회만 하는 controller 는 CredentialLimited 를 요청해야 한다.
+	ClientsetForCluster(ctx context.Context, c client.Reader, namespace, clusterName string, level CredentialLevel) (kubernetes.Interface, error)
+}
+
+// remote cluster cache 를 사용하는 기본 RemoteClientFactory
+type defaultRemoteClientFactory struct{}
+
+var DefaultRemoteClientFactory RemoteClientFactory = defaultRemoteClientFactory{}
+
+// nil clientset 이 non-nil interface 로 반환되지 않도록 error 인 경우 nil 을 반환한다.
+func (defaultRemoteClientFactory) ClientsetForSecret(secret *coreV1.Secret) (kubernetes.Interface, error) {
+	clientset, err := GetRemoteK8sClient(secret)
+	if err != nil {
+		return nil, err
+	}
+	return clientset, nil
+}
+
+func (defaultRemoteClientFactory) ClientsetForKubeconfig(kubeconfig *Kubeconfig) (kubernetes.Interface, error) {
+	clientset, err := GetRemoteK8sClientByKubeConfig(kubeconfig)
+	if err != nil {
+		return nil, err
+	}
+	return clientset, nil
+}
+
+func (f defaultRemoteClientFactory) ClientsetForCluster(ctx context.Context, c client.Reader, namespace, clusterName string, level CredentialLevel) (kubernetes.Interface, error) {
+	secret, err := GetCredentialSecret(ctx, c, namespace, clusterName, level)
+	if err != nil {
+		return nil, err
+	}
+	return f.ClientsetForSecret(secret)
+}
+
+// factory 가 주입되지 않은 경우 DefaultRemoteClientFactory 를 사용한다.
+func RemoteClientFactoryOrDefault(factory RemoteClientFactory) RemoteClientFactory {
+	if factory == nil {
+		return DefaultRemoteClientFactory
+	}
+	return factory
+}
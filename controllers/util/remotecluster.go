@@ -0,0 +1,173 @@
+package util
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"sync"
+
+	"github.com/tmax-cloud/hypercloud-multi-operator/controllers/metrics"
+	coreV1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+	restclient "k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+)
+
+// remoteClusterTracker 는 kubeconfig secret 별로 member cluster 의 client 를 생성해 두고 재사용한다.
+// reconcile 마다 client 를 생성하면 tls handshake 와 discovery 를 매번 수행하게 되므로,
+// kubeconfig 가 변경되거나 cluster 가 삭제되기 전까지 같은 client 를 사용한다.
+type remoteClusterTracker struct {
+	mu       sync.Mutex
+	clusters map[types.NamespacedName]*remoteCluster
+}
+
+type remoteCluster struct {
+	// kubeconfig 나 managed cluster 의 인증 정보가 변경되었는지 확인하기 위한 hash
+	kubeconfigHash string
+	// kubeconfig secret 이 가리키는 cluster manager 의 이름
+	clusterName string
+
+	restConfig *restclient.Config
+	httpClient *http.Client
+	clientset  *kubernetes.Clientset
+	// 처음 요청할 때 생성한다.
+	runtimeClient client.Client
+}
+
+// secret controller, health check, addon 배포 등 모든 controller 가 같은 client 를 공유한다.
+var remoteClusters = &remoteClusterTracker{
+	clusters: map[types.NamespacedName]*remoteCluster{},
+}
+
+func (t *remoteClusterTracker) get(secret *coreV1.Secret) (*remoteCluster, error) {
+	value, ok := secret.Data["value"]
+	if !ok {
+		err := errors.NewBadRequest("secret does not have a value")
+		return nil, err
+	}
+	auth := ManagedClusterAuthFromSecret(secret)
+	sum := sha256.Sum256(append(append([]byte{}, value...), auth.String()...))
+	hash := hex.EncodeToString(sum[:])
+	key := types.NamespacedName{Name: secret.Name, Namespace: secret.Namespace}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if c, ok := t.clusters[key]; ok {
+		if c.kubeconfigHash == hash {
+			return c, nil
+		}
+		// kubeconfig 가 변경된 경우 기존 client 를 버리고 새로 생성한다.
+		c.close()
+		delete(t.clusters, key)
+	}
+
+	c, err := newRemoteCluster(value, auth)
+	if err != nil {
+		return nil, err
+	}
+	c.kubeconfigHash = hash
+	c.clusterName = credentialClusterName(secret)
+	t.clusters[key] = c
+	metrics.RemoteClusterClients.Set(float64(len(t.clusters)))
+	return c, nil
+}
+
+func (t *remoteClusterTracker) getRuntimeClient(secret *coreV1.Secret) (client.Client, error) {
+	c, err := t.get(secret)
+	if err != nil {
+		return nil, err
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return c.getRuntimeClient()
+}
+
+// namespace 의 clusterName 에 해당하는 client 를 모두 제거한다.
+func (t *remoteClusterTracker) remove(namespace, clusterName string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for key, c := range t.clusters {
+		if key.Namespace == namespace && c.clusterName == clusterName {
+			c.close()
+			delete(t.clusters, key)
+		}
+	}
+	metrics.RemoteClusterClients.Set(float64(len(t.clusters)))
+}
+
+func newRemoteCluster(kubeconfig []byte, auth *ManagedClusterAuth) (*remoteCluster, error) {
+	remoteClientConfig, err := clientcmd.NewClientConfigFromBytes(kubeconfig)
+	if err != nil {
+		return nil, err
+	}
+
+	remoteRestConfig, err := remoteClientConfig.ClientConfig()
+	if err != nil {
+		return nil, err
+	}
+	remoteRestConfig.WrapTransport = metrics.InstrumentRoundTripper
+	// managed cluster 는 token 이 만료되므로 client 를 재사용하는 동안 token 을 갱신한다.
+	if err := configureManagedClusterAuth(remoteRestConfig, auth); err != nil {
+		return nil, err
+	}
+
+	httpClient, err := restclient.HTTPClientFor(remoteRestConfig)
+	if err != nil {
+		return nil, err
+	}
+	clientset, err := kubernetes.NewForConfigAndClient(remoteRestConfig, httpClient)
+	if err != nil {
+		return nil, err
+	}
+
+	return &remoteCluster{
+		restConfig: remoteRestConfig,
+		httpClient: httpClient,
+		clientset:  clientset,
+	}, nil
+}
+
+// tracker 의 lock 을 잡은 상태에서 호출해야 한다.
+func (c *remoteCluster) getRuntimeClient() (client.Client, error) {
+	if c.runtimeClient != nil {
+		return c.runtimeClient, nil
+	}
+
+	// 사용하는 resource 에 대해서만 discovery 를 수행하도록 lazy mapper 를 사용한다.
+	mapper, err := apiutil.NewDynamicRESTMapper(c.restConfig, apiutil.WithLazyDiscovery)
+	if err != nil {
+		return nil, err
+	}
+	runtimeClient, err := client.New(c.restConfig, client.Options{Mapper: mapper})
+	if err != nil {
+		return nil, err
+	}
+	c.runtimeClient = runtimeClient
+	return runtimeClient, nil
+}
+
+func (c *remoteCluster) close() {
+	c.httpClient.CloseIdleConnections()
+}
+
+// cluster 가 삭제된 경우 해당 cluster 에 대해 생성해 둔 client 를 제거한다.
+func RemoveRemoteCluster(namespace, clusterName string) {
+	remoteClusters.remove(namespace, clusterName)
+}
+
+// member cluster 의 api server 주소와 kubeconfig 의 인증 정보가 적용된 transport 를 반환한다.
+// proxy 처럼 client 대신 http 요청을 직접 전달하는 경우에 사용한다.
+func GetRemoteK8sTransport(secret *coreV1.Secret) (string, http.RoundTripper, error) {
+	c, err := remoteClusters.get(secret)
+	if err != nil {
+		return "", nil, err
+	}
+	return c.restConfig.Host, c.httpClient.Transport, nil
+}
@@ -0,0 +1,97 @@
+package util
+
+import (
+	"context"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/kubernetes"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// member cluster 에 object 를 생성한 owner 의 종류
+	RemoteOwnerKindWorkloadDistribution = "WorkloadDistribution"
+	RemoteOwnerKindClusterPolicy        = "ClusterPolicy"
+	// owner 는 addon 을 설정한 cluster manager 이다.
+	RemoteOwnerKindCertManagerAddon = "CertManagerAddon"
+)
+
+// RemoteOwner 는 member cluster 의 object 를 생성한 management cluster 의 리소스이다.
+type RemoteOwner struct {
+	Kind      string
+	Namespace string
+	Name      string
+}
+
+// operator 가 생성했음을 나타내는 label 과 owner label 을 설정한다.
+func SetRemoteOwnerLabels(obj metav1.Object, owner RemoteOwner) {
+	labels := obj.GetLabels()
+	if labels == nil {
+		labels = map[string]string{}
+	}
+	labels[LabelKeyManagedBy] = ManagedByOperator
+	labels[LabelKeyOwnerKind] = owner.Kind
+	labels[LabelKeyOwnerNamespace] = owner.Namespace
+	labels[LabelKeyOwnerName] = owner.Name
+	obj.SetLabels(labels)
+}
+
+// owner label 이 모두 있는 경우에만 owner 를 반환한다.
+func GetRemoteOwner(obj metav1.Object) (RemoteOwner, bool) {
+	labels := obj.GetLabels()
+	if labels[LabelKeyManagedBy] != ManagedByOperator {
+		return RemoteOwner{}, false
+	}
+	owner := RemoteOwner{
+		Kind:      labels[LabelKeyOwnerKind],
+		Namespace: labels[LabelKeyOwnerNamespace],
+		Name:      labels[LabelKeyOwnerName],
+	}
+	if owner.Kind == "" || owner.Name == "" {
+		return RemoteOwner{}, false
+	}
+	return owner, true
+}
+
+// member cluster 에서 operator 가 생성한 object 를 모든 resource 에 대해 조회한다.
+// 반환하는 object 는 조회한 resource 의 GroupVersionKind 를 가진다.
+func ListRemoteManagedObjects(ctx context.Context, remoteClientset *kubernetes.Clientset, remoteClient client.Client) ([]metav1.PartialObjectMetadata, error) {
+	// 일부 api group 의 discovery 가 실패해도 나머지 resource 는 조회한다.
+	resourceLists, err := remoteClientset.Discovery().ServerPreferredResources()
+	if err != nil && !discovery.IsGroupDiscoveryFailedError(err) {
+		return nil, ClassifyRemoteError(err)
+	}
+	resourceLists = discovery.FilteredBy(discovery.SupportsAllVerbs{Verbs: []string{"list", "delete"}}, resourceLists)
+
+	objects := []metav1.PartialObjectMetadata{}
+	for _, resourceList := range resourceLists {
+		gv, err := schema.ParseGroupVersion(resourceList.GroupVersion)
+		if err != nil {
+			continue
+		}
+		for _, resource := range resourceList.APIResources {
+			// subresource 는 조회하지 않는다.
+			if strings.Contains(resource.Name, "/") {
+				continue
+			}
+			gvk := gv.WithKind(resource.Kind)
+			list := &metav1.PartialObjectMetadataList{}
+			list.SetGroupVersionKind(gv.WithKind(resource.Kind + "List"))
+			err := ListPages(ctx, remoteClient, list, func() (bool, error) {
+				for _, item := range list.Items {
+					item.SetGroupVersionKind(gvk)
+					objects = append(objects, item)
+				}
+				return true, nil
+			}, client.MatchingLabels{LabelKeyManagedBy: ManagedByOperator})
+			if err != nil {
+				return nil, ClassifyRemoteError(err)
+			}
+		}
+	}
+	return objects, nil
+}
@@ -0,0 +1,39 @@
+package util
+
+import (
+	"context"
+	"crypto/tls"
+	"net/http"
+	"path/filepath"
+
+	"github.com/go-logr/logr"
+	"sigs.k8s.io/controller-runtime/pkg/certwatcher"
+)
+
+const (
+	ServingCertName = "tls.crt"
+	ServingKeyName  = "tls.key"
+	// cert-manager 가 serving cert secret 에 함께 저장하는 발급자의 CA
+	ServingCAName = "ca.crt"
+)
+
+// webhook server 와 같이 certDir 의 tls.crt, tls.key 로 https 요청을 처리하도록 server 를 설정한다.
+// cert-manager 가 cert 를 갱신하면 재시작하지 않아도 새 cert 를 사용한다. ctx 가 종료될 때까지 cert 를 watch 한다.
+// server 는 ListenAndServeTLS("", "") 로 시작해야 한다.
+func ConfigureServingCert(ctx context.Context, server *http.Server, certDir string, log logr.Logger) error {
+	watcher, err := certwatcher.New(filepath.Join(certDir, ServingCertName), filepath.Join(certDir, ServingKeyName))
+	if err != nil {
+		return err
+	}
+	go func() {
+		if err := watcher.Start(ctx); err != nil {
+			log.Error(err, "Failed to watch serving cert", "certDir", certDir)
+		}
+	}()
+
+	server.TLSConfig = &tls.Config{
+		GetCertificate: watcher.GetCertificate,
+		MinVersion:     tls.VersionTLS12,
+	}
+	return nil
+}
@@ -0,0 +1,118 @@
+package util
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"os"
+	"strconv"
+	"strings"
+
+	coreV1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// Shard 는 여러 operator replica 가 namespace 단위로 나누어 reconcile 하도록 한다.
+// namespace 에 LabelKeyShard label 이 있으면 해당 shard 가, 없으면 namespace 이름의 hash 로 정해진 shard 가 처리한다.
+// cluster scope 의 object 는 0 번 shard 가 처리한다.
+// 같은 shard 의 replica 끼리는 leader election 으로 하나만 동작한다.
+type Shard struct {
+	Index int
+	Count int
+}
+
+// 기본값은 shard 를 나누지 않는 것이다.
+var currentShard = Shard{Index: 0, Count: 1}
+
+// index 가 음수이면 statefulset pod 의 hostname 에 붙은 ordinal 을 index 로 사용한다.
+func SetShard(index, count int) (Shard, error) {
+	if count < 1 {
+		return Shard{}, fmt.Errorf("invalid shard count %d", count)
+	}
+	if index < 0 {
+		ordinal, err := hostnameOrdinal()
+		if err != nil {
+			return Shard{}, err
+		}
+		index = ordinal
+	}
+	if index >= count {
+		return Shard{}, fmt.Errorf("shard index %d is out of range for shard count %d", index, count)
+	}
+	currentShard = Shard{Index: index, Count: count}
+	return currentShard, nil
+}
+
+func GetShard() Shard {
+	return currentShard
+}
+
+func (s Shard) Enabled() bool {
+	return s.Count > 1
+}
+
+// shard 별로 leader election 을 하도록 id 에 shard index 를 붙인다.
+func (s Shard) LeaderElectionID(id string) string {
+	if !s.Enabled() {
+		return id
+	}
+	return fmt.Sprintf("%s-shard-%d", id, s.Index)
+}
+
+// namespace 를 처리할 shard 의 index 를 반환한다.
+// label 이 잘못된 값이면 hash 로 정한다.
+func (s Shard) IndexFor(ns *coreV1.Namespace) int {
+	if ns.Name == "" {
+		return 0
+	}
+	if v, ok := ns.Labels[LabelKeyShard]; ok {
+		if index, err := strconv.Atoi(v); err == nil && index >= 0 && index < s.Count {
+			return index
+		}
+	}
+	h := fnv.New32a()
+	h.Write([]byte(ns.Name))
+	return int(h.Sum32() % uint32(s.Count))
+}
+
+// statefulset pod 의 hostname 은 <name>-<ordinal> 형식이다.
+func hostnameOrdinal() (int, error) {
+	hostname, err := os.Hostname()
+	if err != nil {
+		return 0, err
+	}
+	i := strings.LastIndex(hostname, "-")
+	ordinal, err := strconv.Atoi(hostname[i+1:])
+	if i < 0 || err != nil {
+		return 0, fmt.Errorf("cannot get shard index from hostname %q", hostname)
+	}
+	return ordinal, nil
+}
+
+// 다른 shard 가 처리할 namespace 에 대한 request 는 reconcile 하지 않는다.
+// map function 으로 enqueue 된 request 도 걸러내기 위해 predicate 가 아닌 reconciler 에서 확인한다.
+// shard 를 나누지 않으면 r 을 그대로 반환한다.
+// +kubebuilder:rbac:groups="",resources=namespaces,verbs=get;list;watch
+func ShardReconciler(c client.Reader, r reconcile.Reconciler) reconcile.Reconciler {
+	if !currentShard.Enabled() {
+		return r
+	}
+	return reconcile.Func(func(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+		ns := &coreV1.Namespace{}
+		if req.Namespace != "" {
+			if err := c.Get(ctx, types.NamespacedName{Name: req.Namespace}, ns); errors.IsNotFound(err) {
+				return ctrl.Result{}, nil
+			} else if err != nil {
+				return ctrl.Result{}, err
+			}
+		}
+		if currentShard.IndexFor(ns) != currentShard.Index {
+			return ctrl.Result{}, nil
+		}
+		return r.Reconcile(ctx, req)
+	})
+}
@@ -0,0 +1,340 @@
+package util
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	b64 "encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	coreV1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	tenantKeyNonceSize = 12
+	// 암호문 앞에 붙이는 암호화된 data key 의 길이 (2 byte, big endian)
+	tenantKeyLengthSize = 2
+	// kms 의 encryption context 로 namespace 를 지정하여 다른 namespace 의 암호문을 복호화하지 못하게 한다.
+	tenantKeyEncryptionContextNamespace = "namespace"
+
+	vaultTransitMountDefault = "transit"
+	vaultKubernetesAuthPath  = "auth/kubernetes/login"
+	vaultTokenRefreshWindow  = time.Minute
+	serviceAccountTokenFile  = "/var/run/secrets/kubernetes.io/serviceaccount/token"
+)
+
+// TenantKey 는 namespace 에 설정된 tenant 의 key 이다.
+// ClusterRegistration 으로 제출하는 kubeconfig 와 backup bundle 의 kubeconfig secret 은 tenant key 로 암호화한 data key 로 암호화하므로,
+// 한 tenant 의 key 가 노출되어도 다른 tenant 의 credential 은 복호화할 수 없다.
+// 등록된 cluster 의 kubeconfig secret 은 tenant key 로 암호화하지 않고 다른 secret 과 같이 저장한다.
+type TenantKey struct {
+	Namespace       string `json:"namespace"`
+	KMSKeyARN       string `json:"kmsKeyARN,omitempty"`
+	VaultTransitKey string `json:"vaultTransitKey,omitempty"`
+}
+
+// namespace 에 tenant key 가 설정되어 있지 않으면 nil 을 반환한다.
+func GetTenantKey(ctx context.Context, c client.Reader, namespace string) (*TenantKey, error) {
+	ns := &coreV1.Namespace{}
+	if err := c.Get(ctx, types.NamespacedName{Name: namespace}, ns); err != nil {
+		return nil, err
+	}
+
+	key := &TenantKey{
+		Namespace:       namespace,
+		KMSKeyARN:       ns.Annotations[AnnotationKeyTenantKMSKeyARN],
+		VaultTransitKey: ns.Annotations[AnnotationKeyTenantVaultTransitKey],
+	}
+	if key.KMSKeyARN == "" && key.VaultTransitKey == "" {
+		return nil, nil
+	}
+	if err := key.Validate(); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+func (k *TenantKey) Validate() error {
+	if k.KMSKeyARN != "" && k.VaultTransitKey != "" {
+		return fmt.Errorf("only one of %s and %s can be set on namespace %s",
+			AnnotationKeyTenantKMSKeyARN, AnnotationKeyTenantVaultTransitKey, k.Namespace)
+	}
+	if k.KMSKeyARN != "" {
+		if _, _, err := parseKMSKeyARN(k.KMSKeyARN); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (k *TenantKey) String() string {
+	if k.KMSKeyARN != "" {
+		return k.KMSKeyARN
+	}
+	return "vault:" + k.VaultTransitKey
+}
+
+// EncryptWithTenantKey 는 tenant key 로 생성한 data key 로 plaintext 를 암호화한다.
+// 암호문은 2 byte 길이 | tenant key 로 암호화한 data key | 12 byte nonce | aes-gcm 으로 암호화한 plaintext 형식이며,
+// namespace 를 aes-gcm 의 additional data 로 사용한다.
+func EncryptWithTenantKey(ctx context.Context, key *TenantKey, plaintext []byte) ([]byte, error) {
+	dataKey, wrapped, err := key.generateDataKey(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(wrapped) > 0xffff {
+		return nil, fmt.Errorf("encrypted data key of %s is too long", key)
+	}
+	gcm, err := newTenantKeyCipher(dataKey)
+	if err != nil {
+		return nil, err
+	}
+
+	data := make([]byte, tenantKeyLengthSize, tenantKeyLengthSize+len(wrapped)+tenantKeyNonceSize+len(plaintext)+gcm.Overhead())
+	binary.BigEndian.PutUint16(data, uint16(len(wrapped)))
+	data = append(data, wrapped...)
+	nonce := make([]byte, tenantKeyNonceSize)
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+	data = append(data, nonce...)
+	return gcm.Seal(data, nonce, plaintext, []byte(key.Namespace)), nil
+}
+
+func DecryptWithTenantKey(ctx context.Context, key *TenantKey, data []byte) ([]byte, error) {
+	if len(data) < tenantKeyLengthSize {
+		return nil, fmt.Errorf("encrypted data is too short")
+	}
+	wrappedSize := int(binary.BigEndian.Uint16(data))
+	if len(data) < tenantKeyLengthSize+wrappedSize+tenantKeyNonceSize {
+		return nil, fmt.Errorf("encrypted data is too short")
+	}
+	wrapped := data[tenantKeyLengthSize : tenantKeyLengthSize+wrappedSize]
+	nonce := data[tenantKeyLengthSize+wrappedSize : tenantKeyLengthSize+wrappedSize+tenantKeyNonceSize]
+
+	dataKey, err := key.decryptDataKey(ctx, wrapped)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := newTenantKeyCipher(dataKey)
+	if err != nil {
+		return nil, err
+	}
+	return gcm.Open(nil, nonce, data[tenantKeyLengthSize+wrappedSize+tenantKeyNonceSize:], []byte(key.Namespace))
+}
+
+func newTenantKeyCipher(dataKey []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(dataKey)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCMWithNonceSize(block, tenantKeyNonceSize)
+}
+
+// aes-256 data key 의 plaintext 와 tenant key 로 암호화한 data key 를 반환한다.
+func (k *TenantKey) generateDataKey(ctx context.Context) ([]byte, []byte, error) {
+	if k.KMSKeyARN != "" {
+		output := struct {
+			CiphertextBlob []byte
+			Plaintext      []byte
+		}{}
+		input := map[string]interface{}{
+			"KeyId":             k.KMSKeyARN,
+			"KeySpec":           "AES_256",
+			"EncryptionContext": map[string]string{tenantKeyEncryptionContextNamespace: k.Namespace},
+		}
+		if err := callKMS(ctx, k.KMSKeyARN, "GenerateDataKey", input, &output); err != nil {
+			return nil, nil, err
+		}
+		return output.Plaintext, output.CiphertextBlob, nil
+	}
+
+	output := struct {
+		Data struct {
+			Plaintext  string `json:"plaintext"`
+			Ciphertext string `json:"ciphertext"`
+		} `json:"data"`
+	}{}
+	path := fmt.Sprintf("%s/datakey/plaintext/%s", vaultTransitMount(), k.VaultTransitKey)
+	if err := tenantVault.call(ctx, path, map[string]interface{}{"bits": 256}, &output); err != nil {
+		return nil, nil, err
+	}
+	plaintext, err := b64.StdEncoding.DecodeString(output.Data.Plaintext)
+	if err != nil {
+		return nil, nil, err
+	}
+	return plaintext, []byte(output.Data.Ciphertext), nil
+}
+
+func (k *TenantKey) decryptDataKey(ctx context.Context, wrapped []byte) ([]byte, error) {
+	if k.KMSKeyARN != "" {
+		output := struct {
+			Plaintext []byte
+		}{}
+		input := map[string]interface{}{
+			"KeyId":             k.KMSKeyARN,
+			"CiphertextBlob":    wrapped,
+			"EncryptionContext": map[string]string{tenantKeyEncryptionContextNamespace: k.Namespace},
+		}
+		if err := callKMS(ctx, k.KMSKeyARN, "Decrypt", input, &output); err != nil {
+			return nil, err
+		}
+		return output.Plaintext, nil
+	}
+
+	output := struct {
+		Data struct {
+			Plaintext string `json:"plaintext"`
+		} `json:"data"`
+	}{}
+	path := fmt.Sprintf("%s/decrypt/%s", vaultTransitMount(), k.VaultTransitKey)
+	if err := tenantVault.call(ctx, path, map[string]interface{}{"ciphertext": string(wrapped)}, &output); err != nil {
+		return nil, err
+	}
+	return b64.StdEncoding.DecodeString(output.Data.Plaintext)
+}
+
+// arn:<partition>:kms:<region>:<account>:key/<id> 에서 partition 과 region 을 반환한다.
+func parseKMSKeyARN(arn string) (string, string, error) {
+	parts := strings.SplitN(arn, ":", 6)
+	if len(parts) != 6 || parts[0] != "arn" || parts[2] != "kms" || parts[3] == "" ||
+		!(strings.HasPrefix(parts[5], "key/") || strings.HasPrefix(parts[5], "alias/")) {
+		return "", "", fmt.Errorf("%s is not a kms key arn", arn)
+	}
+	return parts[1], parts[3], nil
+}
+
+// kms 의 json api 를 operator 의 aws credential 로 호출한다.
+func callKMS(ctx context.Context, keyARN, action string, input, output interface{}) error {
+	partition, region, err := parseKMSKeyARN(keyARN)
+	if err != nil {
+		return err
+	}
+	creds, err := operatorAWSCredentials(ctx, partition, region)
+	if err != nil {
+		return err
+	}
+
+	body, err := json.Marshal(input)
+	if err != nil {
+		return err
+	}
+	endpoint := fmt.Sprintf("https://kms.%s.%s/", region, awsDomain(partition))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	req.Header.Set("X-Amz-Target", "TrentService."+action)
+	signAWSRequest(req, body, creds, region, "kms", time.Now())
+	respBody, err := doCloudHTTPRequest(req)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(respBody, output)
+}
+
+func vaultTransitMount() string {
+	if mount := os.Getenv(VAULT_TRANSIT_MOUNT); mount != "" {
+		return strings.Trim(mount, "/")
+	}
+	return vaultTransitMountDefault
+}
+
+// vault 의 kubernetes auth 로 operator 의 service account token 을 사용하여 로그인한다.
+// 발급받은 token 은 lease 가 끝나기 전까지 재사용한다.
+type vaultClient struct {
+	mu         sync.Mutex
+	token      string
+	expiration time.Time
+}
+
+var tenantVault = &vaultClient{}
+
+func (v *vaultClient) call(ctx context.Context, path string, input, output interface{}) error {
+	token, err := v.getToken(ctx)
+	if err != nil {
+		return err
+	}
+	err = v.do(ctx, token, path, input, output)
+	if errors.Is(err, ErrPermissionDenied) {
+		// token 이 폐기된 경우 다음 요청에서 다시 로그인한다.
+		v.reset()
+	}
+	return err
+}
+
+func (v *vaultClient) getToken(ctx context.Context) (string, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if v.token != "" && time.Until(v.expiration) > vaultTokenRefreshWindow {
+		return v.token, nil
+	}
+	role := os.Getenv(VAULT_AUTH_ROLE)
+	if os.Getenv(VAULT_ADDR) == "" || role == "" {
+		return "", NewError(ErrPermissionDenied, fmt.Errorf("vault is not configured for the operator"))
+	}
+	jwt, err := ioutil.ReadFile(serviceAccountTokenFile)
+	if err != nil {
+		return "", err
+	}
+
+	output := struct {
+		Auth struct {
+			ClientToken   string `json:"client_token"`
+			LeaseDuration int    `json:"lease_duration"`
+		} `json:"auth"`
+	}{}
+	input := map[string]string{"role": role, "jwt": strings.TrimSpace(string(jwt))}
+	if err := v.do(ctx, "", vaultKubernetesAuthPath, input, &output); err != nil {
+		return "", err
+	}
+	if output.Auth.ClientToken == "" {
+		return "", fmt.Errorf("vault login returned no token")
+	}
+	v.token = output.Auth.ClientToken
+	v.expiration = time.Now().Add(time.Duration(output.Auth.LeaseDuration) * time.Second)
+	return v.token, nil
+}
+
+func (v *vaultClient) reset() {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.token = ""
+}
+
+func (v *vaultClient) do(ctx context.Context, token, path string, input, output interface{}) error {
+	body, err := json.Marshal(input)
+	if err != nil {
+		return err
+	}
+	endpoint := strings.TrimRight(os.Getenv(VAULT_ADDR), "/") + "/v1/" + path
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("X-Vault-Token", token)
+	}
+	respBody, err := doCloudHTTPRequest(req)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(respBody, output)
+}
@@ -1,21 +1,35 @@
 package util
 
 import (
+	"context"
+	cryptorand "crypto/rand"
+	"encoding/hex"
 	"fmt"
 	"hash/fnv"
 	"math/rand"
 	"net/url"
 	"os"
+	"regexp"
+	"strconv"
 	"strings"
 	"time"
 
+	"github.com/tmax-cloud/hypercloud-multi-operator/controllers/metrics"
 	traefikv1alpha1 "github.com/traefik/traefik/v2/pkg/provider/kubernetes/crd/generated/clientset/versioned/typed/traefik/v1alpha1"
 	coreV1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
 	"k8s.io/client-go/kubernetes"
 	restclient "k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+var (
+	invalidSecretNameChars   = regexp.MustCompile(`[^a-z0-9.-]+`)
+	secretNameLabelSeparator = regexp.MustCompile(`[-.]*\.[-.]*`)
 )
 
 // LowestNonZeroResult compares two reconciliation results
@@ -37,29 +51,14 @@ func LowestNonZeroResult(i, j ctrl.Result) ctrl.Result {
 	}
 }
 
+// kubeconfig secret 으로 member cluster 의 clientset 을 가져온다.
+// 같은 secret 에 대해서는 생성해 둔 clientset 을 재사용한다.
 func GetRemoteK8sClient(secret *coreV1.Secret) (*kubernetes.Clientset, error) {
-	value, ok := secret.Data["value"]
-	if !ok {
-		err := errors.NewBadRequest("secret does not have a value")
-		return nil, err
-	}
-
-	remoteClientConfig, err := clientcmd.NewClientConfigFromBytes(value)
-	if err != nil {
-		return nil, err
-	}
-
-	remoteRestConfig, err := remoteClientConfig.ClientConfig()
-	if err != nil {
-		return nil, err
-	}
-
-	remoteClientset, err := kubernetes.NewForConfig(remoteRestConfig)
+	c, err := remoteClusters.get(secret)
 	if err != nil {
 		return nil, err
 	}
-
-	return remoteClientset, nil
+	return c.clientset, nil
 }
 
 func GetRemoteK8sTraefikClient(secret *coreV1.Secret) (*traefikv1alpha1.TraefikV1alpha1Client, error) {
@@ -78,6 +77,10 @@ func GetRemoteK8sTraefikClient(secret *coreV1.Secret) (*traefikv1alpha1.TraefikV
 	if err != nil {
 		return nil, err
 	}
+	remoteRestConfig.WrapTransport = metrics.InstrumentRoundTripper
+	if err := configureManagedClusterAuth(remoteRestConfig, ManagedClusterAuthFromSecret(secret)); err != nil {
+		return nil, err
+	}
 
 	remoteClientset, err := traefikv1alpha1.NewForConfig(remoteRestConfig)
 	if err != nil {
@@ -87,16 +90,40 @@ func GetRemoteK8sTraefikClient(secret *coreV1.Secret) (*traefikv1alpha1.TraefikV
 	return remoteClientset, nil
 }
 
-func GetRemoteK8sClientByKubeConfig(kubeConfig []byte) (*kubernetes.Clientset, error) {
-	remoteClientConfig, err := clientcmd.NewClientConfigFromBytes(kubeConfig)
-	if err != nil {
-		return nil, err
+// 임의의 리소스(unstructured)를 다루기 위한 remote cluster 의 controller-runtime client
+// kubeconfig secret 으로 member cluster 의 controller-runtime client 를 가져온다.
+// 같은 secret 에 대해서는 생성해 둔 client 를 재사용한다.
+func GetRemoteK8sRuntimeClient(secret *coreV1.Secret) (client.Client, error) {
+	return remoteClusters.getRuntimeClient(secret)
+}
+
+// member cluster 에 namespace 가 없으면 생성한다.
+func EnsureRemoteNamespace(remoteClientset kubernetes.Interface, namespace string) error {
+	_, err := remoteClientset.
+		CoreV1().
+		Namespaces().
+		Get(context.TODO(), namespace, metav1.GetOptions{})
+	if errors.IsNotFound(err) {
+		ns := &coreV1.Namespace{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: namespace,
+			},
+		}
+		if _, err := remoteClientset.CoreV1().Namespaces().Create(context.TODO(), ns, metav1.CreateOptions{}); err != nil && !errors.IsAlreadyExists(err) {
+			return err
+		}
+	} else if err != nil {
+		return err
 	}
+	return nil
+}
 
-	remoteRestConfig, err := remoteClientConfig.ClientConfig()
+func GetRemoteK8sClientByKubeConfig(kubeConfig *Kubeconfig) (*kubernetes.Clientset, error) {
+	remoteRestConfig, err := kubeConfig.RESTConfig()
 	if err != nil {
 		return nil, err
 	}
+	remoteRestConfig.Wrap(metrics.InstrumentRoundTripper)
 
 	remoteClientset, err := kubernetes.NewForConfig(remoteRestConfig)
 	if err != nil {
@@ -135,6 +162,15 @@ func CreateSuffixString() string {
 	return string(s)
 }
 
+// hyperauth client 에 사용할 임의의 secret
+func CreateClientSecretString() (string, error) {
+	b := make([]byte, 16)
+	if _, err := cryptorand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
 func MergeJson(dest []byte, source []byte) []byte {
 	dest = append(dest[0:len(dest)-1], 44)
 	dest = append(dest, source[1:]...)
@@ -142,6 +178,9 @@ func MergeJson(dest []byte, source []byte) []byte {
 	return dest
 }
 
+// argocd cluster secret 이름은 DNS-1123 subdomain 규칙을 따라야 하므로,
+// host 를 규칙에 맞게 변환하고 전체 uri 의 hash 를 붙여 이름이 충돌하지 않게 한다.
+// 예) https://[fd00::1]:6443 -> cluster-fd00-1-1a2b3c4d
 func URIToSecretName(uriType, uri string) (string, error) {
 	parsedURI, err := url.ParseRequestURI(uri)
 	if err != nil {
@@ -150,7 +189,37 @@ func URIToSecretName(uriType, uri string) (string, error) {
 
 	h := fnv.New32a()
 	_, _ = h.Write([]byte(uri))
-	host := strings.ToLower(strings.Split(parsedURI.Host, ":")[0])
+	hash := fmt.Sprintf("%08x", h.Sum32())
+
+	host := invalidSecretNameChars.ReplaceAllString(strings.ToLower(parsedURI.Hostname()), "-")
+	host = strings.Trim(secretNameLabelSeparator.ReplaceAllString(host, "."), "-.")
+	maxHostLength := validation.DNS1123SubdomainMaxLength - len(uriType) - len(hash) - 2
+	if len(host) > maxHostLength {
+		host = strings.Trim(host[:maxHostLength], "-.")
+	}
+
+	name := uriType + "-" + hash
+	if host != "" {
+		name = uriType + "-" + host + "-" + hash
+	}
+	if errs := validation.IsDNS1123Subdomain(name); len(errs) > 0 {
+		return "", fmt.Errorf("invalid secret name [%s]: %s", name, strings.Join(errs, ", "))
+	}
+	return name, nil
+}
+
+// 이전 버전에서 사용하던 argocd cluster secret 이름 생성 방식
+// 이미 생성되어 있는 secret 을 찾기 위한 용도로만 사용한다.
+func LegacyURIToSecretName(uriType, uri string) (string, error) {
+	parsedURI, err := url.ParseRequestURI(uri)
+	if err != nil {
+		return "", err
+	}
+
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(uri))
+	// IPv6 address 는 이전 방식으로 유효한 secret 이름을 만들 수 없었으므로 bracket 을 제외한 host 를 사용해도 기존 secret 에 영향이 없다.
+	host := strings.ToLower(parsedURI.Hostname())
 
 	return fmt.Sprintf("%s-%s-%v", uriType, host, h.Sum32()), nil
 }
@@ -183,6 +252,23 @@ func CheckRequiredEnvPreset() error {
 	return nil
 }
 
+// 환경 변수를 duration 으로 변환한다. 설정되지 않은 경우 0 을 반환한다.
+func GetDurationEnv(env string) (time.Duration, error) {
+	value := os.Getenv(env)
+	if value == "" {
+		return 0, nil
+	}
+	return time.ParseDuration(value)
+}
+
+func GetIntEnv(env string) (int, error) {
+	value := os.Getenv(env)
+	if value == "" {
+		return 0, nil
+	}
+	return strconv.Atoi(value)
+}
+
 func IsTrue(str string) bool {
 	str = strings.ToUpper(str)
 	if str == "TRUE" {
@@ -191,12 +277,19 @@ func IsTrue(str string) bool {
 	return false
 }
 
-func IsClusterHealthy(clientSet *kubernetes.Clientset) bool {
+func IsClusterHealthy(clientSet kubernetes.Interface) bool {
+	return CheckClusterHealth(clientSet) == nil
+}
 
-	if _, err := clientSet.ServerVersion(); err != nil {
-		return false
+// remote cluster 의 api server 에 요청하여, 실패한 경우 ErrRemoteUnreachable 또는 ErrPermissionDenied 를 반환한다.
+func CheckClusterHealth(clientSet kubernetes.Interface) error {
+	if _, err := clientSet.Discovery().ServerVersion(); err != nil {
+		if errors.IsUnauthorized(err) || errors.IsForbidden(err) {
+			return NewError(ErrPermissionDenied, err)
+		}
+		return NewError(ErrRemoteUnreachable, err)
 	}
-	return true
+	return nil
 }
 
 // thumbprint가 colon 없이 들어온다면 colon을 붙인다.
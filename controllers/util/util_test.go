@@ -0,0 +1,120 @@
+package util
+
+import (
+	"strings"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+func TestURIToSecretName(t *testing.T) {
+	tests := []struct {
+		name    string
+		uri     string
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "ipv4",
+			uri:  "https://192.168.0.1:6443",
+			want: "cluster-192.168.0.1-50794ed7",
+		},
+		{
+			name: "ipv6",
+			uri:  "https://[fd00::1]:6443",
+			want: "cluster-fd00-1-6b7fd97c",
+		},
+		{
+			name: "uppercase host",
+			uri:  "https://API.Example.com",
+			want: "cluster-api.example.com-09d77ae9",
+		},
+		{
+			name: "empty labels are collapsed",
+			uri:  "https://a..b-.example.com:6443/path",
+			want: "cluster-a.b.example.com-330f977e",
+		},
+		{
+			name:    "invalid uri",
+			uri:     "192.168.0.1:6443",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := URIToSecretName("cluster", tt.uri)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("URIToSecretName() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("URIToSecretName() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestURIToSecretNameLongHost(t *testing.T) {
+	host := strings.Repeat("a", 60) + "." + strings.Repeat("b", 60) + "." + strings.Repeat("c", 60) + "." + strings.Repeat("d", 60) + ".example.com"
+	name, err := URIToSecretName("cluster", "https://"+host+":6443")
+	if err != nil {
+		t.Fatalf("URIToSecretName() error = %v", err)
+	}
+	if len(name) > validation.DNS1123SubdomainMaxLength {
+		t.Errorf("len(name) = %d, want <= %d", len(name), validation.DNS1123SubdomainMaxLength)
+	}
+	if errs := validation.IsDNS1123Subdomain(name); len(errs) > 0 {
+		t.Errorf("name %q is invalid: %v", name, errs)
+	}
+
+	// host 가 잘려도 전체 uri 의 hash 로 구분된다.
+	other, err := URIToSecretName("cluster", "https://"+host+":6444")
+	if err != nil {
+		t.Fatalf("URIToSecretName() error = %v", err)
+	}
+	if name == other {
+		t.Errorf("names of different uris should be different: %q", name)
+	}
+}
+
+func TestLegacyURIToSecretName(t *testing.T) {
+	tests := []struct {
+		name    string
+		uri     string
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "ipv4",
+			uri:  "https://192.168.0.1:6443",
+			want: "cluster-192.168.0.1-1350127319",
+		},
+		{
+			name: "ipv6",
+			uri:  "https://[fd00::1]:6443",
+			want: "cluster-fd00::1-1803540860",
+		},
+		{
+			name: "uppercase host",
+			uri:  "https://API.Example.com",
+			want: "cluster-api.example.com-165116649",
+		},
+		{
+			name:    "invalid uri",
+			uri:     "192.168.0.1:6443",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := LegacyURIToSecretName("cluster", tt.uri)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("LegacyURIToSecretName() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("LegacyURIToSecretName() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
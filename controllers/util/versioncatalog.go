@@ -0,0 +1,96 @@
+package util
+
+import (
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+
+	clusterV1alpha1 "github.com/tmax-cloud/hypercloud-multi-operator/apis/cluster/v1alpha1"
+	"k8s.io/apimachinery/pkg/util/version"
+)
+
+const (
+	// cluster 생성에 사용하는 upstream 의 image registry 와 cni manifest
+	DefaultKubernetesImageRepository = "registry.k8s.io"
+	DefaultCNIManifestURL            = "https://docs.projectcalico.org/archive/v3.24/manifests/calico.yaml"
+)
+
+var minorVersionRegexp = regexp.MustCompile(`^v?[0-9]+\.[0-9]+$`)
+
+// ProvisioningArtifacts 는 지원하는 version 의 cluster 를 생성할 때 image 와 file 을 받을 위치이다.
+type ProvisioningArtifacts struct {
+	// kubeadm 이 control plane image 를 받을 registry
+	ImageRepository string
+	// control plane 이 적용할 cni manifest 의 주소
+	CNIManifestURL string
+}
+
+// 지원하는 kubernetes minor version 목록, 비어있으면 version 을 검사하지 않는다.
+func GetSupportedKubernetesVersions() []string {
+	return getEnvList(SUPPORTED_KUBERNETES_VERSIONS)
+}
+
+// patch version 은 비교하지 않으므로 major.minor 형식만 허용한다. (v 는 붙여도 된다.)
+func validateMinorVersion(value string) []string {
+	if !minorVersionRegexp.MatchString(value) {
+		return []string{"must be a minor version such as 1.26"}
+	}
+	return nil
+}
+
+// version 의 minor version 이 supported 목록에 있는지 확인한다.
+func IsSupportedKubernetesVersion(value string, supported []string) (bool, error) {
+	v, err := version.ParseGeneric(value)
+	if err != nil {
+		return false, err
+	}
+	for _, s := range supported {
+		minor, err := version.ParseGeneric(s)
+		if err != nil {
+			continue
+		}
+		if v.Major() == minor.Major() && v.Minor() == minor.Minor() {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// air-gapped 환경에서 cluster 를 생성하는지 확인한다.
+func IsAirGapped() bool {
+	return IsTrue(os.Getenv(AIR_GAPPED))
+}
+
+// cluster 를 생성할 때 사용할 image registry 와 cni manifest 주소를 반환한다.
+// air-gapped 환경에서는 내부 registry 와 mirror 를 사용하며, mirror 는 upstream 과 같은 경로로 file 을 제공해야 한다.
+func GetProvisioningArtifacts() ProvisioningArtifacts {
+	artifacts := ProvisioningArtifacts{
+		ImageRepository: DefaultKubernetesImageRepository,
+		CNIManifestURL:  DefaultCNIManifestURL,
+	}
+	if !IsAirGapped() {
+		return artifacts
+	}
+	if repository := os.Getenv(AIR_GAPPED_IMAGE_REPOSITORY); repository != "" {
+		artifacts.ImageRepository = repository
+	}
+	if mirror := os.Getenv(AIR_GAPPED_ARTIFACT_MIRROR); mirror != "" {
+		if u, err := url.Parse(DefaultCNIManifestURL); err == nil {
+			artifacts.CNIManifestURL = strings.TrimSuffix(mirror, "/") + u.Path
+		}
+	}
+	return artifacts
+}
+
+// validation webhook 이 사용할 air-gapped 설정을 반환한다.
+// HyperCloudOperatorConfig 로 바뀔 수 있으므로 요청마다 호출한다.
+func GetAirGapPolicy() clusterV1alpha1.AirGapPolicy {
+	return clusterV1alpha1.AirGapPolicy{
+		Enabled:                     IsAirGapped(),
+		ImageRepository:             os.Getenv(AIR_GAPPED_IMAGE_REPOSITORY),
+		ArtifactMirror:              os.Getenv(AIR_GAPPED_ARTIFACT_MIRROR),
+		ChartRepo:                   os.Getenv(AIR_GAPPED_CHART_REPO),
+		SupportedKubernetesVersions: GetSupportedKubernetesVersions(),
+	}
+}
@@ -0,0 +1,131 @@
+package util
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	coreV1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const watchNamespaceCheckInterval = time.Minute
+
+// operator 가 직접 resource 를 생성, 조회하는 namespace 는 watch 대상에 항상 포함한다.
+var systemWatchNamespaces = []string{
+	HypercloudNamespace,
+	ArgoNamespace,
+	ApiGatewayNamespace,
+	CertManagerNamespace,
+}
+
+// GetWatchNamespaces 는 manager 가 watch 할 namespace 목록을 반환한다.
+// namespaces 는 콤마로 구분한 namespace 이름, selector 는 namespace 의 label selector 이다.
+// 둘 다 비어있으면 모든 namespace 를 watch 하도록 nil 을 반환한다.
+// selector 는 시작할 때 조회하고, 이후의 변경은 WatchNamespaceMonitor 가 operator 를 재시작하여 반영한다.
+func GetWatchNamespaces(ctx context.Context, config *rest.Config, namespaces, selector string) ([]string, error) {
+	if strings.TrimSpace(namespaces) == "" && strings.TrimSpace(selector) == "" {
+		return nil, nil
+	}
+
+	result := watchNamespaceSet(namespaces)
+	if strings.TrimSpace(selector) != "" {
+		if _, err := labels.Parse(selector); err != nil {
+			return nil, err
+		}
+		clientset, err := kubernetes.NewForConfig(config)
+		if err != nil {
+			return nil, err
+		}
+		nsList, err := clientset.CoreV1().Namespaces().List(ctx, metav1.ListOptions{LabelSelector: selector})
+		if err != nil {
+			return nil, err
+		}
+		for _, ns := range nsList.Items {
+			result.Insert(ns.Name)
+		}
+	}
+
+	return result.List(), nil
+}
+
+// 항상 watch 하는 namespace 와 이름으로 지정한 namespace
+func watchNamespaceSet(namespaces string) sets.String {
+	result := sets.NewString(systemWatchNamespaces...)
+	for _, ns := range strings.Split(namespaces, ",") {
+		if ns = strings.TrimSpace(ns); ns != "" {
+			result.Insert(ns)
+		}
+	}
+	return result
+}
+
+// WatchNamespaceMonitor 는 Selector 에 해당하는 namespace 가 바뀌었는지 주기적으로 확인한다.
+// manager 의 cache 는 시작할 때의 namespace 로만 만들어지므로, 바뀐 경우 error 를 반환하여 manager 를 종료하고
+// operator 가 재시작되면서 새 namespace 목록으로 cache 를 만들도록 한다.
+type WatchNamespaceMonitor struct {
+	Reader client.Reader
+	// GetWatchNamespaces 에 전달한 값과 결과
+	Namespaces string
+	Selector   string
+	Current    []string
+	Interval   time.Duration
+	Log        logr.Logger
+}
+
+func (m *WatchNamespaceMonitor) Start(ctx context.Context) error {
+	if m.Interval <= 0 {
+		m.Interval = watchNamespaceCheckInterval
+	}
+	selector, err := labels.Parse(m.Selector)
+	if err != nil {
+		return err
+	}
+
+	ticker := time.NewTicker(m.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+		if err := m.check(ctx, selector); err != nil {
+			return err
+		}
+	}
+}
+
+// 모든 replica 의 cache 가 같은 namespace 를 watch 해야 하므로 leader 가 아니어도 동작한다.
+func (m *WatchNamespaceMonitor) NeedLeaderElection() bool {
+	return false
+}
+
+func (m *WatchNamespaceMonitor) check(ctx context.Context, selector labels.Selector) error {
+	nsList := &coreV1.NamespaceList{}
+	if err := m.Reader.List(ctx, nsList, client.MatchingLabelsSelector{Selector: selector}); err != nil {
+		// 일시적으로 조회하지 못한 경우 다음 주기에 다시 확인한다.
+		m.Log.Error(err, "Failed to list namespaces to watch")
+		return nil
+	}
+	wanted := watchNamespaceSet(m.Namespaces)
+	for _, ns := range nsList.Items {
+		wanted.Insert(ns.Name)
+	}
+
+	current := sets.NewString(m.Current...)
+	if wanted.Equal(current) {
+		return nil
+	}
+	m.Log.Info("Namespaces to watch are changed, restarting",
+		"added", wanted.Difference(current).List(), "removed", current.Difference(wanted).List())
+	return fmt.Errorf("namespaces matching selector %q are changed", m.Selector)
+}
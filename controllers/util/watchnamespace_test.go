@@ -0,0 +1,54 @@
+package util
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-logr/logr"
+	coreV1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestWatchNamespaceMonitorCheck(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := clientgoscheme.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	selected := func(name string) *coreV1.Namespace {
+		return &coreV1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: name, Labels: map[string]string{"tenant": "a"}}}
+	}
+	startup := watchNamespaceSet("explicit").Insert("team-a").List()
+
+	tests := []struct {
+		name        string
+		namespaces  []*coreV1.Namespace
+		wantRestart bool
+	}{
+		{name: "unchanged", namespaces: []*coreV1.Namespace{selected("team-a")}},
+		{name: "namespace added", namespaces: []*coreV1.Namespace{selected("team-a"), selected("team-b")}, wantRestart: true},
+		{name: "namespace removed", wantRestart: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			builder := fake.NewClientBuilder().WithScheme(scheme)
+			for _, ns := range tt.namespaces {
+				builder = builder.WithObjects(ns)
+			}
+			m := &WatchNamespaceMonitor{
+				Reader:     builder.Build(),
+				Namespaces: "explicit",
+				Selector:   "tenant=a",
+				Current:    startup,
+				Log:        logr.Discard(),
+			}
+			err := m.check(context.TODO(), labels.SelectorFromSet(labels.Set{"tenant": "a"}))
+			if (err != nil) != tt.wantRestart {
+				t.Errorf("check() error = %v, wantRestart %v", err, tt.wantRestart)
+			}
+		})
+	}
+}
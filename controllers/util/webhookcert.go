@@ -0,0 +1,81 @@
+package util
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/tmax-cloud/hypercloud-multi-operator/controllers/metrics"
+	coreV1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/record"
+)
+
+const (
+	webhookCertCheckInterval = 1 * time.Hour
+	// WEBHOOK_CERT_EXPIRY_WARNING_DAYS 가 설정되지 않은 경우의 기본값
+	webhookCertExpiryWarningDaysDefault = 30
+
+	ReasonWebhookCertExpiring = "WebhookCertExpiring"
+)
+
+// WebhookCertMonitor 는 주기적으로 webhook serving cert 의 만료 시각을 metric 으로 노출하고,
+// 만료가 임박한 경우 serving cert secret 에 warning event 를 기록한다.
+// webhook cert 가 만료되면 CRD 에 대한 모든 요청이 실패하므로 미리 알 수 있도록 한다.
+type WebhookCertMonitor struct {
+	CertDir  string
+	CertName string
+	KeyName  string
+	// 만료까지 남은 기간이 이 일수보다 적으면 warning event 를 기록한다.
+	WarningDays int
+	Recorder    record.EventRecorder
+	Log         logr.Logger
+}
+
+func (m *WebhookCertMonitor) Start(ctx context.Context) error {
+	if m.WarningDays <= 0 {
+		m.WarningDays = webhookCertExpiryWarningDaysDefault
+	}
+
+	ticker := time.NewTicker(webhookCertCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		m.check()
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// 각 replica 가 mount 한 cert 를 확인해야 하므로 leader 가 아니어도 동작한다.
+func (m *WebhookCertMonitor) NeedLeaderElection() bool {
+	return false
+}
+
+func (m *WebhookCertMonitor) check() {
+	cert, err := LoadWebhookCert(m.CertDir, m.CertName, m.KeyName)
+	if err != nil {
+		m.Log.Error(err, "Failed to load webhook serving cert")
+		return
+	}
+	metrics.WebhookCertExpiry.Set(float64(cert.NotAfter.Unix()))
+
+	remaining := time.Until(cert.NotAfter)
+	if remaining > time.Duration(m.WarningDays)*24*time.Hour {
+		return
+	}
+
+	message := fmt.Sprintf("Webhook serving cert expires at %s", cert.NotAfter.Format(time.RFC3339))
+	m.Log.Info("Webhook serving cert is about to expire", "notAfter", cert.NotAfter)
+	// cert 를 발급하는 cert-manager 의 secret 에 event 를 기록한다.
+	ref := &coreV1.ObjectReference{
+		APIVersion: "v1",
+		Kind:       "Secret",
+		Name:       WebhookServerCertSecret,
+		Namespace:  HypercloudNamespace,
+	}
+	m.Recorder.Event(ref, coreV1.EventTypeWarning, ReasonWebhookCertExpiring, message)
+}
@@ -15,9 +15,14 @@ limitations under the License.
 package main
 
 import (
+	"context"
 	"flag"
+	"fmt"
+	"net/http"
 	"os"
 	"os/signal"
+	"path/filepath"
+	"strings"
 	"syscall"
 
 	// +kubebuilder:scaffold:imports
@@ -27,23 +32,37 @@ import (
 	// servicecatalogv1beta1 "github.com/kubernetes-sigs/service-catalog/pkg/apis/servicecatalog/v1beta1"
 	claimV1alpha1 "github.com/tmax-cloud/hypercloud-multi-operator/apis/claim/v1alpha1"
 	clusterV1alpha1 "github.com/tmax-cloud/hypercloud-multi-operator/apis/cluster/v1alpha1"
+	"github.com/tmax-cloud/hypercloud-multi-operator/controllers/authn"
 	claimController "github.com/tmax-cloud/hypercloud-multi-operator/controllers/claim"
 	clusterController "github.com/tmax-cloud/hypercloud-multi-operator/controllers/cluster"
+	"github.com/tmax-cloud/hypercloud-multi-operator/controllers/inventory"
 	k8scontroller "github.com/tmax-cloud/hypercloud-multi-operator/controllers/k8s"
+	"github.com/tmax-cloud/hypercloud-multi-operator/controllers/proxy"
 	"github.com/tmax-cloud/hypercloud-multi-operator/controllers/util"
 	tmaxv1 "github.com/tmax-cloud/template-operator/api/v1"
 	traefikV1alpha1 "github.com/traefik/traefik/v2/pkg/provider/kubernetes/crd/traefik/v1alpha1"
 
+	coreV1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/selection"
+	"k8s.io/apimachinery/pkg/types"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	_ "k8s.io/client-go/plugin/pkg/client/auth/gcp"
+	"k8s.io/client-go/rest"
 
-	clusterV1alpha3 "sigs.k8s.io/cluster-api/api/v1alpha3"
-	controlplanev1 "sigs.k8s.io/cluster-api/controlplane/kubeadm/api/v1alpha3"
+	clusterV1beta1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	bootstrapv1 "sigs.k8s.io/cluster-api/bootstrap/kubeadm/api/v1beta1"
+	controlplanev1 "sigs.k8s.io/cluster-api/controlplane/kubeadm/api/v1beta1"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/healthz"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
 	"sigs.k8s.io/controller-runtime/pkg/manager/signals"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
 )
 
 var (
@@ -55,8 +74,9 @@ func init() {
 	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
 	utilruntime.Must(claimV1alpha1.AddToScheme(scheme))
 	utilruntime.Must(clusterV1alpha1.AddToScheme(scheme))
-	utilruntime.Must(clusterV1alpha3.AddToScheme(scheme))
+	utilruntime.Must(clusterV1beta1.AddToScheme(scheme))
 	utilruntime.Must(controlplanev1.AddToScheme(scheme))
+	utilruntime.Must(bootstrapv1.AddToScheme(scheme))
 	utilruntime.Must(tmaxv1.AddToScheme(scheme))
 	utilruntime.Must(certmanagerV1.AddToScheme(scheme))
 	utilruntime.Must(traefikV1alpha1.AddToScheme(scheme))
@@ -67,11 +87,46 @@ func init() {
 
 func main() {
 	var metricsAddr string
+	var probeAddr string
+	var inventoryAddr string
+	var inventoryCertDir string
+	var proxyAddr string
+	var proxyCertDir string
+	var authnAddr string
+	var authnCertDir string
 	var enableLeaderElection bool
+	var logFormat string
+	var logConfigMap string
+	var shardCount int
+	var shardIndex int
+	var watchNamespaces string
+	var watchNamespaceSelector string
 	flag.StringVar(&metricsAddr, "metrics-addr", ":8080", "The address the metric endpoint binds to.")
+	flag.StringVar(&probeAddr, "health-probe-bind-address", ":8081", "The address the probe endpoint binds to.")
+	flag.StringVar(&inventoryAddr, "inventory-bind-address", "0", "The address the cluster inventory endpoint binds to. Set 0 to disable.")
+	flag.StringVar(&inventoryCertDir, "inventory-cert-dir", filepath.Join(os.TempDir(), "k8s-inventory-server", "serving-certs"),
+		"The directory that contains the serving cert (tls.crt, tls.key) of the cluster inventory endpoint.")
+	flag.StringVar(&proxyAddr, "proxy-bind-address", "0", "The address the member cluster api server proxy binds to. Set 0 to disable.")
+	flag.StringVar(&proxyCertDir, "proxy-cert-dir", filepath.Join(os.TempDir(), "k8s-proxy-server", "serving-certs"),
+		"The directory that contains the serving cert (tls.crt, tls.key) of the member cluster api server proxy.")
+	flag.StringVar(&authnAddr, "authn-bind-address", "0", "The address the member cluster token authentication broker binds to. Set 0 to disable.")
+	flag.StringVar(&authnCertDir, "authn-cert-dir", filepath.Join(os.TempDir(), "k8s-authn-server", "serving-certs"),
+		"The directory that contains the serving cert (tls.crt, tls.key) of the member cluster token authentication broker.")
 	flag.BoolVar(&enableLeaderElection, "enable-leader-election", false,
 		"Enable leader election for controller manager. "+
 			"Enabling this will ensure there is only one active controller manager.")
+	flag.StringVar(&logFormat, "log-format", "",
+		"Log format, one of 'json' or 'console'. Defaults to 'console' in DEV_MODE, 'json' otherwise.")
+	flag.StringVar(&logConfigMap, "log-config-configmap", "",
+		"The name of the ConfigMap in "+util.HypercloudNamespace+" to change log level and format at runtime. Set empty to disable.")
+	flag.IntVar(&shardCount, "shard-count", 1,
+		"The number of shards to split namespaces across operator replicas. Set 1 to disable sharding.")
+	flag.IntVar(&shardIndex, "shard-index", 0,
+		"The shard index of this replica. Set -1 to use the ordinal of the statefulset pod.")
+	flag.StringVar(&watchNamespaces, "watch-namespaces", "",
+		"Comma separated namespaces to watch. Set empty with empty selector to watch all namespaces.")
+	flag.StringVar(&watchNamespaceSelector, "watch-namespace-selector", "",
+		"Label selector of namespaces to watch. Matching namespaces are resolved at startup and the operator restarts when they change.")
 
 	DEV_MODE := os.Getenv(util.DEV_MODE)
 
@@ -81,27 +136,73 @@ func main() {
 	opts.BindFlags(flag.CommandLine)
 	flag.Parse()
 
-	ctrl.SetLogger(zap.New(zap.UseFlagOptions(&opts)))
+	logger, logSettings, err := util.NewLogger(&opts, logFormat)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	ctrl.SetLogger(logger)
+
+	shard, err := util.SetShard(shardIndex, shardCount)
+	if err != nil {
+		setupLog.Error(err, "invalid shard settings")
+		os.Exit(1)
+	}
+	if shard.Enabled() {
+		setupLog.Info("Reconcile namespaces of this shard only", "shardIndex", shard.Index, "shardCount", shard.Count)
+	}
 
 	restConfig := ctrl.GetConfigOrDie()
 
+	namespaces, err := util.GetWatchNamespaces(context.Background(), restConfig, watchNamespaces, watchNamespaceSelector)
+	if err != nil {
+		setupLog.Error(err, "unable to get namespaces to watch")
+		os.Exit(1)
+	}
+	if namespaces != nil {
+		setupLog.Info("Watch the given namespaces only", "namespaces", namespaces)
+	}
+
 	mgr, err := ctrl.NewManager(restConfig, ctrl.Options{
 		Scheme:                     scheme,
 		MetricsBindAddress:         metricsAddr,
+		HealthProbeBindAddress:     probeAddr,
 		Port:                       9443,
 		LeaderElection:             enableLeaderElection,
-		LeaderElectionID:           "86810e1d.tmax.io",
+		LeaderElectionID:           shard.LeaderElectionID("86810e1d.tmax.io"),
 		LeaderElectionResourceLock: "leases",
+		NewCache:                   newCacheFunc(namespaces),
+		// 삭제된 이전 버전의 resource 를 정리할 때만 조회하므로 cache 하지 않는다.
+		ClientDisableCacheFor: []client.Object{
+			&coreV1.Endpoints{},
+		},
 	})
-	
+
 	if err != nil {
 		setupLog.Error(err, "unable to start manager")
 		os.Exit(1)
 	}
 
+	setupIndexes(mgr)
+	// GKE, AKS cluster 의 token 을 갱신할 때 credential secret 을 조회한다.
+	util.SetCredentialsReader(mgr.GetClient())
+	setupFanOutPool()
 	setupReconcilers(mgr)
 	setupWebhooks(mgr)
 	setupChecks()
+	setupProbes(mgr)
+	setupWebhookCertMonitor(mgr)
+	setupDBHealthMonitor(mgr)
+	setupKubeconfigKeyManager(mgr)
+	setupDBWriter(mgr)
+	setupClusterEventPusher(mgr)
+	setupInventoryServer(mgr, inventoryAddr, inventoryCertDir)
+	setupProxyServer(mgr, proxyAddr, proxyCertDir)
+	setupAuthnServer(mgr, authnAddr, authnCertDir)
+	setupCatalogExporter(mgr)
+	setupLogConfigWatcher(mgr, logSettings, logConfigMap)
+	setupOperatorConfigWatcher(mgr)
+	setupWatchNamespaceMonitor(mgr, watchNamespaces, watchNamespaceSelector, namespaces)
 
 	// +kubebuilder:scaffold:builder
 
@@ -123,6 +224,51 @@ func main() {
 	setupLog.Info("Received SIGTERM, shutting down gracefully...")
 }
 
+// cluster 수가 많아져도 manager 의 메모리 사용량이 제한되도록 cache 할 object 를 제한한다.
+func cacheOptions() cache.Options {
+	hasClmName, _ := labels.NewRequirement(clusterV1alpha1.LabelKeyClmName, selection.Exists, nil)
+	argoNamespace := fields.OneTermEqualSelector("metadata.namespace", util.ArgoNamespace)
+
+	return cache.Options{
+		SelectorsByObject: cache.SelectorsByObject{
+			// member cluster 에서 가져와 기록한 event 만 조회한다.
+			&coreV1.Event{}: {
+				Label: labels.NewSelector().Add(*hasClmName),
+			},
+			// argocd 의 resource 는 argocd namespace 에만 생성한다.
+			&argocdV1alpha1.Application{}: {
+				Field: argoNamespace,
+			},
+			&argocdV1alpha1.AppProject{}: {
+				Field: argoNamespace,
+			},
+		},
+		DefaultTransform: util.StripManagedFields,
+	}
+}
+
+// watch 할 namespace 가 주어지면 namespace 별 cache 를 만들고, cluster scope 의 object 는 별도의 cache 로 조회한다.
+func newCacheFunc(namespaces []string) cache.NewCacheFunc {
+	if len(namespaces) == 0 {
+		return cache.BuilderWithOptions(cacheOptions())
+	}
+	return func(config *rest.Config, opts cache.Options) (cache.Cache, error) {
+		options := cacheOptions()
+		options.Scheme = opts.Scheme
+		options.Mapper = opts.Mapper
+		options.Resync = opts.Resync
+		return cache.MultiNamespacedCacheBuilder(namespaces)(config, options)
+	}
+}
+
+// controller 의 map function 과 phase 에서 사용하는 field index 를 등록한다.
+func setupIndexes(mgr ctrl.Manager) {
+	if err := util.SetupIndexes(context.Background(), mgr); err != nil {
+		setupLog.Error(err, "unable to set up field indexes")
+		os.Exit(1)
+	}
+}
+
 func setupReconcilers(mgr ctrl.Manager) {
 	if err := (&claimController.ClusterClaimReconciler{
 		Client: mgr.GetClient(),
@@ -134,9 +280,11 @@ func setupReconcilers(mgr ctrl.Manager) {
 	}
 
 	if err := (&clusterController.ClusterManagerReconciler{
-		Client: mgr.GetClient(),
-		Log:    ctrl.Log.WithName("controllers").WithName("ClusterManager"),
-		Scheme: mgr.GetScheme(),
+		Client:        mgr.GetClient(),
+		Log:           ctrl.Log.WithName("controllers").WithName("ClusterManager"),
+		Scheme:        mgr.GetScheme(),
+		Recorder:      mgr.GetEventRecorderFor("clustermanager-controller"),
+		RemoteClients: util.DefaultRemoteClientFactory,
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "ClusterManager")
 		os.Exit(1)
@@ -152,22 +300,320 @@ func setupReconcilers(mgr ctrl.Manager) {
 	}
 
 	if err := (&k8scontroller.SecretReconciler{
-		Client: mgr.GetClient(),
-		Log:    ctrl.Log.WithName("controller").WithName("secretController"),
-		Scheme: mgr.GetScheme(),
+		Client:        mgr.GetClient(),
+		Log:           ctrl.Log.WithName("controller").WithName("secretController"),
+		Scheme:        mgr.GetScheme(),
+		Recorder:      mgr.GetEventRecorderFor("secret-controller"),
+		RemoteClients: util.DefaultRemoteClientFactory,
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "secretController")
 		os.Exit(1)
 	}
 
 	if err := (&clusterController.ClusterRegistrationReconciler{
+		Client:        mgr.GetClient(),
+		Log:           ctrl.Log.WithName("controllers").WithName("ClusterRegistration"),
+		Scheme:        mgr.GetScheme(),
+		Recorder:      mgr.GetEventRecorderFor("clusterregistration-controller"),
+		RemoteClients: util.DefaultRemoteClientFactory,
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "ClusterRegistration")
+		os.Exit(1)
+	}
+
+	if err := (&clusterController.ClusterMemberReconciler{
+		Client:        mgr.GetClient(),
+		Log:           ctrl.Log.WithName("controllers").WithName("ClusterMember"),
+		Scheme:        mgr.GetScheme(),
+		Recorder:      mgr.GetEventRecorderFor("clustermember-controller"),
+		RemoteClients: util.DefaultRemoteClientFactory,
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "ClusterMember")
+		os.Exit(1)
+	}
+
+	if err := (&clusterController.ClusterGroupReconciler{
+		Client:   mgr.GetClient(),
+		Log:      ctrl.Log.WithName("controllers").WithName("ClusterGroup"),
+		Scheme:   mgr.GetScheme(),
+		Recorder: mgr.GetEventRecorderFor("clustergroup-controller"),
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "ClusterGroup")
+		os.Exit(1)
+	}
+	if err := (&clusterController.WorkloadDistributionReconciler{
+		Client:   mgr.GetClient(),
+		Log:      ctrl.Log.WithName("controllers").WithName("WorkloadDistribution"),
+		Scheme:   mgr.GetScheme(),
+		Recorder: mgr.GetEventRecorderFor("workloaddistribution-controller"),
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "WorkloadDistribution")
+		os.Exit(1)
+	}
+	if err := (&clusterController.ClusterPolicyReconciler{
+		Client:   mgr.GetClient(),
+		Log:      ctrl.Log.WithName("controllers").WithName("ClusterPolicy"),
+		Scheme:   mgr.GetScheme(),
+		Recorder: mgr.GetEventRecorderFor("clusterpolicy-controller"),
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "ClusterPolicy")
+		os.Exit(1)
+	}
+	if err := (&clusterController.ServiceExportReconciler{
+		Client:   mgr.GetClient(),
+		Log:      ctrl.Log.WithName("controllers").WithName("ServiceExport"),
+		Scheme:   mgr.GetScheme(),
+		Recorder: mgr.GetEventRecorderFor("serviceexport-controller"),
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "ServiceExport")
+		os.Exit(1)
+	}
+	if err := (&clusterController.ServiceImportReconciler{
+		Client:   mgr.GetClient(),
+		Log:      ctrl.Log.WithName("controllers").WithName("ServiceImport"),
+		Scheme:   mgr.GetScheme(),
+		Recorder: mgr.GetEventRecorderFor("serviceimport-controller"),
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "ServiceImport")
+		os.Exit(1)
+	}
+	if err := (&clusterController.FederatedRoleBindingReconciler{
+		Client:   mgr.GetClient(),
+		Log:      ctrl.Log.WithName("controllers").WithName("FederatedRoleBinding"),
+		Scheme:   mgr.GetScheme(),
+		Recorder: mgr.GetEventRecorderFor("federatedrolebinding-controller"),
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "FederatedRoleBinding")
+		os.Exit(1)
+	}
+	if err := (&clusterController.SecretSyncReconciler{
+		Client:   mgr.GetClient(),
+		Log:      ctrl.Log.WithName("controllers").WithName("SecretSync"),
+		Scheme:   mgr.GetScheme(),
+		Recorder: mgr.GetEventRecorderFor("secretsync-controller"),
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "SecretSync")
+		os.Exit(1)
+	}
+	if err := (&clusterController.FleetStatusReconciler{
+		Client:        mgr.GetClient(),
+		Log:           ctrl.Log.WithName("controllers").WithName("FleetStatus"),
+		Scheme:        mgr.GetScheme(),
+		Recorder:      mgr.GetEventRecorderFor("fleetstatus-controller"),
+		RemoteClients: util.DefaultRemoteClientFactory,
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "FleetStatus")
+		os.Exit(1)
+	}
+	if err := (&clusterController.NamespaceTemplateReconciler{
+		Client:   mgr.GetClient(),
+		Log:      ctrl.Log.WithName("controllers").WithName("NamespaceTemplate"),
+		Scheme:   mgr.GetScheme(),
+		Recorder: mgr.GetEventRecorderFor("namespacetemplate-controller"),
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "NamespaceTemplate")
+		os.Exit(1)
+	}
+	if err := (&clusterController.MeshFederationReconciler{
+		Client:   mgr.GetClient(),
+		Log:      ctrl.Log.WithName("controllers").WithName("MeshFederation"),
+		Scheme:   mgr.GetScheme(),
+		Recorder: mgr.GetEventRecorderFor("meshfederation-controller"),
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "MeshFederation")
+		os.Exit(1)
+	}
+	if err := (&clusterController.LicenseReconciler{
+		Client:   mgr.GetClient(),
+		Log:      ctrl.Log.WithName("controllers").WithName("License"),
+		Scheme:   mgr.GetScheme(),
+		Recorder: mgr.GetEventRecorderFor("license-controller"),
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "License")
+		os.Exit(1)
+	}
+	if err := (&clusterController.ClusterKubeconfigReconciler{
+		Client:   mgr.GetClient(),
+		Log:      ctrl.Log.WithName("controllers").WithName("ClusterKubeconfig"),
+		Scheme:   mgr.GetScheme(),
+		Recorder: mgr.GetEventRecorderFor("clusterkubeconfig-controller"),
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "ClusterKubeconfig")
+		os.Exit(1)
+	}
+	if err := (&clusterController.MemberClaimReconciler{
+		Client:   mgr.GetClient(),
+		Log:      ctrl.Log.WithName("controllers").WithName("MemberClaim"),
+		Scheme:   mgr.GetScheme(),
+		Recorder: mgr.GetEventRecorderFor("memberclaim-controller"),
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "MemberClaim")
+		os.Exit(1)
+	}
+	if err := (&clusterController.ManagementPeerReconciler{
+		Client:   mgr.GetClient(),
+		Log:      ctrl.Log.WithName("controllers").WithName("ManagementPeer"),
+		Scheme:   mgr.GetScheme(),
+		Recorder: mgr.GetEventRecorderFor("managementpeer-controller"),
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "ManagementPeer")
+		os.Exit(1)
+	}
+	if err := (&clusterController.OperatorBackupReconciler{
+		Client:   mgr.GetClient(),
+		Log:      ctrl.Log.WithName("controllers").WithName("OperatorBackup"),
+		Scheme:   mgr.GetScheme(),
+		Recorder: mgr.GetEventRecorderFor("operatorbackup-controller"),
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "OperatorBackup")
+		os.Exit(1)
+	}
+	if err := (&clusterController.OperatorRestoreReconciler{
+		Client:        mgr.GetClient(),
+		Log:           ctrl.Log.WithName("controllers").WithName("OperatorRestore"),
+		Scheme:        mgr.GetScheme(),
+		Recorder:      mgr.GetEventRecorderFor("operatorrestore-controller"),
+		RemoteClients: util.DefaultRemoteClientFactory,
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "OperatorRestore")
+		os.Exit(1)
+	}
+	if err := (&clusterController.EndpointAnnotationReconciler{
 		Client: mgr.GetClient(),
-		Log:    ctrl.Log.WithName("controllers").WithName("ClusterRegistration"),
+		Log:    ctrl.Log.WithName("controllers").WithName("EndpointAnnotation"),
 		Scheme: mgr.GetScheme(),
 	}).SetupWithManager(mgr); err != nil {
-		setupLog.Error(err, "unable to create controller", "controller", "ClusterRegistration")
+		setupLog.Error(err, "unable to create controller", "controller", "EndpointAnnotation")
+		os.Exit(1)
+	}
+	heartbeatStaleThreshold, err := util.GetDurationEnv(util.HEARTBEAT_STALE_THRESHOLD)
+	if err != nil {
+		setupLog.Error(err, "invalid environment variable", "env", util.HEARTBEAT_STALE_THRESHOLD)
+		os.Exit(1)
+	}
+	if err := (&clusterController.HeartbeatReconciler{
+		Client:         mgr.GetClient(),
+		Log:            ctrl.Log.WithName("controllers").WithName("Heartbeat"),
+		Scheme:         mgr.GetScheme(),
+		Recorder:       mgr.GetEventRecorderFor("heartbeat-controller"),
+		StaleThreshold: heartbeatStaleThreshold,
+		RemoteClients:  util.DefaultRemoteClientFactory,
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "Heartbeat")
+		os.Exit(1)
+	}
+	if err := (&clusterController.DeprecatedAPIReconciler{
+		Client:   mgr.GetClient(),
+		Log:      ctrl.Log.WithName("controllers").WithName("DeprecatedAPI"),
+		Scheme:   mgr.GetScheme(),
+		Recorder: mgr.GetEventRecorderFor("deprecatedapi-controller"),
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "DeprecatedAPI")
 		os.Exit(1)
 	}
+	if err := (&clusterController.CertificateInventoryReconciler{
+		Client:   mgr.GetClient(),
+		Log:      ctrl.Log.WithName("controllers").WithName("CertificateInventory"),
+		Scheme:   mgr.GetScheme(),
+		Recorder: mgr.GetEventRecorderFor("certificateinventory-controller"),
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "CertificateInventory")
+		os.Exit(1)
+	}
+	if err := (&clusterController.AddonHealthReconciler{
+		Client:   mgr.GetClient(),
+		Log:      ctrl.Log.WithName("controllers").WithName("AddonHealth"),
+		Scheme:   mgr.GetScheme(),
+		Recorder: mgr.GetEventRecorderFor("addonhealth-controller"),
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "AddonHealth")
+		os.Exit(1)
+	}
+	if err := (&clusterController.ClusterRolloutReconciler{
+		Client:   mgr.GetClient(),
+		Log:      ctrl.Log.WithName("controllers").WithName("ClusterRollout"),
+		Scheme:   mgr.GetScheme(),
+		Recorder: mgr.GetEventRecorderFor("clusterrollout-controller"),
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "ClusterRollout")
+		os.Exit(1)
+	}
+	if err := (&clusterController.ClusterImportSessionReconciler{
+		Client:        mgr.GetClient(),
+		Log:           ctrl.Log.WithName("controllers").WithName("ClusterImportSession"),
+		Scheme:        mgr.GetScheme(),
+		Recorder:      mgr.GetEventRecorderFor("clusterimportsession-controller"),
+		RemoteClients: util.DefaultRemoteClientFactory,
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "ClusterImportSession")
+		os.Exit(1)
+	}
+	if err := (&clusterController.PendingDBWriteReconciler{
+		Client:   mgr.GetClient(),
+		Log:      ctrl.Log.WithName("controllers").WithName("PendingDBWrite"),
+		Scheme:   mgr.GetScheme(),
+		Recorder: mgr.GetEventRecorderFor("pendingdbwrite-controller"),
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "PendingDBWrite")
+		os.Exit(1)
+	}
+	if err := (&clusterController.RemoteGCReconciler{
+		Client:   mgr.GetClient(),
+		Log:      ctrl.Log.WithName("controllers").WithName("RemoteGC"),
+		Scheme:   mgr.GetScheme(),
+		Recorder: mgr.GetEventRecorderFor("remotegc-controller"),
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "RemoteGC")
+		os.Exit(1)
+	}
+	if pricingConfigMap := os.Getenv(util.COST_PRICING_CONFIGMAP); pricingConfigMap != "" {
+		if err := (&clusterController.ClusterCostReconciler{
+			Client:           mgr.GetClient(),
+			Reader:           mgr.GetAPIReader(),
+			Log:              ctrl.Log.WithName("controllers").WithName("ClusterCost"),
+			Scheme:           mgr.GetScheme(),
+			PricingConfigMap: pricingConfigMap,
+		}).SetupWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create controller", "controller", "ClusterCost")
+			os.Exit(1)
+		}
+	}
+	if importNamespace := os.Getenv(util.ARGOCD_IMPORT_NAMESPACE); importNamespace != "" {
+		if err := (&clusterController.ArgocdImportReconciler{
+			Client:    mgr.GetClient(),
+			Log:       ctrl.Log.WithName("controllers").WithName("ArgocdImport"),
+			Scheme:    mgr.GetScheme(),
+			Recorder:  mgr.GetEventRecorderFor("argocdimport-controller"),
+			Namespace: importNamespace,
+			Owner:     os.Getenv(util.ARGOCD_IMPORT_OWNER),
+		}).SetupWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create controller", "controller", "ArgocdImport")
+			os.Exit(1)
+		}
+	}
+	if util.IsTrue(os.Getenv(util.REMOTE_EVENT_MIRROR)) {
+		if err := (&clusterController.RemoteEventReconciler{
+			Client:   mgr.GetClient(),
+			Log:      ctrl.Log.WithName("controllers").WithName("RemoteEvent"),
+			Scheme:   mgr.GetScheme(),
+			Recorder: mgr.GetEventRecorderFor("remoteevent-controller"),
+		}).SetupWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create controller", "controller", "RemoteEvent")
+			os.Exit(1)
+		}
+	}
+	if mode := os.Getenv(util.HUB_ATTACH_MODE); mode == util.HubAttachModeKarmada || mode == util.HubAttachModeOCM {
+		if err := (&clusterController.HubAttachmentReconciler{
+			Client:   mgr.GetClient(),
+			Log:      ctrl.Log.WithName("controllers").WithName("HubAttachment"),
+			Scheme:   mgr.GetScheme(),
+			Recorder: mgr.GetEventRecorderFor("hubattachment-controller"),
+			Mode:     mode,
+		}).SetupWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create controller", "controller", "HubAttachment")
+			os.Exit(1)
+		}
+	}
 }
 
 func setupWebhooks(mgr ctrl.Manager) {
@@ -181,6 +627,11 @@ func setupWebhooks(mgr ctrl.Manager) {
 		os.Exit(1)
 	}
 
+	if err := claimV1alpha1.SetupClaimReviewWebhookWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create webhook", "webhook", "ClaimReview")
+		os.Exit(1)
+	}
+
 	if err := (&clusterV1alpha1.ClusterManager{}).SetupWebhookWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create webhook", "webhook", "ClusterManager")
 		os.Exit(1)
@@ -191,6 +642,46 @@ func setupWebhooks(mgr ctrl.Manager) {
 		os.Exit(1)
 	}
 
+	if err := clusterV1alpha1.SetupClusterKubeconfigWebhookWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create webhook", "webhook", "ClusterKubeconfig")
+		os.Exit(1)
+	}
+
+	if err := clusterV1alpha1.SetupEntitlementWebhookWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create webhook", "webhook", "Entitlement")
+		os.Exit(1)
+	}
+
+	if err := clusterV1alpha1.SetupMemberClaimWebhookWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create webhook", "webhook", "MemberClaim")
+		os.Exit(1)
+	}
+
+	registrationFailureLimit, err := util.GetIntEnv(util.REGISTRATION_FAILURE_LIMIT)
+	if err != nil {
+		setupLog.Error(err, "invalid environment variable", "env", util.REGISTRATION_FAILURE_LIMIT)
+		os.Exit(1)
+	}
+	registrationFailureWindow, err := util.GetDurationEnv(util.REGISTRATION_FAILURE_WINDOW)
+	if err != nil {
+		setupLog.Error(err, "invalid environment variable", "env", util.REGISTRATION_FAILURE_WINDOW)
+		os.Exit(1)
+	}
+	if err := clusterV1alpha1.SetupRegistrationThrottleWebhookWithManager(mgr, registrationFailureLimit, registrationFailureWindow); err != nil {
+		setupLog.Error(err, "unable to create webhook", "webhook", "RegistrationThrottle")
+		os.Exit(1)
+	}
+
+	if err := clusterV1alpha1.SetupAirGapWebhookWithManager(mgr, util.GetAirGapPolicy); err != nil {
+		setupLog.Error(err, "unable to create webhook", "webhook", "AirGap")
+		os.Exit(1)
+	}
+
+	if err := clusterV1alpha1.SetupCapacityWebhookWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create webhook", "webhook", "Capacity")
+		os.Exit(1)
+	}
+
 }
 
 func setupChecks() {
@@ -199,3 +690,268 @@ func setupChecks() {
 		os.Exit(1)
 	}
 }
+
+// webhook server 는 start 될 때 기본값을 설정하므로 같은 기본값으로 cert 경로와 port 를 계산한다.
+func webhookServerSettings(mgr ctrl.Manager) (certDir, certName, keyName string, port int) {
+	webhookServer := mgr.GetWebhookServer()
+	certDir = webhookServer.CertDir
+	if certDir == "" {
+		certDir = filepath.Join(os.TempDir(), "k8s-webhook-server", "serving-certs")
+	}
+	certName = webhookServer.CertName
+	if certName == "" {
+		certName = "tls.crt"
+	}
+	keyName = webhookServer.KeyName
+	if keyName == "" {
+		keyName = "tls.key"
+	}
+	port = webhookServer.Port
+	if port <= 0 {
+		port = webhook.DefaultPort
+	}
+	return certDir, certName, keyName, port
+}
+
+func setupProbes(mgr ctrl.Manager) {
+	certDir, certName, keyName, port := webhookServerSettings(mgr)
+
+	healthzChecks := map[string]healthz.Checker{
+		"healthz": healthz.Ping,
+		// webhook server 가 응답하지 않으면 재시작한다.
+		"webhook": util.WebhookServerChecker("127.0.0.1", port),
+	}
+	for name, check := range healthzChecks {
+		if err := mgr.AddHealthzCheck(name, check); err != nil {
+			setupLog.Error(err, "unable to set up health check", "check", name)
+			os.Exit(1)
+		}
+	}
+
+	readyzChecks := map[string]healthz.Checker{
+		"webhookcert": util.WebhookCertChecker(certDir, certName, keyName),
+		// cache 가 아닌 api server 에서 직접 조회하여 CRD 와 권한이 유효한지 확인한다.
+		"crds": util.CRDListChecker(
+			mgr.GetAPIReader(),
+			&clusterV1alpha1.ClusterManagerList{},
+			&clusterV1alpha1.ClusterRegistrationList{},
+			&claimV1alpha1.ClusterClaimList{},
+			&claimV1alpha1.ClusterUpdateClaimList{},
+			&clusterV1beta1.ClusterList{},
+		),
+	}
+	for name, check := range readyzChecks {
+		if err := mgr.AddReadyzCheck(name, check); err != nil {
+			setupLog.Error(err, "unable to set up ready check", "check", name)
+			os.Exit(1)
+		}
+	}
+}
+
+func setupWebhookCertMonitor(mgr ctrl.Manager) {
+	warningDays, err := util.GetIntEnv(util.WEBHOOK_CERT_EXPIRY_WARNING_DAYS)
+	if err != nil {
+		setupLog.Error(err, "invalid environment variable", "env", util.WEBHOOK_CERT_EXPIRY_WARNING_DAYS)
+		os.Exit(1)
+	}
+	certDir, certName, keyName, _ := webhookServerSettings(mgr)
+	monitor := &util.WebhookCertMonitor{
+		CertDir:     certDir,
+		CertName:    certName,
+		KeyName:     keyName,
+		WarningDays: warningDays,
+		Recorder:    mgr.GetEventRecorderFor("webhookcert-monitor"),
+		Log:         ctrl.Log.WithName("webhookcert"),
+	}
+	if err := mgr.Add(monitor); err != nil {
+		setupLog.Error(err, "unable to set up webhook cert monitor")
+		os.Exit(1)
+	}
+}
+
+// db 의 상태는 pod 의 readiness 가 아닌 metric 과 metrics server 의 별도 readiness endpoint 로 노출한다.
+func setupDBHealthMonitor(mgr ctrl.Manager) {
+	monitor := &util.DBHealthMonitor{
+		Log: ctrl.Log.WithName("dbhealth"),
+	}
+	if err := mgr.Add(monitor); err != nil {
+		setupLog.Error(err, "unable to set up db health monitor")
+		os.Exit(1)
+	}
+	handler := &healthz.Handler{Checks: map[string]healthz.Checker{"db": monitor.Checker}}
+	if err := mgr.AddMetricsExtraHandler(util.DBReadyzPath, http.StripPrefix(util.DBReadyzPath, handler)); err != nil {
+		setupLog.Error(err, "unable to set up db ready check")
+		os.Exit(1)
+	}
+}
+
+// ClusterRegistration 에 암호화하여 제출한 kubeconfig 를 복호화하는 key 를 준비한다.
+func setupKubeconfigKeyManager(mgr ctrl.Manager) {
+	keyManager := &util.KubeconfigKeyManager{
+		Reader: mgr.GetAPIReader(),
+		Client: mgr.GetClient(),
+		Log:    ctrl.Log.WithName("kubeconfigkey"),
+	}
+	if err := mgr.Add(keyManager); err != nil {
+		setupLog.Error(err, "unable to set up kubeconfig key manager")
+		os.Exit(1)
+	}
+}
+
+// fleet 전체에 적용하는 변경을 member cluster 에 적용하는 작업의 동시 실행 수, 속도, batch 크기를 설정한다.
+func setupFanOutPool() {
+	settings := map[string]int{}
+	for _, env := range []string{util.FANOUT_CONCURRENCY, util.FANOUT_QPS, util.FANOUT_BATCH_SIZE} {
+		value, err := util.GetIntEnv(env)
+		if err != nil {
+			setupLog.Error(err, "invalid environment variable", "env", env)
+			os.Exit(1)
+		}
+		settings[env] = value
+	}
+	util.SetFanOutPool(util.NewFanOutPool(
+		settings[util.FANOUT_CONCURRENCY],
+		settings[util.FANOUT_QPS],
+		settings[util.FANOUT_BATCH_SIZE],
+	))
+}
+
+func setupDBWriter(mgr ctrl.Manager) {
+	flushInterval, err := util.GetDurationEnv(util.DB_FLUSH_INTERVAL)
+	if err != nil {
+		setupLog.Error(err, "invalid environment variable", "env", util.DB_FLUSH_INTERVAL)
+		os.Exit(1)
+	}
+	writer := util.NewDBWriter(flushInterval, ctrl.Log.WithName("dbwriter"))
+	if err := mgr.Add(writer); err != nil {
+		setupLog.Error(err, "unable to set up db writer")
+		os.Exit(1)
+	}
+}
+
+func setupClusterEventPusher(mgr ctrl.Manager) {
+	pusher := util.NewClusterEventPusher(ctrl.Log.WithName("clusterevent"))
+	if err := mgr.Add(pusher); err != nil {
+		setupLog.Error(err, "unable to set up cluster event pusher")
+		os.Exit(1)
+	}
+}
+
+func setupLogConfigWatcher(mgr ctrl.Manager, settings *util.LogSettings, name string) {
+	if name == "" {
+		return
+	}
+	watcher := &util.LogConfigWatcher{
+		Reader:   mgr.GetAPIReader(),
+		Log:      ctrl.Log.WithName("logconfig"),
+		Settings: settings,
+		Key: types.NamespacedName{
+			Name:      name,
+			Namespace: util.HypercloudNamespace,
+		},
+	}
+	if err := mgr.Add(watcher); err != nil {
+		setupLog.Error(err, "unable to set up log config watcher")
+		os.Exit(1)
+	}
+}
+
+// selector 에 해당하는 namespace 가 바뀌면 operator 를 재시작하여 cache 에 반영한다.
+func setupWatchNamespaceMonitor(mgr ctrl.Manager, namespaces, selector string, current []string) {
+	if strings.TrimSpace(selector) == "" {
+		return
+	}
+	monitor := &util.WatchNamespaceMonitor{
+		Reader:     mgr.GetAPIReader(),
+		Namespaces: namespaces,
+		Selector:   selector,
+		Current:    current,
+		Log:        ctrl.Log.WithName("watchnamespace"),
+	}
+	if err := mgr.Add(monitor); err != nil {
+		setupLog.Error(err, "unable to set up watch namespace monitor")
+		os.Exit(1)
+	}
+}
+
+func setupOperatorConfigWatcher(mgr ctrl.Manager) {
+	watcher := &util.OperatorConfigWatcher{
+		Reader: mgr.GetAPIReader(),
+		Client: mgr.GetClient(),
+		Log:    ctrl.Log.WithName("operatorconfig"),
+	}
+	if err := mgr.Add(watcher); err != nil {
+		setupLog.Error(err, "unable to set up operator config watcher")
+		os.Exit(1)
+	}
+}
+
+func setupInventoryServer(mgr ctrl.Manager, bindAddress, certDir string) {
+	if bindAddress == "" || bindAddress == "0" {
+		return
+	}
+	server := &inventory.Server{
+		Client:      mgr.GetClient(),
+		Cache:       mgr.GetCache(),
+		Log:         ctrl.Log.WithName("inventory"),
+		BindAddress: bindAddress,
+		CertDir:     certDir,
+	}
+	if err := mgr.Add(server); err != nil {
+		setupLog.Error(err, "unable to set up inventory server")
+		os.Exit(1)
+	}
+}
+
+func setupProxyServer(mgr ctrl.Manager, bindAddress, certDir string) {
+	if bindAddress == "" || bindAddress == "0" {
+		return
+	}
+	server := &proxy.Server{
+		Client:      mgr.GetClient(),
+		Log:         ctrl.Log.WithName("proxy"),
+		BindAddress: bindAddress,
+		CertDir:     certDir,
+	}
+	if err := mgr.Add(server); err != nil {
+		setupLog.Error(err, "unable to set up proxy server")
+		os.Exit(1)
+	}
+}
+
+func setupAuthnServer(mgr ctrl.Manager, bindAddress, certDir string) {
+	if bindAddress == "" || bindAddress == "0" {
+		return
+	}
+	server := &authn.Server{
+		Client:      mgr.GetClient(),
+		Log:         ctrl.Log.WithName("authn"),
+		BindAddress: bindAddress,
+		CertDir:     certDir,
+	}
+	if err := mgr.Add(server); err != nil {
+		setupLog.Error(err, "unable to set up authn broker")
+		os.Exit(1)
+	}
+}
+
+func setupCatalogExporter(mgr ctrl.Manager) {
+	interval, err := util.GetDurationEnv(util.CATALOG_EXPORT_INTERVAL)
+	if err != nil {
+		setupLog.Error(err, "invalid environment variable", "env", util.CATALOG_EXPORT_INTERVAL)
+		os.Exit(1)
+	}
+	if interval <= 0 {
+		return
+	}
+	exporter := &inventory.CatalogExporter{
+		Client:   mgr.GetClient(),
+		Reader:   mgr.GetAPIReader(),
+		Log:      ctrl.Log.WithName("catalog"),
+		Interval: interval,
+	}
+	if err := mgr.Add(exporter); err != nil {
+		setupLog.Error(err, "unable to set up catalog exporter")
+		os.Exit(1)
+	}
+}